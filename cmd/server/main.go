@@ -17,11 +17,13 @@ Date: 2025-10-31
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 
 	"resource-share-site/internal/config"
+	"resource-share-site/internal/database"
 	"resource-share-site/internal/handler"
 	"resource-share-site/internal/middleware"
 	"resource-share-site/internal/model"
@@ -42,10 +44,28 @@ func main() {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 
-	// 3. 自动迁移数据表
+	// 3. 自动迁移数据表（升级前的用户需在新增 email_verified 列之前识别）
+	legacyUsers := database.NeedsEmailVerifiedBackfill(db)
 	if err := migrateDatabase(db); err != nil {
 		log.Fatalf("数据库迁移失败: %v", err)
 	}
+	if legacyUsers {
+		if err := database.BackfillEmailVerified(db); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+	}
+	if err := database.MigrateLegacyTags(db); err != nil {
+		log.Fatalf("数据库迁移失败: %v", err)
+	}
+	if err := database.MigrateSlugs(db); err != nil {
+		log.Fatalf("数据库迁移失败: %v", err)
+	}
+	if err := database.MigrateArticleContent(db); err != nil {
+		log.Fatalf("数据库迁移失败: %v", err)
+	}
+	if err := database.SeedModerationRules(db); err != nil {
+		log.Fatalf("数据库迁移失败: %v", err)
+	}
 
 	// 4. 初始化Gin
 	gin.SetMode(gin.ReleaseMode)
@@ -56,14 +76,34 @@ func main() {
 	// 5. 添加中间件
 	middleware.RegisterMiddlewares(router)
 
-	// 6. 创建HTTP处理器
-	h := handler.NewHandler(db)
+	// 6. 加载应用配置（失败时使用默认配置）
+	appConfig, err := config.LoadConfigFromFileOrEnv(config.GetConfigFilePath())
+	if err != nil {
+		log.Printf("加载配置失败，使用默认配置: %v", err)
+		appConfig = nil
+	}
+
+	// 创建HTTP处理器
+	h := handler.NewHandler(db, appConfig)
+
+	// 连接Redis（可选，不可用时热点对象的点赞数直接写数据库）
+	if appConfig != nil && appConfig.Redis != nil {
+		redisClient, err := config.InitRedisClient(appConfig.Redis)
+		if err != nil {
+			log.Printf("Redis不可用，点赞数不做缓冲: %v", err)
+		} else {
+			h.SetRedis(redisClient)
+		}
+	}
 
 	// 7. 注册所有路由
 	h.RegisterRoutes(router)
 
+	// 启动后台定时任务
+	h.StartBackgroundJobs(context.Background())
+
 	// 8. 设置路由
-	setupRoutes(router)
+	setupRoutes(router, h)
 
 	// 9. 获取端口
 	port := os.Getenv("PORT")
@@ -76,7 +116,7 @@ func main() {
 	log.Printf("📍 访问地址: http://localhost:%s", port)
 	log.Printf("📖 API文档: http://localhost:%s/", port)
 	log.Printf("🏥 健康检查: http://localhost:%s/health", port)
-	log.Printf("🗺️  Sitemap: http://localhost:%s/seo/sitemap.xml", port)
+	log.Printf("🗺️  Sitemap: http://localhost:%s/sitemap_index.xml", port)
 	log.Printf("=====================================")
 
 	if err := router.Run(":" + port); err != nil {
@@ -90,6 +130,12 @@ func migrateDatabase(db *gorm.DB) error {
 	return db.AutoMigrate(
 		// 用户相关
 		&model.User{},
+		&model.UserToken{},
+		&model.LoginHistory{},
+		&model.UserTwoFactor{},
+		&model.RecoveryCode{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
 
 		// 邀请相关
 		&model.Invitation{},
@@ -99,11 +145,39 @@ func migrateDatabase(db *gorm.DB) error {
 
 		// 资源相关
 		&model.Resource{},
+		&model.ReviewLog{},
+		&model.ModerationRule{},
+		&model.ReviewClaim{},
+		&model.RejectReasonTemplate{},
+		&model.ResourceRevision{},
 		&model.Comment{},
+		&model.SensitiveWord{},
+
+		// 标签相关
+		&model.Tag{},
+		&model.ResourceTag{},
+		&model.ArticleTag{},
+
+		// 下载记录与推荐相关
+		&model.DownloadRecord{},
+		&model.ResourceSimilarity{},
+
+		// 收藏与合集相关
+		&model.Favorite{},
+		&model.Collection{},
+		&model.CollectionItem{},
+		&model.CollectionFollow{},
+
+		// 资源评分相关
+		&model.ResourceRating{},
+
+		// 点赞与表态相关
+		&model.Reaction{},
 
 		// 文章博客相关
 		&model.Article{},
 		&model.ArticleComment{},
+		&model.ArticleRevision{},
 
 		// 积分相关
 		&model.PointsRule{},
@@ -113,14 +187,23 @@ func migrateDatabase(db *gorm.DB) error {
 		&model.Product{},
 		&model.MallOrder{},
 
+		// 通知相关
+		&model.Notification{},
+		&model.NotificationPreference{},
+
 		// SEO相关
 		&model.SEOConfig{},
 		&model.MetaTag{},
 		&model.SitemapUrl{},
 		&model.SEOKeyword{},
 		&model.SEORank{},
+		&model.SEORankImport{},
 		&model.SEOReport{},
+		&model.SEOAuditPage{},
+		&model.SEOAuditFinding{},
 		&model.SEOEvent{},
+		&model.SEOIndexSubmission{},
+		&model.SEORedirect{},
 
 		// 其他
 		&model.Ad{},
@@ -130,13 +213,13 @@ func migrateDatabase(db *gorm.DB) error {
 }
 
 // setupRoutes 设置静态路由和错误处理
-func setupRoutes(router *gin.Engine) {
+func setupRoutes(router *gin.Engine, h *handler.Handler) {
 	// 静态文件服务
 	router.Static("/static", "./web/static")
 	router.StaticFS("/uploads", http.Dir("./uploads"))
 
-	// 全局错误处理
-	router.NoRoute(func(c *gin.Context) {
+	// 全局错误处理（未匹配的路径先查找URL跳转规则）
+	router.NoRoute(h.RedirectNotFound, func(c *gin.Context) {
 		c.JSON(404, gin.H{
 			"error":   "404 - 页面未找到",
 			"message": "请检查您的请求路径是否正确",
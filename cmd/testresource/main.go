@@ -209,14 +209,16 @@ func testResourceUpload(db *gorm.DB, resourceService *resource.ResourceService,
 	fmt.Printf("  ✓ 上传资源3成功: ID=%d, 标题=%s\n", resource3.ID, resource3.Title)
 
 	// 更新资源
-	updated, err := resourceService.UpdateResource(
+	updated, _, err := resourceService.UpdateResource(
 		resource1.ID,
+		user1.ID,
 		"Go语言入门教程（更新版）",
 		"更新的Go语言学习教程，包含最新特性",
 		cat1.ID,
 		"https://example.com/go-tutorial-updated",
 		60,
 		`["Go", "教程", "编程", "更新"]`,
+		"补充最新特性",
 	)
 	if err != nil {
 		log.Printf("更新资源1失败: %v", err)
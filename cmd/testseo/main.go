@@ -532,7 +532,7 @@ func testManagementService(db *gorm.DB) error {
 
 	// 6. 测试分析关键词表现
 	fmt.Println("\n6. 测试分析关键词表现:")
-	analysis, err := managementService.AnalyzeKeywordPerformance(1, 30)
+	analysis, err := managementService.AnalyzeKeywordPerformance(1, "", 30)
 	if err != nil {
 		fmt.Printf("   ❌ 分析关键词表现失败: %v\n", err)
 	} else {
@@ -0,0 +1,74 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// AuthConfig 账户认证配置结构
+type AuthConfig struct {
+	SiteURL            string `mapstructure:"site_url" json:"site_url"`                       // 站点地址（用于生成邮件链接）
+	EmailVerifyTTL     int    `mapstructure:"email_verify_ttl" json:"email_verify_ttl"`       // 邮箱验证链接有效期(小时)
+	PasswordResetTTL   int    `mapstructure:"password_reset_ttl" json:"password_reset_ttl"`   // 密码重置链接有效期(分钟)
+	EmailChangeTTL     int    `mapstructure:"email_change_ttl" json:"email_change_ttl"`       // 修改邮箱确认链接有效期(小时)
+	RestrictUnverified bool   `mapstructure:"restrict_unverified" json:"restrict_unverified"` // 限制未验证邮箱的用户上传和获取积分
+
+	// 登录保护
+	LoginFailureWindow int `mapstructure:"login_failure_window" json:"login_failure_window"`   // 失败次数统计窗口(分钟)
+	LoginDelayAfter    int `mapstructure:"login_delay_after" json:"login_delay_after"`         // 连续失败多少次后开始递增等待
+	MaxLoginFailures   int `mapstructure:"max_login_failures" json:"max_login_failures"`       // 单账户最大失败次数，超过后锁定
+	MaxIPLoginFailures int `mapstructure:"max_ip_login_failures" json:"max_ip_login_failures"` // 单IP最大失败次数，超过后锁定
+	LoginLockDuration  int `mapstructure:"login_lock_duration" json:"login_lock_duration"`     // 锁定时长(分钟)
+
+	// 两步验证
+	TwoFactorIssuer     string `mapstructure:"two_factor_issuer" json:"two_factor_issuer"`           // 认证器App中显示的发行方名称
+	ForceAdminTwoFactor bool   `mapstructure:"force_admin_two_factor" json:"force_admin_two_factor"` // 管理员必须启用两步验证
+}
+
+// DefaultAuthConfig 默认账户认证配置
+func DefaultAuthConfig() *AuthConfig {
+	return &AuthConfig{
+		SiteURL:          "http://localhost:8080",
+		EmailVerifyTTL:   48,
+		PasswordResetTTL: 30,
+		EmailChangeTTL:   24,
+
+		LoginFailureWindow: 15,
+		LoginDelayAfter:    3,
+		MaxLoginFailures:   5,
+		MaxIPLoginFailures: 20,
+		LoginLockDuration:  15,
+
+		TwoFactorIssuer:     "ResourceShareSite",
+		ForceAdminTwoFactor: true,
+	}
+}
+
+// GetEmailVerifyTTL 获取邮箱验证链接有效期
+func (c *AuthConfig) GetEmailVerifyTTL() time.Duration {
+	return time.Duration(c.EmailVerifyTTL) * time.Hour
+}
+
+// GetPasswordResetTTL 获取密码重置链接有效期
+func (c *AuthConfig) GetPasswordResetTTL() time.Duration {
+	return time.Duration(c.PasswordResetTTL) * time.Minute
+}
+
+// GetEmailChangeTTL 获取修改邮箱确认链接有效期
+func (c *AuthConfig) GetEmailChangeTTL() time.Duration {
+	return time.Duration(c.EmailChangeTTL) * time.Hour
+}
+
+// GetLoginFailureWindow 获取登录失败次数统计窗口
+func (c *AuthConfig) GetLoginFailureWindow() time.Duration {
+	return time.Duration(c.LoginFailureWindow) * time.Minute
+}
+
+// GetLoginLockDuration 获取登录锁定时长
+func (c *AuthConfig) GetLoginLockDuration() time.Duration {
+	return time.Duration(c.LoginLockDuration) * time.Minute
+}
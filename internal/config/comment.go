@@ -0,0 +1,24 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+// CommentConfig 评论审核配置结构
+type CommentConfig struct {
+	AutoApproveTrusted bool    `mapstructure:"auto_approve_trusted" json:"auto_approve_trusted"` // 可信用户的评论是否自动通过
+	TrustedMinApproved int     `mapstructure:"trusted_min_approved" json:"trusted_min_approved"` // 可信用户至少需要的已通过评论数
+	TrustedMinRatio    float64 `mapstructure:"trusted_min_ratio" json:"trusted_min_ratio"`       // 可信用户的最低评论通过率(0-1)
+}
+
+// DefaultCommentConfig 默认评论审核配置
+func DefaultCommentConfig() *CommentConfig {
+	return &CommentConfig{
+		AutoApproveTrusted: true,
+		TrustedMinApproved: 5,
+		TrustedMinRatio:    0.95,
+	}
+}
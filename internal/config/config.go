@@ -27,6 +27,48 @@ type AppConfig struct {
 
 	// 日志配置
 	Log *LogConfig `mapstructure:"log"`
+
+	// 邮件配置
+	Mail *MailConfig `mapstructure:"mail"`
+
+	// 通知配置
+	Notification *NotificationConfig `mapstructure:"notification"`
+
+	// 账户认证配置
+	Auth *AuthConfig `mapstructure:"auth"`
+
+	// 第三方登录配置
+	OAuth *OAuthConfig `mapstructure:"oauth"`
+
+	// 人工审核队列配置
+	Review *ReviewConfig `mapstructure:"review"`
+
+	// 评论审核配置
+	Comment *CommentConfig `mapstructure:"comment"`
+
+	// 敏感词过滤配置
+	Filter *FilterConfig `mapstructure:"filter"`
+
+	// 相关资源推荐配置
+	Recommendation *RecommendationConfig `mapstructure:"recommendation"`
+
+	// 资源评分配置
+	Rating *RatingConfig `mapstructure:"rating"`
+
+	// 点赞与表态配置
+	Reaction *ReactionConfig `mapstructure:"reaction"`
+
+	// 搜索引擎优化配置
+	SEO *SEOConfig `mapstructure:"seo"`
+
+	// 搜索引擎URL推送配置
+	Indexing *IndexingConfig `mapstructure:"indexing"`
+
+	// 订阅源配置
+	Feed *FeedConfig `mapstructure:"feed"`
+
+	// 正文渲染配置
+	Content *ContentConfig `mapstructure:"content"`
 }
 
 // AppSettings 应用设置
@@ -186,6 +228,120 @@ func setDefaultConfig(v *viper.Viper) {
 	v.SetDefault("log.max_size", 100)
 	v.SetDefault("log.max_age", 30)
 	v.SetDefault("log.max_backups", 10)
+
+	// 邮件默认配置
+	v.SetDefault("mail.driver", "smtp")
+	v.SetDefault("mail.host", "localhost")
+	v.SetDefault("mail.port", 1025)
+	v.SetDefault("mail.from", "noreply@example.com")
+	v.SetDefault("mail.from_name", "资源分享网站")
+	v.SetDefault("mail.file_dir", "storage/mails")
+	v.SetDefault("mail.timeout", 10)
+
+	// 通知默认配置
+	v.SetDefault("notification.email_enabled", true)
+	v.SetDefault("notification.webhook_enabled", true)
+	v.SetDefault("notification.webhook_timeout", 5)
+	v.SetDefault("notification.queue_size", 1000)
+	v.SetDefault("notification.workers", 2)
+
+	// 账户认证默认配置
+	v.SetDefault("auth.site_url", "http://localhost:8080")
+	v.SetDefault("auth.email_verify_ttl", 48)
+	v.SetDefault("auth.password_reset_ttl", 30)
+	v.SetDefault("auth.email_change_ttl", 24)
+	v.SetDefault("auth.restrict_unverified", false)
+	v.SetDefault("auth.login_failure_window", 15)
+	v.SetDefault("auth.login_delay_after", 3)
+	v.SetDefault("auth.max_login_failures", 5)
+	v.SetDefault("auth.max_ip_login_failures", 20)
+	v.SetDefault("auth.login_lock_duration", 15)
+	v.SetDefault("auth.two_factor_issuer", "ResourceShareSite")
+	v.SetDefault("auth.force_admin_two_factor", true)
+
+	// 第三方登录默认配置
+	v.SetDefault("oauth.state_ttl", 10)
+
+	// 人工审核队列默认配置
+	v.SetDefault("review.claim_ttl", 15)
+	v.SetDefault("review.sla_hours", 24)
+	v.SetDefault("review.trusted_min_reviews", 5)
+	v.SetDefault("review.trusted_min_ratio", 0.9)
+
+	// 评论审核默认配置
+	v.SetDefault("comment.auto_approve_trusted", true)
+	v.SetDefault("comment.trusted_min_approved", 5)
+	v.SetDefault("comment.trusted_min_ratio", 0.95)
+
+	// 敏感词过滤默认配置
+	v.SetDefault("filter.refresh", 60)
+	v.SetDefault("filter.block_severity", 3)
+	v.SetDefault("filter.review_severity", 2)
+
+	// 相关资源推荐默认配置
+	v.SetDefault("recommendation.refresh", 60)
+	v.SetDefault("recommendation.max_related", 20)
+	v.SetDefault("recommendation.min_score", 0.05)
+	v.SetDefault("recommendation.max_user_history", 100)
+	v.SetDefault("recommendation.co_download_weight", 0.5)
+	v.SetDefault("recommendation.tag_weight", 0.3)
+	v.SetDefault("recommendation.title_weight", 0.2)
+
+	// 资源评分默认配置
+	v.SetDefault("rating.prior_mean", 3.0)
+	v.SetDefault("rating.prior_weight", 5)
+	v.SetDefault("rating.require_download", true)
+	v.SetDefault("rating.max_content_length", 1000)
+	v.SetDefault("rating.alert_threshold", 2.0)
+	v.SetDefault("rating.alert_min_ratings", 5)
+	v.SetDefault("rating.alert_cooldown", 24)
+
+	// 点赞与表态默认配置
+	v.SetDefault("reaction.hot_threshold", 30)
+	v.SetDefault("reaction.hot_window", 60)
+	v.SetDefault("reaction.flush_interval", 10)
+
+	// 搜索引擎优化默认配置
+	v.SetDefault("seo.site_url", "")
+	v.SetDefault("seo.site_name", "资源分享网站")
+	v.SetDefault("seo.sitemap_shard_size", MaxSitemapURLs)
+	v.SetDefault("seo.sitemap_gzip", true)
+	v.SetDefault("seo.sitemap_cache_ttl", 60)
+	v.SetDefault("seo.audit_max_pages", 200)
+	v.SetDefault("seo.audit_slow_ms", 1000)
+	v.SetDefault("seo.audit_excludes", []string{"/api/", "/admin", "/auth/"})
+	v.SetDefault("seo.rank_import_max_rows", 50000)
+	v.SetDefault("seo.rank_drop_threshold", 10)
+
+	// 搜索引擎URL推送默认配置
+	v.SetDefault("indexing.indexnow_endpoint", "https://api.indexnow.org/indexnow")
+	v.SetDefault("indexing.indexnow_key", "")
+	v.SetDefault("indexing.indexnow_daily_quota", 10000)
+	v.SetDefault("indexing.baidu_endpoint", "http://data.zz.baidu.com")
+	v.SetDefault("indexing.baidu_token", "")
+	v.SetDefault("indexing.baidu_daily_quota", 100)
+	v.SetDefault("indexing.batch_size", 100)
+	v.SetDefault("indexing.interval", 60)
+	v.SetDefault("indexing.max_attempts", 5)
+	v.SetDefault("indexing.retry_backoff", 60)
+	v.SetDefault("indexing.timeout", 10)
+
+	// 订阅源默认配置
+	v.SetDefault("feed.resource_items", 30)
+	v.SetDefault("feed.article_items", 20)
+	v.SetDefault("feed.category_items", 30)
+	v.SetDefault("feed.max_items", 100)
+	v.SetDefault("feed.full_content", true)
+	v.SetDefault("feed.cache_max_age", 600)
+
+	// 正文渲染默认配置
+	v.SetDefault("content.cache_size", 1000)
+	v.SetDefault("content.highlight_style", "github")
+	v.SetDefault("content.toc_min_level", 2)
+	v.SetDefault("content.toc_max_level", 4)
+	v.SetDefault("content.excerpt_length", 150)
+	v.SetDefault("content.cjk_chars_per_minute", 400)
+	v.SetDefault("content.words_per_minute", 200)
 }
 
 // validateConfig 验证配置
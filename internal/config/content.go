@@ -0,0 +1,64 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+// ContentConfig 正文渲染（Markdown、目录、代码高亮）配置结构
+type ContentConfig struct {
+	CacheSize         int    `mapstructure:"cache_size" json:"cache_size"`                     // 渲染结果缓存条数
+	HighlightStyle    string `mapstructure:"highlight_style" json:"highlight_style"`           // 代码高亮主题（chroma主题名）
+	TOCMinLevel       int    `mapstructure:"toc_min_level" json:"toc_min_level"`               // 目录包含的最高级标题
+	TOCMaxLevel       int    `mapstructure:"toc_max_level" json:"toc_max_level"`               // 目录包含的最低级标题
+	ExcerptLength     int    `mapstructure:"excerpt_length" json:"excerpt_length"`             // 自动摘要的最大字数
+	CJKCharsPerMinute int    `mapstructure:"cjk_chars_per_minute" json:"cjk_chars_per_minute"` // 中文阅读速度(字/分钟)
+	WordsPerMinute    int    `mapstructure:"words_per_minute" json:"words_per_minute"`         // 英文阅读速度(词/分钟)
+}
+
+// DefaultContentConfig 默认正文渲染配置
+func DefaultContentConfig() *ContentConfig {
+	return &ContentConfig{
+		CacheSize:         1000,
+		HighlightStyle:    "github",
+		TOCMinLevel:       2,
+		TOCMaxLevel:       4,
+		ExcerptLength:     150,
+		CJKCharsPerMinute: 400,
+		WordsPerMinute:    200,
+	}
+}
+
+// GetTOCLevels 获取目录包含的标题级别范围
+func (c *ContentConfig) GetTOCLevels() (int, int) {
+	min, max := c.TOCMinLevel, c.TOCMaxLevel
+	if min < 1 || min > 6 {
+		min = 2
+	}
+	if max < min || max > 6 {
+		max = 4
+	}
+	return min, max
+}
+
+// GetExcerptLength 获取自动摘要的最大字数
+func (c *ContentConfig) GetExcerptLength() int {
+	if c.ExcerptLength <= 0 {
+		return 150
+	}
+	return c.ExcerptLength
+}
+
+// GetReadingSpeed 获取阅读速度（中文字/分钟，英文词/分钟）
+func (c *ContentConfig) GetReadingSpeed() (int, int) {
+	cjk, words := c.CJKCharsPerMinute, c.WordsPerMinute
+	if cjk <= 0 {
+		cjk = 400
+	}
+	if words <= 0 {
+		words = 200
+	}
+	return cjk, words
+}
@@ -66,13 +66,46 @@ func AutoMigrate(db *gorm.DB) error {
 		// 用户系统
 		&model.User{},
 		&model.Session{},
+		&model.UserToken{},
+		&model.LoginHistory{},
+		&model.UserTwoFactor{},
+		&model.RecoveryCode{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
 
 		// 分类系统
 		&model.Category{},
 
 		// 资源系统
 		&model.Resource{},
+		&model.ReviewLog{},
+		&model.ModerationRule{},
+		&model.ReviewClaim{},
+		&model.RejectReasonTemplate{},
+		&model.ResourceRevision{},
 		&model.Comment{},
+		&model.SensitiveWord{},
+
+		// 标签系统
+		&model.Tag{},
+		&model.ResourceTag{},
+		&model.ArticleTag{},
+
+		// 下载记录与推荐
+		&model.DownloadRecord{},
+		&model.ResourceSimilarity{},
+
+		// 收藏与合集
+		&model.Favorite{},
+		&model.Collection{},
+		&model.CollectionItem{},
+		&model.CollectionFollow{},
+
+		// 资源评分
+		&model.ResourceRating{},
+
+		// 点赞与表态
+		&model.Reaction{},
 
 		// 邀请系统
 		&model.Invitation{},
@@ -81,6 +114,10 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.PointsRule{},
 		&model.PointRecord{},
 
+		// 通知系统
+		&model.Notification{},
+		&model.NotificationPreference{},
+
 		// 监控审计
 		&model.VisitLog{},
 		&model.IPBlacklist{},
@@ -0,0 +1,53 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+// FeedConfig 订阅源（RSS/Atom/JSON Feed）配置结构
+type FeedConfig struct {
+	ResourceItems int  `mapstructure:"resource_items" json:"resource_items"` // 资源订阅源默认条目数
+	ArticleItems  int  `mapstructure:"article_items" json:"article_items"`   // 文章订阅源默认条目数
+	CategoryItems int  `mapstructure:"category_items" json:"category_items"` // 分类订阅源默认条目数
+	MaxItems      int  `mapstructure:"max_items" json:"max_items"`           // 通过 limit 参数可请求的最大条目数
+	FullContent   bool `mapstructure:"full_content" json:"full_content"`     // 文章条目是否输出全文（否则只输出摘要）
+	CacheMaxAge   int  `mapstructure:"cache_max_age" json:"cache_max_age"`   // 客户端缓存时间(秒)
+}
+
+// DefaultFeedConfig 默认订阅源配置
+func DefaultFeedConfig() *FeedConfig {
+	return &FeedConfig{
+		ResourceItems: 30,
+		ArticleItems:  20,
+		CategoryItems: 30,
+		MaxItems:      100,
+		FullContent:   true,
+		CacheMaxAge:   600,
+	}
+}
+
+// GetMaxItems 获取单个订阅源的最大条目数
+func (c *FeedConfig) GetMaxItems() int {
+	if c.MaxItems <= 0 {
+		return 100
+	}
+	return c.MaxItems
+}
+
+// GetItemLimit 获取订阅源条目数（requested 为请求的条目数，0表示使用默认值）
+func (c *FeedConfig) GetItemLimit(defaultItems, requested int) int {
+	limit := defaultItems
+	if requested > 0 {
+		limit = requested
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if max := c.GetMaxItems(); limit > max {
+		limit = max
+	}
+	return limit
+}
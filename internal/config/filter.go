@@ -0,0 +1,31 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// FilterConfig 敏感词过滤配置结构
+type FilterConfig struct {
+	Refresh        int `mapstructure:"refresh" json:"refresh"`                 // 敏感词库检查更新的间隔(秒)
+	BlockSeverity  int `mapstructure:"block_severity" json:"block_severity"`   // 直接拒绝的最低敏感级别
+	ReviewSeverity int `mapstructure:"review_severity" json:"review_severity"` // 转人工审核的最低敏感级别
+}
+
+// DefaultFilterConfig 默认敏感词过滤配置
+func DefaultFilterConfig() *FilterConfig {
+	return &FilterConfig{
+		Refresh:        60,
+		BlockSeverity:  3,
+		ReviewSeverity: 2,
+	}
+}
+
+// GetRefresh 获取敏感词库检查更新的间隔
+func (c *FilterConfig) GetRefresh() time.Duration {
+	return time.Duration(c.Refresh) * time.Second
+}
@@ -0,0 +1,92 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// IndexingConfig 搜索引擎URL推送配置结构
+type IndexingConfig struct {
+	// IndexNow（Bing、Yandex等共用的推送协议）
+	IndexNowEndpoint   string `mapstructure:"indexnow_endpoint" json:"indexnow_endpoint"`       // 推送接口地址
+	IndexNowKey        string `mapstructure:"indexnow_key" json:"-"`                            // 验证密钥（8-128位字母、数字或-），为空时不推送
+	IndexNowDailyQuota int    `mapstructure:"indexnow_daily_quota" json:"indexnow_daily_quota"` // 每天最多推送的URL数
+
+	// 百度普通收录（链接提交API）
+	BaiduEndpoint   string `mapstructure:"baidu_endpoint" json:"baidu_endpoint"`       // 接口地址
+	BaiduToken      string `mapstructure:"baidu_token" json:"-"`                       // 站长平台提供的准入密钥，为空时不推送
+	BaiduDailyQuota int    `mapstructure:"baidu_daily_quota" json:"baidu_daily_quota"` // 每天最多推送的URL数
+
+	// 队列
+	BatchSize    int `mapstructure:"batch_size" json:"batch_size"`       // 每次请求推送的URL数
+	Interval     int `mapstructure:"interval" json:"interval"`           // 推送队列的处理间隔(秒)
+	MaxAttempts  int `mapstructure:"max_attempts" json:"max_attempts"`   // 单个URL的最大尝试次数
+	RetryBackoff int `mapstructure:"retry_backoff" json:"retry_backoff"` // 首次重试的等待时间(秒)，之后每次翻倍
+	Timeout      int `mapstructure:"timeout" json:"timeout"`             // 请求超时时间(秒)
+}
+
+// DefaultIndexingConfig 默认搜索引擎URL推送配置
+func DefaultIndexingConfig() *IndexingConfig {
+	return &IndexingConfig{
+		IndexNowEndpoint:   "https://api.indexnow.org/indexnow",
+		IndexNowDailyQuota: 10000,
+		BaiduEndpoint:      "http://data.zz.baidu.com",
+		BaiduDailyQuota:    100,
+		BatchSize:          100,
+		Interval:           60,
+		MaxAttempts:        5,
+		RetryBackoff:       60,
+		Timeout:            10,
+	}
+}
+
+// GetInterval 获取推送队列的处理间隔
+func (c *IndexingConfig) GetInterval() time.Duration {
+	if c.Interval <= 0 {
+		return time.Minute
+	}
+	return time.Duration(c.Interval) * time.Second
+}
+
+// GetBatchSize 获取每次请求推送的URL数（IndexNow单次最多10000个）
+func (c *IndexingConfig) GetBatchSize() int {
+	if c.BatchSize <= 0 || c.BatchSize > 10000 {
+		return 100
+	}
+	return c.BatchSize
+}
+
+// GetMaxAttempts 获取单个URL的最大尝试次数
+func (c *IndexingConfig) GetMaxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return 5
+	}
+	return c.MaxAttempts
+}
+
+// GetRetryBackoff 获取第 attempts 次失败后的重试等待时间（指数退避，最长1天）
+func (c *IndexingConfig) GetRetryBackoff(attempts int) time.Duration {
+	backoff := time.Duration(c.RetryBackoff) * time.Second
+	if backoff <= 0 {
+		backoff = time.Minute
+	}
+	for i := 1; i < attempts && backoff < 24*time.Hour; i++ {
+		backoff *= 2
+	}
+	if backoff > 24*time.Hour {
+		backoff = 24 * time.Hour
+	}
+	return backoff
+}
+
+// GetTimeout 获取请求超时时间
+func (c *IndexingConfig) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
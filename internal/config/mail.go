@@ -0,0 +1,73 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// MailConfig 邮件配置结构
+type MailConfig struct {
+	Driver   string `mapstructure:"driver" json:"driver"`       // 驱动: smtp/file/log
+	Host     string `mapstructure:"host" json:"host"`           // SMTP主机
+	Port     int    `mapstructure:"port" json:"port"`           // SMTP端口
+	Username string `mapstructure:"username" json:"username"`   // SMTP用户名（为空则不认证）
+	Password string `mapstructure:"password" json:"password"`   // SMTP密码
+	From     string `mapstructure:"from" json:"from"`           // 发件人地址
+	FromName string `mapstructure:"from_name" json:"from_name"` // 发件人名称
+	FileDir  string `mapstructure:"file_dir" json:"file_dir"`   // 邮件文件保存目录（file驱动）
+	Timeout  int    `mapstructure:"timeout" json:"timeout"`     // SMTP连接和发送超时时间(秒)
+}
+
+// NotificationConfig 通知配置结构
+type NotificationConfig struct {
+	EmailEnabled   bool   `mapstructure:"email_enabled" json:"email_enabled"`     // 是否启用邮件通道
+	WebhookEnabled bool   `mapstructure:"webhook_enabled" json:"webhook_enabled"` // 是否启用Webhook通道
+	WebhookTimeout int    `mapstructure:"webhook_timeout" json:"webhook_timeout"` // Webhook超时时间(秒)
+	WebhookSecret  string `mapstructure:"webhook_secret" json:"webhook_secret"`   // Webhook签名密钥
+	QueueSize      int    `mapstructure:"queue_size" json:"queue_size"`           // 邮件和Webhook投递队列长度
+	Workers        int    `mapstructure:"workers" json:"workers"`                 // 邮件和Webhook投递协程数
+}
+
+// GetAddr 获取SMTP地址
+func (c *MailConfig) GetAddr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// GetTimeout 获取SMTP超时时间
+func (c *MailConfig) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// DefaultMailConfig 默认邮件配置（本地SMTP调试服务）
+func DefaultMailConfig() *MailConfig {
+	return &MailConfig{
+		Driver:   "smtp",
+		Host:     "localhost",
+		Port:     1025,
+		From:     "noreply@example.com",
+		FromName: "资源分享网站",
+		FileDir:  "storage/mails",
+		Timeout:  10,
+	}
+}
+
+// DefaultNotificationConfig 默认通知配置
+func DefaultNotificationConfig() *NotificationConfig {
+	return &NotificationConfig{
+		EmailEnabled:   true,
+		WebhookEnabled: true,
+		WebhookTimeout: 5,
+		QueueSize:      1000,
+		Workers:        2,
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// OAuthConfig 第三方登录配置结构
+type OAuthConfig struct {
+	StateTTL  int                   `mapstructure:"state_ttl" json:"state_ttl"` // 授权请求有效期(分钟)
+	Providers []OAuthProviderConfig `mapstructure:"providers" json:"providers"` // 身份提供方列表
+}
+
+// OAuthProviderConfig 身份提供方配置
+type OAuthProviderConfig struct {
+	Name         string   `mapstructure:"name" json:"name"`                   // 唯一标识（用于路由），如 github、google、keycloak
+	Type         string   `mapstructure:"type" json:"type"`                   // 类型: github/google/oidc
+	DisplayName  string   `mapstructure:"display_name" json:"display_name"`   // 显示名称
+	Enabled      bool     `mapstructure:"enabled" json:"enabled"`             // 是否启用
+	ClientID     string   `mapstructure:"client_id" json:"client_id"`         // 客户端ID
+	ClientSecret string   `mapstructure:"client_secret" json:"-"`             // 客户端密钥
+	Issuer       string   `mapstructure:"issuer" json:"issuer"`               // OIDC发行方地址（用于自动发现端点）
+	AuthURL      string   `mapstructure:"auth_url" json:"auth_url"`           // 授权端点（为空时使用默认值或自动发现）
+	TokenURL     string   `mapstructure:"token_url" json:"token_url"`         // 令牌端点
+	UserInfoURL  string   `mapstructure:"user_info_url" json:"user_info_url"` // 用户信息端点
+	Scopes       []string `mapstructure:"scopes" json:"scopes"`               // 授权范围（为空时使用默认值）
+	RedirectURL  string   `mapstructure:"redirect_url" json:"redirect_url"`   // 回调地址（为空时根据站点地址生成）
+}
+
+// DefaultOAuthConfig 默认第三方登录配置（不启用任何提供方）
+func DefaultOAuthConfig() *OAuthConfig {
+	return &OAuthConfig{
+		StateTTL: 10,
+	}
+}
+
+// GetStateTTL 获取授权请求有效期
+func (c *OAuthConfig) GetStateTTL() time.Duration {
+	return time.Duration(c.StateTTL) * time.Minute
+}
@@ -0,0 +1,39 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// RatingConfig 资源评分配置结构
+type RatingConfig struct {
+	PriorMean        float64 `mapstructure:"prior_mean" json:"prior_mean"`                 // 贝叶斯平均的先验评分(1-5)
+	PriorWeight      float64 `mapstructure:"prior_weight" json:"prior_weight"`             // 先验评分相当于多少个评分
+	RequireDownload  bool    `mapstructure:"require_download" json:"require_download"`     // 是否只允许下载过资源的用户评分
+	MaxContentLength int     `mapstructure:"max_content_length" json:"max_content_length"` // 评价内容最大长度(字符)
+	AlertThreshold   float64 `mapstructure:"alert_threshold" json:"alert_threshold"`       // 平均评分低于该值时通知审核员，0表示不通知
+	AlertMinRatings  int     `mapstructure:"alert_min_ratings" json:"alert_min_ratings"`   // 触发低分提醒所需的最少评分数
+	AlertCooldown    int     `mapstructure:"alert_cooldown" json:"alert_cooldown"`         // 同一资源两次低分提醒的最小间隔(小时)
+}
+
+// DefaultRatingConfig 默认资源评分配置
+func DefaultRatingConfig() *RatingConfig {
+	return &RatingConfig{
+		PriorMean:        3.0,
+		PriorWeight:      5,
+		RequireDownload:  true,
+		MaxContentLength: 1000,
+		AlertThreshold:   2.0,
+		AlertMinRatings:  5,
+		AlertCooldown:    24,
+	}
+}
+
+// GetAlertCooldown 获取低分提醒间隔
+func (c *RatingConfig) GetAlertCooldown() time.Duration {
+	return time.Duration(c.AlertCooldown) * time.Hour
+}
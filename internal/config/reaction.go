@@ -0,0 +1,36 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// ReactionConfig 点赞与表态配置结构
+type ReactionConfig struct {
+	HotThreshold  int `mapstructure:"hot_threshold" json:"hot_threshold"`   // 统计窗口内点赞/取消次数超过该值的对象视为热点，0表示不启用Redis缓冲
+	HotWindow     int `mapstructure:"hot_window" json:"hot_window"`         // 热点统计窗口(秒)
+	FlushInterval int `mapstructure:"flush_interval" json:"flush_interval"` // Redis中缓冲的点赞数写回数据库的间隔(秒)
+}
+
+// DefaultReactionConfig 默认点赞与表态配置
+func DefaultReactionConfig() *ReactionConfig {
+	return &ReactionConfig{
+		HotThreshold:  30,
+		HotWindow:     60,
+		FlushInterval: 10,
+	}
+}
+
+// GetHotWindow 获取热点统计窗口
+func (c *ReactionConfig) GetHotWindow() time.Duration {
+	return time.Duration(c.HotWindow) * time.Second
+}
+
+// GetFlushInterval 获取点赞数写回间隔
+func (c *ReactionConfig) GetFlushInterval() time.Duration {
+	return time.Duration(c.FlushInterval) * time.Second
+}
@@ -0,0 +1,39 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// RecommendationConfig 相关资源推荐配置结构
+type RecommendationConfig struct {
+	Refresh          int     `mapstructure:"refresh" json:"refresh"`                       // 相似度表重建间隔(分钟)，0表示不自动重建
+	MaxRelated       int     `mapstructure:"max_related" json:"max_related"`               // 每个资源保留的相关资源数量
+	MinScore         float64 `mapstructure:"min_score" json:"min_score"`                   // 保留的最低综合得分(0-1)
+	MaxUserHistory   int     `mapstructure:"max_user_history" json:"max_user_history"`     // 每个用户参与计算的最近下载数量
+	CoDownloadWeight float64 `mapstructure:"co_download_weight" json:"co_download_weight"` // 共同下载信号权重
+	TagWeight        float64 `mapstructure:"tag_weight" json:"tag_weight"`                 // 标签重合信号权重
+	TitleWeight      float64 `mapstructure:"title_weight" json:"title_weight"`             // 标题相似信号权重
+}
+
+// DefaultRecommendationConfig 默认相关资源推荐配置
+func DefaultRecommendationConfig() *RecommendationConfig {
+	return &RecommendationConfig{
+		Refresh:          60,
+		MaxRelated:       20,
+		MinScore:         0.05,
+		MaxUserHistory:   100,
+		CoDownloadWeight: 0.5,
+		TagWeight:        0.3,
+		TitleWeight:      0.2,
+	}
+}
+
+// GetRefresh 获取相似度表重建间隔
+func (c *RecommendationConfig) GetRefresh() time.Duration {
+	return time.Duration(c.Refresh) * time.Minute
+}
@@ -0,0 +1,38 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import "time"
+
+// ReviewConfig 人工审核队列配置结构
+type ReviewConfig struct {
+	ClaimTTL          int     `mapstructure:"claim_ttl" json:"claim_ttl"`                     // 认领租约时长(分钟)，超时自动释放
+	SLAHours          int     `mapstructure:"sla_hours" json:"sla_hours"`                     // 审核时效(小时)，超过即视为超时
+	TrustedMinReviews int     `mapstructure:"trusted_min_reviews" json:"trusted_min_reviews"` // 可信上传者至少需要的人工审核次数
+	TrustedMinRatio   float64 `mapstructure:"trusted_min_ratio" json:"trusted_min_ratio"`     // 可信上传者的最低历史通过率(0-1)
+}
+
+// DefaultReviewConfig 默认人工审核队列配置
+func DefaultReviewConfig() *ReviewConfig {
+	return &ReviewConfig{
+		ClaimTTL:          15,
+		SLAHours:          24,
+		TrustedMinReviews: 5,
+		TrustedMinRatio:   0.9,
+	}
+}
+
+// GetClaimTTL 获取认领租约时长
+func (c *ReviewConfig) GetClaimTTL() time.Duration {
+	return time.Duration(c.ClaimTTL) * time.Minute
+}
+
+// GetSLA 获取审核时效
+func (c *ReviewConfig) GetSLA() time.Duration {
+	return time.Duration(c.SLAHours) * time.Hour
+}
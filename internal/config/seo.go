@@ -0,0 +1,92 @@
+/*
+Package config provides configuration management for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxSitemapURLs 单个sitemap文件允许的最大URL数量（sitemaps.org 协议限制）
+const MaxSitemapURLs = 50000
+
+// SEOConfig 搜索引擎优化配置结构
+type SEOConfig struct {
+	SiteURL          string `mapstructure:"site_url" json:"site_url"`                     // 对外访问的站点地址（sitemap等使用），为空时使用 auth.site_url
+	SiteName         string `mapstructure:"site_name" json:"site_name"`                   // 站点名称（页面标题、Open Graph和结构化数据使用）
+	SitemapShardSize int    `mapstructure:"sitemap_shard_size" json:"sitemap_shard_size"` // 每个sitemap分片的URL数量(不超过50000)
+	SitemapGzip      bool   `mapstructure:"sitemap_gzip" json:"sitemap_gzip"`             // sitemap索引中是否引用gzip压缩的分片
+	SitemapCacheTTL  int    `mapstructure:"sitemap_cache_ttl" json:"sitemap_cache_ttl"`   // 两次检查内容变化的最小间隔(秒)
+
+	// 站点审计
+	AuditMaxPages int      `mapstructure:"audit_max_pages" json:"audit_max_pages"` // 单次审计最多请求的页面数
+	AuditSlowMs   int      `mapstructure:"audit_slow_ms" json:"audit_slow_ms"`     // 响应时间超过该值(毫秒)视为慢页面
+	AuditExcludes []string `mapstructure:"audit_excludes" json:"audit_excludes"`   // 审计时不抓取的路径前缀
+
+	// 关键词排名导入
+	RankImportMaxRows int `mapstructure:"rank_import_max_rows" json:"rank_import_max_rows"` // 单次导入最多处理的数据行数
+	RankDropThreshold int `mapstructure:"rank_drop_threshold" json:"rank_drop_threshold"`   // 排名下降超过该名次时提醒管理员(0表示不提醒)
+}
+
+// DefaultSEOConfig 默认搜索引擎优化配置
+func DefaultSEOConfig() *SEOConfig {
+	return &SEOConfig{
+		SiteName:         "资源分享网站",
+		SitemapShardSize: MaxSitemapURLs,
+		SitemapGzip:      true,
+		SitemapCacheTTL:  60,
+		AuditMaxPages:    200,
+		AuditSlowMs:      1000,
+		AuditExcludes:    []string{"/api/", "/admin", "/auth/"},
+
+		RankImportMaxRows: 50000,
+		RankDropThreshold: 10,
+	}
+}
+
+// GetBaseURL 获取不带末尾斜杠的站点地址
+func (c *SEOConfig) GetBaseURL() string {
+	return strings.TrimRight(c.SiteURL, "/")
+}
+
+// GetSitemapShardSize 获取sitemap分片大小（限制在协议允许范围内）
+func (c *SEOConfig) GetSitemapShardSize() int {
+	if c.SitemapShardSize <= 0 || c.SitemapShardSize > MaxSitemapURLs {
+		return MaxSitemapURLs
+	}
+	return c.SitemapShardSize
+}
+
+// GetSitemapCacheTTL 获取sitemap缓存检查间隔
+func (c *SEOConfig) GetSitemapCacheTTL() time.Duration {
+	return time.Duration(c.SitemapCacheTTL) * time.Second
+}
+
+// GetAuditMaxPages 获取单次审计的页面数上限
+func (c *SEOConfig) GetAuditMaxPages() int {
+	if c.AuditMaxPages <= 0 {
+		return 200
+	}
+	return c.AuditMaxPages
+}
+
+// GetAuditSlowThreshold 获取慢页面阈值
+func (c *SEOConfig) GetAuditSlowThreshold() time.Duration {
+	if c.AuditSlowMs <= 0 {
+		return time.Second
+	}
+	return time.Duration(c.AuditSlowMs) * time.Millisecond
+}
+
+// GetRankImportMaxRows 获取单次排名导入的最大行数
+func (c *SEOConfig) GetRankImportMaxRows() int {
+	if c.RankImportMaxRows <= 0 {
+		return 50000
+	}
+	return c.RankImportMaxRows
+}
@@ -12,6 +12,10 @@ import (
 
 	"resource-share-site/internal/config"
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/article"
+	"resource-share-site/internal/service/category"
+	"resource-share-site/internal/service/resource"
+	"resource-share-site/internal/service/tag"
 
 	"gorm.io/gorm"
 )
@@ -20,28 +24,184 @@ import (
 func RunMigrations(db *gorm.DB) error {
 	fmt.Println("开始执行数据库迁移...")
 
+	// 升级前的用户需在新增 email_verified 列之前识别
+	legacyUsers := NeedsEmailVerifiedBackfill(db)
+
 	// 获取所有模型进行自动迁移
 	if err := AutoMigrate(db); err != nil {
 		return fmt.Errorf("自动迁移失败: %w", err)
 	}
 
+	// 数据迁移
+	if legacyUsers {
+		if err := BackfillEmailVerified(db); err != nil {
+			return err
+		}
+	}
+	if err := MigrateLegacyTags(db); err != nil {
+		return err
+	}
+	if err := MigrateSlugs(db); err != nil {
+		return err
+	}
+	if err := MigrateArticleContent(db); err != nil {
+		return err
+	}
+	if err := SeedModerationRules(db); err != nil {
+		return err
+	}
+
 	fmt.Println("数据库迁移完成!")
 	return nil
 }
 
+// NeedsEmailVerifiedBackfill 判断是否为升级前的数据库（用户表已存在但还没有 email_verified 列），需在自动迁移之前调用
+func NeedsEmailVerifiedBackfill(db *gorm.DB) bool {
+	migrator := db.Migrator()
+	return migrator.HasTable(&model.User{}) && !migrator.HasColumn(&model.User{}, "EmailVerified")
+}
+
+// BackfillEmailVerified 将升级前注册的用户标记为邮箱已验证（只在新增 email_verified 列时执行一次），
+// 避免开启 restrict_unverified 后老用户无法上传资源和获得积分
+func BackfillEmailVerified(db *gorm.DB) error {
+	result := db.Model(&model.User{}).
+		Where("email_verified = ?", false).
+		Updates(map[string]interface{}{
+			"email_verified":    true,
+			"email_verified_at": gorm.Expr("created_at"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("标记历史用户邮箱已验证失败: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		fmt.Printf("标记历史用户邮箱已验证：%d 个\n", result.RowsAffected)
+	}
+	return nil
+}
+
+// MigrateLegacyTags 将资源和文章原有的标签字符串解析到标签表（已迁移的数据会跳过，可重复执行）
+func MigrateLegacyTags(db *gorm.DB) error {
+	imported, err := tag.NewTagService(db).ImportLegacyTags()
+	if err != nil {
+		return fmt.Errorf("迁移历史标签失败: %w", err)
+	}
+
+	if imported["resources"] > 0 || imported["articles"] > 0 {
+		fmt.Printf("迁移历史标签：资源 %d 个，文章 %d 个\n", imported["resources"], imported["articles"])
+	}
+	return nil
+}
+
+// MigrateSlugs 为历史资源和分类生成URL slug，并补全标签的规范化名称和slug（已生成的数据会跳过，可重复执行）
+func MigrateSlugs(db *gorm.DB) error {
+	resources, err := resource.NewResourceService(db).BackfillSlugs()
+	if err != nil {
+		return fmt.Errorf("生成资源slug失败: %w", err)
+	}
+	categories, err := category.NewCategoryService(db).BackfillSlugs()
+	if err != nil {
+		return fmt.Errorf("生成分类slug失败: %w", err)
+	}
+	tags, conflicts, err := tag.NewTagService(db).BackfillKeys()
+	if err != nil {
+		return fmt.Errorf("补全标签标识失败: %w", err)
+	}
+
+	if resources > 0 || categories > 0 || tags > 0 {
+		fmt.Printf("生成URL slug：资源 %d 个，分类 %d 个，标签 %d 个\n", resources, categories, tags)
+	}
+	if conflicts > 0 {
+		fmt.Printf("有 %d 个标签的规范化名称与其他标签重复，请在后台确认后手动合并\n", conflicts)
+	}
+	return nil
+}
+
+// MigrateArticleContent 为历史文章计算阅读时间并生成缺失的摘要（已计算的文章会跳过，可重复执行）
+func MigrateArticleContent(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Article{}) {
+		return nil
+	}
+
+	updated, err := article.NewArticleService(db).BackfillContentMeta()
+	if err != nil {
+		return fmt.Errorf("计算文章阅读时间失败: %w", err)
+	}
+	if updated > 0 {
+		fmt.Printf("计算文章阅读时间：%d 篇\n", updated)
+	}
+	return nil
+}
+
+// SeedModerationRules 在自动审核规则表为空时写入默认规则（管理员和版主自动通过、重复链接转人工）。
+// 只要表中已有任一规则就跳过，管理员停用或删除的默认规则不会在重启后被重新创建
+func SeedModerationRules(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&model.ModerationRule{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询自动审核规则失败: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	rules := []model.ModerationRule{
+		{Name: "重复链接", Description: "网盘链接与已有资源重复时转人工审核", Type: model.ModerationRuleDuplicateURL, Config: "{}", Action: model.ModerationDecisionHold, Priority: 10, IsEnabled: true},
+		{Name: "可信角色", Description: "管理员和版主上传的资源自动通过", Type: model.ModerationRuleTrustedRole, Config: `{"roles": ["admin", "moderator"]}`, Action: model.ModerationDecisionApprove, Priority: 100, IsEnabled: true},
+	}
+	if err := db.Create(&rules).Error; err != nil {
+		return fmt.Errorf("创建自动审核规则失败: %w", err)
+	}
+
+	fmt.Printf("创建默认自动审核规则：%d 条\n", len(rules))
+	return nil
+}
+
 // AutoMigrate 自动迁移所有模型
 func AutoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		// 用户系统
 		&model.User{},
 		&model.Session{},
+		&model.UserToken{},
+		&model.LoginHistory{},
+		&model.UserTwoFactor{},
+		&model.RecoveryCode{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
 
 		// 分类系统
 		&model.Category{},
 
 		// 资源系统
 		&model.Resource{},
+		&model.ReviewLog{},
+		&model.ModerationRule{},
+		&model.ReviewClaim{},
+		&model.RejectReasonTemplate{},
+		&model.ResourceRevision{},
 		&model.Comment{},
+		&model.SensitiveWord{},
+
+		// 标签系统
+		&model.Tag{},
+		&model.ResourceTag{},
+		&model.ArticleTag{},
+
+		// 下载记录与推荐
+		&model.DownloadRecord{},
+		&model.ResourceSimilarity{},
+
+		// 收藏与合集
+		&model.Favorite{},
+		&model.Collection{},
+		&model.CollectionItem{},
+		&model.CollectionFollow{},
+
+		// 资源评分
+		&model.ResourceRating{},
+
+		// 点赞与表态
+		&model.Reaction{},
 
 		// 邀请系统
 		&model.Invitation{},
@@ -50,6 +210,10 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.PointsRule{},
 		&model.PointRecord{},
 
+		// 通知系统
+		&model.Notification{},
+		&model.NotificationPreference{},
+
 		// 监控审计
 		&model.VisitLog{},
 		&model.IPBlacklist{},
@@ -173,6 +337,34 @@ func CreateDefaultData(db *gorm.DB) error {
 		}
 	}
 
+	// 创建默认自动审核规则
+	if err := SeedModerationRules(db); err != nil {
+		return err
+	}
+
+	// 创建默认拒绝理由模板
+	rejectTemplates := []model.RejectReasonTemplate{
+		{Code: "broken_link", Title: "链接失效", Content: "网盘链接无法访问或已失效，请更新链接后重新提交", SortOrder: 1, IsEnabled: true},
+		{Code: "incomplete_info", Title: "信息不完整", Content: "资源标题或描述信息不完整，请补充后重新提交", SortOrder: 2, IsEnabled: true},
+		{Code: "duplicate", Title: "重复资源", Content: "站内已存在相同的资源，请勿重复提交", SortOrder: 3, IsEnabled: true},
+		{Code: "wrong_category", Title: "分类错误", Content: "资源所选分类与内容不符，请修改分类后重新提交", SortOrder: 4, IsEnabled: true},
+		{Code: "copyright", Title: "版权问题", Content: "资源涉及版权争议，暂不允许分享", SortOrder: 5, IsEnabled: true},
+		{Code: "prohibited", Title: "违规内容", Content: "资源包含违反社区规范的内容", SortOrder: 6, IsEnabled: true},
+	}
+
+	for _, tpl := range rejectTemplates {
+		var existingTemplate model.RejectReasonTemplate
+		if err := db.Where("code = ?", tpl.Code).First(&existingTemplate).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				if err := db.Create(&tpl).Error; err != nil {
+					return fmt.Errorf("创建拒绝理由模板失败: %w", err)
+				}
+			} else {
+				return fmt.Errorf("查询拒绝理由模板失败: %w", err)
+			}
+		}
+	}
+
 	fmt.Println("默认数据创建完成!")
 	return nil
 }
@@ -185,12 +377,37 @@ func RollbackMigrations(db *gorm.DB) error {
 	if err := db.Migrator().DropTable(
 		"users",
 		"sessions",
+		"user_tokens",
+		"login_histories",
+		"user_two_factors",
+		"recovery_codes",
+		"user_identities",
+		"oauth_states",
 		"categories",
 		"resources",
+		"review_logs",
+		"moderation_rules",
+		"review_claims",
+		"reject_reason_templates",
+		"resource_revisions",
 		"comments",
+		"sensitive_words",
+		"tags",
+		"resource_tags",
+		"article_tags",
+		"download_records",
+		"resource_similarities",
+		"favorites",
+		"collections",
+		"collection_items",
+		"collection_follows",
+		"resource_ratings",
+		"reactions",
 		"invitations",
 		"points_rules",
 		"point_records",
+		"notifications",
+		"notification_preferences",
 		"visit_logs",
 		"ip_blacklists",
 		"admin_logs",
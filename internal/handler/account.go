@@ -0,0 +1,267 @@
+/*
+Package handlers defines account security HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/service/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 邮箱验证与密码重置处理器 ====================
+
+// SendVerificationEmail 重新发送邮箱验证邮件
+func (h *Handler) SendVerificationEmail(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	if err := h.accountService.SendVerificationEmail(userID); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "验证邮件已发送，请查收",
+		"status":  "success",
+	})
+}
+
+// VerifyEmail 验证邮箱（支持邮件链接GET访问和JSON提交）
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	token, ok := bindToken(c)
+	if !ok {
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(token); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "邮箱验证成功",
+		"status":  "success",
+	})
+}
+
+// ForgotPassword 申请重置密码
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.accountService.RequestPasswordReset(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "发送重置邮件失败",
+			"status":  "error",
+		})
+		return
+	}
+
+	// 无论邮箱是否存在都返回相同提示
+	c.JSON(http.StatusOK, gin.H{
+		"message": "如果该邮箱已注册，您将收到一封重置密码的邮件",
+		"status":  "success",
+	})
+}
+
+// ResetPassword 使用邮件中的令牌重置密码
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token           string `json:"token" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=6,max=100"`
+		ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "密码重置成功，请使用新密码登录",
+		"status":  "success",
+	})
+}
+
+// RequestEmailChange 申请修改邮箱
+func (h *Handler) RequestEmailChange(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req struct {
+		NewEmail string `json:"new_email" binding:"required,email,max=100"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.accountService.RequestEmailChange(userID, req.NewEmail, req.Password); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "确认邮件已发送至新邮箱，请查收",
+		"status":  "success",
+	})
+}
+
+// ConfirmEmailChange 确认修改邮箱
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	token, ok := bindToken(c)
+	if !ok {
+		return
+	}
+
+	if err := h.accountService.ConfirmEmailChange(token); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "邮箱修改成功",
+		"status":  "success",
+	})
+}
+
+// bindToken 从查询参数或JSON请求体中获取令牌
+func bindToken(c *gin.Context) (string, bool) {
+	if token := c.Query("token"); token != "" {
+		return token, true
+	}
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "缺少令牌参数",
+			"status":  "error",
+		})
+		return "", false
+	}
+
+	return req.Token, true
+}
+
+// accountErrorStatus 将账户服务错误映射为HTTP状态码
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrEmailInUse):
+		return http.StatusConflict
+	case errors.Is(err, auth.ErrTokenRequestTooOften):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ==================== 登录历史处理器 ====================
+
+// GetLoginHistory 获取当前用户的登录历史
+func (h *Handler) GetLoginHistory(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	histories, total, err := h.loginGuard.GetLoginHistory(userID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取登录历史成功",
+		"status":  "success",
+		"data": gin.H{
+			"histories": histories,
+			"total":     total,
+			"page":      page,
+			"size":      pageSize,
+		},
+	})
+}
+
+// ListLoginAnomalies 获取异常登录记录（管理员）
+func (h *Handler) ListLoginAnomalies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	histories, total, err := h.loginGuard.GetAnomalies(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取异常登录记录成功",
+		"status":  "success",
+		"data": gin.H{
+			"histories": histories,
+			"total":     total,
+			"page":      page,
+			"size":      pageSize,
+		},
+	})
+}
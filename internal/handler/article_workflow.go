@@ -0,0 +1,451 @@
+/*
+Package handlers defines article editorial workflow HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/article"
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/tag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 文章编辑流程处理器 ====================
+
+// ListEditorialArticles 获取编辑流程中的文章（审核员可查看全部，其他用户只能查看自己的文章，可按 status 筛选）
+func (h *Handler) ListEditorialArticles(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	status := model.ArticleStatus(c.Query("status"))
+
+	articles, total, err := h.articleService.ListEditorialArticles(c.GetUint("userID"), status, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取文章列表成功",
+		"status":  "success",
+		"data": gin.H{
+			"articles":  articles,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// UpdateArticle 保存文章内容（作者可修改草稿和待审核的文章，审核员可修改任意文章）
+func (h *Handler) UpdateArticle(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req article.UpdateArticleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	updated, revision, err := h.articleService.UpdateArticle(id, c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "保存文章成功",
+		"status":  "success",
+		"data": gin.H{
+			"article":  updated,
+			"revision": revision,
+		},
+	})
+}
+
+// AutosaveArticle 自动保存编辑中的内容（只记录修订，不修改文章）
+func (h *Handler) AutosaveArticle(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req article.AutosaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	revision, err := h.articleService.Autosave(id, c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "自动保存成功",
+		"status":  "success",
+		"data":    revision,
+	})
+}
+
+// SubmitArticle 提交文章审核（作者或审核员）
+func (h *Handler) SubmitArticle(c *gin.Context) {
+	h.articleTransition(c, "已提交审核", h.articleService.SubmitForReview)
+}
+
+// ApproveArticle 审核通过文章（审核员，填写 publish_at 时定时发布）
+func (h *Handler) ApproveArticle(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Notes     string     `json:"notes" binding:"max=500"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	approved, err := h.articleService.ApproveArticle(id, c.GetUint("userID"), req.Notes, req.PublishAt)
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	message := "文章已通过审核并发布"
+	if approved.Status == model.ArticleStatusScheduled {
+		message = "文章已通过审核，将在计划时间发布"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"status":  "success",
+		"data":    approved,
+	})
+}
+
+// RejectArticle 退回文章（审核员，必须填写审核意见）
+func (h *Handler) RejectArticle(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Notes string `json:"notes" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	rejected, err := h.articleService.RejectArticle(id, c.GetUint("userID"), req.Notes)
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "文章已退回",
+		"status":  "success",
+		"data":    rejected,
+	})
+}
+
+// ScheduleArticle 设置定时发布（审核员）
+func (h *Handler) ScheduleArticle(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PublishAt time.Time `json:"publish_at" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	scheduled, err := h.articleService.ScheduleArticle(id, c.GetUint("userID"), req.PublishAt)
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已设置定时发布",
+		"status":  "success",
+		"data":    scheduled,
+	})
+}
+
+// PublishArticle 立即发布文章（审核员）
+func (h *Handler) PublishArticle(c *gin.Context) {
+	h.articleTransition(c, "文章已发布", h.articleService.PublishArticle)
+}
+
+// UnpublishArticle 取消发布或取消定时发布，文章退回草稿（审核员）
+func (h *Handler) UnpublishArticle(c *gin.Context) {
+	h.articleTransition(c, "文章已下线", h.articleService.UnpublishArticle)
+}
+
+// ArchiveArticle 归档文章（审核员，或作者归档自己未发布的文章）
+func (h *Handler) ArchiveArticle(c *gin.Context) {
+	h.articleTransition(c, "文章已归档", h.articleService.ArchiveArticle)
+}
+
+// UnarchiveArticle 取消归档，文章退回草稿（审核员，或作者恢复自己从未发布的文章）
+func (h *Handler) UnarchiveArticle(c *gin.Context) {
+	h.articleTransition(c, "文章已取消归档", h.articleService.UnarchiveArticle)
+}
+
+// articleTransition 执行无请求参数的文章状态流转
+func (h *Handler) articleTransition(c *gin.Context, message string, action func(id, userID uint) (*model.Article, error)) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+
+	updated, err := action(id, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"status":  "success",
+		"data":    updated,
+	})
+}
+
+// ==================== 文章修订处理器 ====================
+
+// ListArticleRevisions 获取文章修订历史（作者、管理员和版主）
+func (h *Handler) ListArticleRevisions(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+	page, pageSize := parsePagination(c)
+
+	revisions, total, err := h.articleService.ListRevisions(id, c.GetUint("userID"), page, pageSize)
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取修订历史成功",
+		"status":  "success",
+		"data": gin.H{
+			"revisions": revisions,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetArticleRevision 获取文章修订详情及相对上一版本的字段差异
+func (h *Handler) GetArticleRevision(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+	version, ok := parseArticleVersion(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.articleService.GetRevision(id, version, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取修订详情成功",
+		"status":  "success",
+		"data":    detail,
+	})
+}
+
+// CompareArticleRevisions 比较文章的两个版本（from、to 为版本号，不传 to 时与当前内容比较）
+func (h *Handler) CompareArticleRevisions(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "缺少有效的from版本号",
+			"status":  "error",
+		})
+		return
+	}
+
+	changes, err := h.articleService.CompareRevisions(id, from, to, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "比较版本成功",
+		"status":  "success",
+		"data": gin.H{
+			"from":    from,
+			"to":      to,
+			"changes": changes,
+		},
+	})
+}
+
+// RestoreArticleRevision 将文章内容恢复到指定版本
+func (h *Handler) RestoreArticleRevision(c *gin.Context) {
+	id, ok := parseArticleID(c)
+	if !ok {
+		return
+	}
+	version, ok := parseArticleVersion(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" binding:"max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	restored, revision, err := h.articleService.RestoreRevision(id, version, c.GetUint("userID"), req.Note)
+	if err != nil {
+		c.JSON(articleErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "文章已恢复",
+		"status":  "success",
+		"data": gin.H{
+			"article":  restored,
+			"revision": revision,
+		},
+	})
+}
+
+// parseArticleID 解析路径中的文章ID（无效时直接返回错误响应）
+func parseArticleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的文章ID",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseArticleVersion 解析路径中的修订版本号（无效时直接返回错误响应）
+func parseArticleVersion(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的版本号",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// articleErrorStatus 将文章编辑流程错误映射为HTTP状态码
+func articleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, article.ErrArticleNotFound), errors.Is(err, article.ErrArticleRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, article.ErrArticlePermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, article.ErrInvalidArticleTransition):
+		return http.StatusConflict
+	case errors.Is(err, article.ErrSchedulePast), errors.Is(err, article.ErrReviewNotesRequired),
+		errors.Is(err, article.ErrArticleUnchanged), errors.Is(err, filter.ErrBlocked), errors.Is(err, tag.ErrTooManyTags):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
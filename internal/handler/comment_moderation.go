@@ -0,0 +1,282 @@
+/*
+Package handlers defines comment moderation and sensitive-word HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/comment"
+	"resource-share-site/internal/service/filter"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 评论审核处理器 ====================
+
+// ListPendingComments 获取待审核评论队列（审核员，type 可选 resource/article）
+func (h *Handler) ListPendingComments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	items, total, err := h.commentModerationService.GetPendingQueue(comment.CommentType(c.Query("type")), page, pageSize)
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取待审核评论成功",
+		"status":  "success",
+		"data": gin.H{
+			"comments":  items,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// ModerateComments 批量审核评论（审核员）
+func (h *Handler) ModerateComments(c *gin.Context) {
+	var req struct {
+		Items  []comment.CommentRef `json:"items" binding:"required,min=1,max=100,dive"`
+		Action string               `json:"action" binding:"required,oneof=approve reject"`
+		Notes  string               `json:"notes" binding:"max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	status := model.CommentStatusApproved
+	if req.Action == "reject" {
+		status = model.CommentStatusRejected
+	}
+
+	successCount, failed, err := h.commentModerationService.BulkModerate(req.Items, c.GetUint("userID"), status, req.Notes)
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "审核完成",
+		"status":  "success",
+		"data": gin.H{
+			"success_count": successCount,
+			"failed":        failed,
+		},
+	})
+}
+
+// ==================== 敏感词库处理器 ====================
+
+// ListSensitiveWords 获取敏感词列表（管理员）
+func (h *Handler) ListSensitiveWords(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	words, total, err := h.sensitiveWords.ListWords(c.Query("keyword"), c.Query("category"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取敏感词成功",
+		"status":  "success",
+		"data": gin.H{
+			"words":     words,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+			"loaded":    h.sensitiveWords.Size(),
+		},
+	})
+}
+
+// AddSensitiveWords 批量添加同一分类和级别的敏感词（管理员，添加后立即生效）
+func (h *Handler) AddSensitiveWords(c *gin.Context) {
+	var req struct {
+		Words    []string `json:"words" binding:"required,min=1,max=1000"`
+		Category string   `json:"category" binding:"max=50"`
+		Severity int      `json:"severity"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if req.Severity == 0 {
+		req.Severity = model.SensitiveSeverityMedium
+	}
+
+	added, err := h.sensitiveWords.AddWords(req.Words, req.Category, req.Severity)
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "添加敏感词成功",
+		"status":  "success",
+		"data": gin.H{
+			"added": added,
+		},
+	})
+}
+
+// UpdateSensitiveWord 修改敏感词的分类、级别或启用状态（管理员）
+func (h *Handler) UpdateSensitiveWord(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的敏感词ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	var req filter.WordUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.sensitiveWords.UpdateWord(uint(id), &req); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新敏感词成功",
+		"status":  "success",
+	})
+}
+
+// DeleteSensitiveWord 删除敏感词（管理员）
+func (h *Handler) DeleteSensitiveWord(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的敏感词ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.sensitiveWords.DeleteWord(uint(id)); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除敏感词成功",
+		"status":  "success",
+	})
+}
+
+// ReloadSensitiveWords 立即重新加载敏感词库（管理员，直接修改数据库后使用）
+func (h *Handler) ReloadSensitiveWords(c *gin.Context) {
+	if err := h.sensitiveWords.Reload(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "敏感词库已重新加载",
+		"status":  "success",
+		"data": gin.H{
+			"loaded": h.sensitiveWords.Size(),
+		},
+	})
+}
+
+// CheckSensitiveText 使用当前词库检查文本（管理员，用于调试词库）
+func (h *Handler) CheckSensitiveText(c *gin.Context) {
+	var req struct {
+		Text string `json:"text" binding:"required,max=20000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "检查完成",
+		"status":  "success",
+		"data":    h.sensitiveWords.Check(req.Text),
+	})
+}
+
+// commentErrorStatus 将评论审核服务错误映射为HTTP状态码
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, comment.ErrCommentNotFound), errors.Is(err, comment.ErrTargetNotFound),
+		errors.Is(err, filter.ErrWordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, comment.ErrPermissionDenied), errors.Is(err, comment.ErrReviewPermission):
+		return http.StatusForbidden
+	case errors.Is(err, comment.ErrAlreadyModerated):
+		return http.StatusConflict
+	case errors.Is(err, comment.ErrInvalidParent), errors.Is(err, comment.ErrInvalidCommentType),
+		errors.Is(err, comment.ErrInvalidModeration), errors.Is(err, comment.ErrEmptyCommentContent),
+		errors.Is(err, filter.ErrEmptyWord), errors.Is(err, filter.ErrInvalidSeverity), errors.Is(err, filter.ErrBlocked):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
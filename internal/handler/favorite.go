@@ -0,0 +1,604 @@
+/*
+Package handlers defines favorite and collection HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/favorite"
+
+	"github.com/gin-gonic/gin"
+)
+
+// collectionRequest 创建合集请求
+type collectionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	IsPublic    *bool  `json:"is_public"`
+}
+
+// collectionItemRequest 添加合集资源请求
+type collectionItemRequest struct {
+	ResourceID uint   `json:"resource_id" binding:"required"`
+	Note       string `json:"note"`
+}
+
+// collectionOrderRequest 调整合集顺序请求
+type collectionOrderRequest struct {
+	ResourceIDs []uint `json:"resource_ids" binding:"required"`
+}
+
+// ==================== 收藏处理器 ====================
+
+// AddFavorite 收藏资源或文章
+func (h *Handler) AddFavorite(c *gin.Context) {
+	targetType, targetID, ok := parseFavoriteTarget(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.favoriteService.AddFavorite(c.GetUint("userID"), targetType, targetID)
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "收藏成功",
+		"status":  "success",
+		"data": gin.H{
+			"favorited":      true,
+			"favorite_count": count,
+		},
+	})
+}
+
+// RemoveFavorite 取消收藏
+func (h *Handler) RemoveFavorite(c *gin.Context) {
+	targetType, targetID, ok := parseFavoriteTarget(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.favoriteService.RemoveFavorite(c.GetUint("userID"), targetType, targetID)
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已取消收藏",
+		"status":  "success",
+		"data": gin.H{
+			"favorited":      false,
+			"favorite_count": count,
+		},
+	})
+}
+
+// ListFavorites 获取当前用户的收藏（type=resource 或 article）
+func (h *Handler) ListFavorites(c *gin.Context) {
+	targetType, err := favorite.ParseTargetType(c.DefaultQuery("type", string(model.FavoriteTargetResource)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+	page, pageSize := parsePagination(c)
+	userID := c.GetUint("userID")
+
+	var items interface{}
+	var total int64
+	if targetType == model.FavoriteTargetArticle {
+		items, total, err = h.favoriteService.ListFavoriteArticles(userID, page, pageSize)
+	} else {
+		items, total, err = h.favoriteService.ListFavoriteResources(userID, page, pageSize)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取收藏列表成功",
+		"status":  "success",
+		"data": gin.H{
+			"type":      targetType,
+			"items":     items,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// ==================== 合集处理器 ====================
+
+// ListPublicCollections 获取公开合集（sort=popular 或 newest）
+func (h *Handler) ListPublicCollections(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	collections, total, err := h.collectionService.ListPublicCollections(c.DefaultQuery("sort", "popular"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取合集列表成功",
+		"status":  "success",
+		"data": gin.H{
+			"collections": collections,
+			"total":       total,
+			"page":        page,
+			"page_size":   pageSize,
+		},
+	})
+}
+
+// ListUserCollections 获取用户创建的合集（本人可以看到私有合集）
+func (h *Handler) ListUserCollections(c *gin.Context) {
+	ownerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的用户ID",
+			"status":  "error",
+		})
+		return
+	}
+	page, pageSize := parsePagination(c)
+	viewerID, _ := h.getCurrentUserID(c)
+
+	collections, total, err := h.collectionService.ListUserCollections(uint(ownerID), viewerID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取合集列表成功",
+		"status":  "success",
+		"data": gin.H{
+			"collections": collections,
+			"total":       total,
+			"page":        page,
+			"page_size":   pageSize,
+		},
+	})
+}
+
+// ListFollowedCollections 获取当前用户关注的合集
+func (h *Handler) ListFollowedCollections(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	collections, total, err := h.collectionService.ListFollowedCollections(c.GetUint("userID"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取关注的合集成功",
+		"status":  "success",
+		"data": gin.H{
+			"collections": collections,
+			"total":       total,
+			"page":        page,
+			"page_size":   pageSize,
+		},
+	})
+}
+
+// CreateCollection 创建合集
+func (h *Handler) CreateCollection(c *gin.Context) {
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+	isPublic := req.IsPublic == nil || *req.IsPublic
+
+	collection, err := h.collectionService.CreateCollection(c.GetUint("userID"), req.Name, req.Description, isPublic)
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "创建合集成功",
+		"status":  "success",
+		"data":    collection,
+	})
+}
+
+// GetCollection 获取合集详情及其资源
+func (h *Handler) GetCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+	page, pageSize := parsePagination(c)
+	viewerID, _ := h.getCurrentUserID(c)
+
+	collection, err := h.collectionService.GetCollection(collectionID, viewerID)
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	items, total, err := h.collectionService.GetCollectionItems(collectionID, viewerID, page, pageSize)
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	following := false
+	if viewerID != 0 {
+		following, _ = h.collectionService.IsFollowing(collectionID, viewerID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取合集成功",
+		"status":  "success",
+		"data": gin.H{
+			"collection": collection,
+			"items":      items,
+			"total":      total,
+			"following":  following,
+			"page":       page,
+			"page_size":  pageSize,
+		},
+	})
+}
+
+// UpdateCollection 更新合集信息
+func (h *Handler) UpdateCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req favorite.CollectionUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	collection, err := h.collectionService.UpdateCollection(collectionID, c.GetUint("userID"), &req)
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新合集成功",
+		"status":  "success",
+		"data":    collection,
+	})
+}
+
+// DeleteCollection 删除合集
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.collectionService.DeleteCollection(collectionID, c.GetUint("userID")); err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除合集成功",
+		"status":  "success",
+	})
+}
+
+// AddCollectionItem 向合集中添加资源
+func (h *Handler) AddCollectionItem(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req collectionItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	item, err := h.collectionService.AddItem(collectionID, c.GetUint("userID"), req.ResourceID, req.Note)
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "已添加到合集",
+		"status":  "success",
+		"data":    item,
+	})
+}
+
+// RemoveCollectionItem 从合集中移除资源
+func (h *Handler) RemoveCollectionItem(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+	resourceID, err := strconv.ParseUint(c.Param("resource_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的资源ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.collectionService.RemoveItem(collectionID, c.GetUint("userID"), uint(resourceID)); err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已从合集中移除",
+		"status":  "success",
+	})
+}
+
+// ReorderCollectionItems 调整合集中资源的顺序
+func (h *Handler) ReorderCollectionItems(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	var req collectionOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.collectionService.ReorderItems(collectionID, c.GetUint("userID"), req.ResourceIDs); err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "合集顺序已更新",
+		"status":  "success",
+	})
+}
+
+// FollowCollection 关注合集
+func (h *Handler) FollowCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.collectionService.Follow(collectionID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "关注合集成功",
+		"status":  "success",
+		"data": gin.H{
+			"following":       true,
+			"followers_count": count,
+		},
+	})
+}
+
+// UnfollowCollection 取消关注合集
+func (h *Handler) UnfollowCollection(c *gin.Context) {
+	collectionID, ok := parseCollectionID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.collectionService.Unfollow(collectionID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(favoriteErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已取消关注",
+		"status":  "success",
+		"data": gin.H{
+			"following":       false,
+			"followers_count": count,
+		},
+	})
+}
+
+// CollectionPage 合集页面（服务端渲染，Meta标签由SEO配置生成）
+func (h *Handler) CollectionPage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.String(http.StatusNotFound, "合集不存在")
+		return
+	}
+	collectionID := uint(id)
+	page, pageSize := parsePagination(c)
+	viewerID, _ := h.getCurrentUserID(c)
+
+	collection, err := h.collectionService.GetCollection(collectionID, viewerID)
+	if err != nil {
+		c.String(favoriteErrorStatus(err), err.Error())
+		return
+	}
+	items, total, err := h.collectionService.GetCollectionItems(collectionID, viewerID, page, pageSize)
+	if err != nil {
+		c.String(favoriteErrorStatus(err), err.Error())
+		return
+	}
+
+	owner := ""
+	if collection.User != nil {
+		owner = collection.User.Username
+	}
+	seoCtx := h.seoMiddleware.NewContext(model.SEOConfigTypeCollection, c.Request.URL.Path)
+	seoCtx.Collection = collection
+	pageSEO := h.seoMiddleware.SetPageSEO(c, seoCtx)
+	// 私有合集不允许搜索引擎收录
+	if !collection.IsPublic {
+		pageSEO.Meta["robots"] = "noindex, nofollow"
+	}
+
+	data := gin.H{
+		"SEO":        pageSEO,
+		"Collection": collection,
+		"Owner":      owner,
+		"Items":      items,
+		"Total":      total,
+		"Page":       page,
+		"HasMore":    int64(page*pageSize) < total,
+		"NextPage":   page + 1,
+	}
+	h.renderPage(c, http.StatusOK, "collection.html", data, nil)
+}
+
+// parsePagination 解析分页参数（默认第1页，每页20条，最多100条）
+func parsePagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+// parseFavoriteTarget 解析收藏对象类型和ID（失败时直接返回400）
+func parseFavoriteTarget(c *gin.Context) (model.FavoriteTargetType, uint, bool) {
+	targetType, err := favorite.ParseTargetType(c.Param("type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的收藏对象ID",
+			"status":  "error",
+		})
+		return "", 0, false
+	}
+	return targetType, uint(id), true
+}
+
+// parseCollectionID 解析合集ID（失败时直接返回400）
+func parseCollectionID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的合集ID",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// favoriteErrorStatus 将收藏和合集服务的错误映射为HTTP状态码
+func favoriteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, favorite.ErrTargetNotFound),
+		errors.Is(err, favorite.ErrCollectionNotFound),
+		errors.Is(err, favorite.ErrCollectionItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, favorite.ErrCollectionForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, favorite.ErrCollectionItemExists):
+		return http.StatusConflict
+	case errors.Is(err, favorite.ErrInvalidTarget),
+		errors.Is(err, favorite.ErrInvalidCollectionName),
+		errors.Is(err, favorite.ErrInvalidCollectionDesc),
+		errors.Is(err, favorite.ErrCollectionFull),
+		errors.Is(err, favorite.ErrFollowOwnCollection):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
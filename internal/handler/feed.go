@@ -0,0 +1,150 @@
+/*
+Package handlers defines RSS/Atom/JSON Feed HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/service/category"
+	"resource-share-site/internal/service/feed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feedExtensions 订阅源文件扩展名 → 格式
+var feedExtensions = map[string]string{
+	".xml":  feed.FormatRSS,
+	".rss":  feed.FormatRSS,
+	".atom": feed.FormatAtom,
+	".json": feed.FormatJSON,
+}
+
+// ==================== 订阅源处理器 ====================
+
+// Feed 站点订阅源（如 /feed/resources.xml、/feed/articles.atom、/feed/resources.json）
+func (h *Handler) Feed(c *gin.Context) {
+	name, format, ok := parseFeedName(c.Param("name"))
+	if !ok {
+		c.String(http.StatusNotFound, "订阅源不存在")
+		return
+	}
+
+	req := feed.Request{Format: format, Limit: parseFeedLimit(c), SelfPath: c.Request.URL.Path}
+	var (
+		doc *feed.Document
+		err error
+	)
+	switch name {
+	case "resources":
+		doc, err = h.feedService.Resources(req)
+	case "articles":
+		doc, err = h.feedService.Articles(req)
+	default:
+		c.String(http.StatusNotFound, "订阅源不存在")
+		return
+	}
+	h.writeFeed(c, doc, err)
+}
+
+// JSONFeed 站点资源的JSON Feed（/feed.json）
+func (h *Handler) JSONFeed(c *gin.Context) {
+	doc, err := h.feedService.Resources(feed.Request{
+		Format:   feed.FormatJSON,
+		Limit:    parseFeedLimit(c),
+		SelfPath: c.Request.URL.Path,
+	})
+	h.writeFeed(c, doc, err)
+}
+
+// CategoryFeed 分类资源订阅源（/feed/category/:id，默认RSS，可加 .atom 或 .json 扩展名）
+func (h *Handler) CategoryFeed(c *gin.Context) {
+	ref, format, ok := parseFeedName(c.Param("id"))
+	if !ok {
+		ref, format = c.Param("id"), feed.FormatRSS
+	}
+	id, err := strconv.ParseUint(ref, 10, 32)
+	if err != nil || id == 0 {
+		c.String(http.StatusNotFound, "分类不存在")
+		return
+	}
+
+	doc, err := h.feedService.Category(uint(id), feed.Request{
+		Format:   format,
+		Limit:    parseFeedLimit(c),
+		SelfPath: c.Request.URL.Path,
+	})
+	h.writeFeed(c, doc, err)
+}
+
+// writeFeed 输出订阅源（支持 If-None-Match 和 If-Modified-Since 条件请求）
+func (h *Handler) writeFeed(c *gin.Context, doc *feed.Document, err error) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, category.ErrCategoryNotFound) {
+			status = http.StatusNotFound
+		}
+		c.String(status, err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", h.feedService.CacheMaxAge()))
+	c.Header("ETag", doc.ETag)
+	c.Header("Last-Modified", doc.LastModified.Format(http.TimeFormat))
+
+	if feedNotModified(c.Request, doc) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, doc.ContentType, doc.Body)
+}
+
+// feedNotModified 判断客户端缓存是否仍然有效（有 If-None-Match 时忽略 If-Modified-Since）
+func feedNotModified(r *http.Request, doc *feed.Document) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == doc.ETag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		if t, err := time.Parse(http.TimeFormat, since); err == nil {
+			return !doc.LastModified.After(t)
+		}
+	}
+	return false
+}
+
+// parseFeedName 解析订阅源文件名（如 resources.atom），返回名称和格式
+func parseFeedName(file string) (string, string, bool) {
+	dot := strings.LastIndex(file, ".")
+	if dot <= 0 {
+		return "", "", false
+	}
+	format, ok := feedExtensions[file[dot:]]
+	if !ok {
+		return "", "", false
+	}
+	return file[:dot], format, true
+}
+
+// parseFeedLimit 解析 limit 参数（无效时返回0，使用默认条目数）
+func parseFeedLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
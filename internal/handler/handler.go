@@ -9,24 +9,40 @@ Date: 2025-11-02
 package handler
 
 import (
+	"context"
 	"errors"
 	"html/template"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"resource-share-site/internal/config"
 	"resource-share-site/internal/model"
 	"resource-share-site/internal/service/article"
 	"resource-share-site/internal/service/auth"
 	"resource-share-site/internal/service/category"
+	"resource-share-site/internal/service/comment"
+	"resource-share-site/internal/service/favorite"
+	"resource-share-site/internal/service/content"
+	"resource-share-site/internal/service/feed"
+	"resource-share-site/internal/service/filter"
 	"resource-share-site/internal/service/invitation"
+	"resource-share-site/internal/service/mail"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/oauth"
 	"resource-share-site/internal/service/points"
+	"resource-share-site/internal/service/reaction"
+	"resource-share-site/internal/service/recommendation"
 	"resource-share-site/internal/service/resource"
 	"resource-share-site/internal/service/seo"
+	"resource-share-site/internal/service/tag"
 	"resource-share-site/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
 )
 
@@ -34,6 +50,10 @@ import (
 type Handler struct {
 	db                  *gorm.DB
 	authService         *auth.AuthServiceImpl
+	accountService      *auth.AccountService
+	loginGuard          *auth.LoginGuard
+	twoFactorService    *auth.TwoFactorService
+	oauthService        *oauth.OAuthService
 	categoryService     *category.CategoryService
 	resourceService     *resource.ResourceService
 	invitationService   *invitation.InvitationService
@@ -41,13 +61,44 @@ type Handler struct {
 	seoService          *seo.ManagementService
 	articleService      *article.ArticleService
 	articleCommentService *article.ArticleCommentService
+	commentService      *comment.CommentService
+	commentModerationService *comment.ModerationService
+	sensitiveWords      *filter.Dictionary
+	tagService          *tag.TagService
+	recommendationService *recommendation.RecommendationService
+	favoriteService     *favorite.FavoriteService
+	ratingService       *resource.RatingService
+	reactionService     *reaction.ReactionService
+	collectionService   *favorite.CollectionService
+	seoConfigService    *seo.ConfigService
+	sitemapService      *seo.SitemapService
+	seoMiddleware       *seo.Middleware
+	seoAuditService     *seo.AuditService
+	rankImportService   *seo.RankImportService
+	indexingService     *seo.IndexingService
+	redirectService     *seo.RedirectService
+	feedService         *feed.FeedService
+	contentRenderer     *content.Renderer
+	reviewService       *resource.ReviewService
+	moderationService   *resource.ModerationService
+	earningService      *points.EarningService
+	notificationService *notification.NotificationService
 }
 
 // NewHandler 创建新的HTTP处理器
-func NewHandler(db *gorm.DB) *Handler {
-	return &Handler{
+// 参数：
+//   - db: 数据库连接
+//   - cfg: 应用配置（为nil时使用默认配置）
+func NewHandler(db *gorm.DB, cfg *config.AppConfig) *Handler {
+	cfg = withDefaultConfig(cfg)
+	mailer := mail.NewMailer(cfg.Mail)
+
+	h := &Handler{
 		db:                  db,
 		authService:         auth.NewAuthService(db).(*auth.AuthServiceImpl),
+		accountService:      auth.NewAccountService(db, mailer, cfg.Auth, cfg.App.SecretKey),
+		loginGuard:          auth.NewLoginGuard(db, cfg.Auth),
+		twoFactorService:    auth.NewTwoFactorService(db, cfg.Auth),
 		categoryService:     category.NewCategoryService(db),
 		resourceService:     resource.NewResourceService(db),
 		invitationService:   invitation.NewInvitationService(db),
@@ -55,7 +106,196 @@ func NewHandler(db *gorm.DB) *Handler {
 		seoService:          seo.NewManagementService(db),
 		articleService:      article.NewArticleService(db),
 		articleCommentService: article.NewArticleCommentService(db),
+		tagService:          tag.NewTagService(db),
+		recommendationService: recommendation.NewRecommendationService(db),
+		favoriteService:     favorite.NewFavoriteService(db),
+		ratingService:       resource.NewRatingService(db),
+		reactionService:     reaction.NewReactionService(db),
+		collectionService:   favorite.NewCollectionService(db),
+		seoConfigService:    seo.NewConfigService(db),
+		sitemapService:      seo.NewSitemapService(db),
+		feedService:         feed.NewFeedService(db),
+		contentRenderer:     content.NewRenderer(cfg.Content),
+		reviewService:       resource.NewReviewService(db),
+		moderationService:   resource.NewModerationService(db),
+		earningService:      points.NewEarningService(db),
+		notificationService: newNotificationService(db, mailer, cfg.Notification),
+	}
+
+	// 资源自动审核与人工审核队列
+	h.resourceService.SetModerator(h.moderationService)
+	h.reviewService.SetQueueConfig(cfg.Review)
+
+	// 敏感词过滤（资源、评论、文章和用户名共用同一词库，修改后自动热加载）
+	h.sensitiveWords = filter.NewDictionary(db, cfg.Filter)
+	h.resourceService.SetFilter(h.sensitiveWords)
+	h.articleService.SetFilter(h.sensitiveWords)
+	h.authService.SetFilter(h.sensitiveWords)
+	h.ratingService.SetFilter(h.sensitiveWords)
+
+	// 相关资源推荐（相似度表由后台任务定期重建）
+	h.recommendationService.SetConfig(cfg.Recommendation)
+
+	// 资源评分（低分提醒通过通知中心发送给审核员）
+	h.ratingService.SetConfig(cfg.Rating)
+
+	// Sitemap（站点地址和分片大小来自配置）
+	h.sitemapService.SetConfig(cfg.SEO)
+
+	// 订阅源（条目数和缓存时间来自配置）
+	h.feedService.SetConfig(cfg.Feed, cfg.SEO)
+
+	// 正文渲染（文章、资源简介、订阅源共用Markdown渲染和缓存）
+	h.articleService.SetRenderer(h.contentRenderer)
+	h.feedService.SetRenderer(h.contentRenderer)
+
+	// 页面SEO（服务端渲染页面的Meta标签和JSON-LD）
+	h.seoMiddleware = seo.NewMiddleware(h.seoConfigService)
+	h.seoMiddleware.SetConfig(cfg.SEO)
+
+	// SEO站点审计（路由注册后绑定到路由引擎，在进程内抓取页面）
+	h.seoAuditService = seo.NewAuditService(db)
+	h.seoAuditService.SetConfig(cfg.SEO)
+
+	// 关键词排名导入（排名大幅下降时通过通知中心提醒管理员）
+	h.rankImportService = seo.NewRankImportService(db)
+	h.rankImportService.SetConfig(cfg.SEO)
+
+	// 搜索引擎URL推送（资源上线、文章发布和内容删除时加入推送队列，由后台任务推送）
+	h.redirectService = seo.NewRedirectService(db)
+	h.indexingService = seo.NewIndexingService(db)
+	h.indexingService.SetConfig(cfg.Indexing, cfg.SEO.GetBaseURL())
+	h.reviewService.SetIndexer(h.indexingService)
+	h.moderationService.SetIndexer(h.indexingService)
+	h.resourceService.SetIndexer(h.indexingService)
+	h.articleService.SetIndexer(h.indexingService)
+
+	// 点赞与表态（配置Redis后热点对象的点赞数先在Redis中累加）
+	h.reactionService.SetConfig(cfg.Reaction)
+
+	// 评论审核（资源评论和文章评论共用审核流程）
+	h.commentModerationService = comment.NewModerationService(db)
+	h.commentModerationService.SetConfig(cfg.Comment)
+	h.commentModerationService.SetDictionary(h.sensitiveWords)
+	h.commentService = comment.NewCommentService(db)
+	h.commentService.SetModerator(h.commentModerationService)
+	h.articleCommentService.SetModerator(h.commentModerationService)
+
+	// 第三方登录
+	h.oauthService = oauth.NewOAuthService(db, h.authService, cfg.OAuth, cfg.Auth.SiteURL)
+
+	// 登录保护
+	h.authService.SetLoginGuard(h.loginGuard)
+	h.authService.SetTwoFactor(h.twoFactorService)
+
+	// 各业务服务通过通知中心发布事件
+	h.authService.SetNotifier(h.notificationService)
+	h.loginGuard.SetNotifier(h.notificationService)
+	h.invitationService.SetNotifier(h.notificationService)
+	h.mallService.SetNotifier(h.notificationService)
+	h.commentModerationService.SetNotifier(h.notificationService)
+	h.reviewService.SetNotifier(h.notificationService)
+	h.moderationService.SetNotifier(h.notificationService)
+	h.earningService.SetNotifier(h.notificationService)
+	h.resourceService.SetNotifier(h.notificationService)
+	h.articleService.SetNotifier(h.notificationService)
+	h.ratingService.SetNotifier(h.notificationService)
+	h.rankImportService.SetNotifier(h.notificationService)
+
+	// 未验证邮箱的用户限制
+	h.earningService.SetRequireVerifiedEmail(cfg.Auth.RestrictUnverified)
+
+	return h
+}
+
+// SetRedis 设置Redis客户端（用于缓冲热点对象的点赞数），需在 StartBackgroundJobs 之前调用
+func (h *Handler) SetRedis(client *redis.Client) {
+	h.reactionService.SetRedis(client)
+}
+
+// StartBackgroundJobs 启动后台定时任务（通知邮件和Webhook投递、相关资源推荐的相似度表重建、点赞数写回、搜索引擎URL推送、文章定时发布），ctx 结束时停止
+func (h *Handler) StartBackgroundJobs(ctx context.Context) {
+	go h.notificationService.Run(ctx)
+	go h.recommendationService.Run(ctx)
+	go h.reactionService.Run(ctx)
+	go h.indexingService.Run(ctx)
+	go h.articleService.Run(ctx)
+}
+
+// withDefaultConfig 为未配置的部分填充默认配置
+func withDefaultConfig(cfg *config.AppConfig) *config.AppConfig {
+	merged := config.AppConfig{}
+	if cfg != nil {
+		merged = *cfg
+	}
+
+	if merged.App == nil {
+		merged.App = &config.AppSettings{SecretKey: "your-secret-key-change-in-production"}
+	}
+	if merged.Mail == nil {
+		merged.Mail = config.DefaultMailConfig()
+	}
+	if merged.Notification == nil {
+		merged.Notification = config.DefaultNotificationConfig()
+	}
+	if merged.Auth == nil {
+		merged.Auth = config.DefaultAuthConfig()
+	}
+	if merged.OAuth == nil {
+		merged.OAuth = config.DefaultOAuthConfig()
+	}
+	if merged.Review == nil {
+		merged.Review = config.DefaultReviewConfig()
+	}
+	if merged.Comment == nil {
+		merged.Comment = config.DefaultCommentConfig()
+	}
+	if merged.Filter == nil {
+		merged.Filter = config.DefaultFilterConfig()
+	}
+	if merged.Recommendation == nil {
+		merged.Recommendation = config.DefaultRecommendationConfig()
+	}
+	if merged.Rating == nil {
+		merged.Rating = config.DefaultRatingConfig()
+	}
+	if merged.Reaction == nil {
+		merged.Reaction = config.DefaultReactionConfig()
+	}
+	if merged.SEO == nil {
+		merged.SEO = config.DefaultSEOConfig()
+	}
+	if merged.Indexing == nil {
+		merged.Indexing = config.DefaultIndexingConfig()
+	}
+	if merged.Feed == nil {
+		merged.Feed = config.DefaultFeedConfig()
 	}
+	if merged.Content == nil {
+		merged.Content = config.DefaultContentConfig()
+	}
+	if merged.SEO.SiteURL == "" {
+		seoConfig := *merged.SEO
+		seoConfig.SiteURL = merged.Auth.SiteURL
+		merged.SEO = &seoConfig
+	}
+
+	return &merged
+}
+
+// newNotificationService 根据配置创建通知服务并注册投递通道
+func newNotificationService(db *gorm.DB, mailer mail.Mailer, cfg *config.NotificationConfig) *notification.NotificationService {
+	service := notification.NewNotificationService(db)
+	service.SetQueue(cfg.QueueSize, cfg.Workers)
+	if cfg.EmailEnabled {
+		service.RegisterAsyncChannel(notification.NewEmailChannel(mailer))
+	}
+	if cfg.WebhookEnabled {
+		timeout := time.Duration(cfg.WebhookTimeout) * time.Second
+		service.RegisterAsyncChannel(notification.NewWebhookChannel(timeout, cfg.WebhookSecret))
+	}
+
+	return service
 }
 
 // getCurrentUserID 从请求中获取当前用户ID
@@ -73,7 +313,7 @@ func (h *Handler) getCurrentUserID(c *gin.Context) (uint, error) {
 	}
 
 	// 解析token
-	claims, err := utils.ParseToken(tokenString)
+	claims, err := h.parseUserToken(tokenString)
 	if err != nil {
 		return 0, err
 	}
@@ -81,21 +321,68 @@ func (h *Handler) getCurrentUserID(c *gin.Context) (uint, error) {
 	return claims.UserID, nil
 }
 
+// ErrTokenRevoked 令牌已因修改或重置密码而失效
+var ErrTokenRevoked = errors.New("登录已失效，请重新登录")
+
+// parseUserToken 解析token并校验其令牌版本与用户当前版本一致
+func (h *Handler) parseUserToken(tokenString string) (*utils.JWTClaims, error) {
+	claims, err := utils.ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := h.db.Select("id", "token_version").First(&user, claims.UserID).Error; err != nil {
+		return nil, ErrTokenRevoked
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
 // RegisterRoutes 注册所有路由
 func (h *Handler) RegisterRoutes(router *gin.Engine) {
-	// 主页路由
-	router.GET("/", h.HomePage)
+	h.seoAuditService.SetHandler(router)
 	router.GET("/health", h.HealthCheck)
 
-	// 前端页面路由 - 资源分享站
-	router.GET("/resources", h.ResourcesPage)
-	router.GET("/categories", h.CategoriesPage)
-	router.GET("/search", h.SearchPage)
-	router.GET("/resource/:id", h.ResourceDetailPage)
-	router.GET("/login", h.LoginPage)
-	router.GET("/register", h.RegisterPage)
-	router.GET("/articles", h.ArticlesPage)
-	router.GET("/article/:slug", h.ArticleDetailPage)
+	// 前端页面路由 - 资源分享站（SEO中间件为每个页面生成默认的Meta标签）
+	pages := router.Group("/")
+	pages.Use(h.seoMiddleware.SEOMiddleware())
+	{
+		pages.GET("/", h.HomePage)
+		pages.GET("/resources", h.ResourcesPage)
+		pages.GET("/categories", h.CategoriesPage)
+		pages.GET("/category/:id", h.CategoryPage)
+		pages.GET("/c/*path", h.CategoryPathPage)
+		pages.GET("/search", h.SearchPage)
+		pages.GET("/resource/:id", h.ResourceDetailPage)
+		pages.GET("/r/:ref", h.ResourceDetailPage)
+		pages.GET("/login", h.LoginPage)
+		pages.GET("/register", h.RegisterPage)
+		pages.GET("/articles", h.ArticlesPage)
+		pages.GET("/article/:slug", h.ArticleDetailPage)
+		pages.GET("/collection/:id", h.CollectionPage)
+	}
+
+	// Sitemap（索引和分片均支持 .gz 压缩格式）
+	router.GET("/sitemap_index.xml", h.SitemapIndex)
+	router.GET("/sitemap_index.xml.gz", h.SitemapIndex)
+	router.GET("/sitemaps/:file", h.SitemapShard)
+
+	// 订阅源（RSS 2.0、Atom 1.0、JSON Feed 1.1，按扩展名区分格式）
+	router.GET("/feed.json", h.JSONFeed)
+	router.GET("/feed/:name", h.Feed)
+	router.GET("/feed/category/:id", h.CategoryFeed)
+
+	// 代码高亮样式表
+	router.GET("/assets/highlight.css", h.HighlightCSS)
+
+	// IndexNow密钥文件（配置了IndexNow密钥时才注册）
+	if keyPath, _ := h.indexingService.KeyFile(); keyPath != "" {
+		router.GET(keyPath, h.IndexNowKeyFile)
+	}
 
 	// 认证相关路由
 	auth := router.Group("/auth")
@@ -104,6 +391,36 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		auth.POST("/login", h.Login)
 		auth.POST("/logout", h.Logout)
 		auth.GET("/me", h.GetCurrentUser)
+
+		// 邮箱验证、密码重置与修改邮箱
+		auth.POST("/verify-email/send", h.AuthRequired, h.SendVerificationEmail)
+		auth.GET("/verify-email", h.VerifyEmail)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
+		auth.POST("/change-email", h.AuthRequired, h.RequestEmailChange)
+		auth.GET("/change-email/confirm", h.ConfirmEmailChange)
+		auth.POST("/change-email/confirm", h.ConfirmEmailChange)
+
+		// 登录历史
+		auth.GET("/login-history", h.AuthRequired, h.GetLoginHistory)
+
+		// 两步验证
+		auth.POST("/login/2fa", h.TwoFactorLogin)
+		auth.POST("/login/2fa/setup", h.TwoFactorLoginSetup)
+		auth.GET("/2fa/status", h.AuthRequired, h.GetTwoFactorStatus)
+		auth.POST("/2fa/setup", h.AuthRequired, h.SetupTwoFactor)
+		auth.POST("/2fa/enable", h.AuthRequired, h.EnableTwoFactor)
+		auth.POST("/2fa/disable", h.AuthRequired, h.DisableTwoFactor)
+		auth.POST("/2fa/recovery-codes", h.AuthRequired, h.RegenerateRecoveryCodes)
+
+		// 第三方登录与账户关联
+		auth.GET("/oauth/providers", h.ListOAuthProviders)
+		auth.GET("/oauth/:provider/authorize", h.OAuthAuthorize)
+		auth.GET("/oauth/:provider/callback", h.OAuthCallback)
+		auth.POST("/oauth/:provider/link", h.AuthRequired, h.LinkOAuthIdentity)
+		auth.GET("/identities", h.AuthRequired, h.ListIdentities)
+		auth.DELETE("/identities/:provider", h.AuthRequired, h.UnlinkIdentity)
 	}
 
 	// 用户相关路由
@@ -111,6 +428,7 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	{
 		users.GET("/", h.ListUsers)
 		users.GET("/:id", h.GetUser)
+		users.GET("/:id/collections", h.ListUserCollections)
 	}
 
 	// 分类相关路由
@@ -126,7 +444,26 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	{
 		resources.GET("/", h.ListResources)
 		resources.POST("/", h.CreateResource)
+		resources.GET("/recommended", h.AuthRequired, h.GetRecommendedResources)
 		resources.GET("/:id", h.GetResource)
+		resources.PUT("/:id", h.AuthRequired, h.UpdateResource)
+		resources.GET("/:id/revisions", h.AuthRequired, h.ListResourceRevisions)
+		resources.GET("/:id/revisions/compare", h.AuthRequired, h.CompareResourceRevisions)
+		resources.GET("/:id/revisions/:version", h.AuthRequired, h.GetResourceRevision)
+		resources.GET("/:id/related", h.GetRelatedResources)
+		resources.POST("/:id/download", h.AuthRequired, h.DownloadResource)
+		resources.GET("/:id/ratings", h.ListResourceRatings)
+		resources.GET("/:id/rating", h.AuthRequired, h.GetMyResourceRating)
+		resources.PUT("/:id/rating", h.AuthRequired, h.RateResource)
+		resources.DELETE("/:id/rating", h.AuthRequired, h.DeleteResourceRating)
+	}
+
+	// 标签相关路由
+	tags := router.Group("/tags")
+	{
+		tags.GET("/", h.ListTags)
+		tags.GET("/popular", h.GetPopularTagList)
+		tags.GET("/:slug", h.GetTagPage)
 	}
 
 	// 评论相关路由
@@ -156,13 +493,13 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	apiComments := router.Group("/api/comments")
 	{
 		apiComments.POST("/", h.CreateCommentAPI)
-		apiComments.POST("/:id/like", h.LikeCommentAPI)
+		apiComments.POST("/:id/like", h.AuthRequired, h.LikeCommentAPI)
 	}
 
 	// 文章API路由
 	apiArticles := router.Group("/api/articles")
 	{
-		apiArticles.POST("/:id/like", h.LikeArticleAPI)
+		apiArticles.POST("/:id/like", h.AuthRequired, h.LikeArticleAPI)
 	}
 
 	// 需要管理员权限的路由
@@ -170,8 +507,152 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	admin.Use(h.AuthRequired)
 	{
 		admin.GET("/", h.AdminPage)
-		admin.POST("/articles", h.CreateArticle)
 		admin.POST("/articles/:id/like", h.LikeArticle)
+
+		// 文章编辑流程（作者和审核员，权限由服务层按文章和操作检查）
+		admin.GET("/articles", h.ListEditorialArticles)
+		admin.POST("/articles", h.CreateArticle)
+		admin.PUT("/articles/:id", h.UpdateArticle)
+		admin.POST("/articles/:id/autosave", h.AutosaveArticle)
+		admin.POST("/articles/:id/submit", h.SubmitArticle)
+		admin.POST("/articles/:id/approve", h.ReviewerRequired, h.ApproveArticle)
+		admin.POST("/articles/:id/reject", h.ReviewerRequired, h.RejectArticle)
+		admin.POST("/articles/:id/schedule", h.ReviewerRequired, h.ScheduleArticle)
+		admin.POST("/articles/:id/publish", h.ReviewerRequired, h.PublishArticle)
+		admin.POST("/articles/:id/unpublish", h.ReviewerRequired, h.UnpublishArticle)
+		admin.POST("/articles/:id/archive", h.ArchiveArticle)
+		admin.POST("/articles/:id/unarchive", h.UnarchiveArticle)
+		admin.GET("/articles/:id/revisions", h.ListArticleRevisions)
+		admin.GET("/articles/:id/revisions/compare", h.CompareArticleRevisions)
+		admin.GET("/articles/:id/revisions/:version", h.GetArticleRevision)
+		admin.POST("/articles/:id/revisions/:version/restore", h.RestoreArticleRevision)
+		admin.POST("/orders/:id/ship", h.AdminRequired, h.ShipOrder)
+		admin.GET("/login-anomalies", h.AdminRequired, h.ListLoginAnomalies)
+		admin.POST("/users/:id/2fa/reset", h.AdminRequired, h.AdminResetTwoFactor)
+
+		// 自动审核规则
+		admin.GET("/moderation/rules", h.AdminRequired, h.ListModerationRules)
+		admin.POST("/moderation/rules", h.AdminRequired, h.CreateModerationRule)
+		admin.PUT("/moderation/rules/:id", h.AdminRequired, h.UpdateModerationRule)
+		admin.DELETE("/moderation/rules/:id", h.AdminRequired, h.DeleteModerationRule)
+		admin.POST("/moderation/dry-run", h.AdminRequired, h.DryRunModeration)
+		admin.POST("/moderation/resources/:id/run", h.AdminRequired, h.RunModeration)
+
+		// 评论审核与敏感词库
+		admin.GET("/comments/pending", h.ReviewerRequired, h.ListPendingComments)
+		admin.POST("/comments/moderate", h.ReviewerRequired, h.ModerateComments)
+		admin.GET("/sensitive-words", h.AdminRequired, h.ListSensitiveWords)
+		admin.POST("/sensitive-words", h.AdminRequired, h.AddSensitiveWords)
+		admin.PUT("/sensitive-words/:id", h.AdminRequired, h.UpdateSensitiveWord)
+		admin.DELETE("/sensitive-words/:id", h.AdminRequired, h.DeleteSensitiveWord)
+		admin.POST("/sensitive-words/reload", h.AdminRequired, h.ReloadSensitiveWords)
+		admin.POST("/sensitive-words/check", h.AdminRequired, h.CheckSensitiveText)
+
+		// 标签管理
+		admin.POST("/tags", h.AdminRequired, h.CreateTag)
+		admin.PUT("/tags/:id", h.AdminRequired, h.UpdateTag)
+		admin.DELETE("/tags/:id", h.AdminRequired, h.DeleteTag)
+		admin.POST("/tags/:id/synonyms", h.AdminRequired, h.AddTagSynonym)
+		admin.POST("/tags/merge", h.AdminRequired, h.MergeTags)
+		admin.POST("/tags/recount", h.AdminRequired, h.RecountTags)
+
+		// 相关资源推荐
+		admin.POST("/recommendations/rebuild", h.AdminRequired, h.RebuildRecommendations)
+
+		// 资源评分
+		admin.POST("/ratings/recalculate", h.AdminRequired, h.RecalculateRatings)
+		admin.GET("/ratings/pending", h.ReviewerRequired, h.ListPendingRatings)
+		admin.POST("/ratings/:id/approve", h.ReviewerRequired, h.ApproveRating)
+		admin.POST("/ratings/:id/reject", h.ReviewerRequired, h.RejectRating)
+		admin.POST("/reactions/recount", h.AdminRequired, h.RecountLikes)
+
+		// SEO配置与模板预览
+		admin.GET("/seo/configs", h.AdminRequired, h.ListSEOConfigs)
+		admin.POST("/seo/configs", h.AdminRequired, h.CreateSEOConfig)
+		admin.PUT("/seo/configs/:id", h.AdminRequired, h.UpdateSEOConfig)
+		admin.DELETE("/seo/configs/:id", h.AdminRequired, h.DeleteSEOConfig)
+		admin.POST("/seo/preview", h.AdminRequired, h.PreviewSEOTemplates)
+		admin.POST("/seo/audits", h.AdminRequired, h.RunSEOAudit)
+		admin.GET("/seo/audits", h.AdminRequired, h.ListSEOAudits)
+		admin.GET("/seo/audits/:id/pages", h.AdminRequired, h.ListSEOAuditPages)
+		admin.POST("/seo/rank-imports", h.AdminRequired, h.ImportKeywordRanks)
+		admin.GET("/seo/rank-imports", h.AdminRequired, h.ListRankImports)
+		admin.GET("/seo/keywords/:id/ranks", h.AdminRequired, h.GetKeywordRanks)
+		admin.GET("/seo/keywords/:id/performance", h.AdminRequired, h.GetKeywordPerformance)
+		admin.GET("/seo/events", h.AdminRequired, h.ListSEOEvents)
+		admin.GET("/seo/index-submissions", h.AdminRequired, h.ListIndexSubmissions)
+		admin.POST("/seo/index-submissions", h.AdminRequired, h.SubmitIndexURLs)
+		admin.POST("/seo/index-submissions/flush", h.AdminRequired, h.FlushIndexQueue)
+		admin.GET("/seo/redirects", h.AdminRequired, h.ListSEORedirects)
+		admin.POST("/seo/redirects", h.AdminRequired, h.CreateSEORedirect)
+		admin.POST("/seo/redirects/import", h.AdminRequired, h.ImportSEORedirects)
+		admin.PUT("/seo/redirects/:id", h.AdminRequired, h.UpdateSEORedirect)
+		admin.DELETE("/seo/redirects/:id", h.AdminRequired, h.DeleteSEORedirect)
+
+		// 人工审核工作台
+		admin.GET("/reviews/queue", h.ReviewerRequired, h.GetReviewQueue)
+		admin.POST("/reviews/claim-next", h.ReviewerRequired, h.ClaimNextReview)
+		admin.POST("/reviews/:id/claim", h.ReviewerRequired, h.ClaimReview)
+		admin.DELETE("/reviews/:id/claim", h.ReviewerRequired, h.ReleaseReviewClaim)
+		admin.POST("/reviews/:id/approve", h.ReviewerRequired, h.ApproveReview)
+		admin.POST("/reviews/:id/reject", h.ReviewerRequired, h.RejectReview)
+		admin.POST("/reviews/:id/revert", h.ReviewerRequired, h.RevertReview)
+		admin.GET("/reviews/statistics", h.ReviewerRequired, h.GetReviewStatistics)
+		admin.GET("/reviews/reject-templates", h.ReviewerRequired, h.ListRejectTemplates)
+		admin.POST("/reviews/reject-templates", h.AdminRequired, h.CreateRejectTemplate)
+		admin.PUT("/reviews/reject-templates/:id", h.AdminRequired, h.UpdateRejectTemplate)
+		admin.DELETE("/reviews/reject-templates/:id", h.AdminRequired, h.DeleteRejectTemplate)
+
+		// 资源修订审核与回滚
+		admin.GET("/revisions/pending", h.ReviewerRequired, h.ListPendingRevisions)
+		admin.POST("/revisions/:id/approve", h.ReviewerRequired, h.ApproveRevision)
+		admin.POST("/revisions/:id/reject", h.ReviewerRequired, h.RejectRevision)
+		admin.POST("/resources/:id/rollback", h.AdminRequired, h.RollbackResource)
+	}
+
+	// 收藏路由
+	favorites := router.Group("/favorites")
+	favorites.Use(h.AuthRequired)
+	{
+		favorites.GET("/", h.ListFavorites)
+		favorites.POST("/:type/:id", h.AddFavorite)
+		favorites.DELETE("/:type/:id", h.RemoveFavorite)
+	}
+
+	// 点赞与表态路由（type: article / article_comment）
+	reactions := router.Group("/reactions")
+	{
+		reactions.GET("/:type/:id", h.GetReactions)
+		reactions.POST("/:type/:id", h.AuthRequired, h.ToggleReaction)
+	}
+
+	// 资源合集路由
+	collections := router.Group("/collections")
+	{
+		collections.GET("/", h.ListPublicCollections)
+		collections.POST("/", h.AuthRequired, h.CreateCollection)
+		collections.GET("/followed", h.AuthRequired, h.ListFollowedCollections)
+		collections.GET("/:id", h.GetCollection)
+		collections.PUT("/:id", h.AuthRequired, h.UpdateCollection)
+		collections.DELETE("/:id", h.AuthRequired, h.DeleteCollection)
+		collections.POST("/:id/items", h.AuthRequired, h.AddCollectionItem)
+		collections.PUT("/:id/items/order", h.AuthRequired, h.ReorderCollectionItems)
+		collections.DELETE("/:id/items/:resource_id", h.AuthRequired, h.RemoveCollectionItem)
+		collections.POST("/:id/follow", h.AuthRequired, h.FollowCollection)
+		collections.DELETE("/:id/follow", h.AuthRequired, h.UnfollowCollection)
+	}
+
+	// 通知中心路由
+	notifications := router.Group("/notifications")
+	notifications.Use(h.AuthRequired)
+	{
+		notifications.GET("/", h.ListNotifications)
+		notifications.GET("/unread-count", h.GetUnreadNotificationCount)
+		notifications.POST("/read", h.MarkNotificationsRead)
+		notifications.POST("/read-all", h.MarkAllNotificationsRead)
+		notifications.POST("/delete", h.DeleteNotifications)
+		notifications.GET("/preferences", h.GetNotificationPreferences)
+		notifications.PUT("/preferences", h.UpdateNotificationPreference)
 	}
 
 	// 邀请相关路由
@@ -199,7 +680,7 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 	// SEO相关路由
 	seo := router.Group("/seo")
 	{
-		seo.GET("/sitemap.xml", h.GenerateSitemap)
+		seo.GET("/sitemap.xml", h.SitemapIndex)
 		seo.GET("/keywords", h.ListKeywords)
 	}
 
@@ -214,9 +695,7 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 
 // HomePage 主页
 func (h *Handler) HomePage(c *gin.Context) {
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/index.html"))
-	tmpl.Execute(c.Writer, nil)
+	h.renderPage(c, http.StatusOK, "index.html", nil, nil)
 }
 
 // HealthCheck 健康检查
@@ -252,6 +731,9 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
+	// 发送邮箱验证邮件（发送失败不影响注册，用户可稍后重新发送）
+	_ = h.accountService.SendVerificationEmail(response.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "注册成功",
 		"status":  "success",
@@ -270,9 +752,23 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	req.IP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	authCtx := &auth.GORMContext{DB: h.db}
 	response, err := h.authService.Login(authCtx, &req)
 	if err != nil {
+		// 登录被限制时返回429及重试时间
+		var blocked *auth.LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"message": err.Error(),
+				"status":  "error",
+			})
+			return
+		}
+
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": err.Error(),
 			"status":  "error",
@@ -280,6 +776,15 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
+	if response.TwoFactorRequired {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "请完成两步验证",
+			"status":  "success",
+			"data":    response,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "登录成功",
 		"status":  "success",
@@ -315,7 +820,7 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 	}
 
 	// 解析token
-	claims, err := utils.ParseToken(tokenString)
+	claims, err := h.parseUserToken(tokenString)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"message": "无效的token: " + err.Error(),
@@ -346,6 +851,7 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 		"id":                        user.ID,
 		"username":                  user.Username,
 		"email":                     user.Email,
+		"email_verified":            user.EmailVerified,
 		"role":                      user.Role,
 		"status":                    user.Status,
 		"can_upload":                user.CanUpload,
@@ -567,6 +1073,15 @@ func (h *Handler) GetCategory(c *gin.Context) {
 
 // ==================== 资源相关处理器 ====================
 
+// resourceSortFields 资源列表支持的排序方式及对应的排序字段
+var resourceSortFields = map[string]string{
+	"latest":    "created_at",
+	"downloads": "downloads_count",
+	"views":     "views_count",
+	"favorites": "favorites_count",
+	"rating":    "rating",
+}
+
 // ListResources 列出资源
 func (h *Handler) ListResources(c *gin.Context) {
 	// 获取查询参数
@@ -580,7 +1095,16 @@ func (h *Handler) ListResources(c *gin.Context) {
 		categoryID = &id32
 	}
 
-	resources, total, err := h.resourceService.GetResources(page, pageSize, categoryID, nil, nil, nil, nil, "created_at", true)
+	// 标签筛选（tags 为逗号分隔的标签，tag_mode=and 时需包含全部标签）
+	tagFilter := tag.NewFilter(c.Query("tags"), c.Query("tag_mode"))
+
+	// 排序方式（latest 最新, downloads 下载最多, views 浏览最多, favorites 收藏最多, rating 评分最高）
+	orderBy, ok := resourceSortFields[c.DefaultQuery("sort", "latest")]
+	if !ok {
+		orderBy = "created_at"
+	}
+
+	resources, total, err := h.resourceService.GetResources(page, pageSize, categoryID, nil, nil, nil, nil, orderBy, true, tagFilter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "查询资源失败: " + err.Error(),
@@ -631,7 +1155,7 @@ func (h *Handler) CreateResource(c *gin.Context) {
 	}
 
 	// 检查用户是否有上传权限
-	var user map[string]interface{}
+	var user model.User
 	if err := h.db.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"message": "用户不存在",
@@ -640,7 +1164,7 @@ func (h *Handler) CreateResource(c *gin.Context) {
 		return
 	}
 
-	if !user["can_upload"].(bool) {
+	if !user.CanUpload {
 		c.JSON(http.StatusForbidden, gin.H{
 			"message": "您没有上传权限，请联系管理员",
 			"status":  "error",
@@ -648,46 +1172,36 @@ func (h *Handler) CreateResource(c *gin.Context) {
 		return
 	}
 
-	// 检查分类是否存在
-	var categoryCount int64
-	if err := h.db.Model(&map[string]interface{}{}).Where("id = ?", req.CategoryID).Count(&categoryCount).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "检查分类失败",
+	// 检查邮箱验证状态
+	if err := h.accountService.RequireVerifiedEmail(userID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": err.Error(),
 			"status":  "error",
 		})
 		return
 	}
 
-	if categoryCount == 0 {
+	// 创建资源（分类校验和自动审核规则由资源服务处理）
+	resource, err := h.resourceService.CreateResource(req.Title, req.Description, req.CategoryID,
+		req.NetdiskURL, req.PointsPrice, req.Tags, userID, model.ResourceSourceUser)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "分类不存在",
+			"message": "创建资源失败: " + err.Error(),
 			"status":  "error",
 		})
 		return
 	}
 
-	// 创建资源
-	resource := map[string]interface{}{
-		"title":         req.Title,
-		"description":   req.Description,
-		"category_id":   req.CategoryID,
-		"netdisk_url":   req.NetdiskURL,
-		"points_price":  req.PointsPrice,
-		"tags":          req.Tags,
-		"uploaded_by_id": userID,
-		"status":        "pending", // 等待审核
-	}
-
-	if err := h.db.Create(&resource).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "创建资源失败",
-			"status":  "error",
-		})
-		return
+	message := "资源创建成功，等待审核"
+	switch resource.Status {
+	case model.ResourceStatusApproved:
+		message = "资源创建成功，已自动通过审核"
+	case model.ResourceStatusRejected:
+		message = "资源未通过自动审核: " + resource.ReviewNotes
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "资源创建成功，等待审核",
+		"message": message,
 		"status":  "success",
 		"data":    resource,
 	})
@@ -722,11 +1236,17 @@ func (h *Handler) GetResource(c *gin.Context) {
 
 // ==================== 评论相关处理器 ====================
 
-// ListComments 列出评论
+// ListComments 列出资源评论（已通过审核的评论树）
 func (h *Handler) ListComments(c *gin.Context) {
 	resourceID, _ := strconv.Atoi(c.DefaultQuery("resource_id", "0"))
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
 
 	if resourceID == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -736,19 +1256,8 @@ func (h *Handler) ListComments(c *gin.Context) {
 		return
 	}
 
-	var comments []map[string]interface{}
-	var total int64
-
-	if err := h.db.Model(&map[string]interface{}{}).Where("resource_id = ?", resourceID).Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "查询评论失败",
-			"status":  "error",
-		})
-		return
-	}
-
-	offset := (page - 1) * pageSize
-	if err := h.db.Where("resource_id = ?", resourceID).Offset(offset).Limit(pageSize).Find(&comments).Error; err != nil {
+	comments, total, err := h.commentService.GetCommentTree(uint(resourceID), page, pageSize)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "查询评论失败",
 			"status":  "error",
@@ -768,7 +1277,7 @@ func (h *Handler) ListComments(c *gin.Context) {
 	})
 }
 
-// CreateComment 创建评论
+// CreateComment 创建评论（经评论审核流程）
 func (h *Handler) CreateComment(c *gin.Context) {
 	var req struct {
 		ResourceID uint   `json:"resource_id" binding:"required"`
@@ -794,25 +1303,22 @@ func (h *Handler) CreateComment(c *gin.Context) {
 		return
 	}
 
-	// 记录评论
-	comment := map[string]interface{}{
-		"resource_id": req.ResourceID,
-		"user_id":     userID,
-		"content":     req.Content,
-		"parent_id":   req.ParentID,
-		"status":      "active",
-	}
-
-	if err := h.db.Create(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "创建评论失败",
+	comment, err := h.commentService.CreateComment(userID, req.ResourceID, req.Content, req.ParentID)
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": "创建评论失败: " + err.Error(),
 			"status":  "error",
 		})
 		return
 	}
 
+	message := "评论提交成功，等待审核"
+	if comment.Status == model.CommentStatusApproved {
+		message = "评论发表成功"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "评论创建成功",
+		"message": message,
 		"status":  "success",
 		"data":    comment,
 	})
@@ -829,10 +1335,10 @@ func (h *Handler) GetComment(c *gin.Context) {
 		return
 	}
 
-	var comment map[string]interface{}
-	if err := h.db.First(&comment, uint(id)).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "评论不存在",
+	comment, err := h.commentService.GetComment(uint(id))
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": err.Error(),
 			"status":  "error",
 		})
 		return
@@ -845,7 +1351,7 @@ func (h *Handler) GetComment(c *gin.Context) {
 	})
 }
 
-// DeleteComment 删除评论
+// DeleteComment 删除评论（评论作者、管理员或版主）
 func (h *Handler) DeleteComment(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -856,10 +1362,18 @@ func (h *Handler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	// TODO: 检查权限，确保只有评论作者或管理员可以删除
-	if err := h.db.Delete(&map[string]interface{}{}, uint(id)).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "删除评论失败",
+	userID, err := h.getCurrentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "认证失败: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.commentService.DeleteComment(uint(id), userID); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{
+			"message": "删除评论失败: " + err.Error(),
 			"status":  "error",
 		})
 		return
@@ -1041,6 +1555,15 @@ func (h *Handler) DailyCheckin(c *gin.Context) {
 		return
 	}
 
+	// 检查邮箱验证状态
+	if err := h.accountService.RequireVerifiedEmail(userID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
 	// 检查今天是否已经签到
 	today := time.Now().Format("2006-01-02")
 	var count int64
@@ -1189,28 +1712,67 @@ func (h *Handler) PurchaseProduct(c *gin.Context) {
 	})
 }
 
-// ==================== SEO相关处理器 ====================
+// ShipOrder 订单发货（管理员）
+func (h *Handler) ShipOrder(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的订单ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" binding:"max=255"`
+	}
+	// 备注可选，允许空请求体
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.mallService.ShipOrder(uint(orderID), req.Note); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
 
-// GenerateSitemap 生成Sitemap
-func (h *Handler) GenerateSitemap(c *gin.Context) {
-	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
-<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
-  <url>
-    <loc>http://localhost:8080/</loc>
-    <changefreq>daily</changefreq>
-    <priority>1.0</priority>
-  </url>
-</urlset>`
-
-	c.Header("Content-Type", "application/xml")
-	c.String(http.StatusOK, sitemap)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "订单已发货",
+		"status":  "success",
+	})
 }
 
-// ListKeywords 列出关键词
+// ==================== SEO相关处理器 ====================
+
+// ListKeywords 列出启用的关键词（可按分类和语言筛选）
 func (h *Handler) ListKeywords(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	active := true
+	keywords, total, err := h.seoService.ListKeywords(c.Query("category"), c.Query("language"), &active, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "✅ 列出关键词接口 - 功能已就绪",
+		"message": "获取关键词列表成功",
 		"status":  "success",
+		"data": gin.H{
+			"keywords":  keywords,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
 	})
 }
 
@@ -1234,53 +1796,98 @@ func (h *Handler) GetSystemStatistics(c *gin.Context) {
 
 // ResourcesPage 资源列表页面
 func (h *Handler) ResourcesPage(c *gin.Context) {
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/resources.html"))
-	tmpl.Execute(c.Writer, nil)
+	h.renderPage(c, http.StatusOK, "resources.html", nil, nil)
 }
 
 // CategoriesPage 分类浏览页面
 func (h *Handler) CategoriesPage(c *gin.Context) {
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/categories.html"))
-	tmpl.Execute(c.Writer, nil)
+	h.renderPage(c, http.StatusOK, "categories.html", nil, nil)
 }
 
 // SearchPage 搜索结果页面
 func (h *Handler) SearchPage(c *gin.Context) {
-	query := c.DefaultQuery("q", "")
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/search.html"))
+	query := strings.TrimSpace(c.DefaultQuery("q", ""))
+	page, pageSize := parsePagination(c)
+
+	var resources []*model.Resource
+	var total int64
+	if query != "" {
+		approved := model.ResourceStatusApproved
+		var err error
+		resources, total, err = h.resourceService.SearchResources(query, page, pageSize, nil, &approved)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
 	data := gin.H{
-		"Query": query,
+		"Query":     query,
+		"Resources": resources,
+		"Total":     total,
+		"Page":      page,
+		"HasPrev":   page > 1,
+		"PrevPage":  page - 1,
+		"HasNext":   int64(page*pageSize) < total,
+		"NextPage":  page + 1,
 	}
-	tmpl.Execute(c.Writer, data)
+	h.renderPage(c, http.StatusOK, "search.html", data, nil)
 }
 
-// ResourceDetailPage 资源详情页面
+// ResourceDetailPage 资源详情页面（/r/ID-slug，旧的ID地址301跳转到当前地址）
 func (h *Handler) ResourceDetailPage(c *gin.Context) {
-	id := c.Param("id")
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/resource-detail.html"))
+	ref := c.Param("ref")
+	if ref == "" {
+		ref = c.Param("id")
+	}
+	id, _, ok := seo.ParseResourceRef(ref)
+	if !ok {
+		c.String(http.StatusNotFound, "资源不存在")
+		return
+	}
+
+	// 只展示已审核通过的资源
+	res, err := h.resourceService.GetResourceByID(id, false)
+	if err != nil || res.Status != model.ResourceStatusApproved {
+		c.String(http.StatusNotFound, "资源不存在")
+		return
+	}
+
+	// 旧的ID地址或slug已变化时301跳转到当前地址
+	canonical := seo.ResourcePath(res.ID, res.Slug)
+	if c.Request.URL.Path != canonical {
+		permanentRedirect(c, canonical)
+		return
+	}
+
+	seoCtx := h.seoMiddleware.NewContext(model.SEOConfigTypeResource, canonical)
+	seoCtx.Resource = res
+	seoCtx.CategoryObj = res.Category
+	seoCtx.CategoryPath, _ = h.categoryService.GetCategoryPath(res.CategoryID)
+
+	// 简介按Markdown渲染
+	var description template.HTML
+	if rendered, err := h.contentRenderer.Render(content.KindResource, res.ID, res.Description); err == nil {
+		description = template.HTML(rendered.HTML)
+	}
+
 	data := gin.H{
-		"Title": "Go语言入门教程",
-		"ID":    id,
+		"SEO":             h.seoMiddleware.SetPageSEO(c, seoCtx),
+		"Resource":        res,
+		"DescriptionHTML": description,
+		"Tags":            seo.ResourceTagNames(res.Tags),
 	}
-	tmpl.Execute(c.Writer, data)
+	h.renderPage(c, http.StatusOK, "resource-detail.html", data, nil)
 }
 
 // LoginPage 登录页面
 func (h *Handler) LoginPage(c *gin.Context) {
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/login.html"))
-	tmpl.Execute(c.Writer, nil)
+	h.renderPage(c, http.StatusOK, "login.html", nil, nil)
 }
 
 // RegisterPage 注册页面
 func (h *Handler) RegisterPage(c *gin.Context) {
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/register.html"))
-	tmpl.Execute(c.Writer, nil)
+	h.renderPage(c, http.StatusOK, "register.html", nil, nil)
 }
 
 // ==================== 中间件 ====================
@@ -1312,27 +1919,52 @@ func (h *Handler) AdminRequired(c *gin.Context) {
 	}
 
 	// 检查是否为管理员
-	// TODO: 完善管理员检查逻辑
+	if err := auth.NewPermissionService(h.db).RequireAdmin(userID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "权限不足: " + err.Error(),
+			"status":  "error",
+		})
+		c.Abort()
+		return
+	}
+	c.Set("userID", userID)
+}
+
+// ReviewerRequired 需要审核员（管理员或版主）权限的中间件
+func (h *Handler) ReviewerRequired(c *gin.Context) {
+	userID, err := h.getCurrentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "认证失败: " + err.Error(),
+			"status":  "error",
+		})
+		c.Abort()
+		return
+	}
+
+	if err := auth.NewPermissionService(h.db).RequireReviewer(userID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "权限不足: " + err.Error(),
+			"status":  "error",
+		})
+		c.Abort()
+		return
+	}
 	c.Set("userID", userID)
 }
 
 // ==================== 文章相关处理器 ====================
 
-// ListArticles 列出文章
+// ListArticles 列出已发布的文章（其他状态的文章通过 /admin/articles 查看）
 func (h *Handler) ListArticles(c *gin.Context) {
 	_, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
 	_, _ = strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	status := c.Query("status")
 	category := c.Query("category")
 	keyword := c.Query("keyword")
 
-	var statusPtr *model.ArticleStatus
-	if status != "" {
-		s := model.ArticleStatus(status)
-		statusPtr = &s
-	}
+	tagFilter := tag.NewFilter(c.Query("tags"), c.Query("tag_mode"))
 
-	articles, total, err := h.articleService.GetArticles(1, 10, statusPtr, category, keyword)
+	articles, total, err := h.articleService.GetArticles(1, 10, nil, category, keyword, tagFilter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "查询文章失败: " + err.Error(),
@@ -1340,6 +1972,7 @@ func (h *Handler) ListArticles(c *gin.Context) {
 		})
 		return
 	}
+	h.fillArticleLikes(c, articles)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "获取文章列表成功",
@@ -1373,8 +2006,21 @@ func (h *Handler) GetArticle(c *gin.Context) {
 		return
 	}
 
+	// 未发布的文章只有作者和审核员可以查看
+	if article.Status != model.ArticleStatusPublished {
+		viewerID, _ := h.getCurrentUserID(c)
+		if !h.articleService.CanView(article, viewerID) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "文章不存在",
+				"status":  "error",
+			})
+			return
+		}
+	}
+
 	// 增加浏览数
 	h.articleService.IncrementViewCount(uint(id))
+	h.fillArticleLike(c, article)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "获取文章成功",
@@ -1383,7 +2029,7 @@ func (h *Handler) GetArticle(c *gin.Context) {
 	})
 }
 
-// CreateArticle 创建文章（仅管理员）
+// CreateArticle 创建文章（作者只能创建草稿或提交审核，审核员可直接发布）
 func (h *Handler) CreateArticle(c *gin.Context) {
 	var req article.CreateArticleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -1404,11 +2050,9 @@ func (h *Handler) CreateArticle(c *gin.Context) {
 		return
 	}
 
-	// TODO: 检查是否为管理员
-
 	article, err := h.articleService.CreateArticle(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(articleErrorStatus(err), gin.H{
 			"message": "创建文章失败: " + err.Error(),
 			"status":  "error",
 		})
@@ -1422,9 +2066,9 @@ func (h *Handler) CreateArticle(c *gin.Context) {
 	})
 }
 
-// LikeArticle 点赞文章
-func (h *Handler) LikeArticle(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+// ListArticleComments 列出文章评论（已通过审核的评论树）
+func (h *Handler) ListArticleComments(c *gin.Context) {
+	articleID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "无效的文章ID",
@@ -1433,39 +2077,34 @@ func (h *Handler) LikeArticle(c *gin.Context) {
 		return
 	}
 
-	if err := h.articleService.IncrementLikeCount(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "点赞失败",
-			"status":  "error",
-		})
-		return
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "点赞成功",
-		"status":  "success",
-	})
-}
-
-// ListArticleComments 列出文章评论
-func (h *Handler) ListArticleComments(c *gin.Context) {
-	_, err := strconv.Atoi(c.Param("id"))
+	comments, total, err := h.articleCommentService.GetCommentTree(uint(articleID), page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "无效的文章ID",
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "查询评论失败",
 			"status":  "error",
 		})
 		return
 	}
+	h.fillCommentTreeLikes(c, comments)
 
-	_, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
-	_, _ = strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	// TODO: 使用评论服务
 	c.JSON(http.StatusOK, gin.H{
 		"message": "获取评论列表成功",
 		"status":  "success",
-		"data":    []interface{}{},
+		"data": gin.H{
+			"comments": comments,
+			"total":    total,
+			"page":     page,
+			"size":     pageSize,
+		},
 	})
 }
 
@@ -1510,18 +2149,24 @@ func (h *Handler) CreateArticleComment(c *gin.Context) {
 		ParentID:  req.ParentID,
 	}
 
-	_, err = h.articleCommentService.CreateComment(userID, createReq)
+	comment, err := h.articleCommentService.CreateComment(userID, createReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(commentErrorStatus(err), gin.H{
 			"message": "创建评论失败: " + err.Error(),
 			"status":  "error",
 		})
 		return
 	}
 
+	message := "评论提交成功，等待审核"
+	if comment.Status == model.CommentStatusApproved {
+		message = "评论发表成功"
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "评论提交成功，等待审核",
+		"message": message,
 		"status":  "success",
+		"data":    comment,
 	})
 }
 
@@ -1570,9 +2215,10 @@ func (h *Handler) ArticlesPage(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "9"))
 	category := c.Query("category")
 	keyword := c.Query("keyword")
+	tagFilter := tag.NewFilter(c.Query("tag"), "")
 
 	// 获取文章列表
-	articles, total, err := h.articleService.GetArticles(page, pageSize, nil, category, keyword)
+	articles, total, err := h.articleService.GetArticles(page, pageSize, nil, category, keyword, tagFilter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "查询文章失败: " + err.Error()})
 		return
@@ -1598,8 +2244,6 @@ func (h *Handler) ArticlesPage(c *gin.Context) {
 	// 获取当前用户信息
 	currentUser := h.getCurrentUserFromContext(c)
 
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.ParseFiles("web/templates/article-list.html"))
 	data := gin.H{
 		"Articles":        articles,
 		"Total":           total,
@@ -1610,7 +2254,7 @@ func (h *Handler) ArticlesPage(c *gin.Context) {
 		"CurrentUser":     currentUser,
 		"IsAdmin":         currentUser != nil && currentUser["role"] == "admin",
 	}
-	tmpl.Execute(c.Writer, data)
+	h.renderPage(c, http.StatusOK, "article-list.html", data, nil)
 }
 
 // ArticleDetailPage 文章详情页面
@@ -1619,23 +2263,27 @@ func (h *Handler) ArticleDetailPage(c *gin.Context) {
 
 	// 根据slug获取文章
 	article, err := h.articleService.GetArticleBySlug(slug)
-	if err != nil {
-		c.HTML(http.StatusNotFound, "article-not-found", gin.H{
-			"Title": "文章未找到",
-		})
+	if err != nil || article.Status != model.ArticleStatusPublished {
+		c.String(http.StatusNotFound, "文章未找到")
 		return
 	}
 
-	// 增加浏览数
-	h.articleService.IncrementViewCount(article.ID)
+	// 增加浏览数（站点审计的抓取不计入）
+	if !seo.IsAuditRequest(c.Request) {
+		h.articleService.IncrementViewCount(article.ID)
+	}
+	h.fillArticleLike(c, article)
 
 	// 获取评论
 	var comments interface{}
 	var total int64
-	comments, total, err = h.articleCommentService.GetCommentsByArticleID(article.ID, 1, 20)
+	commentList, total, err := h.articleCommentService.GetCommentsByArticleID(article.ID, 1, 20)
 	if err != nil {
 		comments = []interface{}{}
 		total = 0
+	} else {
+		h.fillCommentListLikes(c, commentList)
+		comments = commentList
 	}
 
 	// 获取当前用户信息
@@ -1651,18 +2299,29 @@ func (h *Handler) ArticleDetailPage(c *gin.Context) {
 		},
 	}
 
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	tmpl := template.Must(template.New("").Funcs(funcMap).ParseFiles("web/templates/article-detail.html"))
+	// 正文按Markdown渲染（渲染结果已按白名单清理，带标题锚点和代码高亮）
+	rendered, err := h.contentRenderer.Render(content.KindArticle, article.ID, article.Content)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "文章渲染失败")
+		return
+	}
+
+	seoCtx := h.seoMiddleware.NewContext(model.SEOConfigTypeArticle, c.Request.URL.Path)
+	seoCtx.Article = article
 
 	data := gin.H{
+		"SEO":           h.seoMiddleware.SetPageSEO(c, seoCtx),
 		"Article":       article,
+		"ContentHTML":   template.HTML(rendered.HTML),
+		"TOC":           rendered.TOC,
+		"ReadingTime":   rendered.ReadingTime,
 		"Comments":      comments,
 		"CommentsTotal": total,
 		"CurrentUser":   currentUser,
 		"IsAdmin":       currentUser != nil && currentUser["role"] == "admin",
 		"CurrentPath":   c.Request.URL.Path,
 	}
-	tmpl.Execute(c.Writer, data)
+	h.renderPage(c, http.StatusOK, "article-detail.html", data, funcMap)
 }
 
 // getCurrentUserFromContext 从上下文中获取当前用户
@@ -1680,7 +2339,7 @@ func (h *Handler) getCurrentUserFromContext(c *gin.Context) map[string]interface
 	}
 
 	// 解析token
-	claims, err := utils.ParseToken(tokenString)
+	claims, err := h.parseUserToken(tokenString)
 	if err != nil {
 		return nil
 	}
@@ -1726,67 +2385,22 @@ func (h *Handler) CreateCommentAPI(c *gin.Context) {
 	// 使用评论服务创建评论
 	comment, err := h.articleCommentService.CreateComment(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(commentErrorStatus(err), gin.H{
 			"code":    1001,
 			"message": "创建评论失败: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    1000,
-		"message": "评论提交成功，等待审核",
-		"data":    comment,
-	})
-}
-
-// LikeCommentAPI 点赞评论API
-func (h *Handler) LikeCommentAPI(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    1002,
-			"message": "无效的评论ID",
-		})
-		return
-	}
-
-	if err := h.articleCommentService.IncrementLikeCount(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    1001,
-			"message": "点赞失败",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"code":    1000,
-		"message": "点赞成功",
-	})
-}
-
-// LikeArticleAPI 点赞文章API
-func (h *Handler) LikeArticleAPI(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    1002,
-			"message": "无效的文章ID",
-		})
-		return
-	}
-
-	if err := h.articleService.IncrementLikeCount(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    1001,
-			"message": "点赞失败",
-		})
-		return
+	message := "评论提交成功，等待审核"
+	if comment.Status == model.CommentStatusApproved {
+		message = "评论发表成功"
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    1000,
-		"message": "点赞成功",
+		"message": message,
+		"data":    comment,
 	})
 }
 
@@ -0,0 +1,249 @@
+/*
+Package handlers defines auto-moderation HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/resource"
+
+	"github.com/gin-gonic/gin"
+)
+
+// moderationRuleRequest 审核规则请求
+type moderationRuleRequest struct {
+	Name        string                   `json:"name" binding:"required,max=100"`
+	Description string                   `json:"description" binding:"max=500"`
+	Type        model.ModerationRuleType `json:"type" binding:"required"`
+	Config      string                   `json:"config"` // JSON格式
+	Action      model.ModerationDecision `json:"action" binding:"required"`
+	CategoryID  *uint                    `json:"category_id"`
+	Priority    int                      `json:"priority"`
+	IsEnabled   bool                     `json:"is_enabled"`
+}
+
+// toModel 转换为规则模型
+func (r *moderationRuleRequest) toModel() *model.ModerationRule {
+	return &model.ModerationRule{
+		Name:        r.Name,
+		Description: r.Description,
+		Type:        r.Type,
+		Config:      r.Config,
+		Action:      r.Action,
+		CategoryID:  r.CategoryID,
+		Priority:    r.Priority,
+		IsEnabled:   r.IsEnabled,
+	}
+}
+
+// ==================== 自动审核规则处理器 ====================
+
+// ListModerationRules 获取自动审核规则列表（管理员）
+func (h *Handler) ListModerationRules(c *gin.Context) {
+	rules, err := h.moderationService.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取审核规则成功",
+		"status":  "success",
+		"data": gin.H{
+			"rules": rules,
+			"types": model.ModerationRuleTypes,
+		},
+	})
+}
+
+// CreateModerationRule 创建自动审核规则（管理员）
+func (h *Handler) CreateModerationRule(c *gin.Context) {
+	var req moderationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	rule := req.toModel()
+	if err := h.moderationService.CreateRule(rule); err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "创建审核规则成功",
+		"status":  "success",
+		"data":    rule,
+	})
+}
+
+// UpdateModerationRule 更新自动审核规则（管理员）
+func (h *Handler) UpdateModerationRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的规则ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	var req moderationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	rule, err := h.moderationService.UpdateRule(uint(ruleID), req.toModel())
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新审核规则成功",
+		"status":  "success",
+		"data":    rule,
+	})
+}
+
+// DeleteModerationRule 删除自动审核规则（管理员）
+func (h *Handler) DeleteModerationRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的规则ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.moderationService.DeleteRule(uint(ruleID)); err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除审核规则成功",
+		"status":  "success",
+	})
+}
+
+// DryRunModeration 使用规则试运行历史提交（管理员，不修改数据）
+func (h *Handler) DryRunModeration(c *gin.Context) {
+	var req struct {
+		RuleIDs []uint                  `json:"rule_ids"` // 指定已保存的规则
+		Rules   []moderationRuleRequest `json:"rules"`    // 未保存的草稿规则
+		Days    int                     `json:"days"`     // 评估最近多少天的提交
+		Limit   int                     `json:"limit"`    // 最多评估的资源数
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	opts := resource.DryRunOptions{
+		RuleIDs: req.RuleIDs,
+		Limit:   req.Limit,
+	}
+	for i := range req.Rules {
+		draft := req.Rules[i].toModel()
+		draft.IsEnabled = true
+		opts.Rules = append(opts.Rules, *draft)
+	}
+	if req.Days > 0 {
+		since := time.Now().AddDate(0, 0, -req.Days)
+		opts.Since = &since
+	}
+
+	report, err := h.moderationService.DryRun(opts)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "试运行完成",
+		"status":  "success",
+		"data":    report,
+	})
+}
+
+// RunModeration 对指定资源重新执行自动审核（管理员）
+func (h *Handler) RunModeration(c *gin.Context) {
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的资源ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	result, err := h.moderationService.Apply(uint(resourceID))
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "资源已审核通过，无需自动审核",
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "自动审核完成",
+		"status":  "success",
+		"data":    result,
+	})
+}
+
+// moderationErrorStatus 将自动审核服务错误映射为HTTP状态码
+func moderationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, resource.ErrModerationRuleNotFound), errors.Is(err, resource.ErrResourceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, resource.ErrInvalidModerationRule):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Package handlers defines notification center HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notificationIDsRequest 通知ID列表请求
+type notificationIDsRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=100"`
+}
+
+// ==================== 通知中心处理器 ====================
+
+// ListNotifications 获取当前用户的通知收件箱
+func (h *Handler) ListNotifications(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	unreadOnly := c.Query("unread") == "true"
+	notificationType := model.NotificationType(c.Query("type"))
+
+	notifications, total, err := h.notificationService.GetInbox(userID, unreadOnly, notificationType, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "获取通知失败: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取通知成功",
+		"status":  "success",
+		"data": gin.H{
+			"notifications": notifications,
+			"total":         total,
+			"page":          page,
+			"size":          pageSize,
+		},
+	})
+}
+
+// GetUnreadNotificationCount 获取未读通知数
+func (h *Handler) GetUnreadNotificationCount(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	total, byType, err := h.notificationService.GetUnreadCount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "获取未读通知数失败: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取未读通知数成功",
+		"status":  "success",
+		"data": gin.H{
+			"total":   total,
+			"by_type": byType,
+		},
+	})
+}
+
+// MarkNotificationsRead 标记通知为已读
+func (h *Handler) MarkNotificationsRead(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req notificationIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	count, err := h.notificationService.MarkAsRead(userID, req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "标记已读成功",
+		"status":  "success",
+		"data": gin.H{
+			"updated": count,
+		},
+	})
+}
+
+// MarkAllNotificationsRead 标记全部通知为已读
+func (h *Handler) MarkAllNotificationsRead(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	count, err := h.notificationService.MarkAllAsRead(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "全部标记已读成功",
+		"status":  "success",
+		"data": gin.H{
+			"updated": count,
+		},
+	})
+}
+
+// DeleteNotifications 批量删除通知
+func (h *Handler) DeleteNotifications(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req notificationIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	count, err := h.notificationService.BatchDelete(userID, req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除通知成功",
+		"status":  "success",
+		"data": gin.H{
+			"deleted": count,
+		},
+	})
+}
+
+// GetNotificationPreferences 获取通知偏好设置
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	prefs, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取通知偏好成功",
+		"status":  "success",
+		"data":    prefs,
+	})
+}
+
+// UpdateNotificationPreference 更新某类通知的偏好设置
+func (h *Handler) UpdateNotificationPreference(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req struct {
+		Type       model.NotificationType `json:"type" binding:"required"`
+		InApp      bool                   `json:"in_app"`
+		Email      bool                   `json:"email"`
+		Webhook    bool                   `json:"webhook"`
+		WebhookURL string                 `json:"webhook_url" binding:"omitempty,url,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	pref := &model.NotificationPreference{
+		UserID:     userID,
+		Type:       req.Type,
+		InApp:      req.InApp,
+		Email:      req.Email,
+		Webhook:    req.Webhook,
+		WebhookURL: req.WebhookURL,
+	}
+	if err := h.notificationService.UpdatePreference(pref); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, notification.ErrInvalidNotificationType) || errors.Is(err, notification.ErrUnsafeWebhookURL) ||
+			(req.Webhook && req.WebhookURL == "") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新通知偏好成功",
+		"status":  "success",
+		"data":    pref,
+	})
+}
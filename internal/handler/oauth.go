@@ -0,0 +1,171 @@
+/*
+Package handlers defines social login HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"resource-share-site/internal/service/oauth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 第三方登录处理器 ====================
+
+// ListOAuthProviders 获取可用的第三方登录方式
+func (h *Handler) ListOAuthProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取登录方式成功",
+		"status":  "success",
+		"data":    h.oauthService.ListProviders(),
+	})
+}
+
+// OAuthAuthorize 发起第三方登录（redirect=1 时直接跳转到授权页面）
+func (h *Handler) OAuthAuthorize(c *gin.Context) {
+	authURL, err := h.oauthService.BeginAuth(c.Param("provider"), c.Query("invite_code"), nil)
+	if err != nil {
+		c.JSON(oauthErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if c.Query("redirect") == "1" {
+		c.Redirect(http.StatusFound, authURL)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取授权地址成功",
+		"status":  "success",
+		"data": gin.H{
+			"authorization_url": authURL,
+		},
+	})
+}
+
+// OAuthCallback 第三方登录回调
+func (h *Handler) OAuthCallback(c *gin.Context) {
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "第三方授权失败: " + errCode,
+			"status":  "error",
+		})
+		return
+	}
+
+	result, err := h.oauthService.HandleCallback(c.Request.Context(), c.Param("provider"),
+		c.Query("state"), c.Query("code"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(oauthErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	message := "登录成功"
+	switch {
+	case result.Action == oauth.CallbackActionLink:
+		message = "关联成功"
+	case result.Login != nil && result.Login.TwoFactorRequired:
+		message = "请完成两步验证"
+	case result.Action == oauth.CallbackActionRegister:
+		message = "注册成功"
+		// 第三方未验证的邮箱需要重新验证
+		if !result.Login.User.EmailVerified {
+			_ = h.accountService.SendVerificationEmail(result.Login.User.ID)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"status":  "success",
+		"data":    result,
+	})
+}
+
+// LinkOAuthIdentity 为当前用户发起关联第三方身份
+func (h *Handler) LinkOAuthIdentity(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	authURL, err := h.oauthService.BeginAuth(c.Param("provider"), "", &userID)
+	if err != nil {
+		c.JSON(oauthErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取授权地址成功",
+		"status":  "success",
+		"data": gin.H{
+			"authorization_url": authURL,
+		},
+	})
+}
+
+// ListIdentities 获取当前用户关联的第三方身份
+func (h *Handler) ListIdentities(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	identities, err := h.oauthService.ListIdentities(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取关联账户成功",
+		"status":  "success",
+		"data":    identities,
+	})
+}
+
+// UnlinkIdentity 解除关联第三方身份
+func (h *Handler) UnlinkIdentity(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	if err := h.oauthService.Unlink(userID, c.Param("provider")); err != nil {
+		c.JSON(oauthErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已解除关联",
+		"status":  "success",
+	})
+}
+
+// oauthErrorStatus 将第三方登录服务错误映射为HTTP状态码
+func oauthErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, oauth.ErrProviderNotFound), errors.Is(err, oauth.ErrIdentityNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, oauth.ErrIdentityLinked), errors.Is(err, oauth.ErrProviderAlreadyLinked),
+		errors.Is(err, oauth.ErrEmailLinkRequired):
+		return http.StatusConflict
+	case errors.Is(err, oauth.ErrAccountDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, oauth.ErrExchangeFailed), errors.Is(err, oauth.ErrInvalidIDToken):
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Package handlers defines server-side rendered page helpers and category page handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/category"
+	"resource-share-site/internal/service/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageComponents 所有页面共用的组件模板
+const pageComponents = "web/templates/components/*.html"
+
+// renderPage 渲染页面模板（加载公共组件，未指定SEO数据时使用中间件生成的默认值）
+func (h *Handler) renderPage(c *gin.Context, status int, name string, data gin.H, funcs template.FuncMap) {
+	if data == nil {
+		data = gin.H{}
+	}
+	if _, ok := data["SEO"]; !ok {
+		page := seo.GetPageSEO(c)
+		if page == nil {
+			page = h.seoMiddleware.SetPageSEO(c, h.seoMiddleware.NewContext(model.SEOConfigTypeDetail, c.Request.URL.Path))
+		}
+		data["SEO"] = page
+	}
+
+	tmpl := template.New(name).Funcs(template.FuncMap{
+		"summarize":    seo.Summarize,
+		"resourcePath": seo.ResourcePath,
+		"categoryPath": seo.CategoryPath,
+	}).Funcs(funcs)
+	tmpl = template.Must(tmpl.ParseFiles("web/templates/" + name))
+	tmpl = template.Must(tmpl.ParseGlob(pageComponents))
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := tmpl.ExecuteTemplate(c.Writer, name, data); err != nil {
+		c.Error(err)
+	}
+}
+
+// HighlightCSS 代码高亮样式表（按配置的主题生成，正文中的代码块只输出样式类）
+func (h *Handler) HighlightCSS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/css; charset=utf-8", []byte(h.contentRenderer.HighlightCSS()))
+}
+
+// CategoryPage 分类页面（旧的ID地址，已生成slug路径的分类301跳转到新地址）
+func (h *Handler) CategoryPage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.String(http.StatusNotFound, "分类不存在")
+		return
+	}
+
+	cat, err := h.categoryService.GetCategoryByID(uint(id))
+	if err != nil {
+		if errors.Is(err, category.ErrCategoryNotFound) {
+			c.String(http.StatusNotFound, "分类不存在")
+			return
+		}
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if cat.Path != "" {
+		permanentRedirect(c, seo.CategoryPath(cat))
+		return
+	}
+	h.renderCategoryPage(c, cat)
+}
+
+// CategoryPathPage 分类页面（slug路径地址，找不到分类时查找跳转规则）
+func (h *Handler) CategoryPathPage(c *gin.Context) {
+	cat, err := h.categoryService.GetCategoryByPath(c.Param("path"))
+	if err != nil {
+		if errors.Is(err, category.ErrCategoryNotFound) {
+			if h.resolveRedirect(c) {
+				return
+			}
+			c.String(http.StatusNotFound, "分类不存在")
+			return
+		}
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.renderCategoryPage(c, cat)
+}
+
+// renderCategoryPage 渲染分类页面（服务端渲染，含面包屑和结构化数据）
+func (h *Handler) renderCategoryPage(c *gin.Context, cat *model.Category) {
+	categoryID := cat.ID
+	page, pageSize := parsePagination(c)
+	approved := model.ResourceStatusApproved
+	resources, total, err := h.resourceService.GetResourcesByCategory(categoryID, page, pageSize, &approved, "created_at", true)
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	children, _ := h.categoryService.GetChildCategories(categoryID)
+	path, _ := h.categoryService.GetCategoryPath(categoryID)
+
+	seoCtx := h.seoMiddleware.NewContext(model.SEOConfigTypeCategory, seo.CategoryPath(cat))
+	seoCtx.CategoryObj = cat
+	seoCtx.CategoryPath = path
+	pageSEO := h.seoMiddleware.SetPageSEO(c, seoCtx)
+	// 分页后的列表页不收录，避免与第一页重复
+	if page > 1 {
+		pageSEO.Meta["robots"] = "noindex, follow"
+	}
+
+	h.renderPage(c, http.StatusOK, "category.html", gin.H{
+		"SEO":       pageSEO,
+		"Category":  cat,
+		"Children":  children,
+		"Resources": resources,
+		"Total":     total,
+		"Page":      page,
+		"HasMore":   int64(page*pageSize) < total,
+		"NextPage":  page + 1,
+	}, nil)
+}
@@ -0,0 +1,258 @@
+/*
+Package handlers defines resource rating HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/resource"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ratingRequest 资源评分请求
+type ratingRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Content string `json:"content"`
+}
+
+// ==================== 资源评分处理器 ====================
+
+// ListResourceRatings 获取资源的评分汇总和评价列表（sort=latest, highest 或 lowest）
+func (h *Handler) ListResourceRatings(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+	page, pageSize := parsePagination(c)
+
+	summary, err := h.ratingService.GetSummary(id)
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	ratings, total, err := h.ratingService.ListRatings(id, c.DefaultQuery("sort", "latest"), page, pageSize)
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取资源评分成功",
+		"status":  "success",
+		"data": gin.H{
+			"summary":   summary,
+			"ratings":   ratings,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetMyResourceRating 获取当前用户对资源的评分
+func (h *Handler) GetMyResourceRating(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+
+	rating, err := h.ratingService.GetUserRating(id, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取评分成功",
+		"status":  "success",
+		"data":    rating,
+	})
+}
+
+// RateResource 给资源评分（只有下载过资源的用户可以评分，重复提交会修改原评分）
+func (h *Handler) RateResource(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+
+	var req ratingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	rating, err := h.ratingService.RateResource(id, c.GetUint("userID"), req.Rating, req.Content)
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	summary, err := h.ratingService.GetSummary(id)
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	message := "评分成功"
+	if rating.ContentPending {
+		message = "评分成功，评价内容审核通过后展示"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"status":  "success",
+		"data": gin.H{
+			"rating":  rating,
+			"summary": summary,
+		},
+	})
+}
+
+// DeleteResourceRating 删除当前用户对资源的评分
+func (h *Handler) DeleteResourceRating(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ratingService.DeleteRating(id, c.GetUint("userID")); err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "评分已删除",
+		"status":  "success",
+	})
+}
+
+// RecalculateRatings 按当前配置重新计算所有资源的评分（管理员）
+func (h *Handler) RecalculateRatings(c *gin.Context) {
+	updated, err := h.ratingService.RecalculateScores()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "资源评分已重新计算",
+		"status":  "success",
+		"data": gin.H{
+			"updated": updated,
+		},
+	})
+}
+
+// ListPendingRatings 获取评价内容待审核的评分（管理员和版主）
+func (h *Handler) ListPendingRatings(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	ratings, total, err := h.ratingService.ListPendingRatings(page, pageSize)
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取待审核评价成功",
+		"status":  "success",
+		"data": gin.H{
+			"ratings":   ratings,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// ApproveRating 审核通过评价内容（管理员和版主）
+func (h *Handler) ApproveRating(c *gin.Context) {
+	h.moderateRating(c, true, "评价已通过审核")
+}
+
+// RejectRating 拒绝评价内容，清空评价内容并保留评分（管理员和版主）
+func (h *Handler) RejectRating(c *gin.Context) {
+	h.moderateRating(c, false, "评价内容已拒绝")
+}
+
+// moderateRating 审核评价内容
+func (h *Handler) moderateRating(c *gin.Context, approve bool, message string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的评分ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	rating, err := h.ratingService.ModerateRating(uint(id), approve)
+	if err != nil {
+		c.JSON(ratingErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"status":  "success",
+		"data":    rating,
+	})
+}
+
+// ratingErrorStatus 将评分服务的错误映射为HTTP状态码
+func ratingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, resource.ErrResourceNotFound), errors.Is(err, resource.ErrRatingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, resource.ErrRatingNotAllowed), errors.Is(err, resource.ErrRateOwnResource):
+		return http.StatusForbidden
+	case errors.Is(err, resource.ErrInvalidRating), errors.Is(err, resource.ErrRatingContentTooLong),
+		errors.Is(err, filter.ErrBlocked):
+		return http.StatusBadRequest
+	case errors.Is(err, resource.ErrRatingNotPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
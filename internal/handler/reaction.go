@@ -0,0 +1,301 @@
+/*
+Package handlers defines like and reaction HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/article"
+	"resource-share-site/internal/service/reaction"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reactionRequest 表态请求（kind 为空时为点赞）
+type reactionRequest struct {
+	Kind string `json:"kind"`
+}
+
+// ==================== 点赞与表态处理器 ====================
+
+// ToggleReaction 切换当前用户对文章或评论的表态（已表态则取消）
+func (h *Handler) ToggleReaction(c *gin.Context) {
+	targetType, targetID, ok := parseReactionTarget(c)
+	if !ok {
+		return
+	}
+
+	var req reactionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "请求参数错误: " + err.Error(),
+				"status":  "error",
+			})
+			return
+		}
+	}
+	kind, err := reaction.ParseKind(req.Kind)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	result, err := h.reactionService.Toggle(c.GetUint("userID"), targetType, targetID, kind)
+	if err != nil {
+		c.JSON(reactionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	message := "已取消"
+	if result.Active {
+		message = "操作成功"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"status":  "success",
+		"data":    result,
+	})
+}
+
+// GetReactions 获取文章或评论的表态汇总（登录用户同时返回自己的表态）
+func (h *Handler) GetReactions(c *gin.Context) {
+	targetType, targetID, ok := parseReactionTarget(c)
+	if !ok {
+		return
+	}
+
+	viewerID, _ := h.getCurrentUserID(c)
+	summary, err := h.reactionService.GetSummary(targetType, targetID, viewerID)
+	if err != nil {
+		c.JSON(reactionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取表态成功",
+		"status":  "success",
+		"data":    summary,
+	})
+}
+
+// RecountLikes 根据点赞记录重新统计文章和评论的点赞数（仅管理员）
+func (h *Handler) RecountLikes(c *gin.Context) {
+	if err := h.reactionService.RecountLikes(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "点赞数已重新统计",
+		"status":  "success",
+	})
+}
+
+// LikeArticle 切换文章点赞
+func (h *Handler) LikeArticle(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的文章ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	result, err := h.reactionService.Toggle(c.GetUint("userID"), model.ReactionTargetArticle, uint(id), model.ReactionLike)
+	if err != nil {
+		c.JSON(reactionErrorStatus(err), gin.H{
+			"message": "点赞失败: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": likeMessage(result),
+		"status":  "success",
+		"data":    likeData(result),
+	})
+}
+
+// LikeArticleAPI 切换文章点赞API
+func (h *Handler) LikeArticleAPI(c *gin.Context) {
+	h.toggleLikeAPI(c, model.ReactionTargetArticle, "无效的文章ID")
+}
+
+// LikeCommentAPI 切换评论点赞API
+func (h *Handler) LikeCommentAPI(c *gin.Context) {
+	h.toggleLikeAPI(c, model.ReactionTargetArticleComment, "无效的评论ID")
+}
+
+// toggleLikeAPI 切换点赞（API 响应格式）
+func (h *Handler) toggleLikeAPI(c *gin.Context, targetType model.ReactionTargetType, invalidID string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    1002,
+			"message": invalidID,
+		})
+		return
+	}
+
+	result, err := h.reactionService.Toggle(c.GetUint("userID"), targetType, uint(id), model.ReactionLike)
+	if err != nil {
+		c.JSON(reactionErrorStatus(err), gin.H{
+			"code":    1001,
+			"message": "点赞失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    1000,
+		"message": likeMessage(result),
+		"data":    likeData(result),
+	})
+}
+
+// ==================== 点赞状态填充 ====================
+
+// fillArticleLikes 填充文章列表的点赞状态和未写回的点赞数
+func (h *Handler) fillArticleLikes(c *gin.Context, items []article.ArticleListItem) {
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	states := h.likeStates(c, model.ReactionTargetArticle, ids)
+	for i := range items {
+		state := states[items[i].ID]
+		items[i].Liked = state.Liked
+		items[i].LikeCount = state.Count(items[i].LikeCount)
+	}
+}
+
+// fillArticleLike 填充文章详情的点赞状态和未写回的点赞数
+func (h *Handler) fillArticleLike(c *gin.Context, a *model.Article) {
+	state := h.likeStates(c, model.ReactionTargetArticle, []uint{a.ID})[a.ID]
+	a.Liked = state.Liked
+	a.LikeCount = state.Count(a.LikeCount)
+}
+
+// fillCommentTreeLikes 填充评论树（含回复）的点赞状态和未写回的点赞数
+func (h *Handler) fillCommentTreeLikes(c *gin.Context, comments []model.ArticleComment) {
+	var ids []uint
+	var collect func([]model.ArticleComment)
+	collect = func(list []model.ArticleComment) {
+		for i := range list {
+			ids = append(ids, list[i].ID)
+			collect(list[i].Replies)
+		}
+	}
+	collect(comments)
+
+	states := h.likeStates(c, model.ReactionTargetArticleComment, ids)
+	var fill func([]model.ArticleComment)
+	fill = func(list []model.ArticleComment) {
+		for i := range list {
+			state := states[list[i].ID]
+			list[i].Liked = state.Liked
+			list[i].LikeCount = state.Count(list[i].LikeCount)
+			fill(list[i].Replies)
+		}
+	}
+	fill(comments)
+}
+
+// fillCommentListLikes 填充评论列表的点赞状态和未写回的点赞数
+func (h *Handler) fillCommentListLikes(c *gin.Context, items []article.CommentListItem) {
+	ids := make([]uint, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+	states := h.likeStates(c, model.ReactionTargetArticleComment, ids)
+	for i := range items {
+		state := states[items[i].ID]
+		items[i].Liked = state.Liked
+		items[i].LikeCount = state.Count(items[i].LikeCount)
+	}
+}
+
+// likeStates 查询当前访问者（可未登录）对一组对象的点赞状态，查询失败时按未点赞处理
+func (h *Handler) likeStates(c *gin.Context, targetType model.ReactionTargetType, ids []uint) map[uint]reaction.LikeState {
+	viewerID, _ := h.getCurrentUserID(c)
+	states, err := h.reactionService.LikeStates(viewerID, targetType, ids)
+	if err != nil {
+		return map[uint]reaction.LikeState{}
+	}
+	return states
+}
+
+// ==================== 辅助函数 ====================
+
+// likeMessage 点赞切换结果提示
+func likeMessage(result *reaction.ToggleResult) string {
+	if result.Active {
+		return "点赞成功"
+	}
+	return "已取消点赞"
+}
+
+// likeData 点赞切换结果数据
+func likeData(result *reaction.ToggleResult) gin.H {
+	return gin.H{
+		"liked":      result.Active,
+		"like_count": result.Count,
+	}
+}
+
+// parseReactionTarget 解析表态对象类型和ID（失败时直接返回400）
+func parseReactionTarget(c *gin.Context) (model.ReactionTargetType, uint, bool) {
+	targetType, err := reaction.ParseTargetType(c.Param("type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的表态对象ID",
+			"status":  "error",
+		})
+		return "", 0, false
+	}
+	return targetType, uint(id), true
+}
+
+// reactionErrorStatus 表态错误对应的HTTP状态码
+func reactionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, reaction.ErrTargetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, reaction.ErrInvalidTarget),
+		errors.Is(err, reaction.ErrInvalidKind):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Package handlers defines recommendation HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/service/recommendation"
+	"resource-share-site/internal/service/resource"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 下载与推荐处理器 ====================
+
+// DownloadResource 下载资源（记录下载历史，付费资源扣除积分）
+func (h *Handler) DownloadResource(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的资源ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	url, err := h.resourceService.DownloadResource(uint(id), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(downloadErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取下载链接成功",
+		"status":  "success",
+		"data": gin.H{
+			"url": url,
+		},
+	})
+}
+
+// GetRelatedResources 获取相关资源
+func (h *Handler) GetRelatedResources(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的资源ID",
+			"status":  "error",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	resources, err := h.recommendationService.Related(uint(id), limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, recommendation.ErrResourceNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取相关资源成功",
+		"status":  "success",
+		"data":    resources,
+	})
+}
+
+// GetRecommendedResources 获取为当前用户推荐的资源（基于下载历史）
+func (h *Handler) GetRecommendedResources(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	resources, err := h.recommendationService.RecommendForUser(c.GetUint("userID"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取推荐资源成功",
+		"status":  "success",
+		"data":    resources,
+	})
+}
+
+// RebuildRecommendations 立即重建相关资源推荐（管理员）
+func (h *Handler) RebuildRecommendations(c *gin.Context) {
+	result, err := h.recommendationService.Rebuild()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, recommendation.ErrRebuildRunning) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "相关资源推荐已重建",
+		"status":  "success",
+		"data":    result,
+	})
+}
+
+// downloadErrorStatus 将资源下载错误映射为HTTP状态码
+func downloadErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, resource.ErrResourceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, resource.ErrResourceNotApproved):
+		return http.StatusForbidden
+	case errors.Is(err, resource.ErrInsufficientPoints):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
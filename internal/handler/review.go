@@ -0,0 +1,444 @@
+/*
+Package handlers defines moderator review workbench HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/resource"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rejectTemplateRequest 拒绝理由模板请求
+type rejectTemplateRequest struct {
+	Code      string `json:"code" binding:"required,max=50"`
+	Title     string `json:"title" binding:"required,max=100"`
+	Content   string `json:"content" binding:"required,max=500"`
+	SortOrder int    `json:"sort_order"`
+	IsEnabled bool   `json:"is_enabled"`
+}
+
+// toModel 转换为模板模型
+func (r *rejectTemplateRequest) toModel() *model.RejectReasonTemplate {
+	return &model.RejectReasonTemplate{
+		Code:      r.Code,
+		Title:     r.Title,
+		Content:   r.Content,
+		SortOrder: r.SortOrder,
+		IsEnabled: r.IsEnabled,
+	}
+}
+
+// ==================== 人工审核工作台处理器 ====================
+
+// GetReviewQueue 获取审核队列（审核员）
+func (h *Handler) GetReviewQueue(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	opts := resource.ReviewQueueOptions{
+		ReviewerID: c.GetUint("userID"),
+		Page:       page,
+		PageSize:   pageSize,
+		Available:  c.Query("available") == "true",
+		Mine:       c.Query("mine") == "true",
+	}
+	if categoryID, err := strconv.ParseUint(c.Query("category_id"), 10, 32); err == nil {
+		id := uint(categoryID)
+		opts.CategoryID = &id
+	}
+	if source := c.Query("source"); source != "" {
+		s := model.ResourceSource(source)
+		opts.Source = &s
+	}
+
+	items, total, err := h.reviewService.GetReviewQueue(opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "获取审核队列失败: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取审核队列成功",
+		"status":  "success",
+		"data": gin.H{
+			"items":     items,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// ClaimNextReview 认领队列中优先级最高的资源（审核员）
+func (h *Handler) ClaimNextReview(c *gin.Context) {
+	var categoryID *uint
+	if id, err := strconv.ParseUint(c.Query("category_id"), 10, 32); err == nil {
+		cid := uint(id)
+		categoryID = &cid
+	}
+
+	item, err := h.reviewService.ClaimNextResource(c.GetUint("userID"), categoryID)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "认领成功",
+		"status":  "success",
+		"data":    item,
+	})
+}
+
+// ClaimReview 认领或续期指定资源（审核员）
+func (h *Handler) ClaimReview(c *gin.Context) {
+	resourceID, ok := parseReviewResourceID(c)
+	if !ok {
+		return
+	}
+
+	claim, err := h.reviewService.ClaimResource(resourceID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "认领成功",
+		"status":  "success",
+		"data":    claim,
+	})
+}
+
+// ReleaseReviewClaim 释放认领（审核员，管理员可释放他人的认领）
+func (h *Handler) ReleaseReviewClaim(c *gin.Context) {
+	resourceID, ok := parseReviewResourceID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.reviewService.ReleaseClaim(resourceID, c.GetUint("userID")); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已释放认领",
+		"status":  "success",
+	})
+}
+
+// ApproveReview 审核通过（审核员）
+func (h *Handler) ApproveReview(c *gin.Context) {
+	resourceID, ok := parseReviewResourceID(c)
+	if !ok {
+		return
+	}
+
+	// 备注可选，允许不带请求体
+	var req struct {
+		Notes string `json:"notes" binding:"max=500"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "请求参数错误: " + err.Error(),
+				"status":  "error",
+			})
+			return
+		}
+	}
+
+	reviewLog, err := h.reviewService.ReviewResource(resourceID, c.GetUint("userID"), resource.ReviewActionApprove, req.Notes)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "审核通过",
+		"status":  "success",
+		"data":    reviewLog,
+	})
+}
+
+// RejectReview 使用拒绝理由模板拒绝资源（审核员）
+func (h *Handler) RejectReview(c *gin.Context) {
+	resourceID, ok := parseReviewResourceID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ReasonCode string `json:"reason_code" binding:"required"`
+		Notes      string `json:"notes" binding:"max=200"` // 补充说明
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	reviewLog, err := h.reviewService.RejectWithTemplate(resourceID, c.GetUint("userID"), req.ReasonCode, req.Notes)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已拒绝",
+		"status":  "success",
+		"data":    reviewLog,
+	})
+}
+
+// RevertReview 撤回审核，资源恢复到待审核状态（审核员）
+func (h *Handler) RevertReview(c *gin.Context) {
+	resourceID, ok := parseReviewResourceID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Notes string `json:"notes" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	reviewLog, err := h.reviewService.RevertReview(resourceID, c.GetUint("userID"), req.Notes)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已撤回审核",
+		"status":  "success",
+		"data":    reviewLog,
+	})
+}
+
+// GetReviewStatistics 获取审核统计和审核员绩效（审核员）
+func (h *Handler) GetReviewStatistics(c *gin.Context) {
+	var startDate, endDate *time.Time
+	if value := c.Query("start_date"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "开始日期格式错误，应为YYYY-MM-DD",
+				"status":  "error",
+			})
+			return
+		}
+		startDate = &t
+	}
+	if value := c.Query("end_date"); value != "" {
+		t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "结束日期格式错误，应为YYYY-MM-DD",
+				"status":  "error",
+			})
+			return
+		}
+		// 包含结束日期当天
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		endDate = &t
+	}
+
+	stats, err := h.reviewService.GetReviewStatistics(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "获取审核统计失败: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取审核统计成功",
+		"status":  "success",
+		"data":    stats,
+	})
+}
+
+// ==================== 拒绝理由模板处理器 ====================
+
+// ListRejectTemplates 获取拒绝理由模板（审核员，all=true 时包含已停用的模板）
+func (h *Handler) ListRejectTemplates(c *gin.Context) {
+	templates, err := h.reviewService.ListRejectTemplates(c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取拒绝理由模板成功",
+		"status":  "success",
+		"data":    templates,
+	})
+}
+
+// CreateRejectTemplate 创建拒绝理由模板（管理员）
+func (h *Handler) CreateRejectTemplate(c *gin.Context) {
+	var req rejectTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	tpl := req.toModel()
+	if err := h.reviewService.CreateRejectTemplate(tpl); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "创建拒绝理由模板成功",
+		"status":  "success",
+		"data":    tpl,
+	})
+}
+
+// UpdateRejectTemplate 更新拒绝理由模板（管理员）
+func (h *Handler) UpdateRejectTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的模板ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	var req rejectTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	tpl, err := h.reviewService.UpdateRejectTemplate(uint(id), req.toModel())
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新拒绝理由模板成功",
+		"status":  "success",
+		"data":    tpl,
+	})
+}
+
+// DeleteRejectTemplate 删除拒绝理由模板（管理员）
+func (h *Handler) DeleteRejectTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的模板ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.reviewService.DeleteRejectTemplate(uint(id)); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除拒绝理由模板成功",
+		"status":  "success",
+	})
+}
+
+// parseReviewResourceID 解析路径中的资源ID（失败时已写入响应）
+func parseReviewResourceID(c *gin.Context) (uint, bool) {
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的资源ID",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return uint(resourceID), true
+}
+
+// reviewErrorStatus 将审核服务错误映射为HTTP状态码
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, resource.ErrResourceNotFound), errors.Is(err, resource.ErrClaimNotFound),
+		errors.Is(err, resource.ErrRejectTemplateNotFound), errors.Is(err, resource.ErrReviewQueueEmpty):
+		return http.StatusNotFound
+	case errors.Is(err, resource.ErrResourceClaimed), errors.Is(err, resource.ErrResourceNotPending):
+		return http.StatusConflict
+	case errors.Is(err, resource.ErrReviewPermissionDenied):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,365 @@
+/*
+Package handlers defines resource revision HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/content"
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/resource"
+	"resource-share-site/internal/service/tag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reviewNotesRequest 修订审核请求
+type reviewNotesRequest struct {
+	Notes string `json:"notes" binding:"max=500"`
+}
+
+// ==================== 资源修订处理器 ====================
+
+// UpdateResource 修改资源（非管理员修改已上线资源时提交待审核修订）
+func (h *Handler) UpdateResource(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Title       string `json:"title" binding:"required,min=1,max=200"`
+		Description string `json:"description" binding:"required,min=1,max=2000"`
+		CategoryID  uint   `json:"category_id" binding:"required"`
+		NetdiskURL  string `json:"netdisk_url" binding:"required"`
+		PointsPrice int    `json:"points_price" binding:"min=0"`
+		Tags        string `json:"tags"`
+		Reason      string `json:"reason" binding:"max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	updated, revision, err := h.resourceService.UpdateResource(id, c.GetUint("userID"), req.Title, req.Description,
+		req.CategoryID, req.NetdiskURL, req.PointsPrice, req.Tags, req.Reason)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	h.contentRenderer.Invalidate(content.KindResource, id)
+
+	message := "修改资源成功"
+	if revision.Status == model.RevisionStatusPending {
+		message = "修改已提交，审核通过后生效"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"status":  "success",
+		"data": gin.H{
+			"resource": updated,
+			"revision": revision,
+		},
+	})
+}
+
+// ListResourceRevisions 获取资源修订历史（上传者、管理员和版主）
+func (h *Handler) ListResourceRevisions(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	revisions, total, err := h.resourceService.ListRevisions(id, c.GetUint("userID"), page, pageSize)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取修订历史成功",
+		"status":  "success",
+		"data": gin.H{
+			"revisions": revisions,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetResourceRevision 获取修订详情及字段差异
+func (h *Handler) GetResourceRevision(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的版本号",
+			"status":  "error",
+		})
+		return
+	}
+
+	detail, err := h.resourceService.GetRevision(id, version, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取修订详情成功",
+		"status":  "success",
+		"data":    detail,
+	})
+}
+
+// CompareResourceRevisions 比较资源的两个版本（from、to 为版本号）
+func (h *Handler) CompareResourceRevisions(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "缺少有效的from和to版本号",
+			"status":  "error",
+		})
+		return
+	}
+
+	changes, err := h.resourceService.CompareRevisions(id, from, to, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "比较版本成功",
+		"status":  "success",
+		"data": gin.H{
+			"from":    from,
+			"to":      to,
+			"changes": changes,
+		},
+	})
+}
+
+// ==================== 资源修订管理处理器 ====================
+
+// ListPendingRevisions 获取待审核的资源修订（审核员）
+func (h *Handler) ListPendingRevisions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	revisions, total, err := h.resourceService.ListPendingRevisions(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取待审核修订成功",
+		"status":  "success",
+		"data": gin.H{
+			"revisions": revisions,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// ApproveRevision 审核通过资源修订（审核员）
+func (h *Handler) ApproveRevision(c *gin.Context) {
+	id, ok := parseRevisionID(c)
+	if !ok {
+		return
+	}
+
+	var req reviewNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	updated, err := h.resourceService.ApproveRevision(id, c.GetUint("userID"), req.Notes)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "修订已通过并生效",
+		"status":  "success",
+		"data":    updated,
+	})
+}
+
+// RejectRevision 拒绝资源修订（审核员）
+func (h *Handler) RejectRevision(c *gin.Context) {
+	id, ok := parseRevisionID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Notes string `json:"notes" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.resourceService.RejectRevision(id, c.GetUint("userID"), req.Notes); err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "修订已拒绝",
+		"status":  "success",
+	})
+}
+
+// RollbackResource 将资源回滚到指定版本（管理员）
+func (h *Handler) RollbackResource(c *gin.Context) {
+	id, ok := parseResourceID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Version int    `json:"version" binding:"required,min=1"`
+		Reason  string `json:"reason" binding:"max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	updated, revision, err := h.resourceService.RollbackResource(id, req.Version, c.GetUint("userID"), req.Reason)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+	h.contentRenderer.Invalidate(content.KindResource, id)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "资源已回滚",
+		"status":  "success",
+		"data": gin.H{
+			"resource": updated,
+			"revision": revision,
+		},
+	})
+}
+
+// parseResourceID 解析路径中的资源ID（无效时直接返回错误响应）
+func parseResourceID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的资源ID",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseRevisionID 解析路径中的修订ID（无效时直接返回错误响应）
+func parseRevisionID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的修订ID",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// revisionErrorStatus 将资源修订错误映射为HTTP状态码
+func revisionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, resource.ErrResourceNotFound), errors.Is(err, resource.ErrRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, resource.ErrEditPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, resource.ErrRevisionOutdated), errors.Is(err, resource.ErrRevisionNotPending):
+		return http.StatusConflict
+	case errors.Is(err, resource.ErrRevisionUnchanged), errors.Is(err, resource.ErrRevisionNotApplied), errors.Is(err, resource.ErrCategoryNotFound),
+		errors.Is(err, filter.ErrBlocked), errors.Is(err, tag.ErrTooManyTags):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
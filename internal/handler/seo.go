@@ -0,0 +1,335 @@
+/*
+Package handlers defines SEO configuration, template preview and site audit HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/category"
+	"resource-share-site/internal/service/favorite"
+	"resource-share-site/internal/service/resource"
+	"resource-share-site/internal/service/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seoPreviewRequest 模板预览请求
+type seoPreviewRequest struct {
+	Type      model.SEOConfigType `json:"type" binding:"required"`
+	TargetID  uint                `json:"target_id"`
+	Query     string              `json:"query"`
+	Templates map[string]string   `json:"templates" binding:"required"`
+}
+
+// ListSEOConfigs 获取SEO配置列表（管理员）
+func (h *Handler) ListSEOConfigs(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	configs, total, err := h.seoConfigService.ListSEOConfigs(model.SEOConfigType(c.Query("type")), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取SEO配置成功",
+		"status":  "success",
+		"data": gin.H{
+			"configs":   configs,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// CreateSEOConfig 创建SEO配置（管理员，模板字段保存前校验）
+func (h *Handler) CreateSEOConfig(c *gin.Context) {
+	var config model.SEOConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+	if config.ConfigType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "配置类型不能为空",
+			"status":  "error",
+		})
+		return
+	}
+	config.ID = 0
+
+	if err := h.seoConfigService.CreateSEOConfig(&config); err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "创建SEO配置成功",
+		"status":  "success",
+		"data":    config,
+	})
+}
+
+// UpdateSEOConfig 更新SEO配置（管理员，模板字段保存前校验）
+func (h *Handler) UpdateSEOConfig(c *gin.Context) {
+	id, ok := parseSEOConfigID(c)
+	if !ok {
+		return
+	}
+
+	var updates map[string]interface{}
+	if err := c.ShouldBindJSON(&updates); err != nil || len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误",
+			"status":  "error",
+		})
+		return
+	}
+	for _, key := range []string{"id", "created_at", "updated_at"} {
+		delete(updates, key)
+	}
+
+	if err := h.seoConfigService.UpdateSEOConfig(id, updates); err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新SEO配置成功",
+		"status":  "success",
+	})
+}
+
+// DeleteSEOConfig 删除SEO配置（管理员）
+func (h *Handler) DeleteSEOConfig(c *gin.Context) {
+	id, ok := parseSEOConfigID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.seoConfigService.DeleteSEOConfig(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除SEO配置成功",
+		"status":  "success",
+	})
+}
+
+// PreviewSEOTemplates 使用真实实体预览SEO模板（管理员）
+func (h *Handler) PreviewSEOTemplates(c *gin.Context) {
+	var req seoPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	seoCtx, err := h.previewSEOContext(c, req)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "预览SEO模板成功",
+		"status":  "success",
+		"data":    h.seoConfigService.PreviewTemplates(req.Templates, h.seoMiddleware.TemplateData(seoCtx)),
+	})
+}
+
+// RunSEOAudit 执行站点审计（管理员，同步抓取站内页面并保存报告）
+func (h *Handler) RunSEOAudit(c *gin.Context) {
+	report, err := h.seoAuditService.Run()
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "站点审计完成",
+		"status":  "success",
+		"data":    report,
+	})
+}
+
+// ListSEOAudits 获取站点审计报告列表（管理员）
+func (h *Handler) ListSEOAudits(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	reports, total, err := h.seoService.GetSEOReports(model.SEOReportTypeAudit, "", page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取审计报告成功",
+		"status":  "success",
+		"data": gin.H{
+			"reports":   reports,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// ListSEOAuditPages 获取审计报告中的页面及问题（管理员，可按问题代码和严重程度筛选）
+func (h *Handler) ListSEOAuditPages(c *gin.Context) {
+	id, ok := parseSEOConfigID(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := parsePagination(c)
+	pages, total, err := h.seoAuditService.GetAuditPages(id, c.Query("code"), model.SEOAuditSeverity(c.Query("severity")), page, pageSize)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取审计页面成功",
+		"status":  "success",
+		"data": gin.H{
+			"pages":     pages,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// previewSEOContext 加载预览所需的页面实体
+func (h *Handler) previewSEOContext(c *gin.Context, req seoPreviewRequest) (*seo.SEOContext, error) {
+	var seoCtx *seo.SEOContext
+	switch req.Type {
+	case model.SEOConfigTypeResource:
+		res, err := h.resourceService.GetResourceByID(req.TargetID, false)
+		if err != nil {
+			return nil, err
+		}
+		seoCtx = h.seoMiddleware.NewContext(req.Type, seo.ResourcePath(res.ID, res.Slug))
+		seoCtx.Resource = res
+		seoCtx.CategoryObj = res.Category
+		seoCtx.CategoryPath, _ = h.categoryService.GetCategoryPath(res.CategoryID)
+
+	case model.SEOConfigTypeArticle:
+		article, err := h.articleService.GetArticleByID(req.TargetID)
+		if err != nil {
+			return nil, errSEOPreviewTargetNotFound
+		}
+		seoCtx = h.seoMiddleware.NewContext(req.Type, "/article/"+article.Slug)
+		seoCtx.Article = article
+
+	case model.SEOConfigTypeCategory:
+		cat, err := h.categoryService.GetCategoryByID(req.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		seoCtx = h.seoMiddleware.NewContext(req.Type, seo.CategoryPath(cat))
+		seoCtx.CategoryObj = cat
+		seoCtx.CategoryPath, _ = h.categoryService.GetCategoryPath(cat.ID)
+
+	case model.SEOConfigTypeCollection:
+		viewerID, _ := h.getCurrentUserID(c)
+		collection, err := h.collectionService.GetCollection(req.TargetID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		seoCtx = h.seoMiddleware.NewContext(req.Type, fmt.Sprintf("/collection/%d", collection.ID))
+		seoCtx.Collection = collection
+
+	case model.SEOConfigTypeSearch:
+		seoCtx = h.seoMiddleware.NewContext(req.Type, "/search")
+		seoCtx.Query = req.Query
+
+	default:
+		seoCtx = h.seoMiddleware.NewContext(req.Type, "/")
+	}
+	return seoCtx, nil
+}
+
+// errSEOPreviewTargetNotFound 预览的实体不存在
+var errSEOPreviewTargetNotFound = errors.New("预览对象不存在")
+
+// parseSEOConfigID 解析SEO配置或审计报告ID（失败时直接返回400）
+func parseSEOConfigID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的ID",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// seoErrorStatus 将SEO服务错误映射为HTTP状态码
+func seoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, seo.ErrInvalidTemplate), errors.Is(err, seo.ErrTemplateRender),
+		errors.Is(err, seo.ErrUnsupportedSearchEngine), errors.Is(err, seo.ErrUnsupportedImportFormat),
+		errors.Is(err, seo.ErrImportNoRows), errors.Is(err, seo.ErrImportTooManyRows),
+		errors.Is(err, seo.ErrInvalidIndexAction), errors.Is(err, seo.ErrInvalidIndexURL),
+		errors.Is(err, seo.ErrInvalidRedirect), errors.Is(err, seo.ErrRedirectLoop):
+		return http.StatusBadRequest
+	case errors.Is(err, errSEOPreviewTargetNotFound),
+		errors.Is(err, resource.ErrResourceNotFound),
+		errors.Is(err, category.ErrCategoryNotFound),
+		errors.Is(err, favorite.ErrCollectionNotFound),
+		errors.Is(err, seo.ErrAuditNotFound),
+		errors.Is(err, seo.ErrKeywordNotFound),
+		errors.Is(err, seo.ErrNoRankData),
+		errors.Is(err, seo.ErrRedirectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, seo.ErrAuditRunning), errors.Is(err, seo.ErrIndexFlushRunning),
+		errors.Is(err, seo.ErrRedirectExists):
+		return http.StatusConflict
+	case errors.Is(err, seo.ErrIndexingDisabled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
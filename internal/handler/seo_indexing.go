@@ -0,0 +1,115 @@
+/*
+Package handlers defines search engine URL push HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"net/http"
+
+	"resource-share-site/internal/service/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxManualIndexURLs 手动推送单次最多提交的URL数
+const maxManualIndexURLs = 1000
+
+// SubmitIndexURLsRequest 手动推送URL请求
+type SubmitIndexURLsRequest struct {
+	URLs   []string `json:"urls" binding:"required,min=1"` // 站内路径或本站完整URL
+	Action string   `json:"action"`                        // update（默认）或 delete
+}
+
+// IndexNowKeyFile 输出IndexNow密钥文件（搜索引擎通过它验证站点所有权）
+func (h *Handler) IndexNowKeyFile(c *gin.Context) {
+	_, key := h.indexingService.KeyFile()
+	c.String(http.StatusOK, key)
+}
+
+// SubmitIndexURLs 手动将URL加入搜索引擎推送队列（管理员）
+func (h *Handler) SubmitIndexURLs(c *gin.Context) {
+	var req SubmitIndexURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+	if len(req.URLs) > maxManualIndexURLs {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "单次最多提交1000个URL",
+			"status":  "error",
+		})
+		return
+	}
+	if req.Action == "" {
+		req.Action = seo.IndexActionUpdate
+	}
+	if !h.indexingService.Enabled() {
+		c.JSON(seoErrorStatus(seo.ErrIndexingDisabled), gin.H{
+			"message": seo.ErrIndexingDisabled.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.indexingService.PublishURLs(req.Action, req.URLs...); err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "已加入推送队列",
+		"status":  "success",
+	})
+}
+
+// FlushIndexQueue 立即处理搜索引擎推送队列（管理员）
+func (h *Handler) FlushIndexQueue(c *gin.Context) {
+	result, err := h.indexingService.Flush()
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "推送队列处理完成",
+		"status":  "success",
+		"data":    result,
+	})
+}
+
+// ListIndexSubmissions 获取搜索引擎推送记录（管理员，可按推送目标和状态筛选）
+func (h *Handler) ListIndexSubmissions(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	items, total, err := h.indexingService.ListSubmissions(c.Query("search_engine"), c.Query("status"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取推送记录成功",
+		"status":  "success",
+		"data": gin.H{
+			"submissions": items,
+			"total":       total,
+			"page":        page,
+			"page_size":   pageSize,
+		},
+	})
+}
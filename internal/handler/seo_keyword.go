@@ -0,0 +1,207 @@
+/*
+Package handlers defines SEO keyword rank import and performance HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"resource-share-site/internal/service/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRankImportSize 排名导入文件的最大大小
+const maxRankImportSize = 20 << 20
+
+// ImportKeywordRanks 导入站长平台导出的搜索表现数据（管理员）
+// 表单字段：file（CSV或JSON文件）、search_engine（google、bing、baidu）、
+// format（可选，默认根据文件名判断）、date（可选，数据日期，默认当天）、language（可选，新建关键词的语言）
+func (h *Handler) ImportKeywordRanks(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRankImportSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请上传导入文件（不超过20MB）",
+			"status":  "error",
+		})
+		return
+	}
+
+	var recordedOn time.Time
+	if date := c.PostForm("date"); date != "" {
+		recordedOn, err = time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "日期格式错误，应为 YYYY-MM-DD",
+				"status":  "error",
+			})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "读取导入文件失败",
+			"status":  "error",
+		})
+		return
+	}
+	defer file.Close()
+
+	opts := seo.RankImportOptions{
+		SearchEngine: c.PostForm("search_engine"),
+		Format:       c.PostForm("format"),
+		FileName:     fileHeader.Filename,
+		RecordedOn:   recordedOn,
+		Language:     c.PostForm("language"),
+	}
+	if userID, err := h.getCurrentUserID(c); err == nil {
+		opts.UserID = &userID
+	}
+
+	record, err := h.rankImportService.Import(file, opts)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "导入排名数据成功",
+		"status":  "success",
+		"data":    record,
+	})
+}
+
+// ListRankImports 获取排名导入记录（管理员）
+func (h *Handler) ListRankImports(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	imports, total, err := h.rankImportService.ListImports(c.Query("search_engine"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取导入记录成功",
+		"status":  "success",
+		"data": gin.H{
+			"imports":   imports,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetKeywordRanks 获取关键词排名历史（管理员）
+func (h *Handler) GetKeywordRanks(c *gin.Context) {
+	keywordID, ok := parseSEOConfigID(c)
+	if !ok {
+		return
+	}
+	if _, err := h.seoService.GetKeyword(keywordID); err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	page, pageSize := parsePagination(c)
+	ranks, total, err := h.seoService.GetKeywordRanks(keywordID, c.Query("search_engine"), "", page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取排名历史成功",
+		"status":  "success",
+		"data": gin.H{
+			"ranks":     ranks,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetKeywordPerformance 获取关键词表现分析（管理员，days 默认30天）
+func (h *Handler) GetKeywordPerformance(c *gin.Context) {
+	keywordID, ok := parseSEOConfigID(c)
+	if !ok {
+		return
+	}
+	keyword, err := h.seoService.GetKeyword(keywordID)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days > 365 {
+		days = 365
+	}
+	analysis, err := h.seoService.AnalyzeKeywordPerformance(keywordID, c.Query("search_engine"), days)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取关键词表现成功",
+		"status":  "success",
+		"data": gin.H{
+			"keyword":     keyword,
+			"performance": analysis,
+		},
+	})
+}
+
+// ListSEOEvents 获取SEO事件（管理员，可按类型和关键词筛选）
+func (h *Handler) ListSEOEvents(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	keywordID, _ := strconv.ParseUint(c.Query("keyword_id"), 10, 32)
+	events, total, err := h.seoService.ListSEOEvents(c.Query("type"), uint(keywordID), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取SEO事件成功",
+		"status":  "success",
+		"data": gin.H{
+			"events":    events,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
@@ -0,0 +1,201 @@
+/*
+Package handlers defines URL redirect HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"resource-share-site/internal/service/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRedirectImportSize 跳转规则导入文件的最大大小
+const maxRedirectImportSize = 5 << 20
+
+// RedirectNotFound 未匹配任何路由的GET请求按跳转规则跳转（没有规则时交给后续的404处理）
+func (h *Handler) RedirectNotFound(c *gin.Context) {
+	if h.resolveRedirect(c) {
+		c.Abort()
+	}
+}
+
+// resolveRedirect 查找当前路径的跳转规则，找到时发送跳转响应
+func (h *Handler) resolveRedirect(c *gin.Context) bool {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	redirect, err := h.redirectService.Resolve(c.Request.URL.Path)
+	if err != nil {
+		if !errors.Is(err, seo.ErrRedirectNotFound) {
+			c.Error(err)
+		}
+		return false
+	}
+
+	target := redirect.ToPath
+	if query := c.Request.URL.RawQuery; query != "" && !strings.Contains(target, "?") {
+		target += "?" + query
+	}
+	c.Redirect(redirect.StatusCode, target)
+	return true
+}
+
+// permanentRedirect 301跳转到站内路径（保留查询参数）
+func permanentRedirect(c *gin.Context, path string) {
+	if query := c.Request.URL.RawQuery; query != "" {
+		path += "?" + query
+	}
+	c.Redirect(http.StatusMovedPermanently, path)
+}
+
+// ListSEORedirects 获取跳转规则列表（管理员，可按关键词和关联实体类型筛选）
+func (h *Handler) ListSEORedirects(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	redirects, total, err := h.redirectService.List(c.Query("keyword"), c.Query("target_type"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取跳转规则成功",
+		"status":  "success",
+		"data": gin.H{
+			"redirects": redirects,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// CreateSEORedirect 创建跳转规则（管理员）
+func (h *Handler) CreateSEORedirect(c *gin.Context) {
+	var req seo.RedirectInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	redirect, err := h.redirectService.Create(req)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "创建跳转规则成功",
+		"status":  "success",
+		"data":    redirect,
+	})
+}
+
+// UpdateSEORedirect 更新跳转规则（管理员）
+func (h *Handler) UpdateSEORedirect(c *gin.Context) {
+	id, ok := parseSEOConfigID(c)
+	if !ok {
+		return
+	}
+
+	var req seo.RedirectInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	redirect, err := h.redirectService.Update(id, req)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新跳转规则成功",
+		"status":  "success",
+		"data":    redirect,
+	})
+}
+
+// DeleteSEORedirect 删除跳转规则（管理员）
+func (h *Handler) DeleteSEORedirect(c *gin.Context) {
+	id, ok := parseSEOConfigID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.redirectService.Delete(id); err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除跳转规则成功",
+		"status":  "success",
+	})
+}
+
+// ImportSEORedirects 从CSV批量导入跳转规则（管理员）
+// 表单字段：file（CSV文件，每行：旧地址,新地址[,状态码]）
+func (h *Handler) ImportSEORedirects(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRedirectImportSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请上传导入文件（不超过5MB）",
+			"status":  "error",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "读取导入文件失败",
+			"status":  "error",
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := h.redirectService.Import(file)
+	if err != nil {
+		c.JSON(seoErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "导入跳转规则完成",
+		"status":  "success",
+		"data":    result,
+	})
+}
@@ -0,0 +1,65 @@
+/*
+Package handlers defines sitemap HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"resource-share-site/internal/service/seo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== Sitemap处理器 ====================
+
+// SitemapIndex 输出sitemap索引（路径以 .gz 结尾时输出gzip压缩内容）
+func (h *Handler) SitemapIndex(c *gin.Context) {
+	gzipped := strings.HasSuffix(c.Request.URL.Path, ".gz")
+	data, err := h.sitemapService.Index(gzipped)
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSitemap(c, data, gzipped)
+}
+
+// SitemapShard 输出sitemap分片（如 /sitemaps/resources-1.xml 或 /sitemaps/resources-1.xml.gz）
+func (h *Handler) SitemapShard(c *gin.Context) {
+	file := c.Param("file")
+	gzipped := strings.HasSuffix(file, ".gz")
+	name := strings.TrimSuffix(file, ".gz")
+	if !strings.HasSuffix(name, ".xml") {
+		c.String(http.StatusNotFound, seo.ErrSitemapNotFound.Error())
+		return
+	}
+
+	data, err := h.sitemapService.Shard(strings.TrimSuffix(name, ".xml"), gzipped)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, seo.ErrSitemapNotFound) {
+			status = http.StatusNotFound
+		}
+		c.String(status, err.Error())
+		return
+	}
+
+	writeSitemap(c, data, gzipped)
+}
+
+// writeSitemap 按格式写出sitemap内容
+func writeSitemap(c *gin.Context, data []byte, gzipped bool) {
+	c.Header("Cache-Control", "public, max-age=300")
+	if gzipped {
+		c.Data(http.StatusOK, "application/gzip", data)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
@@ -0,0 +1,301 @@
+/*
+Package handlers defines tag HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/service/tag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tagRequest 创建或修改标签请求
+type tagRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// ==================== 标签处理器 ====================
+
+// ListTags 获取标签列表（sort 可选 popular/name/newest）
+func (h *Handler) ListTags(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	tags, total, err := h.tagService.ListTags(c.Query("keyword"), c.Query("sort"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取标签列表成功",
+		"status":  "success",
+		"data": gin.H{
+			"tags":      tags,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		},
+	})
+}
+
+// GetPopularTagList 获取热门标签（type 可选 resource/article，默认按总使用次数）
+func (h *Handler) GetPopularTagList(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	tags, err := h.tagService.PopularTags(c.Query("type"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取热门标签成功",
+		"status":  "success",
+		"data":    tags,
+	})
+}
+
+// GetTagPage 获取标签页（同义词返回主标签的页面）
+func (h *Handler) GetTagPage(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	result, err := h.tagService.GetTagPage(c.Param("slug"), page, pageSize)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取标签成功",
+		"status":  "success",
+		"data": gin.H{
+			"tag":            result.Tag,
+			"synonyms":       result.Synonyms,
+			"resources":      result.Resources,
+			"resource_total": result.ResourceTotal,
+			"articles":       result.Articles,
+			"article_total":  result.ArticleTotal,
+			"page":           page,
+			"page_size":      pageSize,
+		},
+	})
+}
+
+// ==================== 标签管理处理器 ====================
+
+// CreateTag 创建标签（管理员）
+func (h *Handler) CreateTag(c *gin.Context) {
+	var req tagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	created, err := h.tagService.CreateTag(req.Name, req.Description)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "创建标签成功",
+		"status":  "success",
+		"data":    created,
+	})
+}
+
+// UpdateTag 修改标签名称和描述（管理员）
+func (h *Handler) UpdateTag(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	var req tagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	updated, err := h.tagService.UpdateTag(id, req.Name, req.Description)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "更新标签成功",
+		"status":  "success",
+		"data":    updated,
+	})
+}
+
+// DeleteTag 删除标签及其同义词（管理员）
+func (h *Handler) DeleteTag(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tagService.DeleteTag(id); err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "删除标签成功",
+		"status":  "success",
+	})
+}
+
+// AddTagSynonym 为标签添加同义词（管理员，同名标签已存在时合并）
+func (h *Handler) AddTagSynonym(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required,max=50"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	synonym, err := h.tagService.AddSynonym(id, req.Name)
+	if err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "添加同义词成功",
+		"status":  "success",
+		"data":    synonym,
+	})
+}
+
+// MergeTags 合并标签（管理员，原标签变为目标标签的同义词）
+func (h *Handler) MergeTags(c *gin.Context) {
+	var req struct {
+		SourceID uint `json:"source_id" binding:"required"`
+		TargetID uint `json:"target_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	if err := h.tagService.MergeTags(req.SourceID, req.TargetID); err != nil {
+		c.JSON(tagErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "合并标签成功",
+		"status":  "success",
+	})
+}
+
+// RecountTags 重新统计所有标签的使用次数（管理员）
+func (h *Handler) RecountTags(c *gin.Context) {
+	if err := h.tagService.RecountAll(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "标签使用次数已重新统计",
+		"status":  "success",
+	})
+}
+
+// parseTagID 解析路径中的标签ID（无效时直接返回错误响应）
+func parseTagID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的标签ID",
+			"status":  "error",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// tagErrorStatus 将标签服务错误映射为HTTP状态码
+func tagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, tag.ErrTagNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tag.ErrTagExists):
+		return http.StatusConflict
+	case errors.Is(err, tag.ErrInvalidTagName), errors.Is(err, tag.ErrSameTag), errors.Is(err, tag.ErrTooManyTags):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
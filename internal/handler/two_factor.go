@@ -0,0 +1,289 @@
+/*
+Package handlers defines two-factor authentication HTTP request handlers.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"resource-share-site/internal/service/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== 两步验证处理器 ====================
+
+// TwoFactorLogin 两步验证登录（提交验证码或恢复码）
+func (h *Handler) TwoFactorLogin(c *gin.Context) {
+	var req auth.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请输入验证码或恢复码",
+			"status":  "error",
+		})
+		return
+	}
+
+	req.IP = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	authCtx := &auth.GORMContext{DB: h.db}
+	response, err := h.authService.CompleteTwoFactorLogin(authCtx, &req)
+	if err != nil {
+		var blocked *auth.LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"message": err.Error(),
+				"status":  "error",
+			})
+			return
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "登录成功",
+		"status":  "success",
+		"data":    response,
+	})
+}
+
+// TwoFactorLoginSetup 登录过程中配置两步验证（强制启用但尚未配置的账户）
+func (h *Handler) TwoFactorLoginSetup(c *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	challenge, err := h.twoFactorService.GetChallenge(req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	setup, err := h.twoFactorService.BeginSetup(challenge.UserID)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "请使用认证器App扫描二维码，并提交验证码完成登录",
+		"status":  "success",
+		"data":    setup,
+	})
+}
+
+// GetTwoFactorStatus 获取当前用户的两步验证状态
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	status, err := h.twoFactorService.GetStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "获取两步验证状态成功",
+		"status":  "success",
+		"data":    status,
+	})
+}
+
+// SetupTwoFactor 生成两步验证密钥和二维码配置URI
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	setup, err := h.twoFactorService.BeginSetup(userID)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "请使用认证器App扫描二维码，并提交验证码完成启用",
+		"status":  "success",
+		"data":    setup,
+	})
+}
+
+// EnableTwoFactor 验证首个验证码并启用两步验证
+func (h *Handler) EnableTwoFactor(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(userID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "两步验证已启用，请妥善保存恢复码",
+		"status":  "success",
+		"data": gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableTwoFactor 关闭两步验证
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	err := h.twoFactorService.Disable(userID, req.Password, req.Code, &auth.AuditContext{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "两步验证已关闭",
+		"status":  "success",
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码（旧恢复码全部失效）
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "请求参数错误: " + err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "恢复码已重新生成，请妥善保存",
+		"status":  "success",
+		"data": gin.H{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// AdminResetTwoFactor 管理员重置用户的两步验证（管理员）
+func (h *Handler) AdminResetTwoFactor(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "无效的用户ID",
+			"status":  "error",
+		})
+		return
+	}
+
+	err = h.twoFactorService.AdminReset(uint(targetID), &auth.AuditContext{
+		ActorID:   c.GetUint("userID"),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		c.JSON(twoFactorErrorStatus(err), gin.H{
+			"message": err.Error(),
+			"status":  "error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已重置该用户的两步验证",
+		"status":  "success",
+	})
+}
+
+// twoFactorErrorStatus 将两步验证服务错误映射为HTTP状态码
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrTwoFactorRequired):
+		return http.StatusForbidden
+	case errors.Is(err, auth.ErrTwoFactorAlreadyEnabled):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
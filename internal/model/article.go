@@ -17,8 +17,9 @@ import (
 type ArticleStatus string
 
 const (
-	ArticleStatusDraft   ArticleStatus = "draft"   // 草稿
-	ArticleStatusPending ArticleStatus = "pending" // 待审核
+	ArticleStatusDraft     ArticleStatus = "draft"     // 草稿
+	ArticleStatusPending   ArticleStatus = "pending"   // 待审核
+	ArticleStatusScheduled ArticleStatus = "scheduled" // 定时发布（审核通过，到达发布时间后自动发布）
 	ArticleStatusPublished ArticleStatus = "published" // 已发布
 	ArticleStatusArchived  ArticleStatus = "archived"  // 已归档
 )
@@ -31,14 +32,15 @@ type Article struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 基本信息
-	Title   string `gorm:"not null;size:200;index" json:"title" binding:"required,min=1,max=200"`
-	Slug    string `gorm:"not null;uniqueIndex;size:200" json:"slug" binding:"required"`
-	Content string `gorm:"not null;type:longtext" json:"content" binding:"required"`
-	Excerpt string `gorm:"size:500" json:"excerpt"`
+	Title       string `gorm:"not null;size:200;index" json:"title" binding:"required,min=1,max=200"`
+	Slug        string `gorm:"not null;uniqueIndex;size:200" json:"slug" binding:"required"`
+	Content     string `gorm:"not null;type:longtext" json:"content" binding:"required"`
+	Excerpt     string `gorm:"size:500" json:"excerpt"`                // 未填写时根据正文自动生成
+	ReadingTime int    `gorm:"default:0;not null" json:"reading_time"` // 预计阅读时间（分钟，根据正文计算）
 
 	// 媒体资源
 	FeaturedImage string `gorm:"size:500" json:"featured_image"`
-	Tags          string `gorm:"size:200" json:"tags"` // 逗号分隔的标签
+	Tags          string `gorm:"size:200" json:"tags"` // 逗号分隔的标签名（由标签服务根据 article_tags 关联同步）
 
 	// 分类
 	Category string `gorm:"size:100;index" json:"category"`
@@ -50,13 +52,21 @@ type Article struct {
 	AuthorID uint  `gorm:"not null;index" json:"author_id"`
 	Author   *User `gorm:"foreignKey:AuthorID" json:"author"`
 
-	// 发布时间
+	// 发布时间（定时发布的文章为计划发布时间）
 	PublishedAt *time.Time `gorm:"index" json:"published_at"`
 
+	// 编辑流程
+	SubmittedAt *time.Time `json:"submitted_at"` // 最近一次提交审核的时间
+	ArchivedAt  *time.Time `json:"archived_at"`  // 归档时间
+
 	// 统计数据
-	ViewCount    uint `gorm:"default:0;not null" json:"view_count"`
-	LikeCount    uint `gorm:"default:0;not null" json:"like_count"`
-	CommentCount uint `gorm:"default:0;not null" json:"comment_count"`
+	ViewCount     uint `gorm:"default:0;not null" json:"view_count"`
+	LikeCount     uint `gorm:"default:0;not null" json:"like_count"`
+	CommentCount  uint `gorm:"default:0;not null" json:"comment_count"`
+	FavoriteCount uint `gorm:"default:0;not null" json:"favorite_count"` // 收藏数（由收藏服务维护）
+
+	// 当前用户是否点赞（不存储，由处理器根据表态记录填充）
+	Liked bool `gorm:"-" json:"liked"`
 
 	// 审核信息
 	ReviewedByID *uint      `gorm:"index" json:"reviewed_by_id"`
@@ -106,12 +116,13 @@ type ArticleComment struct {
 	UserID uint  `gorm:"not null;index" json:"user_id"`
 	User   *User `gorm:"foreignKey:UserID" json:"user"`
 
-	ArticleID uint   `gorm:"not null;index" json:"article_id"`
+	ArticleID uint     `gorm:"not null;index" json:"article_id"`
 	Article   *Article `gorm:"foreignKey:ArticleID" json:"-"`
 
 	// 父评论（用于回复）
-	ParentID *uint         `gorm:"index" json:"parent_id"`
-	Parent   *ArticleComment `gorm:"foreignKey:ParentID" json:"-"`
+	ParentID *uint            `gorm:"index" json:"parent_id"`
+	Parent   *ArticleComment  `gorm:"foreignKey:ParentID" json:"-"`
+	Replies  []ArticleComment `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
 
 	// 状态
 	Status CommentStatus `gorm:"default:'pending';not null;size:20" json:"status"`
@@ -124,6 +135,7 @@ type ArticleComment struct {
 
 	// 点赞数
 	LikeCount uint `gorm:"default:0;not null" json:"like_count"`
+	Liked     bool `gorm:"-" json:"liked"` // 当前用户是否点赞（不存储）
 }
 
 // TableName 指定表名
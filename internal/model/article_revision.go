@@ -0,0 +1,48 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ArticleRevision 文章修订记录（自动保存和手动保存都保存一份完整快照）
+type ArticleRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ArticleID uint     `gorm:"not null;uniqueIndex:idx_article_revision_version" json:"article_id"`
+	Article   *Article `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
+
+	// 版本号（同一文章内递增）
+	Version int `gorm:"not null;uniqueIndex:idx_article_revision_version" json:"version"`
+
+	// 文章快照
+	Title           string `gorm:"not null;size:200" json:"title"`
+	Content         string `gorm:"type:longtext" json:"content"`
+	Excerpt         string `gorm:"size:500" json:"excerpt"`
+	FeaturedImage   string `gorm:"size:500" json:"featured_image"`
+	Category        string `gorm:"size:100" json:"category"`
+	Tags            string `gorm:"size:200" json:"tags"` // 逗号分隔的标签名
+	MetaTitle       string `gorm:"size:200" json:"meta_title"`
+	MetaDescription string `gorm:"size:500" json:"meta_description"`
+	MetaKeywords    string `gorm:"size:200" json:"meta_keywords"`
+
+	// 修改信息
+	EditorID     uint   `gorm:"not null;index" json:"editor_id"`
+	Editor       *User  `gorm:"foreignKey:EditorID" json:"editor,omitempty"`
+	Autosave     bool   `gorm:"default:false;index" json:"autosave"` // 自动保存（同一编辑者连续的自动保存合并为一条）
+	Note         string `gorm:"size:500" json:"note"`
+	RestoredFrom *int   `json:"restored_from,omitempty"` // 恢复时使用的版本号
+}
+
+// TableName 指定表名
+func (ArticleRevision) TableName() string {
+	return "article_revisions"
+}
@@ -26,6 +26,10 @@ type Category struct {
 	Icon        string `gorm:"size:100" json:"icon"`
 	Color       string `gorm:"size:20" json:"color"`
 
+	// URL标识
+	Slug string `gorm:"size:50" json:"slug"`        // 名称转换的URL标识（同级分类中唯一）
+	Path string `gorm:"size:500;index" json:"path"` // 从根分类到当前分类的slug路径（地址为 /c/:path）
+
 	// 层级关系
 	ParentID *uint      `gorm:"index" json:"parent_id"`
 	Parent   *Category  `gorm:"foreignKey:ParentID" json:"-"`
@@ -51,7 +51,7 @@ type Comment struct {
 	// 回复评论 ID（用于支持嵌套评论）
 	ParentID *uint     `gorm:"index" json:"parent_id"`
 	Parent   *Comment  `gorm:"foreignKey:ParentID" json:"-"`
-	Replies  []Comment `gorm:"foreignKey:ParentID" json:"-"`
+	Replies  []Comment `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
 }
 
 // TableName 指定表名
@@ -0,0 +1,30 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// DownloadRecord 用户下载资源记录（每个用户每个资源一条，用于下载历史和推荐）
+type DownloadRecord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"` // 首次下载时间
+
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_download_user_resource" json:"user_id"`
+	ResourceID uint      `gorm:"not null;uniqueIndex:idx_download_user_resource;index" json:"resource_id"`
+	Resource   *Resource `gorm:"foreignKey:ResourceID" json:"resource,omitempty"`
+
+	DownloadCount    int       `gorm:"default:1" json:"download_count"`
+	LastDownloadedAt time.Time `gorm:"index" json:"last_downloaded_at"`
+}
+
+// TableName 指定表名
+func (DownloadRecord) TableName() string {
+	return "download_records"
+}
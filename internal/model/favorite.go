@@ -0,0 +1,88 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// FavoriteTargetType 收藏对象类型枚举
+type FavoriteTargetType string
+
+const (
+	FavoriteTargetResource FavoriteTargetType = "resource" // 资源
+	FavoriteTargetArticle  FavoriteTargetType = "article"  // 文章
+)
+
+// Favorite 收藏模型（资源和文章共用）
+type Favorite struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID     uint               `gorm:"not null;uniqueIndex:idx_user_favorite" json:"user_id"`
+	TargetType FavoriteTargetType `gorm:"not null;size:20;uniqueIndex:idx_user_favorite;index:idx_favorite_target" json:"target_type"`
+	TargetID   uint               `gorm:"not null;uniqueIndex:idx_user_favorite;index:idx_favorite_target" json:"target_id"`
+}
+
+// TableName 指定表名
+func (Favorite) TableName() string {
+	return "favorites"
+}
+
+// Collection 用户整理的资源合集（公开合集可被其他用户关注）
+type Collection struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint  `gorm:"not null;index" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"owner,omitempty"`
+
+	Name        string `gorm:"not null;size:100" json:"name"`
+	Description string `gorm:"size:1000" json:"description"`
+	IsPublic    bool   `gorm:"default:true;index" json:"is_public"`
+
+	// 统计信息（由收藏服务维护）
+	ItemsCount     int `gorm:"default:0" json:"items_count"`
+	FollowersCount int `gorm:"default:0;index" json:"followers_count"`
+}
+
+// TableName 指定表名
+func (Collection) TableName() string {
+	return "collections"
+}
+
+// CollectionItem 合集中的资源（按 Position 升序排列）
+type CollectionItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	CollectionID uint      `gorm:"not null;uniqueIndex:idx_collection_resource" json:"collection_id"`
+	ResourceID   uint      `gorm:"not null;uniqueIndex:idx_collection_resource;index" json:"resource_id"`
+	Resource     *Resource `gorm:"foreignKey:ResourceID" json:"resource,omitempty"`
+
+	Position int    `gorm:"not null;default:0" json:"position"`
+	Note     string `gorm:"size:500" json:"note"` // 整理者的推荐语
+}
+
+// TableName 指定表名
+func (CollectionItem) TableName() string {
+	return "collection_items"
+}
+
+// CollectionFollow 用户关注的合集
+type CollectionFollow struct {
+	CollectionID uint      `gorm:"primaryKey" json:"collection_id"`
+	UserID       uint      `gorm:"primaryKey;index" json:"user_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (CollectionFollow) TableName() string {
+	return "collection_follows"
+}
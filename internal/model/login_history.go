@@ -0,0 +1,54 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// LoginFailureReason 登录失败原因枚举
+type LoginFailureReason string
+
+const (
+	LoginFailureUserNotFound    LoginFailureReason = "user_not_found"   // 用户不存在
+	LoginFailureWrongPassword   LoginFailureReason = "wrong_password"   // 密码错误
+	LoginFailureWrongOTP        LoginFailureReason = "wrong_otp"        // 两步验证码错误
+	LoginFailureAccountDisabled LoginFailureReason = "account_disabled" // 账户被禁用
+	LoginFailureLocked          LoginFailureReason = "locked"           // 账户或IP已锁定
+	LoginFailureTooFrequent     LoginFailureReason = "too_frequent"     // 尝试过于频繁
+)
+
+// LoginHistory 登录历史模型（成功和失败的登录尝试都会记录）
+type LoginHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	// 登录用户（用户不存在时为空）
+	UserID     *uint  `gorm:"index" json:"user_id"`
+	User       *User  `gorm:"foreignKey:UserID" json:"-"`
+	Identifier string `gorm:"size:100;index" json:"identifier"` // 登录时输入的用户名或邮箱
+
+	// 客户端信息
+	IP        string `gorm:"not null;size:45;index" json:"ip"`
+	UserAgent string `gorm:"size:500" json:"user_agent"`
+	Country   string `gorm:"size:50" json:"country"` // 来自访问日志的地理位置
+	City      string `gorm:"size:50" json:"city"`
+
+	// 登录结果
+	Success       bool               `gorm:"not null" json:"success"`
+	FailureReason LoginFailureReason `gorm:"size:30" json:"failure_reason"`
+
+	// 异常登录标记（如新国家登录）
+	IsAnomaly     bool   `gorm:"not null;index" json:"is_anomaly"`
+	AnomalyReason string `gorm:"size:200" json:"anomaly_reason"`
+}
+
+// TableName 指定表名
+func (LoginHistory) TableName() string {
+	return "login_histories"
+}
@@ -0,0 +1,72 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ModerationRuleType 自动审核规则类型枚举
+type ModerationRuleType string
+
+const (
+	ModerationRuleKeyword         ModerationRuleType = "keyword"          // 关键词黑名单
+	ModerationRuleRegex           ModerationRuleType = "regex"            // 正则黑名单
+	ModerationRuleDomainAllowlist ModerationRuleType = "domain_allowlist" // 网盘域名白名单（不在名单内即命中）
+	ModerationRuleDuplicateURL    ModerationRuleType = "duplicate_url"    // 重复链接
+	ModerationRuleTrustScore      ModerationRuleType = "trust_score"      // 上传者信誉（历史审核通过率）
+	ModerationRulePriceCap        ModerationRuleType = "price_cap"        // 积分价格上限
+	ModerationRuleTrustedRole     ModerationRuleType = "trusted_role"     // 可信角色（如管理员、版主）
+)
+
+// ModerationRuleTypes 所有规则类型
+var ModerationRuleTypes = []ModerationRuleType{
+	ModerationRuleKeyword,
+	ModerationRuleRegex,
+	ModerationRuleDomainAllowlist,
+	ModerationRuleDuplicateURL,
+	ModerationRuleTrustScore,
+	ModerationRulePriceCap,
+	ModerationRuleTrustedRole,
+}
+
+// ModerationDecision 自动审核决定枚举
+type ModerationDecision string
+
+const (
+	ModerationDecisionApprove ModerationDecision = "approve" // 自动通过
+	ModerationDecisionReject  ModerationDecision = "reject"  // 自动拒绝
+	ModerationDecisionHold    ModerationDecision = "hold"    // 转人工审核
+)
+
+// ModerationRule 自动审核规则模型
+type ModerationRule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string             `gorm:"not null;size:100" json:"name"`
+	Description string             `gorm:"size:500" json:"description"`
+	Type        ModerationRuleType `gorm:"not null;size:30;index" json:"type"`
+	Config      string             `gorm:"type:text" json:"config"` // 规则参数（JSON格式，按类型不同）
+
+	// 命中后的决定
+	Action ModerationDecision `gorm:"not null;size:20" json:"action"`
+
+	// 适用范围（为空表示所有分类）
+	CategoryID *uint     `gorm:"index" json:"category_id"`
+	Category   *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+
+	Priority  int  `gorm:"not null;index" json:"priority"` // 优先级，数值小的先执行
+	IsEnabled bool `gorm:"not null" json:"is_enabled"`
+}
+
+// TableName 指定表名
+func (ModerationRule) TableName() string {
+	return "moderation_rules"
+}
@@ -0,0 +1,111 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationType 通知类型枚举
+type NotificationType string
+
+const (
+	NotificationTypeResourceApproved NotificationType = "resource_approved" // 资源审核通过
+	NotificationTypeResourceRejected NotificationType = "resource_rejected" // 资源审核拒绝
+	NotificationTypeCommentReply     NotificationType = "comment_reply"     // 评论被回复
+	NotificationTypeInviteCompleted  NotificationType = "invite_completed"  // 邀请完成
+	NotificationTypePointsReceived   NotificationType = "points_received"   // 积分到账
+	NotificationTypeOrderShipped     NotificationType = "order_shipped"     // 订单发货
+	NotificationTypeSecurityAlert    NotificationType = "security_alert"    // 安全告警
+	NotificationTypeLowRating        NotificationType = "low_rating"        // 资源评分过低（通知审核员）
+	NotificationTypeRankDrop         NotificationType = "rank_drop"         // 关键词排名大幅下降（通知管理员）
+	NotificationTypeArticleApproved  NotificationType = "article_approved"  // 文章审核通过
+	NotificationTypeArticleRejected  NotificationType = "article_rejected"  // 文章审核拒绝
+	NotificationTypeSystem           NotificationType = "system"            // 系统通知
+)
+
+// NotificationTypes 所有通知类型
+var NotificationTypes = []NotificationType{
+	NotificationTypeResourceApproved,
+	NotificationTypeResourceRejected,
+	NotificationTypeCommentReply,
+	NotificationTypeInviteCompleted,
+	NotificationTypePointsReceived,
+	NotificationTypeOrderShipped,
+	NotificationTypeSecurityAlert,
+	NotificationTypeLowRating,
+	NotificationTypeRankDrop,
+	NotificationTypeArticleApproved,
+	NotificationTypeArticleRejected,
+	NotificationTypeSystem,
+}
+
+// NotificationChannel 通知通道枚举
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp   NotificationChannel = "in_app"  // 站内信
+	NotificationChannelEmail   NotificationChannel = "email"   // 邮件
+	NotificationChannelWebhook NotificationChannel = "webhook" // Webhook
+)
+
+// Notification 站内通知模型
+type Notification struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// 接收者
+	UserID uint  `gorm:"not null;index:idx_notification_user_read" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+
+	// 通知内容
+	Type    NotificationType `gorm:"not null;size:30;index" json:"type"`
+	Title   string           `gorm:"not null;size:200" json:"title"`
+	Content string           `gorm:"size:1000" json:"content"`
+	Link    string           `gorm:"size:500" json:"link"` // 跳转链接
+
+	// 关联对象
+	TargetType string `gorm:"size:30" json:"target_type"` // resource, comment, invitation, order ...
+	TargetID   *uint  `json:"target_id"`
+
+	// 阅读状态
+	IsRead bool       `gorm:"default:false;index:idx_notification_user_read" json:"is_read"`
+	ReadAt *time.Time `json:"read_at"`
+}
+
+// TableName 指定表名
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference 用户通知偏好模型（按通知类型配置各通道开关）
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint             `gorm:"not null;uniqueIndex:idx_user_notification_type" json:"user_id"`
+	Type   NotificationType `gorm:"not null;size:30;uniqueIndex:idx_user_notification_type" json:"type"`
+
+	// 通道开关（不设置默认值，避免GORM忽略false零值）
+	InApp   bool `gorm:"not null" json:"in_app"`
+	Email   bool `gorm:"not null" json:"email"`
+	Webhook bool `gorm:"not null" json:"webhook"`
+
+	// Webhook地址
+	WebhookURL string `gorm:"size:500" json:"webhook_url"`
+}
+
+// TableName 指定表名
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
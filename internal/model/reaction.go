@@ -0,0 +1,56 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ReactionTargetType 表态对象类型枚举
+type ReactionTargetType string
+
+const (
+	ReactionTargetArticle        ReactionTargetType = "article"         // 文章
+	ReactionTargetArticleComment ReactionTargetType = "article_comment" // 文章评论
+)
+
+// ReactionKind 表态类型枚举
+type ReactionKind string
+
+const (
+	ReactionLike  ReactionKind = "like"  // 点赞（计入对象的点赞数）
+	ReactionLove  ReactionKind = "love"  // 喜爱
+	ReactionLaugh ReactionKind = "laugh" // 好笑
+	ReactionWow   ReactionKind = "wow"   // 惊讶
+	ReactionSad   ReactionKind = "sad"   // 难过
+)
+
+// ReactionKinds 所有表态类型
+var ReactionKinds = []ReactionKind{
+	ReactionLike,
+	ReactionLove,
+	ReactionLaugh,
+	ReactionWow,
+	ReactionSad,
+}
+
+// Reaction 用户对文章或评论的表态（同一用户对同一对象的每种表态只有一条）
+type Reaction struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID     uint               `gorm:"not null;uniqueIndex:idx_user_reaction" json:"user_id"`
+	TargetType ReactionTargetType `gorm:"not null;size:20;uniqueIndex:idx_user_reaction;index:idx_reaction_target" json:"target_type"`
+	TargetID   uint               `gorm:"not null;uniqueIndex:idx_user_reaction;index:idx_reaction_target" json:"target_id"`
+	Kind       ReactionKind       `gorm:"not null;size:20;uniqueIndex:idx_user_reaction;index:idx_reaction_target" json:"kind"`
+}
+
+// TableName 指定表名
+func (Reaction) TableName() string {
+	return "reactions"
+}
@@ -0,0 +1,31 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ResourceSimilarity 预计算的资源相似度（由推荐服务定期批量重建，每对资源双向各存一条）
+type ResourceSimilarity struct {
+	ResourceID uint `gorm:"primaryKey" json:"resource_id"`
+	RelatedID  uint `gorm:"primaryKey;index" json:"related_id"`
+
+	// 综合得分及各项信号得分（0-1）
+	Score           float64 `gorm:"not null;index" json:"score"`
+	CoDownloadScore float64 `json:"co_download_score"` // 共同下载
+	TagScore        float64 `json:"tag_score"`         // 标签重合
+	TitleScore      float64 `json:"title_score"`       // 标题词项相似
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ResourceSimilarity) TableName() string {
+	return "resource_similarities"
+}
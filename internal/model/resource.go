@@ -41,6 +41,7 @@ type Resource struct {
 
 	// 基本信息
 	Title       string    `gorm:"not null;size:200" json:"title" binding:"required,min=1,max=200"`
+	Slug        string    `gorm:"size:100" json:"slug"` // 标题转换的URL标识（地址为 /r/:id-:slug）
 	Description string    `gorm:"type:text" json:"description"`
 	CategoryID  uint      `gorm:"not null;index" json:"category_id"`
 	Category    *Category `gorm:"foreignKey:CategoryID" json:"category"`
@@ -68,8 +69,15 @@ type Resource struct {
 	// 统计信息
 	DownloadsCount uint `gorm:"default:0" json:"downloads_count"`
 	ViewsCount     uint `gorm:"default:0" json:"views_count"`
+	FavoritesCount uint `gorm:"default:0;index" json:"favorites_count"` // 收藏数（由收藏服务维护）
 
-	// 标签（使用 JSON 格式存储）
+	// 评分信息（由评分服务维护）
+	RatingCount     uint       `gorm:"default:0" json:"rating_count"`
+	RatingAverage   float64    `gorm:"default:0" json:"rating_average"`     // 算术平均分
+	RatingScore     float64    `gorm:"default:0;index" json:"rating_score"` // 贝叶斯平均分（用于排序）
+	RatingAlertedAt *time.Time `json:"-"`                                   // 最近一次低分提醒时间
+
+	// 标签名（JSON 数组，由标签服务根据 resource_tags 关联同步，仅用于展示）
 	Tags string `gorm:"type:text;size:1000" json:"tags"`
 
 	// 关联关系
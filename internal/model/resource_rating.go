@@ -0,0 +1,36 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ResourceRating 资源评分与评价（每个用户对每个资源一条，可修改）
+type ResourceRating struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ResourceID uint      `gorm:"not null;uniqueIndex:idx_resource_rating_user" json:"resource_id"`
+	Resource   *Resource `gorm:"foreignKey:ResourceID" json:"resource,omitempty"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_resource_rating_user;index" json:"user_id"`
+	User       *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+
+	Rating  int    `gorm:"not null" json:"rating"` // 1-5 星
+	Content string `gorm:"type:text" json:"content"`
+
+	// 评价内容审核（命中需人工审核的敏感词时待审核，审核通过前不公开展示，评分照常计入）
+	ContentPending bool   `gorm:"default:false;not null;index" json:"content_pending"`
+	ReviewNotes    string `gorm:"size:500" json:"review_notes,omitempty"`
+}
+
+// TableName 指定表名
+func (ResourceRating) TableName() string {
+	return "resource_ratings"
+}
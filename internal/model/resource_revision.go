@@ -0,0 +1,62 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// RevisionStatus 资源修订状态枚举
+type RevisionStatus string
+
+const (
+	RevisionStatusApplied    RevisionStatus = "applied"    // 已生效
+	RevisionStatusPending    RevisionStatus = "pending"    // 待审核（资源仍显示当前生效版本）
+	RevisionStatusRejected   RevisionStatus = "rejected"   // 审核拒绝
+	RevisionStatusSuperseded RevisionStatus = "superseded" // 审核前被同一资源的新修改取代
+)
+
+// ResourceRevision 资源修订记录（每次修改保存一份完整快照）
+type ResourceRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ResourceID uint      `gorm:"not null;uniqueIndex:idx_resource_revision_version" json:"resource_id"`
+	Resource   *Resource `gorm:"foreignKey:ResourceID" json:"resource,omitempty"`
+
+	// 版本号（同一资源内递增），BaseVersion 为修改时的生效版本
+	Version     int `gorm:"not null;uniqueIndex:idx_resource_revision_version" json:"version"`
+	BaseVersion int `gorm:"default:0" json:"base_version"`
+
+	// 资源快照
+	Title       string `gorm:"not null;size:200" json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+	CategoryID  uint   `gorm:"not null" json:"category_id"`
+	NetdiskURL  string `gorm:"not null;size:500" json:"netdisk_url"`
+	PointsPrice int    `gorm:"default:0" json:"points_price"`
+	Tags        string `gorm:"type:text" json:"tags"` // 标签名JSON数组
+
+	// 修改信息
+	EditorID   uint           `gorm:"not null;index" json:"editor_id"`
+	Editor     *User          `gorm:"foreignKey:EditorID" json:"editor,omitempty"`
+	Reason     string         `gorm:"size:500" json:"reason"`
+	Status     RevisionStatus `gorm:"not null;size:20;index" json:"status"`
+	RollbackOf *int           `json:"rollback_of,omitempty"` // 回滚时恢复的版本号
+
+	// 审核信息（待审核修订）
+	ReviewedByID *uint      `gorm:"index" json:"reviewed_by_id"`
+	ReviewedBy   *User      `gorm:"foreignKey:ReviewedByID" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	ReviewNotes  string     `gorm:"size:500" json:"review_notes"`
+}
+
+// TableName 指定表名
+func (ResourceRevision) TableName() string {
+	return "resource_revisions"
+}
@@ -0,0 +1,44 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ReviewAction 审核动作枚举
+type ReviewAction string
+
+const (
+	ReviewActionApprove ReviewAction = "approve" // 通过
+	ReviewActionReject  ReviewAction = "reject"  // 拒绝
+	ReviewActionRevert  ReviewAction = "revert"  // 撤回
+	ReviewActionHold    ReviewAction = "hold"    // 转人工审核（自动审核）
+)
+
+// ReviewLog 审核日志模型
+type ReviewLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ResourceID uint      `gorm:"not null;index" json:"resource_id"`
+	Resource   *Resource `gorm:"foreignKey:ResourceID" json:"resource"`
+
+	ReviewerID uint  `gorm:"not null;index" json:"reviewer_id"`
+	Reviewer   *User `gorm:"foreignKey:ReviewerID" json:"reviewer"`
+
+	Action     ReviewAction   `gorm:"not null;size:20" json:"action"`
+	Notes      string         `gorm:"size:500" json:"notes"`
+	FromStatus ResourceStatus `gorm:"not null;size:20" json:"from_status"`
+	ToStatus   ResourceStatus `gorm:"not null;size:20" json:"to_status"`
+}
+
+// TableName 指定表名
+func (ReviewLog) TableName() string {
+	return "review_logs"
+}
@@ -0,0 +1,56 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// ReviewClaim 审核认领模型（同一资源同一时间只能被一名审核员认领）
+type ReviewClaim struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ResourceID uint      `gorm:"not null;uniqueIndex" json:"resource_id"`
+	Resource   *Resource `gorm:"foreignKey:ResourceID" json:"-"`
+
+	ReviewerID uint  `gorm:"not null;index" json:"reviewer_id"`
+	Reviewer   *User `gorm:"foreignKey:ReviewerID" json:"reviewer,omitempty"`
+
+	ClaimedAt time.Time `gorm:"not null" json:"claimed_at"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"` // 租约到期时间，到期后自动释放
+}
+
+// TableName 指定表名
+func (ReviewClaim) TableName() string {
+	return "review_claims"
+}
+
+// IsExpired 检查租约是否已过期
+func (c *ReviewClaim) IsExpired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// RejectReasonTemplate 拒绝理由模板模型
+type RejectReasonTemplate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Code      string `gorm:"uniqueIndex;not null;size:50" json:"code"` // 模板代码，如 broken_link
+	Title     string `gorm:"not null;size:100" json:"title"`
+	Content   string `gorm:"not null;size:500" json:"content"` // 发送给上传者的拒绝理由
+	SortOrder int    `gorm:"default:0" json:"sort_order"`
+	IsEnabled bool   `gorm:"not null" json:"is_enabled"`
+}
+
+// TableName 指定表名
+func (RejectReasonTemplate) TableName() string {
+	return "reject_reason_templates"
+}
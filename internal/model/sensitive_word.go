@@ -0,0 +1,36 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// 敏感级别（级别越高处理越严格，具体处理方式由过滤配置决定）
+const (
+	SensitiveSeverityLow    = 1 // 低：替换为*
+	SensitiveSeverityMedium = 2 // 中：转人工审核
+	SensitiveSeverityHigh   = 3 // 高：直接拒绝
+)
+
+// SensitiveWord 敏感词模型
+type SensitiveWord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Word      string `gorm:"uniqueIndex;not null;size:100" json:"word"`
+	Category  string `gorm:"default:'general';not null;size:50;index" json:"category"` // 分类（如 politics、porn、gambling、ad）
+	Severity  int    `gorm:"default:2;not null" json:"severity"`                       // 敏感级别（1-3）
+	IsEnabled bool   `gorm:"not null" json:"is_enabled"`
+}
+
+// TableName 指定表名
+func (SensitiveWord) TableName() string {
+	return "sensitive_words"
+}
@@ -15,11 +15,14 @@ import (
 type SEOConfigType string
 
 const (
-	SEOConfigTypeHome     SEOConfigType = "home"     // 首页
-	SEOConfigTypeList     SEOConfigType = "list"     // 列表页
-	SEOConfigTypeDetail   SEOConfigType = "detail"   // 详情页
-	SEOConfigTypeCategory SEOConfigType = "category" // 分类页
-	SEOConfigTypeResource SEOConfigType = "resource" // 资源页
+	SEOConfigTypeHome       SEOConfigType = "home"       // 首页
+	SEOConfigTypeList       SEOConfigType = "list"       // 列表页
+	SEOConfigTypeDetail     SEOConfigType = "detail"     // 详情页
+	SEOConfigTypeCategory   SEOConfigType = "category"   // 分类页
+	SEOConfigTypeResource   SEOConfigType = "resource"   // 资源页
+	SEOConfigTypeCollection SEOConfigType = "collection" // 合集页
+	SEOConfigTypeArticle    SEOConfigType = "article"    // 文章页
+	SEOConfigTypeSearch     SEOConfigType = "search"     // 搜索结果页
 )
 
 // SEOConfig SEO配置模型
@@ -164,6 +167,14 @@ type SEORank struct {
 	Rank         int    `gorm:"not null" json:"rank"`                  // 排名位置
 	URL          string `gorm:"not null;size:500" json:"url"`          // 目标URL
 
+	// 搜索表现（来自站长平台导出数据）
+	RecordedOn  time.Time `gorm:"index" json:"recorded_on"`        // 数据日期
+	Position    float64   `gorm:"default:0" json:"position"`       // 平均排名（保留小数）
+	Clicks      int       `gorm:"default:0" json:"clicks"`         // 点击量
+	Impressions int       `gorm:"default:0" json:"impressions"`    // 展现量
+	CTR         float64   `gorm:"column:ctr;default:0" json:"ctr"` // 点击率（百分比）
+	ImportID    *uint     `gorm:"index" json:"import_id"`          // 导入批次ID（手工记录为空）
+
 	// 额外信息
 	Title       string `gorm:"size:255" json:"title"`       // 页面标题
 	Description string `gorm:"size:500" json:"description"` // 页面描述
@@ -216,6 +227,70 @@ func (SEOReport) TableName() string {
 	return "seo_reports"
 }
 
+// SEOReportTypeAudit 站点审计报告类型
+const SEOReportTypeAudit = "audit"
+
+// SEOAuditSeverity 审计问题严重程度
+type SEOAuditSeverity string
+
+const (
+	SEOAuditSeverityError   SEOAuditSeverity = "error"   // 错误（严重影响收录）
+	SEOAuditSeverityWarning SEOAuditSeverity = "warning" // 警告
+	SEOAuditSeverityNotice  SEOAuditSeverity = "notice"  // 提示
+)
+
+// SEOAuditPage 站点审计中抓取的页面
+type SEOAuditPage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ReportID uint   `gorm:"not null;index" json:"report_id"` // 所属审计报告
+	URL      string `gorm:"not null;size:500" json:"url"`    // 页面路径（含查询参数）
+
+	// 响应信息
+	StatusCode  int    `json:"status_code"`
+	ContentType string `gorm:"size:100" json:"content_type"`
+	ResponseMs  int64  `json:"response_ms"` // 响应耗时(毫秒)
+
+	// 页面信息（仅HTML页面）
+	Title             string `gorm:"size:500" json:"title"`
+	Description       string `gorm:"size:1000" json:"description"`
+	Canonical         string `gorm:"size:500" json:"canonical"`
+	Indexable         bool   `json:"indexable"` // 状态码为200且未设置noindex
+	H1Count           int    `json:"h1_count"`
+	ImageCount        int    `json:"image_count"`
+	ImagesMissingAlt  int    `json:"images_missing_alt"`
+	LinkCount         int    `json:"link_count"` // 站内链接数
+	HasStructuredData bool   `json:"has_structured_data"`
+
+	// 结果
+	Score    int               `json:"score"` // 页面得分 (0-100)
+	Findings []SEOAuditFinding `gorm:"foreignKey:PageID" json:"findings,omitempty"`
+}
+
+// TableName 指定表名
+func (SEOAuditPage) TableName() string {
+	return "seo_audit_pages"
+}
+
+// SEOAuditFinding 站点审计发现的问题
+type SEOAuditFinding struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ReportID uint             `gorm:"not null;index" json:"report_id"`
+	PageID   uint             `gorm:"not null;index" json:"page_id"`
+	Code     string           `gorm:"not null;size:50;index" json:"code"` // 检查项（如 title_missing、broken_link）
+	Severity SEOAuditSeverity `gorm:"not null;size:20" json:"severity"`
+	Message  string           `gorm:"size:255" json:"message"`
+	Detail   string           `gorm:"size:500" json:"detail"` // 相关的值（如过长的标题、失效的链接）
+}
+
+// TableName 指定表名
+func (SEOAuditFinding) TableName() string {
+	return "seo_audit_findings"
+}
+
 // SEOEvent SEO事件追踪模型
 type SEOEvent struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
@@ -242,3 +317,99 @@ type SEOEvent struct {
 func (SEOEvent) TableName() string {
 	return "seo_events"
 }
+
+// SEO事件类型
+const (
+	SEOEventTypeCrawl      = "crawl"       // 抓取
+	SEOEventTypeIndex      = "index"       // 收录
+	SEOEventTypeRankChange = "rank_change" // 排名变化
+)
+
+// SEORedirect URL跳转规则（分类改名或移动时自动生成，也可由管理员维护）
+type SEORedirect struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 跳转规则
+	FromPath   string `gorm:"not null;size:500;uniqueIndex" json:"from_path"` // 旧地址（站内路径）
+	ToPath     string `gorm:"not null;size:500;index" json:"to_path"`         // 新地址（站内路径或完整URL）
+	StatusCode int    `gorm:"default:301" json:"status_code"`                 // 跳转状态码 (301, 302, 307, 308)
+
+	// 关联实体（自动生成的规则）
+	TargetType SEOConfigType `gorm:"size:20;index:idx_seo_redirect_target,priority:1" json:"target_type"` // 实体类型 (category, resource)，手动规则为空
+	TargetID   *uint         `gorm:"index:idx_seo_redirect_target,priority:2" json:"target_id"`           // 实体ID
+
+	// 统计
+	Hits      int64      `gorm:"default:0" json:"hits"` // 命中次数
+	LastHitAt *time.Time `json:"last_hit_at"`           // 最近命中时间
+	Note      string     `gorm:"size:255" json:"note"`  // 备注
+}
+
+// TableName 指定表名
+func (SEORedirect) TableName() string {
+	return "seo_redirects"
+}
+
+// SEOIndexStatus URL推送状态
+type SEOIndexStatus string
+
+const (
+	SEOIndexStatusPending   SEOIndexStatus = "pending"   // 等待推送（包括等待重试）
+	SEOIndexStatusSubmitted SEOIndexStatus = "submitted" // 已推送
+	SEOIndexStatusFailed    SEOIndexStatus = "failed"    // 推送失败（不再重试）
+)
+
+// SEOIndexSubmission 搜索引擎URL推送队列
+type SEOIndexSubmission struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 推送内容
+	SearchEngine string `gorm:"not null;size:20;index:idx_seo_index_queue,priority:1" json:"search_engine"` // 推送目标 (indexnow, baidu)
+	Action       string `gorm:"not null;size:10" json:"action"`                                             // URL变化类型 (update, delete)
+	URL          string `gorm:"not null;size:500;index" json:"url"`                                         // 完整URL
+
+	// 推送状态
+	Status        SEOIndexStatus `gorm:"not null;size:20;default:'pending';index:idx_seo_index_queue,priority:2" json:"status"`
+	Attempts      int            `gorm:"default:0" json:"attempts"`                                   // 已尝试次数
+	NextAttemptAt time.Time      `gorm:"index:idx_seo_index_queue,priority:3" json:"next_attempt_at"` // 下次推送时间
+	SubmittedAt   *time.Time     `gorm:"index" json:"submitted_at"`                                   // 推送成功时间
+	LastError     string         `gorm:"size:500" json:"last_error"`                                  // 最近一次失败原因
+}
+
+// TableName 指定表名
+func (SEOIndexSubmission) TableName() string {
+	return "seo_index_submissions"
+}
+
+// SEORankImport 关键词排名导入记录
+type SEORankImport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 导入信息
+	SearchEngine string    `gorm:"not null;size:20;index" json:"search_engine"` // 数据来源 (google, bing, baidu)
+	Format       string    `gorm:"size:10" json:"format"`                       // 文件格式 (csv, json)
+	FileName     string    `gorm:"size:255" json:"file_name"`                   // 文件名
+	RecordedOn   time.Time `json:"recorded_on"`                                 // 默认数据日期（行内日期优先）
+
+	// 导入结果
+	TotalRows       int    `gorm:"default:0" json:"total_rows"`       // 数据行数
+	ImportedRows    int    `gorm:"default:0" json:"imported_rows"`    // 写入的排名记录数
+	SkippedRows     int    `gorm:"default:0" json:"skipped_rows"`     // 跳过的行数
+	KeywordsCreated int    `gorm:"default:0" json:"keywords_created"` // 新建的关键词数
+	KeywordsUpdated int    `gorm:"default:0" json:"keywords_updated"` // 更新的关键词数
+	RankChanges     int    `gorm:"default:0" json:"rank_changes"`     // 排名变化事件数
+	RankDrops       int    `gorm:"default:0" json:"rank_drops"`       // 排名大幅下降数
+	Errors          string `gorm:"type:text" json:"errors"`           // 跳过原因（每行一条，最多保留前若干条）
+
+	UserID *uint `gorm:"index" json:"user_id"`       // 操作人ID
+	User   *User `gorm:"foreignKey:UserID" json:"-"` // 操作人
+}
+
+// TableName 指定表名
+func (SEORankImport) TableName() string {
+	return "seo_rank_imports"
+}
@@ -0,0 +1,66 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// Tag 标签模型（资源和文章共用，同义词通过 CanonicalID 指向主标签）
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name        string `gorm:"not null;size:50" json:"name"`
+	NameKey     string `gorm:"uniqueIndex;size:100" json:"-"`             // 规范化后的标签名（全角转半角、小写，保留汉字等文字和 + # .），用于识别同一标签
+	Slug        string `gorm:"uniqueIndex;not null;size:100" json:"slug"` // 标签页地址（汉字转拼音，重名时追加 -2、-3 …）
+	Description string `gorm:"size:500" json:"description"`
+
+	// 同义词指向的主标签（为空时本身即为主标签），同义词不直接关联资源和文章
+	CanonicalID *uint `gorm:"index" json:"canonical_id,omitempty"`
+	Canonical   *Tag  `gorm:"foreignKey:CanonicalID" json:"canonical,omitempty"`
+
+	// 使用次数（由标签服务维护）
+	ResourceCount int `gorm:"default:0;index" json:"resource_count"`
+	ArticleCount  int `gorm:"default:0;index" json:"article_count"`
+}
+
+// TableName 指定表名
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// IsSynonym 检查是否为其他标签的同义词
+func (t *Tag) IsSynonym() bool {
+	return t.CanonicalID != nil
+}
+
+// ResourceTag 资源与标签的关联
+type ResourceTag struct {
+	ResourceID uint      `gorm:"primaryKey" json:"resource_id"`
+	TagID      uint      `gorm:"primaryKey;index" json:"tag_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (ResourceTag) TableName() string {
+	return "resource_tags"
+}
+
+// ArticleTag 文章与标签的关联
+type ArticleTag struct {
+	ArticleID uint      `gorm:"primaryKey" json:"article_id"`
+	TagID     uint      `gorm:"primaryKey;index" json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (ArticleTag) TableName() string {
+	return "article_tags"
+}
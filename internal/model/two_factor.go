@@ -0,0 +1,52 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// UserTwoFactor 用户两步验证（TOTP）配置模型
+type UserTwoFactor struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint  `gorm:"uniqueIndex;not null" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+
+	// TOTP密钥（Base32编码）
+	Secret string `gorm:"not null;size:64" json:"-"`
+
+	// 启用状态（完成首次验证后启用）
+	Enabled   bool       `gorm:"not null" json:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at"`
+
+	// 最后一次使用的时间步，防止验证码重放
+	LastUsedStep int64 `gorm:"not null;default:0" json:"-"`
+}
+
+// TableName 指定表名
+func (UserTwoFactor) TableName() string {
+	return "user_two_factors"
+}
+
+// RecoveryCode 两步验证恢复码模型（仅保存哈希，每个恢复码只能使用一次）
+type RecoveryCode struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash string     `gorm:"not null;size:64" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TableName 指定表名
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
@@ -26,6 +26,16 @@ type User struct {
 	Email        string `gorm:"uniqueIndex;not null;size:100" json:"email" binding:"required,email"`
 	PasswordHash string `gorm:"not null;size:255" json:"-"`
 
+	// 邮箱验证
+	EmailVerified   bool       `gorm:"default:false" json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// 两步验证
+	TwoFactorEnabled bool `gorm:"default:false" json:"two_factor_enabled"`
+
+	// 令牌版本，修改或重置密码时递增，使此前签发的JWT失效
+	TokenVersion uint `gorm:"default:0;not null" json:"-"`
+
 	// 用户状态
 	Role   string `gorm:"default:'user';not null;size:20" json:"role"`     // user, admin
 	Status string `gorm:"default:'active';not null;size:20" json:"status"` // active, banned
@@ -0,0 +1,70 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// UserIdentity 第三方身份模型（一个用户可以关联多个第三方身份）
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 所属用户
+	UserID uint  `gorm:"not null;index" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+
+	// 身份信息（同一提供方的subject唯一）
+	Provider string `gorm:"not null;size:50;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject  string `gorm:"not null;size:255;uniqueIndex:idx_identity_provider_subject" json:"-"`
+
+	// 第三方资料（每次登录时更新）
+	Email         string     `gorm:"size:100" json:"email"`
+	EmailVerified bool       `gorm:"not null" json:"email_verified"`
+	Name          string     `gorm:"size:100" json:"name"`
+	AvatarURL     string     `gorm:"size:500" json:"avatar_url"`
+	LastLoginAt   *time.Time `json:"last_login_at"`
+}
+
+// TableName 指定表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OAuthState 第三方登录授权请求（保存state、PKCE验证码和nonce，一次性使用）
+type OAuthState struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 授权请求
+	Provider     string `gorm:"not null;size:50" json:"provider"`
+	StateHash    string `gorm:"uniqueIndex;not null;size:64" json:"-"` // SHA256(state)
+	CodeVerifier string `gorm:"not null;size:128" json:"-"`            // PKCE验证码
+	Nonce        string `gorm:"size:64" json:"-"`                      // OIDC nonce
+	RedirectURI  string `gorm:"not null;size:500" json:"redirect_uri"`
+
+	// 登录上下文
+	InviteCode string `gorm:"size:36" json:"invite_code"` // 首次注册时使用的邀请码
+	LinkUserID *uint  `gorm:"index" json:"link_user_id"`  // 不为空时表示为该用户关联身份
+
+	// 有效期与使用状态
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// TableName 指定表名
+func (OAuthState) TableName() string {
+	return "oauth_states"
+}
+
+// IsExpired 检查授权请求是否过期
+func (s *OAuthState) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
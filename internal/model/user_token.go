@@ -0,0 +1,56 @@
+/*
+Package model defines all data models for the resource share site.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// TokenPurpose 账户令牌用途枚举
+type TokenPurpose string
+
+const (
+	TokenPurposeVerifyEmail   TokenPurpose = "verify_email"   // 邮箱验证
+	TokenPurposeResetPassword TokenPurpose = "reset_password" // 密码重置
+	TokenPurposeChangeEmail   TokenPurpose = "change_email"   // 修改邮箱
+	TokenPurposeTwoFactor     TokenPurpose = "two_factor"     // 两步验证登录
+)
+
+// UserToken 账户令牌模型（一次性使用，仅保存令牌哈希）
+type UserToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 所属用户
+	UserID uint  `gorm:"not null;index:idx_user_token_purpose" json:"user_id"`
+	User   *User `gorm:"foreignKey:UserID" json:"-"`
+
+	// 令牌信息
+	Purpose   TokenPurpose `gorm:"not null;size:30;index:idx_user_token_purpose" json:"purpose"`
+	TokenHash string       `gorm:"uniqueIndex;not null;size:64" json:"-"` // SHA256(令牌)
+	NewEmail  string       `gorm:"size:100" json:"new_email"`             // 修改邮箱时的新邮箱
+
+	// 有效期与使用状态
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// TableName 指定表名
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+// IsExpired 检查令牌是否过期
+func (t *UserToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+// IsUsed 检查令牌是否已使用
+func (t *UserToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
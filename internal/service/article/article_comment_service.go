@@ -12,19 +12,29 @@ import (
 	"time"
 
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/comment"
 
 	"gorm.io/gorm"
 )
 
 // ArticleCommentService 文章评论服务
 type ArticleCommentService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	moderator *comment.ModerationService
 }
 
 // NewArticleCommentService 创建文章评论服务实例
 func NewArticleCommentService(db *gorm.DB) *ArticleCommentService {
 	return &ArticleCommentService{
-		db: db,
+		db:        db,
+		moderator: comment.NewModerationService(db),
+	}
+}
+
+// SetModerator 设置评论审核服务（与资源评论共用同一审核流程，回复通过审核后通知原评论作者）
+func (s *ArticleCommentService) SetModerator(moderator *comment.ModerationService) {
+	if moderator != nil {
+		s.moderator = moderator
 	}
 }
 
@@ -45,6 +55,7 @@ type CommentListItem struct {
 	ID          uint      `json:"id"`
 	Content     string    `json:"content"`
 	LikeCount   uint      `json:"like_count"`
+	Liked       bool      `json:"liked"` // 当前用户是否点赞（由处理器填充）
 	CreatedAt   time.Time `json:"created_at"`
 
 	// 用户信息
@@ -75,20 +86,36 @@ func (s *ArticleCommentService) CreateComment(userID uint, req *CreateCommentReq
 		}
 	}
 
+	// 自动审核（敏感词、可信用户）
+	verdict, err := s.moderator.Screen(userID, req.Content)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建评论
-	comment := &model.ArticleComment{
-		ArticleID: req.ArticleID,
-		UserID:    userID,
-		Content:   req.Content,
-		ParentID:  req.ParentID,
-		Status:    model.CommentStatusPending, // 评论需要审核
+	articleComment := &model.ArticleComment{
+		ArticleID:   req.ArticleID,
+		UserID:      userID,
+		Content:     verdict.Content,
+		ParentID:    req.ParentID,
+		Status:      verdict.Status,
+		ReviewNotes: verdict.Notes,
+	}
+	if verdict.Status == model.CommentStatusApproved {
+		now := time.Now()
+		articleComment.ReviewedAt = &now
 	}
 
-	if err := s.db.Create(comment).Error; err != nil {
+	if err := s.db.Create(articleComment).Error; err != nil {
 		return nil, err
 	}
 
-	return comment, nil
+	// 自动通过的评论立即计入评论数并通知被回复的作者
+	if articleComment.Status == model.CommentStatusApproved {
+		s.moderator.OnApproved(comment.CommentRef{Type: comment.CommentTypeArticle, ID: articleComment.ID})
+	}
+
+	return articleComment, nil
 }
 
 // GetCommentByID 根据ID获取评论
@@ -147,47 +174,34 @@ func (s *ArticleCommentService) DeleteComment(id uint, userID uint) error {
 	return nil
 }
 
-// ApproveComment 审核通过评论
+// ApproveComment 审核通过评论（经评论审核流程，同步维护文章评论数）
 func (s *ArticleCommentService) ApproveComment(id uint, reviewerID uint, reviewNotes string) error {
-	comment, err := s.GetCommentByID(id)
-	if err != nil {
-		return err
-	}
-
-	comment.Status = model.CommentStatusApproved
-	comment.ReviewedByID = &reviewerID
-	now := time.Now()
-	comment.ReviewedAt = &now
-	comment.ReviewNotes = reviewNotes
-
-	if err := s.db.Save(comment).Error; err != nil {
-		return err
-	}
-
-	// 增加文章的评论数
-	return s.db.Model(&model.Article{}).
-		Where("id = ?", comment.ArticleID).
-		UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error
+	ref := comment.CommentRef{Type: comment.CommentTypeArticle, ID: id}
+	return s.moderator.Moderate(ref, reviewerID, model.CommentStatusApproved, reviewNotes)
 }
 
 // RejectComment 审核拒绝评论
 func (s *ArticleCommentService) RejectComment(id uint, reviewerID uint, reviewNotes string) error {
-	comment, err := s.GetCommentByID(id)
-	if err != nil {
-		return err
-	}
-
-	comment.Status = model.CommentStatusRejected
-	comment.ReviewedByID = &reviewerID
-	now := time.Now()
-	comment.ReviewedAt = &now
-	comment.ReviewNotes = reviewNotes
+	ref := comment.CommentRef{Type: comment.CommentTypeArticle, ID: id}
+	return s.moderator.Moderate(ref, reviewerID, model.CommentStatusRejected, reviewNotes)
+}
 
-	if err := s.db.Save(comment).Error; err != nil {
-		return err
+// GetCommentTree 获取文章下已通过审核的评论树（按顶层评论分页）
+func (s *ArticleCommentService) GetCommentTree(articleID uint, page, pageSize int) ([]model.ArticleComment, int64, error) {
+	var comments []model.ArticleComment
+	if err := s.db.Preload("User").
+		Where("article_id = ? AND status = ?", articleID, model.CommentStatusApproved).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error; err != nil {
+		return nil, 0, err
 	}
 
-	return nil
+	roots, total := comment.BuildTree(comments, page, pageSize,
+		func(c *model.ArticleComment) uint { return c.ID },
+		func(c *model.ArticleComment) *uint { return c.ParentID },
+		func(c *model.ArticleComment, replies []model.ArticleComment) { c.Replies = replies },
+	)
+	return roots, total, nil
 }
 
 // GetCommentsByArticleID 获取文章评论列表
@@ -277,17 +291,3 @@ func (s *ArticleCommentService) GetPendingComments(page, pageSize int) ([]Commen
 
 	return comments, total, nil
 }
-
-// IncrementLikeCount 增加点赞数
-func (s *ArticleCommentService) IncrementLikeCount(id uint) error {
-	return s.db.Model(&model.ArticleComment{}).
-		Where("id = ?", id).
-		UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error
-}
-
-// DecrementLikeCount 减少点赞数
-func (s *ArticleCommentService) DecrementLikeCount(id uint) error {
-	return s.db.Model(&model.ArticleComment{}).
-		Where("id = ?", id).
-		UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error
-}
@@ -9,26 +9,63 @@ package article
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"strconv"
 	"strings"
 	"time"
 
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/content"
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/seo"
+	"resource-share-site/internal/service/tag"
 
 	"gorm.io/gorm"
 )
 
 // ArticleService 文章服务
 type ArticleService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	sensitive *filter.Dictionary
+	tags      *tag.TagService
+	indexer   seo.URLPublisher
+	notifier  notification.Publisher
+	renderer  *content.Renderer
 }
 
 // NewArticleService 创建文章服务实例
 func NewArticleService(db *gorm.DB) *ArticleService {
 	return &ArticleService{
-		db: db,
+		db:       db,
+		tags:     tag.NewTagService(db),
+		renderer: content.NewRenderer(nil),
 	}
 }
 
+// SetRenderer 设置正文渲染器（与页面共用渲染缓存，文章修改时清除对应缓存）
+func (s *ArticleService) SetRenderer(renderer *content.Renderer) {
+	if renderer != nil {
+		s.renderer = renderer
+	}
+}
+
+// SetFilter 设置敏感词库（创建文章时过滤标题、摘要和正文），为nil时不过滤
+func (s *ArticleService) SetFilter(sensitive *filter.Dictionary) {
+	s.sensitive = sensitive
+}
+
+// SetIndexer 设置URL推送器（文章发布、下线和删除时通知搜索引擎），为nil时不推送
+func (s *ArticleService) SetIndexer(indexer seo.URLPublisher) {
+	s.indexer = indexer
+}
+
+// SetNotifier 设置通知发布器（文章审核结果将通知作者），为nil时不通知
+func (s *ArticleService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
 // CreateArticleRequest 创建文章请求
 type CreateArticleRequest struct {
 	Title          string `json:"title" binding:"required,min=1,max=200"`
@@ -51,10 +88,10 @@ type UpdateArticleRequest struct {
 	FeaturedImage  string `json:"featured_image"`
 	Tags           string `json:"tags"`
 	Category       string `json:"category" binding:"required"`
-	Status         model.ArticleStatus `json:"status"`
 	MetaTitle      string `json:"meta_title"`
 	MetaDescription string `json:"meta_description"`
 	MetaKeywords   string `json:"meta_keywords"`
+	Note           string `json:"note" binding:"max=500"` // 修改说明（记录在修订历史中）
 }
 
 // ArticleListItem 文章列表项
@@ -63,6 +100,7 @@ type ArticleListItem struct {
 	Title       string    `json:"title"`
 	Slug        string    `json:"slug"`
 	Excerpt     string    `json:"excerpt"`
+	ReadingTime int       `json:"reading_time"`
 	FeaturedImage string  `json:"featured_image"`
 	Tags        string    `json:"tags"`
 	Category    string    `json:"category"`
@@ -70,6 +108,7 @@ type ArticleListItem struct {
 	PublishedAt *time.Time `json:"published_at"`
 	ViewCount   uint      `json:"view_count"`
 	LikeCount   uint      `json:"like_count"`
+	Liked       bool      `json:"liked"` // 当前用户是否点赞（由处理器填充）
 	CommentCount uint     `json:"comment_count"`
 	CreatedAt   time.Time `json:"created_at"`
 
@@ -78,8 +117,34 @@ type ArticleListItem struct {
 	AuthorName string `json:"author_name"`
 }
 
-// CreateArticle 创建文章
+// CreateArticle 创建文章（作者只能创建草稿或直接提交审核，审核员可直接发布）
 func (s *ArticleService) CreateArticle(authorID uint, req *CreateArticleRequest) (*model.Article, error) {
+	switch req.Status {
+	case "":
+		req.Status = model.ArticleStatusDraft
+	case model.ArticleStatusDraft, model.ArticleStatusPending:
+	case model.ArticleStatusPublished:
+		if err := s.requireReviewer(authorID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrInvalidArticleTransition
+	}
+
+	// 敏感词过滤（违禁词拒绝，需审核的文章不直接发布，低级别敏感词替换为*）
+	screened := s.sensitive.Sanitize(&req.Title, &req.Excerpt, &req.Content, &req.MetaTitle, &req.MetaDescription)
+	if err := screened.Err(); err != nil {
+		return nil, err
+	}
+	if screened.NeedsReview() && req.Status == model.ArticleStatusPublished {
+		req.Status = model.ArticleStatusPending
+	}
+
+	tagNames := tag.ParseTags(req.Tags)
+	if len(tagNames) > tag.MaxTagsPerItem {
+		return nil, tag.ErrTooManyTags
+	}
+
 	// 生成slug
 	slug := s.generateSlug(req.Title)
 
@@ -95,7 +160,6 @@ func (s *ArticleService) CreateArticle(authorID uint, req *CreateArticleRequest)
 		Content:        req.Content,
 		Excerpt:        req.Excerpt,
 		FeaturedImage:  req.FeaturedImage,
-		Tags:           req.Tags,
 		Category:       req.Category,
 		Status:         req.Status,
 		AuthorID:       authorID,
@@ -104,16 +168,34 @@ func (s *ArticleService) CreateArticle(authorID uint, req *CreateArticleRequest)
 		MetaKeywords:   req.MetaKeywords,
 	}
 
+	if err := s.deriveContentMeta(article, ""); err != nil {
+		return nil, err
+	}
+
 	// 如果状态是已发布，设置发布时间
+	now := time.Now()
 	if req.Status == model.ArticleStatusPublished {
-		now := time.Now()
 		article.PublishedAt = &now
+	} else if req.Status == model.ArticleStatusPending {
+		article.SubmittedAt = &now
 	}
 
 	if err := s.db.Create(article).Error; err != nil {
 		return nil, err
 	}
 
+	if err := s.setTags(article, tagNames); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.recordRevision(article, authorID, "创建文章", nil); err != nil {
+		return nil, err
+	}
+
+	if article.Status == model.ArticleStatusPublished {
+		s.publishURL(seo.IndexActionUpdate, article)
+	}
+
 	return article, nil
 }
 
@@ -135,80 +217,161 @@ func (s *ArticleService) GetArticleBySlug(slug string) (*model.Article, error) {
 	return &article, nil
 }
 
-// UpdateArticle 更新文章
-func (s *ArticleService) UpdateArticle(id uint, req *UpdateArticleRequest) (*model.Article, error) {
-	article, err := s.GetArticleByID(id)
+// UpdateArticle 保存文章内容并记录修订（状态不变，状态变化通过编辑流程操作完成）
+// 作者可修改草稿和待审核的文章，审核员可修改任意文章
+func (s *ArticleService) UpdateArticle(id, editorID uint, req *UpdateArticleRequest) (*model.Article, *model.ArticleRevision, error) {
+	article, err := s.getEditableArticle(id, editorID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	screened := s.sensitive.Sanitize(&req.Title, &req.Excerpt, &req.Content, &req.MetaTitle, &req.MetaDescription)
+	if err := screened.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	tagNames := tag.ParseTags(req.Tags)
+	if len(tagNames) > tag.MaxTagsPerItem {
+		return nil, nil, tag.ErrTooManyTags
+	}
+
+	// 功能上线前创建的文章先以修改前的内容作为第一个版本
+	if err := s.ensureBaseline(article); err != nil {
+		return nil, nil, err
 	}
 
 	// 更新字段
+	previousContent := article.Content
 	article.Title = req.Title
 	article.Content = req.Content
 	article.Excerpt = req.Excerpt
 	article.FeaturedImage = req.FeaturedImage
-	article.Tags = req.Tags
 	article.Category = req.Category
-	article.Status = req.Status
 	article.MetaTitle = req.MetaTitle
 	article.MetaDescription = req.MetaDescription
 	article.MetaKeywords = req.MetaKeywords
 
-	// 如果状态从未发布变为已发布，设置发布时间
-	if req.Status == model.ArticleStatusPublished && article.PublishedAt == nil {
-		now := time.Now()
-		article.PublishedAt = &now
+	if err := s.deriveContentMeta(article, previousContent); err != nil {
+		return nil, nil, err
 	}
 
-	if err := s.db.Save(article).Error; err != nil {
-		return nil, err
+	if err := s.db.Omit("Author", "ReviewedBy").Save(article).Error; err != nil {
+		return nil, nil, err
 	}
+	s.renderer.Invalidate(content.KindArticle, article.ID)
 
-	return article, nil
-}
+	if err := s.setTags(article, tagNames); err != nil {
+		return nil, nil, err
+	}
 
-// DeleteArticle 删除文章（软删除）
-func (s *ArticleService) DeleteArticle(id uint) error {
-	if err := s.db.Delete(&model.Article{}, id).Error; err != nil {
-		return err
+	revision, err := s.recordRevision(article, editorID, req.Note, nil)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
+
+	// 已发布的文章内容变化时通知搜索引擎
+	if article.Status == model.ArticleStatusPublished {
+		s.publishURL(seo.IndexActionUpdate, article)
+	}
+
+	return article, revision, nil
 }
 
-// PublishArticle 发布文章
-func (s *ArticleService) PublishArticle(id uint) (*model.Article, error) {
-	article, err := s.GetArticleByID(id)
+// deriveContentMeta 根据正文计算阅读时间，未填写摘要或摘要是根据修改前正文自动生成的时重新生成摘要
+func (s *ArticleService) deriveContentMeta(article *model.Article, previousContent string) error {
+	rendered, err := s.renderer.RenderSource(content.KindArticle, article.Content)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	article.ReadingTime = rendered.ReadingTime
 
-	article.Status = model.ArticleStatusPublished
-	if article.PublishedAt == nil {
-		now := time.Now()
-		article.PublishedAt = &now
+	excerpt := strings.TrimSpace(article.Excerpt)
+	if excerpt != "" && previousContent != "" && previousContent != article.Content {
+		if previous, err := s.renderer.RenderSource(content.KindArticle, previousContent); err == nil && previous.Excerpt == excerpt {
+			excerpt = ""
+		}
 	}
-
-	if err := s.db.Save(article).Error; err != nil {
-		return nil, err
+	if excerpt == "" {
+		excerpt = rendered.Excerpt
 	}
+	article.Excerpt = excerpt
+	return nil
+}
 
-	return article, nil
+// BackfillContentMeta 为历史文章计算阅读时间并生成缺失的摘要（已计算的文章会跳过，可重复执行）
+// 返回：
+//   - 更新的文章数
+//   - 错误信息
+func (s *ArticleService) BackfillContentMeta() (int, error) {
+	const batchSize = 200
+
+	updated := 0
+	var lastID uint
+	for {
+		var articles []model.Article
+		if err := s.db.Select("id", "content", "excerpt").
+			Where("reading_time = 0 AND id > ?", lastID).
+			Order("id ASC").Limit(batchSize).Find(&articles).Error; err != nil {
+			return updated, fmt.Errorf("查询文章失败: %w", err)
+		}
+		if len(articles) == 0 {
+			return updated, nil
+		}
+
+		for i := range articles {
+			article := &articles[i]
+			lastID = article.ID
+			if err := s.deriveContentMeta(article, ""); err != nil {
+				return updated, err
+			}
+			if article.ReadingTime == 0 {
+				continue
+			}
+			if err := s.db.Model(&model.Article{}).Where("id = ?", article.ID).UpdateColumns(map[string]interface{}{
+				"reading_time": article.ReadingTime,
+				"excerpt":      article.Excerpt,
+			}).Error; err != nil {
+				return updated, fmt.Errorf("更新文章阅读时间失败: %w", err)
+			}
+			updated++
+		}
+	}
 }
 
-// UnpublishArticle 取消发布文章
-func (s *ArticleService) UnpublishArticle(id uint) (*model.Article, error) {
-	article, err := s.GetArticleByID(id)
+// setTags 更新文章的标签关联并同步标签字段
+func (s *ArticleService) setTags(article *model.Article, tagNames []string) error {
+	_, formatted, err := s.tags.SetArticleTags(article.ID, tagNames)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	article.Tags = formatted
+	return nil
+}
 
-	article.Status = model.ArticleStatusDraft
+// DeleteArticle 删除文章（软删除）
+func (s *ArticleService) DeleteArticle(id uint) error {
+	var article model.Article
+	found := s.db.Select("id", "slug", "status").First(&article, id).Error == nil
 
-	if err := s.db.Save(article).Error; err != nil {
-		return nil, err
+	if err := s.db.Delete(&model.Article{}, id).Error; err != nil {
+		return err
 	}
+	s.renderer.Invalidate(content.KindArticle, id)
 
-	return article, nil
+	if found && article.Status == model.ArticleStatusPublished {
+		s.publishURL(seo.IndexActionDelete, &article)
+	}
+	return nil
+}
+
+// publishURL 将文章详情页加入搜索引擎推送队列（推送失败不影响文章操作）
+func (s *ArticleService) publishURL(action string, article *model.Article) {
+	if s.indexer == nil {
+		return
+	}
+	if err := s.indexer.PublishURLs(action, seo.ArticlePath(article.Slug)); err != nil {
+		log.Printf("文章 %d 加入URL推送队列失败: %v", article.ID, err)
+	}
 }
 
 // IncrementViewCount 增加浏览数
@@ -216,18 +379,8 @@ func (s *ArticleService) IncrementViewCount(id uint) error {
 	return s.db.Model(&model.Article{}).Where("id = ?", id).UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
 }
 
-// IncrementLikeCount 增加点赞数
-func (s *ArticleService) IncrementLikeCount(id uint) error {
-	return s.db.Model(&model.Article{}).Where("id = ?", id).UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error
-}
-
-// DecrementLikeCount 减少点赞数
-func (s *ArticleService) DecrementLikeCount(id uint) error {
-	return s.db.Model(&model.Article{}).Where("id = ?", id).UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error
-}
-
-// GetArticles 获取文章列表
-func (s *ArticleService) GetArticles(page, pageSize int, status *model.ArticleStatus, category, keyword string) ([]ArticleListItem, int64, error) {
+// GetArticles 获取文章列表（tags 为nil时不按标签筛选）
+func (s *ArticleService) GetArticles(page, pageSize int, status *model.ArticleStatus, category, keyword string, tags *tag.Filter) ([]ArticleListItem, int64, error) {
 	var articles []ArticleListItem
 	var total int64
 
@@ -258,6 +411,12 @@ func (s *ArticleService) GetArticles(page, pageSize int, status *model.ArticleSt
 			"%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
 	}
 
+	// 标签过滤
+	query, err := s.tags.FilterArticles(query, "articles.id", tags)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -313,80 +472,27 @@ func (s *ArticleService) GetCategories() ([]string, error) {
 	return categories, nil
 }
 
-// GetPopularTags 获取热门标签
+// GetPopularTags 获取热门标签（按文章使用次数）
 func (s *ArticleService) GetPopularTags(limit int) ([]string, error) {
-	var tags []string
-
-	// 获取所有已发布文章的标签
-	var articles []model.Article
-	if err := s.db.Where("status = ? AND tags IS NOT NULL", model.ArticleStatusPublished).
-		Find(&articles).Error; err != nil {
+	popular, err := s.tags.PopularTags("article", limit)
+	if err != nil {
 		return nil, err
 	}
 
-	// 统计标签频率
-	tagCount := make(map[string]int)
-	for _, article := range articles {
-		if article.Tags != "" {
-			// 解析标签（逗号分隔）
-			tagList := strings.Split(article.Tags, ",")
-			for _, tag := range tagList {
-				tag = strings.TrimSpace(tag)
-				if tag != "" {
-					tagCount[tag]++
-				}
-			}
-		}
-	}
-
-	// 排序并返回热门标签
-	type tagFreq struct {
-		Tag  string
-		Count int
-	}
-	var tagFreqs []tagFreq
-	for tag, count := range tagCount {
-		tagFreqs = append(tagFreqs, tagFreq{Tag: tag, Count: count})
+	tags := make([]string, 0, len(popular))
+	for _, t := range popular {
+		tags = append(tags, t.Name)
 	}
-
-	// 排序
-	for i := 0; i < len(tagFreqs); i++ {
-		for j := i + 1; j < len(tagFreqs); j++ {
-			if tagFreqs[i].Count < tagFreqs[j].Count {
-				tagFreqs[i], tagFreqs[j] = tagFreqs[j], tagFreqs[i]
-			}
-		}
-	}
-
-	// 返回指定数量的标签
-	for i := 0; i < len(tagFreqs) && i < limit; i++ {
-		tags = append(tags, tagFreqs[i].Tag)
-	}
-
 	return tags, nil
 }
 
-// generateSlug 生成URL友好的slug
+// generateSlug 生成URL友好的slug（汉字转拼音；标题无法转换时按创建时间生成）
 func (s *ArticleService) generateSlug(title string) string {
-	// 将中文标题转换为拼音（简单实现：使用标题的拼音首字母或英文部分）
-	slug := strings.ToLower(title)
-
-	// 替换特殊字符
-	slug = strings.ReplaceAll(slug, " ", "-")
-	slug = strings.ReplaceAll(slug, "_", "-")
-
-	// 移除特殊字符
-	allowed := "abcdefghijklmnopqrstuvwxyz0123456789-"
-	var result strings.Builder
-	for _, char := range slug {
-		if strings.ContainsRune(allowed, char) {
-			result.WriteRune(char)
-		} else {
-			result.WriteRune('-')
-		}
+	slug := seo.Slugify(title, seo.ArticleSlugMaxLength)
+	if slug == "" {
+		slug = "article-" + strconv.FormatInt(time.Now().UnixNano(), 36)
 	}
-
-	return result.String()
+	return slug
 }
 
 // checkSlugExists 检查slug是否已存在
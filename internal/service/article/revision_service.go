@@ -0,0 +1,454 @@
+/*
+Package article provides article and blog services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package article
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/content"
+	"resource-share-site/internal/service/seo"
+	"resource-share-site/internal/service/tag"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义文章修订相关错误
+var (
+	ErrArticleRevisionNotFound = errors.New("文章修订版本不存在")
+	ErrArticleUnchanged        = errors.New("文章内容没有变化")
+)
+
+// FieldChange 文章版本之间的字段差异
+type FieldChange struct {
+	Field   string   `json:"field"`
+	Label   string   `json:"label"`
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Added   []string `json:"added,omitempty"`   // 新增的标签
+	Removed []string `json:"removed,omitempty"` // 移除的标签
+}
+
+// AutosaveRequest 自动保存请求（编辑中的内容可以不完整）
+type AutosaveRequest struct {
+	Title           string `json:"title" binding:"max=200"`
+	Content         string `json:"content"`
+	Excerpt         string `json:"excerpt" binding:"max=500"`
+	FeaturedImage   string `json:"featured_image"`
+	Tags            string `json:"tags"`
+	Category        string `json:"category"`
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
+	MetaKeywords    string `json:"meta_keywords"`
+	Note            string `json:"note" binding:"max=500"`
+}
+
+// RevisionDetail 修订详情（包含相对上一版本的字段差异）
+type RevisionDetail struct {
+	Revision *model.ArticleRevision `json:"revision"`
+	Changes  []FieldChange          `json:"changes"`
+}
+
+// ListRevisions 获取文章的修订历史（作者、管理员和版主可查看）
+// 参数：
+//   - articleID: 文章ID
+//   - viewerID: 查看者ID
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 修订列表（版本号降序）
+//   - 总数
+//   - 错误信息
+func (s *ArticleService) ListRevisions(articleID, viewerID uint, page, pageSize int) ([]model.ArticleRevision, int64, error) {
+	article, err := s.getViewableArticle(articleID, viewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.ensureBaseline(article); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&model.ArticleRevision{}).Where("article_id = ?", articleID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计修订数量失败: %w", err)
+	}
+
+	var revisions []model.ArticleRevision
+	if err := query.Preload("Editor").
+		Order("version DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&revisions).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询修订历史失败: %w", err)
+	}
+
+	return revisions, total, nil
+}
+
+// GetRevision 获取修订详情及其相对上一版本的字段差异
+// 参数：
+//   - articleID: 文章ID
+//   - version: 版本号
+//   - viewerID: 查看者ID
+//
+// 返回：
+//   - 修订详情
+//   - 错误信息
+func (s *ArticleService) GetRevision(articleID uint, version int, viewerID uint) (*RevisionDetail, error) {
+	if _, err := s.getViewableArticle(articleID, viewerID); err != nil {
+		return nil, err
+	}
+
+	revision, err := s.getRevision(articleID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &RevisionDetail{Revision: revision, Changes: []FieldChange{}}
+	var previous model.ArticleRevision
+	err = s.db.Where("article_id = ? AND version < ?", articleID, version).
+		Order("version DESC").
+		First(&previous).Error
+	switch {
+	case err == nil:
+		detail.Changes = diffArticleRevisions(&previous, revision)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("查询上一版本失败: %w", err)
+	}
+
+	return detail, nil
+}
+
+// CompareRevisions 比较文章的两个版本（toVersion 为0时与文章当前内容比较）
+// 参数：
+//   - articleID: 文章ID
+//   - fromVersion: 旧版本号
+//   - toVersion: 新版本号，0表示当前内容
+//   - viewerID: 查看者ID
+//
+// 返回：
+//   - 字段差异列表
+//   - 错误信息
+func (s *ArticleService) CompareRevisions(articleID uint, fromVersion, toVersion int, viewerID uint) ([]FieldChange, error) {
+	article, err := s.getViewableArticle(articleID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := s.getRevision(articleID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to := articleSnapshot(article)
+	if toVersion > 0 {
+		if to, err = s.getRevision(articleID, toVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	return diffArticleRevisions(from, to), nil
+}
+
+// Autosave 自动保存编辑中的内容（只记录修订，不修改文章；同一编辑者连续的自动保存合并为一条）
+// 参数：
+//   - articleID: 文章ID
+//   - editorID: 编辑者ID
+//   - req: 编辑中的文章内容
+//
+// 返回：
+//   - 自动保存的修订
+//   - 错误信息
+func (s *ArticleService) Autosave(articleID, editorID uint, req *AutosaveRequest) (*model.ArticleRevision, error) {
+	article, err := s.getEditableArticle(articleID, editorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureBaseline(article); err != nil {
+		return nil, err
+	}
+
+	draft := &model.ArticleRevision{
+		ArticleID:       article.ID,
+		Title:           req.Title,
+		Content:         req.Content,
+		Excerpt:         req.Excerpt,
+		FeaturedImage:   req.FeaturedImage,
+		Category:        req.Category,
+		Tags:            strings.Join(tag.ParseTags(req.Tags), ","),
+		MetaTitle:       req.MetaTitle,
+		MetaDescription: req.MetaDescription,
+		MetaKeywords:    req.MetaKeywords,
+		EditorID:        editorID,
+		Autosave:        true,
+		Note:            req.Note,
+	}
+
+	latest, err := s.latestRevision(article.ID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && sameArticleContent(latest, draft) {
+		return latest, nil
+	}
+
+	// 覆盖同一编辑者上一次的自动保存
+	if latest != nil && latest.Autosave && latest.EditorID == editorID {
+		draft.ID = latest.ID
+		draft.CreatedAt = latest.CreatedAt
+		draft.Version = latest.Version
+		if err := s.db.Save(draft).Error; err != nil {
+			return nil, fmt.Errorf("保存自动保存修订失败: %w", err)
+		}
+		return draft, nil
+	}
+
+	draft.Version = 1
+	if latest != nil {
+		draft.Version = latest.Version + 1
+	}
+	if err := s.db.Create(draft).Error; err != nil {
+		return nil, fmt.Errorf("创建自动保存修订失败: %w", err)
+	}
+	return draft, nil
+}
+
+// RestoreRevision 将文章内容恢复到指定版本（恢复本身记录为新版本，状态不变）
+// 参数：
+//   - articleID: 文章ID
+//   - version: 要恢复的版本号
+//   - editorID: 编辑者ID
+//   - note: 恢复说明
+//
+// 返回：
+//   - 恢复后的文章
+//   - 新的修订记录
+//   - 错误信息
+func (s *ArticleService) RestoreRevision(articleID uint, version int, editorID uint, note string) (*model.Article, *model.ArticleRevision, error) {
+	article, err := s.getEditableArticle(articleID, editorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.ensureBaseline(article); err != nil {
+		return nil, nil, err
+	}
+
+	target, err := s.getRevision(articleID, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sameArticleContent(articleSnapshot(article), target) {
+		return nil, nil, ErrArticleUnchanged
+	}
+
+	previousContent := article.Content
+	article.Title = target.Title
+	article.Content = target.Content
+	article.Excerpt = target.Excerpt
+	article.FeaturedImage = target.FeaturedImage
+	article.Category = target.Category
+	article.MetaTitle = target.MetaTitle
+	article.MetaDescription = target.MetaDescription
+	article.MetaKeywords = target.MetaKeywords
+
+	if err := s.deriveContentMeta(article, previousContent); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.db.Omit("Author", "ReviewedBy").Save(article).Error; err != nil {
+		return nil, nil, fmt.Errorf("恢复文章内容失败: %w", err)
+	}
+	s.renderer.Invalidate(content.KindArticle, article.ID)
+	if err := s.setTags(article, tag.ParseTags(target.Tags)); err != nil {
+		return nil, nil, err
+	}
+
+	if note == "" {
+		note = fmt.Sprintf("恢复到版本 %d", version)
+	}
+	revision, err := s.recordRevision(article, editorID, note, &version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if article.Status == model.ArticleStatusPublished {
+		s.publishURL(seo.IndexActionUpdate, article)
+	}
+
+	return article, revision, nil
+}
+
+// ensureBaseline 文章还没有修订记录时，以当前内容创建第一个版本
+func (s *ArticleService) ensureBaseline(article *model.Article) error {
+	var count int64
+	if err := s.db.Model(&model.ArticleRevision{}).Where("article_id = ?", article.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("统计修订数量失败: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	baseline := articleSnapshot(article)
+	baseline.Version = 1
+	baseline.EditorID = article.AuthorID
+	baseline.Note = "初始版本"
+	if err := s.db.Create(baseline).Error; err != nil {
+		return fmt.Errorf("创建初始版本失败: %w", err)
+	}
+	return nil
+}
+
+// recordRevision 以文章当前内容记录一个新版本
+// 内容与最新修订相同时不新建版本（最新修订为自动保存时转为正式版本）
+func (s *ArticleService) recordRevision(article *model.Article, editorID uint, note string, restoredFrom *int) (*model.ArticleRevision, error) {
+	revision := articleSnapshot(article)
+	revision.EditorID = editorID
+	revision.Note = note
+	revision.RestoredFrom = restoredFrom
+
+	latest, err := s.latestRevision(article.ID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && restoredFrom == nil && sameArticleContent(latest, revision) {
+		if !latest.Autosave {
+			return latest, nil
+		}
+		latest.Autosave = false
+		latest.EditorID = editorID
+		latest.Note = note
+		if err := s.db.Save(latest).Error; err != nil {
+			return nil, fmt.Errorf("保存修订失败: %w", err)
+		}
+		return latest, nil
+	}
+
+	revision.Version = 1
+	if latest != nil {
+		revision.Version = latest.Version + 1
+	}
+	if err := s.db.Create(revision).Error; err != nil {
+		return nil, fmt.Errorf("创建修订失败: %w", err)
+	}
+	return revision, nil
+}
+
+// latestRevision 获取文章最新的修订（没有修订时返回nil）
+func (s *ArticleService) latestRevision(articleID uint) (*model.ArticleRevision, error) {
+	var revision model.ArticleRevision
+	err := s.db.Where("article_id = ?", articleID).Order("version DESC").First(&revision).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询最新修订失败: %w", err)
+	}
+	return &revision, nil
+}
+
+// getRevision 根据版本号获取修订
+func (s *ArticleService) getRevision(articleID uint, version int) (*model.ArticleRevision, error) {
+	var revision model.ArticleRevision
+	if err := s.db.Preload("Editor").
+		Where("article_id = ? AND version = ?", articleID, version).
+		First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleRevisionNotFound
+		}
+		return nil, fmt.Errorf("查询修订失败: %w", err)
+	}
+	return &revision, nil
+}
+
+// articleSnapshot 根据文章当前内容生成修订快照
+func articleSnapshot(article *model.Article) *model.ArticleRevision {
+	return &model.ArticleRevision{
+		ArticleID:       article.ID,
+		Title:           article.Title,
+		Content:         article.Content,
+		Excerpt:         article.Excerpt,
+		FeaturedImage:   article.FeaturedImage,
+		Category:        article.Category,
+		Tags:            strings.Join(tag.ParseTags(article.Tags), ","),
+		MetaTitle:       article.MetaTitle,
+		MetaDescription: article.MetaDescription,
+		MetaKeywords:    article.MetaKeywords,
+	}
+}
+
+// sameArticleContent 检查两个修订的内容是否相同（标签按规范化名称比较，忽略顺序）
+func sameArticleContent(a, b *model.ArticleRevision) bool {
+	if a.Title != b.Title || a.Content != b.Content || a.Excerpt != b.Excerpt ||
+		a.FeaturedImage != b.FeaturedImage || a.Category != b.Category ||
+		a.MetaTitle != b.MetaTitle || a.MetaDescription != b.MetaDescription || a.MetaKeywords != b.MetaKeywords {
+		return false
+	}
+	added, removed := diffTags(tag.ParseTags(a.Tags), tag.ParseTags(b.Tags))
+	return len(added) == 0 && len(removed) == 0
+}
+
+// diffArticleRevisions 比较两个修订的字段差异
+func diffArticleRevisions(from, to *model.ArticleRevision) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field, label, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Label: label, Old: old, New: new})
+		}
+	}
+
+	add("title", "标题", from.Title, to.Title)
+	add("content", "正文", from.Content, to.Content)
+	add("excerpt", "摘要", from.Excerpt, to.Excerpt)
+	add("featured_image", "封面图", from.FeaturedImage, to.FeaturedImage)
+	add("category", "分类", from.Category, to.Category)
+	add("meta_title", "SEO标题", from.MetaTitle, to.MetaTitle)
+	add("meta_description", "SEO描述", from.MetaDescription, to.MetaDescription)
+	add("meta_keywords", "SEO关键词", from.MetaKeywords, to.MetaKeywords)
+
+	oldTags, newTags := tag.ParseTags(from.Tags), tag.ParseTags(to.Tags)
+	if added, removed := diffTags(oldTags, newTags); len(added) > 0 || len(removed) > 0 {
+		changes = append(changes, FieldChange{
+			Field:   "tags",
+			Label:   "标签",
+			Old:     strings.Join(oldTags, ", "),
+			New:     strings.Join(newTags, ", "),
+			Added:   added,
+			Removed: removed,
+		})
+	}
+
+	return changes
+}
+
+// diffTags 比较两组标签（按规范化标签名，C++ 与 C# 等拼音相同的标签视为不同）
+func diffTags(old, new []string) (added, removed []string) {
+	oldKeys := make(map[string]bool, len(old))
+	for _, name := range old {
+		oldKeys[tag.NormalizeName(name)] = true
+	}
+	newKeys := make(map[string]bool, len(new))
+	for _, name := range new {
+		newKeys[tag.NormalizeName(name)] = true
+		if !oldKeys[tag.NormalizeName(name)] {
+			added = append(added, name)
+		}
+	}
+	for _, name := range old {
+		if !newKeys[tag.NormalizeName(name)] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,511 @@
+/*
+Package article provides article editorial workflow services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package article
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/seo"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义文章编辑流程相关错误
+var (
+	ErrArticleNotFound          = errors.New("文章不存在")
+	ErrArticlePermissionDenied  = errors.New("没有操作该文章的权限")
+	ErrInvalidArticleTransition = errors.New("文章当前状态不允许该操作")
+	ErrSchedulePast             = errors.New("定时发布时间必须晚于当前时间")
+	ErrReviewNotesRequired      = errors.New("退回文章时必须填写审核意见")
+)
+
+// scheduleInterval 定时发布检查间隔
+const scheduleInterval = time.Minute
+
+// transitionColumns 状态流转时更新的字段
+var transitionColumns = []string{
+	"status", "published_at", "submitted_at", "archived_at",
+	"reviewed_by_id", "reviewed_at", "review_notes", "updated_at",
+}
+
+// SubmitForReview 提交文章审核（作者或审核员，草稿 → 待审核）
+func (s *ArticleService) SubmitForReview(id, userID uint) (*model.Article, error) {
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireAuthorOrReviewer(article, userID); err != nil {
+		return nil, err
+	}
+
+	err = s.transition(article, []model.ArticleStatus{model.ArticleStatusDraft}, func(a *model.Article, now time.Time) {
+		a.Status = model.ArticleStatusPending
+		a.SubmittedAt = &now
+	})
+	if err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// ApproveArticle 审核通过文章（审核员，待审核 → 已发布；publishAt 晚于当前时间时 → 定时发布）
+// 参数：
+//   - id: 文章ID
+//   - reviewerID: 审核员ID（管理员或版主）
+//   - notes: 审核意见
+//   - publishAt: 计划发布时间，nil表示立即发布
+//
+// 返回：
+//   - 审核后的文章
+//   - 错误信息
+func (s *ArticleService) ApproveArticle(id, reviewerID uint, notes string, publishAt *time.Time) (*model.Article, error) {
+	if err := s.requireReviewer(reviewerID); err != nil {
+		return nil, err
+	}
+	if publishAt != nil && !publishAt.After(time.Now()) {
+		return nil, ErrSchedulePast
+	}
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transition(article, []model.ArticleStatus{model.ArticleStatusPending}, func(a *model.Article, now time.Time) {
+		a.ReviewedByID = &reviewerID
+		a.ReviewedAt = &now
+		a.ReviewNotes = strings.TrimSpace(notes)
+		if publishAt != nil {
+			a.Status = model.ArticleStatusScheduled
+			a.PublishedAt = publishAt
+		} else {
+			publishNow(a, now)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyReviewResult(article, reviewerID, true)
+	return article, nil
+}
+
+// RejectArticle 退回文章（审核员，待审核 → 草稿，必须填写审核意见）
+func (s *ArticleService) RejectArticle(id, reviewerID uint, notes string) (*model.Article, error) {
+	if err := s.requireReviewer(reviewerID); err != nil {
+		return nil, err
+	}
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return nil, ErrReviewNotesRequired
+	}
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transition(article, []model.ArticleStatus{model.ArticleStatusPending}, func(a *model.Article, now time.Time) {
+		a.Status = model.ArticleStatusDraft
+		a.ReviewedByID = &reviewerID
+		a.ReviewedAt = &now
+		a.ReviewNotes = notes
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyReviewResult(article, reviewerID, false)
+	return article, nil
+}
+
+// ScheduleArticle 设置定时发布（审核员，草稿、待审核或已定时 → 定时发布）
+func (s *ArticleService) ScheduleArticle(id, reviewerID uint, publishAt time.Time) (*model.Article, error) {
+	if err := s.requireReviewer(reviewerID); err != nil {
+		return nil, err
+	}
+	if !publishAt.After(time.Now()) {
+		return nil, ErrSchedulePast
+	}
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := []model.ArticleStatus{model.ArticleStatusDraft, model.ArticleStatusPending, model.ArticleStatusScheduled}
+	err = s.transition(article, from, func(a *model.Article, now time.Time) {
+		a.Status = model.ArticleStatusScheduled
+		a.PublishedAt = &publishAt
+		if a.ReviewedByID == nil {
+			a.ReviewedByID = &reviewerID
+			a.ReviewedAt = &now
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// PublishArticle 立即发布文章（审核员，草稿、待审核或定时发布 → 已发布）
+func (s *ArticleService) PublishArticle(id, reviewerID uint) (*model.Article, error) {
+	if err := s.requireReviewer(reviewerID); err != nil {
+		return nil, err
+	}
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := []model.ArticleStatus{model.ArticleStatusDraft, model.ArticleStatusPending, model.ArticleStatusScheduled}
+	err = s.transition(article, from, func(a *model.Article, now time.Time) {
+		publishNow(a, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// UnpublishArticle 取消发布或取消定时发布（审核员，已发布或定时发布 → 草稿）
+func (s *ArticleService) UnpublishArticle(id, reviewerID uint) (*model.Article, error) {
+	if err := s.requireReviewer(reviewerID); err != nil {
+		return nil, err
+	}
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := []model.ArticleStatus{model.ArticleStatusPublished, model.ArticleStatusScheduled}
+	err = s.transition(article, from, func(a *model.Article, now time.Time) {
+		clearSchedule(a, now)
+		a.Status = model.ArticleStatusDraft
+	})
+	if err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// ArchiveArticle 归档文章（审核员可归档任意文章，作者只能归档自己未发布的文章）
+func (s *ArticleService) ArchiveArticle(id, userID uint) (*model.Article, error) {
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	published := article.Status == model.ArticleStatusPublished || article.Status == model.ArticleStatusScheduled
+	if article.AuthorID != userID || published {
+		if err := s.requireReviewer(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	from := []model.ArticleStatus{
+		model.ArticleStatusDraft, model.ArticleStatusPending, model.ArticleStatusScheduled, model.ArticleStatusPublished,
+	}
+	err = s.transition(article, from, func(a *model.Article, now time.Time) {
+		clearSchedule(a, now)
+		a.Status = model.ArticleStatusArchived
+		a.ArchivedAt = &now
+	})
+	if err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// UnarchiveArticle 取消归档（已归档 → 草稿，需重新审核后发布；作者只能恢复自己从未发布过的文章）
+func (s *ArticleService) UnarchiveArticle(id, userID uint) (*model.Article, error) {
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	if article.AuthorID != userID || article.PublishedAt != nil {
+		if err := s.requireReviewer(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.transition(article, []model.ArticleStatus{model.ArticleStatusArchived}, func(a *model.Article, now time.Time) {
+		a.Status = model.ArticleStatusDraft
+		a.ArchivedAt = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// PublishDue 发布已到计划发布时间的定时文章
+// 返回：
+//   - 发布的文章数
+//   - 错误信息
+func (s *ArticleService) PublishDue(now time.Time) (int, error) {
+	var due []model.Article
+	if err := s.db.Where("status = ? AND published_at <= ?", model.ArticleStatusScheduled, now).
+		Order("published_at ASC").
+		Find(&due).Error; err != nil {
+		return 0, fmt.Errorf("查询待发布文章失败: %w", err)
+	}
+
+	published := 0
+	for i := range due {
+		// 以状态为条件更新，期间被取消定时的文章不会被发布
+		result := s.db.Model(&model.Article{}).
+			Where("id = ? AND status = ?", due[i].ID, model.ArticleStatusScheduled).
+			Update("status", model.ArticleStatusPublished)
+		if result.Error != nil {
+			return published, fmt.Errorf("发布文章 %d 失败: %w", due[i].ID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		due[i].Status = model.ArticleStatusPublished
+		s.publishURL(seo.IndexActionUpdate, &due[i])
+		published++
+	}
+	return published, nil
+}
+
+// Run 定期发布到期的定时文章，ctx 结束时退出
+func (s *ArticleService) Run(ctx context.Context) {
+	s.publishDueAndLog()
+
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.publishDueAndLog()
+		}
+	}
+}
+
+// publishDueAndLog 发布到期的定时文章并记录结果
+func (s *ArticleService) publishDueAndLog() {
+	count, err := s.PublishDue(time.Now())
+	if err != nil {
+		log.Printf("定时发布文章失败: %v", err)
+	}
+	if count > 0 {
+		log.Printf("定时发布文章 %d 篇", count)
+	}
+}
+
+// ListEditorialArticles 获取编辑流程中的文章（审核员可查看全部文章，其他用户只能查看自己的文章）
+// 参数：
+//   - userID: 当前用户ID
+//   - status: 状态筛选，为空时不筛选
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 文章列表（最近更新的在前，待审核的先提交的在前）
+//   - 总数
+//   - 错误信息
+func (s *ArticleService) ListEditorialArticles(userID uint, status model.ArticleStatus, page, pageSize int) ([]ArticleListItem, int64, error) {
+	query := s.db.Table("articles").
+		Select(`
+			articles.*,
+			users.username as author_name
+		`).
+		Joins("LEFT JOIN users ON articles.author_id = users.id").
+		Where("articles.deleted_at IS NULL")
+
+	if s.requireReviewer(userID) != nil {
+		query = query.Where("articles.author_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("articles.status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计文章数量失败: %w", err)
+	}
+
+	order := "articles.updated_at DESC"
+	if status == model.ArticleStatusPending {
+		order = "articles.submitted_at ASC"
+	}
+	var articles []ArticleListItem
+	if err := query.Order(order).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&articles).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询文章失败: %w", err)
+	}
+
+	return articles, total, nil
+}
+
+// CanView 判断用户能否查看文章（已发布的文章所有人可见，其他状态只有作者和审核员可见）
+func (s *ArticleService) CanView(article *model.Article, userID uint) bool {
+	if article.Status == model.ArticleStatusPublished {
+		return true
+	}
+	if userID == 0 {
+		return false
+	}
+	return s.requireAuthorOrReviewer(article, userID) == nil
+}
+
+// transition 检查文章当前状态后执行状态流转
+// 以原状态为条件更新，并发的其他操作（如定时发布）已改变状态时返回 ErrInvalidArticleTransition
+func (s *ArticleService) transition(article *model.Article, from []model.ArticleStatus, apply func(a *model.Article, now time.Time)) error {
+	previous := article.Status
+	allowed := false
+	for _, status := range from {
+		if previous == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrInvalidArticleTransition
+	}
+
+	apply(article, time.Now())
+
+	result := s.db.Model(article).
+		Where("status = ?", previous).
+		Select(transitionColumns).
+		Updates(article)
+	if result.Error != nil {
+		return fmt.Errorf("更新文章状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidArticleTransition
+	}
+
+	// 上线或下线时通知搜索引擎
+	if article.Status == model.ArticleStatusPublished {
+		s.publishURL(seo.IndexActionUpdate, article)
+	} else if previous == model.ArticleStatusPublished {
+		s.publishURL(seo.IndexActionDelete, article)
+	}
+	return nil
+}
+
+// publishNow 设置为已发布（从未发布过或计划时间未到的文章以当前时间作为发布时间）
+func publishNow(a *model.Article, now time.Time) {
+	a.Status = model.ArticleStatusPublished
+	if a.PublishedAt == nil || a.PublishedAt.After(now) {
+		a.PublishedAt = &now
+	}
+}
+
+// clearSchedule 取消尚未到达的计划发布时间
+func clearSchedule(a *model.Article, now time.Time) {
+	if a.Status == model.ArticleStatusScheduled && a.PublishedAt != nil && a.PublishedAt.After(now) {
+		a.PublishedAt = nil
+	}
+}
+
+// getArticle 获取文章
+func (s *ArticleService) getArticle(id uint) (*model.Article, error) {
+	var article model.Article
+	if err := s.db.Preload("Author").First(&article, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		return nil, fmt.Errorf("查询文章失败: %w", err)
+	}
+	return &article, nil
+}
+
+// getViewableArticle 获取用户有权查看编辑记录的文章（作者、管理员或版主）
+func (s *ArticleService) getViewableArticle(id, userID uint) (*model.Article, error) {
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireAuthorOrReviewer(article, userID); err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// getEditableArticle 获取用户有权修改的文章（作者可修改草稿和待审核的文章，管理员和版主可修改任意文章）
+func (s *ArticleService) getEditableArticle(id, userID uint) (*model.Article, error) {
+	article, err := s.getArticle(id)
+	if err != nil {
+		return nil, err
+	}
+	editable := article.Status == model.ArticleStatusDraft || article.Status == model.ArticleStatusPending
+	if article.AuthorID != userID || !editable {
+		if err := s.requireReviewer(userID); err != nil {
+			return nil, err
+		}
+	}
+	return article, nil
+}
+
+// requireAuthorOrReviewer 检查用户是否为文章作者或审核员
+func (s *ArticleService) requireAuthorOrReviewer(article *model.Article, userID uint) error {
+	if article.AuthorID == userID {
+		return nil
+	}
+	return s.requireReviewer(userID)
+}
+
+// requireReviewer 检查用户是否为审核员（管理员或版主）
+func (s *ArticleService) requireReviewer(userID uint) error {
+	var user model.User
+	if err := s.db.Select("id", "role").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrArticlePermissionDenied
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.Role == "admin" || user.Role == "moderator" {
+		return nil
+	}
+	return ErrArticlePermissionDenied
+}
+
+// notifyReviewResult 通知作者文章的审核结果（审核员审核自己的文章时不通知）
+func (s *ArticleService) notifyReviewResult(article *model.Article, reviewerID uint, approved bool) {
+	if s.notifier == nil || article.AuthorID == reviewerID {
+		return
+	}
+
+	event := &notification.Event{
+		UserID:     article.AuthorID,
+		TargetType: "article",
+		TargetID:   &article.ID,
+	}
+	switch {
+	case !approved:
+		event.Type = model.NotificationTypeArticleRejected
+		event.Title = "文章未通过审核"
+		event.Content = fmt.Sprintf("您的文章《%s》未通过审核，已退回草稿", article.Title)
+	case article.Status == model.ArticleStatusScheduled:
+		event.Type = model.NotificationTypeArticleApproved
+		event.Title = "文章审核通过"
+		event.Content = fmt.Sprintf("您的文章《%s》已通过审核，将于 %s 发布", article.Title, article.PublishedAt.Local().Format("2006-01-02 15:04"))
+	default:
+		event.Type = model.NotificationTypeArticleApproved
+		event.Title = "文章审核通过"
+		event.Content = fmt.Sprintf("您的文章《%s》已通过审核并发布", article.Title)
+		event.Link = seo.ArticlePath(article.Slug)
+	}
+	if article.ReviewNotes != "" {
+		event.Content += fmt.Sprintf("，审核意见：%s", article.ReviewNotes)
+	}
+
+	_ = s.notifier.Publish(event)
+}
@@ -0,0 +1,402 @@
+/*
+Package auth provides account email verification, password reset and email change services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/mail"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrInvalidToken           = errors.New("链接无效或已失效")
+	ErrTokenExpired           = errors.New("链接已过期，请重新申请")
+	ErrTokenUsed              = errors.New("链接已被使用")
+	ErrEmailAlreadyVerified   = errors.New("邮箱已验证")
+	ErrEmailNotVerified       = errors.New("请先验证邮箱")
+	ErrEmailInUse             = errors.New("邮箱已被使用")
+	ErrTokenRequestTooOften   = errors.New("请求过于频繁，请稍后再试")
+	ErrEmailChangeNeedConfirm = errors.New("修改邮箱需要验证新邮箱，请使用修改邮箱功能")
+)
+
+// tokenResendInterval 同一用途令牌的最短申请间隔
+const tokenResendInterval = time.Minute
+
+// AccountService 账户安全服务（邮箱验证、密码重置、修改邮箱）
+type AccountService struct {
+	db     *gorm.DB
+	mailer mail.Mailer
+	cfg    *config.AuthConfig
+	secret []byte
+}
+
+// NewAccountService 创建账户安全服务
+// 参数：
+//   - db: 数据库连接
+//   - mailer: 邮件发送器
+//   - cfg: 认证配置（为nil时使用默认配置）
+//   - secret: 令牌签名密钥
+func NewAccountService(db *gorm.DB, mailer mail.Mailer, cfg *config.AuthConfig, secret string) *AccountService {
+	if cfg == nil {
+		cfg = config.DefaultAuthConfig()
+	}
+	return &AccountService{
+		db:     db,
+		mailer: mailer,
+		cfg:    cfg,
+		secret: []byte(secret),
+	}
+}
+
+// SendVerificationEmail 发送邮箱验证邮件
+func (s *AccountService) SendVerificationEmail(userID uint) error {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(user.ID, model.TokenPurposeVerifyEmail, "", s.cfg.GetEmailVerifyTTL())
+	if err != nil {
+		return err
+	}
+
+	link := s.buildLink("/auth/verify-email", token)
+	body := fmt.Sprintf("您好 %s：\n\n请点击以下链接验证您的邮箱（%d小时内有效）：\n%s\n\n如果这不是您本人的操作，请忽略此邮件。",
+		user.Username, s.cfg.EmailVerifyTTL, link)
+
+	return s.mailer.Send(&mail.Message{
+		To:      []string{user.Email},
+		Subject: "请验证您的邮箱",
+		Body:    body,
+	})
+}
+
+// VerifyEmail 使用令牌验证邮箱
+func (s *AccountService) VerifyEmail(token string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.consumeToken(tx, token, model.TokenPurposeVerifyEmail)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", record.UserID).
+			Updates(map[string]interface{}{
+				"email_verified":    true,
+				"email_verified_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("更新邮箱验证状态失败: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RequestPasswordReset 申请密码重置
+// 邮箱不存在时同样返回成功，避免泄露注册信息
+func (s *AccountService) RequestPasswordReset(email string) error {
+	var user model.User
+	if err := s.db.Where("email = ?", strings.TrimSpace(email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if user.Status != "active" {
+		return nil
+	}
+
+	token, err := s.issueToken(user.ID, model.TokenPurposeResetPassword, "", s.cfg.GetPasswordResetTTL())
+	if err != nil {
+		if errors.Is(err, ErrTokenRequestTooOften) {
+			return nil
+		}
+		return err
+	}
+
+	link := s.buildLink("/auth/reset-password", token)
+	body := fmt.Sprintf("您好 %s：\n\n我们收到了重置密码的请求，请点击以下链接设置新密码（%d分钟内有效）：\n%s\n\n如果这不是您本人的操作，请忽略此邮件，您的密码不会被修改。",
+		user.Username, s.cfg.PasswordResetTTL, link)
+
+	return s.mailer.Send(&mail.Message{
+		To:      []string{user.Email},
+		Subject: "重置密码",
+		Body:    body,
+	})
+}
+
+// ResetPassword 使用令牌重置密码
+func (s *AccountService) ResetPassword(token, newPassword string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.consumeToken(tx, token, model.TokenPurposeResetPassword)
+		if err != nil {
+			return err
+		}
+
+		// 能收到重置邮件说明邮箱可用，同时标记为已验证；
+		// 递增令牌版本使重置前签发的JWT全部失效
+		now := time.Now()
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", record.UserID).
+			Updates(map[string]interface{}{
+				"password_hash":     string(hash),
+				"token_version":     gorm.Expr("token_version + 1"),
+				"email_verified":    true,
+				"email_verified_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("更新密码失败: %w", err)
+		}
+
+		// 使该用户其他未使用的重置令牌失效
+		if err := tx.Model(&model.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", record.UserID, model.TokenPurposeResetPassword).
+			Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("清理重置令牌失败: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RequestEmailChange 申请修改邮箱（向新邮箱发送确认邮件）
+// 参数：
+//   - userID: 用户ID
+//   - newEmail: 新邮箱
+//   - password: 当前密码（二次确认身份）
+func (s *AccountService) RequestEmailChange(userID uint, newEmail, password string) error {
+	newEmail = strings.TrimSpace(newEmail)
+
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errors.New("密码错误")
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return errors.New("新邮箱不能与当前邮箱相同")
+	}
+
+	if err := s.checkEmailAvailable(s.db, userID, newEmail); err != nil {
+		return err
+	}
+
+	token, err := s.issueToken(user.ID, model.TokenPurposeChangeEmail, newEmail, s.cfg.GetEmailChangeTTL())
+	if err != nil {
+		return err
+	}
+
+	link := s.buildLink("/auth/change-email/confirm", token)
+	body := fmt.Sprintf("您好 %s：\n\n您正在将账户邮箱修改为 %s，请点击以下链接确认（%d小时内有效）：\n%s\n\n如果这不是您本人的操作，请忽略此邮件。",
+		user.Username, newEmail, s.cfg.EmailChangeTTL, link)
+
+	return s.mailer.Send(&mail.Message{
+		To:      []string{newEmail},
+		Subject: "确认修改邮箱",
+		Body:    body,
+	})
+}
+
+// ConfirmEmailChange 使用令牌确认修改邮箱
+func (s *AccountService) ConfirmEmailChange(token string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.consumeToken(tx, token, model.TokenPurposeChangeEmail)
+		if err != nil {
+			return err
+		}
+
+		// 申请之后邮箱可能已被他人注册，需要再次检查
+		if err := s.checkEmailAvailable(tx, record.UserID, record.NewEmail); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", record.UserID).
+			Updates(map[string]interface{}{
+				"email":             record.NewEmail,
+				"email_verified":    true,
+				"email_verified_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("更新邮箱失败: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RequireVerifiedEmail 检查用户是否已验证邮箱（未开启限制时直接通过）
+func (s *AccountService) RequireVerifiedEmail(userID uint) error {
+	if !s.cfg.RestrictUnverified {
+		return nil
+	}
+
+	var user model.User
+	if err := s.db.Select("id", "email_verified").First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if !user.EmailVerified {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+// RestrictUnverified 是否限制未验证邮箱的用户
+func (s *AccountService) RestrictUnverified() bool {
+	return s.cfg.RestrictUnverified
+}
+
+// issueToken 生成令牌并保存哈希
+// 旧的同用途未使用令牌会被作废，保证同一时间只有最新的链接有效
+func (s *AccountService) issueToken(userID uint, purpose model.TokenPurpose, newEmail string, ttl time.Duration) (string, error) {
+	// 限制申请频率
+	var recent int64
+	if err := s.db.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, time.Now().Add(-tokenResendInterval)).
+		Count(&recent).Error; err != nil {
+		return "", fmt.Errorf("查询令牌失败: %w", err)
+	}
+	if recent > 0 {
+		return "", ErrTokenRequestTooOften
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	value := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("作废旧令牌失败: %w", err)
+		}
+
+		record := &model.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hashToken(value),
+			NewEmail:  newEmail,
+			ExpiresAt: time.Now().Add(ttl),
+		}
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("保存令牌失败: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return value + "." + s.sign(purpose, value), nil
+}
+
+// consumeToken 校验签名并消费令牌（只能使用一次）
+func (s *AccountService) consumeToken(tx *gorm.DB, token string, purpose model.TokenPurpose) (*model.UserToken, error) {
+	value, signature, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || value == "" {
+		return nil, ErrInvalidToken
+	}
+
+	// 先校验签名，避免伪造令牌查询数据库
+	if !hmac.Equal([]byte(signature), []byte(s.sign(purpose, value))) {
+		return nil, ErrInvalidToken
+	}
+
+	var record model.UserToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", hashToken(value), purpose).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("查询令牌失败: %w", err)
+	}
+
+	if record.IsUsed() {
+		return nil, ErrTokenUsed
+	}
+	if record.IsExpired() {
+		return nil, ErrTokenExpired
+	}
+
+	// 条件更新保证并发请求下令牌只被消费一次
+	result := tx.Model(&model.UserToken{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新令牌状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTokenUsed
+	}
+
+	return &record, nil
+}
+
+// checkEmailAvailable 检查邮箱是否可用
+func (s *AccountService) checkEmailAvailable(db *gorm.DB, userID uint, email string) error {
+	var count int64
+	if err := db.Model(&model.User{}).
+		Where("id != ? AND email = ?", userID, email).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("检查邮箱失败: %w", err)
+	}
+	if count > 0 {
+		return ErrEmailInUse
+	}
+	return nil
+}
+
+// sign 计算令牌签名
+func (s *AccountService) sign(purpose model.TokenPurpose, value string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(string(purpose) + ":" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// buildLink 构建邮件中的链接
+func (s *AccountService) buildLink(path, token string) string {
+	return strings.TrimRight(s.cfg.SiteURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// hashToken 计算令牌哈希（数据库中只保存哈希）
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -9,15 +9,47 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/notification"
 	"resource-share-site/pkg/utils"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Errors 定义用户名校验错误
+var (
+	ErrUsernameReserved  = errors.New("该用户名为系统保留，不能使用")
+	ErrUsernameSensitive = errors.New("用户名包含敏感词")
+)
+
+// reservedUsernames 系统保留的用户名（自动审核等系统账户使用，不区分大小写）
+var reservedUsernames = map[string]bool{
+	"system": true,
+}
+
+// IsReservedUsername 判断用户名是否为系统保留
+func IsReservedUsername(username string) bool {
+	return reservedUsernames[strings.ToLower(strings.TrimSpace(username))]
+}
+
+// ValidateUsername 校验用户名可以使用（注册、第三方登录注册和修改资料共用）
+// 用户名无法替换或人工审核，命中任何敏感词都拒绝；系统保留的用户名也不能使用
+func (s *AuthServiceImpl) ValidateUsername(username string) error {
+	if screened := s.sensitive.Check(username); len(screened.Hits) > 0 {
+		return ErrUsernameSensitive
+	}
+	if IsReservedUsername(username) {
+		return ErrUsernameReserved
+	}
+	return nil
+}
+
 // GORMContext 认证上下文
 type GORMContext struct {
 	DB *gorm.DB
@@ -28,6 +60,10 @@ type LoginRequest struct {
 	Identifier string `json:"identifier" binding:"required"` // 用户名或邮箱
 	Password   string `json:"password" binding:"required,min=6,max=100"`
 	Remember   bool   `json:"remember"` // 记住登录状态
+
+	// 客户端信息（由处理器填充，用于登录保护和登录历史）
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 // LoginResponse 登录响应结构
@@ -35,6 +71,24 @@ type LoginResponse struct {
 	Token     string    `json:"token"`
 	ExpiresAt time.Time `json:"expires_at"`
 	User      UserInfo  `json:"user"`
+
+	// 两步验证（需要时不返回Token，客户端凭ChallengeToken完成第二步）
+	TwoFactorRequired      bool     `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool     `json:"two_factor_setup_required,omitempty"` // 账户被强制启用但尚未配置
+	ChallengeToken         string   `json:"challenge_token,omitempty"`
+	RecoveryCodes          []string `json:"recovery_codes,omitempty"` // 首次启用时返回的恢复码
+}
+
+// TwoFactorLoginRequest 两步验证登录请求结构
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`          // 认证器App中的验证码
+	RecoveryCode   string `json:"recovery_code"` // 恢复码（无法使用认证器时）
+	Remember       bool   `json:"remember"`
+
+	// 客户端信息（由处理器填充）
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 // UserInfo 用户信息（不包含敏感信息）
@@ -43,6 +97,7 @@ type UserInfo struct {
 	Username                 string `json:"username"`
 	Email                    string `json:"email"`
 	Role                     string `json:"role"`
+	EmailVerified            bool   `json:"email_verified"`
 	Status                   string `json:"status"`
 	CanUpload                bool   `json:"can_upload"`
 	PointsBalance            int    `json:"points_balance"`
@@ -90,6 +145,9 @@ type AuthService interface {
 	// 登录 - 支持用户名或邮箱
 	Login(ctx *GORMContext, req *LoginRequest) (*LoginResponse, error)
 
+	// 两步验证登录 - 密码验证通过后提交验证码
+	CompleteTwoFactorLogin(ctx *GORMContext, req *TwoFactorLoginRequest) (*LoginResponse, error)
+
 	// 注册
 	Register(ctx *GORMContext, req *RegisterRequest) (*RegisterResponse, error)
 
@@ -111,7 +169,11 @@ type AuthService interface {
 
 // AuthServiceImpl 认证服务实现
 type AuthServiceImpl struct {
-	db *gorm.DB
+	db        *gorm.DB
+	notifier  notification.Publisher
+	guard     *LoginGuard
+	twoFA     *TwoFactorService
+	sensitive *filter.Dictionary
 }
 
 // NewAuthService 创建认证服务
@@ -121,36 +183,183 @@ func NewAuthService(db *gorm.DB) AuthService {
 	}
 }
 
+// SetNotifier 设置通知发布器（邀请注册完成时通知邀请人）
+func (s *AuthServiceImpl) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// SetLoginGuard 设置登录保护（失败计数、锁定和登录历史），为nil时不启用
+func (s *AuthServiceImpl) SetLoginGuard(guard *LoginGuard) {
+	s.guard = guard
+}
+
+// SetTwoFactor 设置两步验证服务，为nil时不启用
+func (s *AuthServiceImpl) SetTwoFactor(twoFA *TwoFactorService) {
+	s.twoFA = twoFA
+}
+
+// SetFilter 设置敏感词库（注册和修改资料时检查用户名），为nil时不检查
+func (s *AuthServiceImpl) SetFilter(sensitive *filter.Dictionary) {
+	s.sensitive = sensitive
+}
+
 // Login 登录 - 支持用户名或邮箱
 func (s *AuthServiceImpl) Login(ctx *GORMContext, req *LoginRequest) (*LoginResponse, error) {
+	// 检查IP是否被临时锁定
+	if s.guard != nil {
+		if err := s.guard.CheckIP(req.IP); err != nil {
+			s.recordLoginFailure(nil, req, model.LoginFailureLocked)
+			return nil, err
+		}
+	}
+
 	// 查找用户（支持用户名或邮箱）
 	user, err := s.FindUserByIdentifier(ctx, req.Identifier)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.recordLoginFailure(nil, req, model.LoginFailureUserNotFound)
 			return nil, errors.New("用户不存在或密码错误")
 		}
 		return nil, err
 	}
 
+	// 检查账户是否被临时锁定或需要等待
+	if s.guard != nil {
+		if err := s.guard.CheckAccount(user.ID); err != nil {
+			reason := model.LoginFailureLocked
+			var blocked *LoginBlockedError
+			if errors.As(err, &blocked) {
+				reason = blocked.Reason
+			}
+			s.recordLoginFailure(&user.ID, req, reason)
+			return nil, err
+		}
+	}
+
 	// 检查用户状态
 	if user.Status != "active" {
+		s.recordLoginFailure(&user.ID, req, model.LoginFailureAccountDisabled)
 		return nil, errors.New("账户已被禁用或未激活")
 	}
 
 	// 验证密码
 	if err := s.VerifyPassword(req.Password, user.PasswordHash); err != nil {
+		s.recordLoginFailure(&user.ID, req, model.LoginFailureWrongPassword)
 		return nil, errors.New("用户不存在或密码错误")
 	}
 
+	return s.LoginUser(user, req.Identifier, req.IP, req.UserAgent, req.Remember)
+}
+
+// LoginUser 为已通过身份验证的用户完成登录（密码登录和第三方登录共用）
+// 需要两步验证时只返回登录会话，验证通过后再签发Token
+func (s *AuthServiceImpl) LoginUser(user *model.User, identifier, ip, userAgent string, remember bool) (*LoginResponse, error) {
+	if s.twoFA != nil && s.twoFA.Required(user) {
+		challenge, err := s.twoFA.CreateChallenge(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResponse{
+			User:                   buildUserInfo(user),
+			TwoFactorRequired:      true,
+			TwoFactorSetupRequired: !user.TwoFactorEnabled,
+			ChallengeToken:         challenge,
+		}, nil
+	}
+
+	return s.issueLoginToken(user, identifier, ip, userAgent, remember)
+}
+
+// CompleteTwoFactorLogin 两步验证登录
+// 账户被强制启用但尚未配置时，首个正确的验证码会同时完成启用并返回恢复码
+// 参数：
+//   - ctx: 认证上下文
+//   - req: 两步验证登录请求（验证码和恢复码二选一）
+//
+// 返回：
+//   - 登录响应
+//   - 错误信息
+func (s *AuthServiceImpl) CompleteTwoFactorLogin(ctx *GORMContext, req *TwoFactorLoginRequest) (*LoginResponse, error) {
+	if s.twoFA == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	challenge, err := s.twoFA.GetChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := s.db.First(&user, challenge.UserID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.Status != "active" {
+		return nil, errors.New("账户已被禁用或未激活")
+	}
+
+	loginReq := &LoginRequest{
+		Identifier: user.Username,
+		IP:         req.IP,
+		UserAgent:  req.UserAgent,
+	}
+
+	// 验证码错误同样计入失败次数
+	if s.guard != nil {
+		if err := s.guard.CheckAccount(user.ID); err != nil {
+			reason := model.LoginFailureLocked
+			var blocked *LoginBlockedError
+			if errors.As(err, &blocked) {
+				reason = blocked.Reason
+			}
+			s.recordLoginFailure(&user.ID, loginReq, reason)
+			return nil, err
+		}
+	}
+
+	var recoveryCodes []string
+	switch {
+	case !user.TwoFactorEnabled:
+		recoveryCodes, err = s.twoFA.Enable(user.ID, req.Code)
+	case req.RecoveryCode != "":
+		err = s.twoFA.UseRecoveryCode(user.ID, req.RecoveryCode, &AuditContext{
+			IP:        req.IP,
+			UserAgent: req.UserAgent,
+		})
+	default:
+		err = s.twoFA.VerifyCode(user.ID, req.Code)
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidOTPCode) || errors.Is(err, ErrInvalidRecoveryCode) {
+			s.recordLoginFailure(&user.ID, loginReq, model.LoginFailureWrongOTP)
+		}
+		return nil, err
+	}
+
+	if err := s.twoFA.ConsumeChallenge(challenge); err != nil {
+		return nil, err
+	}
+	user.TwoFactorEnabled = true
+
+	response, err := s.issueLoginToken(&user, user.Username, req.IP, req.UserAgent, req.Remember)
+	if err != nil {
+		return nil, err
+	}
+	response.RecoveryCodes = recoveryCodes
+
+	return response, nil
+}
+
+// issueLoginToken 签发登录Token并记录登录成功
+func (s *AuthServiceImpl) issueLoginToken(user *model.User, identifier, ip, userAgent string, remember bool) (*LoginResponse, error) {
 	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Username)
+	token, err := utils.GenerateToken(user.ID, user.Username, user.TokenVersion)
 	if err != nil {
 		return nil, err
 	}
 
 	// 设置过期时间
 	var expiresAt time.Time
-	if req.Remember {
+	if remember {
 		expiresAt = time.Now().Add(30 * 24 * time.Hour) // 30天
 	} else {
 		expiresAt = time.Now().Add(24 * time.Hour) // 1天
@@ -159,25 +368,41 @@ func (s *AuthServiceImpl) Login(ctx *GORMContext, req *LoginRequest) (*LoginResp
 	// 更新最后登录时间
 	s.db.Model(user).Update("last_login_at", time.Now())
 
-	// 构建响应
-	response := &LoginResponse{
+	// 记录登录历史
+	if s.guard != nil {
+		_ = s.guard.RecordSuccess(user, identifier, ip, userAgent)
+	}
+
+	return &LoginResponse{
 		Token:     token,
 		ExpiresAt: expiresAt,
-		User: UserInfo{
-			ID:                       user.ID,
-			Username:                 user.Username,
-			Email:                    user.Email,
-			Role:                     user.Role,
-			Status:                   user.Status,
-			CanUpload:                user.CanUpload,
-			PointsBalance:            user.PointsBalance,
-			InviteCode:               user.InviteCode,
-			UploadedResourcesCount:   user.UploadedResourcesCount,
-			DownloadedResourcesCount: user.DownloadedResourcesCount,
-		},
+		User:      buildUserInfo(user),
+	}, nil
+}
+
+// buildUserInfo 构建用户信息（不包含敏感信息）
+func buildUserInfo(user *model.User) UserInfo {
+	return UserInfo{
+		ID:                       user.ID,
+		Username:                 user.Username,
+		Email:                    user.Email,
+		Role:                     user.Role,
+		EmailVerified:            user.EmailVerified,
+		Status:                   user.Status,
+		CanUpload:                user.CanUpload,
+		PointsBalance:            user.PointsBalance,
+		InviteCode:               user.InviteCode,
+		UploadedResourcesCount:   user.UploadedResourcesCount,
+		DownloadedResourcesCount: user.DownloadedResourcesCount,
 	}
+}
 
-	return response, nil
+// recordLoginFailure 记录失败的登录尝试（未启用登录保护时忽略）
+func (s *AuthServiceImpl) recordLoginFailure(userID *uint, req *LoginRequest, reason model.LoginFailureReason) {
+	if s.guard == nil {
+		return
+	}
+	_ = s.guard.RecordFailure(userID, req.Identifier, req.IP, req.UserAgent, reason)
 }
 
 // Register 注册
@@ -187,6 +412,10 @@ func (s *AuthServiceImpl) Register(ctx *GORMContext, req *RegisterRequest) (*Reg
 		return nil, errors.New("两次输入的密码不一致")
 	}
 
+	if err := s.ValidateUsername(req.Username); err != nil {
+		return nil, err
+	}
+
 	// 检查用户名是否已存在
 	var count int64
 	s.db.Model(&model.User{}).Where("username = ?", req.Username).Count(&count)
@@ -206,34 +435,109 @@ func (s *AuthServiceImpl) Register(ctx *GORMContext, req *RegisterRequest) (*Reg
 		return nil, err
 	}
 
+	// 创建用户（处理邀请码）
+	user := model.User{
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: passwordHash,
+	}
+	inviter, err := s.createUser(&user, req.InviteCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建响应
+	response := &RegisterResponse{
+		ID:             user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		InviteCode:     user.InviteCode,
+		PointsBalance:  user.PointsBalance,
+		RequiresInvite: false,
+		RegisteredAt:   time.Now(),
+	}
+
+	// 设置邀请人信息（如果有）
+	if inviter != nil {
+		response.InvitedBy = &UserInfo{
+			ID:       inviter.ID,
+			Username: inviter.Username,
+			Email:    inviter.Email,
+		}
+	}
+
+	return response, nil
+}
+
+// RegisterExternal 通过第三方身份注册新用户（没有本地密码，可通过重置密码设置）
+// 参数：
+//   - username: 用户名（调用方需保证唯一）
+//   - email: 邮箱
+//   - emailVerified: 第三方是否已验证该邮箱
+//   - inviteCode: 邀请码（可选）
+//
+// 返回：
+//   - 新用户
+//   - 错误信息
+func (s *AuthServiceImpl) RegisterExternal(username, email string, emailVerified bool, inviteCode string) (*model.User, error) {
+	if err := s.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询邮箱失败: %w", err)
+	}
+	if count > 0 {
+		return nil, errors.New("邮箱已被使用")
+	}
+
+	user := model.User{
+		Username:      username,
+		Email:         email,
+		EmailVerified: emailVerified,
+	}
+	if emailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if _, err := s.createUser(&user, inviteCode); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// createUser 创建用户并处理邀请码（奖励邀请人积分并通知邀请人）
+// 返回：
+//   - 邀请人（没有有效邀请码时为nil）
+//   - 错误信息
+func (s *AuthServiceImpl) createUser(user *model.User, inviteCode string) (*model.User, error) {
 	// 处理邀请码（可选）
 	var inviter *model.User
-	if req.InviteCode != "" {
+	if inviteCode != "" {
 		// 查找邀请人
-		s.db.Model(&model.User{}).Where("invite_code = ?", req.InviteCode).First(&inviter)
+		s.db.Model(&model.User{}).Where("invite_code = ?", inviteCode).First(&inviter)
 	}
 
-	// 创建用户
-	user := model.User{
-		Username:      req.Username,
-		Email:         req.Email,
-		PasswordHash:  passwordHash,
-		Role:          "user",
-		Status:        "active",
-		CanUpload:     false, // 默认没有上传权限
-		InviteCode:    utils.GenerateInviteCode(),
-		PointsBalance: 0,
-		InvitedByID:   nil,
-	}
+	// 设置默认属性
+	user.Role = "user"
+	user.Status = "active"
+	user.CanUpload = false // 默认没有上传权限
+	user.InviteCode = utils.GenerateInviteCode()
+	user.PointsBalance = 0
+	user.InvitedByID = nil
 
 	if inviter != nil {
 		user.InvitedByID = &inviter.ID
 	}
 
 	// 开启事务
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	var invitePoints int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// 创建用户
-		if err := tx.Create(&user).Error; err != nil {
+		if err := tx.Create(user).Error; err != nil {
 			return err
 		}
 
@@ -261,10 +565,11 @@ func (s *AuthServiceImpl) Register(ctx *GORMContext, req *RegisterRequest) (*Reg
 					if err := tx.Create(&record).Error; err != nil {
 						return err
 					}
+					invitePoints = pointsRule.Points
 
 					// 更新邀请记录
 					var invitation model.Invitation
-					if err := tx.Where("invite_code = ? AND invitee_id IS NULL", req.InviteCode).First(&invitation).Error; err == nil {
+					if err := tx.Where("invite_code = ? AND invitee_id IS NULL", inviteCode).First(&invitation).Error; err == nil {
 						tx.Model(&invitation).Updates(map[string]interface{}{
 							"invitee_id":     user.ID,
 							"status":         model.InvitationStatusCompleted,
@@ -283,27 +588,23 @@ func (s *AuthServiceImpl) Register(ctx *GORMContext, req *RegisterRequest) (*Reg
 		return nil, err
 	}
 
-	// 构建响应
-	response := &RegisterResponse{
-		ID:             user.ID,
-		Username:       user.Username,
-		Email:          user.Email,
-		InviteCode:     user.InviteCode,
-		PointsBalance:  user.PointsBalance,
-		RequiresInvite: false,
-		RegisteredAt:   time.Now(),
-	}
-
-	// 设置邀请人信息（如果有）
-	if inviter != nil {
-		response.InvitedBy = &UserInfo{
-			ID:       inviter.ID,
-			Username: inviter.Username,
-			Email:    inviter.Email,
+	// 通知邀请人
+	if inviter != nil && s.notifier != nil {
+		content := fmt.Sprintf("用户 %s 通过您的邀请码完成了注册", user.Username)
+		if invitePoints > 0 {
+			content += fmt.Sprintf("，奖励 %d 积分已到账", invitePoints)
 		}
+		_ = s.notifier.Publish(&notification.Event{
+			Type:       model.NotificationTypeInviteCompleted,
+			UserID:     inviter.ID,
+			Title:      "邀请已完成",
+			Content:    content,
+			TargetType: "user",
+			TargetID:   &user.ID,
+		})
 	}
 
-	return response, nil
+	return inviter, nil
 }
 
 // ChangePassword 修改密码
@@ -330,8 +631,11 @@ func (s *AuthServiceImpl) ChangePassword(ctx *GORMContext, userID uint, req *Cha
 		return err
 	}
 
-	// 更新密码
-	return s.db.Model(&user).Update("password_hash", newPasswordHash).Error
+	// 更新密码并递增令牌版本，使已签发的登录态失效
+	return s.db.Model(&user).Updates(map[string]interface{}{
+		"password_hash": newPasswordHash,
+		"token_version": gorm.Expr("token_version + 1"),
+	}).Error
 }
 
 // UpdateProfile 更新用户资料
@@ -340,6 +644,10 @@ func (s *AuthServiceImpl) UpdateProfile(ctx *GORMContext, userID uint, req *Upda
 
 	// 检查用户名（如果提供）
 	if req.Username != "" {
+		if err := s.ValidateUsername(req.Username); err != nil {
+			return err
+		}
+
 		// 检查用户名是否已被其他用户使用
 		var count int64
 		s.db.Model(&model.User{}).Where("id != ? AND username = ?", userID, req.Username).Count(&count)
@@ -349,15 +657,15 @@ func (s *AuthServiceImpl) UpdateProfile(ctx *GORMContext, userID uint, req *Upda
 		updates["username"] = req.Username
 	}
 
-	// 检查邮箱（如果提供）
+	// 检查邮箱（如果提供）：修改邮箱需通过新邮箱确认，不能直接更新
 	if req.Email != "" {
-		// 检查邮箱是否已被其他用户使用
-		var count int64
-		s.db.Model(&model.User{}).Where("id != ? AND email = ?", userID, req.Email).Count(&count)
-		if count > 0 {
-			return errors.New("邮箱已被使用")
+		var user model.User
+		if err := s.db.Select("id", "email").First(&user, userID).Error; err != nil {
+			return err
+		}
+		if !strings.EqualFold(user.Email, req.Email) {
+			return ErrEmailChangeNeedConfirm
 		}
-		updates["email"] = req.Email
 	}
 
 	if len(updates) == 0 {
@@ -0,0 +1,363 @@
+/*
+Package auth provides login brute-force protection and login history services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package auth
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxLoginDelay 递增等待的最长时间
+	maxLoginDelay = time.Minute
+
+	// maxFailureScan 计算锁定状态时最多读取的失败记录数
+	maxFailureScan = 500
+)
+
+// LoginBlockedError 登录被限制错误（包含需要等待的时间）
+type LoginBlockedError struct {
+	Reason     model.LoginFailureReason
+	RetryAfter time.Duration
+}
+
+// Error 实现error接口
+func (e *LoginBlockedError) Error() string {
+	if e.Reason == model.LoginFailureLocked {
+		minutes := int(math.Ceil(e.RetryAfter.Minutes()))
+		return fmt.Sprintf("登录失败次数过多，请%d分钟后再试", minutes)
+	}
+	seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	return fmt.Sprintf("尝试过于频繁，请%d秒后再试", seconds)
+}
+
+// LoginGuard 登录保护服务（失败计数、递增等待、临时锁定和登录历史）
+type LoginGuard struct {
+	db       *gorm.DB
+	cfg      *config.AuthConfig
+	notifier notification.Publisher
+}
+
+// NewLoginGuard 创建登录保护服务
+func NewLoginGuard(db *gorm.DB, cfg *config.AuthConfig) *LoginGuard {
+	if cfg == nil {
+		cfg = config.DefaultAuthConfig()
+	}
+	return &LoginGuard{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// SetNotifier 设置通知发布器（异常登录时通知管理员）
+func (g *LoginGuard) SetNotifier(notifier notification.Publisher) {
+	g.notifier = notifier
+}
+
+// CheckIP 检查IP是否因失败次数过多被临时锁定
+func (g *LoginGuard) CheckIP(ip string) error {
+	if ip == "" || g.cfg.MaxIPLoginFailures <= 0 {
+		return nil
+	}
+
+	failures, err := g.recentFailures(g.db.Where("ip = ?", ip).
+		Where("failure_reason IN ?", []model.LoginFailureReason{
+			model.LoginFailureWrongPassword,
+			model.LoginFailureUserNotFound,
+		}))
+	if err != nil {
+		return err
+	}
+
+	return g.checkLocked(failures, g.cfg.MaxIPLoginFailures)
+}
+
+// CheckAccount 检查账户是否被临时锁定或需要等待
+// 连续失败达到阈值后按 2s、4s、8s... 递增等待，达到上限后锁定
+func (g *LoginGuard) CheckAccount(userID uint) error {
+	// 登录成功后重新计数
+	var lastSuccess model.LoginHistory
+	query := g.db.Where("user_id = ? AND failure_reason IN ?", userID, []model.LoginFailureReason{
+		model.LoginFailureWrongPassword,
+		model.LoginFailureWrongOTP,
+	})
+	if err := g.db.Where("user_id = ? AND success = ?", userID, true).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&lastSuccess).Error; err != nil {
+		return fmt.Errorf("查询登录记录失败: %w", err)
+	}
+	if lastSuccess.ID != 0 {
+		query = query.Where("created_at > ?", lastSuccess.CreatedAt)
+	}
+
+	failures, err := g.recentFailures(query)
+	if err != nil {
+		return err
+	}
+
+	if g.cfg.MaxLoginFailures > 0 {
+		if err := g.checkLocked(failures, g.cfg.MaxLoginFailures); err != nil {
+			return err
+		}
+	}
+
+	// 递增等待
+	consecutive := countWithin(failures, time.Now(), g.cfg.GetLoginFailureWindow())
+	if g.cfg.LoginDelayAfter > 0 && consecutive >= g.cfg.LoginDelayAfter {
+		delay := time.Duration(1<<uint(consecutive-g.cfg.LoginDelayAfter+1)) * time.Second
+		if delay > maxLoginDelay || delay <= 0 {
+			delay = maxLoginDelay
+		}
+		if retry := time.Until(failures[len(failures)-1].Add(delay)); retry > 0 {
+			return &LoginBlockedError{Reason: model.LoginFailureTooFrequent, RetryAfter: retry}
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure 记录失败的登录尝试
+func (g *LoginGuard) RecordFailure(userID *uint, identifier, ip, userAgent string, reason model.LoginFailureReason) error {
+	country, city := g.lookupLocation(ip)
+	history := &model.LoginHistory{
+		UserID:        userID,
+		Identifier:    truncate(identifier, 100),
+		IP:            ip,
+		UserAgent:     truncate(userAgent, 500),
+		Country:       country,
+		City:          city,
+		Success:       false,
+		FailureReason: reason,
+	}
+	if err := g.db.Create(history).Error; err != nil {
+		return fmt.Errorf("记录登录历史失败: %w", err)
+	}
+	return nil
+}
+
+// RecordSuccess 记录成功的登录，并检测异常登录（如首次从新的国家登录）
+func (g *LoginGuard) RecordSuccess(user *model.User, identifier, ip, userAgent string) error {
+	country, city := g.lookupLocation(ip)
+	history := &model.LoginHistory{
+		UserID:     &user.ID,
+		Identifier: truncate(identifier, 100),
+		IP:         ip,
+		UserAgent:  truncate(userAgent, 500),
+		Country:    country,
+		City:       city,
+		Success:    true,
+	}
+
+	if country != "" {
+		anomaly, err := g.isNewCountry(user.ID, country)
+		if err != nil {
+			return err
+		}
+		if anomaly {
+			history.IsAnomaly = true
+			history.AnomalyReason = fmt.Sprintf("首次从 %s 登录", country)
+		}
+	}
+
+	if err := g.db.Create(history).Error; err != nil {
+		return fmt.Errorf("记录登录历史失败: %w", err)
+	}
+
+	if history.IsAnomaly {
+		g.alertAdmins(user, history)
+	}
+
+	return nil
+}
+
+// GetLoginHistory 获取用户的登录历史
+// 参数：
+//   - userID: 用户ID
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 登录历史列表
+//   - 总数
+//   - 错误信息
+func (g *LoginGuard) GetLoginHistory(userID uint, page, pageSize int) ([]model.LoginHistory, int64, error) {
+	var histories []model.LoginHistory
+	var total int64
+
+	query := g.db.Model(&model.LoginHistory{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询登录历史总数失败: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&histories).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询登录历史失败: %w", err)
+	}
+
+	return histories, total, nil
+}
+
+// GetAnomalies 获取异常登录记录（管理员）
+func (g *LoginGuard) GetAnomalies(page, pageSize int) ([]model.LoginHistory, int64, error) {
+	var histories []model.LoginHistory
+	var total int64
+
+	query := g.db.Model(&model.LoginHistory{}).Where("is_anomaly = ?", true)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询异常登录总数失败: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("User").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&histories).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询异常登录失败: %w", err)
+	}
+
+	return histories, total, nil
+}
+
+// recentFailures 获取统计窗口内（含锁定期）的失败时间，按时间升序
+func (g *LoginGuard) recentFailures(query *gorm.DB) ([]time.Time, error) {
+	since := time.Now().Add(-g.cfg.GetLoginFailureWindow() - g.cfg.GetLoginLockDuration())
+
+	var times []time.Time
+	if err := query.Model(&model.LoginHistory{}).
+		Where("success = ? AND created_at > ?", false, since).
+		Order("created_at DESC").
+		Limit(maxFailureScan).
+		Pluck("created_at", &times).Error; err != nil {
+		return nil, fmt.Errorf("查询登录失败记录失败: %w", err)
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+// checkLocked 检查失败记录是否触发锁定
+// 任意统计窗口内失败次数达到上限即锁定，锁定从该窗口最后一次失败开始计算
+func (g *LoginGuard) checkLocked(failures []time.Time, limit int) error {
+	window := g.cfg.GetLoginFailureWindow()
+	lockDuration := g.cfg.GetLoginLockDuration()
+
+	var lockedUntil time.Time
+	start := 0
+	for end := range failures {
+		for failures[end].Sub(failures[start]) > window {
+			start++
+		}
+		if end-start+1 >= limit {
+			lockedUntil = failures[end].Add(lockDuration)
+		}
+	}
+
+	if retry := time.Until(lockedUntil); retry > 0 {
+		return &LoginBlockedError{Reason: model.LoginFailureLocked, RetryAfter: retry}
+	}
+	return nil
+}
+
+// lookupLocation 根据访问日志查询IP的地理位置
+func (g *LoginGuard) lookupLocation(ip string) (string, string) {
+	if ip == "" {
+		return "", ""
+	}
+
+	var visit model.VisitLog
+	if err := g.db.Select("country", "city").
+		Where("ip = ? AND country <> ?", ip, "").
+		Order("created_at DESC").
+		Limit(1).
+		Find(&visit).Error; err != nil {
+		return "", ""
+	}
+
+	return visit.Country, visit.City
+}
+
+// isNewCountry 检查是否为用户首次从该国家登录（没有任何历史位置时不视为异常）
+func (g *LoginGuard) isNewCountry(userID uint, country string) (bool, error) {
+	var known, same int64
+	if err := g.db.Model(&model.LoginHistory{}).
+		Where("user_id = ? AND success = ? AND country <> ?", userID, true, "").
+		Count(&known).Error; err != nil {
+		return false, fmt.Errorf("查询登录历史失败: %w", err)
+	}
+	if known == 0 {
+		return false, nil
+	}
+
+	if err := g.db.Model(&model.LoginHistory{}).
+		Where("user_id = ? AND success = ? AND country = ?", userID, true, country).
+		Count(&same).Error; err != nil {
+		return false, fmt.Errorf("查询登录历史失败: %w", err)
+	}
+
+	return same == 0, nil
+}
+
+// alertAdmins 向所有管理员发送异常登录告警
+func (g *LoginGuard) alertAdmins(user *model.User, history *model.LoginHistory) {
+	if g.notifier == nil {
+		return
+	}
+
+	var adminIDs []uint
+	if err := g.db.Model(&model.User{}).
+		Where("role = ? AND status = ?", "admin", "active").
+		Pluck("id", &adminIDs).Error; err != nil {
+		return
+	}
+
+	content := fmt.Sprintf("用户 %s（ID: %d）%s，IP: %s，城市: %s",
+		user.Username, user.ID, history.AnomalyReason, history.IP, history.City)
+	for _, adminID := range adminIDs {
+		_ = g.notifier.Publish(&notification.Event{
+			Type:       model.NotificationTypeSecurityAlert,
+			UserID:     adminID,
+			Title:      "异常登录告警",
+			Content:    content,
+			TargetType: "login_history",
+			TargetID:   &history.ID,
+		})
+	}
+}
+
+// countWithin 统计指定时间之前窗口内的记录数
+func countWithin(times []time.Time, now time.Time, window time.Duration) int {
+	count := 0
+	for _, t := range times {
+		if now.Sub(t) <= window {
+			count++
+		}
+	}
+	return count
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -66,6 +66,30 @@ func (s *PermissionService) RequireAdmin(userID uint) error {
 	return nil
 }
 
+// IsReviewer 检查用户是否为审核员（管理员或版主）
+func (s *PermissionService) IsReviewer(userID uint) (bool, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return false, err
+	}
+
+	return user.Role == "admin" || user.Role == "moderator", nil
+}
+
+// RequireReviewer 需要审核员权限
+func (s *PermissionService) RequireReviewer(userID uint) error {
+	isReviewer, err := s.IsReviewer(userID)
+	if err != nil {
+		return err
+	}
+
+	if !isReviewer {
+		return errors.New("需要审核员权限")
+	}
+
+	return nil
+}
+
 // RequireOwnerOrAdmin 需要所有者或管理员权限
 func (s *PermissionService) RequireOwnerOrAdmin(userID uint, ownerID uint) error {
 	isOwnerOrAdmin, err := s.IsOwnerOrAdmin(userID, ownerID)
@@ -0,0 +1,434 @@
+/*
+Package auth provides TOTP two-factor authentication services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/pkg/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrTwoFactorNotEnabled     = errors.New("未启用两步验证")
+	ErrTwoFactorAlreadyEnabled = errors.New("已启用两步验证")
+	ErrTwoFactorNotSetup       = errors.New("请先获取两步验证密钥")
+	ErrTwoFactorRequired       = errors.New("管理员账户必须启用两步验证")
+	ErrInvalidOTPCode          = errors.New("验证码错误")
+	ErrInvalidRecoveryCode     = errors.New("恢复码无效或已使用")
+	ErrInvalidChallenge        = errors.New("登录会话无效或已过期，请重新登录")
+)
+
+const (
+	// twoFactorChallengeTTL 两步验证登录会话有效期
+	twoFactorChallengeTTL = 5 * time.Minute
+
+	// totpSkew 允许的时钟偏差（时间步）
+	totpSkew = 1
+
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// TwoFactorSetup 两步验证配置信息
+type TwoFactorSetup struct {
+	Secret string `json:"secret"` // 密钥（可手动输入认证器App）
+	URI    string `json:"uri"`    // otpauth:// 配置URI（用于生成二维码）
+}
+
+// AuditContext 审计上下文（记录操作来源）
+type AuditContext struct {
+	ActorID   uint   // 操作人ID
+	IP        string // IP地址
+	UserAgent string // 用户代理
+}
+
+// TwoFactorService 两步验证服务
+type TwoFactorService struct {
+	db  *gorm.DB
+	cfg *config.AuthConfig
+}
+
+// NewTwoFactorService 创建两步验证服务
+func NewTwoFactorService(db *gorm.DB, cfg *config.AuthConfig) *TwoFactorService {
+	if cfg == nil {
+		cfg = config.DefaultAuthConfig()
+	}
+	return &TwoFactorService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Required 检查用户登录是否需要两步验证
+func (s *TwoFactorService) Required(user *model.User) bool {
+	return user.TwoFactorEnabled || s.Forced(user)
+}
+
+// Forced 检查用户是否被策略强制启用两步验证
+func (s *TwoFactorService) Forced(user *model.User) bool {
+	return s.cfg.ForceAdminTwoFactor && user.Role == "admin"
+}
+
+// BeginSetup 生成新的TOTP密钥（启用前需调用Enable完成验证）
+func (s *TwoFactorService) BeginSetup(userID uint) (*TwoFactorSetup, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+
+	// 覆盖之前未完成的配置
+	var tf model.UserTwoFactor
+	if err := s.db.Where("user_id = ?", userID).
+		Assign(map[string]interface{}{
+			"secret":         secret,
+			"enabled":        false,
+			"enabled_at":     nil,
+			"last_used_step": 0,
+		}).
+		FirstOrCreate(&tf, model.UserTwoFactor{UserID: userID}).Error; err != nil {
+		return nil, fmt.Errorf("保存两步验证配置失败: %w", err)
+	}
+
+	return &TwoFactorSetup{
+		Secret: secret,
+		URI:    utils.BuildTOTPURI(s.cfg.TwoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable 验证首个验证码并启用两步验证
+// 返回：
+//   - 恢复码（明文仅此一次返回）
+//   - 错误信息
+func (s *TwoFactorService) Enable(userID uint, code string) ([]string, error) {
+	var tf model.UserTwoFactor
+	if err := s.db.Where("user_id = ?", userID).First(&tf).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTwoFactorNotSetup
+		}
+		return nil, fmt.Errorf("查询两步验证配置失败: %w", err)
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTPCode(tf.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidOTPCode
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&tf).Updates(map[string]interface{}{
+			"enabled":        true,
+			"enabled_at":     now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			return fmt.Errorf("启用两步验证失败: %w", err)
+		}
+
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", userID).
+			Update("two_factor_enabled", true).Error; err != nil {
+			return fmt.Errorf("更新用户状态失败: %w", err)
+		}
+
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable 关闭两步验证（需要密码和当前验证码），并记录审计日志
+func (s *TwoFactorService) Disable(userID uint, password, code string, audit *AuditContext) error {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.Forced(&user) {
+		return ErrTwoFactorRequired
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errors.New("密码错误")
+	}
+	if err := s.VerifyCode(userID, code); err != nil {
+		return err
+	}
+
+	return s.disable(&user, "disable_2fa", audit)
+}
+
+// AdminReset 管理员为用户重置两步验证（如用户丢失设备），并记录审计日志
+func (s *TwoFactorService) AdminReset(userID uint, audit *AuditContext) error {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	return s.disable(&user, "reset_2fa", audit)
+}
+
+// VerifyCode 校验TOTP验证码（同一验证码不能重复使用）
+func (s *TwoFactorService) VerifyCode(userID uint, code string) error {
+	var tf model.UserTwoFactor
+	if err := s.db.Where("user_id = ? AND enabled = ?", userID, true).First(&tf).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnabled
+		}
+		return fmt.Errorf("查询两步验证配置失败: %w", err)
+	}
+
+	step, ok := utils.ValidateTOTPCode(tf.Secret, code, time.Now(), totpSkew)
+	if !ok || step <= tf.LastUsedStep {
+		return ErrInvalidOTPCode
+	}
+
+	// 条件更新防止并发重放
+	result := s.db.Model(&model.UserTwoFactor{}).
+		Where("id = ? AND last_used_step < ?", tf.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return fmt.Errorf("更新两步验证状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidOTPCode
+	}
+
+	return nil
+}
+
+// UseRecoveryCode 使用恢复码通过两步验证，并记录审计日志
+func (s *TwoFactorService) UseRecoveryCode(userID uint, code string, audit *AuditContext) error {
+	hash := hashToken(normalizeRecoveryCode(code))
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.RecoveryCode{}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("更新恢复码失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidRecoveryCode
+		}
+
+		var remaining int64
+		if err := tx.Model(&model.RecoveryCode{}).
+			Where("user_id = ? AND used_at IS NULL", userID).
+			Count(&remaining).Error; err != nil {
+			return fmt.Errorf("查询恢复码失败: %w", err)
+		}
+
+		return writeAdminLog(tx, userID, "use_recovery_code", "",
+			fmt.Sprintf(`{"remaining_codes": %d}`, remaining), audit)
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码（需要当前验证码）
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	if err := s.VerifyCode(userID, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// GetStatus 获取用户两步验证状态
+func (s *TwoFactorService) GetStatus(userID uint) (map[string]interface{}, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	var remaining int64
+	if err := s.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&remaining).Error; err != nil {
+		return nil, fmt.Errorf("查询恢复码失败: %w", err)
+	}
+
+	return map[string]interface{}{
+		"enabled":                  user.TwoFactorEnabled,
+		"required":                 s.Forced(&user),
+		"remaining_recovery_codes": remaining,
+	}, nil
+}
+
+// CreateChallenge 创建两步验证登录会话（密码验证通过后使用）
+func (s *TwoFactorService) CreateChallenge(userID uint) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("生成登录会话失败: %w", err)
+	}
+	value := base64.RawURLEncoding.EncodeToString(raw)
+
+	record := &model.UserToken{
+		UserID:    userID,
+		Purpose:   model.TokenPurposeTwoFactor,
+		TokenHash: hashToken(value),
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return "", fmt.Errorf("保存登录会话失败: %w", err)
+	}
+
+	return value, nil
+}
+
+// GetChallenge 获取有效的两步验证登录会话（不消费）
+func (s *TwoFactorService) GetChallenge(challenge string) (*model.UserToken, error) {
+	var record model.UserToken
+	if err := s.db.Where("token_hash = ? AND purpose = ?", hashToken(strings.TrimSpace(challenge)), model.TokenPurposeTwoFactor).
+		First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, fmt.Errorf("查询登录会话失败: %w", err)
+	}
+
+	if record.IsUsed() || record.IsExpired() {
+		return nil, ErrInvalidChallenge
+	}
+
+	return &record, nil
+}
+
+// ConsumeChallenge 消费两步验证登录会话（只能使用一次）
+func (s *TwoFactorService) ConsumeChallenge(record *model.UserToken) error {
+	result := s.db.Model(&model.UserToken{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("更新登录会话失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidChallenge
+	}
+	return nil
+}
+
+// disable 关闭两步验证并清理密钥和恢复码
+func (s *TwoFactorService) disable(user *model.User, action string, audit *AuditContext) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.UserTwoFactor{}).Error; err != nil {
+			return fmt.Errorf("删除两步验证配置失败: %w", err)
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("删除恢复码失败: %w", err)
+		}
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", user.ID).
+			Update("two_factor_enabled", false).Error; err != nil {
+			return fmt.Errorf("更新用户状态失败: %w", err)
+		}
+
+		return writeAdminLog(tx, user.ID, action,
+			`{"two_factor_enabled": true}`, `{"two_factor_enabled": false}`, audit)
+	})
+}
+
+// replaceRecoveryCodes 生成新的恢复码并替换旧的恢复码
+func (s *TwoFactorService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("删除旧恢复码失败: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]model.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := utils.GenerateRandomString(10)
+		if err != nil {
+			return nil, fmt.Errorf("生成恢复码失败: %w", err)
+		}
+		code := strings.ToLower(raw[:5] + "-" + raw[5:])
+		codes = append(codes, code)
+		records = append(records, model.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("保存恢复码失败: %w", err)
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode 规范化恢复码（忽略大小写、空格和连字符）
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// writeAdminLog 写入管理员审计日志
+func writeAdminLog(tx *gorm.DB, userID uint, action, before, after string, audit *AuditContext) error {
+	log := model.AdminLog{
+		AdminID:    userID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   userID,
+		BeforeData: before,
+		AfterData:  after,
+		CreatedAt:  time.Now(),
+	}
+	if audit != nil {
+		if audit.ActorID != 0 {
+			log.AdminID = audit.ActorID
+		}
+		log.IP = audit.IP
+		log.UserAgent = truncate(audit.UserAgent, 500)
+	}
+
+	if err := tx.Create(&log).Error; err != nil {
+		return fmt.Errorf("记录审计日志失败: %w", err)
+	}
+	return nil
+}
@@ -86,8 +86,14 @@ func (s *CategoryService) CreateCategory(name, description, icon, color string,
 		SortOrder:   sortOrder,
 	}
 
-	if err := s.db.Create(category).Error; err != nil {
-		return nil, fmt.Errorf("创建分类失败: %w", err)
+	// 创建分类并生成slug路径（slug冲突时需要分类ID，因此在创建后生成）
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(category).Error; err != nil {
+			return fmt.Errorf("创建分类失败: %w", err)
+		}
+		return updatePath(tx, category, true)
+	}); err != nil {
+		return nil, err
 	}
 
 	return category, nil
@@ -139,8 +145,18 @@ func (s *CategoryService) UpdateCategory(categoryID uint, name, description, ico
 		"updated_at":  time.Now(),
 	}
 
-	if err := s.db.Model(&category).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("更新分类失败: %w", err)
+	renamed := category.Name != name
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&category).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新分类失败: %w", err)
+		}
+		// 名称变化时重新生成slug，旧地址301跳转到新地址
+		if renamed || category.Path == "" {
+			return updatePath(tx, &category, true)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	return &category, nil
@@ -370,11 +386,14 @@ func (s *CategoryService) MoveCategory(categoryID uint, newParentID *uint) error
 		"updated_at": time.Now(),
 	}
 
-	if err := s.db.Model(&category).Updates(updates).Error; err != nil {
-		return fmt.Errorf("移动分类失败: %w", err)
-	}
-
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&category).Updates(updates).Error; err != nil {
+			return fmt.Errorf("移动分类失败: %w", err)
+		}
+		// 路径包含父分类路径，移动后重新生成（含子分类），旧地址301跳转到新地址
+		category.ParentID = newParentID
+		return updatePath(tx, &category, false)
+	})
 }
 
 // UpdateSortOrder 更新排序
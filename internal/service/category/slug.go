@@ -0,0 +1,168 @@
+/*
+Package category provides category URL slug and path maintenance.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package category
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/seo"
+
+	"gorm.io/gorm"
+)
+
+// GetCategoryByPath 根据slug路径获取分类
+// 参数：
+//   - path: 分类路径（如 ruan-jian/ban-gong）
+//
+// 返回：
+//   - 分类对象
+//   - 错误信息
+func (s *CategoryService) GetCategoryByPath(path string) (*model.Category, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, ErrCategoryNotFound
+	}
+
+	var category model.Category
+	if err := s.db.Where("path = ?", path).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("查询分类失败: %w", err)
+	}
+	return &category, nil
+}
+
+// BackfillSlugs 为尚未生成slug的分类生成slug和路径（可重复执行）
+// 返回：
+//   - 生成路径的分类数量
+//   - 错误信息
+func (s *CategoryService) BackfillSlugs() (int, error) {
+	updated := 0
+	for {
+		var pending []model.Category
+		if err := s.db.Where("path = '' OR path IS NULL").Order("id ASC").Find(&pending).Error; err != nil {
+			return updated, fmt.Errorf("查询分类失败: %w", err)
+		}
+		if len(pending) == 0 {
+			return updated, nil
+		}
+
+		waiting := make(map[uint]bool, len(pending))
+		for i := range pending {
+			waiting[pending[i].ID] = true
+		}
+
+		// 从上往下生成：父分类也在等待时留到下一轮（父分类生成路径时会同时更新子分类）
+		progress := false
+		for i := range pending {
+			category := &pending[i]
+			if category.ParentID != nil && waiting[*category.ParentID] {
+				continue
+			}
+			if err := s.db.Transaction(func(tx *gorm.DB) error {
+				return updatePath(tx, category, true)
+			}); err != nil {
+				return updated, err
+			}
+			updated++
+			progress = true
+		}
+		if !progress {
+			return updated, nil
+		}
+	}
+}
+
+// updatePath 重新生成分类的slug和路径，路径变化时同步子分类、规范URL和跳转规则
+// 参数：
+//   - tx: 事务
+//   - category: 分类（父级和名称为修改后的值）
+//   - rename: 是否按名称重新生成slug（否则保留原slug，仅在与新的同级分类重名时追加序号）
+func updatePath(tx *gorm.DB, category *model.Category, rename bool) error {
+	base := category.Slug
+	if rename || base == "" {
+		base = seo.Slugify(category.Name, seo.CategorySlugMaxLength)
+	}
+	slug, err := uniqueSlug(tx, category, base)
+	if err != nil {
+		return err
+	}
+
+	path := slug
+	if category.ParentID != nil {
+		var parent model.Category
+		err := tx.Select("id", "path").First(&parent, *category.ParentID).Error
+		switch {
+		case err == nil && parent.Path != "":
+			path = parent.Path + "/" + slug
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("查询父级分类失败: %w", err)
+		}
+	}
+	if slug == category.Slug && path == category.Path {
+		return nil
+	}
+
+	change := seo.PathChange{
+		TargetType: model.SEOConfigTypeCategory,
+		TargetID:   category.ID,
+		From:       seo.CategoryPath(category),
+		Redirect:   category.Path != "", // 首次生成路径时旧的ID地址由分类页直接跳转
+	}
+	if err := tx.Model(&model.Category{}).Where("id = ?", category.ID).
+		Updates(map[string]interface{}{"slug": slug, "path": path}).Error; err != nil {
+		return fmt.Errorf("更新分类路径失败: %w", err)
+	}
+	category.Slug, category.Path = slug, path
+	change.To = seo.CategoryPath(category)
+	if err := seo.RecordPathChange(tx, change); err != nil {
+		return err
+	}
+
+	// 子分类的路径包含父分类路径，逐级更新
+	var children []model.Category
+	if err := tx.Where("parent_id = ?", category.ID).Find(&children).Error; err != nil {
+		return fmt.Errorf("查询子分类失败: %w", err)
+	}
+	for i := range children {
+		if err := updatePath(tx, &children[i], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueSlug 生成同级分类中唯一的slug（重名时追加 -2、-3 …，名称无法转换时使用分类ID）
+func uniqueSlug(tx *gorm.DB, category *model.Category, base string) (string, error) {
+	if base == "" {
+		base = fmt.Sprintf("category-%d", category.ID)
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		query := tx.Model(&model.Category{}).Where("slug = ? AND id <> ?", slug, category.ID)
+		if category.ParentID != nil {
+			query = query.Where("parent_id = ?", *category.ParentID)
+		} else {
+			query = query.Where("parent_id IS NULL")
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return "", fmt.Errorf("检查分类slug失败: %w", err)
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Package comment provides resource comment services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// CommentService 资源评论服务（评论提交经过评论审核流程）
+type CommentService struct {
+	db        *gorm.DB
+	moderator *ModerationService
+}
+
+// NewCommentService 创建资源评论服务
+func NewCommentService(db *gorm.DB) *CommentService {
+	return &CommentService{
+		db:        db,
+		moderator: NewModerationService(db),
+	}
+}
+
+// SetModerator 设置评论审核服务（与文章评论共用同一审核流程）
+func (s *CommentService) SetModerator(moderator *ModerationService) {
+	if moderator != nil {
+		s.moderator = moderator
+	}
+}
+
+// CreateComment 发表资源评论
+// 参数：
+//   - userID: 评论者ID
+//   - resourceID: 资源ID
+//   - content: 评论内容
+//   - parentID: 回复的评论ID（可选）
+//
+// 返回：
+//   - 评论（状态为自动审核结果）
+//   - 错误信息
+func (s *CommentService) CreateComment(userID, resourceID uint, content string, parentID *uint) (*model.Comment, error) {
+	var resource model.Resource
+	if err := s.db.Select("id").First(&resource, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTargetNotFound
+		}
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+
+	// 父评论必须存在且属于同一资源
+	if parentID != nil {
+		var parent model.Comment
+		if err := s.db.Select("id", "resource_id").First(&parent, *parentID).Error; err != nil || parent.ResourceID != resourceID {
+			return nil, ErrInvalidParent
+		}
+	}
+
+	verdict, err := s.moderator.Screen(userID, content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &model.Comment{
+		ResourceID:  resourceID,
+		UserID:      userID,
+		Content:     verdict.Content,
+		ParentID:    parentID,
+		Status:      verdict.Status,
+		ReviewNotes: verdict.Notes,
+	}
+	if verdict.Status == model.CommentStatusApproved {
+		now := time.Now()
+		comment.ReviewedAt = &now
+	}
+
+	if err := s.db.Create(comment).Error; err != nil {
+		return nil, fmt.Errorf("创建评论失败: %w", err)
+	}
+
+	if comment.Status == model.CommentStatusApproved {
+		s.moderator.OnApproved(CommentRef{Type: CommentTypeResource, ID: comment.ID})
+	}
+
+	return comment, nil
+}
+
+// GetComment 获取评论详情
+func (s *CommentService) GetComment(id uint) (*model.Comment, error) {
+	var comment model.Comment
+	if err := s.db.Preload("User").First(&comment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("查询评论失败: %w", err)
+	}
+	return &comment, nil
+}
+
+// GetCommentTree 获取资源下已通过审核的评论树（按顶层评论分页）
+// 参数：
+//   - resourceID: 资源ID
+//   - page: 页码
+//   - pageSize: 每页顶层评论数量
+//
+// 返回：
+//   - 顶层评论列表（回复在 Replies 中）
+//   - 顶层评论总数
+//   - 错误信息
+func (s *CommentService) GetCommentTree(resourceID uint, page, pageSize int) ([]model.Comment, int64, error) {
+	var comments []model.Comment
+	if err := s.db.Preload("User").
+		Where("resource_id = ? AND status = ?", resourceID, model.CommentStatusApproved).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询评论失败: %w", err)
+	}
+
+	roots, total := BuildTree(comments, page, pageSize,
+		func(c *model.Comment) uint { return c.ID },
+		func(c *model.Comment) *uint { return c.ParentID },
+		func(c *model.Comment, replies []model.Comment) { c.Replies = replies },
+	)
+	return roots, total, nil
+}
+
+// DeleteComment 删除评论（评论作者、管理员或版主）
+func (s *CommentService) DeleteComment(id, userID uint) error {
+	comment, err := s.GetComment(id)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		if err := s.moderator.checkReviewer(userID); err != nil {
+			return ErrPermissionDenied
+		}
+	}
+
+	if err := s.db.Delete(&model.Comment{}, id).Error; err != nil {
+		return fmt.Errorf("删除评论失败: %w", err)
+	}
+	return nil
+}
+
+// BuildTree 将按时间排序的评论组装为评论树并按顶层评论分页（父评论不可见的回复不展示）
+// 参数：
+//   - items: 评论列表
+//   - page: 页码
+//   - pageSize: 每页顶层评论数量
+//   - id/parent/setReplies: 读取ID、父评论ID和设置回复的方法
+//
+// 返回：
+//   - 顶层评论列表
+//   - 顶层评论总数
+func BuildTree[T any](items []T, page, pageSize int, id func(*T) uint, parent func(*T) *uint, setReplies func(*T, []T)) ([]T, int64) {
+	children := make(map[uint][]int)
+	var roots []int
+	for i := range items {
+		if parentID := parent(&items[i]); parentID != nil {
+			children[*parentID] = append(children[*parentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	total := int64(len(roots))
+	offset := (page - 1) * pageSize
+	if offset >= len(roots) {
+		return []T{}, total
+	}
+	end := offset + pageSize
+	if end > len(roots) {
+		end = len(roots)
+	}
+
+	var build func(index int) T
+	build = func(index int) T {
+		node := items[index]
+		var replies []T
+		for _, child := range children[id(&node)] {
+			replies = append(replies, build(child))
+		}
+		setReplies(&node, replies)
+		return node
+	}
+
+	result := make([]T, 0, end-offset)
+	for _, index := range roots[offset:end] {
+		result = append(result, build(index))
+	}
+	return result, total
+}
@@ -0,0 +1,464 @@
+/*
+Package comment provides the moderation pipeline shared by resource comments and article comments.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/notification"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrCommentNotFound     = errors.New("评论不存在")
+	ErrTargetNotFound      = errors.New("评论对象不存在")
+	ErrInvalidParent       = errors.New("父评论不存在或不属于同一对象")
+	ErrInvalidCommentType  = errors.New("无效的评论类型")
+	ErrInvalidModeration   = errors.New("无效的审核操作")
+	ErrAlreadyModerated    = errors.New("评论已是该状态")
+	ErrPermissionDenied    = errors.New("没有权限")
+	ErrReviewPermission    = errors.New("没有审核权限")
+	ErrEmptyCommentContent = errors.New("评论内容不能为空")
+)
+
+// CommentType 评论类型
+type CommentType string
+
+const (
+	CommentTypeResource CommentType = "resource" // 资源评论
+	CommentTypeArticle  CommentType = "article"  // 文章评论
+)
+
+// CommentRef 评论引用（类型+ID）
+type CommentRef struct {
+	Type CommentType `json:"type" binding:"required"`
+	ID   uint        `json:"id" binding:"required"`
+}
+
+// Verdict 评论提交时的自动审核结果
+type Verdict struct {
+	Status  model.CommentStatus `json:"status"`
+	Notes   string              `json:"notes"`
+	Content string              `json:"content"`           // 低级别敏感词替换为*后的评论内容
+	Matches []string            `json:"matches,omitempty"` // 命中的敏感词
+}
+
+// PendingComment 待审核评论（资源评论和文章评论统一展示）
+type PendingComment struct {
+	Type        CommentType `json:"type"`
+	ID          uint        `json:"id"`
+	Content     string      `json:"content"`
+	ReviewNotes string      `json:"review_notes"` // 自动审核备注（如命中的敏感词）
+	ParentID    *uint       `json:"parent_id"`
+	TargetID    uint        `json:"target_id"` // 资源ID或文章ID
+	TargetTitle string      `json:"target_title"`
+	UserID      uint        `json:"user_id"`
+	Username    string      `json:"username"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// ModerationService 评论审核服务
+type ModerationService struct {
+	db       *gorm.DB
+	cfg      *config.CommentConfig
+	dict     *filter.Dictionary
+	notifier notification.Publisher
+}
+
+// NewModerationService 创建评论审核服务
+func NewModerationService(db *gorm.DB) *ModerationService {
+	return &ModerationService{
+		db:   db,
+		cfg:  config.DefaultCommentConfig(),
+		dict: filter.NewDictionary(db, nil),
+	}
+}
+
+// SetConfig 设置评论审核配置
+func (s *ModerationService) SetConfig(cfg *config.CommentConfig) {
+	if cfg != nil {
+		s.cfg = cfg
+	}
+}
+
+// SetDictionary 设置敏感词库（多个服务共享同一词库实例）
+func (s *ModerationService) SetDictionary(dict *filter.Dictionary) {
+	if dict != nil {
+		s.dict = dict
+	}
+}
+
+// SetNotifier 设置通知发布器（回复通过审核后通知原评论作者）
+func (s *ModerationService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// Screen 评论提交时的自动审核：命中违禁词直接拒绝，命中需审核的敏感词转人工审核，
+// 低级别敏感词替换为*，可信用户自动通过，其余待审核
+// 参数：
+//   - userID: 评论者ID
+//   - content: 评论内容
+//
+// 返回：
+//   - 审核结果
+//   - 错误信息（命中违禁词时为 filter.ErrBlocked）
+func (s *ModerationService) Screen(userID uint, content string) (*Verdict, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyCommentContent
+	}
+
+	result := s.dict.Check(content)
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	if result.NeedsReview() {
+		return &Verdict{
+			Status:  model.CommentStatusPending,
+			Notes:   result.Notes(),
+			Content: result.Text,
+			Matches: result.Words(),
+		}, nil
+	}
+
+	if s.cfg.AutoApproveTrusted {
+		trusted, err := s.IsTrustedUser(userID)
+		if err != nil {
+			return nil, err
+		}
+		if trusted {
+			return &Verdict{Status: model.CommentStatusApproved, Notes: "可信用户自动通过", Content: result.Text, Matches: result.Words()}, nil
+		}
+	}
+
+	return &Verdict{Status: model.CommentStatusPending, Content: result.Text, Matches: result.Words()}, nil
+}
+
+// IsTrustedUser 检查是否为可信用户（管理员、版主，或历史评论通过率达到阈值）
+func (s *ModerationService) IsTrustedUser(userID uint) (bool, error) {
+	var user model.User
+	if err := s.db.Select("id", "role", "status").First(&user, userID).Error; err != nil {
+		return false, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.Status != "active" {
+		return false, nil
+	}
+	if user.Role == "admin" || user.Role == "moderator" {
+		return true, nil
+	}
+
+	var approved, rejected int64
+	for _, table := range []string{"comments", "article_comments"} {
+		var rows []struct {
+			Status model.CommentStatus
+			Count  int64
+		}
+		if err := s.db.Table(table).
+			Select("status, COUNT(*) AS count").
+			Where("user_id = ? AND deleted_at IS NULL", userID).
+			Where("status IN ?", []model.CommentStatus{model.CommentStatusApproved, model.CommentStatusRejected}).
+			Group("status").
+			Scan(&rows).Error; err != nil {
+			return false, fmt.Errorf("统计用户评论失败: %w", err)
+		}
+		for _, row := range rows {
+			if row.Status == model.CommentStatusApproved {
+				approved += row.Count
+			} else {
+				rejected += row.Count
+			}
+		}
+	}
+
+	if approved < int64(s.cfg.TrustedMinApproved) {
+		return false, nil
+	}
+	return float64(approved)/float64(approved+rejected) >= s.cfg.TrustedMinRatio, nil
+}
+
+// GetPendingQueue 获取待审核评论队列（按提交时间先后排序）
+// 参数：
+//   - commentType: 评论类型筛选（为空时包含全部类型）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 待审核评论列表
+//   - 总数
+//   - 错误信息
+func (s *ModerationService) GetPendingQueue(commentType CommentType, page, pageSize int) ([]PendingComment, int64, error) {
+	var types []CommentType
+	switch commentType {
+	case "":
+		types = []CommentType{CommentTypeResource, CommentTypeArticle}
+	case CommentTypeResource, CommentTypeArticle:
+		types = []CommentType{commentType}
+	default:
+		return nil, 0, ErrInvalidCommentType
+	}
+
+	offset := (page - 1) * pageSize
+	var total int64
+	var merged []PendingComment
+
+	// 每种类型取前 offset+pageSize 条，合并后再分页
+	for _, t := range types {
+		var count int64
+		if err := s.pendingQuery(t).Count(&count).Error; err != nil {
+			return nil, 0, fmt.Errorf("查询待审核评论总数失败: %w", err)
+		}
+		total += count
+
+		var items []PendingComment
+		if err := s.pendingQuery(t).
+			Select(pendingColumns(t)).
+			Order("c.created_at ASC, c.id ASC").
+			Limit(offset + pageSize).
+			Scan(&items).Error; err != nil {
+			return nil, 0, fmt.Errorf("查询待审核评论失败: %w", err)
+		}
+		for i := range items {
+			items[i].Type = t
+		}
+		merged = append(merged, items...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.Before(merged[j].CreatedAt)
+	})
+
+	if offset >= len(merged) {
+		return []PendingComment{}, total, nil
+	}
+	end := offset + pageSize
+	if end > len(merged) {
+		end = len(merged)
+	}
+
+	return merged[offset:end], total, nil
+}
+
+// Moderate 审核单条评论
+// 参数：
+//   - ref: 评论引用
+//   - reviewerID: 审核者ID
+//   - status: 目标状态（通过/拒绝）
+//   - notes: 审核备注
+//
+// 返回：
+//   - 错误信息
+func (s *ModerationService) Moderate(ref CommentRef, reviewerID uint, status model.CommentStatus, notes string) error {
+	if err := s.checkReviewer(reviewerID); err != nil {
+		return err
+	}
+	return s.moderate(ref, reviewerID, status, notes)
+}
+
+// BulkModerate 批量审核评论（资源评论和文章评论可混合）
+// 参数：
+//   - refs: 评论引用列表
+//   - reviewerID: 审核者ID
+//   - status: 目标状态（通过/拒绝）
+//   - notes: 审核备注
+//
+// 返回：
+//   - 成功的数量
+//   - 失败的评论列表
+//   - 错误信息
+func (s *ModerationService) BulkModerate(refs []CommentRef, reviewerID uint, status model.CommentStatus, notes string) (int, []CommentRef, error) {
+	if err := s.checkReviewer(reviewerID); err != nil {
+		return 0, nil, err
+	}
+
+	successCount := 0
+	failed := []CommentRef{}
+	for _, ref := range refs {
+		if err := s.moderate(ref, reviewerID, status, notes); err != nil {
+			failed = append(failed, ref)
+			continue
+		}
+		successCount++
+	}
+
+	return successCount, failed, nil
+}
+
+// OnApproved 评论通过审核后的处理（更新文章评论数、通知被回复的评论作者）
+func (s *ModerationService) OnApproved(ref CommentRef) {
+	switch ref.Type {
+	case CommentTypeResource:
+		var comment model.Comment
+		if err := s.db.First(&comment, ref.ID).Error; err != nil || comment.ParentID == nil {
+			return
+		}
+		var parent model.Comment
+		if err := s.db.First(&parent, *comment.ParentID).Error; err != nil {
+			return
+		}
+		s.notifyReply(parent.UserID, comment.UserID, "有用户回复了您在资源下的评论",
+			fmt.Sprintf("/resource/%d", comment.ResourceID), "comment", comment.ID)
+
+	case CommentTypeArticle:
+		var comment model.ArticleComment
+		if err := s.db.First(&comment, ref.ID).Error; err != nil {
+			return
+		}
+		_ = s.db.Model(&model.Article{}).
+			Where("id = ?", comment.ArticleID).
+			UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error
+
+		if comment.ParentID == nil {
+			return
+		}
+		var parent model.ArticleComment
+		if err := s.db.First(&parent, *comment.ParentID).Error; err != nil {
+			return
+		}
+		var article model.Article
+		if err := s.db.Select("id", "title", "slug").First(&article, comment.ArticleID).Error; err != nil {
+			return
+		}
+		s.notifyReply(parent.UserID, comment.UserID, fmt.Sprintf("您在文章《%s》下的评论收到了新回复", article.Title),
+			fmt.Sprintf("/article/%s", article.Slug), "article_comment", comment.ID)
+	}
+}
+
+// moderate 更新评论审核状态（文章评论同步维护评论数）
+func (s *ModerationService) moderate(ref CommentRef, reviewerID uint, status model.CommentStatus, notes string) error {
+	if status != model.CommentStatusApproved && status != model.CommentStatusRejected {
+		return ErrInvalidModeration
+	}
+
+	var table string
+	var oldStatus model.CommentStatus
+	var articleID uint
+
+	switch ref.Type {
+	case CommentTypeResource:
+		var comment model.Comment
+		if err := s.db.First(&comment, ref.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCommentNotFound
+			}
+			return fmt.Errorf("查询评论失败: %w", err)
+		}
+		table, oldStatus = "comments", comment.Status
+	case CommentTypeArticle:
+		var comment model.ArticleComment
+		if err := s.db.First(&comment, ref.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCommentNotFound
+			}
+			return fmt.Errorf("查询评论失败: %w", err)
+		}
+		table, oldStatus, articleID = "article_comments", comment.Status, comment.ArticleID
+	default:
+		return ErrInvalidCommentType
+	}
+
+	if oldStatus == status {
+		return ErrAlreadyModerated
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 以原状态为条件更新，避免并发审核重复计数
+		result := tx.Table(table).
+			Where("id = ? AND status = ?", ref.ID, oldStatus).
+			Updates(map[string]interface{}{
+				"status":         status,
+				"reviewed_by_id": reviewerID,
+				"reviewed_at":    time.Now(),
+				"review_notes":   notes,
+				"updated_at":     time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("更新评论状态失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyModerated
+		}
+
+		// 已通过的文章评论被拒绝时扣减评论数
+		if ref.Type == CommentTypeArticle && oldStatus == model.CommentStatusApproved {
+			if err := tx.Model(&model.Article{}).
+				Where("id = ? AND comment_count > 0", articleID).
+				UpdateColumn("comment_count", gorm.Expr("comment_count - 1")).Error; err != nil {
+				return fmt.Errorf("更新文章评论数失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if status == model.CommentStatusApproved {
+		s.OnApproved(ref)
+	}
+	return nil
+}
+
+// pendingQuery 构建指定类型的待审核评论查询
+func (s *ModerationService) pendingQuery(t CommentType) *gorm.DB {
+	if t == CommentTypeArticle {
+		return s.db.Table("article_comments AS c").
+			Joins("LEFT JOIN articles t ON t.id = c.article_id").
+			Joins("LEFT JOIN users u ON u.id = c.user_id").
+			Where("c.status = ? AND c.deleted_at IS NULL", model.CommentStatusPending)
+	}
+	return s.db.Table("comments AS c").
+		Joins("LEFT JOIN resources t ON t.id = c.resource_id").
+		Joins("LEFT JOIN users u ON u.id = c.user_id").
+		Where("c.status = ? AND c.deleted_at IS NULL", model.CommentStatusPending)
+}
+
+// pendingColumns 待审核评论查询的字段
+func pendingColumns(t CommentType) string {
+	targetColumn := "c.resource_id"
+	if t == CommentTypeArticle {
+		targetColumn = "c.article_id"
+	}
+	return "c.id, c.content, c.review_notes, c.parent_id, " + targetColumn +
+		" AS target_id, t.title AS target_title, c.user_id, u.username, c.created_at"
+}
+
+// checkReviewer 检查审核者权限（管理员或版主）
+func (s *ModerationService) checkReviewer(reviewerID uint) error {
+	var reviewer model.User
+	if err := s.db.Select("id", "role").First(&reviewer, reviewerID).Error; err != nil {
+		return fmt.Errorf("查询审核者失败: %w", err)
+	}
+	if reviewer.Role != "admin" && reviewer.Role != "moderator" {
+		return ErrReviewPermission
+	}
+	return nil
+}
+
+// notifyReply 通知被回复的评论作者（不通知自己，通知失败不影响审核）
+func (s *ModerationService) notifyReply(toUserID, fromUserID uint, content, link, targetType string, commentID uint) {
+	if s.notifier == nil || toUserID == fromUserID {
+		return
+	}
+	_ = s.notifier.Publish(&notification.Event{
+		Type:       model.NotificationTypeCommentReply,
+		UserID:     toUserID,
+		Title:      "您的评论收到了新回复",
+		Content:    content,
+		Link:       link,
+		TargetType: targetType,
+		TargetID:   &commentID,
+	})
+}
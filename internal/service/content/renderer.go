@@ -0,0 +1,403 @@
+/*
+Package content provides the Markdown rendering pipeline for articles and resource descriptions.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package content
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/service/seo"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	xhtml "golang.org/x/net/html"
+)
+
+// Kind 正文类型
+type Kind string
+
+const (
+	KindArticle  Kind = "article"  // 文章正文（标准CommonMark，单个换行不分行）
+	KindResource Kind = "resource" // 资源简介（单个换行保留为换行，兼容以纯文本填写的简介）
+)
+
+// headingIDMaxLength 标题锚点的最大长度
+const headingIDMaxLength = 60
+
+// TOCItem 目录项
+type TOCItem struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	ID    string `json:"id"`
+}
+
+// Rendered 渲染结果
+type Rendered struct {
+	HTML        string    `json:"html"`         // 清理后的HTML
+	TOC         []TOCItem `json:"toc"`          // 标题目录
+	Excerpt     string    `json:"excerpt"`      // 纯文本摘要（不含标题和代码块）
+	ReadingTime int       `json:"reading_time"` // 预计阅读时间（分钟）
+}
+
+// cacheKey 渲染缓存键
+type cacheKey struct {
+	kind Kind
+	id   uint
+}
+
+// cacheEntry 渲染缓存项（源内容摘要变化时视为失效）
+type cacheEntry struct {
+	key      cacheKey
+	digest   [sha256.Size]byte
+	rendered *Rendered
+}
+
+// Renderer Markdown渲染器（渲染结果按对象缓存，最近最少使用的先淘汰）
+type Renderer struct {
+	cfg      *config.ContentConfig
+	markdown map[Kind]goldmark.Markdown
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	order   *list.List
+
+	cssOnce sync.Once
+	css     string
+}
+
+// NewRenderer 创建Markdown渲染器，cfg 为nil时使用默认配置
+func NewRenderer(cfg *config.ContentConfig) *Renderer {
+	if cfg == nil {
+		cfg = config.DefaultContentConfig()
+	}
+	return &Renderer{
+		cfg: cfg,
+		markdown: map[Kind]goldmark.Markdown{
+			KindArticle:  newMarkdown(cfg.HighlightStyle),
+			KindResource: newMarkdown(cfg.HighlightStyle, goldmarkhtml.WithHardWraps()),
+		},
+		entries: make(map[cacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// newMarkdown 创建 CommonMark + GFM（表格、任务列表、删除线、自动链接）解析器，代码块在服务端高亮
+// 原始HTML原样输出，由渲染后的白名单清理负责安全
+func newMarkdown(style string, rendererOptions ...renderer.Option) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+			extension.Strikethrough,
+			extension.Linkify,
+			extension.TaskList,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(style),
+				highlighting.WithFormatOptions(chromahtml.WithClasses(true)),
+			),
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(append(rendererOptions, goldmarkhtml.WithUnsafe())...),
+	)
+}
+
+// Render 渲染对象的正文（按对象缓存，正文变化后自动重新渲染）
+// 参数：
+//   - kind: 正文类型
+//   - id: 对象ID，为0时不缓存
+//   - source: Markdown源文本
+//
+// 返回：
+//   - 渲染结果（调用方不应修改）
+//   - 错误信息
+func (r *Renderer) Render(kind Kind, id uint, source string) (*Rendered, error) {
+	if id == 0 {
+		return r.RenderSource(kind, source)
+	}
+
+	key := cacheKey{kind: kind, id: id}
+	digest := sha256.Sum256([]byte(source))
+	if rendered := r.lookup(key, digest); rendered != nil {
+		return rendered, nil
+	}
+
+	rendered, err := r.RenderSource(kind, source)
+	if err != nil {
+		return nil, err
+	}
+	r.store(key, digest, rendered)
+	return rendered, nil
+}
+
+// RenderSource 渲染Markdown源文本（不缓存）
+func (r *Renderer) RenderSource(kind Kind, source string) (*Rendered, error) {
+	md, ok := r.markdown[kind]
+	if !ok {
+		md = r.markdown[KindArticle]
+	}
+
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
+		return nil, fmt.Errorf("渲染正文失败: %w", err)
+	}
+
+	rendered := &Rendered{
+		HTML: Sanitize(buf.String(), PagePolicy, ""),
+		TOC:  r.tableOfContents(doc, src),
+	}
+	excerpt, all := plainText(rendered.HTML)
+	rendered.Excerpt = truncate(excerpt, r.cfg.GetExcerptLength())
+	rendered.ReadingTime = r.readingTime(all)
+	return rendered, nil
+}
+
+// Invalidate 删除对象的渲染缓存
+func (r *Renderer) Invalidate(kind Kind, id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := cacheKey{kind: kind, id: id}
+	if elem, ok := r.entries[key]; ok {
+		r.order.Remove(elem)
+		delete(r.entries, key)
+	}
+}
+
+// HighlightCSS 代码高亮样式表（与配置的高亮主题对应）
+func (r *Renderer) HighlightCSS() string {
+	r.cssOnce.Do(func() {
+		var buf bytes.Buffer
+		formatter := chromahtml.New(chromahtml.WithClasses(true))
+		if err := formatter.WriteCSS(&buf, styles.Get(r.cfg.HighlightStyle)); err == nil {
+			r.css = buf.String()
+		}
+	})
+	return r.css
+}
+
+// lookup 查找未失效的缓存项
+func (r *Renderer) lookup(key cacheKey, digest [sha256.Size]byte) *Rendered {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	if entry.digest != digest {
+		r.order.Remove(elem)
+		delete(r.entries, key)
+		return nil
+	}
+	r.order.MoveToFront(elem)
+	return entry.rendered
+}
+
+// store 保存缓存项，超出容量时淘汰最近最少使用的项
+func (r *Renderer) store(key cacheKey, digest [sha256.Size]byte, rendered *Rendered) {
+	if r.cfg.CacheSize <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if elem, ok := r.entries[key]; ok {
+		r.order.Remove(elem)
+	}
+	r.entries[key] = r.order.PushFront(&cacheEntry{key: key, digest: digest, rendered: rendered})
+	for r.order.Len() > r.cfg.CacheSize {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// tableOfContents 根据标题生成目录
+func (r *Renderer) tableOfContents(doc ast.Node, src []byte) []TOCItem {
+	minLevel, maxLevel := r.cfg.GetTOCLevels()
+	toc := []TOCItem{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level < minLevel || heading.Level > maxLevel {
+			return ast.WalkSkipChildren, nil
+		}
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		title := strings.TrimSpace(nodeText(heading, src))
+		if title != "" && len(idBytes) > 0 {
+			toc = append(toc, TOCItem{Level: heading.Level, Text: title, ID: string(idBytes)})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return toc
+}
+
+// readingTime 估算阅读时间（分钟，有内容时至少1分钟）
+func (r *Renderer) readingTime(content string) int {
+	cjk, words := 0, 0
+	inWord := false
+	for _, ch := range content {
+		switch {
+		case unicode.In(ch, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(ch) || unicode.IsDigit(ch):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	if cjk == 0 && words == 0 {
+		return 0
+	}
+
+	cjkSpeed, wordSpeed := r.cfg.GetReadingSpeed()
+	minutes := float64(cjk)/float64(cjkSpeed) + float64(words)/float64(wordSpeed)
+	return int(math.Max(1, math.Ceil(minutes)))
+}
+
+// headingIDs 标题锚点生成器（汉字转拼音，同一文档内重复的锚点追加序号）
+type headingIDs struct {
+	used map[string]bool
+}
+
+// newHeadingIDs 创建标题锚点生成器（每次解析使用新的实例）
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: make(map[string]bool)}
+}
+
+// Generate 生成标题锚点
+func (h *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := seo.Slugify(string(value), headingIDMaxLength)
+	if base == "" {
+		base = "section"
+	}
+	id := base
+	for i := 1; h.used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	h.used[id] = true
+	return []byte(id)
+}
+
+// Put 记录已使用的锚点（如手动指定的锚点）
+func (h *headingIDs) Put(value []byte) {
+	h.used[string(value)] = true
+}
+
+// nodeText 提取节点内的纯文本
+func nodeText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			b.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// plainText 从清理后的HTML提取纯文本：摘要文本（不含标题、代码块和表格）和全部文本
+func plainText(source string) (excerpt, all string) {
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(source))
+	var summary, full strings.Builder
+	skipDepth := 0
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == xhtml.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		switch tokenType {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if excludedFromExcerpt(token.Data) && tokenType == xhtml.StartTagToken {
+				skipDepth++
+			}
+			if token.Data == "br" {
+				summary.WriteByte(' ')
+				full.WriteByte(' ')
+			}
+		case xhtml.EndTagToken:
+			if excludedFromExcerpt(token.Data) && skipDepth > 0 {
+				skipDepth--
+			}
+			// 块级元素之间补空格，避免相邻段落的文字连在一起
+			if blockTags[token.Data] {
+				summary.WriteByte(' ')
+				full.WriteByte(' ')
+			}
+		case xhtml.TextToken:
+			full.WriteString(token.Data)
+			if skipDepth == 0 {
+				summary.WriteString(token.Data)
+			}
+		}
+	}
+	return strings.Join(strings.Fields(summary.String()), " "), full.String()
+}
+
+// blockTags 提取纯文本时按块分隔的元素
+var blockTags = map[string]bool{
+	"p": true, "div": true, "li": true, "dt": true, "dd": true, "blockquote": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"tr": true, "th": true, "td": true, "figcaption": true,
+}
+
+// excludedFromExcerpt 摘要不包含的元素
+func excludedFromExcerpt(tag string) bool {
+	switch tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6", "pre", "table", "figcaption":
+		return true
+	}
+	return false
+}
+
+// truncate 按字数截断文本
+func truncate(s string, maxRunes int) string {
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:maxRunes-1])) + "…"
+}
@@ -0,0 +1,245 @@
+/*
+Package content provides HTML sanitization for rendered content.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package content
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// Policy 允许保留的标签及其属性（白名单之外的标签只保留文本内容）
+type Policy map[string][]string
+
+// FeedPolicy 订阅源内容允许保留的标签（不保留锚点、样式类和表单元素）
+var FeedPolicy = Policy{
+	"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "del": nil, "sub": nil, "sup": nil,
+	"ul": nil, "ol": nil, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"blockquote": nil, "pre": nil, "code": {"class"},
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+	"figure": nil, "figcaption": nil,
+	"a":   {"href", "title"},
+	"img": {"src", "alt", "title", "width", "height"},
+}
+
+// PagePolicy 站内页面正文允许保留的标签（标题锚点、代码高亮样式类、任务列表复选框和表格对齐）
+var PagePolicy = Policy{
+	"p": nil, "br": nil, "hr": nil, "div": {"class"}, "span": {"class"},
+	"h1": {"id"}, "h2": {"id"}, "h3": {"id"}, "h4": {"id"}, "h5": {"id"}, "h6": {"id"},
+	"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "del": nil, "sub": nil, "sup": nil,
+	"mark": nil, "kbd": nil, "abbr": {"title"},
+	"ul": {"class"}, "ol": {"start"}, "li": {"class"}, "dl": nil, "dt": nil, "dd": nil,
+	"blockquote": nil, "pre": {"class"}, "code": {"class"},
+	"table": nil, "thead": nil, "tbody": nil, "tr": nil,
+	"th": {"colspan", "rowspan", "align"}, "td": {"colspan", "rowspan", "align"},
+	"figure": nil, "figcaption": nil, "details": nil, "summary": nil,
+	"input": {"type", "checked", "disabled"},
+	"a":     {"href", "title"},
+	"img":   {"src", "alt", "title", "width", "height"},
+}
+
+// droppedTags 连同内容一起删除的标签
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "form": true, "svg": true, "math": true,
+	"textarea": true, "select": true,
+}
+
+// voidTags 没有结束标签的元素
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "input": true}
+
+var (
+	tokenPattern  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)    // id 属性允许的值
+	classPattern  = regexp.MustCompile(`^[A-Za-z0-9_ -]+$`)   // class 属性允许的值
+	numberPattern = regexp.MustCompile(`^[0-9]{1,4}(%|px)?$`) // 尺寸、序号等数值属性允许的值
+)
+
+// Sanitize 按白名单清理HTML：只保留允许的标签和属性，删除脚本等内容，
+// 链接和图片只允许 http、https（链接另允许 mailto）和站内地址，baseURL 不为空时补全为绝对地址，未闭合的标签自动闭合
+func Sanitize(source string, policy Policy, baseURL string) string {
+	var base *url.URL
+	if baseURL != "" {
+		base, _ = url.Parse(strings.TrimRight(baseURL, "/") + "/")
+	}
+
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(source))
+	var out strings.Builder
+	var open []string
+	skipping, skipDepth := "", 0
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == xhtml.ErrorToken {
+			break // io.EOF 或无法继续解析
+		}
+		token := tokenizer.Token()
+
+		// 删除脚本等标签内的全部内容
+		if skipping != "" {
+			switch {
+			case tokenType == xhtml.StartTagToken && token.Data == skipping:
+				skipDepth++
+			case tokenType == xhtml.EndTagToken && token.Data == skipping:
+				skipDepth--
+				if skipDepth == 0 {
+					skipping = ""
+				}
+			}
+			continue
+		}
+
+		switch tokenType {
+		case xhtml.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if droppedTags[token.Data] {
+				if tokenType == xhtml.StartTagToken && !voidTags[token.Data] {
+					skipping, skipDepth = token.Data, 1
+				}
+				continue
+			}
+			attrs, ok := policy[token.Data]
+			if !ok {
+				continue
+			}
+			if token.Data == "img" && sanitizeURL(attrValue(token, "src"), base, false) == "" {
+				continue
+			}
+			// 只保留任务列表的复选框
+			if token.Data == "input" && attrValue(token, "type") != "checkbox" {
+				continue
+			}
+			writeStartTag(&out, token, attrs, base)
+			if !voidTags[token.Data] && tokenType == xhtml.StartTagToken {
+				open = append(open, token.Data)
+			}
+		case xhtml.EndTagToken:
+			// 只输出已打开的标签，并闭合其中未闭合的子标签
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// writeStartTag 输出只包含允许属性的开始标签
+func writeStartTag(out *strings.Builder, token xhtml.Token, allowed []string, base *url.URL) {
+	out.WriteString("<" + token.Data)
+	for _, attr := range token.Attr {
+		if !containsString(allowed, attr.Key) {
+			continue
+		}
+		value, ok := sanitizeAttr(token.Data, attr.Key, attr.Val, base)
+		if !ok {
+			continue
+		}
+		if value == "" && (attr.Key == "checked" || attr.Key == "disabled") {
+			out.WriteString(" " + attr.Key)
+			continue
+		}
+		out.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+	}
+	if token.Data == "a" {
+		out.WriteString(` rel="nofollow noopener"`)
+	}
+	out.WriteString(">")
+}
+
+// sanitizeAttr 检查属性值（返回清理后的值和是否保留）
+func sanitizeAttr(tag, key, value string, base *url.URL) (string, bool) {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "href", "src":
+		value = sanitizeURL(value, base, key == "href")
+		return value, value != ""
+	case "id":
+		return value, tokenPattern.MatchString(value)
+	case "class":
+		return value, classPattern.MatchString(value)
+	case "type":
+		return value, tag == "input" && value == "checkbox"
+	case "checked", "disabled":
+		return "", true
+	case "align":
+		return value, value == "left" || value == "center" || value == "right"
+	case "width", "height", "colspan", "rowspan", "start":
+		return value, numberPattern.MatchString(value)
+	default:
+		return value, true
+	}
+}
+
+// sanitizeURL 检查链接地址，base 不为nil时补全为绝对地址，不允许的协议返回空字符串
+func sanitizeURL(raw string, base *url.URL, allowMailto bool) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.String()
+	case "mailto":
+		if allowMailto {
+			return parsed.String()
+		}
+		return ""
+	case "":
+		// 协议相对地址（//host/path）视为外部链接，按 https 处理
+		if parsed.Host != "" {
+			parsed.Scheme = "https"
+			return parsed.String()
+		}
+		if base == nil || base.Host == "" {
+			return parsed.String()
+		}
+		return base.ResolveReference(parsed).String()
+	default:
+		return ""
+	}
+}
+
+// attrValue 获取标签属性值
+func attrValue(token xhtml.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// containsString 判断切片是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
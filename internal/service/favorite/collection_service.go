@@ -0,0 +1,617 @@
+/*
+Package favorite provides favorites and user-curated resource collections.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package favorite
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrCollectionNotFound     = errors.New("合集不存在")
+	ErrCollectionForbidden    = errors.New("无权操作该合集")
+	ErrInvalidCollectionName  = errors.New("合集名称不能为空且不能超过100个字符")
+	ErrInvalidCollectionDesc  = errors.New("合集描述不能超过1000个字符")
+	ErrCollectionItemExists   = errors.New("资源已在合集中")
+	ErrCollectionItemNotFound = errors.New("合集中没有该资源")
+	ErrCollectionFull         = errors.New("合集中的资源数量已达上限")
+	ErrFollowOwnCollection    = errors.New("不能关注自己的合集")
+)
+
+const (
+	// MaxItemsPerCollection 单个合集最多包含的资源数
+	MaxItemsPerCollection = 500
+	// maxNameLength 合集名称最大长度（字符）
+	maxNameLength = 100
+	// maxDescriptionLength 合集描述最大长度（字符）
+	maxDescriptionLength = 1000
+	// maxNoteLength 推荐语最大长度（字符）
+	maxNoteLength = 500
+)
+
+// CollectionUpdate 合集更新内容（为 nil 的字段不修改）
+type CollectionUpdate struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	IsPublic    *bool   `json:"is_public"`
+}
+
+// CollectionService 资源合集服务
+type CollectionService struct {
+	db *gorm.DB
+}
+
+// NewCollectionService 创建资源合集服务
+func NewCollectionService(db *gorm.DB) *CollectionService {
+	return &CollectionService{
+		db: db,
+	}
+}
+
+// CreateCollection 创建合集
+// 参数：
+//   - userID: 创建者ID
+//   - name: 合集名称
+//   - description: 合集描述
+//   - isPublic: 是否公开
+//
+// 返回：
+//   - 创建的合集
+//   - 错误信息
+func (s *CollectionService) CreateCollection(userID uint, name, description string, isPublic bool) (*model.Collection, error) {
+	name, description, err := validateCollection(name, description)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &model.Collection{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+		IsPublic:    isPublic,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(collection).Error; err != nil {
+			return fmt.Errorf("创建合集失败: %w", err)
+		}
+		// IsPublic 为 false 时插入会被 default:true 覆盖，需要单独更新
+		if !isPublic {
+			if err := tx.Model(collection).UpdateColumn("is_public", false).Error; err != nil {
+				return fmt.Errorf("创建合集失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+// UpdateCollection 更新合集信息（仅创建者）
+// 参数：
+//   - collectionID: 合集ID
+//   - userID: 操作用户ID
+//   - update: 更新内容
+//
+// 返回：
+//   - 更新后的合集
+//   - 错误信息
+func (s *CollectionService) UpdateCollection(collectionID, userID uint, update *CollectionUpdate) (*model.Collection, error) {
+	collection, err := s.getOwnedCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, description := collection.Name, collection.Description
+	if update.Name != nil {
+		name = *update.Name
+	}
+	if update.Description != nil {
+		description = *update.Description
+	}
+	name, description, err = validateCollection(name, description)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":        name,
+		"description": description,
+	}
+	if update.IsPublic != nil {
+		updates["is_public"] = *update.IsPublic
+	}
+	if err := s.db.Model(collection).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("更新合集失败: %w", err)
+	}
+
+	return s.GetCollection(collectionID, userID)
+}
+
+// DeleteCollection 删除合集及其资源和关注记录（仅创建者）
+// 参数：
+//   - collectionID: 合集ID
+//   - userID: 操作用户ID
+//
+// 返回：
+//   - 错误信息
+func (s *CollectionService) DeleteCollection(collectionID, userID uint) error {
+	if _, err := s.getOwnedCollection(collectionID, userID); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collectionID).Delete(&model.CollectionItem{}).Error; err != nil {
+			return fmt.Errorf("删除合集资源失败: %w", err)
+		}
+		if err := tx.Where("collection_id = ?", collectionID).Delete(&model.CollectionFollow{}).Error; err != nil {
+			return fmt.Errorf("删除合集关注失败: %w", err)
+		}
+		if err := tx.Delete(&model.Collection{}, collectionID).Error; err != nil {
+			return fmt.Errorf("删除合集失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetCollection 获取合集详情（私有合集仅创建者可见）
+// 参数：
+//   - collectionID: 合集ID
+//   - viewerID: 访问者ID（未登录为0）
+//
+// 返回：
+//   - 合集信息
+//   - 错误信息
+func (s *CollectionService) GetCollection(collectionID, viewerID uint) (*model.Collection, error) {
+	var collection model.Collection
+	if err := s.db.Preload("User").First(&collection, collectionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, fmt.Errorf("查询合集失败: %w", err)
+	}
+
+	// 私有合集对其他人表现为不存在
+	if !collection.IsPublic && collection.UserID != viewerID {
+		return nil, ErrCollectionNotFound
+	}
+
+	return &collection, nil
+}
+
+// GetCollectionItems 获取合集中的资源（按排列顺序，已下线的资源不显示）
+// 参数：
+//   - collectionID: 合集ID
+//   - viewerID: 访问者ID（未登录为0）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 合集资源列表
+//   - 总数
+//   - 错误信息
+func (s *CollectionService) GetCollectionItems(collectionID, viewerID uint, page, pageSize int) ([]model.CollectionItem, int64, error) {
+	if _, err := s.GetCollection(collectionID, viewerID); err != nil {
+		return nil, 0, err
+	}
+
+	newQuery := func() *gorm.DB {
+		return s.db.Model(&model.CollectionItem{}).
+			Joins("JOIN resources ON resources.id = collection_items.resource_id").
+			Where("collection_items.collection_id = ?", collectionID).
+			Where("resources.status = ? AND resources.deleted_at IS NULL", model.ResourceStatusApproved)
+	}
+
+	var total int64
+	if err := newQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计合集资源失败: %w", err)
+	}
+
+	var items []model.CollectionItem
+	if err := newQuery().
+		Preload("Resource").Preload("Resource.Category").Preload("Resource.UploadedBy").
+		Order("collection_items.position ASC, collection_items.id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询合集资源失败: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// ListUserCollections 获取用户创建的合集（本人可以看到私有合集）
+// 参数：
+//   - ownerID: 合集创建者ID
+//   - viewerID: 访问者ID（未登录为0）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 合集列表
+//   - 总数
+//   - 错误信息
+func (s *CollectionService) ListUserCollections(ownerID, viewerID uint, page, pageSize int) ([]model.Collection, int64, error) {
+	newQuery := func() *gorm.DB {
+		query := s.db.Model(&model.Collection{}).Where("user_id = ?", ownerID)
+		if ownerID != viewerID {
+			query = query.Where("is_public = ?", true)
+		}
+		return query
+	}
+
+	var total int64
+	if err := newQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计合集失败: %w", err)
+	}
+
+	var collections []model.Collection
+	if err := newQuery().Preload("User").
+		Order("updated_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&collections).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询合集失败: %w", err)
+	}
+
+	return collections, total, nil
+}
+
+// ListPublicCollections 获取公开合集（不包含空合集）
+// 参数：
+//   - sort: 排序方式（popular 按关注数, newest 按更新时间）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 合集列表
+//   - 总数
+//   - 错误信息
+func (s *CollectionService) ListPublicCollections(sort string, page, pageSize int) ([]model.Collection, int64, error) {
+	newQuery := func() *gorm.DB {
+		return s.db.Model(&model.Collection{}).Where("is_public = ? AND items_count > 0", true)
+	}
+
+	var total int64
+	if err := newQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计合集失败: %w", err)
+	}
+
+	order := "followers_count DESC, items_count DESC, updated_at DESC"
+	if sort == "newest" {
+		order = "updated_at DESC"
+	}
+
+	var collections []model.Collection
+	if err := newQuery().Preload("User").
+		Order(order).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&collections).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询合集失败: %w", err)
+	}
+
+	return collections, total, nil
+}
+
+// AddItem 向合集中添加资源（追加到末尾，仅创建者）
+// 参数：
+//   - collectionID: 合集ID
+//   - userID: 操作用户ID
+//   - resourceID: 资源ID
+//   - note: 推荐语
+//
+// 返回：
+//   - 添加的合集资源
+//   - 错误信息
+func (s *CollectionService) AddItem(collectionID, userID, resourceID uint, note string) (*model.CollectionItem, error) {
+	collection, err := s.getOwnedCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if collection.ItemsCount >= MaxItemsPerCollection {
+		return nil, ErrCollectionFull
+	}
+
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxNoteLength {
+		note = string([]rune(note)[:maxNoteLength])
+	}
+
+	var exists int64
+	if err := s.db.Model(&model.Resource{}).
+		Where("id = ? AND status = ?", resourceID, model.ResourceStatusApproved).
+		Count(&exists).Error; err != nil {
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+	if exists == 0 {
+		return nil, ErrTargetNotFound
+	}
+
+	item := &model.CollectionItem{
+		CollectionID: collectionID,
+		ResourceID:   resourceID,
+		Note:         note,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var maxPosition int
+		if err := tx.Model(&model.CollectionItem{}).
+			Where("collection_id = ?", collectionID).
+			Select("COALESCE(MAX(position), 0)").
+			Scan(&maxPosition).Error; err != nil {
+			return fmt.Errorf("查询合集资源失败: %w", err)
+		}
+		item.Position = maxPosition + 1
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(item)
+		if result.Error != nil {
+			return fmt.Errorf("添加合集资源失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrCollectionItemExists
+		}
+
+		return tx.Model(&model.Collection{}).Where("id = ?", collectionID).
+			Updates(map[string]interface{}{
+				"items_count": gorm.Expr("items_count + 1"),
+				"updated_at":  item.CreatedAt,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// RemoveItem 从合集中移除资源（仅创建者）
+// 参数：
+//   - collectionID: 合集ID
+//   - userID: 操作用户ID
+//   - resourceID: 资源ID
+//
+// 返回：
+//   - 错误信息
+func (s *CollectionService) RemoveItem(collectionID, userID, resourceID uint) error {
+	if _, err := s.getOwnedCollection(collectionID, userID); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("collection_id = ? AND resource_id = ?", collectionID, resourceID).
+			Delete(&model.CollectionItem{})
+		if result.Error != nil {
+			return fmt.Errorf("移除合集资源失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrCollectionItemNotFound
+		}
+
+		return tx.Model(&model.Collection{}).Where("id = ? AND items_count > 0", collectionID).
+			UpdateColumn("items_count", gorm.Expr("items_count - 1")).Error
+	})
+}
+
+// ReorderItems 调整合集中资源的顺序（未列出的资源保持原有相对顺序排在后面）
+// 参数：
+//   - collectionID: 合集ID
+//   - userID: 操作用户ID
+//   - resourceIDs: 按新顺序排列的资源ID
+//
+// 返回：
+//   - 错误信息
+func (s *CollectionService) ReorderItems(collectionID, userID uint, resourceIDs []uint) error {
+	if _, err := s.getOwnedCollection(collectionID, userID); err != nil {
+		return err
+	}
+
+	var items []model.CollectionItem
+	if err := s.db.Where("collection_id = ?", collectionID).
+		Order("position ASC, id ASC").
+		Find(&items).Error; err != nil {
+		return fmt.Errorf("查询合集资源失败: %w", err)
+	}
+
+	current := make(map[uint]bool, len(items))
+	for _, item := range items {
+		current[item.ResourceID] = true
+	}
+
+	ordered := make([]uint, 0, len(items))
+	listed := make(map[uint]bool, len(resourceIDs))
+	for _, id := range resourceIDs {
+		if !current[id] {
+			return ErrCollectionItemNotFound
+		}
+		if !listed[id] {
+			listed[id] = true
+			ordered = append(ordered, id)
+		}
+	}
+	for _, item := range items {
+		if !listed[item.ResourceID] {
+			ordered = append(ordered, item.ResourceID)
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ordered {
+			if err := tx.Model(&model.CollectionItem{}).
+				Where("collection_id = ? AND resource_id = ?", collectionID, id).
+				UpdateColumn("position", i+1).Error; err != nil {
+				return fmt.Errorf("调整合集顺序失败: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Follow 关注公开合集（重复关注不报错）
+// 参数：
+//   - collectionID: 合集ID
+//   - userID: 用户ID
+//
+// 返回：
+//   - 合集当前的关注数
+//   - 错误信息
+func (s *CollectionService) Follow(collectionID, userID uint) (int, error) {
+	collection, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if collection.UserID == userID {
+		return 0, ErrFollowOwnCollection
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.CollectionFollow{
+			CollectionID: collectionID,
+			UserID:       userID,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("关注合集失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Model(&model.Collection{}).Where("id = ?", collectionID).
+			UpdateColumn("followers_count", gorm.Expr("followers_count + 1")).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return s.followers(collectionID)
+}
+
+// Unfollow 取消关注合集（未关注时不报错）
+// 参数：
+//   - collectionID: 合集ID
+//   - userID: 用户ID
+//
+// 返回：
+//   - 合集当前的关注数
+//   - 错误信息
+func (s *CollectionService) Unfollow(collectionID, userID uint) (int, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("collection_id = ? AND user_id = ?", collectionID, userID).
+			Delete(&model.CollectionFollow{})
+		if result.Error != nil {
+			return fmt.Errorf("取消关注合集失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Model(&model.Collection{}).Where("id = ? AND followers_count > 0", collectionID).
+			UpdateColumn("followers_count", gorm.Expr("followers_count - 1")).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return s.followers(collectionID)
+}
+
+// IsFollowing 检查用户是否关注了合集
+func (s *CollectionService) IsFollowing(collectionID, userID uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&model.CollectionFollow{}).
+		Where("collection_id = ? AND user_id = ?", collectionID, userID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询关注状态失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// ListFollowedCollections 获取用户关注的合集（最近关注在前，已转为私有的合集不显示）
+// 参数：
+//   - userID: 用户ID
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 合集列表
+//   - 总数
+//   - 错误信息
+func (s *CollectionService) ListFollowedCollections(userID uint, page, pageSize int) ([]model.Collection, int64, error) {
+	newQuery := func() *gorm.DB {
+		return s.db.Model(&model.Collection{}).
+			Joins("JOIN collection_follows cf ON cf.collection_id = collections.id").
+			Where("cf.user_id = ? AND collections.is_public = ?", userID, true)
+	}
+
+	var total int64
+	if err := newQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计关注的合集失败: %w", err)
+	}
+
+	var collections []model.Collection
+	if err := newQuery().Preload("User").
+		Order("cf.created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&collections).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询关注的合集失败: %w", err)
+	}
+
+	return collections, total, nil
+}
+
+// getOwnedCollection 获取用户本人的合集
+func (s *CollectionService) getOwnedCollection(collectionID, userID uint) (*model.Collection, error) {
+	var collection model.Collection
+	if err := s.db.First(&collection, collectionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, fmt.Errorf("查询合集失败: %w", err)
+	}
+
+	if collection.UserID != userID {
+		// 私有合集对其他人表现为不存在
+		if !collection.IsPublic {
+			return nil, ErrCollectionNotFound
+		}
+		return nil, ErrCollectionForbidden
+	}
+
+	return &collection, nil
+}
+
+// followers 获取合集当前的关注数
+func (s *CollectionService) followers(collectionID uint) (int, error) {
+	var count int
+	if err := s.db.Model(&model.Collection{}).Select("followers_count").
+		Where("id = ?", collectionID).Scan(&count).Error; err != nil {
+		return 0, fmt.Errorf("查询关注数失败: %w", err)
+	}
+	return count, nil
+}
+
+// validateCollection 校验并规范化合集名称和描述
+func validateCollection(name, description string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return "", "", ErrInvalidCollectionName
+	}
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return "", "", ErrInvalidCollectionDesc
+	}
+	return name, description, nil
+}
@@ -0,0 +1,277 @@
+/*
+Package favorite provides favorites and user-curated resource collections.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package favorite
+
+import (
+	"errors"
+	"fmt"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrInvalidTarget  = errors.New("无效的收藏对象类型")
+	ErrTargetNotFound = errors.New("收藏对象不存在")
+)
+
+// target 收藏对象描述（表名、收藏数字段和可收藏的状态）
+type target struct {
+	table   string
+	counter string
+	status  interface{}
+}
+
+// targets 支持收藏的对象类型
+var targets = map[model.FavoriteTargetType]target{
+	model.FavoriteTargetResource: {
+		table:   "resources",
+		counter: "favorites_count",
+		status:  model.ResourceStatusApproved,
+	},
+	model.FavoriteTargetArticle: {
+		table:   "articles",
+		counter: "favorite_count",
+		status:  model.ArticleStatusPublished,
+	},
+}
+
+// FavoriteService 收藏服务
+type FavoriteService struct {
+	db *gorm.DB
+}
+
+// NewFavoriteService 创建收藏服务
+func NewFavoriteService(db *gorm.DB) *FavoriteService {
+	return &FavoriteService{
+		db: db,
+	}
+}
+
+// ParseTargetType 解析收藏对象类型
+func ParseTargetType(value string) (model.FavoriteTargetType, error) {
+	targetType := model.FavoriteTargetType(value)
+	if _, ok := targets[targetType]; !ok {
+		return "", ErrInvalidTarget
+	}
+	return targetType, nil
+}
+
+// AddFavorite 收藏资源或文章（重复收藏不报错）
+// 参数：
+//   - userID: 用户ID
+//   - targetType: 收藏对象类型
+//   - targetID: 收藏对象ID
+//
+// 返回：
+//   - 收藏对象当前的收藏数
+//   - 错误信息
+func (s *FavoriteService) AddFavorite(userID uint, targetType model.FavoriteTargetType, targetID uint) (uint, error) {
+	t, ok := targets[targetType]
+	if !ok {
+		return 0, ErrInvalidTarget
+	}
+
+	// 只能收藏已上线的资源和已发布的文章
+	var exists int64
+	if err := s.db.Table(t.table).
+		Where("id = ? AND deleted_at IS NULL", targetID).
+		Where("status = ?", t.status).
+		Count(&exists).Error; err != nil {
+		return 0, fmt.Errorf("查询收藏对象失败: %w", err)
+	}
+	if exists == 0 {
+		return 0, ErrTargetNotFound
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Favorite{
+			UserID:     userID,
+			TargetType: targetType,
+			TargetID:   targetID,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("收藏失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Table(t.table).Where("id = ?", targetID).
+			UpdateColumn(t.counter, gorm.Expr(t.counter+" + 1")).Error; err != nil {
+			return fmt.Errorf("更新收藏数失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return s.count(t, targetID)
+}
+
+// RemoveFavorite 取消收藏（未收藏时不报错）
+// 参数：
+//   - userID: 用户ID
+//   - targetType: 收藏对象类型
+//   - targetID: 收藏对象ID
+//
+// 返回：
+//   - 收藏对象当前的收藏数
+//   - 错误信息
+func (s *FavoriteService) RemoveFavorite(userID uint, targetType model.FavoriteTargetType, targetID uint) (uint, error) {
+	t, ok := targets[targetType]
+	if !ok {
+		return 0, ErrInvalidTarget
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+			Delete(&model.Favorite{})
+		if result.Error != nil {
+			return fmt.Errorf("取消收藏失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Table(t.table).Where("id = ? AND "+t.counter+" > 0", targetID).
+			UpdateColumn(t.counter, gorm.Expr(t.counter+" - 1")).Error; err != nil {
+			return fmt.Errorf("更新收藏数失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return s.count(t, targetID)
+}
+
+// FavoritedIDs 检查用户收藏了哪些对象（用于列表页显示收藏状态）
+// 参数：
+//   - userID: 用户ID
+//   - targetType: 收藏对象类型
+//   - targetIDs: 要检查的对象ID列表
+//
+// 返回：
+//   - 已收藏的对象ID集合
+//   - 错误信息
+func (s *FavoriteService) FavoritedIDs(userID uint, targetType model.FavoriteTargetType, targetIDs []uint) (map[uint]bool, error) {
+	favorited := make(map[uint]bool)
+	if len(targetIDs) == 0 {
+		return favorited, nil
+	}
+
+	var ids []uint
+	if err := s.db.Model(&model.Favorite{}).
+		Where("user_id = ? AND target_type = ? AND target_id IN ?", userID, targetType, targetIDs).
+		Pluck("target_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询收藏状态失败: %w", err)
+	}
+	for _, id := range ids {
+		favorited[id] = true
+	}
+	return favorited, nil
+}
+
+// ListFavoriteResources 获取用户收藏的资源（最近收藏在前，已下线的资源不显示）
+// 参数：
+//   - userID: 用户ID
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 资源列表
+//   - 总数
+//   - 错误信息
+func (s *FavoriteService) ListFavoriteResources(userID uint, page, pageSize int) ([]*model.Resource, int64, error) {
+	newQuery := func() *gorm.DB {
+		return s.db.Model(&model.Resource{}).
+			Joins("JOIN favorites f ON f.target_id = resources.id AND f.target_type = ?", model.FavoriteTargetResource).
+			Where("f.user_id = ? AND resources.status = ?", userID, model.ResourceStatusApproved)
+	}
+
+	var total int64
+	if err := newQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计收藏资源失败: %w", err)
+	}
+
+	var resources []*model.Resource
+	if err := newQuery().Preload("Category").Preload("UploadedBy").
+		Order("f.created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&resources).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询收藏资源失败: %w", err)
+	}
+
+	return resources, total, nil
+}
+
+// ListFavoriteArticles 获取用户收藏的文章（最近收藏在前，未发布的文章不显示）
+// 参数：
+//   - userID: 用户ID
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 文章列表
+//   - 总数
+//   - 错误信息
+func (s *FavoriteService) ListFavoriteArticles(userID uint, page, pageSize int) ([]model.Article, int64, error) {
+	newQuery := func() *gorm.DB {
+		return s.db.Model(&model.Article{}).
+			Joins("JOIN favorites f ON f.target_id = articles.id AND f.target_type = ?", model.FavoriteTargetArticle).
+			Where("f.user_id = ? AND articles.status = ?", userID, model.ArticleStatusPublished)
+	}
+
+	var total int64
+	if err := newQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计收藏文章失败: %w", err)
+	}
+
+	var articles []model.Article
+	if err := newQuery().Preload("Author").
+		Order("f.created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&articles).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询收藏文章失败: %w", err)
+	}
+
+	return articles, total, nil
+}
+
+// RecountFavorites 根据收藏记录重新统计资源和文章的收藏数
+// 返回：
+//   - 错误信息
+func (s *FavoriteService) RecountFavorites() error {
+	for targetType, t := range targets {
+		counted := s.db.Model(&model.Favorite{}).
+			Select("COUNT(*)").
+			Where("target_type = ? AND target_id = "+t.table+".id", targetType)
+		if err := s.db.Table(t.table).Where("1 = 1").
+			UpdateColumn(t.counter, counted).Error; err != nil {
+			return fmt.Errorf("重新统计收藏数失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// count 获取收藏对象当前的收藏数
+func (s *FavoriteService) count(t target, targetID uint) (uint, error) {
+	var count uint
+	if err := s.db.Table(t.table).Select(t.counter).Where("id = ?", targetID).Scan(&count).Error; err != nil {
+		return 0, fmt.Errorf("查询收藏数失败: %w", err)
+	}
+	return count, nil
+}
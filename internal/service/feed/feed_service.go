@@ -0,0 +1,386 @@
+/*
+Package feed provides RSS/Atom/JSON Feed syndication services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package feed
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/article"
+	"resource-share-site/internal/service/category"
+	"resource-share-site/internal/service/content"
+	"resource-share-site/internal/service/resource"
+	"resource-share-site/internal/service/seo"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrUnsupportedFeedFormat = errors.New("不支持的订阅源格式，可选值：rss、atom、json")
+)
+
+// 订阅源格式
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// Feed 订阅源（与输出格式无关）
+type Feed struct {
+	Title       string
+	Description string
+	Link        string    // 对应页面的完整URL
+	FeedURL     string    // 订阅源自身的完整URL
+	Updated     time.Time // 最近一个条目的更新时间
+	Items       []Item
+}
+
+// Item 订阅源条目
+type Item struct {
+	ID         string // 稳定的唯一标识（不随slug变化的完整URL）
+	Title      string
+	Link       string // 条目页面的完整URL
+	Summary    string // 纯文本摘要
+	Content    string // 清理后的HTML内容
+	Image      string
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Request 订阅源请求
+type Request struct {
+	Format   string // rss、atom、json
+	Limit    int    // 条目数，0表示使用默认值
+	SelfPath string // 订阅源自身的路径
+}
+
+// Document 输出的订阅源文档
+type Document struct {
+	Body         []byte
+	ContentType  string
+	ETag         string    // 内容摘要，用于条件请求
+	LastModified time.Time // 最近一个条目的更新时间
+}
+
+// FeedService 订阅源服务
+type FeedService struct {
+	db         *gorm.DB
+	resources  *resource.ResourceService
+	articles   *article.ArticleService
+	categories *category.CategoryService
+	renderer   *content.Renderer
+	cfg        *config.FeedConfig
+	baseURL    string
+	siteName   string
+}
+
+// NewFeedService 创建订阅源服务
+func NewFeedService(db *gorm.DB) *FeedService {
+	return &FeedService{
+		db:         db,
+		resources:  resource.NewResourceService(db),
+		articles:   article.NewArticleService(db),
+		categories: category.NewCategoryService(db),
+		renderer:   content.NewRenderer(nil),
+		cfg:        config.DefaultFeedConfig(),
+		siteName:   config.DefaultSEOConfig().SiteName,
+	}
+}
+
+// SetConfig 设置订阅源配置、站点地址和站点名称
+func (s *FeedService) SetConfig(cfg *config.FeedConfig, seoCfg *config.SEOConfig) {
+	if cfg != nil {
+		s.cfg = cfg
+	}
+	if seoCfg != nil {
+		s.baseURL = seoCfg.GetBaseURL()
+		if seoCfg.SiteName != "" {
+			s.siteName = seoCfg.SiteName
+		}
+	}
+}
+
+// SetRenderer 设置正文渲染器（与页面共用渲染缓存）
+func (s *FeedService) SetRenderer(renderer *content.Renderer) {
+	if renderer != nil {
+		s.renderer = renderer
+	}
+}
+
+// CacheMaxAge 客户端缓存时间(秒)
+func (s *FeedService) CacheMaxAge() int {
+	return s.cfg.CacheMaxAge
+}
+
+// Resources 最新通过审核的资源
+func (s *FeedService) Resources(req Request) (*Document, error) {
+	items, err := s.resourceItems(nil, s.cfg.GetItemLimit(s.cfg.ResourceItems, req.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.render(&Feed{
+		Title:       s.siteName + " - 最新资源",
+		Description: s.siteName + "最新审核通过的资源",
+		Link:        s.absoluteURL("/resources"),
+		FeedURL:     s.absoluteURL(req.SelfPath),
+		Items:       items,
+	}, req.Format)
+}
+
+// Category 分类下最新通过审核的资源
+func (s *FeedService) Category(categoryID uint, req Request) (*Document, error) {
+	cat, err := s.categories.GetCategoryByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.resourceItems(&cat.ID, s.cfg.GetItemLimit(s.cfg.CategoryItems, req.Limit))
+	if err != nil {
+		return nil, err
+	}
+
+	description := cat.Description
+	if description == "" {
+		description = fmt.Sprintf("%s分类下最新审核通过的资源", cat.Name)
+	}
+	feed := &Feed{
+		Title:       cat.Name + " - " + s.siteName,
+		Description: description,
+		Link:        s.absoluteURL(seo.CategoryPath(cat)),
+		FeedURL:     s.absoluteURL(req.SelfPath),
+		Updated:     cat.UpdatedAt,
+		Items:       items,
+	}
+	return s.render(feed, req.Format)
+}
+
+// Articles 最新发布的文章
+func (s *FeedService) Articles(req Request) (*Document, error) {
+	limit := s.cfg.GetItemLimit(s.cfg.ArticleItems, req.Limit)
+	list, _, err := s.articles.GetArticles(1, limit, nil, "", "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("查询文章失败: %w", err)
+	}
+
+	// 列表不含正文和更新时间，按ID补充查询
+	ids := make([]uint, 0, len(list))
+	for _, a := range list {
+		ids = append(ids, a.ID)
+	}
+	details := make(map[uint]model.Article, len(list))
+	if len(ids) > 0 {
+		columns := []string{"id", "updated_at"}
+		if s.cfg.FullContent {
+			columns = append(columns, "content")
+		}
+		var rows []model.Article
+		if err := s.db.Select(columns).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("查询文章内容失败: %w", err)
+		}
+		for _, row := range rows {
+			details[row.ID] = row
+		}
+	}
+
+	items := make([]Item, 0, len(list))
+	for _, a := range list {
+		detail := details[a.ID]
+		published := a.CreatedAt
+		if a.PublishedAt != nil {
+			published = *a.PublishedAt
+		}
+		updated := detail.UpdatedAt
+		if updated.Before(published) {
+			updated = published
+		}
+
+		body, excerpt, err := s.itemContent(content.KindArticle, a.ID, detail.Content)
+		if err != nil {
+			return nil, err
+		}
+		summary := a.Excerpt
+		if summary == "" {
+			summary = excerpt
+		}
+		if body == "" {
+			body = "<p>" + html.EscapeString(summary) + "</p>"
+		}
+
+		link := s.absoluteURL(seo.ArticlePath(a.Slug))
+		items = append(items, Item{
+			ID:         link,
+			Title:      a.Title,
+			Link:       link,
+			Summary:    seo.Summarize(summary),
+			Content:    body,
+			Image:      s.absoluteURL(a.FeaturedImage),
+			Author:     a.AuthorName,
+			Categories: splitCategories(a.Category, a.Tags),
+			Published:  published,
+			Updated:    updated,
+		})
+	}
+
+	return s.render(&Feed{
+		Title:       s.siteName + " - 最新文章",
+		Description: s.siteName + "最新发布的文章",
+		Link:        s.absoluteURL("/articles"),
+		FeedURL:     s.absoluteURL(req.SelfPath),
+		Items:       items,
+	}, req.Format)
+}
+
+// resourceItems 查询最新通过审核的资源并转换为条目
+func (s *FeedService) resourceItems(categoryID *uint, limit int) ([]Item, error) {
+	approved := model.ResourceStatusApproved
+	resources, _, err := s.resources.GetResources(1, limit, categoryID, &approved, nil, nil, nil, "created_at", true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(resources))
+	for _, res := range resources {
+		published := res.CreatedAt
+		if res.ReviewedAt != nil {
+			published = *res.ReviewedAt
+		}
+		updated := res.UpdatedAt
+		if updated.Before(published) {
+			updated = published
+		}
+
+		var categories []string
+		if res.Category != nil {
+			categories = append(categories, res.Category.Name)
+		}
+		categories = append(categories, seo.ResourceTagNames(res.Tags)...)
+
+		body, excerpt, err := s.itemContent(content.KindResource, res.ID, res.Description)
+		if err != nil {
+			return nil, err
+		}
+
+		item := Item{
+			// 以ID地址作为唯一标识，slug变化后阅读器不会重复推送
+			ID:         s.absoluteURL(fmt.Sprintf("/resource/%d", res.ID)),
+			Title:      res.Title,
+			Link:       s.absoluteURL(seo.ResourcePath(res.ID, res.Slug)),
+			Summary:    seo.Summarize(excerpt),
+			Content:    body,
+			Categories: uniqueStrings(categories),
+			Published:  published,
+			Updated:    updated,
+		}
+		if res.UploadedBy != nil {
+			item.Author = res.UploadedBy.Username
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// itemContent 渲染条目正文：与页面相同的Markdown渲染结果再按订阅源白名单清理（链接和图片补全为绝对地址）
+func (s *FeedService) itemContent(kind content.Kind, id uint, source string) (string, string, error) {
+	if strings.TrimSpace(source) == "" {
+		return "", "", nil
+	}
+	rendered, err := s.renderer.Render(kind, id, source)
+	if err != nil {
+		return "", "", err
+	}
+	return content.Sanitize(rendered.HTML, content.FeedPolicy, s.baseURL), rendered.Excerpt, nil
+}
+
+// render 按格式输出订阅源并计算缓存标识
+func (s *FeedService) render(feed *Feed, format string) (*Document, error) {
+	for _, item := range feed.Items {
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Unix(0, 0)
+	}
+	feed.Updated = feed.Updated.UTC().Truncate(time.Second)
+
+	var (
+		body        []byte
+		contentType string
+		err         error
+	)
+	switch format {
+	case FormatRSS, "":
+		ttl := s.cfg.CacheMaxAge / 60
+		body, err = renderRSS(feed, ttl)
+		contentType = rssContentType
+	case FormatAtom:
+		body, err = renderAtom(feed)
+		contentType = atomContentType
+	case FormatJSON:
+		body, err = renderJSON(feed)
+		contentType = jsonFeedContentType
+	default:
+		return nil, ErrUnsupportedFeedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("生成订阅源失败: %w", err)
+	}
+
+	sum := sha1.Sum(body)
+	return &Document{
+		Body:         body,
+		ContentType:  contentType,
+		ETag:         `"` + hex.EncodeToString(sum[:10]) + `"`,
+		LastModified: feed.Updated,
+	}, nil
+}
+
+// absoluteURL 将站内路径补全为完整URL
+func (s *FeedService) absoluteURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return s.baseURL + path
+}
+
+// splitCategories 合并文章分类和逗号分隔的标签
+func splitCategories(category, tags string) []string {
+	values := []string{category}
+	values = append(values, strings.Split(tags, ",")...)
+	return uniqueStrings(values)
+}
+
+// uniqueStrings 去掉空白和重复项（保持顺序）
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	return result
+}
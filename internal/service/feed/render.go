@@ -0,0 +1,281 @@
+/*
+Package feed provides RSS 2.0, Atom 1.0 and JSON Feed 1.1 encoding.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+// 订阅源协议命名空间
+const (
+	atomNamespace       = "http://www.w3.org/2005/Atom"
+	dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+	jsonFeedVersion     = "https://jsonfeed.org/version/1.1"
+	feedLanguage        = "zh-CN"
+	feedGeneratorName   = "resource-share-site"
+	rssContentType      = "application/rss+xml; charset=utf-8"
+	atomContentType     = "application/atom+xml; charset=utf-8"
+	jsonFeedContentType = "application/feed+json; charset=utf-8"
+)
+
+// ==================== RSS 2.0 ====================
+
+// rssDocument <rss> 文档
+type rssDocument struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	XmlnsAtom string     `xml:"xmlns:atom,attr"`
+	XmlnsDC   string     `xml:"xmlns:dc,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+// rssChannel <channel> 元素
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	Generator     string    `xml:"generator"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	TTL           int       `xml:"ttl,omitempty"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssItem <item> 元素
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+// rssGUID <guid> 元素
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// renderRSS 输出RSS 2.0文档
+func renderRSS(feed *Feed, ttl int) ([]byte, error) {
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.Link,
+		Description: feed.Description,
+		Language:    feedLanguage,
+		Generator:   feedGeneratorName,
+		TTL:         ttl,
+		AtomLink:    atomLink{Href: feed.FeedURL, Rel: "self", Type: "application/rss+xml"},
+		Items:       make([]rssItem, 0, len(feed.Items)),
+	}
+	if !feed.Updated.IsZero() {
+		channel.LastBuildDate = feed.Updated.Format(time.RFC1123Z)
+	}
+	for _, item := range feed.Items {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.ID},
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Creator:     item.Author,
+			Categories:  item.Categories,
+			Description: item.Content,
+		})
+	}
+
+	return encodeXML(rssDocument{
+		Version:   "2.0",
+		XmlnsAtom: atomNamespace,
+		XmlnsDC:   dublinCoreNamespace,
+		Channel:   channel,
+	})
+}
+
+// ==================== Atom 1.0 ====================
+
+// atomFeed <feed> 文档
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	Xmlns     string      `xml:"xmlns,attr"`
+	Lang      string      `xml:"xml:lang,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	Updated   string      `xml:"updated"`
+	Generator string      `xml:"generator"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+// atomLink <link> 元素
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// atomEntry <entry> 元素
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+// atomPerson <author> 元素
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+// atomCategory <category> 元素
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// atomText 文本内容元素（type 为 text 或 html）
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// renderAtom 输出Atom 1.0文档
+func renderAtom(feed *Feed) ([]byte, error) {
+	doc := atomFeed{
+		Xmlns:     atomNamespace,
+		Lang:      feedLanguage,
+		ID:        feed.FeedURL,
+		Title:     feed.Title,
+		Subtitle:  feed.Description,
+		Updated:   feed.Updated.Format(time.RFC3339),
+		Generator: feedGeneratorName,
+		Links: []atomLink{
+			{Href: feed.Link, Rel: "alternate", Type: "text/html"},
+			{Href: feed.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]atomEntry, 0, len(feed.Items)),
+	}
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Link:      atomLink{Href: item.Link, Rel: "alternate", Type: "text/html"},
+			Published: item.Published.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Value: item.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return encodeXML(doc)
+}
+
+// encodeXML 输出带XML声明的文档
+func encodeXML(doc interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ==================== JSON Feed 1.1 ====================
+
+// jsonFeed JSON Feed 文档
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Language    string         `json:"language"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// jsonFeedItem JSON Feed 条目
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	Summary       string           `json:"summary,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+// jsonFeedAuthor JSON Feed 作者
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// renderJSON 输出JSON Feed 1.1文档
+func renderJSON(feed *Feed) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     feed.FeedURL,
+		Description: feed.Description,
+		Language:    feedLanguage,
+		Items:       make([]jsonFeedItem, 0, len(feed.Items)),
+	}
+	for _, item := range feed.Items {
+		entry := jsonFeedItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Content,
+			Summary:       item.Summary,
+			Image:         item.Image,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+			Tags:          item.Categories,
+		}
+		if item.Author != "" {
+			entry.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+
+	// 内容中的HTML保持原样输出，不转义为 \u003c 等形式
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
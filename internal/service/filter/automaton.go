@@ -0,0 +1,107 @@
+/*
+Package filter provides the sensitive-word dictionary shared by content services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package filter
+
+// acNode Aho-Corasick 自动机节点
+type acNode struct {
+	next   map[rune]int
+	fail   int
+	output []int // 以该节点结尾的模式串下标（包含失配链上的模式串）
+}
+
+// automaton Aho-Corasick 多模式匹配自动机（构建后只读，可并发使用）
+type automaton struct {
+	nodes    []acNode
+	patterns [][]rune
+}
+
+// match 模式串在规范化文本中的一次命中
+type match struct {
+	pattern    int // 模式串下标
+	start, end int // 在规范化文本中的位置 [start, end)
+}
+
+// newAutomaton 根据模式串构建自动机
+func newAutomaton(patterns []string) *automaton {
+	a := &automaton{
+		nodes:    []acNode{{next: make(map[rune]int)}},
+		patterns: make([][]rune, len(patterns)),
+	}
+
+	// 构建字典树
+	for i, pattern := range patterns {
+		runes := []rune(pattern)
+		a.patterns[i] = runes
+		state := 0
+		for _, r := range runes {
+			next, ok := a.nodes[state].next[r]
+			if !ok {
+				a.nodes = append(a.nodes, acNode{next: make(map[rune]int)})
+				next = len(a.nodes) - 1
+				a.nodes[state].next[r] = next
+			}
+			state = next
+		}
+		a.nodes[state].output = append(a.nodes[state].output, i)
+	}
+
+	// 按层次遍历计算失配指针，并合并失配链上的输出
+	queue := make([]int, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[state].next {
+			fail := a.nodes[state].fail
+			for fail != 0 {
+				if _, ok := a.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+			if next, ok := a.nodes[fail].next[r]; ok && next != child {
+				a.nodes[child].fail = next
+			}
+			a.nodes[child].output = append(a.nodes[child].output, a.nodes[a.nodes[child].fail].output...)
+			queue = append(queue, child)
+		}
+	}
+
+	return a
+}
+
+// find 返回文本中所有模式串的命中位置（包含重叠命中）
+func (a *automaton) find(text []rune) []match {
+	if a == nil || len(a.patterns) == 0 {
+		return nil
+	}
+
+	var matches []match
+	state := 0
+	for i, r := range text {
+		for state != 0 {
+			if _, ok := a.nodes[state].next[r]; ok {
+				break
+			}
+			state = a.nodes[state].fail
+		}
+		if next, ok := a.nodes[state].next[r]; ok {
+			state = next
+		}
+		for _, pattern := range a.nodes[state].output {
+			matches = append(matches, match{
+				pattern: pattern,
+				start:   i + 1 - len(a.patterns[pattern]),
+				end:     i + 1,
+			})
+		}
+	}
+	return matches
+}
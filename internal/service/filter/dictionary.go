@@ -0,0 +1,360 @@
+/*
+Package filter provides the sensitive-word dictionary shared by content services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package filter
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrWordNotFound    = errors.New("敏感词不存在")
+	ErrEmptyWord       = errors.New("敏感词不能为空")
+	ErrInvalidSeverity = errors.New("敏感级别必须在1-3之间")
+	ErrBlocked         = errors.New("内容包含违禁词")
+)
+
+// defaultCategory 未指定分类时使用的分类
+const defaultCategory = "general"
+
+// entry 已加载的敏感词
+type entry struct {
+	Word     string
+	Category string
+	Severity int
+}
+
+// Dictionary 敏感词库（从数据库加载并构建 Aho-Corasick 自动机，定期检查版本变化实现热加载）
+// 为nil时不过滤任何内容
+type Dictionary struct {
+	db  *gorm.DB
+	cfg *config.FilterConfig
+
+	mu        sync.RWMutex
+	entries   []entry    // 已启用的敏感词（与自动机模式串一一对应）
+	matcher   *automaton // 基于规范化敏感词构建的自动机
+	version   string     // 词库版本（数量+最后修改时间）
+	checkedAt time.Time
+}
+
+// NewDictionary 创建敏感词库（cfg 为nil时使用默认配置）
+func NewDictionary(db *gorm.DB, cfg *config.FilterConfig) *Dictionary {
+	if cfg == nil {
+		cfg = config.DefaultFilterConfig()
+	}
+	return &Dictionary{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Check 检查文本，低级别敏感词以*替换，并给出整体处理方式
+// 参数：
+//   - text: 待检查的文本
+//
+// 返回：
+//   - 过滤结果（Text 为替换后的文本）
+func (d *Dictionary) Check(text string) *Result {
+	result := &Result{Text: text}
+	if d == nil || text == "" {
+		return result
+	}
+
+	d.refresh()
+
+	d.mu.RLock()
+	entries, matcher := d.entries, d.matcher
+	d.mu.RUnlock()
+
+	normalized, positions := normalize(text)
+	matches := matcher.find(normalized)
+	if len(matches) == 0 {
+		return result
+	}
+
+	original := []rune(text)
+	masked := false
+	seen := make(map[int]int, len(matches))
+	for _, m := range matches {
+		e := entries[m.pattern]
+		mode := d.modeOf(e.Severity)
+
+		if index, ok := seen[m.pattern]; ok {
+			result.Hits[index].Count++
+		} else {
+			seen[m.pattern] = len(result.Hits)
+			result.Hits = append(result.Hits, Hit{
+				Word:     e.Word,
+				Category: e.Category,
+				Severity: e.Severity,
+				Mode:     mode,
+				Count:    1,
+			})
+		}
+		if mode.stricter(result.Mode) {
+			result.Mode = mode
+		}
+
+		// 替换原文中从首字到尾字的全部字符（包括夹在中间的符号）
+		if mode == ModeMask {
+			for i := positions[m.start]; i <= positions[m.end-1]; i++ {
+				original[i] = '*'
+			}
+			masked = true
+		}
+	}
+	if masked {
+		result.Text = string(original)
+	}
+
+	return result
+}
+
+// Sanitize 依次检查多个字段，低级别敏感词直接在字段中替换为*
+// 参数：
+//   - fields: 待检查字段的指针（为nil或空字符串时跳过）
+//
+// 返回：
+//   - 合并后的过滤结果（Text 为空）
+func (d *Dictionary) Sanitize(fields ...*string) *Result {
+	merged := &Result{}
+	for _, field := range fields {
+		if field == nil || *field == "" {
+			continue
+		}
+		result := d.Check(*field)
+		*field = result.Text
+		merged.merge(result)
+	}
+	return merged
+}
+
+// Reload 立即从数据库重新加载词库并重建自动机
+func (d *Dictionary) Reload() error {
+	version, err := d.currentVersion()
+	if err != nil {
+		return err
+	}
+
+	var words []model.SensitiveWord
+	if err := d.db.Select("id", "word", "category", "severity").
+		Where("is_enabled = ?", true).
+		Order("id ASC").
+		Find(&words).Error; err != nil {
+		return fmt.Errorf("加载敏感词库失败: %w", err)
+	}
+
+	entries := make([]entry, 0, len(words))
+	patterns := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		pattern := normalizeWord(word.Word)
+		if pattern == "" || seen[pattern] {
+			continue
+		}
+		seen[pattern] = true
+		patterns = append(patterns, pattern)
+		entries = append(entries, entry{
+			Word:     word.Word,
+			Category: word.Category,
+			Severity: word.Severity,
+		})
+	}
+	matcher := newAutomaton(patterns)
+
+	d.mu.Lock()
+	d.entries = entries
+	d.matcher = matcher
+	d.version = version
+	d.checkedAt = time.Now()
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Size 已加载的敏感词数量
+func (d *Dictionary) Size() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.entries)
+}
+
+// modeOf 根据敏感级别确定处理方式
+func (d *Dictionary) modeOf(severity int) Mode {
+	switch {
+	case severity >= d.cfg.BlockSeverity:
+		return ModeBlock
+	case severity >= d.cfg.ReviewSeverity:
+		return ModeReview
+	default:
+		return ModeMask
+	}
+}
+
+// ListWords 获取敏感词列表（keyword、category 为空时不过滤）
+func (d *Dictionary) ListWords(keyword, category string, page, pageSize int) ([]model.SensitiveWord, int64, error) {
+	query := d.db.Model(&model.SensitiveWord{})
+	if keyword != "" {
+		query = query.Where("word LIKE ?", "%"+keyword+"%")
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询敏感词总数失败: %w", err)
+	}
+
+	var words []model.SensitiveWord
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&words).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询敏感词列表失败: %w", err)
+	}
+
+	return words, total, nil
+}
+
+// AddWords 批量添加同一分类和级别的敏感词（已存在的词会被跳过）
+// 参数：
+//   - words: 敏感词列表
+//   - category: 分类（为空时使用 general）
+//   - severity: 敏感级别（1-3）
+//
+// 返回：
+//   - 新增的数量
+//   - 错误信息
+func (d *Dictionary) AddWords(words []string, category string, severity int) (int, error) {
+	if severity < model.SensitiveSeverityLow || severity > model.SensitiveSeverityHigh {
+		return 0, ErrInvalidSeverity
+	}
+	category = strings.TrimSpace(category)
+	if category == "" {
+		category = defaultCategory
+	}
+
+	records := make([]model.SensitiveWord, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" || seen[strings.ToLower(word)] {
+			continue
+		}
+		seen[strings.ToLower(word)] = true
+		records = append(records, model.SensitiveWord{
+			Word:      word,
+			Category:  category,
+			Severity:  severity,
+			IsEnabled: true,
+		})
+	}
+	if len(records) == 0 {
+		return 0, ErrEmptyWord
+	}
+
+	result := d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&records)
+	if result.Error != nil {
+		return 0, fmt.Errorf("添加敏感词失败: %w", result.Error)
+	}
+
+	return int(result.RowsAffected), d.Reload()
+}
+
+// WordUpdate 敏感词更新内容（为nil的字段不修改）
+type WordUpdate struct {
+	Category  *string `json:"category" binding:"omitempty,max=50"`
+	Severity  *int    `json:"severity"`
+	IsEnabled *bool   `json:"is_enabled"`
+}
+
+// UpdateWord 修改敏感词的分类、级别或启用状态
+func (d *Dictionary) UpdateWord(id uint, update *WordUpdate) error {
+	updates := map[string]interface{}{"updated_at": time.Now()}
+	if update.Category != nil {
+		category := strings.TrimSpace(*update.Category)
+		if category == "" {
+			category = defaultCategory
+		}
+		updates["category"] = category
+	}
+	if update.Severity != nil {
+		if *update.Severity < model.SensitiveSeverityLow || *update.Severity > model.SensitiveSeverityHigh {
+			return ErrInvalidSeverity
+		}
+		updates["severity"] = *update.Severity
+	}
+	if update.IsEnabled != nil {
+		updates["is_enabled"] = *update.IsEnabled
+	}
+
+	result := d.db.Model(&model.SensitiveWord{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("更新敏感词失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWordNotFound
+	}
+	return d.Reload()
+}
+
+// DeleteWord 删除敏感词
+func (d *Dictionary) DeleteWord(id uint) error {
+	result := d.db.Delete(&model.SensitiveWord{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除敏感词失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWordNotFound
+	}
+	return d.Reload()
+}
+
+// refresh 超过检查间隔时对比词库版本，有变化则重新加载（加载失败时沿用旧词库）
+func (d *Dictionary) refresh() {
+	d.mu.Lock()
+	if time.Since(d.checkedAt) < d.cfg.GetRefresh() {
+		d.mu.Unlock()
+		return
+	}
+	d.checkedAt = time.Now()
+	loadedVersion := d.version
+	d.mu.Unlock()
+
+	version, err := d.currentVersion()
+	if err != nil || version == loadedVersion {
+		return
+	}
+	_ = d.Reload()
+}
+
+// currentVersion 获取数据库中词库的版本（其他进程修改词库后也能感知）
+func (d *Dictionary) currentVersion() (string, error) {
+	var row struct {
+		Count     int64
+		UpdatedAt sql.NullString
+	}
+	if err := d.db.Model(&model.SensitiveWord{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS updated_at").
+		Scan(&row).Error; err != nil {
+		return "", fmt.Errorf("查询敏感词库版本失败: %w", err)
+	}
+	return fmt.Sprintf("%d@%s", row.Count, row.UpdatedAt.String), nil
+}
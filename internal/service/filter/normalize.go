@@ -0,0 +1,110 @@
+/*
+Package filter provides the sensitive-word dictionary shared by content services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// traditionalPairs 常用繁体字到简体字的对照（每项为“繁简”两个字）
+const traditionalPairs = `
+萬万 與与 專专 業业 東东 絲丝 兩两 嚴严 喪丧 個个 豐丰 臨临 為为 麗丽 舉举 義义 烏乌 樂乐
+喬乔 習习 鄉乡 書书 買买 亂乱 爭争 於于 虧亏 雲云 亞亚 產产 畝亩 親亲 億亿 僅仅 從从 侖仑
+倉仓 儀仪 們们 價价 眾众 優优 會会 傘伞 偉伟 傳传 傷伤 倫伦 偽伪 體体 餘余 傭佣 僑侨 儉俭
+債债 傾倾 償偿 兒儿 黨党 蘭兰 關关 興兴 養养 獸兽 內内 岡冈 冊册 寫写 軍军 農农 馮冯 衝冲
+決决 況况 凍冻 淨净 準准 涼凉 減减 湊凑 幾几 鳳凤 憑凭 凱凯 擊击 劃划 劉刘 則则 剛刚 創创
+刪删 別别 劑剂 劍剑 劇剧 勸劝 辦办 務务 動动 勵励 勁劲 勞劳 勢势 區区 醫医 華华 協协 單单
+賣卖 盧卢 衛卫 卻却 廠厂 廳厅 曆历 歷历 厲厉 壓压 厭厌 縣县 參参 雙双 發发 髮发 變变 敘叙
+臺台 葉叶 號号 嘆叹 嚇吓 嗎吗 啟启 員员 響响 問问 啞哑 喚唤 嘩哗 囑嘱 團团 園园 圍围 圖图
+國国 圓圆 聖圣 場场 壞坏 塊块 堅坚 壇坛 墳坟 墜坠 壘垒 墊垫 報报 塗涂 壯壮 聲声 殼壳 壺壶
+處处 備备 複复 復复 夠够 頭头 誇夸 奪夺 奮奋 獎奖 婦妇 媽妈 嬰婴 學学 寶宝 實实 寵宠 審审
+寬宽 對对 導导 將将 層层 屬属 歲岁 島岛 嶺岭 幣币 帥帅 師师 帳帐 帶带 幫帮 廣广 庫库 應应
+廟庙 廢废 開开 異异 棄弃 張张 彈弹 強强 歸归 當当 錄录 後后 徑径 徵征 憶忆 懷怀 態态 總总
+戀恋 惡恶 惱恼 悶闷 驚惊 憐怜 慘惨 慣惯 懶懒 戰战 戲戏 戶户 撲扑 執执 擴扩 掃扫 揚扬 擾扰
+撫抚 搶抢 護护 擔担 擬拟 揀拣 擁拥 攔拦 擰拧 撥拨 擇择 掛挂 擋挡 據据 擠挤 換换 損损 撿捡
+撈捞 攜携 擺摆 搖摇 攝摄 數数 敵敌 斷断 時时 晉晋 曬晒 暫暂 曉晓 朧胧 術术 條条 來来 楊杨
+極极 構构 槍枪 樣样 樹树 橋桥 機机 檢检 樓楼 標标 歡欢 歐欧 殘残 殺杀 毀毁 氣气 漢汉 湯汤
+溝沟 沒没 澤泽 潔洁 灑洒 濟济 濃浓 淚泪 淺浅 滅灭 測测 湧涌 滿满 滾滚 灣湾 濕湿 溫温 灘滩
+潛潜 災灾 煙烟 熱热 燈灯 爐炉 爛烂 無无 牆墙 狀状 獨独 獲获 猶犹 獄狱 現现 環环 瑪玛 畫画
+疊叠 療疗 瘋疯 盡尽 監监 盤盘 睜睁 礦矿 碼码 確确 禮礼 禍祸 離离 種种 稱称 穩稳 窮穷 竊窃
+競竞 筆笔 節节 範范 築筑 簡简 類类 糧粮 紀纪 約约 紅红 納纳 純纯 紙纸 級级 紛纷 細细 終终
+組组 結结 絕绝 統统 經经 綠绿 網网 維维 線线 練练 績绩 續续 罰罚 罵骂 羅罗 職职 聯联 聽听
+腦脑 膽胆 臉脸 藝艺 蘇苏 藥药 蟲虫 補补 裝装 見见 規规 視视 覺觉 觀观 計计 訂订 認认 討讨
+讓让 訓训 議议 記记 講讲 許许 論论 設设 訪访 證证 評评 識识 詞词 試试 詩诗 話话 該该 說说
+誰谁 課课 調调 談谈 請请 讀读 貝贝 負负 財财 貨货 貧贫 購购 貸贷 費费 資资 賭赌 賽赛 趕赶
+趙赵 跡迹 踐践 車车 軟软 輕轻 較较 載载 輪轮 輸输 辭辞 邊边 遠远 運运 過过 達达 違违 遞递
+選选 遺遗 還还 鄧邓 鄭郑 醜丑 釋释 針针 釣钓 鈔钞 銀银 銅铜 鋼钢 錢钱 錯错 鍵键 鏡镜 鐘钟
+鐵铁 長长 門门 閃闪 閉闭 間间 閱阅 闆板 陽阳 陰阴 陳陈 際际 險险 隨随 隱隐 雜杂 難难 雞鸡
+電电 靈灵 韓韩 頁页 順顺 須须 預预 領领 頻频 題题 顏颜 願愿 顧顾 風风 飛飞 飯饭 飲饮 館馆
+馬马 駕驾 騙骗 驗验 鬥斗 魚鱼 鳥鸟 鴨鸭 麥麦 黃黄 點点 齊齐 齒齿 龍龙 龜龟 黴霉 麼么 誌志
+係系 賤贱 騷骚 獵猎 贓赃 詐诈
+`
+
+// simplified 繁体字到简体字的映射
+var simplified = buildSimplified()
+
+// buildSimplified 解析繁简对照表
+func buildSimplified() map[rune]rune {
+	fields := strings.Fields(traditionalPairs)
+	table := make(map[rune]rune, len(fields))
+	for _, pair := range fields {
+		runes := []rune(pair)
+		if len(runes) == 2 {
+			table[runes[0]] = runes[1]
+		}
+	}
+	return table
+}
+
+// normalizeRune 规范化单个字符：全角转半角、大写转小写、繁体转简体
+// 返回：
+//   - 规范化后的字符
+//   - 是否参与匹配（空白、标点、符号等插入字符不参与匹配）
+func normalizeRune(r rune) (rune, bool) {
+	switch {
+	case r == '　':
+		r = ' '
+	case r >= '！' && r <= '～':
+		r -= 0xFEE0
+	}
+
+	if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+		return r, false
+	}
+
+	r = unicode.ToLower(r)
+	if s, ok := simplified[r]; ok {
+		r = s
+	}
+	return r, true
+}
+
+// normalize 规范化文本，去掉不参与匹配的字符
+// 返回：
+//   - 规范化后的字符序列
+//   - 每个字符在原文中的位置（按字符计）
+func normalize(text string) ([]rune, []int) {
+	runes := make([]rune, 0, len(text))
+	positions := make([]int, 0, len(text))
+	index := 0
+	for _, r := range text {
+		if n, ok := normalizeRune(r); ok {
+			runes = append(runes, n)
+			positions = append(positions, index)
+		}
+		index++
+	}
+	return runes, positions
+}
+
+// normalizeWord 规范化敏感词（与文本使用相同规则，保证变体也能命中）
+func normalizeWord(word string) string {
+	runes, _ := normalize(word)
+	return string(runes)
+}
@@ -0,0 +1,125 @@
+/*
+Package filter provides the sensitive-word dictionary shared by content services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mode 命中敏感词后的处理方式
+type Mode string
+
+const (
+	ModeNone   Mode = ""       // 未命中
+	ModeMask   Mode = "mask"   // 以*替换后放行
+	ModeReview Mode = "review" // 转人工审核
+	ModeBlock  Mode = "block"  // 直接拒绝
+)
+
+// modeRank 处理方式的严格程度
+var modeRank = map[Mode]int{
+	ModeNone:   0,
+	ModeMask:   1,
+	ModeReview: 2,
+	ModeBlock:  3,
+}
+
+// stricter 是否比另一种处理方式更严格
+func (m Mode) stricter(other Mode) bool {
+	return modeRank[m] > modeRank[other]
+}
+
+// Hit 命中的敏感词
+type Hit struct {
+	Word     string `json:"word"`
+	Category string `json:"category"`
+	Severity int    `json:"severity"`
+	Mode     Mode   `json:"mode"`
+	Count    int    `json:"count"` // 命中次数
+}
+
+// Result 过滤结果
+type Result struct {
+	Text string `json:"text"` // 低级别敏感词替换为*后的文本
+	Mode Mode   `json:"mode"` // 所有命中中最严格的处理方式
+	Hits []Hit  `json:"hits"`
+}
+
+// Blocked 是否应拒绝提交
+func (r *Result) Blocked() bool {
+	return r.Mode == ModeBlock
+}
+
+// NeedsReview 是否应转人工审核
+func (r *Result) NeedsReview() bool {
+	return r.Mode == ModeReview
+}
+
+// Words 返回指定处理方式的命中词（不指定时返回全部）
+func (r *Result) Words(modes ...Mode) []string {
+	var words []string
+	for _, hit := range r.Hits {
+		if len(modes) == 0 || containsMode(modes, hit.Mode) {
+			words = append(words, hit.Word)
+		}
+	}
+	return words
+}
+
+// Notes 生成审核备注（列出需要人工审核的命中词及分类）
+func (r *Result) Notes() string {
+	var parts []string
+	for _, hit := range r.Hits {
+		if hit.Mode == ModeReview || hit.Mode == ModeBlock {
+			parts = append(parts, fmt.Sprintf("%s(%s)", hit.Word, hit.Category))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "命中敏感词：" + strings.Join(parts, "、")
+}
+
+// Err 内容被拒绝时返回包含违禁词的错误，否则返回nil
+func (r *Result) Err() error {
+	if !r.Blocked() {
+		return nil
+	}
+	return fmt.Errorf("%w：%s", ErrBlocked, strings.Join(r.Words(ModeBlock), "、"))
+}
+
+// merge 合并另一个字段的过滤结果
+func (r *Result) merge(other *Result) {
+	for _, hit := range other.Hits {
+		merged := false
+		for i := range r.Hits {
+			if r.Hits[i].Word == hit.Word {
+				r.Hits[i].Count += hit.Count
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			r.Hits = append(r.Hits, hit)
+		}
+	}
+	if other.Mode.stricter(r.Mode) {
+		r.Mode = other.Mode
+	}
+}
+
+// containsMode 检查处理方式是否在列表中
+func containsMode(modes []Mode, mode Mode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
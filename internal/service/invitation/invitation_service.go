@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
 
 	"gorm.io/gorm"
 )
@@ -31,7 +32,8 @@ var (
 
 // InvitationService 邀请服务
 type InvitationService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	notifier notification.Publisher
 }
 
 // NewInvitationService 创建新的邀请服务
@@ -41,6 +43,11 @@ func NewInvitationService(db *gorm.DB) *InvitationService {
 	}
 }
 
+// SetNotifier 设置通知发布器（邀请完成时通知邀请者）
+func (s *InvitationService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
 // GenerateInviteCode 生成邀请码
 // 参数：
 //   - inviterID: 邀请者ID
@@ -247,6 +254,22 @@ func (s *InvitationService) CompleteInvitation(inviteCode string, inviteeID uint
 		return fmt.Errorf("提交事务失败: %w", err)
 	}
 
+	// 通知邀请者
+	if s.notifier != nil {
+		content := fmt.Sprintf("用户 %s 通过您的邀请码完成了注册", invitee.Username)
+		if pointsAward > 0 {
+			content += fmt.Sprintf("，奖励 %d 积分已到账", pointsAward)
+		}
+		_ = s.notifier.Publish(&notification.Event{
+			Type:       model.NotificationTypeInviteCompleted,
+			UserID:     inviter.ID,
+			Title:      "邀请已完成",
+			Content:    content,
+			TargetType: "invitation",
+			TargetID:   &invitation.ID,
+		})
+	}
+
 	return nil
 }
 
@@ -0,0 +1,101 @@
+/*
+Package mail provides development mail drivers and the mailer factory.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"resource-share-site/internal/config"
+)
+
+// NewMailer 根据配置创建邮件发送器
+// 支持的驱动：smtp（默认）、file（写入.eml文件）、log（输出到日志）
+func NewMailer(cfg *config.MailConfig) Mailer {
+	if cfg == nil {
+		cfg = config.DefaultMailConfig()
+	}
+
+	switch strings.ToLower(cfg.Driver) {
+	case "file":
+		return NewFileMailer(cfg)
+	case "log":
+		return NewLogMailer(cfg)
+	default:
+		return NewSMTPMailer(cfg)
+	}
+}
+
+// FileMailer 文件邮件驱动（开发环境使用，将邮件保存为.eml文件）
+type FileMailer struct {
+	cfg     *config.MailConfig
+	counter uint64
+}
+
+// NewFileMailer 创建文件邮件驱动
+func NewFileMailer(cfg *config.MailConfig) *FileMailer {
+	return &FileMailer{
+		cfg: cfg,
+	}
+}
+
+// Send 将邮件写入文件
+func (m *FileMailer) Send(msg *Message) error {
+	if msg == nil || len(msg.To) == 0 {
+		return errors.New("收件人不能为空")
+	}
+
+	dir := m.cfg.FileDir
+	if dir == "" {
+		dir = "storage/mails"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建邮件目录失败: %w", err)
+	}
+
+	// 文件名使用时间戳加序号，避免同一时刻发送多封邮件时冲突
+	seq := atomic.AddUint64(&m.counter, 1)
+	filename := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102-150405.000000"), seq)
+
+	data := BuildMIMEMessage(m.cfg.From, m.cfg.FromName, msg)
+	if err := os.WriteFile(filepath.Join(dir, filename), data, 0o644); err != nil {
+		return fmt.Errorf("写入邮件文件失败: %w", err)
+	}
+
+	return nil
+}
+
+// LogMailer 日志邮件驱动（开发环境使用，将邮件内容输出到日志）
+type LogMailer struct {
+	cfg *config.MailConfig
+}
+
+// NewLogMailer 创建日志邮件驱动
+func NewLogMailer(cfg *config.MailConfig) *LogMailer {
+	return &LogMailer{
+		cfg: cfg,
+	}
+}
+
+// Send 将邮件输出到日志
+func (m *LogMailer) Send(msg *Message) error {
+	if msg == nil || len(msg.To) == 0 {
+		return errors.New("收件人不能为空")
+	}
+
+	log.Printf("[mail] from=%s to=%s subject=%q\n%s",
+		m.cfg.From, strings.Join(msg.To, ","), msg.Subject, msg.Body)
+
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+Package mail provides outgoing mail delivery services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+)
+
+// Message 邮件消息
+type Message struct {
+	To      []string // 收件人
+	Subject string   // 主题
+	Body    string   // 正文
+	HTML    bool     // 是否为HTML正文
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	// 发送邮件
+	Send(msg *Message) error
+}
+
+// SMTPMailer SMTP邮件发送实现
+type SMTPMailer struct {
+	cfg *config.MailConfig
+}
+
+// NewSMTPMailer 创建SMTP邮件发送器
+func NewSMTPMailer(cfg *config.MailConfig) *SMTPMailer {
+	if cfg == nil {
+		cfg = config.DefaultMailConfig()
+	}
+	return &SMTPMailer{
+		cfg: cfg,
+	}
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(msg *Message) error {
+	if msg == nil || len(msg.To) == 0 {
+		return errors.New("收件人不能为空")
+	}
+
+	// 用户名为空时不进行认证（便于使用本地SMTP测试服务）
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	data := BuildMIMEMessage(m.cfg.From, m.cfg.FromName, msg)
+	if err := m.sendMail(auth, msg.To, data); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+
+	return nil
+}
+
+// sendMail 与 smtp.SendMail 流程相同，但连接和整个会话都受超时限制（SMTP服务无响应时不会一直阻塞）
+func (m *SMTPMailer) sendMail(auth smtp.Auth, to []string, data []byte) error {
+	timeout := m.cfg.GetTimeout()
+	conn, err := net.DialTimeout("tcp", m.cfg.GetAddr(), timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err := client.Mail(m.cfg.From); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// BuildMIMEMessage 构建MIME格式的邮件内容
+func BuildMIMEMessage(from, fromName string, msg *Message) []byte {
+	var buf bytes.Buffer
+
+	sender := from
+	if fromName != "" {
+		sender = fmt.Sprintf("%s <%s>", mime.BEncoding.Encode("UTF-8", fromName), from)
+	}
+
+	contentType := "text/plain"
+	if msg.HTML {
+		contentType = "text/html"
+	}
+
+	buf.WriteString("From: " + sender + "\r\n")
+	buf.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: " + contentType + "; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+
+	// 正文使用base64编码，每行76个字符
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,284 @@
+/*
+Package notification provides notification delivery channels.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/mail"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnsafeWebhookURL Webhook地址不是https公网地址
+var ErrUnsafeWebhookURL = errors.New("Webhook地址必须是https公网地址")
+
+// maxWebhookRedirects Webhook最多跟随的跳转次数
+const maxWebhookRedirects = 3
+
+// blockedWebhookNetworks 不允许Webhook访问的保留网段（回环、内网、链路本地等由 isPublicIP 另行判断）
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // 本网络
+	"100.64.0.0/10", // 运营商级NAT
+	"192.0.0.0/24",  // IETF协议分配
+	"198.18.0.0/15", // 网络基准测试
+	"240.0.0.0/4",   // 保留地址
+	"64:ff9b::/96",  // NAT64（可映射到内网IPv4地址）
+)
+
+// Channel 通知投递通道接口
+type Channel interface {
+	// 通道名称
+	Name() model.NotificationChannel
+
+	// 投递通知
+	Deliver(recipient *model.User, notification *model.Notification, pref *model.NotificationPreference) error
+}
+
+// InAppChannel 站内信通道（写入通知收件箱）
+type InAppChannel struct {
+	db *gorm.DB
+}
+
+// NewInAppChannel 创建站内信通道
+func NewInAppChannel(db *gorm.DB) *InAppChannel {
+	return &InAppChannel{
+		db: db,
+	}
+}
+
+// Name 通道名称
+func (c *InAppChannel) Name() model.NotificationChannel {
+	return model.NotificationChannelInApp
+}
+
+// Deliver 投递站内信
+func (c *InAppChannel) Deliver(recipient *model.User, notification *model.Notification, pref *model.NotificationPreference) error {
+	if err := c.db.Create(notification).Error; err != nil {
+		return fmt.Errorf("保存站内通知失败: %w", err)
+	}
+	return nil
+}
+
+// EmailChannel 邮件通道
+type EmailChannel struct {
+	mailer mail.Mailer
+}
+
+// NewEmailChannel 创建邮件通道
+func NewEmailChannel(mailer mail.Mailer) *EmailChannel {
+	return &EmailChannel{
+		mailer: mailer,
+	}
+}
+
+// Name 通道名称
+func (c *EmailChannel) Name() model.NotificationChannel {
+	return model.NotificationChannelEmail
+}
+
+// Deliver 发送通知邮件
+func (c *EmailChannel) Deliver(recipient *model.User, notification *model.Notification, pref *model.NotificationPreference) error {
+	if recipient.Email == "" {
+		return errors.New("用户未设置邮箱")
+	}
+
+	body := notification.Content
+	if notification.Link != "" {
+		body += "\n\n查看详情: " + notification.Link
+	}
+
+	return c.mailer.Send(&mail.Message{
+		To:      []string{recipient.Email},
+		Subject: notification.Title,
+		Body:    body,
+	})
+}
+
+// WebhookChannel Webhook通道
+type WebhookChannel struct {
+	client *http.Client
+	secret string
+}
+
+// WebhookPayload Webhook推送内容
+type WebhookPayload struct {
+	Event      model.NotificationType `json:"event"`
+	UserID     uint                   `json:"user_id"`
+	Title      string                 `json:"title"`
+	Content    string                 `json:"content"`
+	Link       string                 `json:"link,omitempty"`
+	TargetType string                 `json:"target_type,omitempty"`
+	TargetID   *uint                  `json:"target_id,omitempty"`
+	Timestamp  int64                  `json:"timestamp"`
+}
+
+// NewWebhookChannel 创建Webhook通道
+// 参数：
+//   - timeout: 请求超时时间
+//   - secret: 签名密钥（为空则不签名）
+func NewWebhookChannel(timeout time.Duration, secret string) *WebhookChannel {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		Proxy:               nil, // 不经过代理，保证连接的是校验过的地址
+		DialContext:         publicDialContext(dialer),
+		TLSHandshakeTimeout: timeout,
+	}
+	return &WebhookChannel{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxWebhookRedirects {
+					return errors.New("Webhook跳转次数过多")
+				}
+				return ValidateWebhookURL(req.URL.String())
+			},
+		},
+		secret: secret,
+	}
+}
+
+// ValidateWebhookURL 检查Webhook地址（只允许https，主机为IP时必须是公网地址；域名在连接时解析并检查）
+func ValidateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" || parsed.User != nil {
+		return ErrUnsafeWebhookURL
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !isPublicIP(ip) {
+		return ErrUnsafeWebhookURL
+	}
+	if host := strings.ToLower(parsed.Hostname()); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrUnsafeWebhookURL
+	}
+	return nil
+}
+
+// publicDialContext 解析主机名后只连接公网地址（防止通过域名解析或跳转访问内网、回环和云服务元数据地址）
+func publicDialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("无法解析Webhook地址: %s", host)
+		}
+		// 任一解析结果不是公网地址都拒绝，避免DNS轮换绕过检查
+		for _, ip := range ips {
+			if !isPublicIP(ip.IP) {
+				return nil, ErrUnsafeWebhookURL
+			}
+		}
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+	}
+}
+
+// isPublicIP 判断是否为公网地址
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// mustParseCIDRs 解析网段列表
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// Name 通道名称
+func (c *WebhookChannel) Name() model.NotificationChannel {
+	return model.NotificationChannelWebhook
+}
+
+// Deliver 推送Webhook
+func (c *WebhookChannel) Deliver(recipient *model.User, notification *model.Notification, pref *model.NotificationPreference) error {
+	if pref == nil || pref.WebhookURL == "" {
+		return errors.New("未配置Webhook地址")
+	}
+	if err := ValidateWebhookURL(pref.WebhookURL); err != nil {
+		return err
+	}
+
+	payload := WebhookPayload{
+		Event:      notification.Type,
+		UserID:     recipient.ID,
+		Title:      notification.Title,
+		Content:    notification.Content,
+		Link:       notification.Link,
+		TargetType: notification.TargetType,
+		TargetID:   notification.TargetID,
+		Timestamp:  time.Now().Unix(),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化Webhook内容失败: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, pref.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Webhook请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-Event", string(notification.Type))
+
+	// 使用HMAC-SHA256签名，便于接收方校验来源
+	if c.secret != "" {
+		mac := hmac.New(sha256.New, []byte(c.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-SHA256", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送Webhook失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook返回异常状态码: %d", resp.StatusCode)
+	}
+
+	return nil
+}
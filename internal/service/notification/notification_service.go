@@ -0,0 +1,421 @@
+/*
+Package notification provides user notification services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrNotificationNotFound    = errors.New("通知不存在")
+	ErrInvalidNotificationType = errors.New("无效的通知类型")
+	ErrDeliveryQueueFull       = errors.New("通知投递队列已满")
+)
+
+// Event 通知事件
+type Event struct {
+	Type       model.NotificationType // 通知类型
+	UserID     uint                   // 接收者ID
+	Title      string                 // 标题
+	Content    string                 // 内容
+	Link       string                 // 跳转链接
+	TargetType string                 // 关联对象类型
+	TargetID   *uint                  // 关联对象ID
+}
+
+// Publisher 通知发布接口（供其他服务发布事件）
+type Publisher interface {
+	// 发布通知事件
+	Publish(event *Event) error
+}
+
+// delivery 待后台投递的通知
+type delivery struct {
+	channel      Channel
+	recipient    *model.User
+	notification *model.Notification
+	pref         *model.NotificationPreference
+}
+
+// NotificationService 通知服务
+type NotificationService struct {
+	db            *gorm.DB
+	channels      []Channel // 同步投递通道（站内信）
+	asyncChannels []Channel // 后台投递通道（邮件、Webhook）
+	queue         chan *delivery
+	workers       int
+	startOnce     sync.Once
+}
+
+// NewNotificationService 创建通知服务（默认注册站内信通道）
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{
+		db:       db,
+		channels: []Channel{NewInAppChannel(db)},
+		queue:    make(chan *delivery, 1000),
+		workers:  2,
+	}
+}
+
+// RegisterChannel 注册同步投递通道（在发布方的调用中直接投递）
+func (s *NotificationService) RegisterChannel(channel Channel) {
+	s.channels = append(s.channels, channel)
+}
+
+// RegisterAsyncChannel 注册后台投递通道（加入投递队列后立即返回，由 Run 启动的投递协程发送，不阻塞发布方）
+func (s *NotificationService) RegisterAsyncChannel(channel Channel) {
+	s.asyncChannels = append(s.asyncChannels, channel)
+}
+
+// SetQueue 设置投递队列长度和投递协程数，需在 Run 之前调用
+func (s *NotificationService) SetQueue(size, workers int) {
+	if size > 0 {
+		s.queue = make(chan *delivery, size)
+	}
+	if workers > 0 {
+		s.workers = workers
+	}
+}
+
+// Run 启动后台投递协程，ctx 结束时停止
+func (s *NotificationService) Run(ctx context.Context) {
+	s.startOnce.Do(func() {
+		var wg sync.WaitGroup
+		for i := 0; i < s.workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.deliverQueued(ctx)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// deliverQueued 从投递队列取出通知并投递
+func (s *NotificationService) deliverQueued(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-s.queue:
+			if err := d.channel.Deliver(d.recipient, d.notification, d.pref); err != nil {
+				log.Printf("通知 %s 投递给用户 %d 失败: %v", d.channel.Name(), d.recipient.ID, err)
+			}
+		}
+	}
+}
+
+// Publish 发布通知事件，按用户偏好投递到各通道（站内信同步写入，邮件和Webhook由后台协程投递）
+// 参数：
+//   - event: 通知事件
+//
+// 返回：
+//   - 错误信息（各通道投递失败时合并返回）
+func (s *NotificationService) Publish(event *Event) error {
+	if event == nil || event.UserID == 0 {
+		return errors.New("通知接收者不能为空")
+	}
+	if !isValidType(event.Type) {
+		return ErrInvalidNotificationType
+	}
+
+	// 获取接收者
+	var recipient model.User
+	if err := s.db.First(&recipient, event.UserID).Error; err != nil {
+		return fmt.Errorf("查询通知接收者失败: %w", err)
+	}
+
+	// 获取用户偏好
+	pref, err := s.GetPreference(event.UserID, event.Type)
+	if err != nil {
+		return err
+	}
+
+	notification := &model.Notification{
+		UserID:     event.UserID,
+		Type:       event.Type,
+		Title:      event.Title,
+		Content:    event.Content,
+		Link:       event.Link,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+	}
+
+	var errs []error
+	for _, channel := range s.channels {
+		if !channelEnabled(pref, channel.Name()) {
+			continue
+		}
+		if err := channel.Deliver(&recipient, notification, pref); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), err))
+		}
+	}
+
+	// 邮件、Webhook等外部通道加入投递队列（队列已满时丢弃，不阻塞发布方）
+	for _, channel := range s.asyncChannels {
+		if !channelEnabled(pref, channel.Name()) {
+			continue
+		}
+		select {
+		case s.queue <- &delivery{channel: channel, recipient: &recipient, notification: notification, pref: pref}:
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), ErrDeliveryQueueFull))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetInbox 获取用户收件箱
+// 参数：
+//   - userID: 用户ID
+//   - unreadOnly: 是否只返回未读通知
+//   - notificationType: 通知类型筛选（可选）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 通知列表
+//   - 总数
+//   - 错误信息
+func (s *NotificationService) GetInbox(userID uint, unreadOnly bool, notificationType model.NotificationType, page, pageSize int) ([]model.Notification, int64, error) {
+	var notifications []model.Notification
+	var total int64
+
+	query := s.db.Model(&model.Notification{}).Where("user_id = ?", userID)
+
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询通知总数失败: %w", err)
+	}
+
+	// 获取列表
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询通知列表失败: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+// GetUnreadCount 获取未读通知数（按类型分组）
+// 参数：
+//   - userID: 用户ID
+//
+// 返回：
+//   - 未读总数
+//   - 各类型未读数
+//   - 错误信息
+func (s *NotificationService) GetUnreadCount(userID uint) (int64, map[model.NotificationType]int64, error) {
+	var rows []struct {
+		Type  model.NotificationType
+		Count int64
+	}
+
+	if err := s.db.Model(&model.Notification{}).
+		Select("type, COUNT(*) as count").
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Group("type").
+		Scan(&rows).Error; err != nil {
+		return 0, nil, fmt.Errorf("查询未读通知数失败: %w", err)
+	}
+
+	var total int64
+	byType := make(map[model.NotificationType]int64)
+	for _, row := range rows {
+		byType[row.Type] = row.Count
+		total += row.Count
+	}
+
+	return total, byType, nil
+}
+
+// MarkAsRead 标记通知为已读
+// 参数：
+//   - userID: 用户ID
+//   - notificationIDs: 通知ID列表
+//
+// 返回：
+//   - 标记数量
+//   - 错误信息
+func (s *NotificationService) MarkAsRead(userID uint, notificationIDs []uint) (int64, error) {
+	if len(notificationIDs) == 0 {
+		return 0, nil
+	}
+
+	result := s.db.Model(&model.Notification{}).
+		Where("user_id = ? AND id IN ? AND is_read = ?", userID, notificationIDs, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("标记已读失败: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// MarkAllAsRead 标记全部通知为已读
+func (s *NotificationService) MarkAllAsRead(userID uint) (int64, error) {
+	result := s.db.Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("标记全部已读失败: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// BatchDelete 批量删除通知（软删除）
+// 参数：
+//   - userID: 用户ID
+//   - notificationIDs: 通知ID列表
+//
+// 返回：
+//   - 删除数量
+//   - 错误信息
+func (s *NotificationService) BatchDelete(userID uint, notificationIDs []uint) (int64, error) {
+	if len(notificationIDs) == 0 {
+		return 0, nil
+	}
+
+	result := s.db.Where("user_id = ? AND id IN ?", userID, notificationIDs).
+		Delete(&model.Notification{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("删除通知失败: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// GetPreference 获取用户某类通知的偏好（未配置时返回默认偏好）
+func (s *NotificationService) GetPreference(userID uint, notificationType model.NotificationType) (*model.NotificationPreference, error) {
+	var pref model.NotificationPreference
+	err := s.db.Where("user_id = ? AND type = ?", userID, notificationType).First(&pref).Error
+	if err == nil {
+		return &pref, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultPreference(userID, notificationType), nil
+	}
+	return nil, fmt.Errorf("查询通知偏好失败: %w", err)
+}
+
+// GetPreferences 获取用户所有类型的通知偏好
+func (s *NotificationService) GetPreferences(userID uint) ([]model.NotificationPreference, error) {
+	var saved []model.NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&saved).Error; err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败: %w", err)
+	}
+
+	savedByType := make(map[model.NotificationType]model.NotificationPreference)
+	for _, pref := range saved {
+		savedByType[pref.Type] = pref
+	}
+
+	// 未配置的类型使用默认偏好补齐
+	prefs := make([]model.NotificationPreference, 0, len(model.NotificationTypes))
+	for _, t := range model.NotificationTypes {
+		if pref, ok := savedByType[t]; ok {
+			prefs = append(prefs, pref)
+		} else {
+			prefs = append(prefs, *DefaultPreference(userID, t))
+		}
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreference 更新用户通知偏好
+// 参数：
+//   - pref: 偏好设置（UserID和Type确定唯一记录）
+//
+// 返回：
+//   - 错误信息
+func (s *NotificationService) UpdatePreference(pref *model.NotificationPreference) error {
+	if !isValidType(pref.Type) {
+		return ErrInvalidNotificationType
+	}
+	if pref.Webhook && pref.WebhookURL == "" {
+		return errors.New("启用Webhook通知时必须填写Webhook地址")
+	}
+	if pref.WebhookURL != "" {
+		if err := ValidateWebhookURL(pref.WebhookURL); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "webhook", "webhook_url", "updated_at"}),
+	}).Create(pref).Error; err != nil {
+		return fmt.Errorf("更新通知偏好失败: %w", err)
+	}
+
+	return nil
+}
+
+// DefaultPreference 默认通知偏好：仅开启站内信
+func DefaultPreference(userID uint, notificationType model.NotificationType) *model.NotificationPreference {
+	return &model.NotificationPreference{
+		UserID: userID,
+		Type:   notificationType,
+		InApp:  true,
+	}
+}
+
+// channelEnabled 检查偏好中是否启用了通道
+func channelEnabled(pref *model.NotificationPreference, channel model.NotificationChannel) bool {
+	switch channel {
+	case model.NotificationChannelInApp:
+		return pref.InApp
+	case model.NotificationChannelEmail:
+		return pref.Email
+	case model.NotificationChannelWebhook:
+		return pref.Webhook
+	default:
+		return false
+	}
+}
+
+// isValidType 检查通知类型是否有效
+func isValidType(notificationType model.NotificationType) bool {
+	for _, t := range model.NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
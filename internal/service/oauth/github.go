@@ -0,0 +1,133 @@
+/*
+Package oauth provides the GitHub identity provider.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"resource-share-site/internal/config"
+)
+
+// GitHub默认端点
+const (
+	githubAuthURL     = "https://github.com/login/oauth/authorize"
+	githubTokenURL    = "https://github.com/login/oauth/access_token"
+	githubUserInfoURL = "https://api.github.com/user"
+)
+
+// githubUser GitHub用户信息
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// githubEmail GitHub邮箱信息
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// GitHubProvider GitHub身份提供方（OAuth2，不支持OIDC）
+type GitHubProvider struct {
+	cfg    config.OAuthProviderConfig
+	client *http.Client
+}
+
+// NewGitHubProvider 创建GitHub身份提供方（端点可通过配置覆盖，便于对接模拟服务）
+func NewGitHubProvider(cfg config.OAuthProviderConfig) *GitHubProvider {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = githubAuthURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = githubTokenURL
+	}
+	if cfg.UserInfoURL == "" {
+		cfg.UserInfoURL = githubUserInfoURL
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	return &GitHubProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Name 提供方标识
+func (p *GitHubProvider) Name() string {
+	return p.cfg.Name
+}
+
+// DisplayName 显示名称
+func (p *GitHubProvider) DisplayName() string {
+	if p.cfg.DisplayName != "" {
+		return p.cfg.DisplayName
+	}
+	return "GitHub"
+}
+
+// AuthCodeURL 生成授权地址
+func (p *GitHubProvider) AuthCodeURL(state, codeChallenge, nonce, redirectURI string) (string, error) {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	return buildAuthURL(p.cfg.AuthURL, params)
+}
+
+// Exchange 使用授权码换取令牌并获取身份信息（邮箱取已验证的主邮箱）
+func (p *GitHubProvider) Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.client, p.cfg, p.cfg.TokenURL, code, codeVerifier, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	var user githubUser
+	if err := getJSON(ctx, p.client, p.cfg.UserInfoURL, token.AccessToken, &user); err != nil {
+		return nil, fmt.Errorf("获取GitHub用户信息失败: %w", err)
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("%w: GitHub用户信息缺少ID", ErrExchangeFailed)
+	}
+
+	identity := &Identity{
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Email:     user.Email,
+		Name:      user.Name,
+		Username:  user.Login,
+		AvatarURL: user.AvatarURL,
+	}
+
+	// 公开邮箱不一定已验证，以邮箱列表中的主邮箱为准
+	var emails []githubEmail
+	if err := getJSON(ctx, p.client, strings.TrimRight(p.cfg.UserInfoURL, "/")+"/emails", token.AccessToken, &emails); err == nil {
+		for _, email := range emails {
+			if email.Primary {
+				identity.Email = email.Email
+				identity.EmailVerified = email.Verified
+				break
+			}
+		}
+	}
+
+	return identity, nil
+}
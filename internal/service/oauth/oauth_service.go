@@ -0,0 +1,488 @@
+/*
+Package oauth provides social login and account linking services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/auth"
+	"resource-share-site/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrInvalidState          = errors.New("授权请求无效或已过期，请重新登录")
+	ErrMissingEmail          = errors.New("第三方账户未提供邮箱，无法登录")
+	ErrIdentityLinked        = errors.New("该第三方账户已关联其他用户")
+	ErrProviderAlreadyLinked = errors.New("您已关联该登录方式")
+	ErrEmailLinkRequired     = errors.New("该邮箱已注册，请使用密码登录后在账户设置中关联")
+	ErrIdentityNotFound      = errors.New("未关联该登录方式")
+	ErrLastLoginMethod       = errors.New("这是唯一的登录方式，请先设置密码后再解除关联")
+	ErrAccountDisabled       = errors.New("账户已被禁用或未激活")
+)
+
+// 回调处理结果类型
+const (
+	CallbackActionLogin    = "login"    // 已关联的身份登录
+	CallbackActionRegister = "register" // 首次登录，创建新用户
+	CallbackActionLink     = "link"     // 为当前用户关联身份
+)
+
+// usernamePattern 用户名中允许的字符
+var usernamePattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// ProviderInfo 身份提供方信息
+type ProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// CallbackResult 回调处理结果
+type CallbackResult struct {
+	Action   string              `json:"action"`          // login/register/link
+	Provider string              `json:"provider"`        // 提供方
+	Login    *auth.LoginResponse `json:"login,omitempty"` // 登录结果（关联身份时为空）
+	Identity *model.UserIdentity `json:"identity"`        // 关联的身份
+}
+
+// OAuthService 第三方登录服务
+type OAuthService struct {
+	db          *gorm.DB
+	authService *auth.AuthServiceImpl
+	cfg         *config.OAuthConfig
+	siteURL     string
+	providers   map[string]Provider
+	order       []string
+}
+
+// NewOAuthService 创建第三方登录服务（按配置创建已启用的身份提供方）
+// 参数：
+//   - db: 数据库连接
+//   - authService: 认证服务（用于注册和签发登录Token）
+//   - cfg: 第三方登录配置（为nil时使用默认配置）
+//   - siteURL: 站点地址（用于生成默认回调地址）
+func NewOAuthService(db *gorm.DB, authService *auth.AuthServiceImpl, cfg *config.OAuthConfig, siteURL string) *OAuthService {
+	if cfg == nil {
+		cfg = config.DefaultOAuthConfig()
+	}
+
+	s := &OAuthService{
+		db:          db,
+		authService: authService,
+		cfg:         cfg,
+		siteURL:     strings.TrimRight(siteURL, "/"),
+		providers:   make(map[string]Provider),
+	}
+
+	for _, providerCfg := range cfg.Providers {
+		if !providerCfg.Enabled {
+			continue
+		}
+		provider, err := NewProvider(providerCfg)
+		if err != nil {
+			log.Printf("跳过第三方登录提供方 %q: %v", providerCfg.Name, err)
+			continue
+		}
+		s.RegisterProvider(provider)
+	}
+
+	return s
+}
+
+// RegisterProvider 注册身份提供方（同名覆盖）
+func (s *OAuthService) RegisterProvider(provider Provider) {
+	if _, exists := s.providers[provider.Name()]; !exists {
+		s.order = append(s.order, provider.Name())
+	}
+	s.providers[provider.Name()] = provider
+}
+
+// ListProviders 获取已启用的身份提供方
+func (s *OAuthService) ListProviders() []ProviderInfo {
+	infos := make([]ProviderInfo, 0, len(s.order))
+	for _, name := range s.order {
+		infos = append(infos, ProviderInfo{
+			Name:        name,
+			DisplayName: s.providers[name].DisplayName(),
+		})
+	}
+	return infos
+}
+
+// BeginAuth 发起授权请求
+// 参数：
+//   - providerName: 提供方标识
+//   - inviteCode: 邀请码（首次注册时使用，可选）
+//   - linkUserID: 不为nil时为该用户关联身份
+//
+// 返回：
+//   - 授权地址
+//   - 错误信息
+func (s *OAuthService) BeginAuth(providerName, inviteCode string, linkUserID *uint) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", ErrProviderNotFound
+	}
+
+	state, err := randomString(32)
+	if err != nil {
+		return "", fmt.Errorf("生成授权请求失败: %w", err)
+	}
+	verifier, err := GenerateCodeVerifier()
+	if err != nil {
+		return "", fmt.Errorf("生成授权请求失败: %w", err)
+	}
+	nonce, err := randomString(16)
+	if err != nil {
+		return "", fmt.Errorf("生成授权请求失败: %w", err)
+	}
+
+	redirectURI := s.redirectURI(providerName)
+	authURL, err := provider.AuthCodeURL(state, CodeChallengeS256(verifier), nonce, redirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	record := &model.OAuthState{
+		Provider:     providerName,
+		StateHash:    hashState(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		RedirectURI:  redirectURI,
+		InviteCode:   strings.TrimSpace(inviteCode),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(s.cfg.GetStateTTL()),
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return "", fmt.Errorf("保存授权请求失败: %w", err)
+	}
+
+	return authURL, nil
+}
+
+// HandleCallback 处理授权回调
+// 依次尝试：为当前用户关联身份 -> 已关联身份登录 -> 按已验证邮箱关联已有用户 -> 注册新用户
+// 参数：
+//   - ctx: 请求上下文
+//   - providerName: 提供方标识
+//   - state: 授权请求state
+//   - code: 授权码
+//   - ip: 客户端IP
+//   - userAgent: 客户端用户代理
+//
+// 返回：
+//   - 回调处理结果
+//   - 错误信息
+func (s *OAuthService) HandleCallback(ctx context.Context, providerName, state, code, ip, userAgent string) (*CallbackResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+
+	record, err := s.consumeState(providerName, state)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := provider.Exchange(ctx, code, record.CodeVerifier, record.Nonce, record.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	// 为已登录用户关联身份
+	if record.LinkUserID != nil {
+		linked, err := s.linkIdentity(*record.LinkUserID, providerName, identity)
+		if err != nil {
+			return nil, err
+		}
+		return &CallbackResult{Action: CallbackActionLink, Provider: providerName, Identity: linked}, nil
+	}
+
+	// 已关联的身份直接登录
+	var existing model.UserIdentity
+	err = s.db.Where("provider = ? AND subject = ?", providerName, identity.Subject).First(&existing).Error
+	if err == nil {
+		s.refreshIdentity(&existing, identity)
+		return s.login(CallbackActionLogin, providerName, existing.UserID, &existing, ip, userAgent)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询第三方身份失败: %w", err)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" {
+		return nil, ErrMissingEmail
+	}
+
+	// 邮箱已注册：仅当双方都已验证邮箱时自动关联（防止抢注未验证邮箱的账户被接管）
+	var user model.User
+	err = s.db.Where("email = ?", email).First(&user).Error
+	if err == nil {
+		if !identity.EmailVerified || !user.EmailVerified {
+			return nil, ErrEmailLinkRequired
+		}
+		linked, err := s.linkIdentity(user.ID, providerName, identity)
+		if err != nil {
+			return nil, err
+		}
+		return s.login(CallbackActionLogin, providerName, user.ID, linked, ip, userAgent)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	// 首次登录：注册新用户（沿用授权请求中的邀请码）
+	username, err := s.uniqueUsername(identity, email)
+	if err != nil {
+		return nil, err
+	}
+	newUser, err := s.authService.RegisterExternal(username, email, identity.EmailVerified, record.InviteCode)
+	if err != nil {
+		return nil, err
+	}
+	linked, err := s.linkIdentity(newUser.ID, providerName, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.login(CallbackActionRegister, providerName, newUser.ID, linked, ip, userAgent)
+}
+
+// ListIdentities 获取用户关联的第三方身份
+func (s *OAuthService) ListIdentities(userID uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	if err := s.db.Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("查询第三方身份失败: %w", err)
+	}
+	return identities, nil
+}
+
+// Unlink 解除关联第三方身份（没有密码时至少保留一种登录方式）
+func (s *OAuthService) Unlink(userID uint, providerName string) error {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	var identity model.UserIdentity
+	if err := s.db.Where("user_id = ? AND provider = ?", userID, providerName).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrIdentityNotFound
+		}
+		return fmt.Errorf("查询第三方身份失败: %w", err)
+	}
+
+	if user.PasswordHash == "" {
+		var count int64
+		if err := s.db.Model(&model.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return fmt.Errorf("查询第三方身份失败: %w", err)
+		}
+		if count <= 1 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	if err := s.db.Delete(&identity).Error; err != nil {
+		return fmt.Errorf("解除关联失败: %w", err)
+	}
+	return nil
+}
+
+// consumeState 校验并消费授权请求（只能使用一次）
+func (s *OAuthService) consumeState(providerName, state string) (*model.OAuthState, error) {
+	if state == "" {
+		return nil, ErrInvalidState
+	}
+
+	var record model.OAuthState
+	if err := s.db.Where("state_hash = ?", hashState(state)).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidState
+		}
+		return nil, fmt.Errorf("查询授权请求失败: %w", err)
+	}
+	if record.Provider != providerName || record.UsedAt != nil || record.IsExpired() {
+		return nil, ErrInvalidState
+	}
+
+	result := s.db.Model(&model.OAuthState{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新授权请求失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidState
+	}
+
+	return &record, nil
+}
+
+// linkIdentity 为用户关联第三方身份
+func (s *OAuthService) linkIdentity(userID uint, providerName string, identity *Identity) (*model.UserIdentity, error) {
+	var existing model.UserIdentity
+	err := s.db.Where("provider = ? AND subject = ?", providerName, identity.Subject).First(&existing).Error
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityLinked
+		}
+		s.refreshIdentity(&existing, identity)
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询第三方身份失败: %w", err)
+	}
+
+	// 每个提供方只能关联一个身份
+	var count int64
+	if err := s.db.Model(&model.UserIdentity{}).
+		Where("user_id = ? AND provider = ?", userID, providerName).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询第三方身份失败: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrProviderAlreadyLinked
+	}
+
+	linked := &model.UserIdentity{
+		UserID:        userID,
+		Provider:      providerName,
+		Subject:       identity.Subject,
+		Email:         truncate(identity.Email, 100),
+		EmailVerified: identity.EmailVerified,
+		Name:          truncate(identity.Name, 100),
+		AvatarURL:     truncate(identity.AvatarURL, 500),
+	}
+	if err := s.db.Create(linked).Error; err != nil {
+		return nil, fmt.Errorf("关联第三方身份失败: %w", err)
+	}
+	return linked, nil
+}
+
+// refreshIdentity 更新第三方资料
+func (s *OAuthService) refreshIdentity(existing *model.UserIdentity, identity *Identity) {
+	existing.Email = truncate(identity.Email, 100)
+	existing.EmailVerified = identity.EmailVerified
+	existing.Name = truncate(identity.Name, 100)
+	existing.AvatarURL = truncate(identity.AvatarURL, 500)
+	s.db.Model(existing).Updates(map[string]interface{}{
+		"email":          existing.Email,
+		"email_verified": existing.EmailVerified,
+		"name":           existing.Name,
+		"avatar_url":     existing.AvatarURL,
+	})
+}
+
+// login 登录关联的用户（需要两步验证时返回登录会话）
+func (s *OAuthService) login(action, providerName string, userID uint, identity *model.UserIdentity, ip, userAgent string) (*CallbackResult, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.Status != "active" {
+		return nil, ErrAccountDisabled
+	}
+
+	response, err := s.authService.LoginUser(&user, providerName+":"+user.Email, ip, userAgent, false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	identity.LastLoginAt = &now
+	s.db.Model(identity).Update("last_login_at", now)
+
+	return &CallbackResult{
+		Action:   action,
+		Provider: providerName,
+		Login:    response,
+		Identity: identity,
+	}, nil
+}
+
+// uniqueUsername 根据第三方资料生成可用的用户名
+func (s *OAuthService) uniqueUsername(identity *Identity, email string) (string, error) {
+	base := identity.Username
+	if base == "" {
+		base = identity.Name
+	}
+	if base == "" {
+		base = strings.SplitN(email, "@", 2)[0]
+	}
+
+	base = usernamePattern.ReplaceAllString(base, "_")
+	base = strings.Trim(base, "_")
+	if len(base) < 3 {
+		base = "user_" + base
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	// 第三方昵称命中敏感词时加后缀也无法通过，改用通用前缀
+	if errors.Is(s.authService.ValidateUsername(base), auth.ErrUsernameSensitive) {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := s.db.Model(&model.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("查询用户名失败: %w", err)
+		}
+		if count == 0 && s.authService.ValidateUsername(candidate) == nil {
+			return candidate, nil
+		}
+
+		suffix, err := utils.GenerateRandomString(6)
+		if err != nil {
+			return "", fmt.Errorf("生成用户名失败: %w", err)
+		}
+		candidate = base + "_" + strings.ToLower(suffix)
+	}
+
+	return "", errors.New("无法生成可用的用户名，请稍后重试")
+}
+
+// redirectURI 获取提供方的回调地址
+func (s *OAuthService) redirectURI(providerName string) string {
+	for _, providerCfg := range s.cfg.Providers {
+		if providerCfg.Name == providerName && providerCfg.RedirectURL != "" {
+			return providerCfg.RedirectURL
+		}
+	}
+	return s.siteURL + "/auth/oauth/" + providerName + "/callback"
+}
+
+// hashState 计算state哈希（数据库中不保存明文）
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,269 @@
+/*
+Package oauth provides the generic OpenID Connect identity provider.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"resource-share-site/internal/config"
+)
+
+// oidcDiscovery OIDC发现文档（仅使用需要的字段）
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// oidcClaims ID令牌及用户信息端点中的声明
+type oidcClaims struct {
+	Issuer            string          `json:"iss"`
+	Subject           string          `json:"sub"`
+	Audience          json.RawMessage `json:"aud"`
+	ExpiresAt         int64           `json:"exp"`
+	Nonce             string          `json:"nonce"`
+	Email             string          `json:"email"`
+	EmailVerified     flexibleBool    `json:"email_verified"`
+	Name              string          `json:"name"`
+	PreferredUsername string          `json:"preferred_username"`
+	Picture           string          `json:"picture"`
+}
+
+// flexibleBool 兼容布尔值和字符串形式的布尔值（部分提供方返回 "true"）
+type flexibleBool bool
+
+// UnmarshalJSON 实现json.Unmarshaler接口
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	*b = flexibleBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+// OIDCProvider 通用OpenID Connect身份提供方（Google等）
+type OIDCProvider struct {
+	cfg    config.OAuthProviderConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+}
+
+// NewOIDCProvider 创建OIDC身份提供方
+func NewOIDCProvider(cfg config.OAuthProviderConfig) *OIDCProvider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Name 提供方标识
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+// DisplayName 显示名称
+func (p *OIDCProvider) DisplayName() string {
+	if p.cfg.DisplayName != "" {
+		return p.cfg.DisplayName
+	}
+	return p.cfg.Name
+}
+
+// AuthCodeURL 生成授权地址
+func (p *OIDCProvider) AuthCodeURL(state, codeChallenge, nonce, redirectURI string) (string, error) {
+	endpoints, err := p.endpoints(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	return buildAuthURL(endpoints.AuthorizationEndpoint, params)
+}
+
+// Exchange 使用授权码换取令牌并获取身份信息
+// ID令牌通过TLS直接从令牌端点获取（OIDC Core 3.1.3.7），因此只校验iss、aud、exp和nonce
+func (p *OIDCProvider) Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (*Identity, error) {
+	endpoints, err := p.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := exchangeCode(ctx, p.client, p.cfg, endpoints.TokenEndpoint, code, codeVerifier, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims oidcClaims
+	if token.IDToken != "" {
+		parsed, err := parseIDToken(token.IDToken)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.validateClaims(parsed, endpoints.Issuer, nonce); err != nil {
+			return nil, err
+		}
+		claims = *parsed
+	}
+
+	// 没有ID令牌或缺少邮箱时从用户信息端点补充
+	if claims.Email == "" && endpoints.UserInfoEndpoint != "" {
+		var info oidcClaims
+		if err := getJSON(ctx, p.client, endpoints.UserInfoEndpoint, token.AccessToken, &info); err != nil {
+			return nil, fmt.Errorf("获取用户信息失败: %w", err)
+		}
+		if claims.Subject != "" && info.Subject != claims.Subject {
+			return nil, fmt.Errorf("%w: 用户信息与身份令牌不一致", ErrInvalidIDToken)
+		}
+		claims.Subject = info.Subject
+		claims.Email = info.Email
+		claims.EmailVerified = info.EmailVerified
+		if claims.Name == "" {
+			claims.Name = info.Name
+		}
+		if claims.PreferredUsername == "" {
+			claims.PreferredUsername = info.PreferredUsername
+		}
+		if claims.Picture == "" {
+			claims.Picture = info.Picture
+		}
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: 缺少sub声明", ErrInvalidIDToken)
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Username:      claims.PreferredUsername,
+		AvatarURL:     claims.Picture,
+	}, nil
+}
+
+// endpoints 获取端点地址（配置优先，其余通过发现文档补充并缓存）
+func (p *OIDCProvider) endpoints(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	doc := &oidcDiscovery{
+		Issuer:                strings.TrimRight(p.cfg.Issuer, "/"),
+		AuthorizationEndpoint: p.cfg.AuthURL,
+		TokenEndpoint:         p.cfg.TokenURL,
+		UserInfoEndpoint:      p.cfg.UserInfoURL,
+	}
+
+	if p.cfg.Issuer != "" && (doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserInfoEndpoint == "") {
+		discoveryURL := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+		defer cancel()
+
+		var discovered oidcDiscovery
+		if err := getJSON(ctx, p.client, discoveryURL, "", &discovered); err != nil {
+			return nil, fmt.Errorf("获取OIDC发现文档失败: %w", err)
+		}
+		if discovered.Issuer != "" {
+			doc.Issuer = strings.TrimRight(discovered.Issuer, "/")
+		}
+		if doc.AuthorizationEndpoint == "" {
+			doc.AuthorizationEndpoint = discovered.AuthorizationEndpoint
+		}
+		if doc.TokenEndpoint == "" {
+			doc.TokenEndpoint = discovered.TokenEndpoint
+		}
+		if doc.UserInfoEndpoint == "" {
+			doc.UserInfoEndpoint = discovered.UserInfoEndpoint
+		}
+	}
+
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" {
+		return nil, fmt.Errorf("OIDC提供方 %q 缺少授权或令牌端点", p.cfg.Name)
+	}
+
+	p.discovery = doc
+	return doc, nil
+}
+
+// validateClaims 校验ID令牌声明
+func (p *OIDCProvider) validateClaims(claims *oidcClaims, issuer, nonce string) error {
+	if issuer != "" && strings.TrimRight(claims.Issuer, "/") != issuer {
+		return fmt.Errorf("%w: 发行方不匹配", ErrInvalidIDToken)
+	}
+	if !audienceContains(claims.Audience, p.cfg.ClientID) {
+		return fmt.Errorf("%w: 受众不匹配", ErrInvalidIDToken)
+	}
+	if claims.ExpiresAt != 0 && time.Now().Unix() > claims.ExpiresAt {
+		return fmt.Errorf("%w: 已过期", ErrInvalidIDToken)
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return fmt.Errorf("%w: nonce不匹配", ErrInvalidIDToken)
+	}
+	return nil
+}
+
+// parseIDToken 解析ID令牌（JWT）中的声明
+func parseIDToken(idToken string) (*oidcClaims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: 格式错误", ErrInvalidIDToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	var claims oidcClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	return &claims, nil
+}
+
+// audienceContains 检查aud声明（字符串或数组）是否包含指定客户端ID
+func audienceContains(raw json.RawMessage, clientID string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == clientID
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, aud := range list {
+			if aud == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+/*
+Package oauth provides OAuth2/OIDC identity providers for social login.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrProviderNotFound    = errors.New("不支持的登录方式")
+	ErrUnsupportedProvider = errors.New("不支持的身份提供方类型")
+	ErrExchangeFailed      = errors.New("获取第三方授权失败")
+	ErrInvalidIDToken      = errors.New("第三方身份令牌无效")
+)
+
+// httpTimeout 请求身份提供方的超时时间
+const httpTimeout = 10 * time.Second
+
+// Identity 第三方身份信息
+type Identity struct {
+	Subject       string // 提供方内的唯一用户标识
+	Email         string // 邮箱
+	EmailVerified bool   // 提供方是否已验证邮箱
+	Name          string // 显示名称
+	Username      string // 用户名（如GitHub login）
+	AvatarURL     string // 头像地址
+}
+
+// Provider 身份提供方接口（授权码 + PKCE 流程）
+type Provider interface {
+	// Name 提供方标识
+	Name() string
+
+	// DisplayName 显示名称
+	DisplayName() string
+
+	// AuthCodeURL 生成授权地址
+	AuthCodeURL(state, codeChallenge, nonce, redirectURI string) (string, error)
+
+	// Exchange 使用授权码换取令牌并获取身份信息
+	Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (*Identity, error)
+}
+
+// NewProvider 根据配置创建身份提供方
+func NewProvider(cfg config.OAuthProviderConfig) (Provider, error) {
+	if cfg.Name == "" || cfg.ClientID == "" {
+		return nil, fmt.Errorf("身份提供方配置不完整: %q", cfg.Name)
+	}
+
+	switch cfg.Type {
+	case "github":
+		return NewGitHubProvider(cfg), nil
+	case "google":
+		if cfg.Issuer == "" {
+			cfg.Issuer = "https://accounts.google.com"
+		}
+		return NewOIDCProvider(cfg), nil
+	case "oidc":
+		if cfg.Issuer == "" && (cfg.AuthURL == "" || cfg.TokenURL == "") {
+			return nil, fmt.Errorf("OIDC提供方 %q 需要配置issuer或端点地址", cfg.Name)
+		}
+		return NewOIDCProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, cfg.Type)
+	}
+}
+
+// GenerateCodeVerifier 生成PKCE验证码（RFC 7636，43个字符）
+func GenerateCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// CodeChallengeS256 计算PKCE S256质询值
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString 生成URL安全的随机字符串
+func randomString(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// tokenResponse 令牌端点响应
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// buildAuthURL 拼接授权地址
+func buildAuthURL(endpoint string, params url.Values) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("授权端点地址无效: %w", err)
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// exchangeCode 使用授权码和PKCE验证码请求令牌端点
+func exchangeCode(ctx context.Context, client *http.Client, cfg config.OAuthProviderConfig, tokenURL, code, codeVerifier, redirectURI string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if cfg.ClientSecret != "" {
+		form.Set("client_secret", cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("创建令牌请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err := doJSON(client, req, &token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: 响应中缺少access_token", ErrExchangeFailed)
+	}
+
+	return &token, nil
+}
+
+// getJSON 使用访问令牌请求JSON资源
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	return doJSON(client, req, out)
+}
+
+// doJSON 发送请求并解析JSON响应
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 %s 失败: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	// 令牌端点的错误响应同样是JSON，交给调用方处理
+	if resp.StatusCode >= 500 || (resp.StatusCode >= 300 && !json.Valid(body)) {
+		return fmt.Errorf("请求 %s 返回状态码 %d", req.URL.Host, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -28,7 +29,11 @@ import (
 
 // EarningService 积分获取服务
 type EarningService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	notifier notification.Publisher
+
+	// 是否要求用户已验证邮箱才能获得奖励积分
+	requireVerifiedEmail bool
 }
 
 // NewEarningService 创建新的积分获取服务
@@ -38,9 +43,25 @@ func NewEarningService(db *gorm.DB) *EarningService {
 	}
 }
 
+// SetNotifier 设置通知发布器（积分到账时通知用户）
+func (s *EarningService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// SetRequireVerifiedEmail 设置是否要求用户已验证邮箱才能获得奖励积分
+// 管理员手动添加积分和退款不受此限制
+func (s *EarningService) SetRequireVerifiedEmail(require bool) {
+	s.requireVerifiedEmail = require
+}
+
 // EarnPointsByInvite 用户邀请奖励
 func (s *EarningService) EarnPointsByInvite(inviterID, inviteeID uint, points int) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查邮箱验证状态
+		if err := s.checkEmailVerified(tx, inviterID); err != nil {
+			return err
+		}
+
 		// 获取邀请关系
 		var invitation model.Invitation
 		if err := tx.Where("inviter_id = ? AND invitee_id = ?", inviterID, inviteeID).
@@ -74,11 +95,24 @@ func (s *EarningService) EarnPointsByInvite(inviterID, inviteeID uint, points in
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPointsReceived(inviterID, points, "邀请用户奖励")
+	return nil
 }
 
 // EarnPointsByResourceUpload 资源上传奖励
 func (s *EarningService) EarnPointsByResourceUpload(uploaderID, resourceID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	var awarded int
+	var description string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查邮箱验证状态
+		if err := s.checkEmailVerified(tx, uploaderID); err != nil {
+			return err
+		}
+
 		// 获取上传奖励规则
 		rule, err := getRuleByKey(tx, model.PointSourceUploadReward)
 		if err != nil {
@@ -100,19 +134,33 @@ func (s *EarningService) EarnPointsByResourceUpload(uploaderID, resourceID uint)
 		}
 
 		// 添加积分
-		description := fmt.Sprintf("资源上传奖励: %s", rule.RuleName)
+		description = fmt.Sprintf("资源上传奖励: %s", rule.RuleName)
 		if err := s.addPoints(tx, uploaderID, rule.Points, model.PointSourceUploadReward,
 			description, nil, &resourceID); err != nil {
 			return err
 		}
+		awarded = rule.Points
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPointsReceived(uploaderID, awarded, description)
+	return nil
 }
 
 // EarnPointsByResourceDownload 资源下载奖励
 func (s *EarningService) EarnPointsByResourceDownload(downloaderID, resourceID uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	var awarded int
+	var description string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查邮箱验证状态
+		if err := s.checkEmailVerified(tx, downloaderID); err != nil {
+			return err
+		}
+
 		// 获取下载奖励规则
 		rule, err := getRuleByKey(tx, model.PointSourceResourceDownload)
 		if err != nil {
@@ -134,19 +182,31 @@ func (s *EarningService) EarnPointsByResourceDownload(downloaderID, resourceID u
 		}
 
 		// 添加积分
-		description := fmt.Sprintf("资源下载奖励: %s", rule.RuleName)
+		description = fmt.Sprintf("资源下载奖励: %s", rule.RuleName)
 		if err := s.addPoints(tx, downloaderID, rule.Points, model.PointSourceResourceDownload,
 			description, nil, &resourceID); err != nil {
 			return err
 		}
+		awarded = rule.Points
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPointsReceived(downloaderID, awarded, description)
+	return nil
 }
 
 // EarnPointsByDailyCheckin 每日签到奖励
 func (s *EarningService) EarnPointsByDailyCheckin(userID uint) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 检查邮箱验证状态
+		if err := s.checkEmailVerified(tx, userID); err != nil {
+			return err
+		}
+
 		// 获取签到奖励规则
 		rule, err := getRuleByKey(tx, model.PointSourceDailyCheckin)
 		if err != nil {
@@ -185,7 +245,7 @@ func (s *EarningService) EarnPointsByAdmin(userID uint, points int, description
 		return fmt.Errorf("积分数量必须大于0")
 	}
 
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// 添加积分
 		if err := s.addPoints(tx, userID, points, model.PointSourceAdminAdd,
 			description, nil, nil); err != nil {
@@ -207,6 +267,45 @@ func (s *EarningService) EarnPointsByAdmin(userID uint, points int, description
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.notifyPointsReceived(userID, points, description)
+	return nil
+}
+
+// notifyPointsReceived 发送积分到账通知
+func (s *EarningService) notifyPointsReceived(userID uint, points int, description string) {
+	if s.notifier == nil || points <= 0 {
+		return
+	}
+
+	_ = s.notifier.Publish(&notification.Event{
+		Type:       model.NotificationTypePointsReceived,
+		UserID:     userID,
+		Title:      fmt.Sprintf("%d 积分已到账", points),
+		Content:    description,
+		Link:       "/points/records",
+		TargetType: "points",
+	})
+}
+
+// checkEmailVerified 检查用户邮箱是否已验证（未开启限制时直接通过）
+func (s *EarningService) checkEmailVerified(tx *gorm.DB, userID uint) error {
+	if !s.requireVerifiedEmail {
+		return nil
+	}
+
+	var user model.User
+	if err := tx.Select("id", "email_verified").First(&user, userID).Error; err != nil {
+		return fmt.Errorf("用户不存在: %w", err)
+	}
+	if !user.EmailVerified {
+		return fmt.Errorf("请先验证邮箱后再获取积分")
+	}
+
+	return nil
 }
 
 // getRuleByKey 根据规则键获取规则
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -26,7 +27,8 @@ import (
 
 // MallService 积分商城服务
 type MallService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	notifier notification.Publisher
 }
 
 // NewMallService 创建新的积分商城服务
@@ -36,6 +38,11 @@ func NewMallService(db *gorm.DB) *MallService {
 	}
 }
 
+// SetNotifier 设置通知发布器（订单发货时通知用户）
+func (s *MallService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
 // CreateProduct 创建商品
 func (s *MallService) CreateProduct(product *model.Product) error {
 	if product.PointsPrice <= 0 {
@@ -254,8 +261,9 @@ func (s *MallService) RefundOrder(userID, orderID uint, reason string) error {
 		}
 
 		// 检查订单状态
-		if order.Status != model.OrderStatusPaid && order.Status != model.OrderStatusCompleted {
-			return fmt.Errorf("只能对已支付或已完成订单进行退款")
+		if order.Status != model.OrderStatusPaid && order.Status != model.OrderStatusShipped &&
+			order.Status != model.OrderStatusCompleted {
+			return fmt.Errorf("只能对已支付、已发货或已完成订单进行退款")
 		}
 
 		// 退还积分
@@ -292,6 +300,60 @@ func (s *MallService) RefundOrder(userID, orderID uint, reason string) error {
 	})
 }
 
+// ShipOrder 订单发货（管理员操作）
+func (s *MallService) ShipOrder(orderID uint, note string) error {
+	var order model.MallOrder
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 获取订单信息
+		if err := tx.Clauses(clause.Locking{}).
+			Preload("Product").
+			First(&order, orderID).Error; err != nil {
+			return fmt.Errorf("订单不存在: %w", err)
+		}
+
+		// 检查订单状态
+		if order.Status != model.OrderStatusPaid {
+			return fmt.Errorf("只能对已支付订单进行发货")
+		}
+
+		// 更新订单状态
+		order.Status = model.OrderStatusShipped
+		if note != "" {
+			order.Note = note
+		}
+		if err := tx.Save(&order).Error; err != nil {
+			return fmt.Errorf("更新订单状态失败: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 通知下单用户
+	if s.notifier != nil {
+		productName := ""
+		if order.Product != nil {
+			productName = order.Product.Name
+		}
+		content := fmt.Sprintf("您的订单 %s（%s）已发货", order.OrderNo, productName)
+		if note != "" {
+			content += "，备注：" + note
+		}
+		_ = s.notifier.Publish(&notification.Event{
+			Type:       model.NotificationTypeOrderShipped,
+			UserID:     order.UserID,
+			Title:      "订单已发货",
+			Content:    content,
+			TargetType: "order",
+			TargetID:   &order.ID,
+		})
+	}
+
+	return nil
+}
+
 // GetUserOrders 获取用户订单列表
 func (s *MallService) GetUserOrders(userID uint, status model.OrderStatus,
 	page, pageSize int) ([]model.MallOrder, int64, error) {
@@ -0,0 +1,520 @@
+/*
+Package reaction provides per-user likes and emoji reactions on articles and comments.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package reaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrInvalidTarget  = errors.New("无效的表态对象类型")
+	ErrInvalidKind    = errors.New("无效的表态类型")
+	ErrTargetNotFound = errors.New("表态对象不存在")
+)
+
+// Redis 键名
+const (
+	pendingKey    = "reaction:pending" // 待写回数据库的点赞数增量（hash，字段为 对象类型:ID）
+	heatKeyPrefix = "reaction:heat:"   // 热点统计计数
+)
+
+// takePendingScript 原子地取出并清空待写回的增量，多个实例同时写回时不会重复累加
+var takePendingScript = redis.NewScript(`
+local values = redis.call('HGETALL', KEYS[1])
+redis.call('DEL', KEYS[1])
+return values
+`)
+
+// target 表态对象描述（表名、点赞数字段和可表态的状态）
+type target struct {
+	table   string
+	counter string
+	status  interface{}
+}
+
+// targets 支持表态的对象类型
+var targets = map[model.ReactionTargetType]target{
+	model.ReactionTargetArticle: {
+		table:   "articles",
+		counter: "like_count",
+		status:  model.ArticleStatusPublished,
+	},
+	model.ReactionTargetArticleComment: {
+		table:   "article_comments",
+		counter: "like_count",
+		status:  model.CommentStatusApproved,
+	},
+}
+
+// ToggleResult 切换表态的结果
+type ToggleResult struct {
+	Kind   model.ReactionKind `json:"kind"`
+	Active bool               `json:"active"` // 切换后当前用户是否处于该表态
+	Count  int64              `json:"count"`  // 对象当前的该类表态总数
+}
+
+// Summary 对象的表态汇总
+type Summary struct {
+	Counts map[model.ReactionKind]int64 `json:"counts"`
+	Mine   []model.ReactionKind         `json:"mine"`
+}
+
+// LikeState 用户对某个对象的点赞状态（用于列表和详情接口）
+type LikeState struct {
+	Liked   bool  // 当前用户是否点赞
+	Pending int64 // Redis 中尚未写回数据库的点赞数增量
+}
+
+// Count 返回加上未写回增量后的点赞数
+func (s LikeState) Count(stored uint) uint {
+	count := int64(stored) + s.Pending
+	if count < 0 {
+		return 0
+	}
+	return uint(count)
+}
+
+// ReactionService 点赞与表态服务
+type ReactionService struct {
+	db    *gorm.DB
+	cfg   *config.ReactionConfig
+	redis *redis.Client
+}
+
+// NewReactionService 创建点赞与表态服务
+func NewReactionService(db *gorm.DB) *ReactionService {
+	return &ReactionService{
+		db:  db,
+		cfg: config.DefaultReactionConfig(),
+	}
+}
+
+// SetConfig 设置点赞与表态配置
+func (s *ReactionService) SetConfig(cfg *config.ReactionConfig) {
+	if cfg != nil {
+		s.cfg = cfg
+	}
+}
+
+// SetRedis 设置Redis客户端（为nil时点赞数直接写数据库）
+func (s *ReactionService) SetRedis(client *redis.Client) {
+	s.redis = client
+}
+
+// ParseTargetType 解析表态对象类型
+func ParseTargetType(value string) (model.ReactionTargetType, error) {
+	targetType := model.ReactionTargetType(value)
+	if _, ok := targets[targetType]; !ok {
+		return "", ErrInvalidTarget
+	}
+	return targetType, nil
+}
+
+// ParseKind 解析表态类型（为空时为点赞）
+func ParseKind(value string) (model.ReactionKind, error) {
+	if value == "" {
+		return model.ReactionLike, nil
+	}
+	for _, kind := range model.ReactionKinds {
+		if string(kind) == value {
+			return kind, nil
+		}
+	}
+	return "", ErrInvalidKind
+}
+
+// Toggle 切换用户对对象的表态：未表态时添加，已表态时取消
+// 点赞会同步维护对象的点赞数；热点对象的点赞数先累加在Redis中，由 Run 定期写回
+// 参数：
+//   - userID: 用户ID
+//   - targetType: 表态对象类型
+//   - targetID: 表态对象ID
+//   - kind: 表态类型
+//
+// 返回：
+//   - 切换结果
+//   - 错误信息
+func (s *ReactionService) Toggle(userID uint, targetType model.ReactionTargetType, targetID uint, kind model.ReactionKind) (*ToggleResult, error) {
+	t, ok := targets[targetType]
+	if !ok {
+		return nil, ErrInvalidTarget
+	}
+	if _, err := ParseKind(string(kind)); err != nil {
+		return nil, err
+	}
+
+	// 只能对已发布的文章和已通过审核的评论表态
+	var exists int64
+	if err := s.db.Table(t.table).
+		Where("id = ? AND deleted_at IS NULL", targetID).
+		Where("status = ?", t.status).
+		Count(&exists).Error; err != nil {
+		return nil, fmt.Errorf("查询表态对象失败: %w", err)
+	}
+	if exists == 0 {
+		return nil, ErrTargetNotFound
+	}
+
+	counted := kind == model.ReactionLike
+	buffered := counted && s.isHot(targetType, targetID)
+
+	var active bool
+	var delta int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND target_type = ? AND target_id = ? AND kind = ?", userID, targetType, targetID, kind).
+			Delete(&model.Reaction{})
+		if result.Error != nil {
+			return fmt.Errorf("取消表态失败: %w", result.Error)
+		}
+
+		if result.RowsAffected > 0 {
+			delta = -1
+		} else {
+			active = true
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Reaction{
+				UserID:     userID,
+				TargetType: targetType,
+				TargetID:   targetID,
+				Kind:       kind,
+			})
+			if result.Error != nil {
+				return fmt.Errorf("表态失败: %w", result.Error)
+			}
+			// 并发的重复请求已经写入，计数由那次请求维护
+			if result.RowsAffected == 0 {
+				return nil
+			}
+			delta = 1
+		}
+
+		if !counted || buffered || delta == 0 {
+			return nil
+		}
+		return applyDelta(tx, t, targetID, delta)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if buffered && delta != 0 {
+		if err := s.redis.HIncrBy(context.Background(), pendingKey, field(targetType, targetID), delta).Err(); err != nil {
+			// Redis 不可用时直接写数据库，避免计数丢失
+			if err := applyDelta(s.db, t, targetID, delta); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	count, err := s.count(targetType, targetID, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ToggleResult{
+		Kind:   kind,
+		Active: active,
+		Count:  count,
+	}, nil
+}
+
+// GetSummary 获取对象的表态汇总
+// 参数：
+//   - targetType: 表态对象类型
+//   - targetID: 表态对象ID
+//   - userID: 当前用户ID（0表示未登录）
+//
+// 返回：
+//   - 各类表态数量和当前用户的表态
+//   - 错误信息
+func (s *ReactionService) GetSummary(targetType model.ReactionTargetType, targetID, userID uint) (*Summary, error) {
+	t, ok := targets[targetType]
+	if !ok {
+		return nil, ErrInvalidTarget
+	}
+
+	var exists int64
+	if err := s.db.Table(t.table).
+		Where("id = ? AND deleted_at IS NULL", targetID).
+		Where("status = ?", t.status).
+		Count(&exists).Error; err != nil {
+		return nil, fmt.Errorf("查询表态对象失败: %w", err)
+	}
+	if exists == 0 {
+		return nil, ErrTargetNotFound
+	}
+
+	var rows []struct {
+		Kind  model.ReactionKind
+		Count int64
+	}
+	if err := s.db.Model(&model.Reaction{}).
+		Select("kind, COUNT(*) AS count").
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Group("kind").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询表态统计失败: %w", err)
+	}
+
+	summary := &Summary{
+		Counts: make(map[model.ReactionKind]int64, len(model.ReactionKinds)),
+		Mine:   []model.ReactionKind{},
+	}
+	for _, kind := range model.ReactionKinds {
+		summary.Counts[kind] = 0
+	}
+	for _, row := range rows {
+		summary.Counts[row.Kind] = row.Count
+	}
+
+	// 点赞数与列表接口保持一致，使用对象上维护的计数
+	likes, err := s.count(targetType, targetID, model.ReactionLike)
+	if err != nil {
+		return nil, err
+	}
+	summary.Counts[model.ReactionLike] = likes
+
+	if userID != 0 {
+		if err := s.db.Model(&model.Reaction{}).
+			Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+			Order("id ASC").
+			Pluck("kind", &summary.Mine).Error; err != nil {
+			return nil, fmt.Errorf("查询表态状态失败: %w", err)
+		}
+	}
+
+	return summary, nil
+}
+
+// LikeStates 获取用户对一组对象的点赞状态和未写回的点赞数增量
+// 参数：
+//   - userID: 当前用户ID（0表示未登录）
+//   - targetType: 表态对象类型
+//   - targetIDs: 对象ID列表
+//
+// 返回：
+//   - 对象ID到点赞状态的映射（未点赞且没有增量的对象不在其中）
+//   - 错误信息
+func (s *ReactionService) LikeStates(userID uint, targetType model.ReactionTargetType, targetIDs []uint) (map[uint]LikeState, error) {
+	states := make(map[uint]LikeState)
+	if len(targetIDs) == 0 {
+		return states, nil
+	}
+
+	if userID != 0 {
+		var ids []uint
+		if err := s.db.Model(&model.Reaction{}).
+			Where("user_id = ? AND target_type = ? AND kind = ? AND target_id IN ?", userID, targetType, model.ReactionLike, targetIDs).
+			Pluck("target_id", &ids).Error; err != nil {
+			return nil, fmt.Errorf("查询点赞状态失败: %w", err)
+		}
+		for _, id := range ids {
+			states[id] = LikeState{Liked: true}
+		}
+	}
+
+	for id, delta := range s.pending(targetType, targetIDs) {
+		state := states[id]
+		state.Pending = delta
+		states[id] = state
+	}
+
+	return states, nil
+}
+
+// RecountLikes 根据表态记录重新统计所有对象的点赞数（先写回Redis中的增量）
+func (s *ReactionService) RecountLikes() error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	for targetType, t := range targets {
+		sub := s.db.Model(&model.Reaction{}).
+			Select("COUNT(*)").
+			Where("reactions.target_type = ? AND reactions.kind = ?", targetType, model.ReactionLike).
+			Where("reactions.target_id = " + t.table + ".id")
+		if err := s.db.Table(t.table).Where("1 = 1").
+			UpdateColumn(t.counter, sub).Error; err != nil {
+			return fmt.Errorf("重新统计点赞数失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run 定期把Redis中缓冲的点赞数写回数据库，ctx 结束时写回剩余增量后退出
+func (s *ReactionService) Run(ctx context.Context) {
+	interval := s.cfg.GetFlushInterval()
+	if s.redis == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.flushAndLog()
+			return
+		case <-ticker.C:
+			s.flushAndLog()
+		}
+	}
+}
+
+// Flush 把Redis中缓冲的点赞数增量写回数据库（写回失败的增量放回Redis等待下次重试）
+func (s *ReactionService) Flush() error {
+	if s.redis == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	values, err := takePendingScript.Run(ctx, s.redis, []string{pendingKey}).StringSlice()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("读取待写回点赞数失败: %w", err)
+	}
+
+	var failed error
+	for i := 0; i+1 < len(values); i += 2 {
+		targetType, targetID, ok := parseField(values[i])
+		delta, err := strconv.ParseInt(values[i+1], 10, 64)
+		if !ok || err != nil || delta == 0 {
+			continue
+		}
+
+		if err := applyDelta(s.db, targets[targetType], targetID, delta); err != nil {
+			failed = err
+			s.redis.HIncrBy(ctx, pendingKey, values[i], delta)
+		}
+	}
+
+	return failed
+}
+
+// flushAndLog 写回缓冲的点赞数并记录错误
+func (s *ReactionService) flushAndLog() {
+	if err := s.Flush(); err != nil {
+		log.Printf("写回点赞数失败: %v", err)
+	}
+}
+
+// isHot 记录一次点赞操作并判断对象是否为热点（未配置Redis时总是false）
+func (s *ReactionService) isHot(targetType model.ReactionTargetType, targetID uint) bool {
+	if s.redis == nil || s.cfg.HotThreshold <= 0 {
+		return false
+	}
+
+	ctx := context.Background()
+	key := heatKeyPrefix + field(targetType, targetID)
+	hits, err := s.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return false
+	}
+	if hits == 1 {
+		s.redis.Expire(ctx, key, s.cfg.GetHotWindow())
+	}
+	return hits > int64(s.cfg.HotThreshold)
+}
+
+// pending 获取一组对象在Redis中尚未写回的点赞数增量
+func (s *ReactionService) pending(targetType model.ReactionTargetType, targetIDs []uint) map[uint]int64 {
+	deltas := make(map[uint]int64)
+	if s.redis == nil || len(targetIDs) == 0 {
+		return deltas
+	}
+
+	fields := make([]string, len(targetIDs))
+	for i, id := range targetIDs {
+		fields[i] = field(targetType, id)
+	}
+	values, err := s.redis.HMGet(context.Background(), pendingKey, fields...).Result()
+	if err != nil {
+		return deltas
+	}
+
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if delta, err := strconv.ParseInt(str, 10, 64); err == nil && delta != 0 {
+			deltas[targetIDs[i]] = delta
+		}
+	}
+	return deltas
+}
+
+// count 获取对象当前的某类表态总数（点赞数取对象上维护的计数加未写回增量）
+func (s *ReactionService) count(targetType model.ReactionTargetType, targetID uint, kind model.ReactionKind) (int64, error) {
+	if kind != model.ReactionLike {
+		var count int64
+		if err := s.db.Model(&model.Reaction{}).
+			Where("target_type = ? AND target_id = ? AND kind = ?", targetType, targetID, kind).
+			Count(&count).Error; err != nil {
+			return 0, fmt.Errorf("查询表态数失败: %w", err)
+		}
+		return count, nil
+	}
+
+	t := targets[targetType]
+	var stored uint
+	if err := s.db.Table(t.table).Where("id = ?", targetID).
+		Select(t.counter).Scan(&stored).Error; err != nil {
+		return 0, fmt.Errorf("查询点赞数失败: %w", err)
+	}
+
+	state := LikeState{Pending: s.pending(targetType, []uint{targetID})[targetID]}
+	return int64(state.Count(stored)), nil
+}
+
+// applyDelta 把点赞数增量写入对象（点赞数不会减到负数）
+func applyDelta(db *gorm.DB, t target, targetID uint, delta int64) error {
+	expr := gorm.Expr(t.counter+" + ?", delta)
+	if delta < 0 {
+		expr = gorm.Expr("CASE WHEN "+t.counter+" > ? THEN "+t.counter+" - ? ELSE 0 END", -delta, -delta)
+	}
+	if err := db.Table(t.table).Where("id = ?", targetID).
+		UpdateColumn(t.counter, expr).Error; err != nil {
+		return fmt.Errorf("更新点赞数失败: %w", err)
+	}
+	return nil
+}
+
+// field 构建Redis哈希字段名
+func field(targetType model.ReactionTargetType, targetID uint) string {
+	return fmt.Sprintf("%s:%d", targetType, targetID)
+}
+
+// parseField 解析Redis哈希字段名
+func parseField(value string) (model.ReactionTargetType, uint, bool) {
+	idx := strings.LastIndex(value, ":")
+	if idx < 0 {
+		return "", 0, false
+	}
+	targetType := model.ReactionTargetType(value[:idx])
+	if _, ok := targets[targetType]; !ok {
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(value[idx+1:], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return targetType, uint(id), true
+}
@@ -0,0 +1,298 @@
+/*
+Package recommendation provides related-resource and personalized recommendations.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package recommendation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxPostingSize 倒排表长度上限（过于常见的标签或词项区分度低，跳过以控制计算量）
+	maxPostingSize = 500
+	// coDownloadShrink 共同下载人数的收缩系数（避免偶然的一次共同下载得到满分）
+	coDownloadShrink = 2.0
+	// insertBatchSize 写入相似度表的批量大小
+	insertBatchSize = 500
+)
+
+// RebuildResult 相似度表重建结果
+type RebuildResult struct {
+	Resources int       `json:"resources"`  // 参与计算的资源数
+	Pairs     int       `json:"pairs"`      // 写入的相关资源记录数
+	ElapsedMs int64     `json:"elapsed_ms"` // 耗时(毫秒)
+	BuiltAt   time.Time `json:"built_at"`
+}
+
+// pair 无序资源对（a < b）
+type pair struct {
+	a, b uint
+}
+
+// newPair 创建无序资源对
+func newPair(x, y uint) pair {
+	if x > y {
+		x, y = y, x
+	}
+	return pair{a: x, b: y}
+}
+
+// signals 资源对的各项相似度信号
+type signals struct {
+	coDownload float64
+	tag        float64
+	title      float64
+}
+
+// document 参与计算的资源
+type document struct {
+	ID    uint
+	Title string
+}
+
+// Rebuild 重新计算所有已审核通过资源之间的相似度并替换相似度表
+// 返回：
+//   - 重建结果
+//   - 错误信息
+func (s *RecommendationService) Rebuild() (*RebuildResult, error) {
+	if !s.building.TryLock() {
+		return nil, ErrRebuildRunning
+	}
+	defer s.building.Unlock()
+
+	start := time.Now()
+
+	var docs []document
+	if err := s.db.Model(&model.Resource{}).
+		Select("id, title").
+		Where("status = ?", model.ResourceStatusApproved).
+		Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+	approved := make(map[uint]bool, len(docs))
+	for _, doc := range docs {
+		approved[doc.ID] = true
+	}
+
+	scores := make(map[pair]*signals)
+	get := func(p pair) *signals {
+		sig, ok := scores[p]
+		if !ok {
+			sig = &signals{}
+			scores[p] = sig
+		}
+		return sig
+	}
+
+	coDownload, err := s.coDownloadScores(approved)
+	if err != nil {
+		return nil, err
+	}
+	for p, score := range coDownload {
+		get(p).coDownload = score
+	}
+
+	tagScores, err := s.tagScores(approved)
+	if err != nil {
+		return nil, err
+	}
+	for p, score := range tagScores {
+		get(p).tag = score
+	}
+
+	for p, score := range titleScores(docs) {
+		get(p).title = score
+	}
+
+	rows := s.selectRelated(scores, start)
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&model.ResourceSimilarity{}).Error; err != nil {
+			return fmt.Errorf("清空相似度表失败: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(rows, insertBatchSize).Error; err != nil {
+			return fmt.Errorf("写入相似度表失败: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return &RebuildResult{
+		Resources: len(docs),
+		Pairs:     len(rows),
+		ElapsedMs: time.Since(start).Milliseconds(),
+		BuiltAt:   start,
+	}, nil
+}
+
+// selectRelated 计算综合得分并为每个资源保留得分最高的 MaxRelated 个相关资源
+func (s *RecommendationService) selectRelated(scores map[pair]*signals, builtAt time.Time) []model.ResourceSimilarity {
+	related := make(map[uint][]model.ResourceSimilarity)
+	for p, sig := range scores {
+		score := s.cfg.CoDownloadWeight*sig.coDownload + s.cfg.TagWeight*sig.tag + s.cfg.TitleWeight*sig.title
+		if score <= 0 || score < s.cfg.MinScore {
+			continue
+		}
+
+		row := model.ResourceSimilarity{
+			ResourceID:      p.a,
+			RelatedID:       p.b,
+			Score:           score,
+			CoDownloadScore: sig.coDownload,
+			TagScore:        sig.tag,
+			TitleScore:      sig.title,
+			UpdatedAt:       builtAt,
+		}
+		related[p.a] = append(related[p.a], row)
+		row.ResourceID, row.RelatedID = p.b, p.a
+		related[p.b] = append(related[p.b], row)
+	}
+
+	var rows []model.ResourceSimilarity
+	for _, list := range related {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].RelatedID < list[j].RelatedID
+		})
+		if s.cfg.MaxRelated > 0 && len(list) > s.cfg.MaxRelated {
+			list = list[:s.cfg.MaxRelated]
+		}
+		rows = append(rows, list...)
+	}
+	return rows
+}
+
+// coDownloadScores 计算共同下载相似度（下载用户集合的余弦相似度，按共同下载人数收缩）
+func (s *RecommendationService) coDownloadScores(approved map[uint]bool) (map[pair]float64, error) {
+	histories, err := s.histories()
+	if err != nil {
+		return nil, err
+	}
+
+	downloaders := make(map[uint]int)
+	together := make(map[pair]int)
+	for _, history := range histories {
+		items := make([]uint, 0, len(history))
+		for _, id := range history {
+			if approved[id] {
+				items = append(items, id)
+				downloaders[id]++
+			}
+		}
+		for i := 0; i < len(items); i++ {
+			for j := i + 1; j < len(items); j++ {
+				together[newPair(items[i], items[j])]++
+			}
+		}
+	}
+
+	scores := make(map[pair]float64, len(together))
+	for p, n := range together {
+		cosine := float64(n) / math.Sqrt(float64(downloaders[p.a]*downloaders[p.b]))
+		scores[p] = math.Min(1, cosine) * float64(n) / (float64(n) + coDownloadShrink)
+	}
+	return scores, nil
+}
+
+// tagScores 计算标签重合度（标签集合的 Jaccard 系数）
+func (s *RecommendationService) tagScores(approved map[uint]bool) (map[pair]float64, error) {
+	var links []model.ResourceTag
+	if err := s.db.Select("resource_id, tag_id").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("查询资源标签失败: %w", err)
+	}
+
+	tagCount := make(map[uint]int)
+	postings := make(map[uint][]uint)
+	for _, link := range links {
+		if approved[link.ResourceID] {
+			tagCount[link.ResourceID]++
+			postings[link.TagID] = append(postings[link.TagID], link.ResourceID)
+		}
+	}
+
+	shared := make(map[pair]int)
+	for _, ids := range postings {
+		if len(ids) > maxPostingSize {
+			continue
+		}
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				shared[newPair(ids[i], ids[j])]++
+			}
+		}
+	}
+
+	scores := make(map[pair]float64, len(shared))
+	for p, n := range shared {
+		scores[p] = float64(n) / float64(tagCount[p.a]+tagCount[p.b]-n)
+	}
+	return scores, nil
+}
+
+// titleScores 计算标题相似度（词项 TF-IDF 向量的余弦相似度）
+func titleScores(docs []document) map[pair]float64 {
+	type posting struct {
+		id     uint
+		weight float64
+	}
+
+	terms := make([]map[string]int, len(docs))
+	df := make(map[string]int)
+	for i, doc := range docs {
+		terms[i] = tokenize(doc.Title)
+		for term := range terms[i] {
+			df[term]++
+		}
+	}
+
+	total := float64(len(docs))
+	postings := make(map[string][]posting)
+	for i, doc := range docs {
+		weights := make(map[string]float64, len(terms[i]))
+		var norm float64
+		for term, tf := range terms[i] {
+			w := (1 + math.Log(float64(tf))) * math.Log(1+total/float64(df[term]))
+			weights[term] = w
+			norm += w * w
+		}
+		if norm == 0 {
+			continue
+		}
+		norm = math.Sqrt(norm)
+		for term, w := range weights {
+			// 只出现在一个资源中的词项不产生相似资源对
+			if df[term] >= 2 && df[term] <= maxPostingSize {
+				postings[term] = append(postings[term], posting{id: doc.ID, weight: w / norm})
+			}
+		}
+	}
+
+	scores := make(map[pair]float64)
+	for _, list := range postings {
+		for i := 0; i < len(list); i++ {
+			for j := i + 1; j < len(list); j++ {
+				scores[newPair(list[i].id, list[j].id)] += list[i].weight * list[j].weight
+			}
+		}
+	}
+	for p, score := range scores {
+		scores[p] = math.Min(1, score)
+	}
+	return scores
+}
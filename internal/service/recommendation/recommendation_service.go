@@ -0,0 +1,278 @@
+/*
+Package recommendation provides related-resource and personalized recommendations.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package recommendation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/resource"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrResourceNotFound = errors.New("资源不存在")
+	ErrRebuildRunning   = errors.New("相关资源推荐正在重建中")
+)
+
+// maxRecommendations 单次请求返回的最大推荐数量
+const maxRecommendations = 20
+
+// RecommendationService 相关资源推荐服务
+type RecommendationService struct {
+	db       *gorm.DB
+	cfg      *config.RecommendationConfig
+	similar  *resource.CategoryManagementService
+	building sync.Mutex // 同一时间只允许一个重建任务
+}
+
+// NewRecommendationService 创建相关资源推荐服务
+func NewRecommendationService(db *gorm.DB) *RecommendationService {
+	return &RecommendationService{
+		db:      db,
+		cfg:     config.DefaultRecommendationConfig(),
+		similar: resource.NewCategoryManagementService(db),
+	}
+}
+
+// SetConfig 设置推荐配置
+func (s *RecommendationService) SetConfig(cfg *config.RecommendationConfig) {
+	if cfg != nil {
+		s.cfg = cfg
+	}
+}
+
+// Run 按配置的间隔定期重建相似度表，直到 ctx 结束（间隔为0时不自动重建）
+// 参数：
+//   - ctx: 控制任务退出的上下文
+func (s *RecommendationService) Run(ctx context.Context) {
+	interval := s.cfg.GetRefresh()
+	if interval <= 0 {
+		return
+	}
+
+	// 启动时相似度表仍在有效期内则等到下一周期再重建
+	if s.stale(interval) {
+		s.rebuildAndLog()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.rebuildAndLog()
+		}
+	}
+}
+
+// Related 获取与指定资源相关的资源（新资源尚未进入相似度表时用共同标签和同分类资源补齐）
+// 参数：
+//   - resourceID: 资源ID
+//   - limit: 返回数量
+//
+// 返回：
+//   - 相关资源列表（按相似度降序）
+//   - 错误信息
+func (s *RecommendationService) Related(resourceID uint, limit int) ([]*model.Resource, error) {
+	if limit <= 0 || limit > maxRecommendations {
+		limit = 10
+	}
+
+	var reference model.Resource
+	if err := s.db.Select("id").First(&reference, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+
+	var related []*model.Resource
+	if err := s.approvedResources().
+		Joins("JOIN resource_similarities rs ON rs.related_id = resources.id").
+		Where("rs.resource_id = ?", resourceID).
+		Order("rs.score DESC, resources.downloads_count DESC").
+		Limit(limit).
+		Find(&related).Error; err != nil {
+		return nil, fmt.Errorf("查询相关资源失败: %w", err)
+	}
+
+	if len(related) < limit {
+		similar, err := s.similar.FindSimilarResources(resourceID, limit)
+		if err != nil {
+			return nil, err
+		}
+		related = appendUnique(related, similar, limit)
+	}
+
+	return related, nil
+}
+
+// RecommendForUser 根据用户的下载历史推荐资源（排除已下载和本人上传的资源，历史不足时用热门资源补齐）
+// 参数：
+//   - userID: 用户ID
+//   - limit: 返回数量
+//
+// 返回：
+//   - 推荐资源列表
+//   - 错误信息
+func (s *RecommendationService) RecommendForUser(userID uint, limit int) ([]*model.Resource, error) {
+	if limit <= 0 || limit > maxRecommendations {
+		limit = 10
+	}
+
+	histories, err := s.histories(userID)
+	if err != nil {
+		return nil, err
+	}
+	downloaded := histories[userID]
+
+	// 按与已下载资源的相似度之和排序
+	var recommended []*model.Resource
+	if len(downloaded) > 0 {
+		scored := s.db.Model(&model.ResourceSimilarity{}).
+			Select("related_id, SUM(score) AS total").
+			Where("resource_id IN ? AND related_id NOT IN ?", downloaded, downloaded).
+			Group("related_id")
+		if err := s.approvedResources().
+			Joins("JOIN (?) rec ON rec.related_id = resources.id", scored).
+			Where("resources.uploaded_by_id != ?", userID).
+			Order("rec.total DESC, resources.downloads_count DESC").
+			Limit(limit).
+			Find(&recommended).Error; err != nil {
+			return nil, fmt.Errorf("查询推荐资源失败: %w", err)
+		}
+	}
+
+	// 不足时用热门资源补齐
+	if len(recommended) < limit {
+		exclude := append([]uint{}, downloaded...)
+		for _, r := range recommended {
+			exclude = append(exclude, r.ID)
+		}
+
+		query := s.approvedResources().Where("resources.uploaded_by_id != ?", userID)
+		if len(exclude) > 0 {
+			query = query.Where("resources.id NOT IN ?", exclude)
+		}
+
+		var popular []*model.Resource
+		if err := query.
+			Order("resources.downloads_count DESC, resources.created_at DESC").
+			Limit(limit - len(recommended)).
+			Find(&popular).Error; err != nil {
+			return nil, fmt.Errorf("查询热门资源失败: %w", err)
+		}
+		recommended = append(recommended, popular...)
+	}
+
+	return recommended, nil
+}
+
+// approvedResources 已审核通过资源的查询（预加载分类和上传者）
+func (s *RecommendationService) approvedResources() *gorm.DB {
+	return s.db.Model(&model.Resource{}).
+		Where("resources.status = ?", model.ResourceStatusApproved).
+		Preload("Category").Preload("UploadedBy")
+}
+
+// histories 获取用户的下载历史（最近下载在前，每个用户最多 MaxUserHistory 个）
+// 参数：
+//   - userIDs: 用户ID列表（为空时获取所有用户）
+//
+// 返回：
+//   - 用户ID到资源ID列表的映射
+//   - 错误信息
+func (s *RecommendationService) histories(userIDs ...uint) (map[uint][]uint, error) {
+	type download struct {
+		UserID     uint
+		ResourceID uint
+	}
+
+	records := s.db.Model(&model.DownloadRecord{}).
+		Select("user_id, resource_id").
+		Order("last_downloaded_at DESC")
+	// 下载记录表启用前的付费下载只有积分记录
+	paid := s.db.Model(&model.PointRecord{}).
+		Select("user_id, resource_id").
+		Where("source = ? AND resource_id IS NOT NULL", model.PointSourceResourceDownload).
+		Order("created_at DESC")
+	if len(userIDs) > 0 {
+		records = records.Where("user_id IN ?", userIDs)
+		paid = paid.Where("user_id IN ?", userIDs)
+	}
+
+	var downloads, paidDownloads []download
+	if err := records.Find(&downloads).Error; err != nil {
+		return nil, fmt.Errorf("查询下载记录失败: %w", err)
+	}
+	if err := paid.Find(&paidDownloads).Error; err != nil {
+		return nil, fmt.Errorf("查询下载记录失败: %w", err)
+	}
+
+	histories := make(map[uint][]uint)
+	seen := make(map[download]bool)
+	for _, d := range append(downloads, paidDownloads...) {
+		if seen[d] || (s.cfg.MaxUserHistory > 0 && len(histories[d.UserID]) >= s.cfg.MaxUserHistory) {
+			continue
+		}
+		seen[d] = true
+		histories[d.UserID] = append(histories[d.UserID], d.ResourceID)
+	}
+
+	return histories, nil
+}
+
+// stale 检查相似度表是否超过有效期（表为空时视为过期）
+func (s *RecommendationService) stale(interval time.Duration) bool {
+	var latest model.ResourceSimilarity
+	if err := s.db.Order("updated_at DESC").Take(&latest).Error; err != nil {
+		return true
+	}
+	return time.Since(latest.UpdatedAt) >= interval
+}
+
+// rebuildAndLog 执行一次重建并记录结果（供定时任务使用）
+func (s *RecommendationService) rebuildAndLog() {
+	result, err := s.Rebuild()
+	if err != nil {
+		if !errors.Is(err, ErrRebuildRunning) {
+			log.Printf("重建相关资源推荐失败: %v", err)
+		}
+		return
+	}
+	log.Printf("相关资源推荐已重建：资源 %d 个，相关记录 %d 条，耗时 %dms", result.Resources, result.Pairs, result.ElapsedMs)
+}
+
+// appendUnique 将候选资源追加到列表中（跳过重复资源，最多 limit 个）
+func appendUnique(list, candidates []*model.Resource, limit int) []*model.Resource {
+	seen := make(map[uint]bool, len(list))
+	for _, r := range list {
+		seen[r.ID] = true
+	}
+	for _, r := range candidates {
+		if len(list) >= limit {
+			break
+		}
+		if !seen[r.ID] {
+			seen[r.ID] = true
+			list = append(list, r)
+		}
+	}
+	return list
+}
@@ -0,0 +1,71 @@
+/*
+Package recommendation provides related-resource and personalized recommendations.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package recommendation
+
+import (
+	"unicode"
+)
+
+// stopWords 标题中不参与相似度计算的常见词
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true,
+	"of": true, "to": true, "in": true, "on": true, "by": true,
+}
+
+// tokenize 将标题切分为词项（英文数字按单词切分，中文按相邻两字切分，单个汉字成词）
+// 参数：
+//   - title: 资源标题
+//
+// 返回：
+//   - 词项及其出现次数
+func tokenize(title string) map[string]int {
+	terms := make(map[string]int)
+	var word, han []rune
+
+	flushWord := func() {
+		if len(word) >= 2 {
+			if term := string(word); !stopWords[term] {
+				terms[term]++
+			}
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			terms[string(han)]++
+		}
+		for i := 0; i+1 < len(han); i++ {
+			terms[string(han[i:i+2])]++
+		}
+		han = han[:0]
+	}
+
+	for _, r := range title {
+		// 全角字符转半角
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		r = unicode.ToLower(r)
+
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+
+	return terms
+}
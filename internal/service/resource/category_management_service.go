@@ -564,7 +564,7 @@ func (s *CategoryManagementService) getAllChildCategoryIDs(parentID uint, childI
 	return nil
 }
 
-// FindSimilarResources 查找相似资源
+// FindSimilarResources 查找相似资源（优先共同标签多的资源，不足时用同分类资源补齐）
 // 参数：
 //   - resourceID: 参考资源ID
 //   - limit: 限制数量
@@ -583,26 +583,50 @@ func (s *CategoryManagementService) FindSimilarResources(resourceID uint, limit
 		return nil, fmt.Errorf("查询参考资源失败: %w", err)
 	}
 
-	// 基于分类和标签查找相似资源
-	var similarResources []*model.Resource
-	query := s.db.Model(&model.Resource{}).
-		Where("deleted_at IS NULL AND status = ? AND id != ?", model.ResourceStatusApproved, resourceID)
+	// 参考资源的标签
+	var tagIDs []uint
+	if err := s.db.Table("resource_tags").Where("resource_id = ?", resourceID).Pluck("tag_id", &tagIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询资源标签失败: %w", err)
+	}
 
-	// 同分类或相似标签
-	if referenceResource.CategoryID != 0 {
-		query = query.Where("category_id = ?", referenceResource.CategoryID)
+	newQuery := func() *gorm.DB {
+		return s.db.Model(&model.Resource{}).
+			Where("status = ? AND resources.id != ?", model.ResourceStatusApproved, resourceID).
+			Preload("Category").Preload("UploadedBy")
 	}
 
-	if referenceResource.Tags != "" {
-		// 这里可以添加更复杂的标签匹配逻辑
-		// 简单起见，我们先按分类查找
+	// 优先按共同标签数量排序
+	var similarResources []*model.Resource
+	if len(tagIDs) > 0 {
+		shared := s.db.Table("resource_tags").
+			Select("resource_id, COUNT(*) AS shared").
+			Where("tag_id IN ?", tagIDs).
+			Group("resource_id")
+		if err := newQuery().
+			Joins("JOIN (?) st ON st.resource_id = resources.id", shared).
+			Order("st.shared DESC, resources.downloads_count DESC, resources.created_at DESC").
+			Limit(limit).
+			Find(&similarResources).Error; err != nil {
+			return nil, fmt.Errorf("查询相似资源失败: %w", err)
+		}
 	}
 
-	if err := query.Preload("Category").Preload("UploadedBy").
-		Order("downloads_count DESC, created_at DESC").
-		Limit(limit).
-		Find(&similarResources).Error; err != nil {
-		return nil, fmt.Errorf("查询相似资源失败: %w", err)
+	// 不足时用同分类资源补齐
+	if len(similarResources) < limit && referenceResource.CategoryID != 0 {
+		exclude := []uint{resourceID}
+		for _, r := range similarResources {
+			exclude = append(exclude, r.ID)
+		}
+
+		var sameCategory []*model.Resource
+		if err := newQuery().
+			Where("category_id = ? AND resources.id NOT IN ?", referenceResource.CategoryID, exclude).
+			Order("downloads_count DESC, created_at DESC").
+			Limit(limit - len(similarResources)).
+			Find(&sameCategory).Error; err != nil {
+			return nil, fmt.Errorf("查询相似资源失败: %w", err)
+		}
+		similarResources = append(similarResources, sameCategory...)
 	}
 
 	return similarResources, nil
@@ -0,0 +1,832 @@
+/*
+Package resource provides rule-based auto-moderation services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/seo"
+	"resource-share-site/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrModerationRuleNotFound = errors.New("审核规则不存在")
+	ErrInvalidModerationRule  = errors.New("审核规则配置无效")
+)
+
+const (
+	// systemReviewerRole 自动审核使用的系统审核者角色（注册和资料修改都无法设置，按角色而不是用户名识别）
+	systemReviewerRole = "system"
+
+	// systemReviewerName 系统审核者的用户名（已被占用时追加随机后缀）
+	systemReviewerName = "system"
+
+	// maxDryRunResources 试运行最多评估的资源数
+	maxDryRunResources = 1000
+)
+
+// RuleMatch 规则命中记录
+type RuleMatch struct {
+	RuleID   uint                     `json:"rule_id"`
+	RuleName string                   `json:"rule_name"`
+	Type     model.ModerationRuleType `json:"type"`
+	Action   model.ModerationDecision `json:"action"`
+	Reason   string                   `json:"reason"`
+}
+
+// ModerationResult 自动审核结果
+type ModerationResult struct {
+	Decision model.ModerationDecision `json:"decision"`
+	Matches  []RuleMatch              `json:"matches"`
+}
+
+// Notes 生成审核备注（汇总命中规则的原因）
+func (r *ModerationResult) Notes() string {
+	if len(r.Matches) == 0 {
+		return "未命中自动审核规则"
+	}
+
+	reasons := make([]string, 0, len(r.Matches))
+	for _, match := range r.Matches {
+		reasons = append(reasons, fmt.Sprintf("[%s] %s", match.RuleName, match.Reason))
+	}
+
+	notes := "自动审核: " + strings.Join(reasons, "; ")
+	if runes := []rune(notes); len(runes) > 500 {
+		notes = string(runes[:500])
+	}
+	return notes
+}
+
+// DryRunOptions 试运行参数
+type DryRunOptions struct {
+	RuleIDs []uint                 // 指定规则（为空时使用所有已启用规则）
+	Rules   []model.ModerationRule // 未保存的草稿规则（与RuleIDs合并）
+	Since   *time.Time             // 仅评估该时间之后提交的资源
+	Limit   int                    // 最多评估的资源数
+}
+
+// DryRunItem 试运行单个资源的结果
+type DryRunItem struct {
+	ResourceID    uint                     `json:"resource_id"`
+	Title         string                   `json:"title"`
+	CurrentStatus model.ResourceStatus     `json:"current_status"`
+	Decision      model.ModerationDecision `json:"decision"`
+	Matches       []RuleMatch              `json:"matches"`
+}
+
+// DryRunReport 试运行报告
+type DryRunReport struct {
+	Total    int          `json:"total"`
+	Approve  int          `json:"approve"`
+	Reject   int          `json:"reject"`
+	Hold     int          `json:"hold"`
+	Agree    int          `json:"agree"`    // 与人工审核结果一致的数量
+	Disagree int          `json:"disagree"` // 与人工审核结果相反的数量
+	Items    []DryRunItem `json:"items"`
+}
+
+// 各类型规则的配置
+type (
+	keywordRuleConfig struct {
+		Keywords []string `json:"keywords"`
+		Fields   []string `json:"fields"` // title/description/tags，为空时检查全部
+	}
+
+	regexRuleConfig struct {
+		Patterns []string `json:"patterns"`
+		Fields   []string `json:"fields"`
+	}
+
+	domainRuleConfig struct {
+		Domains []string `json:"domains"` // 允许的域名（包含子域名）
+	}
+
+	duplicateRuleConfig struct {
+		IncludeRejected bool `json:"include_rejected"` // 是否与已拒绝的资源比较
+	}
+
+	trustRuleConfig struct {
+		MinReviews int      `json:"min_reviews"` // 至少需要的人工审核次数
+		MinRatio   *float64 `json:"min_ratio"`   // 通过率不低于该值时命中（用于自动通过）
+		MaxRatio   *float64 `json:"max_ratio"`   // 通过率不高于该值时命中（用于转人工或拒绝）
+	}
+
+	priceRuleConfig struct {
+		MaxPrice int `json:"max_price"`
+	}
+
+	roleRuleConfig struct {
+		Roles []string `json:"roles"`
+	}
+)
+
+// ModerationService 自动审核服务（规则引擎）
+type ModerationService struct {
+	db       *gorm.DB
+	notifier notification.Publisher
+	indexer  seo.URLPublisher
+}
+
+// NewModerationService 创建自动审核服务
+func NewModerationService(db *gorm.DB) *ModerationService {
+	return &ModerationService{
+		db: db,
+	}
+}
+
+// SetNotifier 设置通知发布器（自动通过或拒绝时通知上传者）
+func (s *ModerationService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// SetIndexer 设置URL推送器（自动通过时通知搜索引擎）
+func (s *ModerationService) SetIndexer(indexer seo.URLPublisher) {
+	s.indexer = indexer
+}
+
+// ListRules 获取所有审核规则
+func (s *ModerationService) ListRules() ([]model.ModerationRule, error) {
+	var rules []model.ModerationRule
+	if err := s.db.Preload("Category").
+		Order("priority ASC, id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询审核规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateRule 创建审核规则
+func (s *ModerationService) CreateRule(rule *model.ModerationRule) error {
+	rule.ID = 0
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	if err := s.db.Create(rule).Error; err != nil {
+		return fmt.Errorf("创建审核规则失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateRule 更新审核规则
+func (s *ModerationService) UpdateRule(ruleID uint, rule *model.ModerationRule) (*model.ModerationRule, error) {
+	var existing model.ModerationRule
+	if err := s.db.First(&existing, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrModerationRuleNotFound
+		}
+		return nil, fmt.Errorf("查询审核规则失败: %w", err)
+	}
+
+	if err := validateRule(rule); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":        rule.Name,
+		"description": rule.Description,
+		"type":        rule.Type,
+		"config":      rule.Config,
+		"action":      rule.Action,
+		"category_id": rule.CategoryID,
+		"priority":    rule.Priority,
+		"is_enabled":  rule.IsEnabled,
+	}
+	if err := s.db.Model(&existing).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("更新审核规则失败: %w", err)
+	}
+
+	if err := s.db.First(&existing, ruleID).Error; err != nil {
+		return nil, fmt.Errorf("查询审核规则失败: %w", err)
+	}
+	return &existing, nil
+}
+
+// DeleteRule 删除审核规则
+func (s *ModerationService) DeleteRule(ruleID uint) error {
+	result := s.db.Delete(&model.ModerationRule{}, ruleID)
+	if result.Error != nil {
+		return fmt.Errorf("删除审核规则失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrModerationRuleNotFound
+	}
+	return nil
+}
+
+// Evaluate 使用所有已启用的规则评估资源（不修改资源）
+func (s *ModerationService) Evaluate(resource *model.Resource) (*ModerationResult, error) {
+	rules, err := s.enabledRules()
+	if err != nil {
+		return nil, err
+	}
+	return s.evaluate(resource, rules)
+}
+
+// Apply 评估资源并执行审核决定，以系统审核者身份记录审核日志
+// 已通过的资源不再处理：普通用户编辑已通过的资源会生成待审核的修订（见 ResourceService.UpdateResource），
+// 修订内容由审核员审核后才会生效，只有管理员和版主的编辑直接生效；
+// 未命中任何规则时保持待审核状态且不记录日志
+// 参数：
+//   - resourceID: 资源ID
+//
+// 返回：
+//   - 自动审核结果
+//   - 错误信息
+func (s *ModerationService) Apply(resourceID uint) (*ModerationResult, error) {
+	var resource model.Resource
+	if err := s.db.First(&resource, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+	// 已通过资源的编辑走修订审核流程，这里不重复评估
+	if resource.Status == model.ResourceStatusApproved {
+		return nil, nil
+	}
+
+	result, err := s.Evaluate(&resource)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Matches) == 0 {
+		return result, nil
+	}
+
+	reviewerID, err := s.systemReviewerID()
+	if err != nil {
+		return nil, err
+	}
+
+	var action ReviewAction
+	var newStatus model.ResourceStatus
+	switch result.Decision {
+	case model.ModerationDecisionApprove:
+		action, newStatus = ReviewActionApprove, model.ResourceStatusApproved
+	case model.ModerationDecisionReject:
+		action, newStatus = ReviewActionReject, model.ResourceStatusRejected
+	default:
+		action, newStatus = ReviewActionHold, model.ResourceStatusPending
+	}
+
+	notes := result.Notes()
+	oldStatus := resource.Status
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":       newStatus,
+			"review_notes": notes,
+			"updated_at":   time.Now(),
+		}
+		if action != ReviewActionHold {
+			updates["reviewed_by_id"] = reviewerID
+			updates["reviewed_at"] = time.Now()
+		}
+		if err := tx.Model(&resource).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新资源状态失败: %w", err)
+		}
+
+		reviewLog := &ReviewLog{
+			ResourceID: resource.ID,
+			ReviewerID: reviewerID,
+			Action:     action,
+			Notes:      notes,
+			FromStatus: oldStatus,
+			ToStatus:   newStatus,
+		}
+		if err := tx.Create(reviewLog).Error; err != nil {
+			return fmt.Errorf("创建审核日志失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publishReviewResult(s.notifier, &resource, action, notes)
+	if newStatus == model.ResourceStatusApproved {
+		publishResourceURL(s.indexer, seo.IndexActionUpdate, &resource)
+	}
+
+	return result, nil
+}
+
+// DryRun 使用指定规则评估历史提交（不修改任何数据），用于上线规则前检查效果
+func (s *ModerationService) DryRun(opts DryRunOptions) (*DryRunReport, error) {
+	var rules []model.ModerationRule
+	if len(opts.RuleIDs) == 0 && len(opts.Rules) == 0 {
+		enabled, err := s.enabledRules()
+		if err != nil {
+			return nil, err
+		}
+		rules = enabled
+	}
+	if len(opts.RuleIDs) > 0 {
+		var selected []model.ModerationRule
+		if err := s.db.Where("id IN ?", opts.RuleIDs).Find(&selected).Error; err != nil {
+			return nil, fmt.Errorf("查询审核规则失败: %w", err)
+		}
+		rules = append(rules, selected...)
+	}
+	for i := range opts.Rules {
+		if err := validateRule(&opts.Rules[i]); err != nil {
+			return nil, err
+		}
+		rules = append(rules, opts.Rules[i])
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+
+	limit := opts.Limit
+	if limit <= 0 || limit > maxDryRunResources {
+		limit = 200
+	}
+
+	query := s.db.Model(&model.Resource{}).Order("created_at DESC").Limit(limit)
+	if opts.Since != nil {
+		query = query.Where("created_at >= ?", *opts.Since)
+	}
+	var resources []model.Resource
+	if err := query.Find(&resources).Error; err != nil {
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+
+	report := &DryRunReport{Items: make([]DryRunItem, 0, len(resources))}
+	for i := range resources {
+		resource := &resources[i]
+		result, err := s.evaluate(resource, rules)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Total++
+		switch result.Decision {
+		case model.ModerationDecisionApprove:
+			report.Approve++
+		case model.ModerationDecisionReject:
+			report.Reject++
+		default:
+			report.Hold++
+		}
+
+		// 与人工审核结果比较
+		switch {
+		case result.Decision == model.ModerationDecisionApprove && resource.Status == model.ResourceStatusApproved,
+			result.Decision == model.ModerationDecisionReject && resource.Status == model.ResourceStatusRejected:
+			report.Agree++
+		case result.Decision == model.ModerationDecisionApprove && resource.Status == model.ResourceStatusRejected,
+			result.Decision == model.ModerationDecisionReject && resource.Status == model.ResourceStatusApproved:
+			report.Disagree++
+		}
+
+		report.Items = append(report.Items, DryRunItem{
+			ResourceID:    resource.ID,
+			Title:         resource.Title,
+			CurrentStatus: resource.Status,
+			Decision:      result.Decision,
+			Matches:       result.Matches,
+		})
+	}
+
+	return report, nil
+}
+
+// enabledRules 获取已启用的规则（按优先级排序）
+func (s *ModerationService) enabledRules() ([]model.ModerationRule, error) {
+	var rules []model.ModerationRule
+	if err := s.db.Where("is_enabled = ?", true).
+		Order("priority ASC, id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询审核规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// evaluate 按优先级依次执行规则并汇总决定
+// 任一规则拒绝则拒绝；否则任一规则转人工则转人工；否则有规则通过则通过；未命中任何规则时转人工
+func (s *ModerationService) evaluate(resource *model.Resource, rules []model.ModerationRule) (*ModerationResult, error) {
+	env := &ruleEnv{db: s.db, resource: resource}
+	result := &ModerationResult{Decision: model.ModerationDecisionHold, Matches: []RuleMatch{}}
+
+	var approve, hold, reject bool
+	for i := range rules {
+		rule := &rules[i]
+		if rule.CategoryID != nil && *rule.CategoryID != resource.CategoryID {
+			continue
+		}
+
+		matched, reason, err := env.match(rule)
+		if err != nil {
+			return nil, fmt.Errorf("执行审核规则 %q 失败: %w", rule.Name, err)
+		}
+		if !matched {
+			continue
+		}
+
+		result.Matches = append(result.Matches, RuleMatch{
+			RuleID:   rule.ID,
+			RuleName: rule.Name,
+			Type:     rule.Type,
+			Action:   rule.Action,
+			Reason:   reason,
+		})
+		switch rule.Action {
+		case model.ModerationDecisionReject:
+			reject = true
+		case model.ModerationDecisionHold:
+			hold = true
+		case model.ModerationDecisionApprove:
+			approve = true
+		}
+	}
+
+	switch {
+	case reject:
+		result.Decision = model.ModerationDecisionReject
+	case hold:
+		result.Decision = model.ModerationDecisionHold
+	case approve:
+		result.Decision = model.ModerationDecisionApprove
+	}
+
+	return result, nil
+}
+
+// systemReviewerID 获取系统审核者ID（不存在时创建一个不可登录的系统账户）
+func (s *ModerationService) systemReviewerID() (uint, error) {
+	var reviewer model.User
+	err := s.db.Where("role = ?", systemReviewerRole).Order("id").First(&reviewer).Error
+	if err == nil {
+		return reviewer.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("查询系统审核者失败: %w", err)
+	}
+
+	// 保留用户名之前注册的同名用户不会被当作系统审核者，系统账户改用带后缀的用户名和邮箱
+	username, email := systemReviewerName, "system@localhost"
+	var count int64
+	if err := s.db.Unscoped().Model(&model.User{}).
+		Where("username = ? OR email = ?", username, email).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("查询系统审核者失败: %w", err)
+	}
+	if count > 0 {
+		suffix, err := utils.GenerateRandomString(6)
+		if err != nil {
+			return 0, fmt.Errorf("创建系统审核者失败: %w", err)
+		}
+		username = systemReviewerName + "-" + suffix
+		email = username + "@localhost"
+	}
+
+	reviewer = model.User{
+		Username: username,
+		Email:    email,
+		Role:     systemReviewerRole,
+		Status:   "disabled",
+	}
+	if err := s.db.Create(&reviewer).Error; err != nil {
+		return 0, fmt.Errorf("创建系统审核者失败: %w", err)
+	}
+	return reviewer.ID, nil
+}
+
+// ruleEnv 单个资源的规则执行环境（缓存上传者信息）
+type ruleEnv struct {
+	db       *gorm.DB
+	resource *model.Resource
+	uploader *model.User
+	trust    *[2]int64 // 上传者历史人工审核的通过数、拒绝数
+}
+
+// match 执行单条规则
+// 返回：
+//   - 是否命中
+//   - 命中原因
+//   - 错误信息
+func (e *ruleEnv) match(rule *model.ModerationRule) (bool, string, error) {
+	r := e.resource
+
+	switch rule.Type {
+	case model.ModerationRuleKeyword:
+		var cfg keywordRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return false, "", err
+		}
+		for _, field := range resourceFields(r, cfg.Fields) {
+			lower := strings.ToLower(field.text)
+			for _, keyword := range cfg.Keywords {
+				if keyword != "" && strings.Contains(lower, strings.ToLower(keyword)) {
+					return true, fmt.Sprintf("%s包含关键词“%s”", fieldLabel(field.name), keyword), nil
+				}
+			}
+		}
+
+	case model.ModerationRuleRegex:
+		var cfg regexRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return false, "", err
+		}
+		for _, pattern := range cfg.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return false, "", fmt.Errorf("%w: %v", ErrInvalidModerationRule, err)
+			}
+			for _, field := range resourceFields(r, cfg.Fields) {
+				if re.MatchString(field.text) {
+					return true, fmt.Sprintf("%s匹配规则 %s", fieldLabel(field.name), pattern), nil
+				}
+			}
+		}
+
+	case model.ModerationRuleDomainAllowlist:
+		var cfg domainRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return false, "", err
+		}
+		host := netdiskHost(r.NetdiskURL)
+		if host == "" {
+			return true, "网盘链接格式无效", nil
+		}
+		for _, domain := range cfg.Domains {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return false, "", nil
+			}
+		}
+		return true, fmt.Sprintf("网盘域名 %s 不在允许列表中", host), nil
+
+	case model.ModerationRuleDuplicateURL:
+		var cfg duplicateRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return false, "", err
+		}
+		link := strings.TrimSuffix(strings.TrimSpace(r.NetdiskURL), "/")
+		query := e.db.Model(&model.Resource{}).
+			Where("id <> ? AND netdisk_url IN ?", r.ID, []string{link, link + "/"})
+		if !cfg.IncludeRejected {
+			query = query.Where("status <> ?", model.ResourceStatusRejected)
+		}
+		var duplicate model.Resource
+		if err := query.Order("id ASC").Limit(1).Find(&duplicate).Error; err != nil {
+			return false, "", fmt.Errorf("查询重复链接失败: %w", err)
+		}
+		if duplicate.ID != 0 {
+			return true, fmt.Sprintf("网盘链接与资源 #%d 重复", duplicate.ID), nil
+		}
+
+	case model.ModerationRuleTrustScore:
+		var cfg trustRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return false, "", err
+		}
+		approved, rejected, err := e.uploaderTrust()
+		if err != nil {
+			return false, "", err
+		}
+		reviews := approved + rejected
+		if reviews == 0 || reviews < int64(cfg.MinReviews) {
+			return false, "", nil
+		}
+		ratio := float64(approved) / float64(reviews)
+		if cfg.MinRatio != nil && ratio >= *cfg.MinRatio {
+			return true, fmt.Sprintf("上传者历史通过率 %.0f%%（%d次审核）", ratio*100, reviews), nil
+		}
+		if cfg.MaxRatio != nil && ratio <= *cfg.MaxRatio {
+			return true, fmt.Sprintf("上传者历史通过率仅 %.0f%%（%d次审核）", ratio*100, reviews), nil
+		}
+
+	case model.ModerationRulePriceCap:
+		var cfg priceRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return false, "", err
+		}
+		if r.PointsPrice > cfg.MaxPrice {
+			return true, fmt.Sprintf("积分价格 %d 超过上限 %d", r.PointsPrice, cfg.MaxPrice), nil
+		}
+
+	case model.ModerationRuleTrustedRole:
+		var cfg roleRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return false, "", err
+		}
+		uploader, err := e.getUploader()
+		if err != nil {
+			return false, "", err
+		}
+		for _, role := range cfg.Roles {
+			if uploader.Role == role {
+				return true, fmt.Sprintf("上传者角色为 %s", role), nil
+			}
+		}
+
+	default:
+		return false, "", fmt.Errorf("%w: 未知的规则类型 %s", ErrInvalidModerationRule, rule.Type)
+	}
+
+	return false, "", nil
+}
+
+// getUploader 获取上传者
+func (e *ruleEnv) getUploader() (*model.User, error) {
+	if e.uploader == nil {
+		var uploader model.User
+		if err := e.db.First(&uploader, e.resource.UploadedByID).Error; err != nil {
+			return nil, fmt.Errorf("查询上传者失败: %w", err)
+		}
+		e.uploader = &uploader
+	}
+	return e.uploader, nil
+}
+
+// uploaderTrust 统计上传者其他资源的人工审核通过数和拒绝数（不含系统自动审核）
+func (e *ruleEnv) uploaderTrust() (int64, int64, error) {
+	if e.trust == nil {
+		var rows []struct {
+			Action ReviewAction
+			Count  int64
+		}
+		if err := e.db.Table("review_logs").
+			Select("review_logs.action, COUNT(*) AS count").
+			Joins("JOIN resources ON resources.id = review_logs.resource_id").
+			Joins("JOIN users ON users.id = review_logs.reviewer_id").
+			Where("resources.uploaded_by_id = ? AND resources.id <> ?", e.resource.UploadedByID, e.resource.ID).
+			Where("review_logs.action IN ? AND users.role <> ?",
+				[]ReviewAction{ReviewActionApprove, ReviewActionReject}, systemReviewerRole).
+			Group("review_logs.action").
+			Scan(&rows).Error; err != nil {
+			return 0, 0, fmt.Errorf("统计上传者审核记录失败: %w", err)
+		}
+
+		var counts [2]int64
+		for _, row := range rows {
+			if row.Action == ReviewActionApprove {
+				counts[0] = row.Count
+			} else {
+				counts[1] = row.Count
+			}
+		}
+		e.trust = &counts
+	}
+	return e.trust[0], e.trust[1], nil
+}
+
+// validateRule 校验规则的类型、决定和配置
+func validateRule(rule *model.ModerationRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("%w: 规则名称不能为空", ErrInvalidModerationRule)
+	}
+
+	switch rule.Action {
+	case model.ModerationDecisionApprove, model.ModerationDecisionReject, model.ModerationDecisionHold:
+	default:
+		return fmt.Errorf("%w: 未知的审核决定 %s", ErrInvalidModerationRule, rule.Action)
+	}
+
+	if strings.TrimSpace(rule.Config) == "" {
+		rule.Config = "{}"
+	}
+
+	switch rule.Type {
+	case model.ModerationRuleKeyword:
+		var cfg keywordRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return err
+		}
+		if len(cfg.Keywords) == 0 {
+			return fmt.Errorf("%w: 关键词不能为空", ErrInvalidModerationRule)
+		}
+	case model.ModerationRuleRegex:
+		var cfg regexRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return err
+		}
+		if len(cfg.Patterns) == 0 {
+			return fmt.Errorf("%w: 正则表达式不能为空", ErrInvalidModerationRule)
+		}
+		for _, pattern := range cfg.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidModerationRule, err)
+			}
+		}
+	case model.ModerationRuleDomainAllowlist:
+		var cfg domainRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return err
+		}
+		if len(cfg.Domains) == 0 {
+			return fmt.Errorf("%w: 域名列表不能为空", ErrInvalidModerationRule)
+		}
+	case model.ModerationRuleDuplicateURL:
+		var cfg duplicateRuleConfig
+		return decodeRuleConfig(rule, &cfg)
+	case model.ModerationRuleTrustScore:
+		var cfg trustRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return err
+		}
+		if cfg.MinRatio == nil && cfg.MaxRatio == nil {
+			return fmt.Errorf("%w: 需要设置min_ratio或max_ratio", ErrInvalidModerationRule)
+		}
+	case model.ModerationRulePriceCap:
+		var cfg priceRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return err
+		}
+		if cfg.MaxPrice < 0 {
+			return fmt.Errorf("%w: 价格上限不能为负数", ErrInvalidModerationRule)
+		}
+	case model.ModerationRuleTrustedRole:
+		var cfg roleRuleConfig
+		if err := decodeRuleConfig(rule, &cfg); err != nil {
+			return err
+		}
+		if len(cfg.Roles) == 0 {
+			return fmt.Errorf("%w: 角色列表不能为空", ErrInvalidModerationRule)
+		}
+	default:
+		return fmt.Errorf("%w: 未知的规则类型 %s", ErrInvalidModerationRule, rule.Type)
+	}
+
+	return nil
+}
+
+// decodeRuleConfig 解析规则配置
+func decodeRuleConfig(rule *model.ModerationRule, out interface{}) error {
+	config := rule.Config
+	if strings.TrimSpace(config) == "" {
+		config = "{}"
+	}
+	if err := json.Unmarshal([]byte(config), out); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidModerationRule, err)
+	}
+	return nil
+}
+
+// resourceField 需要检查的资源字段
+type resourceField struct {
+	name string
+	text string
+}
+
+// resourceFields 获取需要检查的资源字段（按标题、描述、标签的顺序）
+func resourceFields(r *model.Resource, fields []string) []resourceField {
+	all := []resourceField{
+		{name: "title", text: r.Title},
+		{name: "description", text: r.Description},
+		{name: "tags", text: r.Tags},
+	}
+	if len(fields) == 0 {
+		return all
+	}
+
+	selected := make([]resourceField, 0, len(fields))
+	for _, field := range all {
+		for _, name := range fields {
+			if field.name == name {
+				selected = append(selected, field)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// fieldLabel 字段显示名称
+func fieldLabel(field string) string {
+	switch field {
+	case "title":
+		return "标题"
+	case "description":
+		return "描述"
+	case "tags":
+		return "标签"
+	default:
+		return field
+	}
+}
+
+// netdiskHost 解析网盘链接的域名（小写，不含端口）
+func netdiskHost(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
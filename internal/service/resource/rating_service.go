@@ -0,0 +1,478 @@
+/*
+Package resource provides resource management services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/seo"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrInvalidRating        = errors.New("评分必须是1到5之间的整数")
+	ErrRatingNotAllowed     = errors.New("下载过该资源后才能评分")
+	ErrRateOwnResource      = errors.New("不能给自己上传的资源评分")
+	ErrRatingNotFound       = errors.New("评分不存在")
+	ErrRatingContentTooLong = errors.New("评价内容过长")
+	ErrRatingNotPending     = errors.New("评价内容不在待审核状态")
+)
+
+// RatingSummary 资源评分汇总
+type RatingSummary struct {
+	Count        uint          `json:"count"`
+	Average      float64       `json:"average"`
+	Score        float64       `json:"score"`        // 贝叶斯平均分
+	Distribution map[int]int64 `json:"distribution"` // 各星级的评分数
+}
+
+// RatingService 资源评分服务
+type RatingService struct {
+	db        *gorm.DB
+	cfg       *config.RatingConfig
+	sensitive *filter.Dictionary
+	notifier  notification.Publisher
+}
+
+// NewRatingService 创建资源评分服务
+func NewRatingService(db *gorm.DB) *RatingService {
+	return &RatingService{
+		db:  db,
+		cfg: config.DefaultRatingConfig(),
+	}
+}
+
+// SetConfig 设置评分配置
+func (s *RatingService) SetConfig(cfg *config.RatingConfig) {
+	if cfg != nil {
+		s.cfg = cfg
+	}
+}
+
+// SetFilter 设置敏感词库（过滤评价内容），为nil时不过滤
+func (s *RatingService) SetFilter(sensitive *filter.Dictionary) {
+	s.sensitive = sensitive
+}
+
+// SetNotifier 设置通知发布器（资源评分过低时通知审核员），为nil时不通知
+func (s *RatingService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// RateResource 给资源评分（已评分时修改原评分）
+// 参数：
+//   - resourceID: 资源ID
+//   - userID: 评分用户ID
+//   - rating: 评分(1-5)
+//   - content: 评价内容（可选）
+//
+// 返回：
+//   - 评分记录
+//   - 错误信息
+func (s *RatingService) RateResource(resourceID, userID uint, rating int, content string) (*model.ResourceRating, error) {
+	if rating < 1 || rating > 5 {
+		return nil, ErrInvalidRating
+	}
+	content = strings.TrimSpace(content)
+	if s.cfg.MaxContentLength > 0 && utf8.RuneCountInString(content) > s.cfg.MaxContentLength {
+		return nil, ErrRatingContentTooLong
+	}
+
+	// 敏感词过滤（违禁词拒绝，需人工审核的评价内容待审核后展示，低级别敏感词替换为*）
+	screened := s.sensitive.Sanitize(&content)
+	if err := screened.Err(); err != nil {
+		return nil, err
+	}
+	pending := screened.NeedsReview()
+	notes := ""
+	if pending {
+		notes = screened.Notes()
+	}
+
+	var resource model.Resource
+	if err := s.db.Where("status = ?", model.ResourceStatusApproved).First(&resource, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+	if resource.UploadedByID == userID {
+		return nil, ErrRateOwnResource
+	}
+	if s.cfg.RequireDownload {
+		downloaded, err := s.hasDownloaded(userID, resourceID)
+		if err != nil {
+			return nil, err
+		}
+		if !downloaded {
+			return nil, ErrRatingNotAllowed
+		}
+	}
+
+	var record model.ResourceRating
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("resource_id = ? AND user_id = ?", resourceID, userID).First(&record).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			record = model.ResourceRating{
+				ResourceID:     resourceID,
+				UserID:         userID,
+				Rating:         rating,
+				Content:        content,
+				ContentPending: pending,
+				ReviewNotes:    notes,
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return fmt.Errorf("保存评分失败: %w", err)
+			}
+		case err != nil:
+			return fmt.Errorf("查询评分失败: %w", err)
+		default:
+			if err := tx.Model(&record).Updates(map[string]interface{}{
+				"rating":          rating,
+				"content":         content,
+				"content_pending": pending,
+				"review_notes":    notes,
+			}).Error; err != nil {
+				return fmt.Errorf("保存评分失败: %w", err)
+			}
+		}
+
+		return s.refresh(tx, resourceID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.checkLowRating(resourceID)
+	return &record, nil
+}
+
+// DeleteRating 删除用户本人的评分
+// 参数：
+//   - resourceID: 资源ID
+//   - userID: 评分用户ID
+//
+// 返回：
+//   - 错误信息
+func (s *RatingService) DeleteRating(resourceID, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("resource_id = ? AND user_id = ?", resourceID, userID).Delete(&model.ResourceRating{})
+		if result.Error != nil {
+			return fmt.Errorf("删除评分失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrRatingNotFound
+		}
+		return s.refresh(tx, resourceID)
+	})
+}
+
+// GetUserRating 获取用户对资源的评分
+// 参数：
+//   - resourceID: 资源ID
+//   - userID: 用户ID
+//
+// 返回：
+//   - 评分记录
+//   - 错误信息（未评分时为 ErrRatingNotFound）
+func (s *RatingService) GetUserRating(resourceID, userID uint) (*model.ResourceRating, error) {
+	var record model.ResourceRating
+	if err := s.db.Where("resource_id = ? AND user_id = ?", resourceID, userID).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, fmt.Errorf("查询评分失败: %w", err)
+	}
+	return &record, nil
+}
+
+// ListRatings 获取资源的评分列表（不含评价内容待审核的评分）
+// 参数：
+//   - resourceID: 资源ID
+//   - sort: 排序方式（latest 最新, highest 评分最高, lowest 评分最低）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 评分列表
+//   - 总数
+//   - 错误信息
+func (s *RatingService) ListRatings(resourceID uint, sort string, page, pageSize int) ([]model.ResourceRating, int64, error) {
+	newQuery := func() *gorm.DB {
+		return s.db.Model(&model.ResourceRating{}).Where("resource_id = ? AND content_pending = ?", resourceID, false)
+	}
+
+	var total int64
+	if err := newQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计评分失败: %w", err)
+	}
+
+	order := "updated_at DESC"
+	switch sort {
+	case "highest":
+		order = "rating DESC, updated_at DESC"
+	case "lowest":
+		order = "rating ASC, updated_at DESC"
+	}
+
+	var ratings []model.ResourceRating
+	if err := newQuery().Preload("User").
+		Order(order).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&ratings).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询评分失败: %w", err)
+	}
+
+	return ratings, total, nil
+}
+
+// ListPendingRatings 获取评价内容待审核的评分（最早提交的在前）
+// 参数：
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 评分列表
+//   - 总数
+//   - 错误信息
+func (s *RatingService) ListPendingRatings(page, pageSize int) ([]model.ResourceRating, int64, error) {
+	query := s.db.Model(&model.ResourceRating{}).Where("content_pending = ?", true)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计待审核评价失败: %w", err)
+	}
+
+	var ratings []model.ResourceRating
+	if err := query.Preload("User").Preload("Resource").
+		Order("updated_at ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&ratings).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询待审核评价失败: %w", err)
+	}
+
+	return ratings, total, nil
+}
+
+// ModerateRating 审核评价内容（通过后公开展示，拒绝时清空评价内容，评分保留）
+// 参数：
+//   - id: 评分ID
+//   - approve: 是否通过
+//
+// 返回：
+//   - 审核后的评分记录
+//   - 错误信息
+func (s *RatingService) ModerateRating(id uint, approve bool) (*model.ResourceRating, error) {
+	updates := map[string]interface{}{
+		"content_pending": false,
+		"review_notes":    "",
+	}
+	if !approve {
+		updates["content"] = ""
+	}
+
+	result := s.db.Model(&model.ResourceRating{}).
+		Where("id = ? AND content_pending = ?", id, true).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("审核评价失败: %w", result.Error)
+	}
+
+	var record model.ResourceRating
+	if err := s.db.First(&record, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, fmt.Errorf("查询评分失败: %w", err)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRatingNotPending
+	}
+	return &record, nil
+}
+
+// GetSummary 获取资源的评分汇总
+// 参数：
+//   - resourceID: 资源ID
+//
+// 返回：
+//   - 评分汇总
+//   - 错误信息
+func (s *RatingService) GetSummary(resourceID uint) (*RatingSummary, error) {
+	var resource model.Resource
+	if err := s.db.Select("id, rating_count, rating_average, rating_score").First(&resource, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+
+	var rows []struct {
+		Rating int
+		Count  int64
+	}
+	if err := s.db.Model(&model.ResourceRating{}).
+		Select("rating, COUNT(*) AS count").
+		Where("resource_id = ?", resourceID).
+		Group("rating").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计评分分布失败: %w", err)
+	}
+
+	summary := &RatingSummary{
+		Count:        resource.RatingCount,
+		Average:      resource.RatingAverage,
+		Score:        resource.RatingScore,
+		Distribution: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+	}
+	for _, row := range rows {
+		summary.Distribution[row.Rating] = row.Count
+	}
+	return summary, nil
+}
+
+// RecalculateScores 重新计算所有资源的评分（修改先验参数后使用）
+// 返回：
+//   - 更新的资源数
+//   - 错误信息
+func (s *RatingService) RecalculateScores() (int, error) {
+	var resourceIDs []uint
+	if err := s.db.Model(&model.Resource{}).
+		Where("rating_count > 0 OR id IN (?)", s.db.Model(&model.ResourceRating{}).Select("resource_id")).
+		Pluck("id", &resourceIDs).Error; err != nil {
+		return 0, fmt.Errorf("查询资源失败: %w", err)
+	}
+
+	for _, id := range resourceIDs {
+		if err := s.refresh(s.db, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(resourceIDs), nil
+}
+
+// BayesianScore 计算贝叶斯平均分：评分较少时向先验评分靠拢，评分越多越接近真实平均分
+// 参数：
+//   - count: 评分数
+//   - sum: 评分总和
+//
+// 返回：
+//   - 贝叶斯平均分（没有评分时为0）
+func (s *RatingService) BayesianScore(count, sum int64) float64 {
+	return bayesianScore(s.cfg, count, sum)
+}
+
+// refresh 根据评分记录重新计算资源的评分统计（不修改资源的更新时间）
+func (s *RatingService) refresh(tx *gorm.DB, resourceID uint) error {
+	var totals struct {
+		Count int64
+		Sum   int64
+	}
+	if err := tx.Model(&model.ResourceRating{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(rating), 0) AS sum").
+		Where("resource_id = ?", resourceID).
+		Scan(&totals).Error; err != nil {
+		return fmt.Errorf("统计评分失败: %w", err)
+	}
+
+	average := 0.0
+	if totals.Count > 0 {
+		average = float64(totals.Sum) / float64(totals.Count)
+	}
+	if err := tx.Model(&model.Resource{}).Where("id = ?", resourceID).UpdateColumns(map[string]interface{}{
+		"rating_count":   totals.Count,
+		"rating_average": average,
+		"rating_score":   bayesianScore(s.cfg, totals.Count, totals.Sum),
+	}).Error; err != nil {
+		return fmt.Errorf("更新资源评分失败: %w", err)
+	}
+	return nil
+}
+
+// checkLowRating 资源平均评分过低时通知审核员（同一资源在冷却时间内只提醒一次，失败不影响评分）
+func (s *RatingService) checkLowRating(resourceID uint) {
+	if s.notifier == nil || s.cfg.AlertThreshold <= 0 {
+		return
+	}
+
+	var resource model.Resource
+	if err := s.db.Select("id, title, rating_count, rating_average").First(&resource, resourceID).Error; err != nil {
+		return
+	}
+	if int(resource.RatingCount) < s.cfg.AlertMinRatings || resource.RatingAverage >= s.cfg.AlertThreshold {
+		return
+	}
+
+	// 条件更新提醒时间，避免并发评分重复提醒
+	now := time.Now()
+	result := s.db.Model(&model.Resource{}).
+		Where("id = ? AND (rating_alerted_at IS NULL OR rating_alerted_at < ?)", resourceID, now.Add(-s.cfg.GetAlertCooldown())).
+		UpdateColumn("rating_alerted_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	var reviewerIDs []uint
+	if err := s.db.Model(&model.User{}).
+		Where("role IN ?", []string{"admin", "moderator"}).
+		Pluck("id", &reviewerIDs).Error; err != nil {
+		return
+	}
+	for _, reviewerID := range reviewerIDs {
+		_ = s.notifier.Publish(&notification.Event{
+			Type:       model.NotificationTypeLowRating,
+			UserID:     reviewerID,
+			Title:      fmt.Sprintf("资源《%s》评分过低", resource.Title),
+			Content:    fmt.Sprintf("该资源已收到 %d 个评分，平均 %.1f 分，请检查资源质量。", resource.RatingCount, resource.RatingAverage),
+			Link:       seo.ResourcePath(resource.ID, resource.Slug),
+			TargetType: "resource",
+			TargetID:   &resource.ID,
+		})
+	}
+}
+
+// hasDownloaded 检查用户是否下载过资源（包括下载记录表启用前的付费下载）
+func (s *RatingService) hasDownloaded(userID, resourceID uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&model.DownloadRecord{}).
+		Where("user_id = ? AND resource_id = ?", userID, resourceID).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询下载记录失败: %w", err)
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := s.db.Model(&model.PointRecord{}).
+		Where("user_id = ? AND resource_id = ? AND source = ?", userID, resourceID, model.PointSourceResourceDownload).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询下载记录失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// bayesianScore 计算贝叶斯平均分 (C*m + Σr) / (C + n)
+func bayesianScore(cfg *config.RatingConfig, count, sum int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return (cfg.PriorWeight*cfg.PriorMean + float64(sum)) / (cfg.PriorWeight + float64(count))
+}
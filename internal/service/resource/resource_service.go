@@ -13,8 +13,13 @@ import (
 	"time"
 
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/filter"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/seo"
+	"resource-share-site/internal/service/tag"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Errors 定义自定义错误
@@ -24,20 +29,47 @@ var (
 	ErrResourceDeleted       = errors.New("资源已删除")
 	ErrInsufficientPoints    = errors.New("积分不足")
 	ErrDownloadLimitExceeded = errors.New("超出下载限制")
+	ErrCategoryNotFound      = errors.New("分类不存在")
 )
 
 // ResourceService 资源服务
 type ResourceService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	moderator *ModerationService
+	sensitive *filter.Dictionary
+	tags      *tag.TagService
+	notifier  notification.Publisher
+	indexer   seo.URLPublisher
 }
 
 // NewResourceService 创建新的资源服务
 func NewResourceService(db *gorm.DB) *ResourceService {
 	return &ResourceService{
-		db: db,
+		db:   db,
+		tags: tag.NewTagService(db),
 	}
 }
 
+// SetModerator 设置自动审核服务（创建和更新资源时执行审核规则），为nil时不启用
+func (s *ResourceService) SetModerator(moderator *ModerationService) {
+	s.moderator = moderator
+}
+
+// SetFilter 设置敏感词库（创建和修改资源时过滤标题和描述），为nil时不过滤
+func (s *ResourceService) SetFilter(sensitive *filter.Dictionary) {
+	s.sensitive = sensitive
+}
+
+// SetNotifier 设置通知发布器（通知修改者待审核修订的审核结果），为nil时不通知
+func (s *ResourceService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// SetIndexer 设置URL推送器（删除已上线的资源时通知搜索引擎），为nil时不推送
+func (s *ResourceService) SetIndexer(indexer seo.URLPublisher) {
+	s.indexer = indexer
+}
+
 // CreateResource 创建资源
 // 参数：
 //   - title: 资源标题
@@ -45,7 +77,7 @@ func NewResourceService(db *gorm.DB) *ResourceService {
 //   - categoryID: 分类ID
 //   - netdiskURL: 网盘链接
 //   - pointsPrice: 所需积分（0表示免费）
-//   - tags: 标签（JSON格式）
+//   - tags: 标签（JSON 数组或逗号分隔的标签名）
 //   - uploadedByID: 上传者ID
 //   - source: 资源来源
 //
@@ -57,7 +89,7 @@ func (s *ResourceService) CreateResource(title, description string, categoryID u
 	var category model.Category
 	if err := s.db.First(&category, categoryID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("分类不存在")
+			return nil, ErrCategoryNotFound
 		}
 		return nil, fmt.Errorf("查询分类失败: %w", err)
 	}
@@ -71,70 +103,136 @@ func (s *ResourceService) CreateResource(title, description string, categoryID u
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
 
+	// 敏感词过滤（违禁词拒绝，低级别敏感词替换为*）
+	screened := s.sensitive.Sanitize(&title, &description)
+	if err := screened.Err(); err != nil {
+		return nil, err
+	}
+
+	tagNames := tag.ParseTags(tags)
+	if len(tagNames) > tag.MaxTagsPerItem {
+		return nil, tag.ErrTooManyTags
+	}
+
 	// 创建资源
 	resource := &model.Resource{
 		Title:        title,
+		Slug:         seo.Slugify(title, seo.ResourceSlugMaxLength),
 		Description:  description,
 		CategoryID:   categoryID,
 		NetdiskURL:   netdiskURL,
 		PointsPrice:  pointsPrice,
-		Tags:         tags,
 		UploadedByID: uploadedByID,
 		Source:       source,
 		Status:       model.ResourceStatusPending, // 默认待审核
+		ReviewNotes:  screened.Notes(),
 	}
 
 	if err := s.db.Create(resource).Error; err != nil {
 		return nil, fmt.Errorf("创建资源失败: %w", err)
 	}
 
+	// 关联标签（自动审核规则会检查同步后的标签字段）
+	if err := s.setTags(resource, tagNames); err != nil {
+		return nil, err
+	}
+
+	// 记录初始版本
+	if _, err := s.recordRevision(s.db, resource, uploadedByID, "创建资源"); err != nil {
+		return nil, err
+	}
+
+	// 执行自动审核规则（命中敏感词的资源只能人工审核）
+	if !screened.NeedsReview() {
+		s.moderate(resource)
+	}
+
 	return resource, nil
 }
 
-// UpdateResource 更新资源
+// UpdateResource 更新资源（每次修改保存修订快照，非管理员修改已上线资源时提交待审核修订）
 // 参数：
 //   - resourceID: 资源ID
+//   - editorID: 修改者ID（上传者、管理员或版主）
 //   - title: 资源标题
 //   - description: 资源描述
 //   - categoryID: 分类ID
 //   - netdiskURL: 网盘链接
 //   - pointsPrice: 所需积分
-//   - tags: 标签（JSON格式）
+//   - tags: 标签（JSON 数组或逗号分隔的标签名）
+//   - reason: 修改原因
 //
 // 返回：
-//   - 更新后的资源对象
+//   - 资源对象（提交待审核修订时为未修改的当前版本）
+//   - 本次修改的修订
 //   - 错误信息
-func (s *ResourceService) UpdateResource(resourceID uint, title, description string, categoryID uint, netdiskURL string, pointsPrice int, tags string) (*model.Resource, error) {
+func (s *ResourceService) UpdateResource(resourceID, editorID uint, title, description string, categoryID uint, netdiskURL string, pointsPrice int, tags, reason string) (*model.Resource, *model.ResourceRevision, error) {
 	// 获取原有资源
-	var resource model.Resource
-	if err := s.db.First(&resource, resourceID).Error; err != nil {
+	resource, err := s.getEditableResource(resourceID, editorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 敏感词过滤（违禁词拒绝，低级别敏感词替换为*）
+	screened := s.sensitive.Sanitize(&title, &description)
+	if err := screened.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	tagNames := tag.ParseTags(tags)
+	if len(tagNames) > tag.MaxTagsPerItem {
+		return nil, nil, tag.ErrTooManyTags
+	}
+
+	// 检查分类是否存在
+	var category model.Category
+	if err := s.db.First(&category, categoryID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrResourceNotFound
+			return nil, nil, ErrCategoryNotFound
 		}
-		return nil, fmt.Errorf("查询资源失败: %w", err)
+		return nil, nil, fmt.Errorf("查询分类失败: %w", err)
 	}
 
-	// 检查资源是否已审核通过（已审核的资源不允许修改基本信息）
-	if resource.Status == model.ResourceStatusApproved {
-		return nil, errors.New("已审核通过的资源不允许修改")
+	current, err := s.ensureBaseline(s.db, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revision := &model.ResourceRevision{
+		ResourceID:  resourceID,
+		BaseVersion: current,
+		Title:       title,
+		Description: description,
+		CategoryID:  categoryID,
+		NetdiskURL:  netdiskURL,
+		PointsPrice: pointsPrice,
+		Tags:        formatTags(tagNames),
+		EditorID:    editorID,
+		Reason:      reason,
+	}
+	if sameContent(snapshot(resource), revision) {
+		return nil, nil, ErrRevisionUnchanged
+	}
+
+	// 非管理员修改已上线资源：提交待审核修订，审核通过前资源保持当前版本
+	if resource.Status == model.ResourceStatusApproved && s.requireRole(editorID, "admin", "moderator") != nil {
+		revision.ReviewNotes = screened.Notes()
+		if err := s.submitRevision(revision); err != nil {
+			return nil, nil, err
+		}
+		return resource, revision, nil
 	}
 
-	// 更新资源
-	updates := map[string]interface{}{
-		"title":        title,
-		"description":  description,
-		"category_id":  categoryID,
-		"netdisk_url":  netdiskURL,
-		"points_price": pointsPrice,
-		"tags":         tags,
-		"updated_at":   time.Now(),
+	if err := s.applyRevision(resource, revision); err != nil {
+		return nil, nil, err
 	}
 
-	if err := s.db.Model(&resource).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("更新资源失败: %w", err)
+	// 未上线的资源修改后重新执行自动审核规则（命中敏感词的资源只能人工审核）
+	if resource.Status != model.ResourceStatusApproved && !screened.NeedsReview() {
+		s.moderate(resource)
 	}
 
-	return &resource, nil
+	return resource, revision, nil
 }
 
 // DeleteResource 删除资源
@@ -195,6 +293,10 @@ func (s *ResourceService) DeleteResource(resourceID uint, force bool) error {
 		return fmt.Errorf("提交事务失败: %w", err)
 	}
 
+	if resource.Status == model.ResourceStatusApproved {
+		publishResourceURL(s.indexer, seo.IndexActionDelete, &resource)
+	}
+
 	return nil
 }
 
@@ -233,14 +335,15 @@ func (s *ResourceService) GetResourceByID(resourceID uint, includeDeleted bool)
 //   - uploadedByID: 上传者ID筛选（可选）
 //   - minPrice: 最低价格筛选（可选）
 //   - maxPrice: 最高价格筛选（可选）
-//   - orderBy: 排序字段
+//   - orderBy: 排序字段（rating 按贝叶斯平均分排序）
 //   - orderDesc: 是否降序
+//   - tags: 标签筛选（可选，支持全部匹配或任意匹配）
 //
 // 返回：
 //   - 资源列表
 //   - 总数
 //   - 错误信息
-func (s *ResourceService) GetResources(page, pageSize int, categoryID *uint, status *model.ResourceStatus, uploadedByID *uint, minPrice, maxPrice *int, orderBy string, orderDesc bool) ([]*model.Resource, int64, error) {
+func (s *ResourceService) GetResources(page, pageSize int, categoryID *uint, status *model.ResourceStatus, uploadedByID *uint, minPrice, maxPrice *int, orderBy string, orderDesc bool, tags *tag.Filter) ([]*model.Resource, int64, error) {
 	var resources []*model.Resource
 	var total int64
 
@@ -262,6 +365,10 @@ func (s *ResourceService) GetResources(page, pageSize int, categoryID *uint, sta
 	if maxPrice != nil {
 		query = query.Where("points_price <= ?", *maxPrice)
 	}
+	query, err := s.tags.FilterResources(query, "id", tags)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析标签筛选失败: %w", err)
+	}
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
@@ -269,13 +376,18 @@ func (s *ResourceService) GetResources(page, pageSize int, categoryID *uint, sta
 	}
 
 	// 应用排序
-	if orderBy == "" {
-		orderBy = "created_at"
-	}
+	direction := "ASC"
 	if orderDesc {
-		query = query.Order(fmt.Sprintf("%s DESC", orderBy))
-	} else {
-		query = query.Order(fmt.Sprintf("%s ASC", orderBy))
+		direction = "DESC"
+	}
+	switch orderBy {
+	case "":
+		query = query.Order(fmt.Sprintf("created_at %s", direction))
+	case "rating", "rating_score":
+		// 按贝叶斯平均分排序，分数相同时评分人数多的在前
+		query = query.Order(fmt.Sprintf("rating_score %s, rating_count DESC, created_at DESC", direction))
+	default:
+		query = query.Order(fmt.Sprintf("%s %s", orderBy, direction))
 	}
 
 	// 获取列表
@@ -399,6 +511,24 @@ func (s *ResourceService) DownloadResource(resourceID, userID uint) (string, err
 		return "", fmt.Errorf("更新下载次数失败: %w", err)
 	}
 
+	// 记录下载历史（重复下载只累加次数，用于相关资源推荐）
+	now := time.Now()
+	record := &model.DownloadRecord{
+		UserID:           userID,
+		ResourceID:       resourceID,
+		DownloadCount:    1,
+		LastDownloadedAt: now,
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "resource_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"download_count":     gorm.Expr("download_count + 1"),
+			"last_downloaded_at": now,
+		}),
+	}).Create(record).Error; err != nil {
+		return "", fmt.Errorf("记录下载历史失败: %w", err)
+	}
+
 	return resource.NetdiskURL, nil
 }
 
@@ -440,7 +570,7 @@ func (s *ResourceService) ViewResource(resourceID uint) error {
 //   - 总数
 //   - 错误信息
 func (s *ResourceService) GetUserResources(userID uint, page, pageSize int, status *model.ResourceStatus) ([]*model.Resource, int64, error) {
-	return s.GetResources(page, pageSize, nil, status, &userID, nil, nil, "created_at", true)
+	return s.GetResources(page, pageSize, nil, status, &userID, nil, nil, "created_at", true, nil)
 }
 
 // GetResourcesByCategory 获取指定分类的资源
@@ -449,7 +579,7 @@ func (s *ResourceService) GetUserResources(userID uint, page, pageSize int, stat
 //   - page: 页码
 //   - pageSize: 每页数量
 //   - status: 状态筛选（可选）
-//   - orderBy: 排序字段
+//   - orderBy: 排序字段（rating 按贝叶斯平均分排序）
 //   - orderDesc: 是否降序
 //
 // 返回：
@@ -457,7 +587,7 @@ func (s *ResourceService) GetUserResources(userID uint, page, pageSize int, stat
 //   - 总数
 //   - 错误信息
 func (s *ResourceService) GetResourcesByCategory(categoryID uint, page, pageSize int, status *model.ResourceStatus, orderBy string, orderDesc bool) ([]*model.Resource, int64, error) {
-	return s.GetResources(page, pageSize, &categoryID, status, nil, nil, nil, orderBy, orderDesc)
+	return s.GetResources(page, pageSize, &categoryID, status, nil, nil, nil, orderBy, orderDesc, nil)
 }
 
 // GetFreeResources 获取免费资源
@@ -472,7 +602,7 @@ func (s *ResourceService) GetResourcesByCategory(categoryID uint, page, pageSize
 //   - 错误信息
 func (s *ResourceService) GetFreeResources(page, pageSize int, categoryID *uint) ([]*model.Resource, int64, error) {
 	zero := 0
-	return s.GetResources(page, pageSize, categoryID, nil, nil, &zero, &zero, "created_at", true)
+	return s.GetResources(page, pageSize, categoryID, nil, nil, &zero, &zero, "created_at", true, nil)
 }
 
 // GetPopularResources 获取热门资源（按下载量排序）
@@ -518,29 +648,45 @@ func (s *ResourceService) GetPopularResources(page, pageSize int, categoryID *ui
 // UpdateResourceTags 更新资源标签
 // 参数：
 //   - resourceID: 资源ID
-//   - tags: 标签（JSON格式）
+//   - tags: 标签（JSON 数组或逗号分隔的标签名）
 //
 // 返回：
 //   - 错误信息
 func (s *ResourceService) UpdateResourceTags(resourceID uint, tags string) error {
-	result := s.db.Model(&model.Resource{}).
-		Where("id = ? AND deleted_at IS NULL", resourceID).
-		Updates(map[string]interface{}{
-			"tags":       tags,
-			"updated_at": time.Now(),
-		})
+	tagNames := tag.ParseTags(tags)
+	if len(tagNames) > tag.MaxTagsPerItem {
+		return tag.ErrTooManyTags
+	}
 
-	if result.Error != nil {
-		return fmt.Errorf("更新资源标签失败: %w", result.Error)
+	var resource model.Resource
+	if err := s.db.Select("id").First(&resource, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrResourceNotFound
+		}
+		return fmt.Errorf("查询资源失败: %w", err)
 	}
 
-	if result.RowsAffected == 0 {
-		return ErrResourceNotFound
+	if err := s.setTags(&resource, tagNames); err != nil {
+		return err
+	}
+
+	if err := s.db.Model(&resource).UpdateColumn("updated_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("更新资源标签失败: %w", err)
 	}
 
 	return nil
 }
 
+// setTags 更新资源的标签关联并同步标签字段
+func (s *ResourceService) setTags(resource *model.Resource, tagNames []string) error {
+	_, formatted, err := s.tags.SetResourceTags(resource.ID, tagNames)
+	if err != nil {
+		return fmt.Errorf("更新资源标签失败: %w", err)
+	}
+	resource.Tags = formatted
+	return nil
+}
+
 // CountResources 统计资源数量
 // 参数：
 //   - categoryID: 分类ID筛选（可选）
@@ -570,3 +716,52 @@ func (s *ResourceService) CountResources(categoryID *uint, status *model.Resourc
 
 	return count, nil
 }
+
+// moderate 执行自动审核并刷新资源状态（自动审核失败时资源保持待人工审核）
+func (s *ResourceService) moderate(resource *model.Resource) {
+	if s.moderator == nil {
+		return
+	}
+
+	result, err := s.moderator.Apply(resource.ID)
+	if err != nil || result == nil || len(result.Matches) == 0 {
+		return
+	}
+
+	_ = s.db.First(resource, resource.ID).Error
+}
+
+// BackfillSlugs 为尚未生成slug的资源按标题生成slug（可重复执行）
+// 返回：
+//   - 生成slug的资源数量
+//   - 错误信息
+func (s *ResourceService) BackfillSlugs() (int, error) {
+	const batchSize = 500
+
+	updated := 0
+	var lastID uint
+	for {
+		var resources []model.Resource
+		if err := s.db.Select("id", "title").
+			Where("(slug = '' OR slug IS NULL) AND id > ?", lastID).
+			Order("id ASC").Limit(batchSize).Find(&resources).Error; err != nil {
+			return updated, fmt.Errorf("查询资源失败: %w", err)
+		}
+		if len(resources) == 0 {
+			return updated, nil
+		}
+
+		for _, resource := range resources {
+			lastID = resource.ID
+			slug := seo.Slugify(resource.Title, seo.ResourceSlugMaxLength)
+			if slug == "" {
+				continue
+			}
+			if err := s.db.Model(&model.Resource{}).Where("id = ?", resource.ID).
+				UpdateColumn("slug", slug).Error; err != nil {
+				return updated, fmt.Errorf("更新资源slug失败: %w", err)
+			}
+			updated++
+		}
+	}
+}
@@ -0,0 +1,947 @@
+/*
+Package resource provides the moderator review queue services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 审核队列优先级（数值越大越优先）
+const (
+	ReviewPriorityNormal  = 0 // 普通上传者
+	ReviewPriorityTrusted = 1 // 可信上传者（版主、管理员或历史通过率高）
+	ReviewPriorityVIP     = 2 // VIP上传者
+)
+
+// ReviewQueueOptions 审核队列查询选项
+type ReviewQueueOptions struct {
+	ReviewerID uint                  // 当前审核员ID
+	Page       int                   // 页码
+	PageSize   int                   // 每页数量
+	CategoryID *uint                 // 分类筛选（可选）
+	Source     *model.ResourceSource // 来源筛选（可选）
+	Available  bool                  // 只显示未被其他审核员认领的资源
+	Mine       bool                  // 只显示自己认领的资源
+}
+
+// ReviewQueueItem 审核队列条目
+type ReviewQueueItem struct {
+	Resource       *model.Resource    `json:"resource"`
+	Priority       int                `json:"priority"`
+	PriorityReason string             `json:"priority_reason,omitempty"` // vip/trusted
+	PendingSince   time.Time          `json:"pending_since"`             // 进入待审核状态的时间
+	WaitingMinutes int64              `json:"waiting_minutes"`           // 已等待时长（SLA计时）
+	SLADeadline    time.Time          `json:"sla_deadline"`
+	Overdue        bool               `json:"overdue"` // 是否已超过审核时效
+	Claim          *model.ReviewClaim `json:"claim,omitempty"`
+	ClaimedByMe    bool               `json:"claimed_by_me"`
+}
+
+// ReviewerStats 审核员绩效统计
+type ReviewerStats struct {
+	ReviewerID            uint    `json:"reviewer_id"`
+	Username              string  `json:"username"`
+	Decisions             int     `json:"decisions"` // 审核决定数（通过+拒绝）
+	Approved              int     `json:"approved"`
+	Rejected              int     `json:"rejected"`
+	Reversed              int     `json:"reversed"`                // 被撤回的决定数
+	ReversalRate          float64 `json:"reversal_rate"`           // 撤回率(%)
+	ThroughputPerDay      float64 `json:"throughput_per_day"`      // 日均审核数
+	MedianDecisionMinutes float64 `json:"median_decision_minutes"` // 从进入待审核到做出决定的耗时中位数(分钟)
+
+	durations []float64
+}
+
+// reviewerReport 审核员绩效汇总
+type reviewerReport struct {
+	Reviewers             []*ReviewerStats
+	AvgDecisionMinutes    float64
+	MedianDecisionMinutes float64
+	ReversalRate          float64
+}
+
+// queueEntry 待审核资源的排序信息
+type queueEntry struct {
+	id           uint
+	uploaderID   uint
+	pendingSince time.Time
+	priority     int
+	reason       string
+}
+
+// ==================== 审核队列 ====================
+
+// GetReviewQueue 获取审核队列（VIP和可信上传者优先，同优先级按等待时间排序）
+// 参数：
+//   - opts: 查询选项
+//
+// 返回：
+//   - 队列条目列表
+//   - 总数
+//   - 错误信息
+func (s *ReviewService) GetReviewQueue(opts ReviewQueueOptions) ([]*ReviewQueueItem, int64, error) {
+	// 释放超时的认领
+	if _, err := s.ReleaseExpiredClaims(); err != nil {
+		return nil, 0, err
+	}
+
+	newQuery := func() *gorm.DB {
+		query := s.db.Model(&model.Resource{}).
+			Where("status = ? AND deleted_at IS NULL", model.ResourceStatusPending)
+		if opts.CategoryID != nil {
+			query = query.Where("category_id = ?", *opts.CategoryID)
+		}
+		if opts.Source != nil {
+			query = query.Where("source = ?", *opts.Source)
+		}
+		return query
+	}
+
+	entries, err := s.loadPendingEntries(newQuery)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	claims, err := s.activeClaims()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 按认领情况筛选
+	filtered := entries[:0]
+	for _, entry := range entries {
+		claim := claims[entry.id]
+		if opts.Mine && (claim == nil || claim.ReviewerID != opts.ReviewerID) {
+			continue
+		}
+		if opts.Available && claim != nil && claim.ReviewerID != opts.ReviewerID {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	entries = filtered
+
+	if err := s.applyPriorities(entries); err != nil {
+		return nil, 0, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].priority != entries[j].priority {
+			return entries[i].priority > entries[j].priority
+		}
+		if !entries[i].pendingSince.Equal(entries[j].pendingSince) {
+			return entries[i].pendingSince.Before(entries[j].pendingSince)
+		}
+		return entries[i].id < entries[j].id
+	})
+
+	total := int64(len(entries))
+
+	// 分页
+	offset := (opts.Page - 1) * opts.PageSize
+	if offset >= len(entries) {
+		return []*ReviewQueueItem{}, total, nil
+	}
+	end := offset + opts.PageSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+	entries = entries[offset:end]
+
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.id)
+	}
+
+	var resources []*model.Resource
+	if err := s.db.Preload("Category").Preload("UploadedBy").
+		Where("id IN ?", ids).
+		Find(&resources).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询待审核资源列表失败: %w", err)
+	}
+	resourceMap := make(map[uint]*model.Resource, len(resources))
+	for _, resource := range resources {
+		resourceMap[resource.ID] = resource
+	}
+
+	now := time.Now()
+	sla := s.cfg.GetSLA()
+	items := make([]*ReviewQueueItem, 0, len(entries))
+	for _, entry := range entries {
+		resource, ok := resourceMap[entry.id]
+		if !ok {
+			continue
+		}
+		item := &ReviewQueueItem{
+			Resource:       resource,
+			Priority:       entry.priority,
+			PriorityReason: entry.reason,
+			PendingSince:   entry.pendingSince,
+			WaitingMinutes: int64(now.Sub(entry.pendingSince).Minutes()),
+			SLADeadline:    entry.pendingSince.Add(sla),
+			Claim:          claims[entry.id],
+		}
+		item.Overdue = now.After(item.SLADeadline)
+		item.ClaimedByMe = item.Claim != nil && item.Claim.ReviewerID == opts.ReviewerID
+		items = append(items, item)
+	}
+
+	return items, total, nil
+}
+
+// ClaimResource 认领待审核资源（本人已认领时续期租约）
+// 参数：
+//   - resourceID: 资源ID
+//   - reviewerID: 审核员ID
+//
+// 返回：
+//   - 认领记录
+//   - 错误信息
+func (s *ReviewService) ClaimResource(resourceID, reviewerID uint) (*model.ReviewClaim, error) {
+	if _, err := s.getReviewer(reviewerID); err != nil {
+		return nil, err
+	}
+
+	var resource model.Resource
+	if err := s.db.Select("id", "status").First(&resource, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+	if resource.Status != model.ResourceStatusPending {
+		return nil, ErrResourceNotPending
+	}
+
+	now := time.Now()
+	claim := &model.ReviewClaim{
+		ResourceID: resourceID,
+		ReviewerID: reviewerID,
+		ClaimedAt:  now,
+		ExpiresAt:  now.Add(s.cfg.GetClaimTTL()),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 该资源已过期的认领自动释放
+		if err := tx.Where("resource_id = ? AND expires_at <= ?", resourceID, now).
+			Delete(&model.ReviewClaim{}).Error; err != nil {
+			return fmt.Errorf("释放过期认领失败: %w", err)
+		}
+
+		// 依靠唯一索引保证同一资源只有一个认领
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(claim)
+		if result.Error != nil {
+			return fmt.Errorf("认领资源失败: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		var current model.ReviewClaim
+		if err := tx.Where("resource_id = ?", resourceID).First(&current).Error; err != nil {
+			return fmt.Errorf("查询认领记录失败: %w", err)
+		}
+		if current.ReviewerID != reviewerID {
+			return fmt.Errorf("%w，租约到期时间 %s", ErrResourceClaimed, current.ExpiresAt.Format("2006-01-02 15:04"))
+		}
+
+		// 续期
+		if err := tx.Model(&current).Update("expires_at", claim.ExpiresAt).Error; err != nil {
+			return fmt.Errorf("续期认领失败: %w", err)
+		}
+		current.ExpiresAt = claim.ExpiresAt
+		*claim = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// ClaimNextResource 认领队列中优先级最高且未被认领的资源
+// 参数：
+//   - reviewerID: 审核员ID
+//   - categoryID: 分类筛选（可选）
+//
+// 返回：
+//   - 已认领的队列条目
+//   - 错误信息
+func (s *ReviewService) ClaimNextResource(reviewerID uint, categoryID *uint) (*ReviewQueueItem, error) {
+	if _, err := s.getReviewer(reviewerID); err != nil {
+		return nil, err
+	}
+
+	items, _, err := s.GetReviewQueue(ReviewQueueOptions{
+		ReviewerID: reviewerID,
+		Page:       1,
+		PageSize:   20,
+		CategoryID: categoryID,
+		Available:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.Claim != nil {
+			continue
+		}
+		claim, err := s.ClaimResource(item.Resource.ID, reviewerID)
+		if err != nil {
+			// 并发情况下可能已被他人认领或审核，继续尝试下一个
+			if errors.Is(err, ErrResourceClaimed) || errors.Is(err, ErrResourceNotPending) {
+				continue
+			}
+			return nil, err
+		}
+		item.Claim = claim
+		item.ClaimedByMe = true
+		return item, nil
+	}
+
+	return nil, ErrReviewQueueEmpty
+}
+
+// ReleaseClaim 释放认领（管理员可释放他人的认领）
+// 参数：
+//   - resourceID: 资源ID
+//   - reviewerID: 审核员ID
+//
+// 返回：
+//   - 错误信息
+func (s *ReviewService) ReleaseClaim(resourceID, reviewerID uint) error {
+	reviewer, err := s.getReviewer(reviewerID)
+	if err != nil {
+		return err
+	}
+
+	query := s.db.Where("resource_id = ?", resourceID)
+	if reviewer.Role != "admin" {
+		query = query.Where("reviewer_id = ?", reviewerID)
+	}
+
+	result := query.Delete(&model.ReviewClaim{})
+	if result.Error != nil {
+		return fmt.Errorf("释放认领失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrClaimNotFound
+	}
+
+	return nil
+}
+
+// ReleaseExpiredClaims 释放所有超时的认领
+// 返回：
+//   - 释放的数量
+//   - 错误信息
+func (s *ReviewService) ReleaseExpiredClaims() (int64, error) {
+	result := s.db.Where("expires_at <= ?", time.Now()).Delete(&model.ReviewClaim{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("释放超时认领失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ==================== 拒绝理由模板 ====================
+
+// ListRejectTemplates 获取拒绝理由模板列表
+func (s *ReviewService) ListRejectTemplates(includeDisabled bool) ([]model.RejectReasonTemplate, error) {
+	query := s.db.Model(&model.RejectReasonTemplate{})
+	if !includeDisabled {
+		query = query.Where("is_enabled = ?", true)
+	}
+
+	var templates []model.RejectReasonTemplate
+	if err := query.Order("sort_order ASC, id ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("查询拒绝理由模板失败: %w", err)
+	}
+	return templates, nil
+}
+
+// CreateRejectTemplate 创建拒绝理由模板
+func (s *ReviewService) CreateRejectTemplate(tpl *model.RejectReasonTemplate) error {
+	if err := validateRejectTemplate(tpl); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.RejectReasonTemplate{}).Where("code = ?", tpl.Code).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询拒绝理由模板失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: 模板代码 %s 已存在", ErrInvalidRejectTemplate, tpl.Code)
+	}
+
+	if err := s.db.Create(tpl).Error; err != nil {
+		return fmt.Errorf("创建拒绝理由模板失败: %w", err)
+	}
+	return nil
+}
+
+// UpdateRejectTemplate 更新拒绝理由模板
+func (s *ReviewService) UpdateRejectTemplate(id uint, updates *model.RejectReasonTemplate) (*model.RejectReasonTemplate, error) {
+	var tpl model.RejectReasonTemplate
+	if err := s.db.First(&tpl, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRejectTemplateNotFound
+		}
+		return nil, fmt.Errorf("查询拒绝理由模板失败: %w", err)
+	}
+
+	if err := validateRejectTemplate(updates); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.RejectReasonTemplate{}).
+		Where("code = ? AND id <> ?", updates.Code, id).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询拒绝理由模板失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: 模板代码 %s 已存在", ErrInvalidRejectTemplate, updates.Code)
+	}
+
+	tpl.Code = updates.Code
+	tpl.Title = updates.Title
+	tpl.Content = updates.Content
+	tpl.SortOrder = updates.SortOrder
+	tpl.IsEnabled = updates.IsEnabled
+	if err := s.db.Save(&tpl).Error; err != nil {
+		return nil, fmt.Errorf("更新拒绝理由模板失败: %w", err)
+	}
+	return &tpl, nil
+}
+
+// DeleteRejectTemplate 删除拒绝理由模板
+func (s *ReviewService) DeleteRejectTemplate(id uint) error {
+	result := s.db.Delete(&model.RejectReasonTemplate{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除拒绝理由模板失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRejectTemplateNotFound
+	}
+	return nil
+}
+
+// RejectWithTemplate 使用拒绝理由模板拒绝资源
+// 参数：
+//   - resourceID: 资源ID
+//   - reviewerID: 审核员ID
+//   - code: 模板代码
+//   - notes: 补充说明（可选，附加在模板内容之后）
+//
+// 返回：
+//   - 审核日志
+//   - 错误信息
+func (s *ReviewService) RejectWithTemplate(resourceID, reviewerID uint, code, notes string) (*ReviewLog, error) {
+	var tpl model.RejectReasonTemplate
+	if err := s.db.Where("code = ? AND is_enabled = ?", code, true).First(&tpl).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRejectTemplateNotFound
+		}
+		return nil, fmt.Errorf("查询拒绝理由模板失败: %w", err)
+	}
+
+	reason := tpl.Content
+	if notes = strings.TrimSpace(notes); notes != "" {
+		reason = fmt.Sprintf("%s（%s）", reason, notes)
+	}
+	if runes := []rune(reason); len(runes) > 500 {
+		reason = string(runes[:500])
+	}
+
+	return s.ReviewResource(resourceID, reviewerID, ReviewActionReject, reason)
+}
+
+// validateRejectTemplate 校验拒绝理由模板
+func validateRejectTemplate(tpl *model.RejectReasonTemplate) error {
+	tpl.Code = strings.TrimSpace(tpl.Code)
+	if tpl.Code == "" || strings.TrimSpace(tpl.Title) == "" || strings.TrimSpace(tpl.Content) == "" {
+		return fmt.Errorf("%w: 代码、标题和内容不能为空", ErrInvalidRejectTemplate)
+	}
+	return nil
+}
+
+// ==================== 内部方法 ====================
+
+// getReviewer 获取审核员（管理员或版主）
+func (s *ReviewService) getReviewer(reviewerID uint) (*model.User, error) {
+	var reviewer model.User
+	if err := s.db.First(&reviewer, reviewerID).Error; err != nil {
+		return nil, fmt.Errorf("查询审核者失败: %w", err)
+	}
+
+	if reviewer.Role != "admin" && reviewer.Role != "moderator" {
+		return nil, ErrReviewPermissionDenied
+	}
+	return &reviewer, nil
+}
+
+// checkClaim 检查资源是否被其他审核员认领（租约未到期）
+func (s *ReviewService) checkClaim(resourceID, reviewerID uint) error {
+	var claim model.ReviewClaim
+	err := s.db.Where("resource_id = ? AND expires_at > ?", resourceID, time.Now()).First(&claim).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询认领记录失败: %w", err)
+	}
+
+	if claim.ReviewerID != reviewerID {
+		return fmt.Errorf("%w，租约到期时间 %s", ErrResourceClaimed, claim.ExpiresAt.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// claimedByOthers 获取被其他审核员认领的资源
+func (s *ReviewService) claimedByOthers(resourceIDs []uint, reviewerID uint) (map[uint]bool, error) {
+	var claims []model.ReviewClaim
+	if err := s.db.Where("resource_id IN ? AND reviewer_id <> ? AND expires_at > ?", resourceIDs, reviewerID, time.Now()).
+		Find(&claims).Error; err != nil {
+		return nil, fmt.Errorf("查询认领记录失败: %w", err)
+	}
+
+	claimed := make(map[uint]bool, len(claims))
+	for _, claim := range claims {
+		claimed[claim.ResourceID] = true
+	}
+	return claimed, nil
+}
+
+// activeClaims 获取所有未过期的认领
+func (s *ReviewService) activeClaims() (map[uint]*model.ReviewClaim, error) {
+	var claims []*model.ReviewClaim
+	if err := s.db.Preload("Reviewer").Where("expires_at > ?", time.Now()).Find(&claims).Error; err != nil {
+		return nil, fmt.Errorf("查询认领记录失败: %w", err)
+	}
+
+	result := make(map[uint]*model.ReviewClaim, len(claims))
+	for _, claim := range claims {
+		result[claim.ResourceID] = claim
+	}
+	return result, nil
+}
+
+// loadPendingEntries 加载待审核资源及其进入待审核状态的时间（撤回审核后重新计时）
+func (s *ReviewService) loadPendingEntries(newQuery func() *gorm.DB) ([]*queueEntry, error) {
+	var rows []struct {
+		ID           uint
+		UploadedByID uint
+		CreatedAt    time.Time
+	}
+	if err := newQuery().Select("id", "uploaded_by_id", "created_at").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询待审核资源失败: %w", err)
+	}
+
+	var reverts []struct {
+		ResourceID uint
+		CreatedAt  time.Time
+	}
+	if err := s.db.Model(&ReviewLog{}).
+		Select("resource_id", "created_at").
+		Where("action = ? AND resource_id IN (?)", ReviewActionRevert, newQuery().Select("id")).
+		Scan(&reverts).Error; err != nil {
+		return nil, fmt.Errorf("查询撤回记录失败: %w", err)
+	}
+	revertedAt := make(map[uint]time.Time, len(reverts))
+	for _, revert := range reverts {
+		if revert.CreatedAt.After(revertedAt[revert.ResourceID]) {
+			revertedAt[revert.ResourceID] = revert.CreatedAt
+		}
+	}
+
+	entries := make([]*queueEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := &queueEntry{
+			id:           row.ID,
+			uploaderID:   row.UploadedByID,
+			pendingSince: row.CreatedAt,
+		}
+		if at, ok := revertedAt[row.ID]; ok && at.After(entry.pendingSince) {
+			entry.pendingSince = at
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// applyPriorities 根据上传者计算队列优先级（VIP > 可信上传者 > 普通）
+func (s *ReviewService) applyPriorities(entries []*queueEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	uploaderIDs := make([]uint, 0, len(entries))
+	seen := make(map[uint]bool)
+	for _, entry := range entries {
+		if !seen[entry.uploaderID] {
+			seen[entry.uploaderID] = true
+			uploaderIDs = append(uploaderIDs, entry.uploaderID)
+		}
+	}
+
+	vip, err := s.vipUploaders(uploaderIDs)
+	if err != nil {
+		return err
+	}
+	trusted, err := s.trustedUploaders(uploaderIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch {
+		case vip[entry.uploaderID]:
+			entry.priority, entry.reason = ReviewPriorityVIP, "vip"
+		case trusted[entry.uploaderID]:
+			entry.priority, entry.reason = ReviewPriorityTrusted, "trusted"
+		default:
+			entry.priority = ReviewPriorityNormal
+		}
+	}
+	return nil
+}
+
+// vipUploaders 获取VIP有效期内的上传者（已支付的VIP商品订单）
+func (s *ReviewService) vipUploaders(uploaderIDs []uint) (map[uint]bool, error) {
+	var orders []struct {
+		UserID    uint
+		CreatedAt time.Time
+		Quantity  int
+		ValidDays *int
+	}
+	if err := s.db.Table("mall_orders").
+		Select("mall_orders.user_id, mall_orders.created_at, mall_orders.quantity, products.valid_days").
+		Joins("JOIN products ON products.id = mall_orders.product_id").
+		Where("products.category = ? AND mall_orders.user_id IN ?", model.ProductCategoryVip, uploaderIDs).
+		Where("mall_orders.status IN ?", []model.OrderStatus{
+			model.OrderStatusPaid, model.OrderStatusShipped, model.OrderStatusCompleted,
+		}).
+		Scan(&orders).Error; err != nil {
+		return nil, fmt.Errorf("查询VIP订单失败: %w", err)
+	}
+
+	now := time.Now()
+	vip := make(map[uint]bool)
+	for _, order := range orders {
+		// 未设置有效天数的VIP视为永久有效
+		if order.ValidDays == nil || order.CreatedAt.AddDate(0, 0, *order.ValidDays*order.Quantity).After(now) {
+			vip[order.UserID] = true
+		}
+	}
+	return vip, nil
+}
+
+// trustedUploaders 获取可信上传者（版主、管理员，或人工审核通过率达到阈值）
+func (s *ReviewService) trustedUploaders(uploaderIDs []uint) (map[uint]bool, error) {
+	trusted := make(map[uint]bool)
+
+	var staff []uint
+	if err := s.db.Model(&model.User{}).
+		Where("id IN ? AND role IN ?", uploaderIDs, []string{"admin", "moderator"}).
+		Pluck("id", &staff).Error; err != nil {
+		return nil, fmt.Errorf("查询上传者角色失败: %w", err)
+	}
+	for _, id := range staff {
+		trusted[id] = true
+	}
+
+	var rows []struct {
+		UploadedByID uint
+		Action       ReviewAction
+		Count        int64
+	}
+	if err := s.db.Table("review_logs").
+		Select("resources.uploaded_by_id, review_logs.action, COUNT(*) AS count").
+		Joins("JOIN resources ON resources.id = review_logs.resource_id").
+		Joins("JOIN users ON users.id = review_logs.reviewer_id").
+		Where("resources.uploaded_by_id IN ?", uploaderIDs).
+		Where("review_logs.action IN ? AND users.role <> ?",
+			[]ReviewAction{ReviewActionApprove, ReviewActionReject}, systemReviewerRole).
+		Group("resources.uploaded_by_id, review_logs.action").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计上传者审核记录失败: %w", err)
+	}
+
+	counts := make(map[uint]*[2]int64)
+	for _, row := range rows {
+		if counts[row.UploadedByID] == nil {
+			counts[row.UploadedByID] = &[2]int64{}
+		}
+		if row.Action == ReviewActionApprove {
+			counts[row.UploadedByID][0] = row.Count
+		} else {
+			counts[row.UploadedByID][1] = row.Count
+		}
+	}
+
+	for uploaderID, count := range counts {
+		reviews := count[0] + count[1]
+		if reviews == 0 || reviews < int64(s.cfg.TrustedMinReviews) {
+			continue
+		}
+		if float64(count[0])/float64(reviews) >= s.cfg.TrustedMinRatio {
+			trusted[uploaderID] = true
+		}
+	}
+	return trusted, nil
+}
+
+// getQueueSummary 获取审核队列状态（待审核、已认领、超时数量）
+func (s *ReviewService) getQueueSummary() (map[string]interface{}, error) {
+	entries, err := s.loadPendingEntries(func() *gorm.DB {
+		return s.db.Model(&model.Resource{}).
+			Where("status = ? AND deleted_at IS NULL", model.ResourceStatusPending)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.activeClaims()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sla := s.cfg.GetSLA()
+	var claimed, overdue, oldestMinutes int64
+	for _, entry := range entries {
+		if claims[entry.id] != nil {
+			claimed++
+		}
+		if now.Sub(entry.pendingSince) > sla {
+			overdue++
+		}
+		if waiting := int64(now.Sub(entry.pendingSince).Minutes()); waiting > oldestMinutes {
+			oldestMinutes = waiting
+		}
+	}
+
+	return map[string]interface{}{
+		"pending":                len(entries),
+		"claimed":                claimed,
+		"overdue":                overdue,
+		"oldest_waiting_minutes": oldestMinutes,
+		"sla_hours":              s.cfg.SLAHours,
+	}, nil
+}
+
+// getReviewerReport 统计审核员绩效
+// 决定耗时从资源进入待审核状态（创建或被撤回）算起；撤回计入被撤回决定的审核员。
+// 系统自动审核的决定不计入统计。
+func (s *ReviewService) getReviewerReport(startDate, endDate *time.Time) (*reviewerReport, error) {
+	inRange := func(t time.Time) bool {
+		return (startDate == nil || !t.Before(*startDate)) && (endDate == nil || !t.After(*endDate))
+	}
+
+	// 统计范围内有审核决定的资源
+	decided := s.db.Model(&ReviewLog{}).Select("resource_id").
+		Where("action IN ?", []ReviewAction{ReviewActionApprove, ReviewActionReject})
+	if startDate != nil {
+		decided = decided.Where("created_at >= ?", *startDate)
+	}
+	if endDate != nil {
+		decided = decided.Where("created_at <= ?", *endDate)
+	}
+
+	// 加载这些资源的完整审核历史
+	var logs []ReviewLog
+	if err := s.db.Select("id", "resource_id", "reviewer_id", "action", "created_at").
+		Where("resource_id IN (?)", decided).
+		Where("action IN ?", []ReviewAction{ReviewActionApprove, ReviewActionReject, ReviewActionRevert}).
+		Order("resource_id ASC, created_at ASC, id ASC").
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询审核日志失败: %w", err)
+	}
+
+	report := &reviewerReport{Reviewers: []*ReviewerStats{}}
+	if len(logs) == 0 {
+		return report, nil
+	}
+
+	resourceIDs := make([]uint, 0)
+	for i, log := range logs {
+		if i == 0 || logs[i-1].ResourceID != log.ResourceID {
+			resourceIDs = append(resourceIDs, log.ResourceID)
+		}
+	}
+
+	var resources []struct {
+		ID        uint
+		CreatedAt time.Time
+	}
+	if err := s.db.Unscoped().Model(&model.Resource{}).
+		Select("id", "created_at").
+		Where("id IN ?", resourceIDs).
+		Scan(&resources).Error; err != nil {
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+	createdAt := make(map[uint]time.Time, len(resources))
+	for _, resource := range resources {
+		createdAt[resource.ID] = resource.CreatedAt
+	}
+
+	var systemID uint
+	if err := s.db.Model(&model.User{}).
+		Where("role = ?", systemReviewerRole).
+		Order("id").
+		Limit(1).
+		Pluck("id", &systemID).Error; err != nil {
+		return nil, fmt.Errorf("查询系统审核者失败: %w", err)
+	}
+
+	statsMap := make(map[uint]*ReviewerStats)
+	var firstDecision time.Time
+	var lastResourceID uint
+	var pendingSince time.Time
+	var lastDecision *ReviewerStats // 最近一次（统计范围内）人工决定的审核员，用于归属撤回
+
+	for _, log := range logs {
+		if log.ResourceID != lastResourceID {
+			lastResourceID = log.ResourceID
+			pendingSince = createdAt[log.ResourceID]
+			lastDecision = nil
+		}
+
+		switch log.Action {
+		case ReviewActionApprove, ReviewActionReject:
+			lastDecision = nil
+			if log.ReviewerID != systemID && inRange(log.CreatedAt) {
+				stats := statsMap[log.ReviewerID]
+				if stats == nil {
+					stats = &ReviewerStats{ReviewerID: log.ReviewerID}
+					statsMap[log.ReviewerID] = stats
+				}
+				stats.Decisions++
+				if log.Action == ReviewActionApprove {
+					stats.Approved++
+				} else {
+					stats.Rejected++
+				}
+				if !pendingSince.IsZero() && !log.CreatedAt.Before(pendingSince) {
+					stats.durations = append(stats.durations, log.CreatedAt.Sub(pendingSince).Minutes())
+				}
+				if firstDecision.IsZero() || log.CreatedAt.Before(firstDecision) {
+					firstDecision = log.CreatedAt
+				}
+				lastDecision = stats
+			}
+			pendingSince = log.CreatedAt
+
+		case ReviewActionRevert:
+			if lastDecision != nil {
+				lastDecision.Reversed++
+			}
+			lastDecision = nil
+			pendingSince = log.CreatedAt
+		}
+	}
+
+	if len(statsMap) == 0 {
+		return report, nil
+	}
+
+	// 统计周期（天），用于计算吞吐量
+	from, to := firstDecision, time.Now()
+	if startDate != nil {
+		from = *startDate
+	}
+	if endDate != nil && endDate.Before(to) {
+		to = *endDate
+	}
+	days := to.Sub(from).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+
+	reviewerIDs := make([]uint, 0, len(statsMap))
+	for id := range statsMap {
+		reviewerIDs = append(reviewerIDs, id)
+	}
+	var users []model.User
+	if err := s.db.Select("id", "username").Where("id IN ?", reviewerIDs).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("查询审核员失败: %w", err)
+	}
+	for _, user := range users {
+		statsMap[user.ID].Username = user.Username
+	}
+
+	var allDurations []float64
+	var totalDecisions, totalReversed int
+	for _, stats := range statsMap {
+		stats.ReversalRate = roundTo2(percent(stats.Reversed, stats.Decisions))
+		stats.ThroughputPerDay = roundTo2(float64(stats.Decisions) / days)
+		stats.MedianDecisionMinutes = roundTo2(median(stats.durations))
+		allDurations = append(allDurations, stats.durations...)
+		totalDecisions += stats.Decisions
+		totalReversed += stats.Reversed
+		report.Reviewers = append(report.Reviewers, stats)
+	}
+
+	sort.Slice(report.Reviewers, func(i, j int) bool {
+		if report.Reviewers[i].Decisions != report.Reviewers[j].Decisions {
+			return report.Reviewers[i].Decisions > report.Reviewers[j].Decisions
+		}
+		return report.Reviewers[i].ReviewerID < report.Reviewers[j].ReviewerID
+	})
+
+	if len(allDurations) > 0 {
+		var sum float64
+		for _, d := range allDurations {
+			sum += d
+		}
+		report.AvgDecisionMinutes = roundTo2(sum / float64(len(allDurations)))
+	}
+	report.MedianDecisionMinutes = roundTo2(median(allDurations))
+	report.ReversalRate = roundTo2(percent(totalReversed, totalDecisions))
+
+	return report, nil
+}
+
+// median 计算中位数
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// percent 计算百分比
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// roundTo2 保留两位小数
+func roundTo2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
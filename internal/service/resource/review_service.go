@@ -10,13 +10,28 @@ package resource
 import (
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
+	"resource-share-site/internal/config"
 	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/seo"
 
 	"gorm.io/gorm"
 )
 
+// Errors 定义自定义错误
+var (
+	ErrReviewPermissionDenied = errors.New("没有审核权限")
+	ErrResourceNotPending     = errors.New("资源不在待审核状态")
+	ErrResourceClaimed        = errors.New("资源已被其他审核员认领")
+	ErrClaimNotFound          = errors.New("未认领该资源")
+	ErrReviewQueueEmpty       = errors.New("暂无可认领的待审核资源")
+	ErrRejectTemplateNotFound = errors.New("拒绝理由模板不存在")
+	ErrInvalidRejectTemplate  = errors.New("拒绝理由模板无效")
+)
+
 // ReviewStatus 审核状态
 type ReviewStatus string
 
@@ -27,48 +42,51 @@ const (
 )
 
 // ReviewAction 审核动作
-type ReviewAction string
+type ReviewAction = model.ReviewAction
 
 const (
-	ReviewActionApprove ReviewAction = "approve" // 通过
-	ReviewActionReject  ReviewAction = "reject"  // 拒绝
-	ReviewActionRevert  ReviewAction = "revert"  // 撤回
+	ReviewActionApprove = model.ReviewActionApprove // 通过
+	ReviewActionReject  = model.ReviewActionReject  // 拒绝
+	ReviewActionRevert  = model.ReviewActionRevert  // 撤回
+	ReviewActionHold    = model.ReviewActionHold    // 转人工审核（自动审核）
 )
 
 // ReviewLog 审核日志模型
-type ReviewLog struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-
-	ResourceID uint            `gorm:"not null;index" json:"resource_id"`
-	Resource   *model.Resource `gorm:"foreignKey:ResourceID" json:"resource"`
-
-	ReviewerID uint        `gorm:"not null;index" json:"reviewer_id"`
-	Reviewer   *model.User `gorm:"foreignKey:ReviewerID" json:"reviewer"`
-
-	Action     ReviewAction         `gorm:"not null;size:20" json:"action"`
-	Notes      string               `gorm:"size:500" json:"notes"`
-	FromStatus model.ResourceStatus `gorm:"not null;size:20" json:"from_status"`
-	ToStatus   model.ResourceStatus `gorm:"not null;size:20" json:"to_status"`
-}
-
-// TableName 指定表名
-func (ReviewLog) TableName() string {
-	return "review_logs"
-}
+type ReviewLog = model.ReviewLog
 
 // ReviewService 审核服务
 type ReviewService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	notifier notification.Publisher
+	indexer  seo.URLPublisher
+	cfg      *config.ReviewConfig
 }
 
 // NewReviewService 创建新的审核服务
 func NewReviewService(db *gorm.DB) *ReviewService {
 	return &ReviewService{
-		db: db,
+		db:  db,
+		cfg: config.DefaultReviewConfig(),
+	}
+}
+
+// SetQueueConfig 设置审核队列配置（认领租约时长、审核时效和优先级阈值）
+func (s *ReviewService) SetQueueConfig(cfg *config.ReviewConfig) {
+	if cfg != nil {
+		s.cfg = cfg
 	}
 }
 
+// SetNotifier 设置通知发布器（审核结果将通知上传者）
+func (s *ReviewService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// SetIndexer 设置URL推送器（审核通过或撤销通过时通知搜索引擎）
+func (s *ReviewService) SetIndexer(indexer seo.URLPublisher) {
+	s.indexer = indexer
+}
+
 // ReviewResource 审核资源
 // 参数：
 //   - resourceID: 资源ID
@@ -89,15 +107,9 @@ func (s *ReviewService) ReviewResource(resourceID, reviewerID uint, action Revie
 		return nil, fmt.Errorf("查询资源失败: %w", err)
 	}
 
-	// 检查审核者是否有权限
-	var reviewer model.User
-	if err := s.db.First(&reviewer, reviewerID).Error; err != nil {
-		return nil, fmt.Errorf("查询审核者失败: %w", err)
-	}
-
 	// 检查审核者权限（管理员或版主）
-	if reviewer.Role != "admin" && reviewer.Role != "moderator" {
-		return nil, errors.New("没有审核权限")
+	if _, err := s.getReviewer(reviewerID); err != nil {
+		return nil, err
 	}
 
 	// 记录原状态
@@ -125,6 +137,13 @@ func (s *ReviewService) ReviewResource(resourceID, reviewerID uint, action Revie
 		return nil, errors.New("资源已经审核拒绝")
 	}
 
+	// 已被其他审核员认领且租约未到期的资源不能审核
+	if action != ReviewActionRevert {
+		if err := s.checkClaim(resourceID, reviewerID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 开始事务
 	tx := s.db.Begin()
 	if tx.Error != nil {
@@ -165,11 +184,28 @@ func (s *ReviewService) ReviewResource(resourceID, reviewerID uint, action Revie
 		return nil, fmt.Errorf("创建审核日志失败: %w", err)
 	}
 
+	// 审核完成后释放认领
+	if err := tx.Where("resource_id = ?", resourceID).Delete(&model.ReviewClaim{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("释放认领失败: %w", err)
+	}
+
 	// 提交事务
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("提交事务失败: %w", err)
 	}
 
+	// 通知上传者审核结果
+	s.notifyReviewResult(&resource, action, notes)
+
+	// 资源上线或下线时推送给搜索引擎
+	switch {
+	case newStatus == model.ResourceStatusApproved:
+		publishResourceURL(s.indexer, seo.IndexActionUpdate, &resource)
+	case oldStatus == model.ResourceStatusApproved:
+		publishResourceURL(s.indexer, seo.IndexActionDelete, &resource)
+	}
+
 	return reviewLog, nil
 }
 
@@ -190,13 +226,14 @@ func (s *ReviewService) BatchReviewResources(resourceIDs []uint, reviewerID uint
 	}
 
 	// 检查审核者权限
-	var reviewer model.User
-	if err := s.db.First(&reviewer, reviewerID).Error; err != nil {
-		return 0, nil, fmt.Errorf("查询审核者失败: %w", err)
+	if _, err := s.getReviewer(reviewerID); err != nil {
+		return 0, nil, err
 	}
 
-	if reviewer.Role != "admin" && reviewer.Role != "moderator" {
-		return 0, nil, errors.New("没有审核权限")
+	// 跳过被其他审核员认领的资源
+	claimedByOthers, err := s.claimedByOthers(resourceIDs, reviewerID)
+	if err != nil {
+		return 0, nil, err
 	}
 
 	// 确定新状态
@@ -224,9 +261,16 @@ func (s *ReviewService) BatchReviewResources(resourceIDs []uint, reviewerID uint
 
 	successCount := 0
 	failedIDs := []uint{}
+	reviewed := []model.Resource{}
+	unpublished := []model.Resource{}
 
 	// 逐个处理资源
 	for _, resourceID := range resourceIDs {
+		if claimedByOthers[resourceID] {
+			failedIDs = append(failedIDs, resourceID)
+			continue
+		}
+
 		var resource model.Resource
 		if err := tx.First(&resource, resourceID).Error; err != nil {
 			failedIDs = append(failedIDs, resourceID)
@@ -273,6 +317,15 @@ func (s *ReviewService) BatchReviewResources(resourceIDs []uint, reviewerID uint
 			continue
 		}
 
+		if err := tx.Where("resource_id = ?", resourceID).Delete(&model.ReviewClaim{}).Error; err != nil {
+			failedIDs = append(failedIDs, resourceID)
+			continue
+		}
+
+		reviewed = append(reviewed, resource)
+		if oldStatus == model.ResourceStatusApproved {
+			unpublished = append(unpublished, resource)
+		}
 		successCount++
 	}
 
@@ -281,6 +334,19 @@ func (s *ReviewService) BatchReviewResources(resourceIDs []uint, reviewerID uint
 		return 0, nil, fmt.Errorf("提交事务失败: %w", err)
 	}
 
+	// 通知上传者审核结果
+	for i := range reviewed {
+		s.notifyReviewResult(&reviewed[i], action, notes)
+		if newStatus == model.ResourceStatusApproved {
+			publishResourceURL(s.indexer, seo.IndexActionUpdate, &reviewed[i])
+		}
+	}
+
+	// 已上线的资源被拒绝时通知搜索引擎下线
+	for i := range unpublished {
+		publishResourceURL(s.indexer, seo.IndexActionDelete, &unpublished[i])
+	}
+
 	return successCount, failedIDs, nil
 }
 
@@ -436,44 +502,47 @@ func (s *ReviewService) GetReviewLogs(resourceID, reviewerID *uint, page, pageSi
 //   - endDate: 统计结束时间（可选）
 //
 // 返回：
-//   - 统计信息
+//   - 统计信息（包含审核员绩效和队列状态，耗时单位为分钟）
 //   - 错误信息
 func (s *ReviewService) GetReviewStatistics(startDate, endDate *time.Time) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
-	query := s.db.Model(&model.Resource{}).Where("deleted_at IS NULL")
-
-	if startDate != nil {
-		query = query.Where("created_at >= ?", *startDate)
-	}
-	if endDate != nil {
-		query = query.Where("created_at <= ?", *endDate)
+	// 每次统计使用新的查询，避免条件相互叠加
+	newQuery := func() *gorm.DB {
+		query := s.db.Model(&model.Resource{}).Where("deleted_at IS NULL")
+		if startDate != nil {
+			query = query.Where("created_at >= ?", *startDate)
+		}
+		if endDate != nil {
+			query = query.Where("created_at <= ?", *endDate)
+		}
+		return query
 	}
 
 	// 总数
 	var total int64
-	if err := query.Count(&total).Error; err != nil {
+	if err := newQuery().Count(&total).Error; err != nil {
 		return nil, fmt.Errorf("查询总数失败: %w", err)
 	}
 	stats["total"] = total
 
 	// 待审核数
 	var pending int64
-	if err := query.Where("status = ?", model.ResourceStatusPending).Count(&pending).Error; err != nil {
+	if err := newQuery().Where("status = ?", model.ResourceStatusPending).Count(&pending).Error; err != nil {
 		return nil, fmt.Errorf("查询待审核数失败: %w", err)
 	}
 	stats["pending"] = pending
 
 	// 已通过数
 	var approved int64
-	if err := query.Where("status = ?", model.ResourceStatusApproved).Count(&approved).Error; err != nil {
+	if err := newQuery().Where("status = ?", model.ResourceStatusApproved).Count(&approved).Error; err != nil {
 		return nil, fmt.Errorf("查询已通过数失败: %w", err)
 	}
 	stats["approved"] = approved
 
 	// 已拒绝数
 	var rejected int64
-	if err := query.Where("status = ?", model.ResourceStatusRejected).Count(&rejected).Error; err != nil {
+	if err := newQuery().Where("status = ?", model.ResourceStatusRejected).Count(&rejected).Error; err != nil {
 		return nil, fmt.Errorf("查询已拒绝数失败: %w", err)
 	}
 	stats["rejected"] = rejected
@@ -485,18 +554,27 @@ func (s *ReviewService) GetReviewStatistics(startDate, endDate *time.Time) (map[
 	}
 	stats["approval_rate"] = approvalRate
 
-	// 平均审核时间（需要从审核日志计算）
-	var avgReviewTime float64
-	if startDate != nil && endDate != nil {
-		// 这里可以添加更复杂的平均审核时间计算逻辑
-		avgReviewTime = 0
+	// 审核员绩效（吞吐量、撤回率、决定耗时）
+	report, err := s.getReviewerReport(startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
-	stats["avg_review_time"] = avgReviewTime
+	stats["avg_review_time"] = report.AvgDecisionMinutes
+	stats["median_review_time"] = report.MedianDecisionMinutes
+	stats["reversal_rate"] = report.ReversalRate
+	stats["reviewers"] = report.Reviewers
+
+	// 审核队列状态
+	queue, err := s.getQueueSummary()
+	if err != nil {
+		return nil, err
+	}
+	stats["queue"] = queue
 
 	return stats, nil
 }
 
-// AutoApproveResource 自动审核资源（简单的规则审核）
+// AutoApproveResource 自动审核资源（使用自动审核规则引擎评估，不修改资源）
 // 参数：
 //   - resourceID: 资源ID
 //
@@ -515,33 +593,17 @@ func (s *ReviewService) AutoApproveResource(resourceID uint) (bool, error) {
 		return false, nil
 	}
 
-	// 简单的自动审核规则
-	// 1. 检查标题和描述是否完整
-	if resource.Title == "" || resource.Description == "" {
+	// 基本信息不完整的资源不自动通过
+	if resource.Title == "" || resource.Description == "" || resource.NetdiskURL == "" {
 		return false, nil
 	}
 
-	// 2. 检查是否有有效的网盘链接
-	if resource.NetdiskURL == "" {
-		return false, nil
+	result, err := NewModerationService(s.db).Evaluate(&resource)
+	if err != nil {
+		return false, err
 	}
 
-	// 3. 检查是否属于系统允许的分类
-	// 这里可以添加更复杂的分类检查逻辑
-
-	// 4. 检查上传者信誉（这里简化处理）
-	var uploader model.User
-	if err := s.db.First(&uploader, resource.UploadedByID).Error; err != nil {
-		return false, fmt.Errorf("查询上传者失败: %w", err)
-	}
-
-	// 如果上传者是管理员或信誉良好，自动通过
-	if uploader.Role == "admin" || uploader.Role == "moderator" {
-		return true, nil
-	}
-
-	// 默认不自动通过
-	return false, nil
+	return result.Decision == model.ModerationDecisionApprove, nil
 }
 
 // RevertReview 撤回审核（将资源恢复到待审核状态）
@@ -556,3 +618,51 @@ func (s *ReviewService) AutoApproveResource(resourceID uint) (bool, error) {
 func (s *ReviewService) RevertReview(resourceID, reviewerID uint, notes string) (*ReviewLog, error) {
 	return s.ReviewResource(resourceID, reviewerID, ReviewActionRevert, notes)
 }
+
+// notifyReviewResult 通知上传者审核结果（通知失败不影响审核流程）
+func (s *ReviewService) notifyReviewResult(resource *model.Resource, action ReviewAction, notes string) {
+	publishReviewResult(s.notifier, resource, action, notes)
+}
+
+// publishReviewResult 发布审核结果通知（人工审核和自动审核共用）
+func publishReviewResult(notifier notification.Publisher, resource *model.Resource, action ReviewAction, notes string) {
+	if notifier == nil {
+		return
+	}
+
+	event := &notification.Event{
+		UserID:     resource.UploadedByID,
+		Link:       seo.ResourcePath(resource.ID, resource.Slug),
+		TargetType: "resource",
+		TargetID:   &resource.ID,
+	}
+
+	switch action {
+	case ReviewActionApprove:
+		event.Type = model.NotificationTypeResourceApproved
+		event.Title = "资源审核通过"
+		event.Content = fmt.Sprintf("您上传的资源《%s》已通过审核", resource.Title)
+	case ReviewActionReject:
+		event.Type = model.NotificationTypeResourceRejected
+		event.Title = "资源审核未通过"
+		event.Content = fmt.Sprintf("您上传的资源《%s》未通过审核", resource.Title)
+	default:
+		return
+	}
+
+	if notes != "" {
+		event.Content += fmt.Sprintf("，审核备注：%s", notes)
+	}
+
+	_ = notifier.Publish(event)
+}
+
+// publishResourceURL 将资源详情页加入搜索引擎推送队列（推送失败不影响审核流程）
+func publishResourceURL(indexer seo.URLPublisher, action string, resource *model.Resource) {
+	if indexer == nil {
+		return
+	}
+	if err := indexer.PublishURLs(action, seo.ResourcePath(resource.ID, resource.Slug)); err != nil {
+		log.Printf("资源 %d 加入URL推送队列失败: %v", resource.ID, err)
+	}
+}
@@ -0,0 +1,663 @@
+/*
+Package resource provides resource management services.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
+	"resource-share-site/internal/service/seo"
+	"resource-share-site/internal/service/tag"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义资源修订相关错误
+var (
+	ErrRevisionNotFound     = errors.New("修订版本不存在")
+	ErrRevisionUnchanged    = errors.New("资源内容没有变化")
+	ErrRevisionNotPending   = errors.New("修订不在待审核状态")
+	ErrRevisionOutdated     = errors.New("修订基于的版本已被更新，请重新提交修改")
+	ErrRevisionNotApplied   = errors.New("只能回滚到曾经生效的版本")
+	ErrEditPermissionDenied = errors.New("没有修改该资源的权限")
+)
+
+// FieldChange 修订之间的字段差异
+type FieldChange struct {
+	Field   string   `json:"field"`
+	Label   string   `json:"label"`
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Added   []string `json:"added,omitempty"`   // 新增的标签
+	Removed []string `json:"removed,omitempty"` // 移除的标签
+}
+
+// RevisionDetail 修订详情（包含相对修改时生效版本的字段差异）
+type RevisionDetail struct {
+	Revision *model.ResourceRevision `json:"revision"`
+	Changes  []FieldChange           `json:"changes"`
+}
+
+// ListRevisions 获取资源的修订历史（上传者、管理员和版主可查看）
+// 参数：
+//   - resourceID: 资源ID
+//   - viewerID: 查看者ID
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 修订列表（版本号降序）
+//   - 总数
+//   - 错误信息
+func (s *ResourceService) ListRevisions(resourceID, viewerID uint, page, pageSize int) ([]model.ResourceRevision, int64, error) {
+	resource, err := s.getEditableResource(resourceID, viewerID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := s.ensureBaseline(s.db, resource); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&model.ResourceRevision{}).Where("resource_id = ?", resourceID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计修订数量失败: %w", err)
+	}
+
+	var revisions []model.ResourceRevision
+	if err := query.Preload("Editor").Preload("ReviewedBy").
+		Order("version DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&revisions).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询修订历史失败: %w", err)
+	}
+
+	return revisions, total, nil
+}
+
+// GetRevision 获取修订详情及其相对修改时生效版本的字段差异
+// 参数：
+//   - resourceID: 资源ID
+//   - version: 版本号
+//   - viewerID: 查看者ID
+//
+// 返回：
+//   - 修订详情
+//   - 错误信息
+func (s *ResourceService) GetRevision(resourceID uint, version int, viewerID uint) (*RevisionDetail, error) {
+	if _, err := s.getEditableResource(resourceID, viewerID); err != nil {
+		return nil, err
+	}
+
+	revision, err := s.getRevision(resourceID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &RevisionDetail{Revision: revision, Changes: []FieldChange{}}
+	if revision.BaseVersion > 0 {
+		base, err := s.getRevision(resourceID, revision.BaseVersion)
+		if err != nil {
+			return nil, err
+		}
+		detail.Changes = s.diffRevisions(base, revision)
+	}
+
+	return detail, nil
+}
+
+// CompareRevisions 比较资源的两个版本
+// 参数：
+//   - resourceID: 资源ID
+//   - fromVersion: 旧版本号
+//   - toVersion: 新版本号
+//   - viewerID: 查看者ID
+//
+// 返回：
+//   - 字段差异列表
+//   - 错误信息
+func (s *ResourceService) CompareRevisions(resourceID uint, fromVersion, toVersion int, viewerID uint) ([]FieldChange, error) {
+	if _, err := s.getEditableResource(resourceID, viewerID); err != nil {
+		return nil, err
+	}
+
+	from, err := s.getRevision(resourceID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getRevision(resourceID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.diffRevisions(from, to), nil
+}
+
+// ListPendingRevisions 获取待审核的资源修订（审核员使用，先提交的在前）
+// 参数：
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 待审核修订列表
+//   - 总数
+//   - 错误信息
+func (s *ResourceService) ListPendingRevisions(page, pageSize int) ([]model.ResourceRevision, int64, error) {
+	query := s.db.Model(&model.ResourceRevision{}).Where("status = ?", model.RevisionStatusPending)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计待审核修订失败: %w", err)
+	}
+
+	var revisions []model.ResourceRevision
+	if err := query.Preload("Resource").Preload("Editor").
+		Order("created_at ASC, id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&revisions).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询待审核修订失败: %w", err)
+	}
+
+	return revisions, total, nil
+}
+
+// ApproveRevision 审核通过待审核修订并使其生效
+// 参数：
+//   - revisionID: 修订ID
+//   - reviewerID: 审核员ID（管理员或版主）
+//   - notes: 审核备注
+//
+// 返回：
+//   - 生效后的资源对象
+//   - 错误信息
+func (s *ResourceService) ApproveRevision(revisionID, reviewerID uint, notes string) (*model.Resource, error) {
+	if err := s.requireRole(reviewerID, "admin", "moderator"); err != nil {
+		return nil, err
+	}
+
+	revision, resource, err := s.getPendingRevision(revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 审核期间资源已有新版本生效时，整份快照会覆盖其他人的修改
+	current, err := s.currentVersion(s.db, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revision.BaseVersion != current {
+		return nil, ErrRevisionOutdated
+	}
+
+	now := time.Now()
+	revision.ReviewedByID = &reviewerID
+	revision.ReviewedAt = &now
+	revision.ReviewNotes = notes
+	if err := s.applyRevision(resource, revision); err != nil {
+		return nil, err
+	}
+
+	s.notifyRevisionResult(resource, revision, true)
+
+	return resource, nil
+}
+
+// RejectRevision 拒绝待审核修订（资源保持当前版本）
+// 参数：
+//   - revisionID: 修订ID
+//   - reviewerID: 审核员ID（管理员或版主）
+//   - notes: 拒绝原因
+//
+// 返回：
+//   - 错误信息
+func (s *ResourceService) RejectRevision(revisionID, reviewerID uint, notes string) error {
+	if err := s.requireRole(reviewerID, "admin", "moderator"); err != nil {
+		return err
+	}
+
+	revision, resource, err := s.getPendingRevision(revisionID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(revision).Updates(map[string]interface{}{
+		"status":         model.RevisionStatusRejected,
+		"reviewed_by_id": reviewerID,
+		"reviewed_at":    now,
+		"review_notes":   notes,
+	}).Error; err != nil {
+		return fmt.Errorf("更新修订状态失败: %w", err)
+	}
+	revision.ReviewNotes = notes
+
+	s.notifyRevisionResult(resource, revision, false)
+
+	return nil
+}
+
+// RollbackResource 将资源回滚到指定版本（管理员，回滚本身也会生成新的修订）
+// 参数：
+//   - resourceID: 资源ID
+//   - version: 要恢复的版本号
+//   - adminID: 管理员ID
+//   - reason: 回滚原因
+//
+// 返回：
+//   - 回滚后的资源对象
+//   - 新生成的修订
+//   - 错误信息
+func (s *ResourceService) RollbackResource(resourceID uint, version int, adminID uint, reason string) (*model.Resource, *model.ResourceRevision, error) {
+	if err := s.requireRole(adminID, "admin"); err != nil {
+		return nil, nil, err
+	}
+
+	resource, err := s.getResource(resourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := s.ensureBaseline(s.db, resource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	target, err := s.getRevision(resourceID, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target.Status != model.RevisionStatusApplied {
+		return nil, nil, ErrRevisionNotApplied
+	}
+
+	if reason == "" {
+		reason = fmt.Sprintf("回滚到版本 %d", version)
+	}
+	revision := &model.ResourceRevision{
+		ResourceID:  resourceID,
+		BaseVersion: current,
+		Title:       target.Title,
+		Description: target.Description,
+		CategoryID:  target.CategoryID,
+		NetdiskURL:  target.NetdiskURL,
+		PointsPrice: target.PointsPrice,
+		Tags:        target.Tags,
+		EditorID:    adminID,
+		Reason:      reason,
+		RollbackOf:  &version,
+	}
+	if sameContent(snapshot(resource), revision) {
+		return nil, nil, ErrRevisionUnchanged
+	}
+
+	if err := s.applyRevision(resource, revision); err != nil {
+		return nil, nil, err
+	}
+
+	return resource, revision, nil
+}
+
+// submitRevision 提交待审核修订（同一资源之前未审核的修订标记为已取代）
+func (s *ResourceService) submitRevision(revision *model.ResourceRevision) error {
+	revision.Status = model.RevisionStatusPending
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ResourceRevision{}).
+			Where("resource_id = ? AND status = ?", revision.ResourceID, model.RevisionStatusPending).
+			Update("status", model.RevisionStatusSuperseded).Error; err != nil {
+			return fmt.Errorf("更新修订状态失败: %w", err)
+		}
+
+		version, err := s.nextVersion(tx, revision.ResourceID)
+		if err != nil {
+			return err
+		}
+		revision.Version = version
+
+		if err := tx.Create(revision).Error; err != nil {
+			return fmt.Errorf("保存修订失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// applyRevision 将修订内容写入资源并记录为已生效版本（新修订会分配版本号）
+func (s *ResourceService) applyRevision(resource *model.Resource, revision *model.ResourceRevision) error {
+	now := time.Now()
+	slug := seo.Slugify(revision.Title, seo.ResourceSlugMaxLength)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Resource{}).Where("id = ?", resource.ID).Updates(map[string]interface{}{
+			"title":        revision.Title,
+			"slug":         slug,
+			"description":  revision.Description,
+			"category_id":  revision.CategoryID,
+			"netdisk_url":  revision.NetdiskURL,
+			"points_price": revision.PointsPrice,
+			"updated_at":   now,
+		}).Error; err != nil {
+			return fmt.Errorf("更新资源失败: %w", err)
+		}
+
+		// 标题变化后地址随之变化（旧地址由详情页按ID跳转）
+		if err := seo.RecordPathChange(tx, seo.PathChange{
+			TargetType: model.SEOConfigTypeResource,
+			TargetID:   resource.ID,
+			From:       seo.ResourcePath(resource.ID, resource.Slug),
+			To:         seo.ResourcePath(resource.ID, slug),
+		}); err != nil {
+			return err
+		}
+
+		// 快照中保存合并同义词后的标签
+		_, formatted, err := tag.NewTagService(tx).SetResourceTags(resource.ID, tag.ParseTags(revision.Tags))
+		if err != nil {
+			return fmt.Errorf("更新资源标签失败: %w", err)
+		}
+		revision.Tags = formatted
+		revision.Status = model.RevisionStatusApplied
+
+		if revision.ID != 0 {
+			if err := tx.Save(revision).Error; err != nil {
+				return fmt.Errorf("保存修订失败: %w", err)
+			}
+			return nil
+		}
+
+		version, err := s.nextVersion(tx, resource.ID)
+		if err != nil {
+			return err
+		}
+		revision.Version = version
+		if err := tx.Create(revision).Error; err != nil {
+			return fmt.Errorf("保存修订失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	resource.Title = revision.Title
+	resource.Slug = slug
+	resource.Description = revision.Description
+	resource.CategoryID = revision.CategoryID
+	resource.NetdiskURL = revision.NetdiskURL
+	resource.PointsPrice = revision.PointsPrice
+	resource.Tags = revision.Tags
+	resource.UpdatedAt = now
+	return nil
+}
+
+// recordRevision 将资源当前内容记录为新的已生效版本
+func (s *ResourceService) recordRevision(db *gorm.DB, resource *model.Resource, editorID uint, reason string) (*model.ResourceRevision, error) {
+	revision := snapshot(resource)
+	revision.EditorID = editorID
+	revision.Reason = reason
+	revision.Status = model.RevisionStatusApplied
+
+	version, err := s.nextVersion(db, resource.ID)
+	if err != nil {
+		return nil, err
+	}
+	revision.Version = version
+	revision.BaseVersion = version - 1
+
+	if err := db.Create(revision).Error; err != nil {
+		return nil, fmt.Errorf("保存修订失败: %w", err)
+	}
+	return revision, nil
+}
+
+// ensureBaseline 确保资源有已生效版本（修订功能上线前的资源以当前内容作为初始版本）
+// 返回当前生效的版本号
+func (s *ResourceService) ensureBaseline(db *gorm.DB, resource *model.Resource) (int, error) {
+	current, err := s.currentVersion(db, resource.ID)
+	if err != nil || current > 0 {
+		return current, err
+	}
+
+	revision, err := s.recordRevision(db, resource, resource.UploadedByID, "初始版本")
+	if err != nil {
+		return 0, err
+	}
+	return revision.Version, nil
+}
+
+// currentVersion 获取资源当前生效的版本号（没有修订时为0）
+func (s *ResourceService) currentVersion(db *gorm.DB, resourceID uint) (int, error) {
+	var version int
+	if err := db.Model(&model.ResourceRevision{}).
+		Select("COALESCE(MAX(version), 0)").
+		Where("resource_id = ? AND status = ?", resourceID, model.RevisionStatusApplied).
+		Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("查询资源版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// nextVersion 获取资源的下一个版本号
+func (s *ResourceService) nextVersion(db *gorm.DB, resourceID uint) (int, error) {
+	var version int
+	if err := db.Model(&model.ResourceRevision{}).
+		Select("COALESCE(MAX(version), 0)").
+		Where("resource_id = ?", resourceID).
+		Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("查询资源版本失败: %w", err)
+	}
+	return version + 1, nil
+}
+
+// getRevision 按版本号获取资源修订
+func (s *ResourceService) getRevision(resourceID uint, version int) (*model.ResourceRevision, error) {
+	var revision model.ResourceRevision
+	if err := s.db.Preload("Editor").Preload("ReviewedBy").
+		Where("resource_id = ? AND version = ?", resourceID, version).
+		First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("查询修订失败: %w", err)
+	}
+	return &revision, nil
+}
+
+// getPendingRevision 获取待审核修订及其资源
+func (s *ResourceService) getPendingRevision(revisionID uint) (*model.ResourceRevision, *model.Resource, error) {
+	var revision model.ResourceRevision
+	if err := s.db.First(&revision, revisionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRevisionNotFound
+		}
+		return nil, nil, fmt.Errorf("查询修订失败: %w", err)
+	}
+	if revision.Status != model.RevisionStatusPending {
+		return nil, nil, ErrRevisionNotPending
+	}
+
+	resource, err := s.getResource(revision.ResourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &revision, resource, nil
+}
+
+// getResource 获取资源
+func (s *ResourceService) getResource(resourceID uint) (*model.Resource, error) {
+	var resource model.Resource
+	if err := s.db.First(&resource, resourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("查询资源失败: %w", err)
+	}
+	return &resource, nil
+}
+
+// getEditableResource 获取用户有权修改的资源（上传者、管理员或版主）
+func (s *ResourceService) getEditableResource(resourceID, userID uint) (*model.Resource, error) {
+	resource, err := s.getResource(resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if resource.UploadedByID != userID {
+		if err := s.requireRole(userID, "admin", "moderator"); err != nil {
+			return nil, err
+		}
+	}
+	return resource, nil
+}
+
+// requireRole 检查用户是否具有指定角色之一
+func (s *ResourceService) requireRole(userID uint, roles ...string) error {
+	var user model.User
+	if err := s.db.Select("id", "role").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEditPermissionDenied
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	for _, role := range roles {
+		if user.Role == role {
+			return nil
+		}
+	}
+	return ErrEditPermissionDenied
+}
+
+// diffRevisions 比较两个修订的字段差异
+func (s *ResourceService) diffRevisions(from, to *model.ResourceRevision) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field, label, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Label: label, Old: old, New: new})
+		}
+	}
+
+	add("title", "标题", from.Title, to.Title)
+	add("description", "描述", from.Description, to.Description)
+	if from.CategoryID != to.CategoryID {
+		add("category_id", "分类", s.categoryLabel(from.CategoryID), s.categoryLabel(to.CategoryID))
+	}
+	add("netdisk_url", "网盘链接", from.NetdiskURL, to.NetdiskURL)
+	add("points_price", "所需积分", strconv.Itoa(from.PointsPrice), strconv.Itoa(to.PointsPrice))
+
+	oldTags, newTags := tag.ParseTags(from.Tags), tag.ParseTags(to.Tags)
+	if added, removed := diffTags(oldTags, newTags); len(added) > 0 || len(removed) > 0 {
+		changes = append(changes, FieldChange{
+			Field:   "tags",
+			Label:   "标签",
+			Old:     strings.Join(oldTags, ", "),
+			New:     strings.Join(newTags, ", "),
+			Added:   added,
+			Removed: removed,
+		})
+	}
+
+	return changes
+}
+
+// categoryLabel 分类显示名称（分类已删除时显示ID）
+func (s *ResourceService) categoryLabel(categoryID uint) string {
+	var category model.Category
+	if err := s.db.Select("id", "name").First(&category, categoryID).Error; err != nil {
+		return fmt.Sprintf("#%d", categoryID)
+	}
+	return fmt.Sprintf("%s (#%d)", category.Name, categoryID)
+}
+
+// notifyRevisionResult 通知修改者待审核修订的审核结果
+func (s *ResourceService) notifyRevisionResult(resource *model.Resource, revision *model.ResourceRevision, approved bool) {
+	if s.notifier == nil {
+		return
+	}
+
+	event := &notification.Event{
+		UserID:     revision.EditorID,
+		Link:       seo.ResourcePath(resource.ID, resource.Slug),
+		TargetType: "resource",
+		TargetID:   &resource.ID,
+	}
+	if approved {
+		event.Type = model.NotificationTypeResourceApproved
+		event.Title = "资源修改审核通过"
+		event.Content = fmt.Sprintf("您对资源《%s》的修改已通过审核", resource.Title)
+	} else {
+		event.Type = model.NotificationTypeResourceRejected
+		event.Title = "资源修改未通过审核"
+		event.Content = fmt.Sprintf("您对资源《%s》的修改未通过审核", resource.Title)
+	}
+	if revision.ReviewNotes != "" {
+		event.Content += fmt.Sprintf("，审核备注：%s", revision.ReviewNotes)
+	}
+
+	_ = s.notifier.Publish(event)
+}
+
+// snapshot 根据资源当前内容生成修订快照
+func snapshot(resource *model.Resource) *model.ResourceRevision {
+	return &model.ResourceRevision{
+		ResourceID:  resource.ID,
+		Title:       resource.Title,
+		Description: resource.Description,
+		CategoryID:  resource.CategoryID,
+		NetdiskURL:  resource.NetdiskURL,
+		PointsPrice: resource.PointsPrice,
+		Tags:        formatTags(tag.ParseTags(resource.Tags)),
+	}
+}
+
+// sameContent 检查两个修订的内容是否相同（标签按规范化名称比较，忽略顺序）
+func sameContent(a, b *model.ResourceRevision) bool {
+	if a.Title != b.Title || a.Description != b.Description || a.CategoryID != b.CategoryID ||
+		a.NetdiskURL != b.NetdiskURL || a.PointsPrice != b.PointsPrice {
+		return false
+	}
+	added, removed := diffTags(tag.ParseTags(a.Tags), tag.ParseTags(b.Tags))
+	return len(added) == 0 && len(removed) == 0
+}
+
+// diffTags 比较两组标签（按规范化标签名，C++ 与 C# 等拼音相同的标签视为不同）
+func diffTags(old, new []string) (added, removed []string) {
+	oldKeys := make(map[string]bool, len(old))
+	for _, name := range old {
+		oldKeys[tag.NormalizeName(name)] = true
+	}
+	newKeys := make(map[string]bool, len(new))
+	for _, name := range new {
+		newKeys[tag.NormalizeName(name)] = true
+		if !oldKeys[tag.NormalizeName(name)] {
+			added = append(added, name)
+		}
+	}
+	for _, name := range old {
+		if !newKeys[tag.NormalizeName(name)] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// formatTags 修订快照中的标签格式（JSON数组，与资源标签字段一致）
+func formatTags(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(names)
+	return string(data)
+}
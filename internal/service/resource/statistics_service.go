@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"time"
 
+	"resource-share-site/internal/config"
 	"resource-share-site/internal/model"
 
 	"gorm.io/gorm"
@@ -69,7 +70,10 @@ type UploaderStatistics struct {
 	ApprovalRate float64 `json:"approval_rate"`
 	AvgDownloads float64 `json:"avg_downloads"`
 	AvgViews     float64 `json:"avg_views"`
-	Score        float64 `json:"score"` // 排行榜评分
+	RatingsCount int64   `json:"ratings_count"` // 已上线资源收到的评分数
+	AvgRating    float64 `json:"avg_rating"`    // 算术平均分
+	RatingScore  float64 `json:"rating_score"`  // 贝叶斯平均分
+	Score        float64 `json:"score"`         // 排行榜评分
 
 	// 时间信息
 	FirstUploadAt *time.Time `json:"first_upload_at"`
@@ -84,6 +88,7 @@ type PopularResource struct {
 	UploaderName   string    `json:"uploader_name"`
 	DownloadsCount int64     `json:"downloads_count"`
 	ViewsCount     int64     `json:"views_count"`
+	FavoritesCount int64     `json:"favorites_count"`
 	PointsPrice    int       `json:"points_price"`
 	CreatedAt      time.Time `json:"created_at"`
 	Score          float64   `json:"score"`
@@ -100,13 +105,22 @@ type ResourceTrends struct {
 
 // StatisticsService 统计服务
 type StatisticsService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	ratingCfg *config.RatingConfig
 }
 
 // NewStatisticsService 创建新的统计服务
 func NewStatisticsService(db *gorm.DB) *StatisticsService {
 	return &StatisticsService{
-		db: db,
+		db:        db,
+		ratingCfg: config.DefaultRatingConfig(),
+	}
+}
+
+// SetRatingConfig 设置评分配置（上传者评分排行使用与资源评分相同的先验参数）
+func (s *StatisticsService) SetRatingConfig(cfg *config.RatingConfig) {
+	if cfg != nil {
+		s.ratingCfg = cfg
 	}
 }
 
@@ -285,6 +299,17 @@ func (s *StatisticsService) GetUploaderStatistics(userID uint, startDate, endDat
 		stats.AvgViews = float64(stats.TotalViews) / float64(stats.TotalResources)
 	}
 
+	// 评分指标（只统计已上线资源收到的评分）
+	ratingsCount, ratingsSum, err := s.uploaderRatings(userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	stats.RatingsCount = ratingsCount
+	if ratingsCount > 0 {
+		stats.AvgRating = float64(ratingsSum) / float64(ratingsCount)
+	}
+	stats.RatingScore = bayesianScore(s.ratingCfg, ratingsCount, ratingsSum)
+
 	// 时间信息
 	var firstUploadAt time.Time
 	result := query.Order("created_at ASC").Pluck("created_at", &firstUploadAt)
@@ -303,7 +328,7 @@ func (s *StatisticsService) GetUploaderStatistics(userID uint, startDate, endDat
 
 // GetPopularResources 获取热门资源排行
 // 参数：
-//   - rankingType: 排行类型（downloads, views, latest）
+//   - rankingType: 排行类型（downloads, views, favorites, score 综合评分, latest）
 //   - period: 时间周期（day, week, month, year, all）
 //   - categoryID: 分类ID筛选（可选）
 //   - limit: 限制数量
@@ -329,7 +354,7 @@ func (s *StatisticsService) GetPopularResources(rankingType, period string, cate
 	// 基础查询
 	query := s.db.Table("resources").
 		Select(`resources.id as resource_id, resources.title, resources.downloads_count,
-				resources.views_count, resources.points_price, resources.created_at,
+				resources.views_count, resources.favorites_count, resources.points_price, resources.created_at,
 				categories.name as category_name, users.username as uploader_name`).
 		Joins("LEFT JOIN categories ON resources.category_id = categories.id").
 		Joins("LEFT JOIN users ON resources.uploaded_by_id = users.id").
@@ -349,6 +374,10 @@ func (s *StatisticsService) GetPopularResources(rankingType, period string, cate
 		query = query.Order("resources.downloads_count DESC, resources.created_at DESC")
 	case "views":
 		query = query.Order("resources.views_count DESC, resources.created_at DESC")
+	case "favorites":
+		query = query.Order("resources.favorites_count DESC, resources.downloads_count DESC, resources.created_at DESC")
+	case "score":
+		query = query.Order("resources.downloads_count * 0.6 + resources.views_count * 0.3 + resources.favorites_count * 1.0 DESC, resources.created_at DESC")
 	case "latest":
 		query = query.Order("resources.created_at DESC")
 	default:
@@ -371,13 +400,15 @@ func (s *StatisticsService) GetPopularResources(rankingType, period string, cate
 			UploaderName:   result["uploader_name"].(string),
 			DownloadsCount: result["downloads_count"].(int64),
 			ViewsCount:     result["views_count"].(int64),
+			FavoritesCount: result["favorites_count"].(int64),
 			PointsPrice:    int(result["points_price"].(int64)),
 			CreatedAt:      result["created_at"].(time.Time),
 		}
 
-		// 计算评分（综合下载量和浏览量）
+		// 计算评分（综合下载量、浏览量和收藏数，收藏代表更强的兴趣）
 		downloadsScore := float64(popularResources[i].DownloadsCount) * 0.6
 		viewsScore := float64(popularResources[i].ViewsCount) * 0.3
+		favoritesScore := float64(popularResources[i].FavoritesCount) * 1.0
 		timeScore := 0.0
 		if periodStart != nil {
 			// 越新的资源时间分数越高
@@ -386,7 +417,7 @@ func (s *StatisticsService) GetPopularResources(rankingType, period string, cate
 				timeScore = float64(30-daysSinceCreation) * 0.1
 			}
 		}
-		popularResources[i].Score = downloadsScore + viewsScore + timeScore
+		popularResources[i].Score = downloadsScore + viewsScore + favoritesScore + timeScore
 	}
 
 	return popularResources, nil
@@ -481,7 +512,7 @@ func (s *StatisticsService) GetResourceTrends(days int, categoryID *uint, metric
 
 // GetUploadersRanking 获取上传者排行榜
 // 参数：
-//   - rankingType: 排行类型（resources, downloads, views）
+//   - rankingType: 排行类型（resources, downloads, views, rating 按资源评分的贝叶斯平均分）
 //   - period: 时间周期
 //   - limit: 限制数量
 //   - offset: 偏移量
@@ -503,6 +534,11 @@ func (s *StatisticsService) GetUploadersRanking(rankingType, period string, limi
 		return nil, fmt.Errorf("计算时间范围失败: %w", err)
 	}
 
+	// 评分排行直接在数据库中聚合排序
+	if rankingType == "rating" {
+		return s.getUploadersRankingByRating(periodStart, limit, offset)
+	}
+
 	// 获取上传者统计
 	var uploaders []*UploaderStatistics
 
@@ -576,6 +612,63 @@ func (s *StatisticsService) GetUploadersRanking(rankingType, period string, limi
 	return uploaders, nil
 }
 
+// getUploadersRankingByRating 按上传资源收到评分的贝叶斯平均分获取上传者排行
+// （评分较少的上传者向先验评分靠拢，避免少量高分排在前面）
+func (s *StatisticsService) getUploadersRankingByRating(periodStart *time.Time, limit, offset int) ([]*UploaderStatistics, error) {
+	var rows []struct {
+		UploaderID uint
+	}
+	query := s.ratingsQuery(periodStart, nil).
+		Select("resources.uploaded_by_id AS uploader_id").
+		Group("resources.uploaded_by_id").
+		Order(fmt.Sprintf("(%f + SUM(resource_ratings.rating)) / (%f + COUNT(*)) DESC, COUNT(*) DESC",
+			s.ratingCfg.PriorWeight*s.ratingCfg.PriorMean, s.ratingCfg.PriorWeight))
+	if err := query.Offset(offset).Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询上传者评分排行失败: %w", err)
+	}
+
+	uploaders := make([]*UploaderStatistics, 0, len(rows))
+	for _, row := range rows {
+		uploaderStats, err := s.GetUploaderStatistics(row.UploaderID, periodStart, nil)
+		if err != nil {
+			continue // 跳过查询失败的用户
+		}
+		uploaderStats.Score = uploaderStats.RatingScore
+		uploaders = append(uploaders, uploaderStats)
+	}
+
+	return uploaders, nil
+}
+
+// uploaderRatings 统计上传者已上线资源收到的评分数和评分总和
+func (s *StatisticsService) uploaderRatings(userID uint, startDate, endDate *time.Time) (int64, int64, error) {
+	var totals struct {
+		Count int64
+		Sum   int64
+	}
+	if err := s.ratingsQuery(startDate, endDate).
+		Select("COUNT(*) AS count, COALESCE(SUM(resource_ratings.rating), 0) AS sum").
+		Where("resources.uploaded_by_id = ?", userID).
+		Scan(&totals).Error; err != nil {
+		return 0, 0, fmt.Errorf("查询评分统计失败: %w", err)
+	}
+	return totals.Count, totals.Sum, nil
+}
+
+// ratingsQuery 已上线资源的评分查询（按评分时间筛选）
+func (s *StatisticsService) ratingsQuery(startDate, endDate *time.Time) *gorm.DB {
+	query := s.db.Model(&model.ResourceRating{}).
+		Joins("JOIN resources ON resources.id = resource_ratings.resource_id").
+		Where("resources.deleted_at IS NULL AND resources.status = ?", model.ResourceStatusApproved)
+	if startDate != nil {
+		query = query.Where("resource_ratings.updated_at >= ?", *startDate)
+	}
+	if endDate != nil {
+		query = query.Where("resource_ratings.updated_at <= ?", *endDate)
+	}
+	return query
+}
+
 // getDailyUploads 获取每日上传数
 func (s *StatisticsService) getDailyUploads(days int, startDate, endDate *time.Time) ([]int64, []string) {
 	uploads := make([]int64, days)
@@ -0,0 +1,828 @@
+/*
+SEO Audit Service - SEO站点审计服务
+
+通过进程内的 http.Handler 抓取站点自身页面（无需网络），并检查：
+- 标题和描述（缺失、长度、重复）
+- 规范URL（canonical）
+- 标题层级（h1 数量、跳级）
+- 图片 alt 属性
+- 站内失效链接
+- 响应速度
+- 结构化数据（JSON-LD）
+
+每个页面的问题单独保存，报告得分和建议根据实际结果计算。
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+Date: 2025-10-31
+*/
+
+package seo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrAuditRunning        = errors.New("站点审计正在进行中")
+	ErrAuditHandlerMissing = errors.New("站点审计未配置HTTP处理器")
+	ErrAuditNotFound       = errors.New("审计报告不存在")
+)
+
+// AuditUserAgent 审计请求使用的User-Agent
+const AuditUserAgent = "ResourceShareSite-SEOAudit/1.0"
+
+// auditContextKey 标记审计请求的上下文键（页面处理器据此跳过浏览数统计等副作用）
+// 审计请求在进程内直接交给路由处理，使用上下文而不是请求头标记，外部客户端无法伪造
+type auditContextKey struct{}
+
+const (
+	// 标题和描述的推荐长度（字符数）
+	titleMinRunes       = 10
+	titleMaxRunes       = 60
+	descriptionMinRunes = 50
+	descriptionMaxRunes = 160
+
+	// maxBrokenLinkFindings 单个页面最多记录的失效链接数
+	maxBrokenLinkFindings = 20
+)
+
+// 各严重程度的扣分
+var severityPenalty = map[model.SEOAuditSeverity]int{
+	model.SEOAuditSeverityError:   15,
+	model.SEOAuditSeverityWarning: 5,
+	model.SEOAuditSeverityNotice:  1,
+}
+
+// auditAdvice 各检查项对应的建议（%d 为出现问题的页面数）
+var auditAdvice = map[string]string{
+	"http_status":             "%d 个页面返回错误状态码，请修复或移除指向它们的链接",
+	"broken_link":             "%d 个页面包含失效的站内链接，请更新或删除这些链接",
+	"title_missing":           "%d 个页面缺少<title>，请在SEO配置或页面模板中设置标题",
+	"title_too_short":         "%d 个页面标题过短，建议标题包含 10-60 个字符",
+	"title_too_long":          "%d 个页面标题过长，超出部分在搜索结果中会被截断",
+	"title_duplicate":         "%d 个页面标题重复，建议在标题模板中加入资源或分类名称",
+	"description_missing":     "%d 个页面缺少描述（meta description）",
+	"description_too_short":   "%d 个页面描述过短，建议描述包含 50-160 个字符",
+	"description_too_long":    "%d 个页面描述过长，超出部分在搜索结果中会被截断",
+	"description_duplicate":   "%d 个页面描述重复，建议根据页面内容生成描述",
+	"canonical_missing":       "%d 个页面缺少规范URL（canonical）",
+	"canonical_multiple":      "%d 个页面存在多个canonical标签",
+	"canonical_mismatch":      "%d 个页面的canonical指向其他地址，请确认是否符合预期",
+	"h1_missing":              "%d 个页面缺少<h1>标题",
+	"h1_multiple":             "%d 个页面包含多个<h1>标题",
+	"heading_skip":            "%d 个页面的标题层级存在跳级（如 h2 直接到 h4）",
+	"image_alt_missing":       "%d 个页面存在缺少alt属性的图片",
+	"slow_response":           "%d 个页面响应较慢，建议优化查询或增加缓存",
+	"structured_data_missing": "%d 个页面缺少结构化数据（JSON-LD）",
+	"structured_data_invalid": "%d 个页面的结构化数据不是有效的JSON",
+}
+
+// AuditService SEO站点审计服务
+type AuditService struct {
+	db      *gorm.DB
+	cfg     *config.SEOConfig
+	handler http.Handler
+	running sync.Mutex
+}
+
+// NewAuditService 创建SEO站点审计服务
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{
+		db:  db,
+		cfg: config.DefaultSEOConfig(),
+	}
+}
+
+// SetConfig 设置搜索引擎优化配置（页面数上限、慢页面阈值、排除路径）
+func (s *AuditService) SetConfig(cfg *config.SEOConfig) {
+	if cfg != nil {
+		s.cfg = cfg
+	}
+}
+
+// SetHandler 设置用于抓取页面的HTTP处理器（通常为路由引擎）
+func (s *AuditService) SetHandler(handler http.Handler) {
+	s.handler = handler
+}
+
+// IsAuditRequest 判断请求是否来自站点审计
+func IsAuditRequest(r *http.Request) bool {
+	audit, _ := r.Context().Value(auditContextKey{}).(bool)
+	return audit
+}
+
+// auditedPage 抓取中的页面
+type auditedPage struct {
+	page     model.SEOAuditPage
+	findings []model.SEOAuditFinding
+	links    []string
+	isHTML   bool
+}
+
+// addFinding 记录问题
+func (p *auditedPage) addFinding(code string, severity model.SEOAuditSeverity, message, detail string) {
+	p.findings = append(p.findings, model.SEOAuditFinding{
+		Code:     code,
+		Severity: severity,
+		Message:  message,
+		Detail:   truncateDetail(detail),
+	})
+}
+
+// Run 执行一次站点审计并保存报告
+func (s *AuditService) Run() (*model.SEOReport, error) {
+	if s.handler == nil {
+		return nil, ErrAuditHandlerMissing
+	}
+	if !s.running.TryLock() {
+		return nil, ErrAuditRunning
+	}
+	defer s.running.Unlock()
+
+	startedAt := time.Now()
+	pages, unchecked := s.crawl()
+	checkDuplicates(pages)
+	checkBrokenLinks(pages)
+
+	ordered := make([]*auditedPage, 0, len(pages))
+	for _, p := range pages {
+		p.page.Score = pageScore(p.findings)
+		ordered = append(ordered, p)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].page.URL < ordered[j].page.URL })
+
+	report := s.buildReport(ordered, unchecked, time.Since(startedAt))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		for _, p := range ordered {
+			p.page.ReportID = report.ID
+			if err := tx.Omit("Findings").Create(&p.page).Error; err != nil {
+				return err
+			}
+			if len(p.findings) == 0 {
+				continue
+			}
+			for i := range p.findings {
+				p.findings[i].ReportID = report.ID
+				p.findings[i].PageID = p.page.ID
+			}
+			if err := tx.CreateInBatches(p.findings, 100).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存审计结果失败: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetAuditReport 获取审计报告
+func (s *AuditService) GetAuditReport(reportID uint) (*model.SEOReport, error) {
+	var report model.SEOReport
+	if err := s.db.Where("report_type = ?", model.SEOReportTypeAudit).First(&report, reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuditNotFound
+		}
+		return nil, fmt.Errorf("查询审计报告失败: %w", err)
+	}
+	return &report, nil
+}
+
+// GetLatestAuditReport 获取最近一次审计报告（没有时返回nil）
+func (s *AuditService) GetLatestAuditReport() (*model.SEOReport, error) {
+	return latestAuditReport(s.db)
+}
+
+// GetAuditPages 获取审计报告中的页面及问题（按得分从低到高）
+// 参数：
+//   - reportID: 审计报告ID
+//   - code: 只返回存在该问题的页面（可选）
+//   - severity: 只返回存在该严重程度问题的页面（可选）
+func (s *AuditService) GetAuditPages(reportID uint, code string, severity model.SEOAuditSeverity, page, pageSize int) ([]model.SEOAuditPage, int64, error) {
+	if _, err := s.GetAuditReport(reportID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&model.SEOAuditPage{}).Where("report_id = ?", reportID)
+	if code != "" || severity != "" {
+		findings := s.db.Model(&model.SEOAuditFinding{}).Select("page_id").Where("report_id = ?", reportID)
+		if code != "" {
+			findings = findings.Where("code = ?", code)
+		}
+		if severity != "" {
+			findings = findings.Where("severity = ?", severity)
+		}
+		query = query.Where("id IN (?)", findings)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询审计页面总数失败: %w", err)
+	}
+
+	var pages []model.SEOAuditPage
+	if err := query.Preload("Findings").
+		Order("score ASC").Order("url ASC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&pages).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询审计页面失败: %w", err)
+	}
+	return pages, total, nil
+}
+
+// latestAuditReport 查询最近一次审计报告（没有时返回nil）
+func latestAuditReport(db *gorm.DB) (*model.SEOReport, error) {
+	var report model.SEOReport
+	err := db.Where("report_type = ?", model.SEOReportTypeAudit).Order("id DESC").First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询审计报告失败: %w", err)
+	}
+	return &report, nil
+}
+
+// crawl 从首页和sitemap出发按广度优先抓取站内页面，返回抓取结果和因数量限制未检查的链接数
+func (s *AuditService) crawl() (map[string]*auditedPage, int) {
+	maxPages := s.cfg.GetAuditMaxPages()
+	queue := append([]string{"/"}, s.sitemapPaths()...)
+	queued := make(map[string]bool, len(queue))
+	for _, path := range queue {
+		queued[path] = true
+	}
+
+	pages := make(map[string]*auditedPage)
+	for len(queue) > 0 && len(pages) < maxPages {
+		path := queue[0]
+		queue = queue[1:]
+
+		p := s.fetchPage(path)
+		pages[path] = p
+		for _, link := range p.links {
+			if !queued[link] && !s.excluded(link) {
+				queued[link] = true
+				queue = append(queue, link)
+			}
+		}
+	}
+	return pages, len(queue)
+}
+
+// excluded 判断路径是否在排除列表中
+func (s *AuditService) excluded(path string) bool {
+	for _, prefix := range s.cfg.AuditExcludes {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// request 通过进程内处理器发起GET请求
+func (s *AuditService) request(path string) (*httptest.ResponseRecorder, time.Duration) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req = req.WithContext(context.WithValue(req.Context(), auditContextKey{}, true))
+	req.Header.Set("User-Agent", AuditUserAgent)
+	if host := s.siteHost(); host != "" {
+		req.Host = host
+	}
+
+	recorder := httptest.NewRecorder()
+	startedAt := time.Now()
+	s.handler.ServeHTTP(recorder, req)
+	return recorder, time.Since(startedAt)
+}
+
+// siteHost 站点地址中的主机名
+func (s *AuditService) siteHost() string {
+	if parsed, err := url.Parse(s.cfg.GetBaseURL()); err == nil {
+		return parsed.Host
+	}
+	return ""
+}
+
+// sitemapPaths 从sitemap索引和分片中读取页面路径
+func (s *AuditService) sitemapPaths() []string {
+	recorder, _ := s.request("/sitemap_index.xml")
+	if recorder.Code != http.StatusOK {
+		return nil
+	}
+
+	var paths []string
+	for _, loc := range sitemapLocs(recorder.Body) {
+		shard := s.internalPath("/", loc)
+		if shard == "" || strings.HasSuffix(shard, ".gz") {
+			continue
+		}
+		shardRecorder, _ := s.request(shard)
+		if shardRecorder.Code != http.StatusOK {
+			continue
+		}
+		for _, pageLoc := range sitemapLocs(shardRecorder.Body) {
+			if path := s.internalPath("/", pageLoc); path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// sitemapLocs 读取sitemap中所有<loc>的值
+func sitemapLocs(body io.Reader) []string {
+	var locs []string
+	decoder := xml.NewDecoder(body)
+	inLoc := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return locs
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			inLoc = t.Name.Local == "loc"
+		case xml.EndElement:
+			inLoc = false
+		case xml.CharData:
+			if inLoc {
+				locs = append(locs, strings.TrimSpace(string(t)))
+			}
+		}
+	}
+}
+
+// internalPath 将链接解析为站内路径（含查询参数），站外链接返回空字符串
+func (s *AuditService) internalPath(basePath, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https" {
+		return "" // mailto:、javascript: 等
+	}
+	if ref.Host != "" && ref.Host != s.siteHost() {
+		return ""
+	}
+
+	base := &url.URL{Path: basePath}
+	resolved := base.ResolveReference(ref)
+	path := resolved.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// 重新编码查询参数，保证路径可以直接用于构造请求
+	if query := resolved.Query().Encode(); query != "" {
+		path += "?" + query
+	}
+	return path
+}
+
+// fetchPage 抓取并检查单个页面
+func (s *AuditService) fetchPage(path string) *auditedPage {
+	recorder, elapsed := s.request(path)
+	p := &auditedPage{page: model.SEOAuditPage{
+		URL:         path,
+		StatusCode:  recorder.Code,
+		ContentType: recorder.Header().Get("Content-Type"),
+		ResponseMs:  elapsed.Milliseconds(),
+	}}
+
+	if elapsed > s.cfg.GetAuditSlowThreshold() {
+		p.addFinding("slow_response", model.SEOAuditSeverityWarning, "页面响应较慢", fmt.Sprintf("%dms", p.page.ResponseMs))
+	}
+
+	switch {
+	case recorder.Code >= http.StatusBadRequest:
+		p.addFinding("http_status", model.SEOAuditSeverityError, "页面返回错误状态码", fmt.Sprintf("%d", recorder.Code))
+		return p
+	case recorder.Code >= http.StatusMultipleChoices:
+		// 跟随站内重定向
+		if target := s.internalPath(path, recorder.Header().Get("Location")); target != "" {
+			p.links = append(p.links, target)
+		}
+		return p
+	}
+
+	if !strings.HasPrefix(p.page.ContentType, "text/html") {
+		return p
+	}
+	p.isHTML = true
+	s.inspectHTML(p, recorder.Body.Bytes())
+	return p
+}
+
+// htmlSummary 页面解析结果
+type htmlSummary struct {
+	title       string
+	description *string
+	robots      string
+	canonicals  []string
+	headings    []int
+	images      int
+	missingAlt  int
+	links       []string
+	jsonLD      []string
+}
+
+// inspectHTML 解析HTML并执行页面级检查
+func (s *AuditService) inspectHTML(p *auditedPage, body []byte) {
+	summary := parseHTML(body)
+
+	// 站内链接（去重）
+	seen := make(map[string]bool)
+	for _, href := range summary.links {
+		if link := s.internalPath(p.page.URL, href); link != "" && !seen[link] {
+			seen[link] = true
+			p.links = append(p.links, link)
+		}
+	}
+	p.page.LinkCount = len(p.links)
+
+	// 标题
+	p.page.Title = strings.TrimSpace(summary.title)
+	switch length := utf8.RuneCountInString(p.page.Title); {
+	case length == 0:
+		p.addFinding("title_missing", model.SEOAuditSeverityError, "页面缺少标题", "")
+	case length < titleMinRunes:
+		p.addFinding("title_too_short", model.SEOAuditSeverityWarning, "页面标题过短", p.page.Title)
+	case length > titleMaxRunes:
+		p.addFinding("title_too_long", model.SEOAuditSeverityWarning, "页面标题过长", p.page.Title)
+	}
+
+	// 描述
+	if summary.description == nil || strings.TrimSpace(*summary.description) == "" {
+		p.addFinding("description_missing", model.SEOAuditSeverityWarning, "页面缺少描述", "")
+	} else {
+		p.page.Description = strings.TrimSpace(*summary.description)
+		switch length := utf8.RuneCountInString(p.page.Description); {
+		case length < descriptionMinRunes:
+			p.addFinding("description_too_short", model.SEOAuditSeverityNotice, "页面描述过短", p.page.Description)
+		case length > descriptionMaxRunes:
+			p.addFinding("description_too_long", model.SEOAuditSeverityNotice, "页面描述过长", p.page.Description)
+		}
+	}
+
+	// 规范URL
+	switch len(summary.canonicals) {
+	case 0:
+		p.addFinding("canonical_missing", model.SEOAuditSeverityWarning, "页面缺少canonical标签", "")
+	case 1:
+		p.page.Canonical = summary.canonicals[0]
+		if target := s.internalPath(p.page.URL, p.page.Canonical); target != p.page.URL {
+			p.addFinding("canonical_mismatch", model.SEOAuditSeverityNotice, "canonical指向其他地址", p.page.Canonical)
+		}
+	default:
+		p.page.Canonical = summary.canonicals[0]
+		p.addFinding("canonical_multiple", model.SEOAuditSeverityError, "页面存在多个canonical标签", strings.Join(summary.canonicals, " "))
+	}
+
+	// 是否允许收录
+	p.page.Indexable = !strings.Contains(strings.ToLower(summary.robots), "noindex")
+
+	// 标题层级
+	previous := 0
+	skipped := ""
+	for _, level := range summary.headings {
+		if level == 1 {
+			p.page.H1Count++
+		}
+		if previous > 0 && level > previous+1 && skipped == "" {
+			skipped = fmt.Sprintf("h%d → h%d", previous, level)
+		}
+		previous = level
+	}
+	switch {
+	case p.page.H1Count == 0:
+		p.addFinding("h1_missing", model.SEOAuditSeverityWarning, "页面缺少h1标题", "")
+	case p.page.H1Count > 1:
+		p.addFinding("h1_multiple", model.SEOAuditSeverityNotice, "页面包含多个h1标题", fmt.Sprintf("%d", p.page.H1Count))
+	}
+	if skipped != "" {
+		p.addFinding("heading_skip", model.SEOAuditSeverityNotice, "标题层级跳级", skipped)
+	}
+
+	// 图片alt
+	p.page.ImageCount = summary.images
+	p.page.ImagesMissingAlt = summary.missingAlt
+	if summary.missingAlt > 0 {
+		p.addFinding("image_alt_missing", model.SEOAuditSeverityWarning, "图片缺少alt属性", fmt.Sprintf("%d/%d", summary.missingAlt, summary.images))
+	}
+
+	// 结构化数据
+	p.page.HasStructuredData = len(summary.jsonLD) > 0
+	if !p.page.HasStructuredData {
+		p.addFinding("structured_data_missing", model.SEOAuditSeverityNotice, "页面缺少结构化数据", "")
+	}
+	for _, data := range summary.jsonLD {
+		if !json.Valid([]byte(data)) {
+			p.addFinding("structured_data_invalid", model.SEOAuditSeverityError, "结构化数据不是有效的JSON", data)
+			break
+		}
+	}
+}
+
+// parseHTML 解析页面中与SEO相关的元素
+func parseHTML(body []byte) *htmlSummary {
+	summary := &htmlSummary{}
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	var textTarget *string
+	var jsonLD *strings.Builder
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return summary
+
+		case html.TextToken:
+			if textTarget != nil {
+				*textTarget += string(tokenizer.Text())
+			}
+			if jsonLD != nil {
+				jsonLD.Write(tokenizer.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				textTarget = nil
+			case "script":
+				if jsonLD != nil {
+					summary.jsonLD = append(summary.jsonLD, strings.TrimSpace(jsonLD.String()))
+					jsonLD = nil
+				}
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			attrs := make(map[string]*string, len(token.Attr))
+			for i := range token.Attr {
+				attrs[strings.ToLower(token.Attr[i].Key)] = &token.Attr[i].Val
+			}
+			attr := func(key string) string {
+				if value, ok := attrs[key]; ok {
+					return *value
+				}
+				return ""
+			}
+
+			switch token.Data {
+			case "title":
+				if summary.title == "" {
+					textTarget = &summary.title
+				}
+			case "meta":
+				switch strings.ToLower(attr("name")) {
+				case "description":
+					content := attr("content")
+					summary.description = &content
+				case "robots":
+					summary.robots = attr("content")
+				}
+			case "link":
+				if strings.EqualFold(attr("rel"), "canonical") {
+					summary.canonicals = append(summary.canonicals, attr("href"))
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				summary.headings = append(summary.headings, int(token.Data[1]-'0'))
+			case "img":
+				summary.images++
+				if _, ok := attrs["alt"]; !ok {
+					summary.missingAlt++
+				}
+			case "a":
+				if href, ok := attrs["href"]; ok {
+					summary.links = append(summary.links, *href)
+				}
+			case "script":
+				if strings.EqualFold(attr("type"), "application/ld+json") && tokenType == html.StartTagToken {
+					jsonLD = &strings.Builder{}
+				}
+			}
+		}
+	}
+}
+
+// checkDuplicates 检查可收录页面之间重复的标题和描述
+func checkDuplicates(pages map[string]*auditedPage) {
+	titles := make(map[string][]*auditedPage)
+	descriptions := make(map[string][]*auditedPage)
+	for _, p := range pages {
+		if !p.isHTML || !p.page.Indexable {
+			continue
+		}
+		if p.page.Title != "" {
+			titles[p.page.Title] = append(titles[p.page.Title], p)
+		}
+		if p.page.Description != "" {
+			descriptions[p.page.Description] = append(descriptions[p.page.Description], p)
+		}
+	}
+
+	for title, group := range titles {
+		if len(group) < 2 {
+			continue
+		}
+		for _, p := range group {
+			p.addFinding("title_duplicate", model.SEOAuditSeverityWarning, fmt.Sprintf("标题与其他 %d 个页面重复", len(group)-1), title)
+		}
+	}
+	for description, group := range descriptions {
+		if len(group) < 2 {
+			continue
+		}
+		for _, p := range group {
+			p.addFinding("description_duplicate", model.SEOAuditSeverityNotice, fmt.Sprintf("描述与其他 %d 个页面重复", len(group)-1), description)
+		}
+	}
+}
+
+// checkBrokenLinks 检查页面中指向错误页面的站内链接
+func checkBrokenLinks(pages map[string]*auditedPage) {
+	for _, p := range pages {
+		broken := 0
+		for _, link := range p.links {
+			target, ok := pages[link]
+			if !ok || target.page.StatusCode < http.StatusBadRequest {
+				continue
+			}
+			p.addFinding("broken_link", model.SEOAuditSeverityError, fmt.Sprintf("链接返回 %d", target.page.StatusCode), link)
+			if broken++; broken >= maxBrokenLinkFindings {
+				break
+			}
+		}
+	}
+}
+
+// pageScore 根据问题计算页面得分
+func pageScore(findings []model.SEOAuditFinding) int {
+	score := 100
+	for _, finding := range findings {
+		score -= severityPenalty[finding.Severity]
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// buildReport 汇总审计结果生成报告
+func (s *AuditService) buildReport(pages []*auditedPage, unchecked int, duration time.Duration) *model.SEOReport {
+	report := &model.SEOReport{
+		ReportType: model.SEOReportTypeAudit,
+		Period:     time.Now().Format("2006-01-02 15:04"),
+		TotalPages: len(pages),
+	}
+
+	var totalKeywords int64
+	s.db.Model(&model.SEOKeyword{}).Where("is_active = ?", true).Count(&totalKeywords)
+	report.TotalKeywords = int(totalKeywords)
+
+	bySeverity := make(map[model.SEOAuditSeverity]int)
+	byCode := make(map[string]int)      // 问题出现次数
+	pagesByCode := make(map[string]int) // 存在该问题的页面数
+	htmlPages, scoreSum := 0, 0
+	var totalMs int64
+	for _, p := range pages {
+		totalMs += p.page.ResponseMs
+		scoreSum += p.page.Score
+		if p.isHTML {
+			htmlPages++
+			if p.page.Indexable {
+				report.IndexedPages++
+			}
+		}
+		codes := make(map[string]bool)
+		for _, finding := range p.findings {
+			bySeverity[finding.Severity]++
+			byCode[finding.Code]++
+			codes[finding.Code] = true
+		}
+		for code := range codes {
+			pagesByCode[code]++
+		}
+	}
+	if len(pages) > 0 {
+		report.SEOScore = scoreSum / len(pages)
+	}
+
+	// 最慢的页面
+	slowest := make([]*auditedPage, len(pages))
+	copy(slowest, pages)
+	sort.Slice(slowest, func(i, j int) bool { return slowest[i].page.ResponseMs > slowest[j].page.ResponseMs })
+	if len(slowest) > 5 {
+		slowest = slowest[:5]
+	}
+	slowPages := make([]map[string]interface{}, 0, len(slowest))
+	for _, p := range slowest {
+		slowPages = append(slowPages, map[string]interface{}{"url": p.page.URL, "response_ms": p.page.ResponseMs})
+	}
+
+	reportData := map[string]interface{}{
+		"generated_at":    time.Now(),
+		"duration_ms":     duration.Milliseconds(),
+		"html_pages":      htmlPages,
+		"unchecked_links": unchecked,
+		"by_severity":     bySeverity,
+		"by_code":         byCode,
+		"pages_by_code":   pagesByCode,
+		"slowest_pages":   slowPages,
+	}
+	if len(pages) > 0 {
+		reportData["avg_response_ms"] = totalMs / int64(len(pages))
+	}
+	if data, err := json.Marshal(reportData); err == nil {
+		report.ReportData = string(data)
+	}
+
+	report.Recommendations = strings.Join(auditRecommendations(pagesByCode, byCode, unchecked), "\n")
+	return report
+}
+
+// auditRecommendations 根据问题分布生成建议（按影响从大到小排序）
+func auditRecommendations(pagesByCode, byCode map[string]int, unchecked int) []string {
+	codes := make([]string, 0, len(pagesByCode))
+	for code := range pagesByCode {
+		codes = append(codes, code)
+	}
+	weight := func(code string) int {
+		return pagesByCode[code] * codeSeverityPenalty(code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if weight(codes[i]) != weight(codes[j]) {
+			return weight(codes[i]) > weight(codes[j])
+		}
+		return codes[i] < codes[j]
+	})
+
+	var recommendations []string
+	for _, code := range codes {
+		if advice, ok := auditAdvice[code]; ok {
+			recommendations = append(recommendations, fmt.Sprintf(advice, pagesByCode[code]))
+		}
+	}
+	if count := byCode["broken_link"]; count > 0 {
+		recommendations = append(recommendations, fmt.Sprintf("共发现 %d 处失效的站内链接", count))
+	}
+	if unchecked > 0 {
+		recommendations = append(recommendations, fmt.Sprintf("还有 %d 个站内链接因页面数上限未被检查，可调大 seo.audit_max_pages", unchecked))
+	}
+	if len(recommendations) == 0 {
+		recommendations = append(recommendations, "未发现SEO问题")
+	}
+	return recommendations
+}
+
+// codeSeverityPenalty 检查项对应的扣分（用于建议排序）
+func codeSeverityPenalty(code string) int {
+	switch code {
+	case "http_status", "broken_link", "title_missing", "canonical_multiple", "structured_data_invalid":
+		return severityPenalty[model.SEOAuditSeverityError]
+	case "description_too_short", "description_too_long", "description_duplicate",
+		"canonical_mismatch", "h1_multiple", "heading_skip", "structured_data_missing":
+		return severityPenalty[model.SEOAuditSeverityNotice]
+	default:
+		return severityPenalty[model.SEOAuditSeverityWarning]
+	}
+}
+
+// truncateDetail 截断问题详情以适应字段长度
+func truncateDetail(detail string) string {
+	const maxRunes = 200
+	if utf8.RuneCountInString(detail) <= maxRunes {
+		return detail
+	}
+	return string([]rune(detail)[:maxRunes-1]) + "…"
+}
@@ -26,21 +26,69 @@ import (
 
 // ConfigService SEO配置服务
 type ConfigService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	engine *TemplateEngine
 }
 
 // NewConfigService 创建新的SEO配置服务
 func NewConfigService(db *gorm.DB) *ConfigService {
 	return &ConfigService{
-		db: db,
+		db:     db,
+		engine: NewTemplateEngine(),
 	}
 }
 
+// templateFields 支持模板语法的SEO配置字段（列名）
+var templateFields = []string{
+	"meta_title", "meta_description", "meta_keywords", "meta_author",
+	"og_title", "og_description", "og_url",
+	"twitter_title", "twitter_description",
+	"canonical_url",
+}
+
+// templateFieldValues 获取配置中各模板字段的值
+func templateFieldValues(config *model.SEOConfig) map[string]string {
+	return map[string]string{
+		"meta_title":          config.MetaTitle,
+		"meta_description":    config.MetaDescription,
+		"meta_keywords":       config.MetaKeywords,
+		"meta_author":         config.MetaAuthor,
+		"og_title":            config.OGTitle,
+		"og_description":      config.OGDescription,
+		"og_url":              config.OGUrl,
+		"twitter_title":       config.TwitterTitle,
+		"twitter_description": config.TwitterDescription,
+		"canonical_url":       config.CanonicalURL,
+	}
+}
+
+// ValidateTemplate 校验单个SEO模板
+func (s *ConfigService) ValidateTemplate(source string) error {
+	return s.engine.Validate(source)
+}
+
+// validateTemplates 校验模板字段（返回第一个错误，错误信息包含字段名）
+func (s *ConfigService) validateTemplates(values map[string]string) error {
+	for _, field := range templateFields {
+		source, ok := values[field]
+		if !ok {
+			continue
+		}
+		if err := s.engine.Validate(source); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	return nil
+}
+
 // CreateSEOConfig 创建SEO配置
 func (s *ConfigService) CreateSEOConfig(config *model.SEOConfig) error {
 	if config.ConfigType == "" {
 		return fmt.Errorf("配置类型不能为空")
 	}
+	if err := s.validateTemplates(templateFieldValues(config)); err != nil {
+		return err
+	}
 
 	// 设置默认值
 	if config.Robots == "" {
@@ -52,6 +100,10 @@ func (s *ConfigService) CreateSEOConfig(config *model.SEOConfig) error {
 	if config.Priority == 0 {
 		config.Priority = 0.5
 	}
+	// 资源和分类的规范URL默认使用slug地址，地址变化时自动更新
+	if config.CanonicalURL == "" && config.TargetID != nil {
+		config.CanonicalURL = EntityPath(s.db, config.ConfigType, *config.TargetID)
+	}
 
 	if err := s.db.Create(config).Error; err != nil {
 		return fmt.Errorf("创建SEO配置失败: %w", err)
@@ -62,6 +114,18 @@ func (s *ConfigService) CreateSEOConfig(config *model.SEOConfig) error {
 
 // UpdateSEOConfig 更新SEO配置
 func (s *ConfigService) UpdateSEOConfig(configID uint, updates map[string]interface{}) error {
+	// 更新中的模板字段需要先通过校验（同时支持列名和字段名）
+	values := make(map[string]string)
+	for key, value := range updates {
+		column := s.db.NamingStrategy.ColumnName("", key)
+		if source, ok := value.(string); ok {
+			values[column] = source
+		}
+	}
+	if err := s.validateTemplates(values); err != nil {
+		return err
+	}
+
 	if err := s.db.Model(&model.SEOConfig{}).
 		Where("id = ?", configID).
 		Updates(updates).Error; err != nil {
@@ -133,24 +197,35 @@ func (s *ConfigService) ListSEOConfigs(configType model.SEOConfigType, page, pag
 
 // GenerateMetaTags 生成Meta标签
 func (s *ConfigService) GenerateMetaTags(configType model.SEOConfigType, targetID *uint, context map[string]interface{}) (map[string]string, error) {
-	metaTags := make(map[string]string)
+	return s.GeneratePageMetaTags(configType, targetID, NewTemplateData(context))
+}
 
-	// 获取SEO配置
+// GeneratePageMetaTags 使用模板数据生成Meta标签（模板可访问资源、文章、分类等实体字段）
+func (s *ConfigService) GeneratePageMetaTags(configType model.SEOConfigType, targetID *uint, data *TemplateData) (map[string]string, error) {
+	if data == nil {
+		data = NewTemplateData(nil)
+	}
+
+	// 获取SEO配置（没有针对具体对象的配置时使用该类型的通用配置）
 	config, err := s.GetSEOConfig(configType, targetID)
+	if err == nil && config == nil && targetID != nil {
+		config, err = s.GetSEOConfig(configType, nil)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("获取SEO配置失败: %w", err)
 	}
 
-	// 如果没有配置，返回默认值
+	// 以默认值为基础，配置中渲染结果非空的字段覆盖默认值
+	metaTags, _ := s.getDefaultMetaTags(configType, data.context)
 	if config == nil {
-		return s.getDefaultMetaTags(configType, context)
+		return metaTags, nil
 	}
 
 	// 解析模板
-	title := s.parseTemplate(config.MetaTitle, context)
-	description := s.parseTemplate(config.MetaDescription, context)
-	keywords := s.parseTemplate(config.MetaKeywords, context)
-	author := s.parseTemplate(config.MetaAuthor, context)
+	title := s.renderTemplate(config.MetaTitle, data)
+	description := s.renderTemplate(config.MetaDescription, data)
+	keywords := s.renderTemplate(config.MetaKeywords, data)
+	author := s.renderTemplate(config.MetaAuthor, data)
 
 	// 设置Meta标签
 	if title != "" {
@@ -174,11 +249,11 @@ func (s *ConfigService) GenerateMetaTags(configType model.SEOConfigType, targetI
 	}
 
 	// Open Graph标签
-	if config.OGTitle != "" {
-		metaTags["og:title"] = s.parseTemplate(config.OGTitle, context)
+	if rendered := s.renderTemplate(config.OGTitle, data); rendered != "" {
+		metaTags["og:title"] = rendered
 	}
-	if config.OGDescription != "" {
-		metaTags["og:description"] = s.parseTemplate(config.OGDescription, context)
+	if rendered := s.renderTemplate(config.OGDescription, data); rendered != "" {
+		metaTags["og:description"] = rendered
 	}
 	if config.OGImage != "" {
 		metaTags["og:image"] = config.OGImage
@@ -188,8 +263,8 @@ func (s *ConfigService) GenerateMetaTags(configType model.SEOConfigType, targetI
 	} else {
 		metaTags["og:type"] = "website"
 	}
-	if config.OGUrl != "" {
-		metaTags["og:url"] = s.parseTemplate(config.OGUrl, context)
+	if rendered := s.renderTemplate(config.OGUrl, data); rendered != "" {
+		metaTags["og:url"] = rendered
 	}
 
 	// Twitter Card标签
@@ -198,19 +273,19 @@ func (s *ConfigService) GenerateMetaTags(configType model.SEOConfigType, targetI
 	} else {
 		metaTags["twitter:card"] = "summary_large_image"
 	}
-	if config.TwitterTitle != "" {
-		metaTags["twitter:title"] = s.parseTemplate(config.TwitterTitle, context)
+	if rendered := s.renderTemplate(config.TwitterTitle, data); rendered != "" {
+		metaTags["twitter:title"] = rendered
 	}
-	if config.TwitterDescription != "" {
-		metaTags["twitter:description"] = s.parseTemplate(config.TwitterDescription, context)
+	if rendered := s.renderTemplate(config.TwitterDescription, data); rendered != "" {
+		metaTags["twitter:description"] = rendered
 	}
 	if config.TwitterImage != "" {
 		metaTags["twitter:image"] = config.TwitterImage
 	}
 
 	// 其他重要标签
-	if config.CanonicalURL != "" {
-		metaTags["canonical"] = s.parseTemplate(config.CanonicalURL, context)
+	if rendered := s.renderTemplate(config.CanonicalURL, data); rendered != "" {
+		metaTags["canonical"] = rendered
 	}
 	if config.Robots != "" {
 		metaTags["robots"] = config.Robots
@@ -219,24 +294,43 @@ func (s *ConfigService) GenerateMetaTags(configType model.SEOConfigType, targetI
 	return metaTags, nil
 }
 
-// parseTemplate 解析模板字符串
-func (s *ConfigService) parseTemplate(template string, context map[string]interface{}) string {
-	if template == "" {
+// renderTemplate 渲染模板字段（渲染失败时返回空字符串，由调用方使用默认值）
+func (s *ConfigService) renderTemplate(source string, data *TemplateData) string {
+	result, err := s.engine.Render(source, data, OutputText)
+	if err != nil {
 		return ""
 	}
+	return result
+}
 
-	// 简单的模板解析，支持 {{key}} 格式
-	result := template
-
-	// 这里可以实现更复杂的模板解析逻辑
-	// 目前先做简单的字符串替换
+// TemplatePreview 模板预览结果
+type TemplatePreview struct {
+	Text   string `json:"text"`            // 渲染后的纯文本
+	HTML   string `json:"html"`            // HTML转义后的结果
+	JSONLD string `json:"jsonld"`          // JSON-LD字符串转义后的结果
+	Error  string `json:"error,omitempty"` // 校验或渲染错误
+}
 
-	for key, value := range context {
-		placeholder := "{{" + key + "}}"
-		result = strings.ReplaceAll(result, placeholder, fmt.Sprintf("%v", value))
+// PreviewTemplates 使用实体数据预览多个模板（键为字段名）
+func (s *ConfigService) PreviewTemplates(templates map[string]string, data *TemplateData) map[string]TemplatePreview {
+	previews := make(map[string]TemplatePreview, len(templates))
+	for field, source := range templates {
+		if err := s.engine.Validate(source); err != nil {
+			previews[field] = TemplatePreview{Error: err.Error()}
+			continue
+		}
+		text, err := s.engine.Render(source, data, OutputText)
+		if err != nil {
+			previews[field] = TemplatePreview{Error: err.Error()}
+			continue
+		}
+		previews[field] = TemplatePreview{
+			Text:   text,
+			HTML:   Escape(text, OutputHTML),
+			JSONLD: Escape(text, OutputJSONLD),
+		}
 	}
-
-	return result
+	return previews
 }
 
 // getDefaultMetaTags 获取默认Meta标签
@@ -258,7 +352,31 @@ func (s *ConfigService) getDefaultMetaTags(configType model.SEOConfigType, conte
 		} else {
 			metaTags["title"] = "资源详情 - 资源分享网站"
 		}
-		metaTags["description"] = "下载优质资源，提升您的技能和效率"
+		if description, ok := context["description"].(string); ok && description != "" {
+			metaTags["description"] = description
+		} else {
+			metaTags["description"] = "下载优质资源，提升您的技能和效率"
+		}
+		if keywords, ok := context["keywords"].(string); ok && keywords != "" {
+			metaTags["keywords"] = keywords
+		}
+		metaTags["robots"] = "index, follow"
+
+	case model.SEOConfigTypeArticle:
+		if title, ok := context["title"].(string); ok {
+			metaTags["title"] = fmt.Sprintf("%s - 资源分享网站", title)
+		} else {
+			metaTags["title"] = "文章 - 资源分享网站"
+		}
+		if description, ok := context["description"].(string); ok && description != "" {
+			metaTags["description"] = description
+		}
+		if keywords, ok := context["keywords"].(string); ok && keywords != "" {
+			metaTags["keywords"] = keywords
+		}
+		if author, ok := context["author"].(string); ok && author != "" {
+			metaTags["author"] = author
+		}
 		metaTags["robots"] = "index, follow"
 
 	case model.SEOConfigTypeCategory:
@@ -267,6 +385,36 @@ func (s *ConfigService) getDefaultMetaTags(configType model.SEOConfigType, conte
 			metaTags["description"] = fmt.Sprintf("浏览 %s 分类下的优质资源", name)
 			metaTags["keywords"] = name + ",资源分类"
 		}
+		if description, ok := context["description"].(string); ok && description != "" {
+			metaTags["description"] = description
+		}
+		metaTags["robots"] = "index, follow"
+
+	case model.SEOConfigTypeSearch:
+		if query, ok := context["query"].(string); ok && query != "" {
+			metaTags["title"] = fmt.Sprintf("搜索: %s - 资源分享网站", query)
+			metaTags["description"] = fmt.Sprintf("与 %s 相关的资源搜索结果", query)
+		} else {
+			metaTags["title"] = "搜索资源 - 资源分享网站"
+			metaTags["description"] = "搜索站内的优质资源"
+		}
+		// 搜索结果页不收录，但允许跟踪其中的链接
+		metaTags["robots"] = "noindex, follow"
+
+	case model.SEOConfigTypeCollection:
+		if name, ok := context["name"].(string); ok {
+			metaTags["title"] = fmt.Sprintf("%s - 资源合集 - 资源分享网站", name)
+			metaTags["keywords"] = name + ",资源合集"
+		} else {
+			metaTags["title"] = "资源合集 - 资源分享网站"
+		}
+		if description, ok := context["description"].(string); ok && description != "" {
+			metaTags["description"] = description
+		} else if owner, ok := context["owner"].(string); ok {
+			metaTags["description"] = fmt.Sprintf("%s 整理的 %v 个优质资源", owner, context["count"])
+		} else {
+			metaTags["description"] = "用户整理的优质资源合集"
+		}
 		metaTags["robots"] = "index, follow"
 
 	default:
@@ -282,7 +430,7 @@ func (s *ConfigService) getDefaultMetaTags(configType model.SEOConfigType, conte
 	return metaTags, nil
 }
 
-// GenerateSitemap 生成Sitemap
+// GenerateSitemap 生成只包含手动维护URL的Sitemap（完整的分片Sitemap由 SitemapService 生成）
 func (s *ConfigService) GenerateSitemap(baseURL string) (string, error) {
 	var urls []model.SitemapUrl
 
@@ -292,37 +440,30 @@ func (s *ConfigService) GenerateSitemap(baseURL string) (string, error) {
 		return "", fmt.Errorf("查询Sitemap URL失败: %w", err)
 	}
 
-	var sitemap strings.Builder
-	sitemap.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
-	sitemap.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
-
+	entries := make([]sitemapURL, 0, len(urls))
 	for _, url := range urls {
 		loc := url.Loc
 		if !strings.HasPrefix(loc, "http") {
-			loc = baseURL + loc
+			loc = strings.TrimRight(baseURL, "/") + loc
 		}
 
-		sitemap.WriteString(`  <url>` + "\n")
-		sitemap.WriteString(fmt.Sprintf(`    <loc>%s</loc>`+"\n", loc))
-
-		if url.LastMod != nil {
-			sitemap.WriteString(fmt.Sprintf(`    <lastmod>%s</lastmod>`+"\n", url.LastMod.Format("2006-01-02")))
+		entry := sitemapURL{
+			Loc:        loc,
+			LastMod:    formatLastMod(url.LastMod),
+			ChangeFreq: url.ChangeFreq,
 		}
-
-		if url.ChangeFreq != "" {
-			sitemap.WriteString(fmt.Sprintf(`    <changefreq>%s</changefreq>`+"\n", url.ChangeFreq))
-		}
-
 		if url.Priority > 0 {
-			sitemap.WriteString(fmt.Sprintf(`    <priority>%.1f</priority>`+"\n", url.Priority))
+			entry.Priority = fmt.Sprintf("%.1f", url.Priority)
 		}
-
-		sitemap.WriteString(`  </url>` + "\n")
+		entries = append(entries, entry)
 	}
 
-	sitemap.WriteString(`</urlset>`)
+	sitemap, err := renderURLSet(entries)
+	if err != nil {
+		return "", err
+	}
 
-	return sitemap.String(), nil
+	return string(sitemap), nil
 }
 
 // AddSitemapUrl 添加Sitemap URL
@@ -397,21 +538,21 @@ func (s *ConfigService) GetSitemapUrls(pageType model.SEOConfigType, page, pageS
 func (s *ConfigService) AutoGenerateSitemap(baseURL string) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		// 1. 清空现有Sitemap URL
-		if err := tx.Exec("DELETE FROM sitemap_urls WHERE page_type IN ('resource', 'category')").Error; err != nil {
+		if err := tx.Exec("DELETE FROM sitemap_urls WHERE page_type IN ('resource', 'category', 'collection')").Error; err != nil {
 			return fmt.Errorf("清空Sitemap URL失败: %w", err)
 		}
 
 		// 2. 添加资源页面
 		var resources []model.Resource
 		if err := tx.Where("status = ?", "approved").
-			Select("id, title, updated_at").
+			Select("id, slug, title, updated_at").
 			Find(&resources).Error; err != nil {
 			return fmt.Errorf("查询资源失败: %w", err)
 		}
 
 		for _, resource := range resources {
 			url := model.SitemapUrl{
-				Loc:        fmt.Sprintf("/resource/%d", resource.ID),
+				Loc:        ResourcePath(resource.ID, resource.Slug),
 				LastMod:    &resource.UpdatedAt,
 				ChangeFreq: "weekly",
 				Priority:   0.8,
@@ -426,14 +567,14 @@ func (s *ConfigService) AutoGenerateSitemap(baseURL string) error {
 
 		// 3. 添加分类页面
 		var categories []model.Category
-		if err := tx.Select("id, name, updated_at").
+		if err := tx.Select("id, name, path, updated_at").
 			Find(&categories).Error; err != nil {
 			return fmt.Errorf("查询分类失败: %w", err)
 		}
 
 		for _, category := range categories {
 			url := model.SitemapUrl{
-				Loc:        fmt.Sprintf("/category/%d", category.ID),
+				Loc:        CategoryPath(&category),
 				LastMod:    &category.UpdatedAt,
 				ChangeFreq: "daily",
 				Priority:   0.9,
@@ -446,7 +587,30 @@ func (s *ConfigService) AutoGenerateSitemap(baseURL string) error {
 			}
 		}
 
-		// 4. 添加首页
+		// 4. 添加公开的合集页面
+		var collections []model.Collection
+		if err := tx.Select("id, updated_at").
+			Where("is_public = ? AND items_count > 0", true).
+			Find(&collections).Error; err != nil {
+			return fmt.Errorf("查询合集失败: %w", err)
+		}
+
+		for _, collection := range collections {
+			url := model.SitemapUrl{
+				Loc:        fmt.Sprintf("/collection/%d", collection.ID),
+				LastMod:    &collection.UpdatedAt,
+				ChangeFreq: "weekly",
+				Priority:   0.6,
+				PageType:   model.SEOConfigTypeCollection,
+				TargetID:   &collection.ID,
+				IsActive:   true,
+			}
+			if err := tx.Create(&url).Error; err != nil {
+				return fmt.Errorf("添加合集URL失败: %w", err)
+			}
+		}
+
+		// 5. 添加首页
 		now := time.Now()
 		homeURL := model.SitemapUrl{
 			Loc:        "/",
@@ -0,0 +1,609 @@
+/*
+SEO Indexing Service - 搜索引擎URL推送服务
+
+资源审核通过、文章发布或下线删除时，将URL推送给搜索引擎以加快收录：
+- IndexNow（Bing、Yandex等搜索引擎共用的推送协议）
+- 百度普通收录（链接提交API）
+
+URL先写入推送队列，由后台任务按批次推送，失败时按指数退避重试，
+并遵守各搜索引擎的每日配额。每个URL的推送结果都会记录为SEO事件。
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+Date: 2025-10-31
+*/
+
+package seo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrIndexingDisabled   = errors.New("未配置任何搜索引擎推送")
+	ErrInvalidIndexAction = errors.New("无效的推送类型，可选值：update、delete")
+	ErrInvalidIndexURL    = errors.New("只能推送本站URL")
+	ErrIndexFlushRunning  = errors.New("推送队列正在处理中")
+)
+
+// URL变化类型
+const (
+	IndexActionUpdate = "update" // 新增或更新
+	IndexActionDelete = "delete" // 删除或下线
+)
+
+// 推送目标
+const (
+	IndexEngineIndexNow = "indexnow"
+	IndexEngineBaidu    = "baidu"
+)
+
+// indexEngineNames 推送目标在事件中的显示名称
+var indexEngineNames = map[string]string{
+	IndexEngineIndexNow: "IndexNow",
+	IndexEngineBaidu:    "百度",
+}
+
+// URLPublisher URL推送接口，内容服务通过它在发布、下线时通知搜索引擎
+type URLPublisher interface {
+	PublishURLs(action string, paths ...string) error
+}
+
+// IndexFlushResult 一次推送队列处理的结果
+type IndexFlushResult struct {
+	Submitted int `json:"submitted"` // 推送成功的URL数
+	Retrying  int `json:"retrying"`  // 推送失败、等待重试的URL数
+	Failed    int `json:"failed"`    // 推送失败、不再重试的URL数
+}
+
+// indexOutcome 一批URL的推送结果
+type indexOutcome struct {
+	statusCode int
+	err        error
+	rejected   map[string]string // 被搜索引擎拒绝的URL及原因（不再重试）
+	retry      bool              // 失败时是否可以重试
+	retryAt    time.Time         // 指定的重试时间（如配额用尽时推迟到次日）
+	stop       bool              // 本轮不再继续推送该搜索引擎
+}
+
+// IndexingService 搜索引擎URL推送服务
+type IndexingService struct {
+	db      *gorm.DB
+	cfg     *config.IndexingConfig
+	siteURL string
+	client  *http.Client
+	running sync.Mutex
+}
+
+// NewIndexingService 创建搜索引擎URL推送服务
+func NewIndexingService(db *gorm.DB) *IndexingService {
+	cfg := config.DefaultIndexingConfig()
+	return &IndexingService{
+		db:     db,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.GetTimeout()},
+	}
+}
+
+// SetConfig 设置推送配置
+// 参数：
+//   - cfg: 推送配置
+//   - siteURL: 站点地址（用于补全URL和IndexNow的host）
+func (s *IndexingService) SetConfig(cfg *config.IndexingConfig, siteURL string) {
+	if cfg != nil {
+		s.cfg = cfg
+		s.client = &http.Client{Timeout: cfg.GetTimeout()}
+	}
+	s.siteURL = strings.TrimRight(siteURL, "/")
+}
+
+// Enabled 是否配置了至少一个推送目标
+func (s *IndexingService) Enabled() bool {
+	return len(s.engines()) > 0
+}
+
+// KeyFile 获取IndexNow密钥文件的路径和内容（未配置IndexNow时返回空）
+func (s *IndexingService) KeyFile() (path, key string) {
+	if s.cfg.IndexNowKey == "" {
+		return "", ""
+	}
+	return "/" + s.cfg.IndexNowKey + ".txt", s.cfg.IndexNowKey
+}
+
+// PublishURLs 将URL加入推送队列（未配置推送目标时直接忽略）
+// 参数：
+//   - action: URL变化类型 (update, delete)
+//   - paths: 站内路径或本站完整URL
+func (s *IndexingService) PublishURLs(action string, paths ...string) error {
+	if action != IndexActionUpdate && action != IndexActionDelete {
+		return ErrInvalidIndexAction
+	}
+	engines := s.engines()
+	if len(engines) == 0 {
+		return nil
+	}
+
+	urls := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		loc, err := s.absoluteURL(path)
+		if err != nil {
+			return err
+		}
+		if !seen[loc] {
+			seen[loc] = true
+			urls = append(urls, loc)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, engine := range engines {
+			// 同一URL尚未推送时只保留最新的变化类型
+			var pending []model.SEOIndexSubmission
+			if err := tx.Where("search_engine = ? AND status = ? AND url IN ?", engine, model.SEOIndexStatusPending, urls).
+				Find(&pending).Error; err != nil {
+				return fmt.Errorf("查询推送队列失败: %w", err)
+			}
+			queued := make(map[string]*model.SEOIndexSubmission, len(pending))
+			for i := range pending {
+				queued[pending[i].URL] = &pending[i]
+			}
+
+			for _, loc := range urls {
+				if item, ok := queued[loc]; ok {
+					if err := tx.Model(item).Updates(map[string]interface{}{
+						"action":          action,
+						"attempts":        0,
+						"next_attempt_at": now,
+						"last_error":      "",
+					}).Error; err != nil {
+						return fmt.Errorf("更新推送队列失败: %w", err)
+					}
+					continue
+				}
+				item := &model.SEOIndexSubmission{
+					SearchEngine:  engine,
+					Action:        action,
+					URL:           loc,
+					Status:        model.SEOIndexStatusPending,
+					NextAttemptAt: now,
+				}
+				if err := tx.Create(item).Error; err != nil {
+					return fmt.Errorf("写入推送队列失败: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Run 按配置的间隔定期处理推送队列，直到 ctx 结束（未配置推送目标时不启动）
+// 参数：
+//   - ctx: 控制任务退出的上下文
+func (s *IndexingService) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.GetInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.flushAndLog()
+		}
+	}
+}
+
+// Flush 立即处理推送队列中已到推送时间的URL
+func (s *IndexingService) Flush() (*IndexFlushResult, error) {
+	engines := s.engines()
+	if len(engines) == 0 {
+		return nil, ErrIndexingDisabled
+	}
+	if !s.running.TryLock() {
+		return nil, ErrIndexFlushRunning
+	}
+	defer s.running.Unlock()
+
+	result := &IndexFlushResult{}
+	for _, engine := range engines {
+		if err := s.flushEngine(engine, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// ListSubmissions 获取推送记录
+// 参数：
+//   - engine: 推送目标（为空时不过滤）
+//   - status: 推送状态（为空时不过滤）
+//   - page: 页码
+//   - pageSize: 每页数量
+func (s *IndexingService) ListSubmissions(engine, status string, page, pageSize int) ([]model.SEOIndexSubmission, int64, error) {
+	query := s.db.Model(&model.SEOIndexSubmission{})
+	if engine != "" {
+		query = query.Where("search_engine = ?", engine)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计推送记录失败: %w", err)
+	}
+
+	var items []model.SEOIndexSubmission
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&items).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询推送记录失败: %w", err)
+	}
+	return items, total, nil
+}
+
+// flushAndLog 处理推送队列并记录日志（供后台任务使用）
+func (s *IndexingService) flushAndLog() {
+	result, err := s.Flush()
+	if err != nil {
+		if !errors.Is(err, ErrIndexFlushRunning) {
+			log.Printf("处理URL推送队列失败: %v", err)
+		}
+		return
+	}
+	if result.Submitted+result.Retrying+result.Failed > 0 {
+		log.Printf("URL推送完成：成功 %d 个，待重试 %d 个，失败 %d 个", result.Submitted, result.Retrying, result.Failed)
+	}
+}
+
+// flushEngine 在每日配额内分批推送某个搜索引擎的队列
+func (s *IndexingService) flushEngine(engine string, result *IndexFlushResult) error {
+	remaining, err := s.remainingQuota(engine)
+	if err != nil {
+		return err
+	}
+
+	batchSize := s.cfg.GetBatchSize()
+	var lastID uint
+	for remaining > 0 {
+		limit := batchSize
+		if remaining < limit {
+			limit = remaining
+		}
+
+		var batch []model.SEOIndexSubmission
+		if err := s.db.Where("search_engine = ? AND status = ? AND next_attempt_at <= ? AND id > ?",
+			engine, model.SEOIndexStatusPending, time.Now(), lastID).
+			Order("id ASC").
+			Limit(limit).
+			Find(&batch).Error; err != nil {
+			return fmt.Errorf("查询推送队列失败: %w", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+
+		stop := false
+		for action, items := range groupByAction(batch) {
+			outcome := s.submit(engine, action, items)
+			if err := s.record(engine, items, outcome, result); err != nil {
+				return err
+			}
+			stop = stop || outcome.stop
+		}
+		if stop {
+			return nil
+		}
+
+		remaining -= len(batch)
+		if len(batch) < limit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// remainingQuota 今天还可以推送的URL数
+func (s *IndexingService) remainingQuota(engine string) (int, error) {
+	quota := s.cfg.IndexNowDailyQuota
+	if engine == IndexEngineBaidu {
+		quota = s.cfg.BaiduDailyQuota
+	}
+	if quota <= 0 {
+		return 0, nil
+	}
+
+	var used int64
+	if err := s.db.Model(&model.SEOIndexSubmission{}).
+		Where("search_engine = ? AND status = ? AND submitted_at >= ?",
+			engine, model.SEOIndexStatusSubmitted, truncateToDay(time.Now())).
+		Count(&used).Error; err != nil {
+		return 0, fmt.Errorf("统计今日推送数量失败: %w", err)
+	}
+	if int(used) >= quota {
+		return 0, nil
+	}
+	return quota - int(used), nil
+}
+
+// submit 推送一批变化类型相同的URL
+func (s *IndexingService) submit(engine, action string, items []model.SEOIndexSubmission) *indexOutcome {
+	urls := make([]string, len(items))
+	for i := range items {
+		urls[i] = items[i].URL
+	}
+	if engine == IndexEngineBaidu {
+		return s.submitBaidu(action, urls)
+	}
+	// IndexNow不区分变化类型，搜索引擎重新抓取后自行判断页面是否已删除
+	return s.submitIndexNow(urls)
+}
+
+// submitIndexNow 推送到IndexNow
+func (s *IndexingService) submitIndexNow(urls []string) *indexOutcome {
+	site, err := url.Parse(s.siteURL)
+	if err != nil || site.Host == "" {
+		return &indexOutcome{err: fmt.Errorf("站点地址无效: %s", s.siteURL), retry: true, stop: true}
+	}
+	keyPath, key := s.KeyFile()
+	payload, err := json.Marshal(map[string]interface{}{
+		"host":        site.Host,
+		"key":         key,
+		"keyLocation": s.siteURL + keyPath,
+		"urlList":     urls,
+	})
+	if err != nil {
+		return &indexOutcome{err: err}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.cfg.IndexNowEndpoint, bytes.NewReader(payload))
+	if err != nil {
+		return &indexOutcome{err: fmt.Errorf("推送地址无效: %w", err), retry: true, stop: true}
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &indexOutcome{err: fmt.Errorf("请求失败: %w", err), retry: true, stop: true}
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	outcome := &indexOutcome{statusCode: resp.StatusCode}
+	switch {
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusAccepted:
+		return outcome
+	case resp.StatusCode == http.StatusForbidden:
+		// 密钥文件尚未能被访问（如刚部署），稍后重试
+		outcome.err = errors.New("密钥验证失败")
+		outcome.retry = true
+		outcome.stop = true
+	case resp.StatusCode == http.StatusTooManyRequests:
+		outcome.err = errors.New("请求过于频繁")
+		outcome.retry = true
+		outcome.stop = true
+	case resp.StatusCode >= http.StatusInternalServerError:
+		outcome.err = fmt.Errorf("服务端错误: %s", responseMessage(resp.Status, body))
+		outcome.retry = true
+		outcome.stop = true
+	default:
+		// 400 请求格式错误、422 URL不属于该host等，重试也不会成功
+		outcome.err = fmt.Errorf("推送被拒绝: %s", responseMessage(resp.Status, body))
+	}
+	return outcome
+}
+
+// baiduResponse 百度链接提交接口的返回
+type baiduResponse struct {
+	Success     int      `json:"success"`
+	Remain      *int     `json:"remain"`
+	NotSameSite []string `json:"not_same_site"`
+	NotValid    []string `json:"not_valid"`
+	Error       int      `json:"error"`
+	Message     string   `json:"message"`
+}
+
+// submitBaidu 推送到百度（删除的URL提交到死链接口）
+func (s *IndexingService) submitBaidu(action string, urls []string) *indexOutcome {
+	api := "urls"
+	if action == IndexActionDelete {
+		api = "del"
+	}
+	query := url.Values{}
+	query.Set("site", s.siteURL)
+	query.Set("token", s.cfg.BaiduToken)
+	endpoint := strings.TrimRight(s.cfg.BaiduEndpoint, "/") + "/" + api + "?" + query.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(strings.Join(urls, "\n")))
+	if err != nil {
+		return &indexOutcome{err: fmt.Errorf("推送地址无效: %w", err), retry: true, stop: true}
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &indexOutcome{err: fmt.Errorf("请求失败: %w", err), retry: true, stop: true}
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	outcome := &indexOutcome{statusCode: resp.StatusCode}
+	var parsed baiduResponse
+	_ = json.Unmarshal(body, &parsed)
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		outcome.rejected = make(map[string]string)
+		for _, loc := range parsed.NotSameSite {
+			outcome.rejected[loc] = "不是本站URL"
+		}
+		for _, loc := range parsed.NotValid {
+			outcome.rejected[loc] = "不合法的URL"
+		}
+		// 配额已用完时本轮不再推送
+		outcome.stop = parsed.Remain != nil && *parsed.Remain <= 0
+		return outcome
+	case strings.Contains(strings.ToLower(parsed.Message), "over quota"):
+		// 当天配额已用完，推迟到次日
+		outcome.err = errors.New("超过每日配额")
+		outcome.retry = true
+		outcome.retryAt = truncateToDay(time.Now()).AddDate(0, 0, 1)
+		outcome.stop = true
+	case resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= http.StatusInternalServerError:
+		// 401 token校验失败（如站点验证未生效）、限流和服务端错误可以重试
+		outcome.err = fmt.Errorf("推送失败: %s", responseMessage(resp.Status, []byte(parsed.Message)))
+		outcome.retry = true
+		outcome.stop = true
+	default:
+		outcome.err = fmt.Errorf("推送被拒绝: %s", responseMessage(resp.Status, []byte(parsed.Message)))
+	}
+	return outcome
+}
+
+// record 保存一批URL的推送结果并记录SEO事件
+func (s *IndexingService) record(engine string, items []model.SEOIndexSubmission, outcome *indexOutcome, result *IndexFlushResult) error {
+	now := time.Now()
+	maxAttempts := s.cfg.GetMaxAttempts()
+	name := indexEngineNames[engine]
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range items {
+			item := &items[i]
+			item.Attempts++
+
+			var errMessage, eventName string
+			switch reason, rejected := outcome.rejected[item.URL]; {
+			case outcome.err == nil && !rejected:
+				item.Status = model.SEOIndexStatusSubmitted
+				item.SubmittedAt = &now
+				item.LastError = ""
+				eventName = name + "推送成功"
+				result.Submitted++
+			case rejected:
+				errMessage = reason
+				item.Status = model.SEOIndexStatusFailed
+				eventName = name + "推送失败"
+				result.Failed++
+			case outcome.retry && item.Attempts < maxAttempts:
+				errMessage = outcome.err.Error()
+				item.NextAttemptAt = now.Add(s.cfg.GetRetryBackoff(item.Attempts))
+				if !outcome.retryAt.IsZero() {
+					item.NextAttemptAt = outcome.retryAt
+				}
+				eventName = name + "推送失败，等待重试"
+				result.Retrying++
+			default:
+				errMessage = outcome.err.Error()
+				item.Status = model.SEOIndexStatusFailed
+				eventName = name + "推送失败"
+				result.Failed++
+			}
+			if errMessage != "" {
+				item.LastError = limitRunes(errMessage, 500)
+			}
+
+			if err := tx.Model(item).Select("status", "attempts", "next_attempt_at", "submitted_at", "last_error").
+				Updates(item).Error; err != nil {
+				return fmt.Errorf("更新推送队列失败: %w", err)
+			}
+
+			details, _ := json.Marshal(map[string]interface{}{
+				"submission_id": item.ID,
+				"action":        item.Action,
+				"attempts":      item.Attempts,
+				"http_status":   outcome.statusCode,
+				"error":         errMessage,
+			})
+			event := &model.SEOEvent{
+				EventType: model.SEOEventTypeIndex,
+				EventName: eventName,
+				PageURL:   limitRunes(item.URL, 500),
+				OldValue:  item.Action,
+				NewValue:  string(item.Status),
+				Details:   string(details),
+				Source:    engine,
+			}
+			if err := tx.Create(event).Error; err != nil {
+				return fmt.Errorf("记录推送事件失败: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// engines 已配置的推送目标
+func (s *IndexingService) engines() []string {
+	var engines []string
+	if s.cfg.IndexNowKey != "" && s.cfg.IndexNowEndpoint != "" {
+		engines = append(engines, IndexEngineIndexNow)
+	}
+	if s.cfg.BaiduToken != "" && s.cfg.BaiduEndpoint != "" {
+		engines = append(engines, IndexEngineBaidu)
+	}
+	return engines
+}
+
+// absoluteURL 站内路径补全为完整URL（完整URL必须属于本站）
+func (s *IndexingService) absoluteURL(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		if s.siteURL == "" || (path != s.siteURL && !strings.HasPrefix(path, s.siteURL+"/")) {
+			return "", ErrInvalidIndexURL
+		}
+		return path, nil
+	}
+	if path == "" || strings.HasPrefix(path, "//") {
+		return "", ErrInvalidIndexURL
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return s.siteURL + path, nil
+}
+
+// groupByAction 按变化类型分组（保持队列顺序）
+func groupByAction(items []model.SEOIndexSubmission) map[string][]model.SEOIndexSubmission {
+	groups := make(map[string][]model.SEOIndexSubmission)
+	for _, item := range items {
+		groups[item.Action] = append(groups[item.Action], item)
+	}
+	return groups
+}
+
+// responseMessage 组合HTTP状态和返回内容作为错误描述
+func responseMessage(status string, body []byte) string {
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return status
+	}
+	return status + " " + limitRunes(message, 200)
+}
@@ -15,7 +15,10 @@ Date: 2025-10-31
 package seo
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -25,6 +28,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// Errors 定义自定义错误
+var (
+	ErrKeywordNotFound = errors.New("关键词不存在")
+	ErrNoRankData      = errors.New("没有找到排名数据")
+)
+
 // ManagementService SEO管理服务
 type ManagementService struct {
 	db *gorm.DB
@@ -87,7 +96,7 @@ func (s *ManagementService) GetKeyword(keywordID uint) (*model.SEOKeyword, error
 	var keyword model.SEOKeyword
 	if err := s.db.First(&keyword, keywordID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("关键词不存在")
+			return nil, ErrKeywordNotFound
 		}
 		return nil, fmt.Errorf("查询关键词失败: %w", err)
 	}
@@ -158,6 +167,12 @@ func (s *ManagementService) TrackKeywordRank(rank *model.SEORank) error {
 	if rank.KeywordID == 0 || rank.SearchEngine == "" || rank.URL == "" {
 		return fmt.Errorf("关键词ID、搜索引擎和URL不能为空")
 	}
+	if rank.RecordedOn.IsZero() {
+		rank.RecordedOn = truncateToDay(time.Now())
+	}
+	if rank.Position == 0 {
+		rank.Position = float64(rank.Rank)
+	}
 
 	if err := s.db.Create(rank).Error; err != nil {
 		return fmt.Errorf("记录关键词排名失败: %w", err)
@@ -221,95 +236,164 @@ func (s *ManagementService) GetCurrentRank(keywordID uint, searchEngine string)
 }
 
 // AnalyzeKeywordPerformance 分析关键词表现
-func (s *ManagementService) AnalyzeKeywordPerformance(keywordID uint, days int) (map[string]interface{}, error) {
+// 按数据日期汇总排名、点击量和展现量（同一天多个页面的排名按展现量加权），
+// searchEngine 为空时汇总所有搜索引擎
+func (s *ManagementService) AnalyzeKeywordPerformance(keywordID uint, searchEngine string, days int) (map[string]interface{}, error) {
 	if days <= 0 {
 		days = 30 // 默认30天
 	}
+	since := time.Now().AddDate(0, 0, -days)
 
-	analysis := make(map[string]interface{})
-
-	// 获取排名历史
+	// 获取排名历史（手工记录的旧数据没有数据日期，使用记录时间）
 	var ranks []model.SEORank
-	s.db.Where("keyword_id = ? AND created_at >= ?", keywordID, time.Now().AddDate(0, 0, -days)).
-		Order("created_at ASC").
-		Find(&ranks)
-
-	if len(ranks) == 0 {
-		return nil, fmt.Errorf("没有找到排名数据")
+	query := s.db.Where("keyword_id = ?", keywordID).
+		Where("recorded_on >= ? OR (recorded_on IS NULL AND created_at >= ?)", since, since)
+	if searchEngine != "" {
+		query = query.Where("search_engine = ?", searchEngine)
 	}
+	if err := query.Find(&ranks).Error; err != nil {
+		return nil, fmt.Errorf("查询排名数据失败: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, ErrNoRankData
+	}
+
+	// 按日期汇总
+	type dailyStats struct {
+		Date        string  `json:"date"`
+		Position    float64 `json:"position"`
+		Clicks      int     `json:"clicks"`
+		Impressions int     `json:"impressions"`
+		weightSum   float64
+	}
+	byDate := make(map[string]*dailyStats)
+	engines := make(map[string]int)
+	totalClicks, totalImpressions := 0, 0
+	bestRank, worstRank := 0, 0
+	for _, rank := range ranks {
+		date := rank.RecordedOn
+		if date.IsZero() {
+			date = rank.CreatedAt
+		}
+		key := date.Format("2006-01-02")
+		stats, ok := byDate[key]
+		if !ok {
+			stats = &dailyStats{Date: key}
+			byDate[key] = stats
+		}
 
-	// 计算统计信息
-	var totalRanks, avgRank, bestRank, worstRank int
-	var rankChanges []int
-
-	for i, rank := range ranks {
-		totalRanks += rank.Rank
-		avgRank = totalRanks / (i + 1)
-
-		if i == 0 {
+		position := rank.Position
+		if position == 0 {
+			position = float64(rank.Rank)
+		}
+		weight := float64(rank.Impressions)
+		if weight <= 0 {
+			weight = 1
+		}
+		stats.Position += position * weight
+		stats.weightSum += weight
+		stats.Clicks += rank.Clicks
+		stats.Impressions += rank.Impressions
+
+		engines[rank.SearchEngine]++
+		totalClicks += rank.Clicks
+		totalImpressions += rank.Impressions
+		if bestRank == 0 || rank.Rank < bestRank {
 			bestRank = rank.Rank
+		}
+		if rank.Rank > worstRank {
 			worstRank = rank.Rank
-		} else {
-			if rank.Rank < bestRank {
-				bestRank = rank.Rank
-			}
-			if rank.Rank > worstRank {
-				worstRank = rank.Rank
-			}
-
-			// 计算排名变化
-			prevRank := ranks[i-1].Rank
-			change := prevRank - rank.Rank // 正数表示排名提升
-			rankChanges = append(rankChanges, change)
 		}
 	}
 
-	// 计算趋势
-	var trend string
-	if len(rankChanges) > 0 {
-		avgChange := 0
-		for _, change := range rankChanges {
-			avgChange += change
+	daily := make([]*dailyStats, 0, len(byDate))
+	for _, stats := range byDate {
+		stats.Position = math.Round(stats.Position/stats.weightSum*10) / 10
+		daily = append(daily, stats)
+	}
+	sort.Slice(daily, func(i, j int) bool { return daily[i].Date < daily[j].Date })
+
+	// 计算平均排名和每日排名变化（正数表示排名提升）
+	var positionSum float64
+	var rankChanges []float64
+	for i, stats := range daily {
+		positionSum += stats.Position
+		if i > 0 {
+			rankChanges = append(rankChanges, math.Round((daily[i-1].Position-stats.Position)*10)/10)
 		}
-		avgChange = avgChange / len(rankChanges)
+	}
 
-		if avgChange > 0 {
+	// 计算趋势（比较首末两天）
+	trend := "稳定"
+	if len(daily) > 1 {
+		if change := daily[0].Position - daily[len(daily)-1].Position; change > 0 {
 			trend = "上升"
-		} else if avgChange < 0 {
+		} else if change < 0 {
 			trend = "下降"
-		} else {
-			trend = "稳定"
 		}
 	}
 
-	analysis["total_records"] = len(ranks)
-	analysis["average_rank"] = avgRank
-	analysis["best_rank"] = bestRank
-	analysis["worst_rank"] = worstRank
-	analysis["trend"] = trend
-	analysis["rank_changes"] = rankChanges
-	analysis["period_days"] = days
+	ctr := 0.0
+	if totalImpressions > 0 {
+		ctr = math.Round(float64(totalClicks)/float64(totalImpressions)*10000) / 100
+	}
+
+	return map[string]interface{}{
+		"total_records":     len(ranks),
+		"average_rank":      math.Round(positionSum/float64(len(daily))*10) / 10,
+		"current_rank":      daily[len(daily)-1].Position,
+		"best_rank":         bestRank,
+		"worst_rank":        worstRank,
+		"trend":             trend,
+		"rank_changes":      rankChanges,
+		"period_days":       days,
+		"total_clicks":      totalClicks,
+		"total_impressions": totalImpressions,
+		"ctr":               ctr,
+		"search_engines":    engines,
+		"daily":             daily,
+	}, nil
+}
 
-	return analysis, nil
+// ListSEOEvents 获取SEO事件（如导入排名时产生的排名变化）
+func (s *ManagementService) ListSEOEvents(eventType string, keywordID uint, page, pageSize int) ([]model.SEOEvent, int64, error) {
+	query := s.db.Model(&model.SEOEvent{})
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if keywordID > 0 {
+		query = query.Where("keyword_id = ?", keywordID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取SEO事件总数失败: %w", err)
+	}
+
+	var events []model.SEOEvent
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取SEO事件失败: %w", err)
+	}
+	return events, total, nil
 }
 
 // SEOReporting SEO报告
 
 // GenerateSEOReport 生成SEO报告
+// 页面数、得分和建议来自最近一次站点审计，排名数据来自排名记录；
+// 流量类指标没有数据来源，保持为零
 func (s *ManagementService) GenerateSEOReport(period string) (*model.SEOReport, error) {
 	report := &model.SEOReport{
 		ReportType: period,
 		Period:     time.Now().Format("2006-01"),
 	}
 
-	// 获取统计数据
-	// 总页面数
-	var totalPages int64
-	s.db.Model(&model.SEOConfig{}).Where("is_active = ?", true).Count(&totalPages)
-	report.TotalPages = int(totalPages)
-
-	// 已索引页面数（简化处理，这里假设所有激活的SEO配置对应的页面都被索引）
-	report.IndexedPages = report.TotalPages
+	audit, err := latestAuditReport(s.db)
+	if err != nil {
+		return nil, err
+	}
 
 	// 总关键词数
 	var totalKeywords int64
@@ -319,74 +403,44 @@ func (s *ManagementService) GenerateSEOReport(period string) (*model.SEOReport,
 	// 平均排名
 	var avgRank float64
 	var rankCount int64
-	s.db.Model(&model.SEORank{}).
-		Select("AVG(rank)").Scan(&avgRank)
 	s.db.Model(&model.SEORank{}).Count(&rankCount)
-
 	if rankCount > 0 {
+		s.db.Model(&model.SEORank{}).Select("AVG(rank)").Scan(&avgRank)
 		report.AvgRank = avgRank
 	}
 
-	// SEO得分计算（简化版）
-	seoScore := 0
-	if report.IndexedPages > 0 {
-		seoScore += 30 // 基础分
-	}
-	if report.TotalKeywords > 0 {
-		seoScore += 25
-	}
-	if report.AvgRank > 0 && report.AvgRank <= 10 {
-		seoScore += 20
+	// 生成报告数据和建议
+	metrics := map[string]interface{}{
+		"rank_records": rankCount,
 	}
-	if report.AvgRank > 0 && report.AvgRank <= 20 {
-		seoScore += 15
-	}
-	if report.AvgRank > 0 && report.AvgRank <= 50 {
-		seoScore += 10
-	}
-
-	report.SEOScore = seoScore
-
-	// 设置默认值
-	if report.OrganicTraffic == 0 {
-		report.OrganicTraffic = 1000
-	}
-	if report.ClickThroughRate == 0 {
-		report.ClickThroughRate = 2.5
-	}
-	if report.BounceRate == 0 {
-		report.BounceRate = 45.0
-	}
-	if report.AvgSessionDuration == 0 {
-		report.AvgSessionDuration = 180.0
-	}
-
-	// 生成报告数据
 	reportData := map[string]interface{}{
 		"generated_at": time.Now(),
-		"metrics": map[string]interface{}{
-			"indexed_ratio":    float64(report.IndexedPages) / float64(report.TotalPages) * 100,
-			"keyword_coverage": float64(report.IndexedPages) / float64(report.TotalKeywords) * 100,
-		},
+		"metrics":      metrics,
 	}
-
-	report.ReportData = fmt.Sprintf("%v", reportData)
-
-	// 生成建议
 	var recommendations []string
-	if report.SEOScore < 60 {
-		recommendations = append(recommendations, "SEO得分较低，建议优化页面标题和描述")
+
+	if audit == nil {
+		recommendations = append(recommendations, "尚无站点审计结果，请先执行站点审计以获得页面得分和优化建议")
+	} else {
+		report.TotalPages = audit.TotalPages
+		report.IndexedPages = audit.IndexedPages
+		report.SEOScore = audit.SEOScore
+		reportData["audit_report_id"] = audit.ID
+		reportData["audit_period"] = audit.Period
+		if audit.TotalPages > 0 {
+			metrics["indexable_ratio"] = float64(audit.IndexedPages) / float64(audit.TotalPages) * 100
+		}
+		if audit.Recommendations != "" {
+			recommendations = append(recommendations, strings.Split(audit.Recommendations, "\n")...)
+		}
 	}
 	if report.AvgRank > 20 {
 		recommendations = append(recommendations, "平均排名较靠后，建议优化关键词密度和内容质量")
 	}
-	if report.BounceRate > 60 {
-		recommendations = append(recommendations, "跳出率较高，建议改善页面加载速度和用户体验")
-	}
-	if report.ClickThroughRate < 2.0 {
-		recommendations = append(recommendations, "点击率较低，建议优化标题和描述以提高吸引力")
-	}
 
+	if data, err := json.Marshal(reportData); err == nil {
+		report.ReportData = string(data)
+	}
 	report.Recommendations = strings.Join(recommendations, "\n")
 
 	// 保存报告
@@ -11,10 +11,14 @@ Date: 2025-10-31
 package seo
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"resource-share-site/internal/config"
 	"resource-share-site/internal/model"
 
 	"github.com/gin-gonic/gin"
@@ -22,44 +26,58 @@ import (
 
 // SEOContext SEO上下文
 type SEOContext struct {
-	PageType    model.SEOConfigType
-	TargetID    *uint
-	Title       string
-	Name        string
-	Category    string
-	Resource    *model.Resource
-	CategoryObj *model.Category
-	CustomData  map[string]interface{}
+	PageType     model.SEOConfigType
+	TargetID     *uint
+	Title        string
+	Name         string
+	Category     string
+	Description  string
+	Keywords     string
+	Image        string // 分享图片（相对路径会补全为站点地址）
+	Query        string // 搜索关键词
+	Path         string // 规范URL的路径部分（为空时使用请求路径）
+	Resource     *model.Resource
+	Article      *model.Article
+	Collection   *model.Collection
+	CategoryObj  *model.Category
+	CategoryPath []*model.Category // 从根分类到当前分类的路径（用于面包屑）
+	SiteName     string
+	SiteURL      string
+	CustomData   map[string]interface{}
 }
 
 // Middleware SEO中间件
 type Middleware struct {
 	configService *ConfigService
+	cfg           *config.SEOConfig
 }
 
 // NewMiddleware 创建SEO中间件
 func NewMiddleware(configService *ConfigService) *Middleware {
 	return &Middleware{
 		configService: configService,
+		cfg:           config.DefaultSEOConfig(),
 	}
 }
 
-// SEOMiddleware SEO中间件处理函数
+// SetConfig 设置搜索引擎优化配置（站点地址和名称）
+func (m *Middleware) SetConfig(cfg *config.SEOConfig) {
+	if cfg != nil {
+		m.cfg = cfg
+	}
+}
+
+// SEOMiddleware SEO中间件处理函数（按路径生成默认的页面SEO数据，页面处理器可用 SetPageSEO 覆盖）
 func (m *Middleware) SEOMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 解析SEO上下文
 		seoCtx := m.parseSEOContext(c)
 
 		// 生成Meta标签
-		metaTags, err := m.configService.GenerateMetaTags(
-			seoCtx.PageType,
-			seoCtx.TargetID,
-			m.buildContextData(seoCtx),
-		)
-
-		if err == nil && len(metaTags) > 0 {
+		if page := m.buildPageSEO(seoCtx); len(page.Meta) > 0 {
 			// 将Meta标签存储到上下文
-			c.Set("seo_meta_tags", metaTags)
+			c.Set("seo_meta_tags", page.Meta)
+			c.Set(pageSEOKey, page)
 		}
 
 		c.Next()
@@ -70,6 +88,9 @@ func (m *Middleware) SEOMiddleware() gin.HandlerFunc {
 func (m *Middleware) parseSEOContext(c *gin.Context) *SEOContext {
 	path := c.Request.URL.Path
 	seoCtx := &SEOContext{
+		Path:       path,
+		SiteName:   m.cfg.SiteName,
+		SiteURL:    m.cfg.GetBaseURL(),
 		CustomData: make(map[string]interface{}),
 	}
 
@@ -85,12 +106,35 @@ func (m *Middleware) parseSEOContext(c *gin.Context) *SEOContext {
 			// 可以在这里加载资源对象
 		}
 
+	case strings.HasPrefix(path, "/r/"):
+		seoCtx.PageType = model.SEOConfigTypeResource
+		if id, _, ok := ParseResourceRef(strings.TrimPrefix(path, "/r/")); ok {
+			seoCtx.TargetID = &id
+		}
+
+	case strings.HasPrefix(path, "/c/"):
+		seoCtx.PageType = model.SEOConfigTypeCategory
+
 	case strings.HasPrefix(path, "/category/"):
 		seoCtx.PageType = model.SEOConfigTypeCategory
 		if id := m.extractIDFromPath(path, 2); id != nil {
 			seoCtx.TargetID = id
 		}
 
+	case strings.HasPrefix(path, "/collection/"):
+		seoCtx.PageType = model.SEOConfigTypeCollection
+		if id := m.extractIDFromPath(path, 2); id != nil {
+			seoCtx.TargetID = id
+		}
+
+	case strings.HasPrefix(path, "/article/"):
+		seoCtx.PageType = model.SEOConfigTypeArticle
+
+	case path == "/search":
+		seoCtx.PageType = model.SEOConfigTypeSearch
+		seoCtx.Query = c.Query("q")
+		seoCtx.Path = "/search?q=" + url.QueryEscape(seoCtx.Query)
+
 	case strings.HasPrefix(path, "/list"):
 		seoCtx.PageType = model.SEOConfigTypeList
 
@@ -145,6 +189,18 @@ func (m *Middleware) buildContextData(seoCtx *SEOContext) map[string]interface{}
 	if seoCtx.Category != "" {
 		data["category"] = seoCtx.Category
 	}
+	if seoCtx.Description != "" {
+		data["description"] = seoCtx.Description
+	}
+	if seoCtx.Keywords != "" {
+		data["keywords"] = seoCtx.Keywords
+	}
+	if seoCtx.Query != "" {
+		data["query"] = seoCtx.Query
+	}
+	if seoCtx.Article != nil && seoCtx.Article.Author != nil {
+		data["author"] = seoCtx.Article.Author.Username
+	}
 
 	// 目标ID
 	if seoCtx.TargetID != nil {
@@ -157,12 +213,19 @@ func (m *Middleware) buildContextData(seoCtx *SEOContext) map[string]interface{}
 		data["page_suffix"] = fmt.Sprintf(" - 第%d页", page)
 	}
 
+	// 页面自定义数据（不覆盖上面的字段）
+	for key, value := range seoCtx.CustomData {
+		if _, exists := data[key]; !exists {
+			data[key] = value
+		}
+	}
+
 	// 站点信息
-	data["site_name"] = "资源分享网站"
-	data["site_url"] = "https://example.com"
+	data["site_name"] = seoCtx.SiteName
+	data["site_url"] = seoCtx.SiteURL
 
 	// 时间信息
-	data["current_year"] = "2025"
+	data["current_year"] = strconv.Itoa(time.Now().Year())
 
 	return data
 }
@@ -189,6 +252,13 @@ func RenderMetaTags(metaTags map[string]string) string {
 		return ""
 	}
 
+	// 标签内容可能来自用户数据或SEO模板，输出前统一做HTML转义
+	escaped := make(map[string]string, len(metaTags))
+	for key, value := range metaTags {
+		escaped[key] = Escape(value, OutputHTML)
+	}
+	metaTags = escaped
+
 	var builder strings.Builder
 
 	// 渲染title
@@ -235,53 +305,140 @@ func RenderMetaTags(metaTags map[string]string) string {
 	return builder.String()
 }
 
-// GenerateJSONLD 生成JSON-LD结构化数据
+// GenerateJSONLD 生成JSON-LD结构化数据（多个对象时输出为 @graph）
 func GenerateJSONLD(seoCtx *SEOContext) string {
-	var jsonld strings.Builder
+	siteName := seoCtx.SiteName
+	if siteName == "" {
+		siteName = "资源分享网站"
+	}
+	siteURL := strings.TrimRight(seoCtx.SiteURL, "/")
+	pageURL := siteURL + seoCtx.Path
 
+	var nodes []map[string]interface{}
 	switch seoCtx.PageType {
 	case model.SEOConfigTypeHome:
-		jsonld.WriteString(`{
-			"@context": "https://schema.org",
-			"@type": "WebSite",
-			"name": "资源分享网站",
-			"url": "https://example.com",
+		nodes = append(nodes, map[string]interface{}{
+			"@type":       "WebSite",
+			"name":        siteName,
+			"url":         siteURL + "/",
 			"description": "优质资源一站式分享平台",
-			"potentialAction": {
-				"@type": "SearchAction",
-				"target": "https://example.com/search?q={search_term_string}",
-				"query-input": "required name=search_term_string"
-			}
-		}`)
+			"potentialAction": map[string]interface{}{
+				"@type":       "SearchAction",
+				"target":      siteURL + "/search?q={search_term_string}",
+				"query-input": "required name=search_term_string",
+			},
+		})
 
 	case model.SEOConfigTypeResource:
-		if seoCtx.Resource != nil {
-			jsonld.WriteString(fmt.Sprintf(`{
-				"@context": "https://schema.org",
-				"@type": "CreativeWork",
-				"name": "%s",
-				"description": "%s",
-				"author": {
-					"@type": "Person",
-					"name": "资源分享网站"
-				},
-				"datePublished": "%s"
-			}`, seoCtx.Resource.Title, seoCtx.Resource.Description, seoCtx.Resource.CreatedAt.Format("2006-01-02")))
+		if resource := seoCtx.Resource; resource != nil {
+			node := map[string]interface{}{
+				"@type":        "CreativeWork",
+				"name":         resource.Title,
+				"description":  seoCtx.Description,
+				"url":          pageURL,
+				"dateCreated":  resource.CreatedAt.Format(time.RFC3339),
+				"dateModified": resource.UpdatedAt.Format(time.RFC3339),
+			}
+			if resource.UploadedBy != nil {
+				node["author"] = map[string]interface{}{"@type": "Person", "name": resource.UploadedBy.Username}
+			}
+			if resource.Category != nil {
+				node["genre"] = resource.Category.Name
+			}
+			if seoCtx.Keywords != "" {
+				node["keywords"] = seoCtx.Keywords
+			}
+			if resource.RatingCount > 0 {
+				node["aggregateRating"] = map[string]interface{}{
+					"@type":       "AggregateRating",
+					"ratingValue": fmt.Sprintf("%.1f", resource.RatingAverage),
+					"ratingCount": resource.RatingCount,
+					"bestRating":  5,
+					"worstRating": 1,
+				}
+			}
+			nodes = append(nodes, node)
+		}
+
+	case model.SEOConfigTypeArticle:
+		if article := seoCtx.Article; article != nil {
+			node := map[string]interface{}{
+				"@type":            "Article",
+				"headline":         article.Title,
+				"description":      seoCtx.Description,
+				"url":              pageURL,
+				"mainEntityOfPage": pageURL,
+				"dateModified":     article.UpdatedAt.Format(time.RFC3339),
+				"publisher":        map[string]interface{}{"@type": "Organization", "name": siteName, "url": siteURL + "/"},
+			}
+			published := article.CreatedAt
+			if article.PublishedAt != nil {
+				published = *article.PublishedAt
+			}
+			node["datePublished"] = published.Format(time.RFC3339)
+			if article.Author != nil {
+				node["author"] = map[string]interface{}{"@type": "Person", "name": article.Author.Username}
+			}
+			if seoCtx.Image != "" {
+				node["image"] = absoluteURL(siteURL, seoCtx.Image)
+			}
+			if seoCtx.Keywords != "" {
+				node["keywords"] = seoCtx.Keywords
+			}
+			nodes = append(nodes, node)
 		}
 
 	case model.SEOConfigTypeCategory:
 		if seoCtx.CategoryObj != nil {
-			jsonld.WriteString(fmt.Sprintf(`{
-				"@context": "https://schema.org",
-				"@type": "CollectionPage",
-				"name": "%s",
-				"description": "%s",
-				"url": "https://example.com/category/%d"
-			}`, seoCtx.CategoryObj.Name, seoCtx.CategoryObj.Description, *seoCtx.TargetID))
+			nodes = append(nodes, map[string]interface{}{
+				"@type":       "CollectionPage",
+				"name":        seoCtx.CategoryObj.Name,
+				"description": seoCtx.Description,
+				"url":         pageURL,
+			})
 		}
+
+	case model.SEOConfigTypeSearch:
+		nodes = append(nodes, map[string]interface{}{
+			"@type": "SearchResultsPage",
+			"name":  "搜索: " + seoCtx.Query,
+			"url":   pageURL,
+		})
+	}
+
+	if breadcrumbs := Breadcrumbs(seoCtx); len(breadcrumbs) > 1 {
+		items := make([]map[string]interface{}, 0, len(breadcrumbs))
+		for i, crumb := range breadcrumbs {
+			items = append(items, map[string]interface{}{
+				"@type":    "ListItem",
+				"position": i + 1,
+				"name":     crumb.Name,
+				"item":     siteURL + crumb.Path,
+			})
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"@type":           "BreadcrumbList",
+			"itemListElement": items,
+		})
 	}
 
-	return jsonld.String()
+	var doc map[string]interface{}
+	switch len(nodes) {
+	case 0:
+		return ""
+	case 1:
+		doc = nodes[0]
+	default:
+		doc = map[string]interface{}{"@graph": nodes}
+	}
+	doc["@context"] = "https://schema.org"
+
+	// json.Marshal 会转义 <、> 和 &，内容可以直接放入 <script> 标签
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return ""
+	}
+	return string(data)
 }
 
 // SEOResponse SEO响应结构
@@ -323,18 +480,6 @@ func (m *Middleware) GenerateSEOResponse(c *gin.Context) *SEOResponse {
 	return response
 }
 
-// 设置页面SEO信息
-func (m *Middleware) SetPageSEO(c *gin.Context, pageType model.SEOConfigType, targetID *uint, customData map[string]interface{}) {
-	// 生成Meta标签
-	metaTags, err := m.configService.GenerateMetaTags(pageType, targetID, customData)
-	if err != nil {
-		return
-	}
-
-	// 存储到上下文
-	c.Set("seo_meta_tags", metaTags)
-}
-
 // 批量设置SEO
 func (m *Middleware) BulkSetSEO(c *gin.Context, configs []struct {
 	PageType model.SEOConfigType
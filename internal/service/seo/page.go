@@ -0,0 +1,359 @@
+/*
+SEO Page - 页面SEO数据
+
+为服务端渲染的页面生成<head>所需的数据：
+- 基于SEO配置生成的title/description/keywords等Meta标签
+- 补全为绝对地址的canonical、Open Graph和Twitter Card标签
+- JSON-LD结构化数据（含面包屑导航）
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+Date: 2025-10-31
+*/
+
+package seo
+
+import (
+	"encoding/json"
+	"html"
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"resource-share-site/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageSEOKey 页面SEO数据在gin上下文中的键
+const pageSEOKey = "seo_page"
+
+// summaryMaxRunes 自动生成描述的最大字符数
+const summaryMaxRunes = 160
+
+// MetaTag 单个Meta标签
+type MetaTag struct {
+	Name    string
+	Content string
+}
+
+// Breadcrumb 面包屑导航项
+type Breadcrumb struct {
+	Name string
+	Path string
+}
+
+// PageSEO 页面<head>渲染数据
+type PageSEO struct {
+	Meta        map[string]string
+	JSONLD      template.JS // 已经过JSON编码，可直接输出到<script>标签
+	Breadcrumbs []Breadcrumb
+}
+
+// Title 页面标题
+func (p *PageSEO) Title() string {
+	return p.Meta["title"]
+}
+
+// OpenGraph 按名称排序的Open Graph标签（og:* 和 article:*）
+func (p *PageSEO) OpenGraph() []MetaTag {
+	return p.tagsWithPrefix("og:", "article:")
+}
+
+// Twitter 按名称排序的Twitter Card标签
+func (p *PageSEO) Twitter() []MetaTag {
+	return p.tagsWithPrefix("twitter:")
+}
+
+// tagsWithPrefix 获取指定前缀的Meta标签
+func (p *PageSEO) tagsWithPrefix(prefixes ...string) []MetaTag {
+	var tags []MetaTag
+	for name, content := range p.Meta {
+		if content == "" {
+			continue
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				tags = append(tags, MetaTag{Name: name, Content: content})
+				break
+			}
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+// NewContext 创建带站点信息的SEO上下文
+func (m *Middleware) NewContext(pageType model.SEOConfigType, path string) *SEOContext {
+	return &SEOContext{
+		PageType:   pageType,
+		Path:       path,
+		SiteName:   m.cfg.SiteName,
+		SiteURL:    m.cfg.GetBaseURL(),
+		CustomData: make(map[string]interface{}),
+	}
+}
+
+// SetPageSEO 根据页面实体生成SEO数据并存入上下文（覆盖中间件生成的默认值）
+func (m *Middleware) SetPageSEO(c *gin.Context, seoCtx *SEOContext) *PageSEO {
+	if seoCtx.SiteName == "" {
+		seoCtx.SiteName = m.cfg.SiteName
+	}
+	if seoCtx.SiteURL == "" {
+		seoCtx.SiteURL = m.cfg.GetBaseURL()
+	}
+	if seoCtx.Path == "" {
+		seoCtx.Path = c.Request.URL.Path
+	}
+
+	page := m.buildPageSEO(seoCtx)
+	c.Set("seo_meta_tags", page.Meta)
+	c.Set(pageSEOKey, page)
+	return page
+}
+
+// GetPageSEO 获取上下文中的页面SEO数据（未设置时返回nil）
+func GetPageSEO(c *gin.Context) *PageSEO {
+	if value, exists := c.Get(pageSEOKey); exists {
+		if page, ok := value.(*PageSEO); ok {
+			return page
+		}
+	}
+	return nil
+}
+
+// buildPageSEO 生成Meta标签和JSON-LD
+func (m *Middleware) buildPageSEO(seoCtx *SEOContext) *PageSEO {
+	metaTags, err := m.configService.GeneratePageMetaTags(seoCtx.PageType, seoCtx.TargetID, m.TemplateData(seoCtx))
+	if err != nil || metaTags == nil {
+		metaTags = make(map[string]string)
+	}
+
+	siteURL := strings.TrimRight(seoCtx.SiteURL, "/")
+	if metaTags["title"] == "" {
+		metaTags["title"] = seoCtx.SiteName
+	}
+	if metaTags["canonical"] == "" {
+		metaTags["canonical"] = siteURL + seoCtx.Path
+	} else {
+		metaTags["canonical"] = absoluteURL(siteURL, metaTags["canonical"])
+	}
+	if metaTags["og:url"] == "" {
+		metaTags["og:url"] = metaTags["canonical"]
+	}
+	setDefault(metaTags, "og:site_name", seoCtx.SiteName)
+	setDefault(metaTags, "og:title", metaTags["title"])
+	setDefault(metaTags, "og:description", metaTags["description"])
+	setDefault(metaTags, "twitter:title", metaTags["og:title"])
+	setDefault(metaTags, "twitter:description", metaTags["og:description"])
+	if seoCtx.Image != "" {
+		setDefault(metaTags, "og:image", seoCtx.Image)
+	}
+	if image := metaTags["og:image"]; image != "" {
+		metaTags["og:image"] = absoluteURL(siteURL, image)
+		setDefault(metaTags, "twitter:image", metaTags["og:image"])
+	}
+
+	if article := seoCtx.Article; article != nil {
+		metaTags["og:type"] = "article"
+		if article.PublishedAt != nil {
+			metaTags["article:published_time"] = article.PublishedAt.Format(time.RFC3339)
+		}
+		metaTags["article:modified_time"] = article.UpdatedAt.Format(time.RFC3339)
+		if article.Category != "" {
+			metaTags["article:section"] = article.Category
+		}
+	}
+
+	return &PageSEO{
+		Meta:        metaTags,
+		JSONLD:      template.JS(GenerateJSONLD(seoCtx)),
+		Breadcrumbs: Breadcrumbs(seoCtx),
+	}
+}
+
+// TemplateData 根据SEO上下文生成SEO模板数据（包含页面实体的字段）
+func (m *Middleware) TemplateData(seoCtx *SEOContext) *TemplateData {
+	m.fillFromEntities(seoCtx)
+
+	data := NewTemplateData(m.buildContextData(seoCtx))
+	data.Query = seoCtx.Query
+	data.SetResource(seoCtx.Resource)
+	data.SetArticle(seoCtx.Article)
+	data.SetCategory(seoCtx.CategoryObj, seoCtx.CategoryPath)
+	data.SetCollection(seoCtx.Collection)
+	return data
+}
+
+// fillFromEntities 根据资源/文章/分类补全标题、描述和关键词
+func (m *Middleware) fillFromEntities(seoCtx *SEOContext) {
+	if resource := seoCtx.Resource; resource != nil {
+		if seoCtx.TargetID == nil {
+			seoCtx.TargetID = &resource.ID
+		}
+		if seoCtx.Title == "" {
+			seoCtx.Title = resource.Title
+		}
+		if seoCtx.Description == "" {
+			seoCtx.Description = Summarize(resource.Description)
+		}
+		if seoCtx.Category == "" && resource.Category != nil {
+			seoCtx.Category = resource.Category.Name
+		}
+		if seoCtx.Keywords == "" {
+			seoCtx.Keywords = joinKeywords(strings.Join(ResourceTagNames(resource.Tags), ","), seoCtx.Category)
+		}
+	}
+
+	if article := seoCtx.Article; article != nil {
+		if seoCtx.TargetID == nil {
+			seoCtx.TargetID = &article.ID
+		}
+		if seoCtx.Title == "" {
+			seoCtx.Title = article.MetaTitle
+		}
+		if seoCtx.Title == "" {
+			seoCtx.Title = article.Title
+		}
+		if seoCtx.Description == "" {
+			if article.MetaDescription != "" {
+				seoCtx.Description = Summarize(article.MetaDescription)
+			} else if article.Excerpt != "" {
+				seoCtx.Description = Summarize(article.Excerpt)
+			} else {
+				seoCtx.Description = Summarize(article.Content)
+			}
+		}
+		if seoCtx.Category == "" {
+			seoCtx.Category = article.Category
+		}
+		if seoCtx.Keywords == "" {
+			seoCtx.Keywords = joinKeywords(article.MetaKeywords+","+article.Tags, article.Category)
+		}
+		if seoCtx.Image == "" {
+			seoCtx.Image = article.FeaturedImage
+		}
+	}
+
+	if collection := seoCtx.Collection; collection != nil {
+		if seoCtx.TargetID == nil {
+			seoCtx.TargetID = &collection.ID
+		}
+		if seoCtx.Name == "" {
+			seoCtx.Name = collection.Name
+		}
+		if seoCtx.Description == "" {
+			seoCtx.Description = Summarize(collection.Description)
+		}
+		if collection.User != nil {
+			seoCtx.CustomData["owner"] = collection.User.Username
+		}
+		seoCtx.CustomData["count"] = collection.ItemsCount
+	}
+
+	if category := seoCtx.CategoryObj; category != nil {
+		if seoCtx.TargetID == nil && seoCtx.PageType == model.SEOConfigTypeCategory {
+			seoCtx.TargetID = &category.ID
+		}
+		if seoCtx.Name == "" {
+			seoCtx.Name = category.Name
+		}
+		if seoCtx.Description == "" && seoCtx.PageType == model.SEOConfigTypeCategory {
+			seoCtx.Description = Summarize(category.Description)
+		}
+	}
+}
+
+// Breadcrumbs 生成面包屑导航（首页 > 分类路径 > 当前页面）
+func Breadcrumbs(seoCtx *SEOContext) []Breadcrumb {
+	crumbs := []Breadcrumb{{Name: "首页", Path: "/"}}
+	switch seoCtx.PageType {
+	case model.SEOConfigTypeResource, model.SEOConfigTypeCategory:
+		for _, category := range seoCtx.CategoryPath {
+			crumbs = append(crumbs, Breadcrumb{Name: category.Name, Path: CategoryPath(category)})
+		}
+		if seoCtx.Resource != nil {
+			crumbs = append(crumbs, Breadcrumb{Name: seoCtx.Resource.Title, Path: seoCtx.Path})
+		}
+	case model.SEOConfigTypeArticle:
+		crumbs = append(crumbs, Breadcrumb{Name: "文章", Path: "/articles"})
+		if seoCtx.Article != nil {
+			crumbs = append(crumbs, Breadcrumb{Name: seoCtx.Article.Title, Path: seoCtx.Path})
+		}
+	default:
+		return nil
+	}
+	return crumbs
+}
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// Summarize 将正文压缩为适合description的摘要（去除HTML标签、合并空白并截断）
+func Summarize(text string) string {
+	text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " "))
+	text = strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+	if utf8.RuneCountInString(text) <= summaryMaxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:summaryMaxRunes-1])) + "…"
+}
+
+// joinKeywords 合并逗号分隔的标签和分类名为关键词
+func joinKeywords(tags string, extra ...string) string {
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, keyword := range append(strings.Split(tags, ","), extra...) {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" || seen[keyword] {
+			continue
+		}
+		seen[keyword] = true
+		keywords = append(keywords, keyword)
+	}
+	return strings.Join(keywords, ",")
+}
+
+// ResourceTagNames 解析资源的展示标签（JSON数组，兼容逗号分隔的旧数据）
+func ResourceTagNames(tags string) []string {
+	tags = strings.TrimSpace(tags)
+	if tags == "" {
+		return nil
+	}
+	var names []string
+	if strings.HasPrefix(tags, "[") {
+		if err := json.Unmarshal([]byte(tags), &names); err == nil {
+			return names
+		}
+	}
+	for _, name := range strings.Split(tags, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// absoluteURL 将站内相对地址补全为绝对地址
+func absoluteURL(siteURL, ref string) string {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "//") {
+		return ref
+	}
+	if !strings.HasPrefix(ref, "/") {
+		ref = "/" + ref
+	}
+	return siteURL + ref
+}
+
+// setDefault 仅在标签为空时设置
+func setDefault(metaTags map[string]string, name, value string) {
+	if metaTags[name] == "" && value != "" {
+		metaTags[name] = value
+	}
+}
@@ -0,0 +1,782 @@
+/*
+SEO Rank Import Service - 关键词排名导入服务
+
+导入站长平台导出的搜索表现数据，包括：
+- Google Search Console（CSV导出、Search Analytics API的JSON）
+- Bing Webmaster（CSV导出、GetQueryStats接口的JSON）
+- 百度站长平台（CSV导出，支持GBK编码）
+
+导入时自动创建或更新关键词、写入排名历史、记录排名变化事件，
+排名大幅下降时通知管理员。
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+Date: 2025-10-31
+*/
+
+package seo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/notification"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrUnsupportedSearchEngine = errors.New("不支持的搜索引擎，可选值：google、bing、baidu")
+	ErrUnsupportedImportFormat = errors.New("不支持的文件格式，可选值：csv、json")
+	ErrImportNoRows            = errors.New("导入文件中没有可识别的数据")
+	ErrImportTooManyRows       = errors.New("导入文件的数据行数超过上限")
+)
+
+// 支持的搜索引擎
+const (
+	SearchEngineGoogle = "google"
+	SearchEngineBing   = "bing"
+	SearchEngineBaidu  = "baidu"
+)
+
+// 支持的导入格式
+const (
+	RankImportFormatCSV  = "csv"
+	RankImportFormatJSON = "json"
+)
+
+// maxImportErrors 导入记录中保留的跳过原因条数
+const maxImportErrors = 50
+
+// maxDropsInAlert 单条提醒中列出的排名下降关键词数
+const maxDropsInAlert = 10
+
+// 导出文件列名 → 字段（列名统一转为小写并去掉空格和标点后匹配）
+var rankColumnAliases = map[string]string{
+	// 查询词
+	"query": "query", "queries": "query", "topqueries": "query", "keyword": "query", "keywords": "query",
+	"searchquery": "query", "关键词": "query", "搜索词": "query", "查询词": "query", "热门查询": "query", "查询": "query",
+	// 页面
+	"page": "page", "pages": "page", "toppages": "page", "url": "page", "landingpage": "page",
+	"页面": "page", "页面url": "page", "链接": "page", "网址": "page", "着陆页": "page",
+	// 点击量
+	"clicks": "clicks", "点击": "clicks", "点击量": "clicks", "点击次数": "clicks",
+	// 展现量
+	"impressions": "impressions", "展现": "impressions", "展现量": "impressions", "展示次数": "impressions", "曝光量": "impressions",
+	// 点击率
+	"ctr": "ctr", "点击率": "ctr",
+	// 平均排名
+	"position": "position", "avgposition": "position", "averageposition": "position", "avgimpressionposition": "position",
+	"平均排名": "position", "排名": "position", "平均位置": "position",
+	// 日期
+	"date": "date", "日期": "date",
+}
+
+// columnNameCleaner 列名中需要去掉的字符
+var columnNameCleaner = strings.NewReplacer(" ", "", "_", "", "-", "", ".", "", "(", "", ")", "", "（", "", "）", "", "\ufeff", "")
+
+// bingDatePattern Bing接口中的日期格式，如 /Date(1700000000000-0800)/
+var bingDatePattern = regexp.MustCompile(`^/Date\((-?\d+)([+-]\d{4})?\)/$`)
+
+// RankImportOptions 排名导入选项
+type RankImportOptions struct {
+	SearchEngine string    // 数据来源（google、bing、baidu）
+	Format       string    // 文件格式（csv、json），为空时根据文件名判断
+	FileName     string    // 文件名
+	RecordedOn   time.Time // 数据日期（行内有日期时以行内为准），为空时使用当天
+	Language     string    // 新建关键词的语言，为空时使用 zh
+	UserID       *uint     // 操作人ID
+}
+
+// rankRow 导出文件中的一行数据
+type rankRow struct {
+	line        int
+	query       string
+	page        string
+	clicks      int
+	impressions int
+	ctr         float64
+	position    float64
+	date        time.Time
+}
+
+// rankGroup 同一关键词、页面和日期的汇总数据
+type rankGroup struct {
+	query       string
+	page        string
+	date        time.Time
+	clicks      int
+	impressions int
+	ctr         float64
+	positionSum float64 // 按展现量加权的排名之和
+	weightSum   float64
+}
+
+// position 加权平均排名
+func (g *rankGroup) position() float64 {
+	if g.weightSum == 0 {
+		return 0
+	}
+	return math.Round(g.positionSum/g.weightSum*10) / 10
+}
+
+// clickRate 点击率（百分比，有展现量时根据点击量计算）
+func (g *rankGroup) clickRate() float64 {
+	if g.impressions > 0 {
+		return math.Round(float64(g.clicks)/float64(g.impressions)*10000) / 100
+	}
+	return g.ctr
+}
+
+// rankDrop 排名大幅下降的关键词
+type rankDrop struct {
+	keyword string
+	page    string
+	from    float64
+	to      float64
+}
+
+// RankImportService 关键词排名导入服务
+type RankImportService struct {
+	db       *gorm.DB
+	cfg      *config.SEOConfig
+	notifier notification.Publisher
+}
+
+// NewRankImportService 创建关键词排名导入服务
+func NewRankImportService(db *gorm.DB) *RankImportService {
+	return &RankImportService{
+		db:  db,
+		cfg: config.DefaultSEOConfig(),
+	}
+}
+
+// SetConfig 设置搜索引擎优化配置（导入行数上限、排名下降提醒阈值）
+func (s *RankImportService) SetConfig(cfg *config.SEOConfig) {
+	if cfg != nil {
+		s.cfg = cfg
+	}
+}
+
+// SetNotifier 设置通知发布器（排名大幅下降时通知管理员），为nil时不通知
+func (s *RankImportService) SetNotifier(notifier notification.Publisher) {
+	s.notifier = notifier
+}
+
+// Import 导入站长平台导出的搜索表现数据
+func (s *RankImportService) Import(r io.Reader, opts RankImportOptions) (*model.SEORankImport, error) {
+	engine := strings.ToLower(strings.TrimSpace(opts.SearchEngine))
+	if engine != SearchEngineGoogle && engine != SearchEngineBing && engine != SearchEngineBaidu {
+		return nil, ErrUnsupportedSearchEngine
+	}
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == "" {
+		format = detectImportFormat(opts.FileName)
+	}
+	if format != RankImportFormatCSV && format != RankImportFormatJSON {
+		return nil, ErrUnsupportedImportFormat
+	}
+	recordedOn := truncateToDay(opts.RecordedOn)
+	if opts.RecordedOn.IsZero() {
+		recordedOn = truncateToDay(time.Now())
+	}
+	language := opts.Language
+	if language == "" {
+		language = "zh"
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取导入文件失败: %w", err)
+	}
+	data = normalizeEncoding(data)
+
+	var rows []rankRow
+	if format == RankImportFormatJSON {
+		rows, err = parseRankJSON(data)
+	} else {
+		rows, err = parseRankCSV(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrImportNoRows
+	}
+	if len(rows) > s.cfg.GetRankImportMaxRows() {
+		return nil, fmt.Errorf("%w（%d 行，上限 %d 行）", ErrImportTooManyRows, len(rows), s.cfg.GetRankImportMaxRows())
+	}
+
+	record := &model.SEORankImport{
+		SearchEngine: engine,
+		Format:       format,
+		FileName:     limitRunes(opts.FileName, 255),
+		RecordedOn:   recordedOn,
+		TotalRows:    len(rows),
+		UserID:       opts.UserID,
+	}
+	groups, skipped := groupRankRows(rows, recordedOn)
+	// 所有行都被跳过时（如 Search Console 的网页报表没有查询词列）不记录导入
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("%w（%d 行全部被跳过，%s）", ErrImportNoRows, len(rows), skipped[0])
+	}
+	record.SkippedRows = len(skipped)
+	if len(skipped) > maxImportErrors {
+		skipped = append(skipped[:maxImportErrors], fmt.Sprintf("……另有 %d 行被跳过", len(skipped)-maxImportErrors))
+	}
+	record.Errors = strings.Join(skipped, "\n")
+
+	var drops []rankDrop
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		var err error
+		drops, err = s.saveGroups(tx, record, groups, language)
+		if err != nil {
+			return err
+		}
+		return tx.Save(record).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存排名数据失败: %w", err)
+	}
+
+	s.notifyDrops(record, drops)
+	return record, nil
+}
+
+// ListImports 获取排名导入记录
+func (s *RankImportService) ListImports(searchEngine string, page, pageSize int) ([]model.SEORankImport, int64, error) {
+	query := s.db.Model(&model.SEORankImport{})
+	if searchEngine != "" {
+		query = query.Where("search_engine = ?", searchEngine)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取导入记录总数失败: %w", err)
+	}
+
+	var imports []model.SEORankImport
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&imports).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取导入记录失败: %w", err)
+	}
+	return imports, total, nil
+}
+
+// saveGroups 写入关键词、排名记录和排名变化事件，返回排名大幅下降的关键词
+func (s *RankImportService) saveGroups(tx *gorm.DB, record *model.SEORankImport, groups []*rankGroup, language string) ([]rankDrop, error) {
+	keywords, err := s.ensureKeywords(tx, record, groups, language)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []model.SEOEvent
+	var drops []rankDrop
+	latest := make(map[uint][]*rankGroup) // 每个关键词最新日期的数据，用于更新关键词统计
+	for _, group := range groups {
+		keyword := keywords[strings.ToLower(group.query)]
+		position := group.position()
+		rank := model.SEORank{
+			KeywordID:    keyword.ID,
+			SearchEngine: record.SearchEngine,
+			Rank:         int(math.Max(1, math.Round(position))),
+			URL:          group.page,
+			RecordedOn:   group.date,
+			Position:     position,
+			Clicks:       group.clicks,
+			Impressions:  group.impressions,
+			CTR:          group.clickRate(),
+			ImportID:     &record.ID,
+		}
+
+		// 同一天重复导入时覆盖原记录
+		var existing model.SEORank
+		err := tx.Where("keyword_id = ? AND search_engine = ? AND url = ? AND recorded_on = ?",
+			keyword.ID, record.SearchEngine, group.page, group.date).First(&existing).Error
+		switch {
+		case err == nil:
+			rank.ID = existing.ID
+			rank.CreatedAt = existing.CreatedAt
+			if err := tx.Save(&rank).Error; err != nil {
+				return nil, err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&rank).Error; err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+		record.ImportedRows++
+
+		// 与上一次记录比较
+		var previous model.SEORank
+		err = tx.Where("keyword_id = ? AND search_engine = ? AND url = ? AND recorded_on < ?",
+			keyword.ID, record.SearchEngine, group.page, group.date).
+			Order("recorded_on DESC").First(&previous).Error
+		if err == nil {
+			if event, drop := s.compareRank(record, keyword, &previous, &rank); event != nil {
+				events = append(events, *event)
+				record.RankChanges++
+				if drop != nil {
+					drops = append(drops, *drop)
+					record.RankDrops++
+				}
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		switch current := latest[keyword.ID]; {
+		case len(current) == 0 || group.date.After(current[0].date):
+			latest[keyword.ID] = []*rankGroup{group}
+		case group.date.Equal(current[0].date):
+			latest[keyword.ID] = append(current, group)
+		}
+	}
+
+	if len(events) > 0 {
+		if err := tx.CreateInBatches(events, 100).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// 更新关键词的平均排名和点击率
+	for keywordID, current := range latest {
+		total := &rankGroup{}
+		for _, group := range current {
+			total.clicks += group.clicks
+			total.impressions += group.impressions
+			total.ctr = group.ctr
+			total.positionSum += group.positionSum
+			total.weightSum += group.weightSum
+		}
+		if err := tx.Model(&model.SEOKeyword{}).Where("id = ?", keywordID).Updates(map[string]interface{}{
+			"avg_position": total.position(),
+			"click_rate":   total.clickRate(),
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return drops, nil
+}
+
+// ensureKeywords 查找或创建导入数据中的关键词
+func (s *RankImportService) ensureKeywords(tx *gorm.DB, record *model.SEORankImport, groups []*rankGroup, language string) (map[string]*model.SEOKeyword, error) {
+	// 关键词不区分大小写（与MySQL默认排序规则一致），以首次出现的写法为准
+	var names []string
+	seen := make(map[string]bool)
+	for _, group := range groups {
+		if key := strings.ToLower(group.query); !seen[key] {
+			seen[key] = true
+			names = append(names, group.query)
+		}
+	}
+
+	keywords := make(map[string]*model.SEOKeyword, len(names))
+	for start := 0; start < len(names); start += 500 {
+		end := start + 500
+		if end > len(names) {
+			end = len(names)
+		}
+		lowered := make([]string, 0, end-start)
+		for _, name := range names[start:end] {
+			lowered = append(lowered, strings.ToLower(name))
+		}
+		var existing []model.SEOKeyword
+		if err := tx.Where("LOWER(keyword) IN ?", lowered).Find(&existing).Error; err != nil {
+			return nil, err
+		}
+		for i := range existing {
+			keywords[strings.ToLower(existing[i].Keyword)] = &existing[i]
+		}
+	}
+	record.KeywordsUpdated = len(keywords)
+
+	for _, name := range names {
+		if _, ok := keywords[strings.ToLower(name)]; ok {
+			continue
+		}
+		keyword := &model.SEOKeyword{
+			Keyword:  name,
+			Language: language,
+			IsActive: true,
+			Note:     fmt.Sprintf("从 %s 导入", record.SearchEngine),
+		}
+		if err := tx.Create(keyword).Error; err != nil {
+			return nil, err
+		}
+		keywords[strings.ToLower(name)] = keyword
+		record.KeywordsCreated++
+	}
+	return keywords, nil
+}
+
+// compareRank 比较前后两次排名，名次变化时生成事件，下降超过阈值时同时返回提醒数据
+func (s *RankImportService) compareRank(record *model.SEORankImport, keyword *model.SEOKeyword, previous, current *model.SEORank) (*model.SEOEvent, *rankDrop) {
+	oldPosition := previous.Position
+	if oldPosition == 0 {
+		oldPosition = float64(previous.Rank) // 手工记录的排名没有小数
+	}
+	if int(math.Round(oldPosition)) == current.Rank {
+		return nil, nil
+	}
+
+	change := current.Position - oldPosition // 正数表示排名下降
+	eventName := "排名上升"
+	var drop *rankDrop
+	if change > 0 {
+		eventName = "排名下降"
+		if threshold := s.cfg.RankDropThreshold; threshold > 0 && change >= float64(threshold) {
+			eventName = "排名大幅下降"
+			drop = &rankDrop{keyword: keyword.Keyword, page: current.URL, from: oldPosition, to: current.Position}
+		}
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"import_id":     record.ID,
+		"previous_date": previous.RecordedOn.Format("2006-01-02"),
+		"date":          current.RecordedOn.Format("2006-01-02"),
+		"change":        math.Round(change*10) / 10,
+		"clicks":        current.Clicks,
+		"impressions":   current.Impressions,
+		"ctr":           current.CTR,
+	})
+	keywordID := keyword.ID
+	return &model.SEOEvent{
+		EventType: model.SEOEventTypeRankChange,
+		EventName: eventName,
+		PageURL:   current.URL,
+		KeywordID: &keywordID,
+		OldValue:  strconv.FormatFloat(oldPosition, 'f', 1, 64),
+		NewValue:  strconv.FormatFloat(current.Position, 'f', 1, 64),
+		Details:   string(details),
+		Source:    record.SearchEngine,
+		UserID:    record.UserID,
+	}, drop
+}
+
+// notifyDrops 将排名大幅下降的关键词汇总通知管理员（失败不影响导入）
+func (s *RankImportService) notifyDrops(record *model.SEORankImport, drops []rankDrop) {
+	if s.notifier == nil || len(drops) == 0 {
+		return
+	}
+
+	sort.Slice(drops, func(i, j int) bool { return drops[i].to-drops[i].from > drops[j].to-drops[j].from })
+	lines := make([]string, 0, maxDropsInAlert+1)
+	for i, drop := range drops {
+		if i == maxDropsInAlert {
+			lines = append(lines, fmt.Sprintf("……共 %d 个", len(drops)))
+			break
+		}
+		line := fmt.Sprintf("「%s」%.1f → %.1f", drop.keyword, drop.from, drop.to)
+		if drop.page != "" {
+			line += "（" + drop.page + "）"
+		}
+		lines = append(lines, line)
+	}
+
+	var adminIDs []uint
+	if err := s.db.Model(&model.User{}).Where("role = ?", "admin").Pluck("id", &adminIDs).Error; err != nil {
+		return
+	}
+	for _, adminID := range adminIDs {
+		_ = s.notifier.Publish(&notification.Event{
+			Type:       model.NotificationTypeRankDrop,
+			UserID:     adminID,
+			Title:      fmt.Sprintf("%s 有 %d 个关键词排名大幅下降", record.SearchEngine, len(drops)),
+			Content:    strings.Join(lines, "\n"),
+			TargetType: "seo_rank_import",
+			TargetID:   &record.ID,
+		})
+	}
+}
+
+// groupRankRows 按关键词、页面和日期汇总数据行，返回汇总结果和跳过原因
+func groupRankRows(rows []rankRow, recordedOn time.Time) ([]*rankGroup, []string) {
+	var groups []*rankGroup
+	index := make(map[string]*rankGroup)
+	var skipped []string
+	for _, row := range rows {
+		query := strings.Join(strings.Fields(row.query), " ")
+		switch {
+		case query == "":
+			skipped = append(skipped, fmt.Sprintf("第 %d 行: 缺少查询词", row.line))
+			continue
+		case utf8.RuneCountInString(query) > 100:
+			skipped = append(skipped, fmt.Sprintf("第 %d 行: 查询词超过100个字符", row.line))
+			continue
+		case row.position <= 0:
+			skipped = append(skipped, fmt.Sprintf("第 %d 行: 缺少平均排名", row.line))
+			continue
+		}
+
+		date := row.date
+		if date.IsZero() {
+			date = recordedOn
+		}
+		page := limitRunes(strings.TrimSpace(row.page), 500)
+		key := strings.ToLower(query) + "\x00" + page + "\x00" + date.Format("2006-01-02")
+		group, ok := index[key]
+		if !ok {
+			group = &rankGroup{query: query, page: page, date: date}
+			index[key] = group
+			groups = append(groups, group)
+		}
+
+		weight := float64(row.impressions)
+		if weight <= 0 {
+			weight = 1
+		}
+		group.clicks += row.clicks
+		group.impressions += row.impressions
+		group.ctr = row.ctr
+		group.positionSum += row.position * weight
+		group.weightSum += weight
+	}
+	return groups, skipped
+}
+
+// parseRankCSV 解析CSV/TSV导出文件（自动跳过表头前的说明行）
+func parseRankCSV(data []byte) ([]rankRow, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	if firstLine, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine(); bytes.Count(firstLine, []byte("\t")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = '\t'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportNoRows, err)
+	}
+
+	// 表头：前10行中第一个能识别出查询词（或页面）和排名列的行
+	headerIndex := -1
+	var columns map[string]int
+	for i := 0; i < len(records) && i < 10; i++ {
+		columns = mapRankColumns(records[i])
+		_, hasQuery := columns["query"]
+		_, hasPage := columns["page"]
+		if _, hasPosition := columns["position"]; (hasQuery || hasPage) && hasPosition {
+			headerIndex = i
+			break
+		}
+	}
+	if headerIndex < 0 {
+		return nil, ErrImportNoRows
+	}
+
+	var rows []rankRow
+	for i, record := range records[headerIndex+1:] {
+		value := func(field string) string {
+			if col, ok := columns[field]; ok && col < len(record) {
+				return strings.TrimSpace(record[col])
+			}
+			return ""
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		rows = append(rows, newRankRow(headerIndex+i+2, value))
+	}
+	return rows, nil
+}
+
+// parseRankJSON 解析JSON导出数据（对象数组、Search Console的rows、Bing接口的d）
+func parseRankJSON(data []byte) ([]rankRow, error) {
+	var root interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImportNoRows, err)
+	}
+
+	items, _ := root.([]interface{})
+	if object, ok := root.(map[string]interface{}); ok {
+		for _, key := range []string{"rows", "d", "data", "list"} {
+			if list, ok := object[key].([]interface{}); ok {
+				items = list
+				break
+			}
+			if nested, ok := object[key].(map[string]interface{}); ok {
+				if list, ok := nested["list"].([]interface{}); ok {
+					items = list
+					break
+				}
+			}
+		}
+	}
+
+	var rows []rankRow
+	for i, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fields := make(map[string]string)
+		for key, raw := range object {
+			if field, ok := rankColumnAliases[normalizeColumnName(key)]; ok {
+				if _, exists := fields[field]; !exists {
+					fields[field] = jsonString(raw)
+				}
+			}
+		}
+		// Search Console API：keys 按请求的维度顺序排列（query、page）
+		if keys, ok := object["keys"].([]interface{}); ok {
+			for _, key := range keys {
+				value := jsonString(key)
+				if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+					fields["page"] = value
+				} else if _, exists := fields["query"]; !exists {
+					fields["query"] = value
+				}
+			}
+		}
+		rows = append(rows, newRankRow(i+1, func(field string) string { return fields[field] }))
+	}
+	return rows, nil
+}
+
+// newRankRow 根据字段取值函数构造数据行
+func newRankRow(line int, value func(field string) string) rankRow {
+	row := rankRow{
+		line:  line,
+		query: value("query"),
+		page:  value("page"),
+		date:  parseRankDate(value("date")),
+	}
+	row.clicks = int(parseMetric(value("clicks")))
+	row.impressions = int(parseMetric(value("impressions")))
+	row.position = parseMetric(value("position"))
+	if ctr := value("ctr"); ctr != "" {
+		row.ctr = parseMetric(ctr)
+		// Search Console API 返回0-1之间的比例
+		if !strings.Contains(ctr, "%") && row.ctr <= 1 {
+			row.ctr *= 100
+		}
+	}
+	return row
+}
+
+// mapRankColumns 识别表头中各字段所在的列
+func mapRankColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, name := range header {
+		if field, ok := rankColumnAliases[normalizeColumnName(name)]; ok {
+			if _, exists := columns[field]; !exists {
+				columns[field] = i
+			}
+		}
+	}
+	return columns
+}
+
+// normalizeColumnName 统一列名格式
+func normalizeColumnName(name string) string {
+	return strings.ToLower(columnNameCleaner.Replace(strings.TrimSpace(name)))
+}
+
+// parseMetric 解析数值（支持千分位、百分号，无法解析时返回0）
+func parseMetric(value string) float64 {
+	value = strings.NewReplacer(",", "", "%", "", " ", "").Replace(value)
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) || number < 0 {
+		return 0
+	}
+	return number
+}
+
+// parseRankDate 解析数据日期，无法解析时返回零值
+func parseRankDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}
+	}
+	if match := bingDatePattern.FindStringSubmatch(value); match != nil {
+		millis, _ := strconv.ParseInt(match[1], 10, 64)
+		return truncateToDay(time.UnixMilli(millis).UTC())
+	}
+	for _, layout := range []string{"2006-01-02", "2006/01/02", "20060102", "2006-1-2", "2006/1/2", time.RFC3339, "2006-01-02T15:04:05"} {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return truncateToDay(date)
+		}
+	}
+	return time.Time{}
+}
+
+// jsonString 将JSON值转换为字符串
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// normalizeEncoding 去掉UTF-8 BOM，非UTF-8内容按GBK解码（百度站长平台导出文件）
+func normalizeEncoding(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return data
+	}
+	if decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(data); err == nil {
+		return decoded
+	}
+	return data
+}
+
+// detectImportFormat 根据文件扩展名判断格式
+func detectImportFormat(fileName string) string {
+	name := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(name, ".json"):
+		return RankImportFormatJSON
+	case strings.HasSuffix(name, ".csv"), strings.HasSuffix(name, ".tsv"), strings.HasSuffix(name, ".txt"):
+		return RankImportFormatCSV
+	}
+	return ""
+}
+
+// truncateToDay 截断到当天零点
+func truncateToDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// limitRunes 按字符数截断字符串（用于数据库字段长度）
+func limitRunes(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+	return string([]rune(value)[:max])
+}
@@ -0,0 +1,356 @@
+/*
+SEO Redirect Service - URL跳转服务
+
+管理站内URL跳转规则，包括：
+- 分类改名或移动时自动生成的301跳转（见 RecordPathChange）
+- 管理员手动维护的跳转规则及CSV批量导入
+- 保存时检查跳转循环，访问时统计命中次数
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+Date: 2025-10-31
+*/
+
+package seo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrRedirectNotFound = errors.New("跳转规则不存在")
+	ErrRedirectExists   = errors.New("该地址的跳转规则已存在")
+	ErrRedirectLoop     = errors.New("跳转规则会形成循环")
+	ErrInvalidRedirect  = errors.New("跳转规则无效")
+)
+
+// maxRedirectHops 检查跳转循环时最多跟随的跳转次数
+const maxRedirectHops = 10
+
+// maxRedirectImportRows 单次导入的最大行数
+const maxRedirectImportRows = 5000
+
+// RedirectInput 跳转规则输入
+type RedirectInput struct {
+	FromPath   string `json:"from_path"`   // 旧地址（站内路径）
+	ToPath     string `json:"to_path"`     // 新地址（站内路径或完整URL）
+	StatusCode int    `json:"status_code"` // 跳转状态码，为空时使用301
+	Note       string `json:"note"`        // 备注
+}
+
+// RedirectImportResult 跳转规则导入结果
+type RedirectImportResult struct {
+	Created int      `json:"created"` // 新建数
+	Updated int      `json:"updated"` // 更新数
+	Skipped int      `json:"skipped"` // 跳过数
+	Errors  []string `json:"errors"`  // 跳过原因
+}
+
+// RedirectService URL跳转服务
+type RedirectService struct {
+	db *gorm.DB
+}
+
+// NewRedirectService 创建URL跳转服务
+func NewRedirectService(db *gorm.DB) *RedirectService {
+	return &RedirectService{db: db}
+}
+
+// Resolve 查找访问路径对应的跳转规则并记录命中
+// 参数：
+//   - path: 访问路径（不含查询参数）
+//
+// 返回：
+//   - 跳转规则
+//   - 错误信息（没有规则时返回 ErrRedirectNotFound）
+func (s *RedirectService) Resolve(path string) (*model.SEORedirect, error) {
+	path = normalizeRedirectPath(path)
+	if path == "" {
+		return nil, ErrRedirectNotFound
+	}
+
+	var redirect model.SEORedirect
+	if err := s.db.Where("from_path = ?", path).First(&redirect).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRedirectNotFound
+		}
+		return nil, fmt.Errorf("查询跳转规则失败: %w", err)
+	}
+
+	now := time.Now()
+	s.db.Model(&model.SEORedirect{}).Where("id = ?", redirect.ID).UpdateColumns(map[string]interface{}{
+		"hits":        gorm.Expr("hits + 1"),
+		"last_hit_at": now,
+	})
+	return &redirect, nil
+}
+
+// List 获取跳转规则列表
+// 参数：
+//   - keyword: 按旧地址或新地址模糊搜索
+//   - targetType: 关联实体类型，manual 表示手动规则，为空时不筛选
+//   - page, pageSize: 分页参数
+func (s *RedirectService) List(keyword, targetType string, page, pageSize int) ([]model.SEORedirect, int64, error) {
+	query := s.db.Model(&model.SEORedirect{})
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("from_path LIKE ? OR to_path LIKE ?", like, like)
+	}
+	switch targetType {
+	case "":
+	case "manual":
+		query = query.Where("target_type = '' OR target_type IS NULL")
+	default:
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计跳转规则失败: %w", err)
+	}
+
+	var redirects []model.SEORedirect
+	if err := query.Order("updated_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&redirects).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询跳转规则失败: %w", err)
+	}
+	return redirects, total, nil
+}
+
+// Create 创建跳转规则
+func (s *RedirectService) Create(input RedirectInput) (*model.SEORedirect, error) {
+	redirect := &model.SEORedirect{}
+	if err := s.apply(redirect, input); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.SEORedirect{}).Where("from_path = ?", redirect.FromPath).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询跳转规则失败: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrRedirectExists
+	}
+
+	if err := s.db.Create(redirect).Error; err != nil {
+		return nil, fmt.Errorf("创建跳转规则失败: %w", err)
+	}
+	return redirect, nil
+}
+
+// Update 更新跳转规则（手动修改后不再视为自动生成的规则）
+func (s *RedirectService) Update(id uint, input RedirectInput) (*model.SEORedirect, error) {
+	var redirect model.SEORedirect
+	if err := s.db.First(&redirect, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRedirectNotFound
+		}
+		return nil, fmt.Errorf("查询跳转规则失败: %w", err)
+	}
+	if err := s.apply(&redirect, input); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.SEORedirect{}).
+		Where("from_path = ? AND id <> ?", redirect.FromPath, redirect.ID).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询跳转规则失败: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrRedirectExists
+	}
+
+	redirect.TargetType = ""
+	redirect.TargetID = nil
+	if err := s.db.Save(&redirect).Error; err != nil {
+		return nil, fmt.Errorf("更新跳转规则失败: %w", err)
+	}
+	return &redirect, nil
+}
+
+// Delete 删除跳转规则
+func (s *RedirectService) Delete(id uint) error {
+	result := s.db.Delete(&model.SEORedirect{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除跳转规则失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRedirectNotFound
+	}
+	return nil
+}
+
+// Import 从CSV导入跳转规则（每行：旧地址,新地址[,状态码]，可带表头），旧地址已存在时更新
+func (s *RedirectService) Import(r io.Reader) (*RedirectImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	result := &RedirectImportResult{Errors: []string{}}
+	skip := func(line int, reason string) {
+		result.Skipped++
+		if len(result.Errors) < maxImportErrors {
+			result.Errors = append(result.Errors, fmt.Sprintf("第 %d 行: %s", line, reason))
+		}
+	}
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				skip(line, "格式错误")
+				continue
+			}
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		if line > maxRedirectImportRows {
+			return nil, fmt.Errorf("%w（上限 %d 行）", ErrImportTooManyRows, maxRedirectImportRows)
+		}
+
+		if len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+		}
+		if len(record) == 0 || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+		// 表头
+		if line == 1 && !strings.HasPrefix(strings.TrimSpace(record[0]), "/") {
+			continue
+		}
+		if len(record) < 2 {
+			skip(line, "缺少新地址")
+			continue
+		}
+
+		input := RedirectInput{FromPath: record[0], ToPath: record[1]}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			code, err := strconv.Atoi(strings.TrimSpace(record[2]))
+			if err != nil {
+				skip(line, "状态码无效")
+				continue
+			}
+			input.StatusCode = code
+		}
+
+		var existing model.SEORedirect
+		err = s.db.Where("from_path = ?", normalizeRedirectPath(input.FromPath)).First(&existing).Error
+		switch {
+		case err == nil:
+			input.Note = existing.Note
+			if _, err := s.Update(existing.ID, input); err != nil {
+				skip(line, err.Error())
+				continue
+			}
+			result.Updated++
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if _, err := s.Create(input); err != nil {
+				skip(line, err.Error())
+				continue
+			}
+			result.Created++
+		default:
+			return nil, fmt.Errorf("查询跳转规则失败: %w", err)
+		}
+	}
+
+	if result.Skipped > len(result.Errors) {
+		result.Errors = append(result.Errors, fmt.Sprintf("……另有 %d 行被跳过", result.Skipped-len(result.Errors)))
+	}
+	return result, nil
+}
+
+// apply 校验输入并写入跳转规则
+func (s *RedirectService) apply(redirect *model.SEORedirect, input RedirectInput) error {
+	from := normalizeRedirectPath(input.FromPath)
+	if from == "" || from == "/" {
+		return fmt.Errorf("%w：旧地址必须是以 / 开头的站内路径", ErrInvalidRedirect)
+	}
+
+	to := strings.TrimSpace(input.ToPath)
+	switch {
+	case strings.HasPrefix(to, "http://"), strings.HasPrefix(to, "https://"):
+	case strings.HasPrefix(to, "/") && !strings.HasPrefix(to, "//"):
+		to = normalizeRedirectPath(to)
+	default:
+		return fmt.Errorf("%w：新地址必须是站内路径或完整URL", ErrInvalidRedirect)
+	}
+	if from == to {
+		return fmt.Errorf("%w：新地址不能与旧地址相同", ErrInvalidRedirect)
+	}
+
+	code := input.StatusCode
+	if code == 0 {
+		code = 301
+	}
+	switch code {
+	case 301, 302, 307, 308:
+	default:
+		return fmt.Errorf("%w：状态码只能是301、302、307或308", ErrInvalidRedirect)
+	}
+
+	if err := s.checkLoop(redirect.ID, from, to); err != nil {
+		return err
+	}
+
+	redirect.FromPath = from
+	redirect.ToPath = to
+	redirect.StatusCode = code
+	redirect.Note = strings.TrimSpace(input.Note)
+	return nil
+}
+
+// checkLoop 从新地址出发沿已有规则跳转，检查是否会回到旧地址
+func (s *RedirectService) checkLoop(id uint, from, to string) error {
+	current := to
+	for hop := 0; hop < maxRedirectHops; hop++ {
+		if current == from {
+			return ErrRedirectLoop
+		}
+		if !strings.HasPrefix(current, "/") {
+			return nil
+		}
+
+		var next model.SEORedirect
+		err := s.db.Select("to_path").Where("from_path = ? AND id <> ?", current, id).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("查询跳转规则失败: %w", err)
+		}
+		current = next.ToPath
+	}
+	return fmt.Errorf("%w（跳转次数超过 %d 次）", ErrRedirectLoop, maxRedirectHops)
+}
+
+// normalizeRedirectPath 规范化站内路径（去掉查询参数、片段和末尾斜杠）
+func normalizeRedirectPath(path string) string {
+	path = strings.TrimSpace(path)
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") {
+		return ""
+	}
+	if len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+	return path
+}
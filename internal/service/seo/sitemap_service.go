@@ -0,0 +1,552 @@
+/*
+Sitemap Service - Sitemap生成服务
+
+提供动态Sitemap生成功能，包括：
+- 资源、文章、分类、标签、合集和静态页面的URL枚举
+- 按50000条分片并生成sitemap索引
+- gzip压缩输出和图片扩展
+- 按内容变化增量重建分片
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package seo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"resource-share-site/internal/config"
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrSitemapNotFound = errors.New("sitemap不存在")
+)
+
+// Sitemap 协议命名空间
+const (
+	sitemapNamespace      = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	sitemapImageNamespace = "http://www.google.com/schemas/sitemap-image/1.1"
+)
+
+// sitemapBatchSize 分批读取数据库的批大小
+const sitemapBatchSize = 1000
+
+// sitemapPagesSection 静态页面和手动维护的URL所在分区
+const sitemapPagesSection = "pages"
+
+// sitemapURLSet <urlset> 文档
+type sitemapURLSet struct {
+	XMLName    xml.Name     `xml:"urlset"`
+	Xmlns      string       `xml:"xmlns,attr"`
+	XmlnsImage string       `xml:"xmlns:image,attr,omitempty"`
+	URLs       []sitemapURL `xml:"url"`
+}
+
+// sitemapURL <url> 条目
+type sitemapURL struct {
+	Loc        string         `xml:"loc"`
+	LastMod    string         `xml:"lastmod,omitempty"`
+	ChangeFreq string         `xml:"changefreq,omitempty"`
+	Priority   string         `xml:"priority,omitempty"`
+	Images     []sitemapImage `xml:"image:image,omitempty"`
+
+	lastMod *time.Time
+}
+
+// sitemapImage <image:image> 图片扩展
+type sitemapImage struct {
+	Loc   string `xml:"image:loc"`
+	Title string `xml:"image:title,omitempty"`
+}
+
+// sitemapIndex <sitemapindex> 文档
+type sitemapIndex struct {
+	XMLName  xml.Name            `xml:"sitemapindex"`
+	Xmlns    string              `xml:"xmlns,attr"`
+	Sitemaps []sitemapIndexEntry `xml:"sitemap"`
+}
+
+// sitemapIndexEntry <sitemap> 条目
+type sitemapIndexEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemapRow 各分区查询结果的统一结构
+type sitemapRow struct {
+	ID        uint
+	Slug      string
+	Title     string
+	Image     string
+	UpdatedAt time.Time
+}
+
+// sitemapSection 动态生成的sitemap分区
+type sitemapSection struct {
+	name       string
+	columns    string                       // 查询的列（需映射到 sitemapRow）
+	query      func(db *gorm.DB) *gorm.DB   // 筛选出应出现在sitemap中的记录
+	loc        func(row *sitemapRow) string // 页面路径
+	changeFreq string
+	priority   float64
+}
+
+// sitemapSections 按索引中的顺序排列的动态分区
+var sitemapSections = []sitemapSection{
+	{
+		name:    "resources",
+		columns: "id, slug, title, updated_at",
+		query: func(db *gorm.DB) *gorm.DB {
+			return db.Model(&model.Resource{}).Where("status = ?", model.ResourceStatusApproved)
+		},
+		loc: func(row *sitemapRow) string {
+			return ResourcePath(row.ID, row.Slug)
+		},
+		changeFreq: "weekly",
+		priority:   0.8,
+	},
+	{
+		name:    "articles",
+		columns: "id, slug, title, featured_image AS image, updated_at",
+		query: func(db *gorm.DB) *gorm.DB {
+			return db.Model(&model.Article{}).Where("status = ?", model.ArticleStatusPublished)
+		},
+		loc: func(row *sitemapRow) string {
+			return ArticlePath(row.Slug)
+		},
+		changeFreq: "weekly",
+		priority:   0.7,
+	},
+	{
+		name:    "categories",
+		columns: "id, path AS slug, name AS title, updated_at",
+		query: func(db *gorm.DB) *gorm.DB {
+			return db.Model(&model.Category{})
+		},
+		loc: func(row *sitemapRow) string {
+			return CategoryPath(&model.Category{ID: row.ID, Path: row.Slug})
+		},
+		changeFreq: "daily",
+		priority:   0.9,
+	},
+	{
+		name:    "tags",
+		columns: "id, slug, name AS title, updated_at",
+		query: func(db *gorm.DB) *gorm.DB {
+			// 同义词、未使用和尚未生成 slug 的标签不单独收录
+			return db.Model(&model.Tag{}).
+				Where("canonical_id IS NULL AND resource_count + article_count > 0 AND slug <> ''")
+		},
+		loc: func(row *sitemapRow) string {
+			return "/tags/" + url.PathEscape(row.Slug)
+		},
+		changeFreq: "weekly",
+		priority:   0.5,
+	},
+	{
+		name:    "collections",
+		columns: "id, name AS title, updated_at",
+		query: func(db *gorm.DB) *gorm.DB {
+			return db.Model(&model.Collection{}).Where("is_public = ? AND items_count > 0", true)
+		},
+		loc: func(row *sitemapRow) string {
+			return fmt.Sprintf("/collection/%d", row.ID)
+		},
+		changeFreq: "weekly",
+		priority:   0.6,
+	},
+}
+
+// sitemapStaticPages 固定收录的静态页面
+var sitemapStaticPages = []struct {
+	path       string
+	changeFreq string
+	priority   float64
+}{
+	{"/", "daily", 1.0},
+	{"/resources", "daily", 0.9},
+	{"/articles", "daily", 0.8},
+	{"/categories", "weekly", 0.7},
+}
+
+// sitemapGeneratedTypes 已由动态分区生成的页面类型，手动URL中的这些类型不再重复收录
+var sitemapGeneratedTypes = []model.SEOConfigType{
+	model.SEOConfigTypeHome,
+	model.SEOConfigTypeResource,
+	model.SEOConfigTypeCategory,
+	model.SEOConfigTypeCollection,
+}
+
+// sitemapShard 渲染好的sitemap分片
+type sitemapShard struct {
+	name    string
+	lastMod *time.Time
+	xml     []byte
+	gz      []byte
+}
+
+// sitemapCache 分区缓存（指纹不变时复用已渲染的分片）
+type sitemapCache struct {
+	fingerprint string
+	shards      []*sitemapShard
+}
+
+// SitemapService Sitemap生成服务
+type SitemapService struct {
+	db  *gorm.DB
+	cfg *config.SEOConfig
+
+	mu        sync.Mutex
+	caches    map[string]*sitemapCache
+	checkedAt time.Time
+}
+
+// NewSitemapService 创建Sitemap生成服务
+func NewSitemapService(db *gorm.DB) *SitemapService {
+	return &SitemapService{
+		db:     db,
+		cfg:    config.DefaultSEOConfig(),
+		caches: make(map[string]*sitemapCache),
+	}
+}
+
+// SetConfig 设置搜索引擎优化配置（会清空已生成的缓存）
+func (s *SitemapService) SetConfig(cfg *config.SEOConfig) {
+	if cfg == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	s.caches = make(map[string]*sitemapCache)
+	s.checkedAt = time.Time{}
+}
+
+// Index 生成sitemap索引
+// 参数：
+//   - gzipped: 是否返回gzip压缩后的内容
+//
+// 返回：
+//   - sitemap_index.xml 内容
+//   - 错误信息
+func (s *SitemapService) Index(gzipped bool) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	ext := ".xml"
+	if s.cfg.SitemapGzip {
+		ext = ".xml.gz"
+	}
+
+	index := sitemapIndex{Xmlns: sitemapNamespace}
+	for _, name := range s.sectionNames() {
+		cache, ok := s.caches[name]
+		if !ok {
+			continue
+		}
+		for _, shard := range cache.shards {
+			index.Sitemaps = append(index.Sitemaps, sitemapIndexEntry{
+				Loc:     s.cfg.GetBaseURL() + "/sitemaps/" + shard.name + ext,
+				LastMod: formatLastMod(shard.lastMod),
+			})
+		}
+	}
+
+	data, err := marshalXML(index)
+	if err != nil {
+		return nil, err
+	}
+	if gzipped {
+		return gzipBytes(data)
+	}
+	return data, nil
+}
+
+// Shard 获取sitemap分片
+// 参数：
+//   - name: 分片名（如 resources-1）
+//   - gzipped: 是否返回gzip压缩后的内容
+//
+// 返回：
+//   - 分片内容
+//   - 错误信息
+func (s *SitemapService) Shard(name string, gzipped bool) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	section := name
+	if idx := strings.LastIndex(name, "-"); idx > 0 {
+		section = name[:idx]
+	}
+	if cache, ok := s.caches[section]; ok {
+		for _, shard := range cache.shards {
+			if shard.name != name {
+				continue
+			}
+			if gzipped {
+				return shard.gz, nil
+			}
+			return shard.xml, nil
+		}
+	}
+
+	return nil, ErrSitemapNotFound
+}
+
+// refresh 检查各分区内容是否变化，只重建发生变化的分区（调用方需持有锁）
+func (s *SitemapService) refresh() error {
+	if !s.checkedAt.IsZero() && time.Since(s.checkedAt) < s.cfg.GetSitemapCacheTTL() {
+		return nil
+	}
+
+	fingerprint, err := s.pagesFingerprint()
+	if err != nil {
+		return err
+	}
+	if cache, ok := s.caches[sitemapPagesSection]; !ok || cache.fingerprint != fingerprint {
+		urls, err := s.pageURLs()
+		if err != nil {
+			return err
+		}
+		if err := s.store(sitemapPagesSection, fingerprint, urls); err != nil {
+			return err
+		}
+	}
+
+	for i := range sitemapSections {
+		section := &sitemapSections[i]
+		fingerprint, err := s.fingerprint(section.query(s.db))
+		if err != nil {
+			return fmt.Errorf("检查%s变化失败: %w", section.name, err)
+		}
+		if cache, ok := s.caches[section.name]; ok && cache.fingerprint == fingerprint {
+			continue
+		}
+
+		urls, err := s.sectionURLs(section)
+		if err != nil {
+			return err
+		}
+		if err := s.store(section.name, fingerprint, urls); err != nil {
+			return err
+		}
+	}
+
+	s.checkedAt = time.Now()
+	return nil
+}
+
+// store 按分片大小渲染并缓存分区
+func (s *SitemapService) store(name, fingerprint string, urls []sitemapURL) error {
+	cache := &sitemapCache{fingerprint: fingerprint}
+	size := s.cfg.GetSitemapShardSize()
+	for start := 0; start < len(urls); start += size {
+		end := start + size
+		if end > len(urls) {
+			end = len(urls)
+		}
+
+		shard, err := renderShard(fmt.Sprintf("%s-%d", name, len(cache.shards)+1), urls[start:end])
+		if err != nil {
+			return err
+		}
+		cache.shards = append(cache.shards, shard)
+	}
+
+	s.caches[name] = cache
+	return nil
+}
+
+// sectionURLs 按ID分批读取分区的全部URL
+func (s *SitemapService) sectionURLs(section *sitemapSection) ([]sitemapURL, error) {
+	var urls []sitemapURL
+	var lastID uint
+	for {
+		var rows []sitemapRow
+		if err := section.query(s.db).
+			Select(section.columns).
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(sitemapBatchSize).
+			Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("查询%s失败: %w", section.name, err)
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			entry := s.newURL(section.loc(row), &row.UpdatedAt, section.changeFreq, section.priority)
+			if row.Image != "" {
+				entry.Images = []sitemapImage{{Loc: s.absoluteURL(row.Image), Title: row.Title}}
+			}
+			urls = append(urls, entry)
+		}
+
+		if len(rows) < sitemapBatchSize {
+			return urls, nil
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// pageURLs 生成静态页面和手动维护的URL
+func (s *SitemapService) pageURLs() ([]sitemapURL, error) {
+	urls := make([]sitemapURL, 0, len(sitemapStaticPages))
+	for _, page := range sitemapStaticPages {
+		urls = append(urls, s.newURL(page.path, nil, page.changeFreq, page.priority))
+	}
+
+	var manual []model.SitemapUrl
+	if err := s.manualURLs().Order("priority DESC, id ASC").Find(&manual).Error; err != nil {
+		return nil, fmt.Errorf("查询Sitemap URL失败: %w", err)
+	}
+	for i := range manual {
+		urls = append(urls, s.newURL(manual[i].Loc, manual[i].LastMod, manual[i].ChangeFreq, manual[i].Priority))
+	}
+
+	return urls, nil
+}
+
+// pagesFingerprint 手动维护的URL的指纹（静态页面固定不变）
+func (s *SitemapService) pagesFingerprint() (string, error) {
+	fingerprint, err := s.fingerprint(s.manualURLs())
+	if err != nil {
+		return "", fmt.Errorf("检查Sitemap URL变化失败: %w", err)
+	}
+	return s.cfg.GetBaseURL() + "|" + fingerprint, nil
+}
+
+// manualURLs 需要收录的手动维护URL查询
+func (s *SitemapService) manualURLs() *gorm.DB {
+	return s.db.Model(&model.SitemapUrl{}).
+		Where("is_active = ?", true).
+		Where("page_type NOT IN ? OR page_type IS NULL", sitemapGeneratedTypes)
+}
+
+// fingerprint 用记录数和最近更新时间判断分区内容是否变化
+func (s *SitemapService) fingerprint(query *gorm.DB) (string, error) {
+	var count int64
+	var latest sql.NullString
+	if err := query.Select("COUNT(*), MAX(updated_at)").Row().Scan(&count, &latest); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d|%s", count, latest.String), nil
+}
+
+// newURL 创建sitemap条目
+func (s *SitemapService) newURL(loc string, lastMod *time.Time, changeFreq string, priority float64) sitemapURL {
+	entry := sitemapURL{
+		Loc:        s.absoluteURL(loc),
+		LastMod:    formatLastMod(lastMod),
+		ChangeFreq: changeFreq,
+		lastMod:    lastMod,
+	}
+	if priority > 0 {
+		entry.Priority = fmt.Sprintf("%.1f", priority)
+	}
+	return entry
+}
+
+// absoluteURL 相对路径补全为站点地址
+func (s *SitemapService) absoluteURL(loc string) string {
+	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		return loc
+	}
+	if !strings.HasPrefix(loc, "/") {
+		loc = "/" + loc
+	}
+	return s.cfg.GetBaseURL() + loc
+}
+
+// sectionNames 索引中分区的顺序
+func (s *SitemapService) sectionNames() []string {
+	names := []string{sitemapPagesSection}
+	for _, section := range sitemapSections {
+		names = append(names, section.name)
+	}
+	return names
+}
+
+// renderShard 渲染一个sitemap分片（同时生成gzip版本）
+func renderShard(name string, urls []sitemapURL) (*sitemapShard, error) {
+	data, err := renderURLSet(urls)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzipBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	shard := &sitemapShard{name: name, xml: data, gz: gz}
+	for i := range urls {
+		if lastMod := urls[i].lastMod; lastMod != nil && (shard.lastMod == nil || lastMod.After(*shard.lastMod)) {
+			shard.lastMod = lastMod
+		}
+	}
+	return shard, nil
+}
+
+// renderURLSet 渲染 <urlset> 文档（有图片时声明图片扩展命名空间）
+func renderURLSet(urls []sitemapURL) ([]byte, error) {
+	set := sitemapURLSet{Xmlns: sitemapNamespace, URLs: urls}
+	for i := range urls {
+		if len(urls[i].Images) > 0 {
+			set.XmlnsImage = sitemapImageNamespace
+			break
+		}
+	}
+	return marshalXML(set)
+}
+
+// marshalXML 序列化为带XML声明的文档
+func marshalXML(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成Sitemap失败: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// gzipBytes gzip压缩
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("压缩Sitemap失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("压缩Sitemap失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatLastMod 格式化为W3C日期时间
+func formatLastMod(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
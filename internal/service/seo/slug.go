@@ -0,0 +1,199 @@
+/*
+SEO Slug - URL标识与实体地址
+
+生成资源和分类的URL标识，并统一维护实体的访问地址：
+- 资源：/r/:id-:slug（按ID定位，slug变化后旧地址跳转到新地址）
+- 分类：/c/:path（父子分类的slug按层级拼接）
+- 文章：/article/:slug
+
+地址变化时自动同步SEO配置中的规范URL，分类地址变化时写入跳转规则。
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+Date: 2025-10-31
+*/
+
+package seo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"resource-share-site/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// slug最大长度
+const (
+	ResourceSlugMaxLength = 80
+	CategorySlugMaxLength = 40
+	TagSlugMaxLength      = 80
+	ArticleSlugMaxLength  = 100
+)
+
+// Slugify 生成URL友好的标识（汉字转拼音，全角转半角，只保留小写字母和数字，其余字符转为-）
+// 参数：
+//   - text: 标题或名称
+//   - maxLength: 最大长度（超出时在单词边界截断）
+func Slugify(text string, maxLength int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range Transliterate(text) {
+		switch {
+		case r == '　':
+			r = ' '
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		}
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+	}
+
+	slug := b.String()
+	if maxLength > 0 && len(slug) > maxLength {
+		slug = slug[:maxLength]
+		if i := strings.LastIndexByte(slug, '-'); i > maxLength/2 {
+			slug = slug[:i]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
+
+// ResourcePath 资源详情页路径
+func ResourcePath(id uint, slug string) string {
+	if slug == "" {
+		return fmt.Sprintf("/r/%d", id)
+	}
+	return fmt.Sprintf("/r/%d-%s", id, slug)
+}
+
+// ParseResourceRef 解析资源地址中的 :id-:slug 部分
+func ParseResourceRef(ref string) (id uint, slug string, ok bool) {
+	idPart, slug, _ := strings.Cut(ref, "-")
+	value, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil || value == 0 {
+		return 0, "", false
+	}
+	return uint(value), slug, true
+}
+
+// CategoryPath 分类页路径（尚未生成slug路径的分类使用ID地址）
+func CategoryPath(category *model.Category) string {
+	if category.Path == "" {
+		return fmt.Sprintf("/category/%d", category.ID)
+	}
+	return "/c/" + category.Path
+}
+
+// ArticlePath 文章详情页路径
+func ArticlePath(slug string) string {
+	return "/article/" + url.PathEscape(slug)
+}
+
+// EntityPath 获取资源或分类当前的访问路径（其他类型或实体不存在时返回空字符串）
+func EntityPath(db *gorm.DB, configType model.SEOConfigType, id uint) string {
+	switch configType {
+	case model.SEOConfigTypeResource:
+		var resource model.Resource
+		if err := db.Select("id", "slug").First(&resource, id).Error; err == nil {
+			return ResourcePath(resource.ID, resource.Slug)
+		}
+	case model.SEOConfigTypeCategory:
+		var category model.Category
+		if err := db.Select("id", "path").First(&category, id).Error; err == nil {
+			return CategoryPath(&category)
+		}
+	}
+	return ""
+}
+
+// PathChange 实体访问地址的变化
+type PathChange struct {
+	TargetType model.SEOConfigType // 实体类型 (resource, category)
+	TargetID   uint                // 实体ID
+	From       string              // 旧路径
+	To         string              // 新路径
+	Redirect   bool                // 是否写入跳转规则（资源地址含ID，旧slug可直接跳转，不需要写入）
+}
+
+// RecordPathChange 在实体地址变化的事务中同步规范URL和跳转规则
+// 参数：
+//   - tx: 修改实体的事务
+//   - change: 地址变化
+func RecordPathChange(tx *gorm.DB, change PathChange) error {
+	if change.From == "" || change.From == change.To {
+		return nil
+	}
+	// 未迁移SEO表时（如只执行基础迁移的命令行工具）无需同步
+	if !tx.Migrator().HasTable(&model.SEOConfig{}) {
+		return nil
+	}
+
+	// 规范URL为空或仍指向旧地址（包括补全站点地址后的旧地址）时更新为新地址
+	if err := tx.Model(&model.SEOConfig{}).
+		Where("config_type = ? AND target_id = ?", change.TargetType, change.TargetID).
+		Where("canonical_url IN ? OR canonical_url LIKE ? OR canonical_url LIKE ?",
+			[]string{"", change.From}, "http://%"+change.From, "https://%"+change.From).
+		Update("canonical_url", change.To).Error; err != nil {
+		return fmt.Errorf("更新规范URL失败: %w", err)
+	}
+
+	if !change.Redirect {
+		return nil
+	}
+
+	// 新地址重新启用，不再跳转
+	if err := tx.Where("from_path = ?", change.To).Delete(&model.SEORedirect{}).Error; err != nil {
+		return fmt.Errorf("删除跳转规则失败: %w", err)
+	}
+	// 指向旧地址的规则直接指向新地址，避免多次跳转
+	if err := tx.Model(&model.SEORedirect{}).
+		Where("to_path = ?", change.From).
+		Update("to_path", change.To).Error; err != nil {
+		return fmt.Errorf("更新跳转规则失败: %w", err)
+	}
+
+	var existing model.SEORedirect
+	err := tx.Where("from_path = ?", change.From).First(&existing).Error
+	switch {
+	case err == nil:
+		existing.ToPath = change.To
+		existing.StatusCode = 301
+		existing.TargetType = change.TargetType
+		existing.TargetID = &change.TargetID
+		if err := tx.Save(&existing).Error; err != nil {
+			return fmt.Errorf("更新跳转规则失败: %w", err)
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		targetID := change.TargetID
+		redirect := &model.SEORedirect{
+			FromPath:   change.From,
+			ToPath:     change.To,
+			StatusCode: 301,
+			TargetType: change.TargetType,
+			TargetID:   &targetID,
+		}
+		if err := tx.Create(redirect).Error; err != nil {
+			return fmt.Errorf("创建跳转规则失败: %w", err)
+		}
+	default:
+		return fmt.Errorf("查询跳转规则失败: %w", err)
+	}
+	return nil
+}
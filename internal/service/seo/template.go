@@ -0,0 +1,541 @@
+/*
+SEO Template Engine - SEO模板引擎
+
+基于 text/template 的受限模板语言，用于SEO配置中的标题、描述等字段：
+- 只能访问 TemplateData 中的只读数据（站点、资源、文章、分类、合集等）
+- 只允许白名单函数：truncate、default、lower、join、date
+- 禁止 define/template/block、call 以及对数字的 range，避免越权和长时间执行
+- 兼容旧的 {{key}} 占位符写法
+- 输出时可按HTML或JSON-LD上下文转义
+
+示例：{{.Resource.Title | truncate 60}} - {{.Category.Name | default "资源"}} | {{.Site.Name}}
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+Date: 2025-10-31
+*/
+
+package seo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
+
+	"resource-share-site/internal/model"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrInvalidTemplate = errors.New("SEO模板无效")
+	ErrTemplateRender  = errors.New("SEO模板渲染失败")
+)
+
+const (
+	// maxTemplateSize 模板源码的最大长度
+	maxTemplateSize = 2000
+	// maxTemplateOutput 模板输出的最大长度
+	maxTemplateOutput = 4000
+)
+
+// OutputContext 模板输出上下文（决定转义方式）
+type OutputContext int
+
+const (
+	OutputText   OutputContext = iota // 纯文本（由调用方负责转义，如 html/template）
+	OutputHTML                        // HTML文本或属性值
+	OutputJSONLD                      // JSON-LD字符串内容（不含两侧引号）
+)
+
+// TemplateSite 站点信息
+type TemplateSite struct {
+	Name string
+	URL  string
+}
+
+// TemplateResource 模板中可访问的资源字段
+type TemplateResource struct {
+	ID          uint
+	Title       string
+	Slug        string
+	Description string
+	Category    string
+	Uploader    string
+	Tags        []string
+	Downloads   uint
+	Views       uint
+	Rating      float64
+	RatingCount uint
+	PointsPrice int
+	URL         string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TemplateArticle 模板中可访问的文章字段
+type TemplateArticle struct {
+	ID          uint
+	Title       string
+	Slug        string
+	Excerpt     string
+	Category    string
+	Author      string
+	Tags        []string
+	Image       string
+	URL         string
+	PublishedAt time.Time
+	UpdatedAt   time.Time
+}
+
+// TemplateCategory 模板中可访问的分类字段
+type TemplateCategory struct {
+	ID          uint
+	Name        string
+	Description string
+	Path        []string // 从根分类到当前分类的名称
+	Slug        string   // 从根分类到当前分类的slug路径
+	URL         string
+}
+
+// TemplateCollection 模板中可访问的合集字段
+type TemplateCollection struct {
+	ID          uint
+	Name        string
+	Description string
+	Owner       string
+	ItemsCount  int
+	URL         string
+}
+
+// TemplateData 模板数据（实体不存在时对应字段为零值，便于配合 default 使用）
+type TemplateData struct {
+	Site       TemplateSite
+	Resource   TemplateResource
+	Article    TemplateArticle
+	Category   TemplateCategory
+	Collection TemplateCollection
+	Query      string
+	Page       int
+	Year       int
+	Vars       map[string]string // 旧版 {{key}} 占位符使用的变量
+
+	context map[string]interface{} // 原始上下文（用于生成默认Meta标签）
+}
+
+// NewTemplateData 根据旧版上下文创建模板数据
+func NewTemplateData(context map[string]interface{}) *TemplateData {
+	data := &TemplateData{
+		Year:    time.Now().Year(),
+		Vars:    make(map[string]string, len(context)),
+		context: context,
+	}
+	for key, value := range context {
+		data.Vars[key] = fmt.Sprintf("%v", value)
+	}
+	if name, ok := context["site_name"].(string); ok {
+		data.Site.Name = name
+	}
+	if siteURL, ok := context["site_url"].(string); ok {
+		data.Site.URL = siteURL
+	}
+	if query, ok := context["query"].(string); ok {
+		data.Query = query
+	}
+	if page, ok := context["page"].(int); ok {
+		data.Page = page
+	}
+	return data
+}
+
+// SetResource 设置资源数据
+func (d *TemplateData) SetResource(resource *model.Resource) {
+	if resource == nil {
+		return
+	}
+	d.Resource = TemplateResource{
+		ID:          resource.ID,
+		Title:       resource.Title,
+		Description: Summarize(resource.Description),
+		Tags:        ResourceTagNames(resource.Tags),
+		Downloads:   resource.DownloadsCount,
+		Views:       resource.ViewsCount,
+		Rating:      resource.RatingAverage,
+		RatingCount: resource.RatingCount,
+		PointsPrice: resource.PointsPrice,
+		Slug:        resource.Slug,
+		URL:         d.Site.URL + ResourcePath(resource.ID, resource.Slug),
+		CreatedAt:   resource.CreatedAt,
+		UpdatedAt:   resource.UpdatedAt,
+	}
+	if resource.Category != nil {
+		d.Resource.Category = resource.Category.Name
+	}
+	if resource.UploadedBy != nil {
+		d.Resource.Uploader = resource.UploadedBy.Username
+	}
+}
+
+// SetArticle 设置文章数据
+func (d *TemplateData) SetArticle(article *model.Article) {
+	if article == nil {
+		return
+	}
+	d.Article = TemplateArticle{
+		ID:        article.ID,
+		Title:     article.Title,
+		Slug:      article.Slug,
+		Excerpt:   Summarize(article.Excerpt),
+		Category:  article.Category,
+		Tags:      ResourceTagNames(article.Tags),
+		Image:     article.FeaturedImage,
+		URL:       d.Site.URL + ArticlePath(article.Slug),
+		UpdatedAt: article.UpdatedAt,
+	}
+	if article.PublishedAt != nil {
+		d.Article.PublishedAt = *article.PublishedAt
+	}
+	if article.Author != nil {
+		d.Article.Author = article.Author.Username
+	}
+}
+
+// SetCategory 设置分类数据
+func (d *TemplateData) SetCategory(category *model.Category, path []*model.Category) {
+	if category == nil {
+		return
+	}
+	d.Category = TemplateCategory{
+		ID:          category.ID,
+		Name:        category.Name,
+		Description: category.Description,
+		Slug:        category.Path,
+		URL:         d.Site.URL + CategoryPath(category),
+	}
+	for _, item := range path {
+		d.Category.Path = append(d.Category.Path, item.Name)
+	}
+}
+
+// SetCollection 设置合集数据
+func (d *TemplateData) SetCollection(collection *model.Collection) {
+	if collection == nil {
+		return
+	}
+	d.Collection = TemplateCollection{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		ItemsCount:  collection.ItemsCount,
+		URL:         fmt.Sprintf("%s/collection/%d", d.Site.URL, collection.ID),
+	}
+	if collection.User != nil {
+		d.Collection.Owner = collection.User.Username
+	}
+}
+
+// templateFuncs 白名单函数（call 被覆盖为禁用）
+var templateFuncs = template.FuncMap{
+	"truncate": truncateRunes,
+	"default":  defaultValue,
+	"lower":    strings.ToLower,
+	"join":     joinValues,
+	"date":     formatDate,
+	"call": func(...interface{}) (string, error) {
+		return "", errors.New("call 函数不可用")
+	},
+}
+
+// legacyPlaceholder 旧版 {{key}} 占位符
+var legacyPlaceholder = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*\}\}`)
+
+// TemplateEngine SEO模板引擎（解析结果按源码缓存）
+type TemplateEngine struct {
+	cache sync.Map // map[string]*template.Template
+}
+
+// NewTemplateEngine 创建SEO模板引擎
+func NewTemplateEngine() *TemplateEngine {
+	return &TemplateEngine{}
+}
+
+// Validate 校验模板（语法、沙箱限制，并使用示例数据试渲染以发现不存在的字段）
+func (e *TemplateEngine) Validate(source string) error {
+	if strings.TrimSpace(source) == "" {
+		return nil
+	}
+	tmpl, err := e.parse(source)
+	if err != nil {
+		return err
+	}
+	if _, err := execute(tmpl, sampleTemplateData()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return nil
+}
+
+// Render 渲染模板并按输出上下文转义
+func (e *TemplateEngine) Render(source string, data *TemplateData, outputCtx OutputContext) (string, error) {
+	if source == "" {
+		return "", nil
+	}
+	if data == nil {
+		data = NewTemplateData(nil)
+	}
+	tmpl, err := e.parse(source)
+	if err != nil {
+		return "", err
+	}
+	text, err := execute(tmpl, data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTemplateRender, err)
+	}
+	return Escape(text, outputCtx), nil
+}
+
+// parse 解析模板（带缓存）
+func (e *TemplateEngine) parse(source string) (*template.Template, error) {
+	if cached, ok := e.cache.Load(source); ok {
+		return cached.(*template.Template), nil
+	}
+	if len(source) > maxTemplateSize {
+		return nil, fmt.Errorf("%w: 模板长度不能超过%d个字符", ErrInvalidTemplate, maxTemplateSize)
+	}
+
+	tmpl, err := template.New("seo").Funcs(templateFuncs).Parse(upgradeLegacyPlaceholders(source))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if len(tmpl.Templates()) > 1 {
+		return nil, fmt.Errorf("%w: 不支持 define/block", ErrInvalidTemplate)
+	}
+	if err := checkSandbox(tmpl.Tree.Root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	e.cache.Store(source, tmpl)
+	return tmpl, nil
+}
+
+// upgradeLegacyPlaceholders 将旧版 {{key}} 写法转换为 {{index .Vars "key"}}
+func upgradeLegacyPlaceholders(source string) string {
+	return legacyPlaceholder.ReplaceAllStringFunc(source, func(placeholder string) string {
+		key := legacyPlaceholder.FindStringSubmatch(placeholder)[1]
+		if _, isFunc := templateFuncs[key]; isFunc {
+			return placeholder
+		}
+		switch key {
+		case "if", "else", "end", "range", "with", "break", "continue",
+			"define", "template", "block", "nil", "true", "false":
+			return placeholder
+		}
+		return fmt.Sprintf(`{{index .Vars %q}}`, key)
+	})
+}
+
+// checkSandbox 检查模板语法树是否只使用允许的结构
+func checkSandbox(node parse.Node) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkSandbox(child); err != nil {
+				return err
+			}
+		}
+	case *parse.TemplateNode:
+		return errors.New("不支持 template 指令")
+	case *parse.RangeNode:
+		// range 只能遍历列表字段（如 .Resource.Tags），不能遍历数字、变量或函数结果
+		if !rangesOverList(n.Pipe) {
+			return errors.New("range 只能用于遍历列表字段（如 .Resource.Tags）")
+		}
+		if err := checkSandbox(n.List); err != nil {
+			return err
+		}
+		return checkSandbox(n.ElseList)
+	case *parse.IfNode:
+		if err := checkSandbox(n.List); err != nil {
+			return err
+		}
+		return checkSandbox(n.ElseList)
+	case *parse.WithNode:
+		if err := checkSandbox(n.List); err != nil {
+			return err
+		}
+		return checkSandbox(n.ElseList)
+	}
+	return nil
+}
+
+// rangesOverList 判断 range 的对象是否为 TemplateData 中的列表字段
+func rangesOverList(pipe *parse.PipeNode) bool {
+	if len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok {
+		return false
+	}
+	typ := reflect.TypeOf(TemplateData{})
+	for _, ident := range field.Ident {
+		if typ.Kind() != reflect.Struct {
+			return false
+		}
+		f, found := typ.FieldByName(ident)
+		if !found || !f.IsExported() {
+			return false
+		}
+		typ = f.Type
+	}
+	return typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map
+}
+
+// execute 执行模板（限制输出长度，输出去除首尾空白）
+func execute(tmpl *template.Template, data *TemplateData) (string, error) {
+	var buf limitedBuffer
+	// 传入值而不是指针，模板中无法调用 TemplateData 的修改方法
+	if err := tmpl.Execute(&buf, *data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// limitedBuffer 超过最大长度时报错的缓冲区
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+// Write 写入数据
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxTemplateOutput {
+		return 0, fmt.Errorf("输出超过%d个字符", maxTemplateOutput)
+	}
+	return b.Buffer.Write(p)
+}
+
+// Escape 按输出上下文转义文本
+func Escape(text string, outputCtx OutputContext) string {
+	switch outputCtx {
+	case OutputHTML:
+		return html.EscapeString(text)
+	case OutputJSONLD:
+		// json.Marshal 会转义引号、控制字符以及 <、>、&
+		encoded, _ := json.Marshal(text)
+		return string(encoded[1 : len(encoded)-1])
+	default:
+		return text
+	}
+}
+
+// truncateRunes 按字符数截断（用法：{{.Resource.Title | truncate 60}}）
+func truncateRunes(length int, value interface{}) string {
+	text := toString(value)
+	if length <= 0 || utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:length-1])) + "…"
+}
+
+// defaultValue 值为空时使用默认值（用法：{{.Category.Name | default "资源"}}）
+func defaultValue(fallback string, value interface{}) string {
+	if isEmptyValue(value) {
+		return fallback
+	}
+	return toString(value)
+}
+
+// joinValues 连接列表（用法：{{.Resource.Tags | join ","}}）
+func joinValues(sep string, value interface{}) string {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(value)
+	}
+	items := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if item := toString(rv.Index(i).Interface()); item != "" {
+			items = append(items, item)
+		}
+	}
+	return strings.Join(items, sep)
+}
+
+// formatDate 格式化时间（用法：{{.Article.PublishedAt | date "2006-01-02"}}），零值输出空字符串
+func formatDate(layout string, value interface{}) string {
+	switch t := value.(type) {
+	case time.Time:
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	case *time.Time:
+		if t == nil || t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	default:
+		return toString(value)
+	}
+}
+
+// toString 转换为字符串
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// isEmptyValue 判断值是否为空（nil、零值、空字符串或空列表）
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if text, ok := value.(string); ok {
+		return strings.TrimSpace(text) == ""
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
+
+// sampleTemplateData 校验模板时使用的示例数据
+func sampleTemplateData() *TemplateData {
+	now := time.Now()
+	return &TemplateData{
+		Site:       TemplateSite{Name: "资源分享网站", URL: "https://example.com"},
+		Resource:   TemplateResource{ID: 1, Title: "示例资源", Slug: "shi-li-zi-yuan", Description: "示例描述", Category: "示例分类", Tags: []string{"示例"}, CreatedAt: now, UpdatedAt: now},
+		Article:    TemplateArticle{ID: 1, Title: "示例文章", Slug: "example", Tags: []string{"示例"}, PublishedAt: now, UpdatedAt: now},
+		Category:   TemplateCategory{ID: 1, Name: "示例分类", Path: []string{"示例分类"}, Slug: "shi-li-fen-lei"},
+		Collection: TemplateCollection{ID: 1, Name: "示例合集"},
+		Query:      "示例",
+		Page:       1,
+		Year:       now.Year(),
+		Vars:       map[string]string{},
+	}
+}
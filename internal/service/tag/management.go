@@ -0,0 +1,530 @@
+/*
+Package tag provides tag services shared by resources and articles.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package tag
+
+import (
+	"errors"
+	"fmt"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/seo"
+
+	"gorm.io/gorm"
+)
+
+// TagPage 标签页数据
+type TagPage struct {
+	Tag           *model.Tag        `json:"tag"`
+	Synonyms      []model.Tag       `json:"synonyms"`
+	Resources     []*model.Resource `json:"resources"`
+	ResourceTotal int64             `json:"resource_total"`
+	Articles      []model.Article   `json:"articles"`
+	ArticleTotal  int64             `json:"article_total"`
+}
+
+// ListTags 获取标签列表（不含同义词）
+// 参数：
+//   - keyword: 名称关键词（可选）
+//   - sort: 排序方式（popular/name/newest，默认 popular）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 标签列表
+//   - 总数
+//   - 错误信息
+func (s *TagService) ListTags(keyword, sort string, page, pageSize int) ([]model.Tag, int64, error) {
+	query := s.db.Model(&model.Tag{}).Where("canonical_id IS NULL")
+	if keyword != "" {
+		// 拼音为空时（如关键词只有符号）不按 slug 匹配，避免匹配全部标签
+		if slug := seo.Slugify(keyword, seo.TagSlugMaxLength); slug != "" {
+			query = query.Where("name LIKE ? OR slug LIKE ?", "%"+keyword+"%", "%"+slug+"%")
+		} else {
+			query = query.Where("name LIKE ?", "%"+keyword+"%")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询标签总数失败: %w", err)
+	}
+
+	switch sort {
+	case "name":
+		query = query.Order("slug ASC")
+	case "newest":
+		query = query.Order("created_at DESC, id DESC")
+	default:
+		query = query.Order("resource_count + article_count DESC, id ASC")
+	}
+
+	var tags []model.Tag
+	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&tags).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询标签列表失败: %w", err)
+	}
+	return tags, total, nil
+}
+
+// PopularTags 获取热门标签
+// 参数：
+//   - kind: resource 按资源使用次数、article 按文章使用次数，其余按总次数
+//   - limit: 数量
+//
+// 返回：
+//   - 标签列表
+//   - 错误信息
+func (s *TagService) PopularTags(kind string, limit int) ([]model.Tag, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	order := "resource_count + article_count"
+	switch kind {
+	case "resource":
+		order = "resource_count"
+	case "article":
+		order = "article_count"
+	}
+
+	var tags []model.Tag
+	if err := s.db.Where("canonical_id IS NULL AND " + order + " > 0").
+		Order(order + " DESC, id ASC").
+		Limit(limit).
+		Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("查询热门标签失败: %w", err)
+	}
+	return tags, nil
+}
+
+// GetTag 根据 slug 或标签名获取标签（同义词会返回其主标签）
+func (s *TagService) GetTag(slug string) (*model.Tag, error) {
+	var tag model.Tag
+	err := s.db.Where("slug = ?", slug).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.db.Where("name_key = ?", NormalizeName(slug)).First(&tag).Error
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	if tag.CanonicalID != nil {
+		return s.getTagByID(*tag.CanonicalID)
+	}
+	return &tag, nil
+}
+
+// GetTagPage 获取标签页（已通过审核的资源和已发布的文章）
+// 参数：
+//   - slug: 标签 slug（同义词会返回主标签的页面）
+//   - page: 页码
+//   - pageSize: 每页数量
+//
+// 返回：
+//   - 标签页数据
+//   - 错误信息
+func (s *TagService) GetTagPage(slug string, page, pageSize int) (*TagPage, error) {
+	tag, err := s.GetTag(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TagPage{Tag: tag}
+	if err := s.db.Where("canonical_id = ?", tag.ID).Order("slug ASC").Find(&result.Synonyms).Error; err != nil {
+		return nil, fmt.Errorf("查询同义词失败: %w", err)
+	}
+
+	resourceQuery := s.db.Model(&model.Resource{}).
+		Where("status = ?", model.ResourceStatusApproved).
+		Where("id IN (?)", s.db.Table(resourceTarget.joinTable).Select("resource_id").Where("tag_id = ?", tag.ID))
+	if err := resourceQuery.Count(&result.ResourceTotal).Error; err != nil {
+		return nil, fmt.Errorf("查询标签资源总数失败: %w", err)
+	}
+	if err := resourceQuery.Preload("Category").
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&result.Resources).Error; err != nil {
+		return nil, fmt.Errorf("查询标签资源失败: %w", err)
+	}
+
+	articleQuery := s.db.Model(&model.Article{}).
+		Where("status = ?", model.ArticleStatusPublished).
+		Where("id IN (?)", s.db.Table(articleTarget.joinTable).Select("article_id").Where("tag_id = ?", tag.ID))
+	if err := articleQuery.Count(&result.ArticleTotal).Error; err != nil {
+		return nil, fmt.Errorf("查询标签文章总数失败: %w", err)
+	}
+	if err := articleQuery.Preload("Author").
+		Order("published_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&result.Articles).Error; err != nil {
+		return nil, fmt.Errorf("查询标签文章失败: %w", err)
+	}
+
+	return result, nil
+}
+
+// CreateTag 创建标签
+func (s *TagService) CreateTag(name, description string) (*model.Tag, error) {
+	name = cleanName(name)
+	key := NormalizeName(name)
+	if key == "" {
+		return nil, ErrInvalidTagName
+	}
+
+	var count int64
+	if err := s.db.Model(&model.Tag{}).Where("name_key = ?", key).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrTagExists
+	}
+
+	slug, err := uniqueSlug(s.db, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	tag := &model.Tag{Name: name, NameKey: key, Slug: slug, Description: description}
+	if err := s.db.Create(tag).Error; err != nil {
+		return nil, fmt.Errorf("创建标签失败: %w", err)
+	}
+	return tag, nil
+}
+
+// UpdateTag 修改标签名称和描述（改名后同步资源和文章的标签字段，拼音变化时重新生成 slug）
+func (s *TagService) UpdateTag(id uint, name, description string) (*model.Tag, error) {
+	tag, err := s.getTagByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	name = cleanName(name)
+	key := NormalizeName(name)
+	if key == "" {
+		return nil, ErrInvalidTagName
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if key != tag.NameKey {
+			var count int64
+			if err := tx.Model(&model.Tag{}).Where("name_key = ? AND id != ?", key, id).Count(&count).Error; err != nil {
+				return fmt.Errorf("查询标签失败: %w", err)
+			}
+			if count > 0 {
+				return ErrTagExists
+			}
+		}
+		if seo.Slugify(name, seo.TagSlugMaxLength) != seo.Slugify(tag.Name, seo.TagSlugMaxLength) {
+			slug, err := uniqueSlug(tx, name, id)
+			if err != nil {
+				return err
+			}
+			tag.Slug = slug
+		}
+
+		renamed := name != tag.Name
+		tag.Name = name
+		tag.NameKey = key
+		tag.Description = description
+		if err := tx.Save(tag).Error; err != nil {
+			return fmt.Errorf("更新标签失败: %w", err)
+		}
+
+		if renamed {
+			for _, t := range s.targets() {
+				owners, err := s.owners(tx, t, id)
+				if err != nil {
+					return err
+				}
+				if err := s.syncOwners(tx, t, owners); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// DeleteTag 删除标签（同时删除其同义词和所有关联）
+func (s *TagService) DeleteTag(id uint) error {
+	if _, err := s.getTagByID(id); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range s.targets() {
+			owners, err := s.owners(tx, t, id)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM "+t.joinTable+" WHERE tag_id = ?", id).Error; err != nil {
+				return fmt.Errorf("删除标签关联失败: %w", err)
+			}
+			if err := s.syncOwners(tx, t, owners); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("canonical_id = ? OR id = ?", id, id).Delete(&model.Tag{}).Error; err != nil {
+			return fmt.Errorf("删除标签失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddSynonym 为标签添加同义词（同名标签已存在且有关联时合并到该标签）
+// 参数：
+//   - id: 主标签ID（传入同义词时使用其主标签）
+//   - name: 同义词
+//
+// 返回：
+//   - 同义词标签
+//   - 错误信息
+func (s *TagService) AddSynonym(id uint, name string) (*model.Tag, error) {
+	canonical, err := s.canonicalOf(id)
+	if err != nil {
+		return nil, err
+	}
+
+	name = cleanName(name)
+	key := NormalizeName(name)
+	if key == "" {
+		return nil, ErrInvalidTagName
+	}
+
+	var existing model.Tag
+	err = s.db.Where("name_key = ?", key).First(&existing).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		slug, err := uniqueSlug(s.db, name, 0)
+		if err != nil {
+			return nil, err
+		}
+		synonym := &model.Tag{Name: name, NameKey: key, Slug: slug, CanonicalID: &canonical.ID}
+		if err := s.db.Create(synonym).Error; err != nil {
+			return nil, fmt.Errorf("创建同义词失败: %w", err)
+		}
+		return synonym, nil
+	case err != nil:
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	case existing.ID == canonical.ID:
+		return nil, ErrSameTag
+	case existing.CanonicalID != nil && *existing.CanonicalID == canonical.ID:
+		return &existing, nil
+	}
+
+	if err := s.MergeTags(existing.ID, canonical.ID); err != nil {
+		return nil, err
+	}
+	return s.getTagByID(existing.ID)
+}
+
+// MergeTags 将标签合并到另一个标签（原标签变为目标标签的同义词，关联转移到目标标签）
+// 参数：
+//   - sourceID: 被合并的标签ID
+//   - targetID: 目标标签ID（传入同义词时使用其主标签）
+//
+// 返回：
+//   - 错误信息
+func (s *TagService) MergeTags(sourceID, targetID uint) error {
+	source, err := s.getTagByID(sourceID)
+	if err != nil {
+		return err
+	}
+	dest, err := s.canonicalOf(targetID)
+	if err != nil {
+		return err
+	}
+	if source.ID == dest.ID {
+		return ErrSameTag
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, t := range s.targets() {
+			owners, err := s.owners(tx, t, source.ID)
+			if err != nil {
+				return err
+			}
+
+			// 已关联目标标签的对象不重复关联
+			insert := fmt.Sprintf(`INSERT INTO %[1]s (%[2]s, tag_id, created_at)
+				SELECT %[2]s, ?, created_at FROM %[1]s
+				WHERE tag_id = ? AND %[2]s NOT IN (SELECT %[2]s FROM %[1]s WHERE tag_id = ?)`, t.joinTable, t.ownerColumn)
+			if err := tx.Exec(insert, dest.ID, source.ID, dest.ID).Error; err != nil {
+				return fmt.Errorf("转移标签关联失败: %w", err)
+			}
+			if err := tx.Exec("DELETE FROM "+t.joinTable+" WHERE tag_id = ?", source.ID).Error; err != nil {
+				return fmt.Errorf("删除标签关联失败: %w", err)
+			}
+
+			if err := s.recount(tx, t, []uint{source.ID, dest.ID}); err != nil {
+				return err
+			}
+			if err := s.syncOwners(tx, t, owners); err != nil {
+				return err
+			}
+		}
+
+		// 原标签及其同义词都指向目标标签
+		if err := tx.Model(&model.Tag{}).Where("canonical_id = ?", source.ID).Update("canonical_id", dest.ID).Error; err != nil {
+			return fmt.Errorf("转移同义词失败: %w", err)
+		}
+		if err := tx.Model(source).Updates(map[string]interface{}{"canonical_id": dest.ID}).Error; err != nil {
+			return fmt.Errorf("更新标签失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// RecountAll 重新统计所有标签的使用次数
+func (s *TagService) RecountAll() error {
+	var ids []uint
+	if err := s.db.Model(&model.Tag{}).Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("查询标签失败: %w", err)
+	}
+	for _, t := range s.targets() {
+		if err := s.recount(s.db, t, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportLegacyTags 将资源和文章原有的标签字符串导入标签表（已有关联的对象跳过，可重复执行）
+// 返回：
+//   - 各对象表导入的数量（如 resources、articles）
+//   - 错误信息
+func (s *TagService) ImportLegacyTags() (map[string]int, error) {
+	imported := make(map[string]int)
+	for _, t := range s.targets() {
+		count, err := s.importLegacy(t)
+		imported[t.ownerTable] = count
+		if err != nil {
+			return imported, err
+		}
+	}
+	return imported, nil
+}
+
+// importLegacy 导入一类对象的标签字符串（已删除的对象不导入）
+func (s *TagService) importLegacy(t target) (int, error) {
+	var rows []struct {
+		ID   uint
+		Tags string
+	}
+	if err := s.db.Table(t.ownerTable).
+		Select("id, tags").
+		Where("deleted_at IS NULL").
+		Where("tags IS NOT NULL AND tags != ''").
+		Where("NOT EXISTS (SELECT 1 FROM " + t.joinTable + " jt WHERE jt." + t.ownerColumn + " = " + t.ownerTable + ".id)").
+		Find(&rows).Error; err != nil {
+		return 0, fmt.Errorf("查询历史标签失败: %w", err)
+	}
+
+	imported := 0
+	for _, row := range rows {
+		names := ParseTags(row.Tags)
+		if len(names) == 0 {
+			continue
+		}
+		if len(names) > MaxTagsPerItem {
+			names = names[:MaxTagsPerItem]
+		}
+		if _, _, err := s.setTags(t, row.ID, names); err != nil {
+			return imported, fmt.Errorf("导入标签失败(%s #%d): %w", t.ownerTable, row.ID, err)
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// BackfillKeys 为尚未生成规范化名称的标签补全名称标识，并为 slug 为空的标签生成 slug（已补全的标签跳过，可重复执行）
+// 不会自动合并标签：规范化名称已被其他标签使用时保持为空并计入冲突数量，由管理员在后台确认后合并
+// 返回：
+//   - 补全的标签数量
+//   - 规范化名称冲突的标签数量
+//   - 错误信息
+func (s *TagService) BackfillKeys() (int, int, error) {
+	var tags []model.Tag
+	if err := s.db.Where("name_key IS NULL OR name_key = '' OR slug = ''").Order("id ASC").Find(&tags).Error; err != nil {
+		return 0, 0, fmt.Errorf("查询标签失败: %w", err)
+	}
+
+	updated, conflicts := 0, 0
+	for _, tag := range tags {
+		updates := make(map[string]interface{})
+		if tag.Slug == "" {
+			slug, err := uniqueSlug(s.db, tag.Name, tag.ID)
+			if err != nil {
+				return updated, conflicts, err
+			}
+			updates["slug"] = slug
+		}
+		if key := NormalizeName(tag.Name); tag.NameKey == "" && key != "" {
+			var count int64
+			if err := s.db.Model(&model.Tag{}).Where("name_key = ? AND id <> ?", key, tag.ID).Count(&count).Error; err != nil {
+				return updated, conflicts, fmt.Errorf("查询标签失败: %w", err)
+			}
+			if count > 0 {
+				conflicts++
+			} else {
+				updates["name_key"] = key
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		if err := s.db.Model(&model.Tag{}).Where("id = ?", tag.ID).Updates(updates).Error; err != nil {
+			return updated, conflicts, fmt.Errorf("更新标签失败(#%d): %w", tag.ID, err)
+		}
+		updated++
+	}
+	return updated, conflicts, nil
+}
+
+// targets 返回已建表的关联对象类型（部分部署没有文章表）
+func (s *TagService) targets() []target {
+	targets := make([]target, 0, 2)
+	for _, t := range []target{resourceTarget, articleTarget} {
+		if s.db.Migrator().HasTable(t.ownerTable) {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// getTagByID 根据ID获取标签
+func (s *TagService) getTagByID(id uint) (*model.Tag, error) {
+	var tag model.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	return &tag, nil
+}
+
+// canonicalOf 获取标签的主标签（本身为主标签时返回自身）
+func (s *TagService) canonicalOf(id uint) (*model.Tag, error) {
+	tag, err := s.getTagByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if tag.CanonicalID != nil {
+		return s.getTagByID(*tag.CanonicalID)
+	}
+	return tag, nil
+}
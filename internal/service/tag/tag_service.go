@@ -0,0 +1,490 @@
+/*
+Package tag provides tag services shared by resources and articles.
+
+Author: Felix Wang
+Email: felixwang.biz@gmail.com
+*/
+
+package tag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"resource-share-site/internal/model"
+	"resource-share-site/internal/service/seo"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors 定义自定义错误
+var (
+	ErrTagNotFound    = errors.New("标签不存在")
+	ErrInvalidTagName = errors.New("标签名无效")
+	ErrTagExists      = errors.New("标签已存在")
+	ErrSameTag        = errors.New("不能与自身合并")
+	ErrTooManyTags    = errors.New("标签数量超过限制")
+)
+
+const (
+	// MaxTagsPerItem 每个资源或文章最多关联的标签数
+	MaxTagsPerItem = 10
+	// maxNameLength 标签名最大长度（字符）
+	maxNameLength = 50
+	// maxArticleTagsLength 文章标签字符串的最大长度（与 articles.tags 字段一致）
+	maxArticleTagsLength = 200
+)
+
+// MatchMode 多标签筛选方式
+type MatchMode string
+
+const (
+	MatchAny MatchMode = "or"  // 包含任意一个标签
+	MatchAll MatchMode = "and" // 包含全部标签
+)
+
+// ParseMatchMode 解析筛选方式（and/all 为全部匹配，其余为任意匹配）
+func ParseMatchMode(mode string) MatchMode {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "and", "all":
+		return MatchAll
+	default:
+		return MatchAny
+	}
+}
+
+// Filter 按标签筛选的条件
+type Filter struct {
+	Tags []string  // 标签名或 slug
+	Mode MatchMode // 筛选方式
+}
+
+// NewFilter 根据查询参数创建筛选条件（没有标签时返回nil）
+func NewFilter(tags, mode string) *Filter {
+	names := ParseTags(tags)
+	if len(names) == 0 {
+		return nil
+	}
+	return &Filter{Tags: names, Mode: ParseMatchMode(mode)}
+}
+
+// target 标签关联的对象类型
+type target struct {
+	joinTable   string                // 关联表
+	ownerColumn string                // 关联表中对象ID列
+	ownerTable  string                // 对象表
+	countColumn string                // tags 表中的使用次数列
+	format      func([]string) string // 同步到对象 tags 字段的格式
+}
+
+var (
+	resourceTarget = target{
+		joinTable:   "resource_tags",
+		ownerColumn: "resource_id",
+		ownerTable:  "resources",
+		countColumn: "resource_count",
+		format:      formatJSON,
+	}
+	articleTarget = target{
+		joinTable:   "article_tags",
+		ownerColumn: "article_id",
+		ownerTable:  "articles",
+		countColumn: "article_count",
+		format:      formatComma,
+	}
+)
+
+// TagService 标签服务
+type TagService struct {
+	db *gorm.DB
+}
+
+// NewTagService 创建标签服务
+func NewTagService(db *gorm.DB) *TagService {
+	return &TagService{
+		db: db,
+	}
+}
+
+// ParseTags 解析标签字符串（支持 JSON 数组，以及逗号、顿号、分号、竖线分隔）
+func ParseTags(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	var parts []string
+	if strings.HasPrefix(raw, "[") {
+		if err := json.Unmarshal([]byte(raw), &parts); err != nil {
+			parts = nil
+			raw = strings.Trim(raw, "[]")
+		}
+	}
+	if parts == nil {
+		parts = strings.FieldsFunc(raw, func(r rune) bool {
+			switch r {
+			case ',', '，', '、', ';', '；', '|':
+				return true
+			}
+			return false
+		})
+	}
+
+	names := make([]string, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		name := cleanName(strings.Trim(strings.TrimSpace(part), `"'`))
+		key := NormalizeName(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// NormalizeName 生成规范化的标签名，用于识别同一标签（全角转半角、小写，保留各语言文字、数字和 + # .，其余字符转为-）
+// 与 URL slug 不同，不做拼音转换，C、C++、C# 以及同音的中文标签不会被视为同一标签
+func NormalizeName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r == '　':
+			r = ' '
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		}
+		r = unicode.ToLower(r)
+
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '+' || r == '#' || r == '.' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// cleanName 整理标签名（合并空白并限制长度）
+func cleanName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = strings.TrimSpace(string(runes[:maxNameLength]))
+	}
+	return name
+}
+
+// ==================== 标签关联 ====================
+
+// SetResourceTags 设置资源的标签（同义词归并到主标签，不存在的标签自动创建）
+// 参数：
+//   - resourceID: 资源ID
+//   - names: 标签名列表
+//
+// 返回：
+//   - 关联后的标签列表
+//   - 同步到 resources.tags 的标签字符串
+//   - 错误信息
+func (s *TagService) SetResourceTags(resourceID uint, names []string) ([]model.Tag, string, error) {
+	return s.setTags(resourceTarget, resourceID, names)
+}
+
+// SetArticleTags 设置文章的标签（同义词归并到主标签，不存在的标签自动创建）
+// 参数：
+//   - articleID: 文章ID
+//   - names: 标签名列表
+//
+// 返回：
+//   - 关联后的标签列表
+//   - 同步到 articles.tags 的标签字符串
+//   - 错误信息
+func (s *TagService) SetArticleTags(articleID uint, names []string) ([]model.Tag, string, error) {
+	return s.setTags(articleTarget, articleID, names)
+}
+
+// GetResourceTags 获取资源的标签
+func (s *TagService) GetResourceTags(resourceID uint) ([]model.Tag, error) {
+	return s.ownerTags(s.db, resourceTarget, resourceID)
+}
+
+// GetArticleTags 获取文章的标签
+func (s *TagService) GetArticleTags(articleID uint) ([]model.Tag, error) {
+	return s.ownerTags(s.db, articleTarget, articleID)
+}
+
+// FilterResources 为资源查询添加标签筛选条件
+// 参数：
+//   - query: 资源查询
+//   - column: 查询中资源ID的列名（如 id 或 resources.id）
+//   - filter: 筛选条件（为nil时不筛选）
+//
+// 返回：
+//   - 添加条件后的查询
+//   - 错误信息
+func (s *TagService) FilterResources(query *gorm.DB, column string, filter *Filter) (*gorm.DB, error) {
+	return s.applyFilter(query, resourceTarget, column, filter)
+}
+
+// FilterArticles 为文章查询添加标签筛选条件
+// 参数：
+//   - query: 文章查询
+//   - column: 查询中文章ID的列名（如 id 或 articles.id）
+//   - filter: 筛选条件（为nil时不筛选）
+//
+// 返回：
+//   - 添加条件后的查询
+//   - 错误信息
+func (s *TagService) FilterArticles(query *gorm.DB, column string, filter *Filter) (*gorm.DB, error) {
+	return s.applyFilter(query, articleTarget, column, filter)
+}
+
+// setTags 在事务中替换对象的标签关联并更新使用次数
+func (s *TagService) setTags(t target, ownerID uint, names []string) ([]model.Tag, string, error) {
+	var tags []model.Tag
+	var formatted string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tags, err = s.resolve(tx, names, true)
+		if err != nil {
+			return err
+		}
+		if len(tags) > MaxTagsPerItem {
+			return ErrTooManyTags
+		}
+
+		var oldIDs []uint
+		if err := tx.Table(t.joinTable).Where(t.ownerColumn+" = ?", ownerID).Pluck("tag_id", &oldIDs).Error; err != nil {
+			return fmt.Errorf("查询原有标签失败: %w", err)
+		}
+		if err := tx.Exec("DELETE FROM "+t.joinTable+" WHERE "+t.ownerColumn+" = ?", ownerID).Error; err != nil {
+			return fmt.Errorf("删除原有标签失败: %w", err)
+		}
+
+		now := time.Now()
+		rows := make([]map[string]interface{}, 0, len(tags))
+		tagNames := make([]string, 0, len(tags))
+		affected := append([]uint{}, oldIDs...)
+		for _, tag := range tags {
+			rows = append(rows, map[string]interface{}{
+				t.ownerColumn: ownerID,
+				"tag_id":      tag.ID,
+				"created_at":  now,
+			})
+			tagNames = append(tagNames, tag.Name)
+			affected = append(affected, tag.ID)
+		}
+		if len(rows) > 0 {
+			if err := tx.Table(t.joinTable).Create(&rows).Error; err != nil {
+				return fmt.Errorf("保存标签关联失败: %w", err)
+			}
+		}
+
+		if err := s.recount(tx, t, affected); err != nil {
+			return err
+		}
+
+		formatted = t.format(tagNames)
+		if err := tx.Table(t.ownerTable).Where("id = ?", ownerID).UpdateColumn("tags", formatted).Error; err != nil {
+			return fmt.Errorf("同步标签字段失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return tags, formatted, nil
+}
+
+// resolve 将标签名解析为主标签（去重，create 为 true 时自动创建不存在的标签）
+// 按规范化标签名匹配；只查询不创建时（如筛选条件）也接受标签页地址中的 slug
+func (s *TagService) resolve(tx *gorm.DB, names []string, create bool) ([]model.Tag, error) {
+	tags := make([]model.Tag, 0, len(names))
+	seen := make(map[uint]bool, len(names))
+	for _, name := range names {
+		name = cleanName(name)
+		key := NormalizeName(name)
+		if key == "" {
+			continue
+		}
+
+		var tag model.Tag
+		err := tx.Where("name_key = ?", key).First(&tag).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if !create {
+				if err = tx.Where("slug = ?", name).First(&tag).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+					continue
+				}
+			} else {
+				tag = model.Tag{Name: name, NameKey: key}
+				if tag.Slug, err = uniqueSlug(tx, name, 0); err != nil {
+					return nil, err
+				}
+				if err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+					return nil, fmt.Errorf("创建标签失败: %w", err)
+				}
+				// 并发创建同名标签时使用已存在的标签
+				if tag.ID == 0 {
+					err = tx.Where("name_key = ?", key).First(&tag).Error
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询标签失败: %w", err)
+		}
+
+		if tag.CanonicalID != nil {
+			var canonical model.Tag
+			if err := tx.First(&canonical, *tag.CanonicalID).Error; err != nil {
+				return nil, fmt.Errorf("查询主标签失败: %w", err)
+			}
+			tag = canonical
+		}
+		if seen[tag.ID] {
+			continue
+		}
+		seen[tag.ID] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// uniqueSlug 生成唯一的标签页 slug（汉字转拼音，重名时追加 -2、-3 …，名称无法转换时使用 tag）
+func uniqueSlug(tx *gorm.DB, name string, tagID uint) (string, error) {
+	base := seo.Slugify(name, seo.TagSlugMaxLength)
+	if base == "" {
+		base = "tag"
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Model(&model.Tag{}).Where("slug = ? AND id <> ?", slug, tagID).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("检查标签slug失败: %w", err)
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// ownerTags 获取对象关联的标签
+func (s *TagService) ownerTags(tx *gorm.DB, t target, ownerID uint) ([]model.Tag, error) {
+	var tags []model.Tag
+	if err := tx.Table("tags").
+		Select("tags.*").
+		Joins("JOIN "+t.joinTable+" jt ON jt.tag_id = tags.id").
+		Where("jt."+t.ownerColumn+" = ?", ownerID).
+		Order("jt.created_at ASC, tags.id ASC").
+		Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	return tags, nil
+}
+
+// applyFilter 添加标签筛选条件（全部匹配时任一标签不存在则没有结果）
+func (s *TagService) applyFilter(query *gorm.DB, t target, column string, filter *Filter) (*gorm.DB, error) {
+	if filter == nil || len(filter.Tags) == 0 {
+		return query, nil
+	}
+
+	tags, err := s.resolve(s.db, filter.Tags, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 || (filter.Mode == MatchAll && len(tags) < len(filter.Tags)) {
+		return query.Where("1 = 0"), nil
+	}
+
+	ids := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+
+	sub := s.db.Table(t.joinTable).Select(t.ownerColumn).Where("tag_id IN ?", ids)
+	if filter.Mode == MatchAll {
+		sub = sub.Group(t.ownerColumn).Having("COUNT(DISTINCT tag_id) = ?", len(ids))
+	}
+	return query.Where(column+" IN (?)", sub), nil
+}
+
+// recount 重新统计标签的使用次数（不计已删除的对象）
+func (s *TagService) recount(tx *gorm.DB, t target, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	sql := fmt.Sprintf(`UPDATE tags SET %s = (
+		SELECT COUNT(*) FROM %s jt JOIN %s o ON o.id = jt.%s
+		WHERE jt.tag_id = tags.id AND o.deleted_at IS NULL
+	) WHERE id IN ?`, t.countColumn, t.joinTable, t.ownerTable, t.ownerColumn)
+	if err := tx.Exec(sql, tagIDs).Error; err != nil {
+		return fmt.Errorf("统计标签使用次数失败: %w", err)
+	}
+	return nil
+}
+
+// syncOwners 根据关联重新生成对象的标签字段（标签改名、合并或删除后调用）
+func (s *TagService) syncOwners(tx *gorm.DB, t target, ownerIDs []uint) error {
+	for _, ownerID := range ownerIDs {
+		tags, err := s.ownerTags(tx, t, ownerID)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		if err := tx.Table(t.ownerTable).Where("id = ?", ownerID).UpdateColumn("tags", t.format(names)).Error; err != nil {
+			return fmt.Errorf("同步标签字段失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// owners 获取关联了指定标签的对象ID
+func (s *TagService) owners(tx *gorm.DB, t target, tagID uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Table(t.joinTable).Where("tag_id = ?", tagID).Pluck(t.ownerColumn, &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询标签关联失败: %w", err)
+	}
+	return ids, nil
+}
+
+// formatJSON 资源标签字段格式（JSON 数组）
+func formatJSON(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(names)
+	return string(data)
+}
+
+// formatComma 文章标签字段格式（逗号分隔，超出字段长度的标签不写入）
+func formatComma(names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		length := len([]rune(b.String())) + len([]rune(name))
+		if b.Len() > 0 {
+			length++
+		}
+		if length > maxArticleTagsLength {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+	}
+	return b.String()
+}
@@ -13,17 +13,19 @@ import (
 
 // JWTClaims JWT声明
 type JWTClaims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID       uint   `json:"user_id"`
+	Username     string `json:"username"`
+	TokenVersion uint   `json:"token_version"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(userID uint, username string) (string, error) {
+// GenerateToken 生成JWT token（tokenVersion 与用户当前令牌版本一致时才有效）
+func GenerateToken(userID uint, username string, tokenVersion uint) (string, error) {
 	// 创建token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
-		UserID:   userID,
-		Username: username,
+		UserID:       userID,
+		Username:     username,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24小时过期
 			NotBefore: jwt.NewNumericDate(time.Now()),